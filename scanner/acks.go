@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Findings that can be acknowledged, the same names are used by the collector
+const (
+	FindingOracleJDK     = "oracle_jdk"
+	FindingExecFailed    = "exec_failed"
+	FindingArchMismatch  = "arch_mismatch"
+	FindingDefaultJava   = "default_runtime"
+	FindingJavaEnvInject = "java_env_injection"
+)
+
+var findingNames = []string{FindingOracleJDK, FindingExecFailed, FindingArchMismatch, FindingDefaultJava, FindingJavaEnvInject}
+
+// Acknowledgment marks a finding as known and accepted, e.g. a licensed Oracle
+// JDK, so that it no longer alerts. Host and Path are globs; an empty Finding,
+// Host or Path matches any.
+type Acknowledgment struct {
+	ID      string `json:"id,omitempty"`
+	Finding string `json:"finding,omitempty"`
+	Host    string `json:"host,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Note    string `json:"note"`
+	Ticket  string `json:"ticket,omitempty"`
+
+	// Expires is the day (YYYY-MM-DD) from which the acknowledgment no longer applies
+	Expires string `json:"expires,omitempty"`
+}
+
+// loadAcknowledgments reads acknowledgments from a JSON file or, for an http(s)
+// URL, from the collector, and returns those that apply to host at time now
+func loadAcknowledgments(source, host string, now time.Time) ([]Acknowledgment, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		data, err = fetchAcknowledgments(source, host)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load acknowledgments from %s: %v", source, err)
+	}
+	var acks []Acknowledgment
+	if err := json.Unmarshal(data, &acks); err != nil {
+		return nil, fmt.Errorf("failed to parse acknowledgments from %s: %v", source, err)
+	}
+
+	var applicable []Acknowledgment
+	for i, ack := range acks {
+		if err := ack.validate(); err != nil {
+			return nil, fmt.Errorf("invalid acknowledgment %d in %s: %v", i+1, source, err)
+		}
+		if ack.appliesTo(host, now) {
+			applicable = append(applicable, ack)
+		}
+	}
+	return applicable, nil
+}
+
+// fetchAcknowledgments gets the acknowledgments for host from the collector
+func fetchAcknowledgments(source, host string) ([]byte, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	query.Set("computer_name", host)
+	u.RawQuery = query.Encode()
+
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+	return body, nil
+}
+
+// validate checks the fields of an acknowledgment
+func (a *Acknowledgment) validate() error {
+	if strings.TrimSpace(a.Note) == "" {
+		return fmt.Errorf("note is required")
+	}
+	if a.Finding != "" && !slices.Contains(findingNames, a.Finding) {
+		return fmt.Errorf("unknown finding %q (use %s)", a.Finding, strings.Join(findingNames, ", "))
+	}
+	if _, err := path.Match(strings.ToLower(a.Host), ""); err != nil {
+		return fmt.Errorf("invalid host pattern %q", a.Host)
+	}
+	if a.Expires != "" {
+		if _, err := time.Parse(time.DateOnly, a.Expires); err != nil {
+			return fmt.Errorf("invalid expiry %q (use YYYY-MM-DD)", a.Expires)
+		}
+	}
+	return nil
+}
+
+// appliesTo checks if the acknowledgment covers host and has not expired
+func (a *Acknowledgment) appliesTo(host string, now time.Time) bool {
+	if a.Expires != "" {
+		expires, _ := time.ParseInLocation(time.DateOnly, a.Expires, now.Location())
+		if !now.Before(expires) {
+			return false
+		}
+	}
+	if a.Host == "" {
+		return true
+	}
+	matched, _ := path.Match(strings.ToLower(a.Host), strings.ToLower(host))
+	return matched
+}
+
+// covers checks if the acknowledgment covers a finding at a path, an empty path
+// for findings of the host rather than of a runtime
+func (a *Acknowledgment) covers(finding, javaPath string) bool {
+	if a.Finding != "" && a.Finding != finding {
+		return false
+	}
+	return a.Path == "" || (javaPath != "" && matchGlob(expandPattern(a.Path), filepath.ToSlash(javaPath)))
+}
+
+// String describes the acknowledgment for text output
+func (a *Acknowledgment) String() string {
+	if a.Ticket == "" {
+		return a.Note
+	}
+	return a.Ticket + ": " + a.Note
+}
+
+// findAcknowledgment returns the first acknowledgment covering a finding, nil if there is none
+func findAcknowledgment(acks []Acknowledgment, finding, javaPath string) *Acknowledgment {
+	for i := range acks {
+		if acks[i].covers(finding, javaPath) {
+			return &acks[i]
+		}
+	}
+	return nil
+}
+
+// runtimeFindings returns the findings of a runtime that can be acknowledged
+func runtimeFindings(rt *JavaRuntimeJSON) []string {
+	var findings []string
+	if rt.IsOracle || (rt.RequireLicense != nil && *rt.RequireLicense) {
+		findings = append(findings, FindingOracleJDK)
+	}
+	if rt.ExecFailed {
+		findings = append(findings, FindingExecFailed)
+	}
+	if rt.ArchMismatch {
+		findings = append(findings, FindingArchMismatch)
+	}
+	return findings
+}
+
+// applyAcknowledgments attaches to every runtime the acknowledgments covering its findings
+func applyAcknowledgments(output *JSONOutput, acks []Acknowledgment) {
+	if len(acks) == 0 {
+		return
+	}
+	output.Acknowledgments = acks
+	for i := range output.Runtimes {
+		rt := &output.Runtimes[i]
+		for _, finding := range runtimeFindings(rt) {
+			if ack := findAcknowledgment(acks, finding, rt.JavaExecutable); ack != nil && !slices.Contains(rt.Acknowledgments, *ack) {
+				rt.Acknowledgments = append(rt.Acknowledgments, *ack)
+			}
+		}
+	}
+}
+
+// dropAcknowledged removes the violations covered by an acknowledgment of the report
+func dropAcknowledged(violations []Violation, output *JSONOutput) []Violation {
+	return slices.DeleteFunc(violations, func(v Violation) bool {
+		return findAcknowledgment(output.Acknowledgments, v.Finding, v.Path) != nil
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const testAcks = `[
+  {"finding": "oracle_jdk", "host": "build-*", "path": "/opt/oracle/**", "note": "licensed", "ticket": "LIC-123"},
+  {"finding": "oracle_jdk", "host": "db-01", "note": "other host"},
+  {"finding": "exec_failed", "note": "expired", "expires": "2024-01-01"},
+  {"finding": "default_runtime", "note": "JAVA_HOME is set per job"}
+]`
+
+func TestLoadAcknowledgments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acks.json")
+	writeTestFile(t, path, testAcks)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	acks, err := loadAcknowledgments(path, "BUILD-07", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acks) != 2 || acks[0].Ticket != "LIC-123" || acks[1].Finding != FindingDefaultJava {
+		t.Errorf("Expected the acknowledgments of the host that have not expired, got %+v", acks)
+	}
+
+	for _, invalid := range []string{
+		`[{"finding": "oracle_jdk"}]`,
+		`[{"finding": "oracle", "note": "x"}]`,
+		`[{"note": "x", "expires": "March"}]`,
+		`[{"note": "x", "host": "["}]`,
+	} {
+		writeTestFile(t, path, invalid)
+		if _, err := loadAcknowledgments(path, "build-07", now); err == nil {
+			t.Errorf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestFetchAcknowledgments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/jfind/acknowledgments" || r.URL.Query().Get("computer_name") != "build-07" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testAcks))
+	}))
+	defer server.Close()
+
+	acks, err := loadAcknowledgments(server.URL+"/api/jfind/acknowledgments", "build-07", time.Now())
+	if err != nil || len(acks) != 2 {
+		t.Errorf("Unexpected acknowledgments %+v, %v", acks, err)
+	}
+	if _, err := loadAcknowledgments(server.URL+"/missing", "build-07", time.Now()); err == nil {
+		t.Error("Expected an error for a missing URL")
+	}
+}
+
+func TestApplyAcknowledgments(t *testing.T) {
+	licensed := true
+	output := JSONOutput{
+		DefaultRuntime: &DefaultRuntime{PathJava: "/usr/bin/java", Warnings: []string{"PATH and JAVA_HOME disagree"}},
+		Runtimes: []JavaRuntimeJSON{
+			{JavaExecutable: "/opt/oracle/jdk-17/bin/java", IsOracle: true, RequireLicense: &licensed, JavaVendor: "Oracle Corporation"},
+			{JavaExecutable: "/usr/lib/jvm/oracle-8/bin/java", IsOracle: true, JavaVendor: "Oracle Corporation"},
+			{JavaExecutable: "/opt/temurin/bin/java"},
+		},
+	}
+	acks := []Acknowledgment{
+		{Finding: FindingOracleJDK, Path: "/opt/oracle/**", Note: "licensed", Ticket: "LIC-123"},
+		{Finding: FindingDefaultJava, Note: "JAVA_HOME is set per job"},
+	}
+	applyAcknowledgments(&output, acks)
+
+	if len(output.Acknowledgments) != 2 {
+		t.Errorf("Expected the acknowledgments in the report, got %+v", output.Acknowledgments)
+	}
+	if got := output.Runtimes[0].Acknowledgments; len(got) != 1 || got[0].Ticket != "LIC-123" {
+		t.Errorf("Expected the license acknowledgment on the runtime, got %+v", got)
+	}
+	if got := output.Runtimes[1].Acknowledgments; len(got) != 0 {
+		t.Errorf("Expected no acknowledgment outside the path, got %+v", got)
+	}
+
+	violations := findViolations(&output)
+	if len(violations) != 1 || violations[0].Path != "/usr/lib/jvm/oracle-8/bin/java" || violations[0].Finding != FindingOracleJDK {
+		t.Errorf("Expected only the unacknowledged Oracle JDK, got %+v", violations)
+	}
+}
+
+// TestAcknowledgmentGlobSpec runs the glob cases the collector matches acknowledgments
+// with as well, see tests/test_acknowledgments.py
+func TestAcknowledgmentGlobSpec(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "glob.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		Paths []struct {
+			Pattern, Name string
+			Match         bool
+			Windows       bool
+		}
+		Hosts []struct {
+			Pattern, Host string
+			Match         bool
+		}
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range spec.Paths {
+		// Paths are matched as on the system the scanner runs on
+		if c.Windows != (runtime.GOOS == "windows") {
+			continue
+		}
+		ack := Acknowledgment{Path: c.Pattern, Note: "spec"}
+		if got := ack.covers(FindingOracleJDK, c.Name); got != c.Match {
+			t.Errorf("Expected %q to match %q: %v, got %v", c.Pattern, c.Name, c.Match, got)
+		}
+	}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, c := range spec.Hosts {
+		ack := Acknowledgment{Host: c.Pattern, Note: "spec"}
+		if got := ack.appliesTo(c.Host, now); got != c.Match {
+			t.Errorf("Expected host %q to match %q: %v, got %v", c.Pattern, c.Host, c.Match, got)
+		}
+	}
+}
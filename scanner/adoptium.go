@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AdoptiumInfo is the enrichment of the adoptium enricher
+type AdoptiumInfo struct {
+	LatestVersion string `json:"latest_version"`
+	UpToDate      bool   `json:"up_to_date"`
+}
+
+// adoptiumEnricher looks up the newest Eclipse Temurin release of the major
+// version of a runtime in the Adoptium API, through the cache and rate limit
+// of vendor_api
+type adoptiumEnricher struct {
+	api     *vendorAPI
+	baseURL string
+}
+
+// adoptiumRelease is the part of a release of the Adoptium assets API jfind uses
+type adoptiumRelease struct {
+	ReleaseName string `json:"release_name"`
+	Version     struct {
+		Major          int    `json:"major"`
+		Security       int    `json:"security"`
+		OpenJDKVersion string `json:"openjdk_version"`
+	} `json:"version"`
+}
+
+// newAdoptiumEnricher creates the adoptium enricher of the configuration
+func newAdoptiumEnricher(cfg *Config) (Enricher, error) {
+	baseURL := defaultAdoptiumURL
+	if cfg.VendorAPI != nil && cfg.VendorAPI.AdoptiumURL != "" {
+		baseURL = strings.TrimSuffix(cfg.VendorAPI.AdoptiumURL, "/")
+	}
+	return &adoptiumEnricher{api: cfg.vendorAPI(), baseURL: baseURL}, nil
+}
+
+// Name returns the name of the enricher
+func (a *adoptiumEnricher) Name() string {
+	return "adoptium"
+}
+
+// Enrich compares the update of the runtime with the newest Temurin release of
+// its major version. Major versions Adoptium has no release of are skipped.
+func (a *adoptiumEnricher) Enrich(result *JavaResult) (any, error) {
+	version, _ := runtimeVersion(result)
+	major, update := parseJavaVersion(version)
+	if major == 0 {
+		return nil, nil
+	}
+	latest, err := a.latest(major)
+	if err != nil || latest == nil {
+		return nil, err
+	}
+	return &AdoptiumInfo{LatestVersion: latest.Version.OpenJDKVersion, UpToDate: update >= latest.Version.Security}, nil
+}
+
+// latest returns the newest release of a major version, nil if there is none
+func (a *adoptiumEnricher) latest(major int) (*adoptiumRelease, error) {
+	body, err := a.api.get(fmt.Sprintf("%s/v3/assets/latest/%d/hotspot?image_type=jdk", a.baseURL, major))
+	if err != nil {
+		return nil, err
+	}
+	var releases []adoptiumRelease
+	if err := json.Unmarshal(body, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse the releases of Java %d from Adoptium: %v", major, err)
+	}
+	var latest *adoptiumRelease
+	for i, release := range releases {
+		if release.Version.Major == major && (latest == nil || release.Version.Security > latest.Version.Security) {
+			latest = &releases[i]
+		}
+	}
+	return latest, nil
+}
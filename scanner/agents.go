@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Categories of Java agents
+const (
+	AgentAPM      = "apm"
+	AgentSecurity = "security"
+	AgentProfiler = "profiler"
+	AgentCoverage = "coverage"
+)
+
+// Scopes of Java agents
+const (
+	AgentScopeGlobal  = "global"  // every JVM started with the environment picks it up
+	AgentScopeService = "service" // the JVMs of one service or application
+)
+
+// defaultAgentConfigs are the service configurations searched for agents
+var defaultAgentConfigs = []string{
+	"/etc/environment",
+	"/etc/profile.d/*.sh",
+	"/etc/systemd/system/*.service",
+	"/etc/systemd/system/*.service.d/*.conf",
+	"/lib/systemd/system/*.service",
+	"/usr/lib/systemd/system/*.service",
+	"/etc/default/*",
+	"/etc/sysconfig/*",
+	"/opt/*/bin/setenv.sh",
+	"/opt/*/conf/wrapper.conf",
+	"/usr/share/tomcat*/bin/setenv.sh",
+}
+
+// globalAgentConfigs are the configurations that apply to every login and service
+var globalAgentConfigs = []string{"/etc/environment", "/etc/profile.d/*.sh"}
+
+// agentProducts recognize agents by a part of the file name or directory
+var agentProducts = []struct {
+	match    string
+	product  string
+	category string
+}{
+	{"dd-java-agent", "Datadog", AgentAPM},
+	{"newrelic", "New Relic", AgentAPM},
+	{"elastic-apm-agent", "Elastic APM", AgentAPM},
+	{"splunk-otel-javaagent", "Splunk OpenTelemetry", AgentAPM},
+	{"opentelemetry-javaagent", "OpenTelemetry", AgentAPM},
+	{"applicationinsights-agent", "Azure Application Insights", AgentAPM},
+	{"appdynamics", "AppDynamics", AgentAPM},
+	{"oneagent", "Dynatrace", AgentAPM},
+	{"dynatrace", "Dynatrace", AgentAPM},
+	{"instana", "Instana", AgentAPM},
+	{"skywalking-agent", "Apache SkyWalking", AgentAPM},
+	{"pinpoint-bootstrap", "Pinpoint", AgentAPM},
+	{"glowroot", "Glowroot", AgentAPM},
+	{"contrast", "Contrast Security", AgentSecurity},
+	{"imperva", "Imperva RASP", AgentSecurity},
+	{"sqreen", "Sqreen", AgentSecurity},
+	{"jprofilerti", "JProfiler", AgentProfiler},
+	{"yjpagent", "YourKit", AgentProfiler},
+	{"async-profiler", "async-profiler", AgentProfiler},
+	{"libasyncprofiler", "async-profiler", AgentProfiler},
+	{"pyroscope", "Pyroscope", AgentProfiler},
+	{"jacocoagent", "JaCoCo", AgentCoverage},
+}
+
+// agentOption matches -javaagent:<jar>[=options] and -agentpath:<library>[=options],
+// where a quoted option or path may contain spaces
+var agentOption = regexp.MustCompile(`"-(javaagent|agentpath):([^"=]+)|-(javaagent|agentpath):("[^"]+"|[^\s"',;=]+)`)
+
+// javaHomeAssignment matches JAVA_HOME=<path> in shell scripts, environment files and units
+var javaHomeAssignment = regexp.MustCompile(`\bJAVA_HOME=["']?([^"'\s;]+)`)
+
+// JavaAgent is a Java agent or native agent library configured on the host
+type JavaAgent struct {
+	Path     string `json:"path"`
+	Option   string `json:"option"` // javaagent or agentpath
+	Product  string `json:"product,omitempty"`
+	Category string `json:"category,omitempty"`
+	Source   string `json:"source"` // environment variable or configuration file
+	Scope    string `json:"scope"`
+	JavaHome string `json:"java_home,omitempty"` // runtime of the service, if the configuration names it
+	Missing  bool   `json:"missing,omitempty"`
+}
+
+// detectJavaAgents returns the agents set in the Java option variables of this
+// process, if env is set, and in the service configurations matching the globs
+func detectJavaAgents(configs []string, env bool) []JavaAgent {
+	var agents []JavaAgent
+	if env {
+		for _, name := range javaOptionVariables {
+			if name == "CLASSPATH" {
+				continue
+			}
+			for _, agent := range parseAgentOptions(os.Getenv(name)) {
+				agent.Source, agent.Scope = name, AgentScopeGlobal
+				agents = append(agents, agent)
+			}
+		}
+	}
+
+	var seen []string
+	for _, pattern := range configs {
+		matches, _ := filepath.Glob(filepath.FromSlash(expandPattern(pattern)))
+		for _, path := range matches {
+			if slices.Contains(seen, path) {
+				continue
+			}
+			seen = append(seen, path)
+			agents = append(agents, configuredAgents(path, isGlobalAgentConfig(path))...)
+		}
+	}
+	return agents
+}
+
+// isGlobalAgentConfig reports whether a configuration applies to every JVM
+func isGlobalAgentConfig(path string) bool {
+	for _, pattern := range globalAgentConfigs {
+		if matchGlob(pattern, filepath.ToSlash(path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// configuredAgents returns the agents of a configuration file. The runtime of a
+// service is taken from its java executable or JAVA_HOME in the same file.
+func configuredAgents(path string, global bool) []JavaAgent {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	var agents []JavaAgent
+	javaHome, javaPath := "", ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if m := javaHomeAssignment.FindStringSubmatch(line); m != nil && !strings.Contains(m[1], "$") {
+			javaHome = m[1]
+		}
+		if javaPath == "" {
+			javaPath = findJavaCommand(line)
+		}
+		agents = append(agents, parseAgentOptions(line)...)
+	}
+	if len(agents) == 0 {
+		return nil
+	}
+
+	// A java executable named by the service wins over JAVA_HOME
+	if javaPath != "" && !strings.HasPrefix(javaPath, "$") {
+		javaHome = runtimeHome(javaPath)
+	}
+	scope := AgentScopeService
+	if global {
+		scope, javaHome = AgentScopeGlobal, ""
+	}
+	for i := range agents {
+		agents[i].Source, agents[i].Scope, agents[i].JavaHome = path, scope, javaHome
+	}
+	return agents
+}
+
+// findJavaCommand returns the first java executable on a command line, with
+// $JAVA_HOME left in place
+func findJavaCommand(line string) string {
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '"' || r == '\'' || r == '='
+	})
+	for _, field := range fields {
+		if strings.HasSuffix(field, "/bin/java") {
+			return field
+		}
+	}
+	return ""
+}
+
+// parseAgentOptions returns the agents of the -javaagent and -agentpath options in a text
+func parseAgentOptions(text string) []JavaAgent {
+	var agents []JavaAgent
+	for _, m := range agentOption.FindAllStringSubmatch(text, -1) {
+		agent := JavaAgent{Path: m[2], Option: m[1]}
+		if m[1] == "" {
+			agent = JavaAgent{Path: strings.Trim(m[4], `"`), Option: m[3]}
+		}
+		agent.Product, agent.Category = agentProduct(agent.Path)
+		if !strings.Contains(agent.Path, "$") {
+			if _, err := os.Stat(agent.Path); os.IsNotExist(err) {
+				agent.Missing = true
+			}
+		}
+		agents = append(agents, agent)
+	}
+	return agents
+}
+
+// agentProduct returns the product and category of an agent, if known
+func agentProduct(path string) (string, string) {
+	lower := strings.ToLower(filepath.ToSlash(path))
+	for _, p := range agentProducts {
+		if strings.Contains(lower, p.match) {
+			return p.product, p.category
+		}
+	}
+	return "", ""
+}
+
+// agentsAttachedTo returns the paths of the agents a java executable runs with:
+// those of the global environment and of the services configured to use it
+func agentsAttachedTo(agents []JavaAgent, javaPath string) []string {
+	if len(agents) == 0 {
+		return nil
+	}
+	home := runtimeHome(javaPath)
+	var paths []string
+	for _, agent := range agents {
+		if agent.Scope == AgentScopeGlobal || (agent.JavaHome != "" && (sameHome(agent.JavaHome, home) || sameHome(agent.JavaHome, filepath.Dir(filepath.Dir(javaPath))))) {
+			if !slices.Contains(paths, agent.Path) {
+				paths = append(paths, agent.Path)
+			}
+		}
+	}
+	return paths
+}
+
+// agentLabel describes an agent in the text output
+func agentLabel(agent JavaAgent) string {
+	if agent.Product != "" {
+		return agent.Product + " (" + agent.Path + ")"
+	}
+	return agent.Path
+}
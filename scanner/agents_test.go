@@ -0,0 +1,81 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParseAgentOptions(t *testing.T) {
+	agents := parseAgentOptions(`-Xmx1g -javaagent:/opt/datadog/dd-java-agent.jar=service=shop "-javaagent:/opt/my agent/agent.jar" -agentpath:/opt/jprofiler/bin/linux-x64/libjprofilerti.so=port=8849`)
+	want := []JavaAgent{
+		{Path: "/opt/datadog/dd-java-agent.jar", Option: "javaagent", Product: "Datadog", Category: AgentAPM, Missing: true},
+		{Path: "/opt/my agent/agent.jar", Option: "javaagent", Missing: true},
+		{Path: "/opt/jprofiler/bin/linux-x64/libjprofilerti.so", Option: "agentpath", Product: "JProfiler", Category: AgentProfiler, Missing: true},
+	}
+	if !reflect.DeepEqual(agents, want) {
+		t.Errorf("Expected %+v, got %+v", want, agents)
+	}
+}
+
+func TestDetectJavaAgents(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("service configurations are Unix shell and systemd files")
+	}
+	dir := t.TempDir()
+	jdk := filepath.Join(dir, "temurin-17")
+	java := createFakeJava(t, jdk)
+	agentJar := filepath.Join(dir, "newrelic", "newrelic.jar")
+	writeTestFile(t, agentJar, "")
+
+	writeTestFile(t, filepath.Join(dir, "units", "shop.service"), `[Service]
+# Environment="JAVA_TOOL_OPTIONS=-javaagent:/opt/old/agent.jar"
+ExecStart=`+java+` -javaagent:`+agentJar+` -jar /opt/shop/shop.jar
+`)
+	writeTestFile(t, filepath.Join(dir, "units", "billing.service"), `[Service]
+Environment=JAVA_HOME=/opt/jdk-21
+ExecStart=${JAVA_HOME}/bin/java -javaagent:/opt/contrast/contrast.jar -jar billing.jar
+`)
+	writeTestFile(t, filepath.Join(dir, "units", "batch.service"), "[Service]\nExecStart=/opt/batch/run.sh -javaagent:/opt/otel/opentelemetry-javaagent.jar\n")
+	writeTestFile(t, filepath.Join(dir, "units", "plain.service"), "[Service]\nExecStart=/usr/bin/sleep infinity\n")
+	t.Setenv("JAVA_TOOL_OPTIONS", "-javaagent:/opt/elastic/elastic-apm-agent.jar")
+	t.Setenv("_JAVA_OPTIONS", "")
+	t.Setenv("JDK_JAVA_OPTIONS", "")
+
+	agents := detectJavaAgents([]string{filepath.Join(dir, "units", "*.service"), filepath.Join(dir, "units", "shop.service")}, true)
+	byPath := make(map[string]JavaAgent)
+	for _, agent := range agents {
+		byPath[agent.Path] = agent
+	}
+	if len(agents) != 4 || len(byPath) != 4 {
+		t.Fatalf("Expected 4 agents, got %+v", agents)
+	}
+	if agent := byPath["/opt/elastic/elastic-apm-agent.jar"]; agent.Source != "JAVA_TOOL_OPTIONS" || agent.Scope != AgentScopeGlobal {
+		t.Errorf("Expected a global agent of JAVA_TOOL_OPTIONS, got %+v", agent)
+	}
+	if agent := byPath[agentJar]; agent.JavaHome != jdk || agent.Scope != AgentScopeService || agent.Missing || agent.Product != "New Relic" {
+		t.Errorf("Expected the agent of the shop service on %s, got %+v", jdk, agent)
+	}
+	if agent := byPath["/opt/contrast/contrast.jar"]; agent.JavaHome != "/opt/jdk-21" || agent.Category != AgentSecurity {
+		t.Errorf("Expected the agent of the billing service on JAVA_HOME, got %+v", agent)
+	}
+	if agent := byPath["/opt/otel/opentelemetry-javaagent.jar"]; agent.JavaHome != "" || agent.Source != filepath.Join(dir, "units", "batch.service") {
+		t.Errorf("Expected an agent without a known runtime, got %+v", agent)
+	}
+
+	attached := agentsAttachedTo(agents, java)
+	if want := []string{"/opt/elastic/elastic-apm-agent.jar", agentJar}; !reflect.DeepEqual(attached, want) {
+		t.Errorf("Expected %v attached to %s, got %v", want, java, attached)
+	}
+	if attached := agentsAttachedTo(agents, "/opt/jdk-11/bin/java"); !reflect.DeepEqual(attached, []string{"/opt/elastic/elastic-apm-agent.jar"}) {
+		t.Errorf("Expected only the global agent, got %v", attached)
+	}
+
+	if agents := detectJavaAgents(nil, false); agents != nil {
+		t.Errorf("Expected no agents without the environment, got %+v", agents)
+	}
+	if !isGlobalAgentConfig("/etc/profile.d/apm.sh") || isGlobalAgentConfig("/etc/default/tomcat9") {
+		t.Error("Expected only /etc/environment and /etc/profile.d to apply to every JVM")
+	}
+}
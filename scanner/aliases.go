@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// runtimeKey identifies a runtime by its java executable and its home
+type runtimeKey struct {
+	java fileKey
+	home fileKey
+}
+
+// homeIdentity identifies the home of a java executable by its release file,
+// or lib/modules if it has none. Deduplicating tools hard link the identical
+// launchers of different JDKs, whose release files differ. It is the zero key
+// for a home with neither.
+func homeIdentity(java string) fileKey {
+	home := runtimeHome(java)
+	for _, name := range []string{"release", filepath.Join("lib", "modules")} {
+		file := filepath.Join(home, name)
+		if info, err := os.Stat(file); err == nil {
+			if key, ok := fileIdentity(file, info); ok {
+				return key
+			}
+		}
+	}
+	return fileKey{}
+}
+
+// collapseAliases reports a java executable that was found under several paths
+// once. Files are told apart by device and inode, and so are the release files
+// of their homes, so both symbolic links to the same file, e.g. /usr/bin/java,
+// /etc/alternatives/java and the binary of the JDK they point to, and hard
+// links or bind mounts of a JDK are one result. The mounts of an image layer
+// in several containers are not: overlayfs gives every mount a device of its own.
+//
+// Paths through symbolic links become aliases of a path without them, kept if
+// it was found, otherwise of the first path. If the file was found under more
+// than one path without links, they are all listed in Paths. Results whose
+// file cannot be identified are kept as they are.
+func collapseAliases(results []*JavaResult) []*JavaResult {
+	var groups [][]*JavaResult
+	byFile := make(map[runtimeKey]int)
+	for _, result := range results {
+		info, err := os.Stat(result.Path)
+		if err != nil {
+			groups = append(groups, []*JavaResult{result})
+			continue
+		}
+		java, ok := fileIdentity(result.Path, info)
+		if !ok {
+			groups = append(groups, []*JavaResult{result})
+			continue
+		}
+		key := runtimeKey{java: java, home: homeIdentity(result.Path)}
+		if i, ok := byFile[key]; ok {
+			groups[i] = append(groups[i], result)
+			continue
+		}
+		byFile[key] = len(groups)
+		groups = append(groups, []*JavaResult{result})
+	}
+	if len(groups) == len(results) {
+		return results
+	}
+
+	kept := make([]*JavaResult, 0, len(groups))
+	for _, group := range groups {
+		primary := group[0]
+		var paths []string
+		for _, result := range group {
+			if target, err := filepath.EvalSymlinks(result.Path); err == nil && target == result.Path {
+				if len(paths) == 0 {
+					primary = result
+				}
+				paths = append(paths, result.Path)
+			}
+		}
+		for _, result := range group {
+			if result.Path != primary.Path && !slices.Contains(paths, result.Path) {
+				primary.Aliases = append(primary.Aliases, result.Path)
+			}
+		}
+		slices.Sort(primary.Aliases)
+		primary.Aliases = slices.Compact(primary.Aliases)
+		slices.Sort(paths)
+		if paths = slices.Compact(paths); len(paths) > 1 {
+			primary.Paths = paths
+		}
+		kept = append(kept, primary)
+	}
+	return kept
+}
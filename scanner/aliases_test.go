@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCollapseAliases(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires privileges on Windows")
+	}
+	dir := t.TempDir()
+	java := createFakeJava(t, filepath.Join(dir, "usr", "lib", "jvm", "jdk-17"))
+	alternatives := filepath.Join(dir, "etc", "alternatives", "java")
+	usrBin := filepath.Join(dir, "usr", "bin", "java")
+	for link, target := range map[string]string{alternatives: java, usrBin: alternatives} {
+		os.MkdirAll(filepath.Dir(link), 0755)
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createFakeJava(t, filepath.Join(dir, "opt", "jdk-21"))
+
+	results, err := NewJavaFinder(dir, -1, false, false).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Path != java {
+		t.Fatalf("Expected the links to be reported with the real binary, got %v", results)
+	}
+	if !slices.Equal(results[0].Aliases, []string{alternatives, usrBin}) || results[1].Aliases != nil {
+		t.Errorf("Expected the links as aliases, got %v and %v", results[0].Aliases, results[1].Aliases)
+	}
+	if output := buildJSONOutput(results, NewJavaFinder(dir, -1, false, false), time.Now()); output.Meta.CountResult != 2 || len(output.Runtimes[0].Aliases) != 2 {
+		t.Errorf("Expected two distinct runtimes, got %+v", output.Meta)
+	}
+
+	// Without the real binary, the first link found stands for it
+	finder := NewJavaFinder(dir, -1, false, false)
+	finder.roots = []string{filepath.Join(dir, "etc"), filepath.Join(dir, "usr", "bin")}
+	results, err = finder.Find()
+	if err != nil || len(results) != 1 || results[0].Path != alternatives || !slices.Equal(results[0].Aliases, []string{usrBin}) {
+		t.Errorf("Expected the first link with the other one as alias, got %v (%v)", results, err)
+	}
+}
+
+func TestCollapseHardLinks(t *testing.T) {
+	dir := t.TempDir()
+	java := createFakeJava(t, filepath.Join(dir, "image", "jdk-21"))
+	var paths []string
+	for _, container := range []string{"c1", "c2"} {
+		link := filepath.Join(dir, container, "opt", "java", "bin", javaExecutableName())
+		os.MkdirAll(filepath.Dir(link), 0755)
+		if err := os.Link(java, link); err != nil {
+			t.Skipf("hard links are not supported: %v", err)
+		}
+		paths = append(paths, link)
+	}
+	paths = append(paths, java)
+	slices.Sort(paths)
+
+	finder := NewJavaFinder(dir, -1, false, false)
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !slices.Equal(results[0].Paths, paths) || results[0].Aliases != nil {
+		t.Fatalf("Expected one runtime with all paths, got %v", results)
+	}
+	if output := buildJSONOutput(results, finder, time.Now()); output.Meta.CountResult != 1 || len(output.Runtimes[0].Paths) != 3 {
+		t.Errorf("Expected one distinct runtime, got %+v", output.Meta)
+	}
+}
+
+func TestCollapseHardLinksKeepsHomesApart(t *testing.T) {
+	dir := t.TempDir()
+	// A deduplicating tool linked the identical launchers of two JDKs
+	java := createFakeJava(t, filepath.Join(dir, "jdk-21.0.4"))
+	writeTestFile(t, filepath.Join(dir, "jdk-21.0.4", "release"), "JAVA_VERSION=\"21.0.4\"\n")
+	other := filepath.Join(dir, "jdk-21.0.5", "bin", javaExecutableName())
+	os.MkdirAll(filepath.Dir(other), 0755)
+	if err := os.Link(java, other); err != nil {
+		t.Skipf("hard links are not supported: %v", err)
+	}
+	writeTestFile(t, filepath.Join(dir, "jdk-21.0.5", "release"), "JAVA_VERSION=\"21.0.5\"\n")
+
+	// A hard linked copy of a whole JDK is one runtime
+	copied := filepath.Join(dir, "copy", "jdk-21.0.4")
+	os.MkdirAll(filepath.Join(copied, "bin"), 0755)
+	if err := os.Link(java, filepath.Join(copied, "bin", javaExecutableName())); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(dir, "jdk-21.0.4", "release"), filepath.Join(copied, "release")); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := NewJavaFinder(dir, -1, false, false).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected the two JDKs apart and the copy with its original, got %v", results)
+	}
+	for _, result := range results {
+		if filepath.Base(filepath.Dir(filepath.Dir(result.Path))) == "jdk-21.0.4" && len(result.Paths) != 2 {
+			t.Errorf("Expected the copy as a path of %s, got %v", result.Path, result.Paths)
+		}
+	}
+}
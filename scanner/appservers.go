@@ -0,0 +1,411 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Application servers detected with -app-servers
+const (
+	AppServerTomcat           = "tomcat"
+	AppServerWildFly          = "wildfly"
+	AppServerJBossEAP         = "jboss-eap"
+	AppServerWebLogic         = "weblogic"
+	AppServerWebSphere        = "websphere"
+	AppServerWebSphereLiberty = "websphere-liberty"
+	AppServerJetty            = "jetty"
+)
+
+// appServerNames are the product names of the text output
+var appServerNames = map[string]string{
+	AppServerTomcat:           "Tomcat",
+	AppServerWildFly:          "WildFly",
+	AppServerJBossEAP:         "JBoss EAP",
+	AppServerWebLogic:         "WebLogic",
+	AppServerWebSphere:        "WebSphere",
+	AppServerWebSphereLiberty: "WebSphere Liberty",
+	AppServerJetty:            "Jetty",
+}
+
+// AppServer is an installation of a Java application server and the runtime it
+// is configured to use
+type AppServer struct {
+	Product string `json:"product"`
+	Version string `json:"version,omitempty"`
+	Home    string `json:"home"`
+
+	// JavaHome is the runtime named by the configuration of the server, or the
+	// runtime bundled with it; empty if the server uses the default runtime
+	JavaHome string `json:"java_home,omitempty"`
+
+	// JavaHomeSource is the file or registry key that names the runtime
+	JavaHomeSource string `json:"java_home_source,omitempty"`
+	BundledJava    bool   `json:"bundled_java,omitempty"`
+
+	// JavaExecutable is the java executable of the scan in JavaHome, OracleJDK
+	// whether it is an Oracle JDK, the pairing license audits look for
+	JavaExecutable string `json:"java_executable,omitempty"`
+	OracleJDK      bool   `json:"oracle_jdk,omitempty"`
+}
+
+// appServerSignature recognizes a server by a file the walk passes: home is
+// the installation directory for the path of the file, or empty if the file
+// does not belong to the server after all
+type appServerSignature struct {
+	product string
+	home    func(path string) string
+}
+
+// appServerSignatures are the signature files by file name
+var appServerSignatures = map[string]appServerSignature{
+	"catalina.sh":  {AppServerTomcat, tomcatHome},
+	"catalina.bat": {AppServerTomcat, tomcatHome},
+	"jboss-modules.jar": {AppServerWildFly, func(path string) string {
+		return filepath.Dir(path)
+	}},
+	"weblogic.jar": {AppServerWebLogic, func(path string) string {
+		// ORACLE_HOME/wlserver/server/lib/weblogic.jar, wlserver_10.3 before 12c
+		lib := filepath.Dir(path)
+		wlserver := filepath.Dir(filepath.Dir(lib))
+		if filepath.Base(lib) != "lib" || !strings.HasPrefix(filepath.Base(wlserver), "wlserver") {
+			return ""
+		}
+		return filepath.Dir(wlserver)
+	}},
+	"WAS.product": {AppServerWebSphere, func(path string) string {
+		return parentWithin(path, "properties", "version")
+	}},
+	"WebSphereApplicationServer.properties": {AppServerWebSphereLiberty, func(path string) string {
+		return parentWithin(path, "lib", "versions")
+	}},
+	"start.jar": {AppServerJetty, func(path string) string {
+		home := filepath.Dir(path)
+		if _, err := os.Stat(filepath.Join(home, "etc", "jetty.xml")); err != nil {
+			return ""
+		}
+		return home
+	}},
+}
+
+// appServerConfigs are the files of a server that may name its runtime,
+// relative to its home and most specific first
+var appServerConfigs = map[string][]string{
+	AppServerTomcat:   {"bin/setenv.sh", "bin/setenv.bat"},
+	AppServerWildFly:  {"bin/standalone.conf", "bin/domain.conf", "bin/standalone.conf.bat", "bin/domain.conf.bat"},
+	AppServerJBossEAP: {"bin/standalone.conf", "bin/domain.conf", "bin/standalone.conf.bat", "bin/domain.conf.bat"},
+	AppServerWebLogic: {
+		"user_projects/domains/*/bin/setDomainEnv.sh", "user_projects/domains/*/bin/setDomainEnv.cmd",
+		"oracle_common/common/bin/commBaseEnv.sh", "oracle_common/common/bin/commBaseEnv.cmd",
+		"oracle_common/common/bin/commEnv.sh", "oracle_common/common/bin/commEnv.cmd",
+		"wlserver/.product.properties", "common/bin/commEnv.sh", "common/bin/commEnv.cmd",
+	},
+	AppServerWebSphere:        {"bin/setupCmdLine.sh", "bin/setupCmdLine.bat"},
+	AppServerWebSphereLiberty: {"etc/server.env"},
+	AppServerJetty:            {"/etc/default/jetty"},
+}
+
+// appServerBundledJava are the runtimes shipped with a server, relative to its home
+var appServerBundledJava = []string{"java/8.0", "java/jre", "java", "jre", "jdk"}
+
+// appServerHomeVariables stand for the home of a server in its configuration
+var appServerHomeVariables = []string{
+	"CATALINA_HOME", "CATALINA_BASE", "JBOSS_HOME", "ORACLE_HOME", "MW_HOME", "WAS_HOME", "WLP_INSTALL_DIR", "JETTY_HOME",
+}
+
+// javaHomeSetting matches the assignment of JAVA_HOME or JRE_HOME in shell and
+// batch scripts and environment files, e.g. set "JAVA_HOME=C:\Program Files\Java\jdk-17"
+var javaHomeSetting = regexp.MustCompile(`(?i)^(?:export\s+|set\s+)?(?:"(?:JAVA_HOME|JRE_HOME)=([^"]*)"|(?:JAVA_HOME|JRE_HOME)=("[^"]*"|'[^']*'|[^\s";]+))`)
+
+// Versions in the files of the servers
+var (
+	tomcatServerInfo = regexp.MustCompile(`(?m)^server\.info=Apache Tomcat/(\S+)`)
+	wildflyVersion   = regexp.MustCompile(`(\d+\.\d+[\w.-]*)`)
+	weblogicVersion  = regexp.MustCompile(`name="WebLogic Server"\s+version="([^"]+)"`)
+	websphereVersion = regexp.MustCompile(`<version>([^<]+)</version>`)
+	libertyVersion   = regexp.MustCompile(`(?m)^com\.ibm\.websphere\.productVersion=(\S+)`)
+	jettyVersion     = regexp.MustCompile(`jetty-(\d[\w.-]*)`)
+)
+
+// isAppServerSignature reports whether a file name is a signature file of a server
+func isAppServerSignature(name string) bool {
+	_, ok := appServerSignatures[name]
+	return ok
+}
+
+// appServerScanner collects the servers whose signature files the walk passes
+type appServerScanner struct {
+	mu      sync.Mutex // the walk workers pass signature files concurrently
+	servers []AppServer
+}
+
+// inspect records the server a signature file belongs to, once per home
+func (s *appServerScanner) inspect(path string) {
+	signature := appServerSignatures[filepath.Base(path)]
+	home := signature.home(path)
+	if home == "" || s.known(home) {
+		return
+	}
+	server, ok := detectAppServer(signature.product, home)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !slices.ContainsFunc(s.servers, func(server AppServer) bool { return server.Home == home }) {
+		s.servers = append(s.servers, server)
+	}
+}
+
+// known checks if the server of a home was recorded already
+func (s *appServerScanner) known(home string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.ContainsFunc(s.servers, func(server AppServer) bool { return server.Home == home })
+}
+
+// list returns the servers found, sorted by home
+func (s *appServerScanner) list() []AppServer {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	servers := slices.Clone(s.servers)
+	slices.SortFunc(servers, func(a, b AppServer) int { return strings.Compare(a.Home, b.Home) })
+	return servers
+}
+
+// detectAppServer reads the version of a server and the runtime it is configured to use
+func detectAppServer(product, home string) (AppServer, bool) {
+	server := AppServer{Product: product, Home: home}
+	switch product {
+	case AppServerTomcat:
+		server.Version = tomcatVersion(home)
+	case AppServerWildFly:
+		data, err := os.ReadFile(filepath.Join(home, "version.txt"))
+		if err != nil {
+			// jboss-modules.jar alone is embedded in other products as well
+			if _, err := os.Stat(filepath.Join(home, "standalone")); err != nil {
+				return server, false
+			}
+		}
+		if strings.Contains(string(data), "Enterprise Application Platform") {
+			server.Product = AppServerJBossEAP
+		}
+		if m := wildflyVersion.FindSubmatch(data); m != nil {
+			server.Version = string(m[1])
+		}
+	case AppServerWebLogic:
+		for _, registry := range []string{"inventory/registry.xml", "registry.xml"} {
+			if data, err := os.ReadFile(filepath.Join(home, filepath.FromSlash(registry))); err == nil {
+				if m := weblogicVersion.FindSubmatch(data); m != nil {
+					server.Version = string(m[1])
+					break
+				}
+			}
+		}
+	case AppServerWebSphere:
+		server.Version = matchFile(filepath.Join(home, "properties", "version", "WAS.product"), websphereVersion)
+	case AppServerWebSphereLiberty:
+		server.Version = matchFile(filepath.Join(home, "lib", "versions", "WebSphereApplicationServer.properties"), libertyVersion)
+	case AppServerJetty:
+		server.Version = matchFile(filepath.Join(home, "VERSION.txt"), jettyVersion)
+	}
+
+	server.JavaHome, server.JavaHomeSource = appServerJavaHome(server.Product, home)
+	if server.JavaHome == "" {
+		for _, dir := range appServerBundledJava {
+			java := filepath.Join(home, filepath.FromSlash(dir))
+			if _, err := os.Stat(filepath.Join(java, "bin", javaExecutableName())); err == nil {
+				server.JavaHome = java
+				break
+			}
+		}
+	}
+	server.BundledJava = server.JavaHome != "" && withinRoot(home, server.JavaHome)
+	return server, true
+}
+
+// appServerJavaHome returns the runtime named by the configuration of a server
+// and where it is named
+func appServerJavaHome(product, home string) (string, string) {
+	configs := slices.Clone(appServerConfigs[product])
+	// Distribution packages keep the settings of e.g. /usr/share/tomcat9 in /etc/default/tomcat9
+	configs = append(configs, "/etc/default/"+filepath.Base(home), "/etc/sysconfig/"+filepath.Base(home))
+	for _, config := range configs {
+		pattern := filepath.FromSlash(config)
+		if !strings.HasPrefix(config, "/") {
+			pattern = filepath.Join(home, pattern)
+		}
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			if java := configuredJavaHome(path, home); java != "" {
+				return java, path
+			}
+		}
+	}
+	if product == AppServerTomcat {
+		return tomcatServiceJava(home)
+	}
+	return "", ""
+}
+
+// configuredJavaHome returns the runtime a configuration file names by JAVA_HOME,
+// JRE_HOME or a java executable. The home of the server is substituted for its
+// variables, values with other variables are ignored.
+func configuredJavaHome(path, home string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+
+	javaHome, javaPath := "", ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		lower := strings.ToLower(line)
+		if line == "" || line[0] == '#' || line[0] == ';' || strings.HasPrefix(lower, "rem ") || strings.HasPrefix(line, "::") {
+			continue
+		}
+		if m := javaHomeSetting.FindStringSubmatch(line); m != nil {
+			if value := expandHomeVariables(strings.Trim(m[1]+m[2], `"'`), home); value != "" {
+				javaHome = value
+			}
+		}
+		if java := findJavaCommand(line); java != "" && javaPath == "" {
+			javaPath = expandHomeVariables(java, home)
+		}
+	}
+	// The last assignment wins, as when the script runs
+	if javaHome != "" {
+		return filepath.Clean(javaHome)
+	}
+	if javaPath != "" {
+		return filepath.Dir(filepath.Dir(javaPath))
+	}
+	return ""
+}
+
+// expandHomeVariables replaces the variables of the server home in a value,
+// or returns an empty string if it contains other variables
+func expandHomeVariables(value, home string) string {
+	for _, name := range appServerHomeVariables {
+		for _, variable := range []string{"${" + name + "}", "$" + name, "%" + name + "%"} {
+			value = strings.ReplaceAll(value, variable, home)
+		}
+	}
+	if strings.ContainsAny(value, "$%") || value == "" {
+		return ""
+	}
+	return value
+}
+
+// tomcatHome returns the home of the Tomcat whose bin directory holds catalina.sh
+func tomcatHome(path string) string {
+	home := filepath.Dir(filepath.Dir(path))
+	if filepath.Base(filepath.Dir(path)) != "bin" {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(home, "lib", "catalina.jar")); err != nil {
+		return ""
+	}
+	return home
+}
+
+// tomcatVersion reads the version of Tomcat from ServerInfo.properties in catalina.jar
+func tomcatVersion(home string) string {
+	archive, err := zip.OpenReader(filepath.Join(home, "lib", "catalina.jar"))
+	if err != nil {
+		return ""
+	}
+	defer archive.Close()
+	for _, entry := range archive.File {
+		if entry.Name != "org/apache/catalina/util/ServerInfo.properties" {
+			continue
+		}
+		file, err := entry.Open()
+		if err != nil {
+			return ""
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(io.LimitReader(file, 64*1024))
+		if m := tomcatServerInfo.FindSubmatch(data); m != nil {
+			return string(m[1])
+		}
+	}
+	return ""
+}
+
+// parentWithin returns the directory above the given subdirectories of a file,
+// e.g. the home of home/properties/version/WAS.product
+func parentWithin(path string, dirs ...string) string {
+	dir := filepath.Dir(path)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if filepath.Base(dir) != dirs[i] {
+			return ""
+		}
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// matchFile returns the first submatch of a pattern in a file
+func matchFile(path string, pattern *regexp.Regexp) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	if m := pattern.FindSubmatch(data); m != nil {
+		return strings.TrimSpace(string(m[1]))
+	}
+	return ""
+}
+
+// pairAppServers links the servers to the java executables of the scan
+func pairAppServers(servers []AppServer, runtimes []JavaRuntimeJSON) []AppServer {
+	for i := range servers {
+		if servers[i].JavaHome == "" {
+			continue
+		}
+		for _, runtime := range runtimes {
+			home := runtimeHome(runtime.JavaExecutable)
+			if sameHome(servers[i].JavaHome, home) || sameHome(servers[i].JavaHome, filepath.Dir(filepath.Dir(runtime.JavaExecutable))) {
+				servers[i].JavaExecutable = runtime.JavaExecutable
+				servers[i].OracleJDK = runtime.IsOracle
+				break
+			}
+		}
+	}
+	return servers
+}
+
+// appServersUsing returns the homes of the servers running on a java executable
+func appServersUsing(servers []AppServer, javaPath string) []string {
+	var homes []string
+	for _, server := range servers {
+		if server.JavaExecutable == javaPath {
+			homes = append(homes, server.Home)
+		}
+	}
+	return homes
+}
+
+// appServerLabel describes a server in the text output
+func appServerLabel(server AppServer) string {
+	label := appServerNames[server.Product]
+	if server.Version != "" {
+		label += " " + server.Version
+	}
+	return label
+}
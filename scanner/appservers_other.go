@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// tomcatServiceJava returns the runtime of the Windows service of a Tomcat, which
+// only exists on Windows
+func tomcatServiceJava(home string) (string, string) {
+	return "", ""
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAppServers(t *testing.T) {
+	root := t.TempDir()
+	jdk17 := filepath.Join(root, "jdk-17")
+	jdk8 := filepath.Join(root, "jdk1.8.0_401")
+	createFakeJava(t, jdk17)
+	createFakeJava(t, jdk8)
+
+	tomcat := filepath.Join(root, "opt", "tomcat")
+	writeTestFile(t, filepath.Join(tomcat, "bin", "catalina.sh"), "")
+	writeTestFile(t, filepath.Join(tomcat, "bin", "catalina.bat"), "")
+	writeTestJar(t, filepath.Join(tomcat, "lib", "catalina.jar"), map[string]string{
+		"org/apache/catalina/util/ServerInfo.properties": "server.info=Apache Tomcat/9.0.85\nserver.number=9.0.85.0\n",
+	})
+	writeTestFile(t, filepath.Join(tomcat, "bin", "setenv.sh"), "# Runtime of Tomcat\nexport JAVA_HOME=\"/usr/lib/jvm/default\"\nJAVA_HOME=\""+jdk17+"\"\n")
+
+	weblogic := filepath.Join(root, "u01", "oracle")
+	writeTestJar(t, filepath.Join(weblogic, "wlserver", "server", "lib", "weblogic.jar"), map[string]string{"META-INF/MANIFEST.MF": ""})
+	writeTestFile(t, filepath.Join(weblogic, "inventory", "registry.xml"),
+		`<registry><distributions><distribution status="installed" name="WebLogic Server" version="14.1.1.0.0"></distribution></distributions></registry>`)
+	writeTestFile(t, filepath.Join(weblogic, "oracle_common", "common", "bin", "commBaseEnv.sh"),
+		"if [ -z \"${JAVA_HOME}\" ]; then\n  JAVA_HOME=\""+jdk8+"\"\nfi\nexport JAVA_HOME\n")
+
+	wildfly := filepath.Join(root, "opt", "wildfly")
+	writeTestFile(t, filepath.Join(wildfly, "jboss-modules.jar"), "")
+	writeTestFile(t, filepath.Join(wildfly, "version.txt"), "WildFly Full 30.0.1.Final (WildFly Core 22.0.2.Final) - 2023-12-06\n")
+	writeTestFile(t, filepath.Join(wildfly, "bin", "standalone.conf"), "#JAVA_HOME=\"/opt/java/jdk\"\n")
+
+	websphere := filepath.Join(root, "IBM", "WebSphere", "AppServer")
+	writeTestFile(t, filepath.Join(websphere, "properties", "version", "WAS.product"), "<product><version>9.0.5.14</version></product>")
+	writeTestFile(t, filepath.Join(websphere, "bin", "setupCmdLine.sh"), "JAVA_HOME=\"${WAS_HOME}/java/8.0\"\n")
+	createFakeJava(t, filepath.Join(websphere, "java", "8.0"))
+
+	// A start.jar without the configuration of Jetty and a lone jboss-modules.jar
+	writeTestFile(t, filepath.Join(root, "tools", "start.jar"), "")
+	writeTestFile(t, filepath.Join(root, "tools", "jboss-modules.jar"), "")
+
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.appServers = &appServerScanner{}
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := buildJSONOutput(results, finder, time.Now())
+	java := javaExecutableName()
+	want := []AppServer{
+		{Product: AppServerWebSphere, Version: "9.0.5.14", Home: websphere, JavaHome: filepath.Join(websphere, "java", "8.0"),
+			JavaHomeSource: filepath.Join(websphere, "bin", "setupCmdLine.sh"), BundledJava: true,
+			JavaExecutable: filepath.Join(websphere, "java", "8.0", "bin", java)},
+		{Product: AppServerTomcat, Version: "9.0.85", Home: tomcat, JavaHome: jdk17,
+			JavaHomeSource: filepath.Join(tomcat, "bin", "setenv.sh"), JavaExecutable: filepath.Join(jdk17, "bin", java)},
+		{Product: AppServerWildFly, Version: "30.0.1.Final", Home: wildfly},
+		{Product: AppServerWebLogic, Version: "14.1.1.0.0", Home: weblogic, JavaHome: jdk8,
+			JavaHomeSource: filepath.Join(weblogic, "oracle_common", "common", "bin", "commBaseEnv.sh"), JavaExecutable: filepath.Join(jdk8, "bin", java)},
+	}
+	if !reflect.DeepEqual(output.AppServers, want) {
+		t.Errorf("Expected the servers\n%+v\ngot\n%+v", want, output.AppServers)
+	}
+	for _, runtime := range output.Runtimes {
+		if runtime.JavaExecutable == filepath.Join(jdk8, "bin", java) && !reflect.DeepEqual(runtime.AppServers, []string{weblogic}) {
+			t.Errorf("Expected WebLogic to run on %s, got %v", runtime.JavaExecutable, runtime.AppServers)
+		}
+	}
+
+	// An evaluated Oracle JDK marks the pairing for license audits
+	runtimes := []JavaRuntimeJSON{{JavaExecutable: filepath.Join(jdk8, "bin", java), IsOracle: true}}
+	servers := pairAppServers([]AppServer{{Product: AppServerWebLogic, Home: weblogic, JavaHome: jdk8}}, runtimes)
+	if !servers[0].OracleJDK {
+		t.Errorf("Expected WebLogic on an Oracle JDK, got %+v", servers[0])
+	}
+}
+
+func TestConfiguredJavaHome(t *testing.T) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "server")
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"batch", "rem set JAVA_HOME=C:\\old\r\nset \"JAVA_HOME=C:\\Program Files\\Java\\jdk-17\"\r\n", `C:\Program Files\Java\jdk-17`},
+		{"jre home", "JRE_HOME=/usr/lib/jvm/jre-11\n", "/usr/lib/jvm/jre-11"},
+		{"java command", "JAVA=/usr/lib/jvm/java-21/bin/java\n", "/usr/lib/jvm/java-21"},
+		{"home variable", "JAVA_HOME=$CATALINA_HOME/jre\n", filepath.Join(home, "jre")},
+		{"other variable", "JAVA_HOME=$JDK_DIR\n", ""},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "config")
+		if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := configuredJavaHome(path, home); got != filepath.Clean(tt.want) && got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
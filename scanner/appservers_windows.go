@@ -0,0 +1,65 @@
+//go:build windows
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// procrunKeys hold the services installed with the Procrun service wrapper of
+// Tomcat, of 64-bit and 32-bit installers
+var procrunKeys = []string{
+	`SOFTWARE\Apache Software Foundation\Procrun 2.0`,
+	`SOFTWARE\WOW6432Node\Apache Software Foundation\Procrun 2.0`,
+}
+
+// tomcatServiceJava returns the runtime of the Windows service of a Tomcat: the
+// jvm.dll configured for the service whose class path is in the home
+func tomcatServiceJava(home string) (string, string) {
+	for _, procrun := range procrunKeys {
+		for _, service := range registrySubkeys(procrun) {
+			key := procrun + `\` + service + `\Parameters\Java`
+			classPath, ok := readRegistryString(key, "Classpath")
+			if !ok || !strings.Contains(strings.ToLower(classPath), strings.ToLower(home)) {
+				continue
+			}
+			// The jvm.dll of bin\server, or "auto" for the runtime of the registry
+			jvm, ok := readRegistryString(key, "Jvm")
+			if !ok || !strings.EqualFold(filepath.Ext(jvm), ".dll") {
+				return "", ""
+			}
+			java := filepath.Dir(filepath.Dir(filepath.Dir(jvm)))
+			if strings.EqualFold(filepath.Base(java), "jre") {
+				java = filepath.Dir(java)
+			}
+			return java, `HKLM\` + key
+		}
+	}
+	return "", ""
+}
+
+// registrySubkeys lists the names of the subkeys of a key below HKEY_LOCAL_MACHINE
+func registrySubkeys(key string) []string {
+	keyPtr, err := syscall.UTF16PtrFromString(key)
+	if err != nil {
+		return nil
+	}
+	var handle syscall.Handle
+	if err := syscall.RegOpenKeyEx(syscall.HKEY_LOCAL_MACHINE, keyPtr, 0, syscall.KEY_READ, &handle); err != nil {
+		return nil
+	}
+	defer syscall.RegCloseKey(handle)
+
+	var names []string
+	for i := uint32(0); ; i++ {
+		// Key names have at most 255 characters
+		buf := make([]uint16, 256)
+		size := uint32(len(buf))
+		if err := syscall.RegEnumKeyEx(handle, i, &buf[0], &size, nil, nil, nil, nil); err != nil {
+			return names
+		}
+		names = append(names, syscall.UTF16ToString(buf[:size]))
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// archSupport tells how the host can run a binary of another architecture
+type archSupport int
+
+const (
+	archNative      archSupport = iota // same architecture or a compatible subset, e.g. 386 on amd64
+	archEmulated                       // may run through an emulation layer that can be missing
+	archUnsupported                    // cannot run at all
+)
+
+// emulatedArchs lists the binary architectures each OS and host architecture can
+// run through an emulation layer: Rosetta 2 on macOS, the x64 emulation of
+// Windows 11 on ARM and the AArch32 support of some 64-bit ARM CPUs
+var emulatedArchs = map[string]map[string][]string{
+	"darwin": {
+		"arm64": {"amd64"},
+	},
+	"windows": {
+		"arm64": {"amd64", "386", "arm"},
+	},
+	"linux": {
+		"arm64": {"arm"},
+	},
+}
+
+// nativeArchs lists architectures a host runs natively besides its own
+var nativeArchs = map[string][]string{
+	"amd64": {"386"},
+}
+
+// hostArchSupport tells if a host running goos on hostArch can execute a binary
+// built for binArch. Unknown binary architectures are assumed to run natively.
+func hostArchSupport(goos, hostArch, binArch string) archSupport {
+	if binArch == "" || binArch == hostArch {
+		return archNative
+	}
+	for _, arch := range nativeArchs[hostArch] {
+		if arch == binArch && goos != "darwin" {
+			return archNative
+		}
+	}
+	for _, arch := range emulatedArchs[goos][hostArch] {
+		if arch == binArch {
+			return archEmulated
+		}
+	}
+	return archUnsupported
+}
+
+// archSupport tells if the binary can be executed on this host. The host
+// architecture is the one jfind was built for, so an amd64 build of jfind
+// running under emulation sees an amd64 host.
+func (b *binaryInfo) archSupport() archSupport {
+	return hostArchSupport(runtime.GOOS, runtime.GOARCH, b.Arch)
+}
+
+// archMismatchError describes a binary that cannot be executed on this host
+func (b *binaryInfo) archMismatchError() error {
+	return fmt.Errorf("%s binary cannot run on %s/%s host", b.Arch, runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestHostArchSupport(t *testing.T) {
+	tests := []struct {
+		goos     string
+		hostArch string
+		binArch  string
+		want     archSupport
+	}{
+		{"linux", "amd64", "amd64", archNative},
+		{"linux", "amd64", "386", archNative},
+		{"linux", "amd64", "", archNative},
+		{"linux", "amd64", "arm64", archUnsupported},
+		{"linux", "arm64", "arm", archEmulated},
+		{"linux", "arm64", "amd64", archUnsupported},
+		{"linux", "riscv64", "arm64", archUnsupported},
+		{"windows", "amd64", "386", archNative},
+		{"windows", "arm64", "amd64", archEmulated},
+		{"windows", "amd64", "arm64", archUnsupported},
+		{"darwin", "arm64", "amd64", archEmulated},
+		{"darwin", "amd64", "386", archUnsupported},
+		{"darwin", "amd64", "arm64", archUnsupported},
+	}
+	for _, tt := range tests {
+		if got := hostArchSupport(tt.goos, tt.hostArch, tt.binArch); got != tt.want {
+			t.Errorf("hostArchSupport(%s, %s, %s) = %d, want %d", tt.goos, tt.hostArch, tt.binArch, got, tt.want)
+		}
+	}
+}
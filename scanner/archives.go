@@ -0,0 +1,176 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// maxArchivedRelease limits the size of a release file read from an archive
+const maxArchivedRelease = 64 * 1024
+
+// maxRuntimeTarball limits the size of a tarball looked into. A tarball is
+// decompressed as a whole to list it, JDK distributions are well below this
+// while backups and image layers easily are not.
+const maxRuntimeTarball = 512 << 20
+
+// isRuntimeArchive reports whether a file is an archive -scan-archives looks into
+func isRuntimeArchive(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// archiveTooLarge reports whether a runtime archive is skipped for its size.
+// A zip file is listed from its central directory whatever its size, a
+// tarball only up to maxRuntimeTarball.
+func archiveTooLarge(name string, size int64) bool {
+	return !strings.HasSuffix(strings.ToLower(name), ".zip") && size > maxRuntimeTarball
+}
+
+// archivedRuntime is a runtime in an archive, its java executable and the
+// release file of its home, if the archive has one
+type archivedRuntime struct {
+	entry   string
+	release map[string]string
+}
+
+// archiveListing collects the java executables and release files of an archive
+// while its entries are listed
+type archiveListing struct {
+	javas    []string
+	releases map[string]map[string]string // release files by the directory they are in
+}
+
+// add looks at an entry of an archive and reads it if it is a release file.
+// Entries are named as in the archive, with or without a leading ./.
+func (l *archiveListing) add(name string, open func() (io.ReadCloser, error)) error {
+	name = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, `\`, "/")), "/")
+	dir, base := path.Split(name)
+	switch {
+	case (base == "java" || base == "java.exe") && path.Base(dir) == "bin":
+		// Archives are cross-platform, a Windows JDK is found on Linux as well
+		l.javas = append(l.javas, name)
+	case base == "release":
+		r, err := open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		data, err := io.ReadAll(io.LimitReader(r, maxArchivedRelease))
+		if err != nil {
+			return err
+		}
+		if l.releases == nil {
+			l.releases = make(map[string]map[string]string)
+		}
+		l.releases[path.Clean(dir)] = parseReleaseFile(string(data))
+	}
+	return nil
+}
+
+// runtimes returns a runtime for each java executable whose home has a release
+// file, which leaves out the jre of a JDK 8. Without release files, the java
+// executable closest to the root of the archive is the runtime.
+func (l *archiveListing) runtimes() []archivedRuntime {
+	slices.SortFunc(l.javas, func(a, b string) int {
+		if d := strings.Count(a, "/") - strings.Count(b, "/"); d != 0 {
+			return d
+		}
+		return strings.Compare(a, b)
+	})
+	var runtimes []archivedRuntime
+	for _, java := range l.javas {
+		if release, ok := l.releases[path.Dir(path.Dir(java))]; ok {
+			runtimes = append(runtimes, archivedRuntime{java, release})
+		}
+	}
+	if len(runtimes) == 0 && len(l.javas) > 0 {
+		runtimes = append(runtimes, archivedRuntime{entry: l.javas[0]})
+	}
+	return runtimes
+}
+
+// inspectArchive lists a zip file or gzip compressed tarball for the runtimes
+// it contains. Nothing is extracted to disk: a zip file is read from its
+// central directory, a tarball is decompressed as a stream.
+func inspectArchive(file string) ([]archivedRuntime, error) {
+	var listing archiveListing
+	if strings.HasSuffix(strings.ToLower(file), ".zip") {
+		archive, err := zip.OpenReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer archive.Close()
+		for _, entry := range archive.File {
+			if entry.FileInfo().IsDir() {
+				continue
+			}
+			if err := listing.add(entry.Name, entry.Open); err != nil {
+				return nil, err
+			}
+		}
+		return listing.runtimes(), nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := listing.add(header.Name, func() (io.ReadCloser, error) { return io.NopCloser(tr), nil }); err != nil {
+			return nil, err
+		}
+	}
+	return listing.runtimes(), nil
+}
+
+// newArchiveResults reports the runtimes packaged in an archive found by
+// -scan-archives. Their path is the entry of the java executable in the
+// archive, e.g. /srv/artifacts/jdk-21.tar.gz!/jdk-21.0.5+11/bin/java, and
+// their version comes from the release file, as they cannot be executed.
+func (f *JavaFinder) newArchiveResults(archive string) ([]*JavaResult, error) {
+	runtimes, err := inspectArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*JavaResult, 0, len(runtimes))
+	for _, runtime := range runtimes {
+		result := &JavaResult{Path: archive + "!/" + runtime.entry, Archive: archive, Release: runtime.release}
+		if result.Properties = releaseProperties(result.Release); result.Properties != nil {
+			result.Evaluated = true
+			result.Status = ProbeStatic
+		}
+		result.PathClass = f.classifier.classify(archive)
+		if f.checkModules {
+			f.checkRuntimeModules(result)
+		}
+		result.Replacement = recommendReplacement(f.replacements, result, f.sys.Clock.Now())
+		result.Latest = f.releases.latest(result)
+		result.Confidence, result.Evidence = scoreConfidence(result)
+		f.enrich(result)
+		results = append(results, result)
+	}
+	return results, nil
+}
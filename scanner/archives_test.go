@@ -0,0 +1,98 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeTestTarball creates a gzip compressed tarball of regular files
+func writeTestTarball(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for _, name := range slices.Sorted(maps.Keys(entries)) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(entries[name])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(entries[name]))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanArchives(t *testing.T) {
+	dir := t.TempDir()
+	writeTestTarball(t, filepath.Join(dir, "OpenJDK21U-jdk_x64_linux.tar.gz"), map[string]string{
+		"./jdk-21.0.5+11/bin/java": "ELF",
+		"./jdk-21.0.5+11/release":  "IMPLEMENTOR=\"Eclipse Adoptium\"\nJAVA_VERSION=\"21.0.5\"\nJAVA_VERSION_DATE=\"2024-10-15\"\n",
+	})
+	// The jre of a JDK 8 has no release file of its own
+	writeTestJar(t, filepath.Join(dir, "windows", "jdk8.zip"), map[string]string{
+		"jdk1.8.0_392/bin/java.exe":     "MZ",
+		"jdk1.8.0_392/jre/bin/java.exe": "MZ",
+		"jdk1.8.0_392/release":          "JAVA_VERSION=\"1.8.0_392\"\nIMPLEMENTOR=\"Azul Systems, Inc.\"\n",
+	})
+	writeTestJar(t, filepath.Join(dir, "sources.zip"), map[string]string{"src/java/Main.java": ""})
+	writeTestFile(t, filepath.Join(dir, "broken.tgz"), "not gzip")
+
+	finder := NewJavaFinder(dir, -1, false, false)
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("Expected no runtimes without -scan-archives, got %d", len(results))
+	}
+
+	finder = NewJavaFinder(dir, -1, false, false)
+	finder.archives = true
+	results, err = finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := buildJSONOutput(results, finder, finder.sys.Clock.Now())
+	want := map[string]string{
+		filepath.Join(dir, "OpenJDK21U-jdk_x64_linux.tar.gz") + "!/jdk-21.0.5+11/bin/java": "21.0.5",
+		filepath.Join(dir, "windows", "jdk8.zip") + "!/jdk1.8.0_392/bin/java.exe":          "1.8.0_392",
+	}
+	if len(report.Runtimes) != len(want) {
+		t.Fatalf("Expected %d packaged runtimes, got %+v", len(want), report.Runtimes)
+	}
+	for _, runtime := range report.Runtimes {
+		if version, ok := want[runtime.JavaExecutable]; !ok || runtime.JavaVersion != version || !runtime.Packaged || runtime.Archive == "" {
+			t.Errorf("Unexpected runtime %+v", runtime)
+		}
+	}
+}
+
+func TestArchiveTooLarge(t *testing.T) {
+	tests := []struct {
+		name string
+		size int64
+		want bool
+	}{
+		{"OpenJDK21U-jdk_x64_linux.tar.gz", 200 << 20, false},
+		{"backup.tar.gz", maxRuntimeTarball + 1, true},
+		{"layer.TGZ", 4 << 30, true},
+		{"jdk8.zip", 4 << 30, false},
+	}
+	for _, tt := range tests {
+		if got := archiveTooLarge(tt.name, tt.size); got != tt.want {
+			t.Errorf("archiveTooLarge(%q, %d) = %v, want %v", tt.name, tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Evaluation modes reported in the metadata
+const (
+	EvalModeExec      = "exec"
+	EvalModeNoExec    = "no-exec"
+	EvalModeThrottled = "throttled"
+	EvalModeRemote    = "ssh" // jfind remote-eval
+)
+
+// Reactions to real-time antivirus selected with -av-aware
+const (
+	AVAwareNoExec   = "no-exec"
+	AVAwareThrottle = "throttle"
+)
+
+// avThrottleInterval is the minimum time between two probes in throttled mode,
+// giving the on-access scanner time to finish with the previous runtime
+const avThrottleInterval = time.Second
+
+// avProcesses maps the process names of real-time antivirus agents to the
+// product. Linux truncates process names to 15 characters.
+var avProcesses = map[string]string{
+	"wdavdaemon":      "Microsoft Defender for Endpoint",
+	"falcon-sensor":   "CrowdStrike Falcon",
+	"falcond":         "CrowdStrike Falcon",
+	"clamonacc":       "ClamAV on-access scanning",
+	"s1-agent":        "SentinelOne",
+	"sentineld":       "SentinelOne",
+	"sophos_threat_d": "Sophos",
+	"ds_am":           "Trend Micro Deep Security",
+	"cbagentd":        "VMware Carbon Black",
+	"eset_daemon":     "ESET",
+}
+
+// matchAVProcess returns the product of a running process, if it is an antivirus agent
+func matchAVProcess(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if len(name) > 15 {
+		name = name[:15]
+	}
+	for process, product := range avProcesses {
+		if len(process) > 15 {
+			process = process[:15]
+		}
+		if name == process {
+			return product, true
+		}
+	}
+	return "", false
+}
+
+// probeThrottle spaces out probes so an on-access scanner is not flooded
+type probeThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the next probe may start. A nil throttle never waits.
+func (t *probeThrottle) wait() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if d := time.Until(t.next); d > 0 {
+		time.Sleep(d)
+	}
+	t.next = time.Now().Add(t.interval)
+}
+
+// releaseProperties derives the properties of a runtime from its release file,
+// for evaluation without executing it. Oracle JDK builds are marked with
+// BUILD_TYPE="commercial", which tells them apart from Oracle OpenJDK builds.
+func releaseProperties(release map[string]string) *JavaProperties {
+	version := release["JAVA_VERSION"]
+	if version == "" {
+		return nil
+	}
+	props := &JavaProperties{Version: version, Vendor: release["IMPLEMENTOR"], RuntimeName: "OpenJDK Runtime Environment"}
+	if release["BUILD_TYPE"] == "commercial" {
+		props.RuntimeName = "Java(TM) SE Runtime Environment"
+	}
+	props.Major, props.Update = parseJavaVersion(version)
+	return props
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// detectRealtimeAV returns the name of an active real-time antivirus product,
+// recognized by the process of its agent
+func detectRealtimeAV() string {
+	var names []string
+	switch runtime.GOOS {
+	case "linux":
+		comms, _ := filepath.Glob("/proc/[0-9]*/comm")
+		for _, comm := range comms {
+			if data, err := os.ReadFile(comm); err == nil {
+				names = append(names, string(data))
+			}
+		}
+	default:
+		output, err := exec.Command("ps", "-axco", "comm=").Output()
+		if err != nil {
+			return ""
+		}
+		names = strings.Split(string(output), "\n")
+	}
+	for _, name := range names {
+		if product, ok := matchAVProcess(name); ok {
+			return product
+		}
+	}
+	return ""
+}
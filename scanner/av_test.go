@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNoExecEvaluation(t *testing.T) {
+	root := t.TempDir()
+	oracle := createFakeJava(t, filepath.Join(root, "jdk-17-oracle"))
+	writeTestFile(t, filepath.Join(root, "jdk-17-oracle", "release"),
+		"IMPLEMENTOR=\"Oracle Corporation\"\nJAVA_VERSION=\"17.0.9\"\nBUILD_TYPE=\"commercial\"\n")
+	bare := createFakeJava(t, filepath.Join(root, "bare"))
+
+	// The fake executables would fail if they were run
+	finder := NewJavaFinder(root, -1, false, true)
+	finder.noExec = true
+
+	result := finder.newResult(context.Background(), oracle)
+	if result.Status != ProbeStatic || result.Properties == nil {
+		t.Fatalf("Expected static evaluation, got %+v", result)
+	}
+	if p := result.Properties; p.Major != 17 || p.Update != 9 || p.RuntimeName != "Java(TM) SE Runtime Environment" {
+		t.Errorf("Unexpected properties %+v", p)
+	}
+
+	result = finder.newResult(context.Background(), bare)
+	if result.Status != ProbeNotExecuted || result.Error != nil {
+		t.Errorf("Expected not_executed without release file, got %+v", result)
+	}
+	output := buildJSONOutput([]*JavaResult{result}, finder, time.Now())
+	if output.Runtimes[0].ExecFailed {
+		t.Error("A runtime that was not executed must not be reported as failed")
+	}
+}
+
+func TestNoExecPatchLevel(t *testing.T) {
+	root := t.TempDir()
+	oracle := createFakeJava(t, filepath.Join(root, "jdk-17-oracle"))
+	writeTestFile(t, filepath.Join(root, "jdk-17-oracle", "release"),
+		"IMPLEMENTOR=\"Oracle Corporation\"\nJAVA_VERSION=\"17.0.9\"\nBUILD_TYPE=\"commercial\"\n")
+
+	finder := NewJavaFinder(root, -1, false, true)
+	finder.noExec = true
+
+	// The release file is enough to rate the patch level
+	result := finder.newResult(context.Background(), oracle)
+	if runtime := buildJSONOutput([]*JavaResult{result}, finder, time.Now()).Runtimes[0]; runtime.CPURelease != "2023-10-17" || runtime.PatchLagQuarters == nil {
+		t.Errorf("Expected the patch level of the October 2023 CPU, got %q", runtime.CPURelease)
+	}
+}
+
+func TestMatchAVProcess(t *testing.T) {
+	if product, ok := matchAVProcess("wdavdaemon\n"); !ok || product != "Microsoft Defender for Endpoint" {
+		t.Errorf("Expected Defender for Endpoint, got %q", product)
+	}
+	// Linux truncates the name to 15 characters
+	if _, ok := matchAVProcess("sophos_threat_detector"); !ok {
+		t.Error("Expected a match of the untruncated Sophos process name")
+	}
+	if _, ok := matchAVProcess("bash"); ok {
+		t.Error("Expected no match for bash")
+	}
+}
+
+func TestProbeThrottle(t *testing.T) {
+	var none *probeThrottle
+	none.wait()
+
+	throttle := &probeThrottle{interval: 50 * time.Millisecond}
+	start := time.Now()
+	throttle.wait()
+	throttle.wait()
+	throttle.wait()
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected three probes to take at least 100ms, took %v", elapsed)
+	}
+}
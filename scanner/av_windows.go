@@ -0,0 +1,62 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// defenderProduct is reported when Microsoft Defender real-time protection is on
+const defenderProduct = "Microsoft Defender Antivirus"
+
+// detectRealtimeAV reports Microsoft Defender if its real-time protection is
+// enabled, neither turned off locally nor by group policy
+func detectRealtimeAV() string {
+	if _, ok := readRegistryDWORD(`SOFTWARE\Microsoft\Windows Defender`, "ProductStatus"); !ok {
+		if _, ok := readRegistryString(`SOFTWARE\Microsoft\Windows Defender`, "InstallLocation"); !ok {
+			return ""
+		}
+	}
+	for _, key := range []string{
+		`SOFTWARE\Microsoft\Windows Defender\Real-Time Protection`,
+		`SOFTWARE\Policies\Microsoft\Windows Defender\Real-Time Protection`,
+	} {
+		if disabled, ok := readRegistryDWORD(key, "DisableRealtimeMonitoring"); ok && disabled == 1 {
+			return ""
+		}
+	}
+	if disabled, ok := readRegistryDWORD(`SOFTWARE\Policies\Microsoft\Windows Defender`, "DisableAntiSpyware"); ok && disabled == 1 {
+		return ""
+	}
+	return defenderProduct
+}
+
+// readRegistryDWORD reads a DWORD value below HKEY_LOCAL_MACHINE
+func readRegistryDWORD(key, name string) (uint32, bool) {
+	keyPtr, err := syscall.UTF16PtrFromString(key)
+	if err != nil {
+		return 0, false
+	}
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, false
+	}
+
+	var handle syscall.Handle
+	if err := syscall.RegOpenKeyEx(syscall.HKEY_LOCAL_MACHINE, keyPtr, 0, syscall.KEY_READ, &handle); err != nil {
+		return 0, false
+	}
+	defer syscall.RegCloseKey(handle)
+
+	var valueType uint32
+	var value uint32
+	size := uint32(unsafe.Sizeof(value))
+	if err := syscall.RegQueryValueEx(handle, namePtr, nil, &valueType, (*byte)(unsafe.Pointer(&value)), &size); err != nil {
+		return 0, false
+	}
+	if valueType != syscall.REG_DWORD {
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,45 @@
+//go:build darwin
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// setiopolicy_np(IOPOL_TYPE_DISK, IOPOL_SCOPE_PROCESS, IOPOL_THROTTLE) via the iopolicysys syscall
+	iopolCmdSet       = 1
+	iopolTypeDisk     = 0
+	iopolScopeProcess = 0
+	iopolThrottle     = 3
+
+	// setpriority(PRIO_DARWIN_PROCESS, 0, PRIO_DARWIN_BG)
+	prioDarwinProcess = 4
+	prioDarwinBG      = 0x1000
+)
+
+// iopolParam is the _iopol_param_t argument of iopolicysys
+type iopolParam struct {
+	scope  int32
+	iotype int32
+	policy int32
+}
+
+// enterBackgroundMode throttles the disk I/O of jfind and moves it to the
+// background band, which also lowers its CPU priority. Probes inherit both.
+func enterBackgroundMode() (string, error) {
+	param := iopolParam{scope: iopolScopeProcess, iotype: iopolTypeDisk, policy: iopolThrottle}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOPOLICYSYS, iopolCmdSet, uintptr(unsafe.Pointer(&param)), 0); errno != 0 {
+		return "", fmt.Errorf("failed to set I/O policy: %v", errno)
+	}
+	if err := syscall.Setpriority(prioDarwinProcess, 0, prioDarwinBG); err != nil {
+		return "", fmt.Errorf("failed to enter the background band: %v", err)
+	}
+	return "I/O throttle, background band", nil
+}
+
+// backgroundCommand does nothing, probes inherit the priority on macOS
+func backgroundCommand(cmd *exec.Cmd) {}
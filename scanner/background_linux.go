@@ -0,0 +1,43 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassIdle  = 3
+	ioprioClassShift = 13
+)
+
+// enterBackgroundMode moves jfind to the idle I/O scheduling class (ionice -c 3)
+// and the lowest CPU priority. Both are per thread on Linux, so they are applied
+// to every thread; threads started later and probes inherit them.
+func enterBackgroundMode() (string, error) {
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return "", fmt.Errorf("failed to list threads: %v", err)
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), ioprioClassIdle<<ioprioClassShift); errno != 0 {
+			return "", fmt.Errorf("failed to set idle I/O class: %v", errno)
+		}
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, 19); err != nil {
+			return "", fmt.Errorf("failed to lower CPU priority: %v", err)
+		}
+	}
+	return "ionice idle, nice 19", nil
+}
+
+// backgroundCommand does nothing, probes inherit the priority on Linux
+func backgroundCommand(cmd *exec.Cmd) {}
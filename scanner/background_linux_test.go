@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestEnterBackgroundMode(t *testing.T) {
+	// Lowering the priority can't be undone, so it is lowered in a child process
+	// running this test, not in the test binary running the other tests
+	if os.Getenv("JFIND_TEST_BACKGROUND") == "" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestEnterBackgroundMode$", "-test.v")
+		cmd.Env = append(os.Environ(), "JFIND_TEST_BACKGROUND=1")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("Background mode failed: %v\n%s", err, output)
+		}
+		if strings.Contains(string(output), "--- SKIP") {
+			t.Skipf("Skipped in the child process:\n%s", output)
+		}
+		return
+	}
+
+	mode, err := enterBackgroundMode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode == "" {
+		t.Error("Expected a description of the background mode")
+	}
+
+	// Every thread must have been moved, not just the calling one
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func() {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			ioprio, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_GET, ioprioWhoProcess, 0, 0)
+			if errno != 0 || ioprio>>ioprioClassShift != ioprioClassIdle {
+				t.Errorf("Expected idle I/O class, got %d (%v)", ioprio>>ioprioClassShift, errno)
+			}
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+
+	// Probes inherit the priority
+	output, err := exec.Command("sh", "-c", "cut -d' ' -f19 /proc/self/stat").Output()
+	if err != nil {
+		t.Skipf("can't read the priority of a child process: %v", err)
+	}
+	if nice := strings.TrimSpace(string(output)); nice != "19" {
+		t.Errorf("Expected nice 19 in the child process, got %q", nice)
+	}
+}
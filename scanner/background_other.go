@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// enterBackgroundMode is not implemented on this platform
+func enterBackgroundMode() (string, error) {
+	return "", fmt.Errorf("-background is not supported on %s", runtime.GOOS)
+}
+
+// backgroundCommand does nothing on this platform
+func backgroundCommand(cmd *exec.Cmd) {}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+const (
+	// processModeBackgroundBegin lowers the I/O and memory priority of the process
+	processModeBackgroundBegin = 0x00100000
+	idlePriorityClass          = 0x00000040
+)
+
+var procSetPriorityClass = kernel32.NewProc("SetPriorityClass")
+
+// enterBackgroundMode switches jfind to background processing mode, which gives
+// it very low I/O priority and low memory and CPU priority
+func enterBackgroundMode() (string, error) {
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return "", err
+	}
+	if r, _, err := procSetPriorityClass.Call(uintptr(process), processModeBackgroundBegin); r == 0 {
+		return "", fmt.Errorf("failed to enter background mode: %v", err)
+	}
+	return "background processing mode", nil
+}
+
+// backgroundCommand starts a probe with idle priority, it does not inherit the
+// background processing mode
+func backgroundCommand(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= idlePriorityClass
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BaselineInfo reports the previous scan an incremental scan started from
+type BaselineInfo struct {
+	ScanID         string `json:"scan_id"`
+	ScanTimestamp  string `json:"scan_ts"`
+	CarriedForward int    `json:"carried_forward"` // runtimes not probed again
+	UnchangedDirs  int    `json:"unchanged_dirs"`  // directories not read again
+}
+
+// scanBaseline is the report of a previous scan (-baseline) and the index of
+// the directories its walk read. Directories that are unchanged since are not
+// read again, and the probe results of runtimes that are unchanged since are
+// carried forward instead of running them again.
+type scanBaseline struct {
+	scanID   string
+	time     time.Time
+	runtimes map[string]JavaRuntimeJSON // by java executable
+	carried  atomic.Int64
+
+	// index is the directory index of the previous walk, nil if there is none
+	index     *dirIndex
+	indexPath string
+	unchanged atomic.Int64
+
+	// walked is the directory index of this walk, saved to indexPath when it completes
+	mu     sync.Mutex
+	walked map[string]indexedDir
+}
+
+// dirIndexVersion is the version of the directory index format
+const dirIndexVersion = 1
+
+// changeTimeSlack is how long before the start of the previous walk a change
+// of a directory still has it read again. File systems keep times coarser
+// than the clock, FAT in steps of two seconds.
+const changeTimeSlack = 2 * time.Second
+
+// dirIndex lists the directories a walk read, with their subdirectories and
+// the files the walk looks at. A directory whose entries were neither added,
+// removed nor renamed since has the same entries, so the next walk takes them
+// from the index instead of reading the directory.
+type dirIndex struct {
+	Version int                   `json:"version"`
+	Walked  time.Time             `json:"walked"` // start of the walk
+	Names   string                `json:"names"`  // -names and -name-pattern of the walk
+	Dirs    map[string]indexedDir `json:"dirs"`
+}
+
+// indexedDir is a directory of the index
+type indexedDir struct {
+	Dirs  []string `json:"dirs,omitempty"`
+	Files []string `json:"files,omitempty"`
+}
+
+// names returns the entries of the directory in lexical order
+func (d indexedDir) names() []string {
+	names := slices.Concat(d.Dirs, d.Files)
+	slices.Sort(names)
+	return names
+}
+
+// indexedFile checks if the walk looks at a file, only those are kept in the
+// directory index
+func (f *JavaFinder) indexedFile(name string) bool {
+	return f.matchesName(name) || name == "release" || isRuntimeArchive(name) || isLibraryArchive(name) || isAppServerSignature(name)
+}
+
+// loadBaseline reads the report of a previous scan
+func loadBaseline(path string) (*scanBaseline, error) {
+	report, err := loadReport(path)
+	if err != nil {
+		return nil, err
+	}
+	scanned, err := time.Parse(time.RFC3339, report.Meta.ScanTimestamp)
+	if err != nil {
+		return nil, fmt.Errorf("baseline %s has no valid scan_ts: %v", path, err)
+	}
+	b := &scanBaseline{scanID: report.Meta.ScanID, time: scanned, runtimes: make(map[string]JavaRuntimeJSON)}
+	for _, runtime := range report.Runtimes {
+		b.runtimes[runtime.JavaExecutable] = runtime
+		for _, alias := range runtime.Aliases {
+			b.runtimes[alias] = runtime
+		}
+	}
+
+	// The first incremental scan has no index yet and reads every directory
+	b.indexPath = path + ".dirs"
+	data, err := os.ReadFile(b.indexPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read the directory index of the baseline: %v", err)
+	}
+	if err == nil {
+		var index dirIndex
+		if err := json.Unmarshal(data, &index); err != nil || index.Version != dirIndexVersion {
+			logf("Warning: ignoring the directory index %s, it is invalid or of another version\n", b.indexPath)
+		} else {
+			b.index = &index
+		}
+	}
+	return b, nil
+}
+
+// unchangedDir returns the entries of a directory from the index of the
+// previous walk if it was read then and its change time, which every added,
+// removed or renamed entry sets, is not after the start of that walk. An index
+// of a walk looking for other names is not used.
+func (f *JavaFinder) unchangedDir(dir queuedDir) ([]string, bool) {
+	b := f.baseline
+	if b == nil || b.index == nil || b.index.Names != f.names.String() {
+		return nil, false
+	}
+	entry, ok := b.index.Dirs[dir.path]
+	if !ok || changeTime(dir.info).After(b.index.Walked.Add(-changeTimeSlack)) {
+		return nil, false
+	}
+	b.unchanged.Add(1)
+	return entry.names(), true
+}
+
+// indexDir records a directory read by the walk in the index of this walk
+func (b *scanBaseline) indexDir(path string, entry indexedDir) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.walked[path] = entry
+}
+
+// saveIndex writes the index of a completed walk that started at walked next
+// to the baseline, replacing that of the previous walk
+func (b *scanBaseline) saveIndex(walked time.Time, names string) error {
+	b.mu.Lock()
+	data, err := json.Marshal(dirIndex{Version: dirIndexVersion, Walked: walked.UTC(), Names: names, Dirs: b.walked})
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(b.indexPath), filepath.Base(b.indexPath)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write the directory index: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the directory index: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the directory index: %v", err)
+	}
+	return os.Rename(tmp.Name(), b.indexPath)
+}
+
+// carry returns the probe result of the baseline for a java executable if it
+// was probed successfully, still resolves to the same executable and neither
+// the executable, the one it resolves to, their directories nor the release
+// file of the home changed since the baseline scan. The change time is used,
+// which unlike the modification time cannot be set back by unpacking an
+// archive. Failed probes are always run again.
+func (f *JavaFinder) carry(path string) (JavaResult, bool) {
+	b := f.baseline
+	if b == nil {
+		return JavaResult{}, false
+	}
+	runtime, ok := b.runtimes[path]
+	if !ok || ProbeStatus(runtime.ProbeStatus) != ProbeOK || runtime.JavaVersion == "" {
+		return JavaResult{}, false
+	}
+	if f.heapProbe && runtime.MaxHeap == 0 || f.captureBytes > 0 && runtime.ProbeOutput == nil {
+		// The baseline scan did not probe what this one does
+		return JavaResult{}, false
+	}
+	// An update behind a link, e.g. of the alternatives /usr/bin/java links
+	// to, changes the executable it resolves to but not the link
+	names := []string{path, filepath.Dir(path)}
+	target := path
+	if resolved, err := filepath.EvalSymlinks(path); err == nil && resolved != path {
+		if resolved != runtime.JavaExecutable && !slices.Contains(runtime.Aliases, resolved) {
+			return JavaResult{}, false
+		}
+		target = resolved
+		names = append(names, target, filepath.Dir(target))
+	}
+	if home, found := findJavaHome(f.sys.FS, target); found {
+		names = append(names, filepath.Join(home, "release"))
+	}
+	for _, name := range names {
+		info, err := f.sys.FS.Lstat(name)
+		if err != nil || changeTime(info).After(b.time) {
+			return JavaResult{}, false
+		}
+	}
+	b.carried.Add(1)
+	return JavaResult{
+		Path:      path,
+		Evaluated: true,
+		Status:    ProbeOK,
+		Properties: &JavaProperties{
+			Version:          runtime.JavaVersion,
+			Vendor:           runtime.JavaVendor,
+			RuntimeName:      runtime.JavaRuntime,
+			Major:            runtime.VersionMajor,
+			Update:           runtime.VersionUpdate,
+			VMName:           runtime.JavaVM,
+			VMVersion:        runtime.JavaVMVersion,
+			InitialHeap:      runtime.InitialHeap,
+			MaxHeap:          runtime.MaxHeap,
+			ContainerSupport: runtime.ContainerSupport,
+		},
+		Startup: runtime.StartupBenchmark,
+		Output:  runtime.ProbeOutput,
+	}, true
+}
+
+// reset starts counting the runtimes carried forward and the directories not
+// read again by a new scan, and its directory index
+func (b *scanBaseline) reset() {
+	if b != nil {
+		b.carried.Store(0)
+		b.unchanged.Store(0)
+		b.mu.Lock()
+		b.walked = make(map[string]indexedDir)
+		b.mu.Unlock()
+	}
+}
+
+// info returns the baseline for the report
+func (b *scanBaseline) info() *BaselineInfo {
+	if b == nil {
+		return nil
+	}
+	return &BaselineInfo{
+		ScanID:         b.scanID,
+		ScanTimestamp:  b.time.UTC().Format(time.RFC3339),
+		CarriedForward: int(b.carried.Load()),
+		UnchangedDirs:  int(b.unchanged.Load()),
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBaselineCarriesUnchangedRuntimes(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	unchanged := filepath.Join(root, "jdk-17", "bin", javaExecutableName())
+	updated := filepath.Join(root, "jdk-21", "bin", javaExecutableName())
+	scanned := time.Date(2025, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	baseline := JSONOutput{
+		Meta: MetaInfo{ScanID: "previous", ScanTimestamp: scanned.Format(time.RFC3339)},
+		Runtimes: []JavaRuntimeJSON{
+			{JavaExecutable: unchanged, JavaVersion: "17.0.9", JavaVendor: "Eclipse Adoptium", VersionMajor: 17, VersionUpdate: 9, ProbeStatus: string(ProbeOK)},
+			{JavaExecutable: updated, JavaVersion: "21.0.1", JavaVendor: "Eclipse Adoptium", VersionMajor: 21, VersionUpdate: 1, ProbeStatus: string(ProbeOK)},
+		},
+	}
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "previous.json")
+	writeTestFile(t, file, string(data))
+
+	fsys := fakeFileSystem{}
+	fsys.add(unchanged, 0755, "")
+	fsys.add(updated, 0755, "")
+	// The JDK 21 was updated in place after the baseline scan
+	fsys[filepath.Dir(updated)] = fakeFile{mode: fsys[filepath.Dir(updated)].mode, modTime: scanned.Add(time.Hour)}
+
+	finder := NewJavaFinder(root, -1, false, true)
+	finder.SetSystem(System{FS: fsys, Exec: fakeExecutor{
+		unchanged: "    java.version = 17.0.99\n",
+		updated:   "    java.version = 21.0.5\n    java.vendor = Eclipse Adoptium\n",
+	}})
+	if finder.baseline, err = loadBaseline(file); err != nil {
+		t.Fatal(err)
+	}
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := make(map[string]string)
+	for _, result := range results {
+		versions[result.Path] = result.Properties.Version
+	}
+	if versions[unchanged] != "17.0.9" {
+		t.Errorf("Expected the version of the baseline for the unchanged runtime, got %s", versions[unchanged])
+	}
+	if versions[updated] != "21.0.5" {
+		t.Errorf("Expected the updated runtime to be probed again, got %s", versions[updated])
+	}
+
+	info := buildJSONOutput(results, finder, time.Now()).Meta.Baseline
+	if info == nil || info.ScanID != "previous" || info.CarriedForward != 1 {
+		t.Errorf("Expected the baseline with one runtime carried forward, got %+v", info)
+	}
+}
+
+func TestBaselineChecksResolvedExecutable(t *testing.T) {
+	dir := t.TempDir()
+	jdk11 := createFakeJava(t, filepath.Join(dir, "jdk-11"))
+	jdk17 := createFakeJava(t, filepath.Join(dir, "jdk-17"))
+	writeTestFile(t, filepath.Join(dir, "jdk-17", "release"), `JAVA_VERSION="17.0.9"`+"\n")
+	link := filepath.Join(dir, "alternatives", javaExecutableName())
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(jdk11, link); err != nil {
+		t.Skip("symbolic links are not supported:", err)
+	}
+	resolved, _ := filepath.EvalSymlinks(jdk11)
+	finder := NewJavaFinder(dir, -1, false, true)
+	finder.baseline = &scanBaseline{time: time.Now().Add(time.Hour), runtimes: map[string]JavaRuntimeJSON{
+		link: {JavaExecutable: resolved, JavaVersion: "11.0.21", ProbeStatus: string(ProbeOK)},
+	}}
+	if _, ok := finder.carry(link); !ok {
+		t.Fatal("Expected the result of the unchanged link to be carried forward")
+	}
+
+	// The link now resolves to another runtime, e.g. after update-alternatives
+	os.Remove(link)
+	if err := os.Symlink(jdk17, link); err != nil {
+		t.Fatal(err)
+	}
+	if result, ok := finder.carry(link); ok {
+		t.Errorf("Expected the link to another runtime to be probed again, got %+v", result.Properties)
+	}
+}
+
+func TestBaselineChecksReleaseFile(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	java := filepath.Join(root, "jdk-17", "bin", javaExecutableName())
+	release := filepath.Join(root, "jdk-17", "release")
+	scanned := time.Date(2025, 6, 1, 2, 0, 0, 0, time.UTC)
+	fsys := fakeFileSystem{}
+	fsys.add(java, 0755, "")
+	fsys.add(release, 0644, `JAVA_VERSION="17.0.10"`+"\n")
+	// The home was updated in place after the baseline scan, the executable was not
+	fsys[release] = fakeFile{mode: fsys[release].mode, content: fsys[release].content, modTime: scanned.Add(time.Hour)}
+
+	finder := NewJavaFinder(root, -1, false, true)
+	finder.SetSystem(System{FS: fsys})
+	finder.baseline = &scanBaseline{time: scanned, runtimes: map[string]JavaRuntimeJSON{
+		java: {JavaExecutable: java, JavaVersion: "17.0.9", ProbeStatus: string(ProbeOK)},
+	}}
+	if _, ok := finder.carry(java); ok {
+		t.Error("Expected a runtime whose release file changed to be probed again")
+	}
+}
+
+// readCountingFS records the directories read from a fake file system
+type readCountingFS struct {
+	fakeFileSystem
+	read *[]string
+}
+
+func (m readCountingFS) ReadDirNames(name string) ([]string, error) {
+	*m.read = append(*m.read, name)
+	return m.fakeFileSystem.ReadDirNames(name)
+}
+
+func TestBaselineReadsChangedDirectoriesOnly(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	jdk17 := filepath.Join(root, "opt", "jdk-17", "bin", javaExecutableName())
+	jdk21 := filepath.Join(root, "srv", "app", "jdk-21", "bin", javaExecutableName())
+	scanned := time.Date(2025, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	baseline := JSONOutput{
+		Meta: MetaInfo{ScanID: "previous", ScanTimestamp: scanned.Format(time.RFC3339)},
+		Runtimes: []JavaRuntimeJSON{
+			{JavaExecutable: jdk17, JavaVersion: "17.0.9", JavaVendor: "Eclipse Adoptium", VersionMajor: 17, VersionUpdate: 9, ProbeStatus: string(ProbeOK)},
+		},
+	}
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "previous.json")
+	writeTestFile(t, file, string(data))
+
+	fsys := fakeFileSystem{}
+	fsys.add(jdk17, 0755, "")
+	fsys.add(filepath.Join(root, "home", "user", "docs", "notes.txt"), 0644, "")
+	fsys.add(filepath.Join(root, "srv", "app", "app.jar"), 0644, "")
+	var read []string
+	finder := NewJavaFinder(root, -1, false, true)
+	finder.SetSystem(System{FS: readCountingFS{fakeFileSystem: fsys, read: &read}, Exec: fakeExecutor{
+		jdk21: "    java.version = 21.0.5\n    java.vendor = Eclipse Adoptium\n",
+	}})
+
+	// Without an index of a previous walk, every directory is read and indexed
+	if finder.baseline, err = loadBaseline(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := finder.Find(); err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 9 {
+		t.Fatalf("Expected all 9 directories to be read, got %v", read)
+	}
+	if _, err := os.Stat(file + ".dirs"); err != nil {
+		t.Fatalf("Expected the directory index next to the baseline: %v", err)
+	}
+
+	// A JDK unpacked into /srv/app changes that directory only
+	fsys.add(jdk21, 0755, "")
+	fsys[filepath.Join(root, "srv", "app")] = fakeFile{mode: fsys[root].mode, modTime: time.Now().Add(time.Hour)}
+	read = nil
+	if finder.baseline, err = loadBaseline(file); err != nil {
+		t.Fatal(err)
+	}
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(root, "srv", "app"), filepath.Dir(filepath.Dir(jdk21)), filepath.Dir(jdk21)}
+	if !slices.Equal(read, want) {
+		t.Errorf("Expected only %v to be read, got %v", want, read)
+	}
+	versions := make(map[string]string)
+	for _, result := range results {
+		versions[result.Path] = result.Properties.Version
+	}
+	if versions[jdk17] != "17.0.9" || versions[jdk21] != "21.0.5" {
+		t.Errorf("Expected the runtime of the baseline and the new one, got %v", versions)
+	}
+	info := buildJSONOutput(results, finder, time.Now()).Meta.Baseline
+	if info == nil || info.CarriedForward != 1 || info.UnchangedDirs != 8 {
+		t.Errorf("Expected one runtime carried forward and 8 directories not read again, got %+v", info)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// StartupBenchmark is the time java -version takes to start and exit
+type StartupBenchmark struct {
+	Runs     int     `json:"runs"`
+	MedianMS float64 `json:"median_ms"`
+	MinMS    float64 `json:"min_ms"`
+	MaxMS    float64 `json:"max_ms"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// benchmarkStartup runs java -version the given number of times in one probe
+// sandbox and measures each run. The first failing run ends the benchmark.
+func (f *JavaFinder) benchmarkStartup(ctx context.Context, javaPath string, runs int) *StartupBenchmark {
+	sandbox, err := newProbeSandbox()
+	if err != nil {
+		return &StartupBenchmark{Error: fmt.Sprintf("failed to create probe sandbox: %v", err)}
+	}
+	defer sandbox.cleanup()
+
+	var durations []time.Duration
+	for i := 0; i < runs; i++ {
+		f.throttle.wait()
+		name, args := f.evalCmd.buildProbe(javaPath, "-version")
+		cmd := probeCommand(ctx, name, args...)
+		sandbox.apply(cmd)
+		if f.background != "" {
+			backgroundCommand(cmd)
+		}
+		start := time.Now()
+		if err := f.runProbe(cmd); err != nil {
+			return &StartupBenchmark{Runs: len(durations), Error: err.Error()}
+		}
+		durations = append(durations, time.Since(start))
+	}
+	return summarizeStartup(durations)
+}
+
+// summarizeStartup returns the median, minimum and maximum of the run times in
+// milliseconds; the median of an even number of runs is the mean of the middle two
+func summarizeStartup(durations []time.Duration) *StartupBenchmark {
+	b := &StartupBenchmark{Runs: len(durations)}
+	if len(durations) == 0 {
+		return b
+	}
+	sorted := slices.Sorted(slices.Values(durations))
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + median) / 2
+	}
+	b.MedianMS = milliseconds(median)
+	b.MinMS = milliseconds(sorted[0])
+	b.MaxMS = milliseconds(sorted[len(sorted)-1])
+	return b
+}
+
+// milliseconds converts a duration to milliseconds rounded to a tenth
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*10) / 10
+}
+
+// formatMS formats milliseconds for the text output
+func formatMS(ms float64) string {
+	return strconv.FormatFloat(ms, 'f', 1, 64)
+}
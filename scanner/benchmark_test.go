@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSummarizeStartup(t *testing.T) {
+	ms := time.Millisecond
+	b := summarizeStartup([]time.Duration{90 * ms, 40 * ms, 50*ms + 250*time.Microsecond})
+	if b.Runs != 3 || b.MedianMS != 50.3 || b.MinMS != 40 || b.MaxMS != 90 {
+		t.Errorf("Unexpected summary of an odd number of runs: %+v", b)
+	}
+	if b := summarizeStartup([]time.Duration{40 * ms, 90 * ms, 50 * ms, 60 * ms}); b.MedianMS != 55 {
+		t.Errorf("Expected the mean of the middle runs, got %+v", b)
+	}
+	if b := summarizeStartup(nil); b.Runs != 0 || b.MedianMS != 0 {
+		t.Errorf("Expected an empty summary, got %+v", b)
+	}
+}
+
+func TestBenchmarkStartup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake java executable is a shell script")
+	}
+	dir := t.TempDir()
+	java := createFakeJava(t, filepath.Join(dir, "jdk-17"))
+	finder := NewJavaFinder(dir, -1, false, false)
+	finder.benchmarkRuns = 3
+
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := results[0].Startup; b == nil || b.Runs != 3 || b.Error != "" || b.MinMS > b.MedianMS || b.MedianMS > b.MaxMS {
+		t.Errorf("Expected 3 timed runs, got %+v", b)
+	}
+
+	if err := os.WriteFile(java, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if b := finder.benchmarkStartup(context.Background(), java, 3); b.Runs != 0 || b.Error == "" {
+		t.Errorf("Expected the failing run to end the benchmark, got %+v", b)
+	}
+}
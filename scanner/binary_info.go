@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// binaryInfo describes the executable format of a java binary
+type binaryInfo struct {
+	Format    string   // elf, pe or macho
+	Arch      string   // architecture using GOARCH names, e.g. amd64, 386, arm64
+	Bits      int      // 32 or 64
+	Libraries []string // shared libraries the binary depends on
+	RPath     []string // library search path embedded in the binary (ELF only)
+}
+
+// elfArchs maps ELF machine types to GOARCH names
+var elfArchs = map[elf.Machine]string{
+	elf.EM_X86_64:  "amd64",
+	elf.EM_386:     "386",
+	elf.EM_AARCH64: "arm64",
+	elf.EM_ARM:     "arm",
+	elf.EM_RISCV:   "riscv64",
+	elf.EM_PPC64:   "ppc64le",
+	elf.EM_S390:    "s390x",
+}
+
+// peArchs maps PE machine types to GOARCH names
+var peArchs = map[uint16]string{
+	pe.IMAGE_FILE_MACHINE_AMD64: "amd64",
+	pe.IMAGE_FILE_MACHINE_I386:  "386",
+	pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+	pe.IMAGE_FILE_MACHINE_ARMNT: "arm",
+}
+
+// machoArchs maps Mach-O CPU types to GOARCH names
+var machoArchs = map[macho.Cpu]string{
+	macho.CpuAmd64: "amd64",
+	macho.Cpu386:   "386",
+	macho.CpuArm64: "arm64",
+	macho.CpuArm:   "arm",
+}
+
+// inspectBinary reads the headers of an executable without running it
+func inspectBinary(path string) (*binaryInfo, error) {
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		info := &binaryInfo{Format: "elf", Arch: elfArchs[f.Machine], Bits: 64}
+		if f.Class == elf.ELFCLASS32 {
+			info.Bits = 32
+		}
+		if f.Machine == elf.EM_PPC64 && f.Data == elf.ELFDATA2MSB {
+			info.Arch = "ppc64"
+		}
+		info.Libraries, _ = f.ImportedLibraries()
+		for _, tag := range []elf.DynTag{elf.DT_RUNPATH, elf.DT_RPATH} {
+			if values, err := f.DynString(tag); err == nil {
+				for _, v := range values {
+					info.RPath = append(info.RPath, filepath.SplitList(v)...)
+				}
+			}
+		}
+		return info, nil
+	}
+
+	if f, err := pe.Open(path); err == nil {
+		defer f.Close()
+		info := &binaryInfo{Format: "pe", Arch: peArchs[f.FileHeader.Machine], Bits: 64}
+		if _, ok := f.OptionalHeader.(*pe.OptionalHeader32); ok {
+			info.Bits = 32
+		}
+		info.Libraries, _ = f.ImportedLibraries()
+		return info, nil
+	}
+
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		info := &binaryInfo{Format: "macho", Arch: machoArchs[f.Cpu], Bits: 64}
+		if f.Magic == macho.Magic32 {
+			info.Bits = 32
+		}
+		info.Libraries, _ = f.ImportedLibraries()
+		return info, nil
+	}
+
+	if f, err := macho.OpenFat(path); err == nil {
+		defer f.Close()
+		// Universal binary: report the slice matching the host if there is one
+		arch := f.Arches[0]
+		for _, a := range f.Arches {
+			if machoArchs[a.Cpu] == runtime.GOARCH {
+				arch = a
+			}
+		}
+		info := &binaryInfo{Format: "macho", Arch: machoArchs[arch.Cpu], Bits: 64}
+		if arch.Magic == macho.Magic32 {
+			info.Bits = 32
+		}
+		info.Libraries, _ = arch.ImportedLibraries()
+		return info, nil
+	}
+
+	return nil, fmt.Errorf("unknown executable format")
+}
+
+// hostBits returns the word size of the host architecture
+func hostBits() int {
+	switch runtime.GOARCH {
+	case "386", "arm", "mips", "mipsle", "wasm":
+		return 32
+	}
+	return 64
+}
+
+// is32BitOnHost checks if a binary is a 32-bit build running on a 64-bit host
+func (b *binaryInfo) is32BitOnHost() bool {
+	return b.Bits == 32 && hostBits() == 64
+}
+
+// dependencyIssues checks if the shared libraries of a binary can be resolved,
+// similar to ldd but without executing anything. Only binaries in the native
+// format of the host are checked.
+func (b *binaryInfo) dependencyIssues(binaryPath string) []string {
+	var issues []string
+	switch {
+	case b.Format == "elf" && runtime.GOOS == "linux":
+		for _, lib := range b.Libraries {
+			if !resolveELFLibrary(lib, binaryPath, b.RPath, b.Bits) {
+				issues = append(issues, "missing shared library "+lib)
+			}
+		}
+	case b.Format == "pe" && runtime.GOOS == "windows":
+		for _, lib := range b.Libraries {
+			if !resolvePELibrary(lib, binaryPath, b.Bits) {
+				issues = append(issues, "missing DLL "+lib)
+			}
+		}
+	case b.Format == "macho" && runtime.GOOS == "darwin":
+		// System libraries live in the dyld shared cache and not on disk,
+		// so only libraries relative to the executable can be checked
+		dir := filepath.Dir(binaryPath)
+		for _, lib := range b.Libraries {
+			for _, prefix := range []string{"@executable_path/", "@loader_path/"} {
+				if rel, ok := strings.CutPrefix(lib, prefix); ok {
+					if _, err := os.Stat(filepath.Join(dir, rel)); err != nil {
+						issues = append(issues, "missing shared library "+lib)
+					}
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// resolveELFLibrary searches a shared library the way the dynamic linker does
+func resolveELFLibrary(lib, binaryPath string, rpath []string, bits int) bool {
+	if strings.Contains(lib, "/") {
+		return elfLibraryMatches(lib, bits)
+	}
+
+	origin := filepath.Dir(binaryPath)
+	var dirs []string
+	for _, p := range rpath {
+		p = strings.ReplaceAll(p, "${ORIGIN}", origin)
+		dirs = append(dirs, strings.ReplaceAll(p, "$ORIGIN", origin))
+	}
+	dirs = append(dirs, filepath.SplitList(os.Getenv("LD_LIBRARY_PATH"))...)
+	dirs = append(dirs, systemLibraryDirs()...)
+
+	for _, dir := range dirs {
+		if dir != "" && elfLibraryMatches(filepath.Join(dir, lib), bits) {
+			return true
+		}
+	}
+	return false
+}
+
+// elfLibraryMatches checks if path is an ELF library of the requested word size
+func elfLibraryMatches(path string, bits int) bool {
+	f, err := elf.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	return (f.Class == elf.ELFCLASS32) == (bits == 32)
+}
+
+var (
+	libraryDirsOnce sync.Once
+	libraryDirs     []string
+)
+
+// systemLibraryDirs returns the default library directories of the dynamic linker,
+// including the directories configured in /etc/ld.so.conf
+func systemLibraryDirs() []string {
+	libraryDirsOnce.Do(func() {
+		libraryDirs = readLdSoConf("/etc/ld.so.conf", 0)
+		libraryDirs = append(libraryDirs,
+			"/lib", "/usr/lib", "/lib64", "/usr/lib64", "/lib32", "/usr/lib32",
+			"/usr/local/lib")
+	})
+	return libraryDirs
+}
+
+// readLdSoConf parses an ld.so.conf file and the files it includes
+func readLdSoConf(path string, depth int) []string {
+	if depth > 4 {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var dirs []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		if line == "" {
+			continue
+		}
+		if pattern, ok := strings.CutPrefix(line, "include"); ok {
+			pattern = strings.TrimSpace(pattern)
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(path), pattern)
+			}
+			matches, _ := filepath.Glob(pattern)
+			for _, match := range matches {
+				dirs = append(dirs, readLdSoConf(match, depth+1)...)
+			}
+			continue
+		}
+		dirs = append(dirs, line)
+	}
+	return dirs
+}
+
+// resolvePELibrary searches a DLL in the standard Windows search order
+func resolvePELibrary(lib, binaryPath string, bits int) bool {
+	lower := strings.ToLower(lib)
+	// API sets are virtual DLLs resolved by the loader
+	if strings.HasPrefix(lower, "api-ms-") || strings.HasPrefix(lower, "ext-ms-") {
+		return true
+	}
+
+	windir := os.Getenv("SystemRoot")
+	if windir == "" {
+		windir = `C:\Windows`
+	}
+	systemDir := filepath.Join(windir, "System32")
+	if bits == 32 && hostBits() == 64 {
+		systemDir = filepath.Join(windir, "SysWOW64")
+	}
+
+	dirs := []string{filepath.Dir(binaryPath), systemDir, windir}
+	dirs = append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, lib)); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestInspectBinary(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip("test executable not available")
+	}
+
+	info, err := inspectBinary(exe)
+	if err != nil {
+		t.Fatalf("Failed to inspect test binary: %v", err)
+	}
+	if info.Arch != runtime.GOARCH {
+		t.Errorf("Expected arch %s, got %s", runtime.GOARCH, info.Arch)
+	}
+	if info.is32BitOnHost() {
+		t.Error("Test binary must not be reported as 32-bit on 64-bit host")
+	}
+
+	script := filepath.Join(t.TempDir(), "java")
+	os.WriteFile(script, []byte("#!/bin/sh\n"), 0755)
+	if _, err := inspectBinary(script); err == nil {
+		t.Error("Expected error for shell script")
+	}
+}
+
+func TestReadLdSoConf(t *testing.T) {
+	dir := t.TempDir()
+	confDir := filepath.Join(dir, "ld.so.conf.d")
+	os.Mkdir(confDir, 0755)
+	os.WriteFile(filepath.Join(dir, "ld.so.conf"), []byte("# comment\ninclude ld.so.conf.d/*.conf\n/opt/lib\n"), 0644)
+	os.WriteFile(filepath.Join(confDir, "x86_64.conf"), []byte("/lib/x86_64-linux-gnu\n/usr/lib/x86_64-linux-gnu # multiarch\n"), 0644)
+
+	got := readLdSoConf(filepath.Join(dir, "ld.so.conf"), 0)
+	want := []string{"/lib/x86_64-linux-gnu", "/usr/lib/x86_64-linux-gnu", "/opt/lib"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
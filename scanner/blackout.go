@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BlackoutWindow is a recurring time span during which jfind serve defers scheduled scans,
+// e.g. business hours or a month-end freeze of the storage systems
+type BlackoutWindow struct {
+	Name string `json:"name"`
+
+	// Days limits the window to weekdays (mon, tue, ...), MonthDays to days of the
+	// month where -1 is the last day. The window applies every day if both are empty.
+	Days      []string `json:"days,omitempty"`
+	MonthDays []int    `json:"month_days,omitempty"`
+
+	// Start and End are times of day like 08:00, an End before Start ends the next day
+	Start string `json:"start"`
+	End   string `json:"end"`
+
+	// Timezone is an IANA time zone name, default is the local time zone
+	Timezone string `json:"timezone,omitempty"`
+
+	weekdays map[time.Weekday]bool
+	start    time.Duration
+	end      time.Duration
+	location *time.Location
+}
+
+// Deferral records why a scheduled scan did not start on time
+type Deferral struct {
+	Reason      string `json:"reason"`
+	ScheduledAt string `json:"scheduled_at"`
+	Until       string `json:"until"`
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parse validates the window and prepares it for matching
+func (w *BlackoutWindow) parse() error {
+	if w.Name == "" {
+		return fmt.Errorf("blackout window without name")
+	}
+	var err error
+	if w.start, err = parseTimeOfDay(w.Start); err != nil {
+		return fmt.Errorf("invalid start of blackout window %q: %v", w.Name, err)
+	}
+	if w.end, err = parseTimeOfDay(w.End); err != nil {
+		return fmt.Errorf("invalid end of blackout window %q: %v", w.Name, err)
+	}
+	if w.start == w.end {
+		return fmt.Errorf("blackout window %q is empty", w.Name)
+	}
+
+	w.weekdays = make(map[time.Weekday]bool)
+	for _, day := range w.Days {
+		weekday, ok := weekdayNames[strings.ToLower(day)]
+		if !ok {
+			return fmt.Errorf("invalid day %q in blackout window %q", day, w.Name)
+		}
+		w.weekdays[weekday] = true
+	}
+	for _, day := range w.MonthDays {
+		if day == 0 || day < -31 || day > 31 {
+			return fmt.Errorf("invalid day of month %d in blackout window %q", day, w.Name)
+		}
+	}
+
+	w.location = time.Local
+	if w.Timezone != "" {
+		if w.location, err = time.LoadLocation(w.Timezone); err != nil {
+			return fmt.Errorf("invalid time zone of blackout window %q: %v", w.Name, err)
+		}
+	}
+	return nil
+}
+
+// parseTimeOfDay parses HH:MM, 24:00 is the end of the day
+func parseTimeOfDay(s string) (time.Duration, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(s, "%d:%d", &hours, &minutes); err != nil || len(s) != 5 {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	if hours < 0 || minutes < 0 || minutes > 59 || hours*60+minutes > 24*60 {
+		return 0, fmt.Errorf("%q is not a time of day", s)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// appliesOn checks if the window starts on the given day
+func (w *BlackoutWindow) appliesOn(day time.Time) bool {
+	if len(w.weekdays) > 0 && !w.weekdays[day.Weekday()] {
+		return false
+	}
+	if len(w.MonthDays) == 0 {
+		return true
+	}
+	// Day 0 of the next month is the last day of this month
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, d := range w.MonthDays {
+		if d == day.Day() || (d < 0 && daysInMonth+d+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// activeUntil returns the end of the occurrence of the window that covers t, if any
+func (w *BlackoutWindow) activeUntil(t time.Time) (time.Time, bool) {
+	local := t.In(w.location)
+	// An occurrence that started yesterday may still be running
+	for offset := -1; offset <= 0; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, w.location)
+		if !w.appliesOn(day) {
+			continue
+		}
+		start := clockTime(day, w.start)
+		end := clockTime(day, w.end)
+		if w.end < w.start {
+			end = clockTime(day.AddDate(0, 0, 1), w.end)
+		}
+		if !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// clockTime returns the wall clock time of day on the given day, which is not
+// the same as adding a duration to midnight on days with a DST change
+func clockTime(day time.Time, timeOfDay time.Duration) time.Time {
+	minutes := int(timeOfDay / time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
+
+// maxBlackout stops following adjoining windows, e.g. of a window that covers every day
+const maxBlackout = 31 * 24 * time.Hour
+
+// activeBlackout returns the window covering t and the time when no window covers
+// it anymore, following windows that overlap or adjoin each other
+func activeBlackout(windows []BlackoutWindow, t time.Time) (*BlackoutWindow, time.Time) {
+	var active *BlackoutWindow
+	until := t
+	for extended := true; extended && until.Sub(t) < maxBlackout; {
+		extended = false
+		for i := range windows {
+			if end, ok := windows[i].activeUntil(until); ok && end.After(until) {
+				if active == nil {
+					active = &windows[i]
+				}
+				until = end
+				extended = true
+			}
+		}
+	}
+	return active, until
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBlackoutWindows(t *testing.T) {
+	windows := []BlackoutWindow{
+		{Name: "business hours", Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "08:00", End: "18:00", Timezone: "UTC"},
+		{Name: "batch", Start: "22:00", End: "02:00", Timezone: "UTC"},
+		{Name: "month-end freeze", MonthDays: []int{-1}, Start: "18:00", End: "22:00", Timezone: "UTC"},
+	}
+	for i := range windows {
+		if err := windows[i].parse(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		at     string
+		window string
+		until  string
+	}{
+		{"weekday business hours", "2025-03-05T09:30:00Z", "business hours", "2025-03-05T18:00:00Z"},
+		{"weekend", "2025-03-08T09:30:00Z", "", ""},
+		{"evening", "2025-03-05T19:00:00Z", "", ""},
+		{"overnight after midnight", "2025-03-06T01:00:00Z", "batch", "2025-03-06T02:00:00Z"},
+		{"window end is exclusive", "2025-03-06T02:00:00Z", "", ""},
+		// Business hours, month-end freeze and batch window follow each other on Feb 28
+		{"adjoining windows", "2025-02-28T17:00:00Z", "business hours", "2025-03-01T02:00:00Z"},
+		{"no freeze before month end", "2025-02-27T19:00:00Z", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, _ := time.Parse(time.RFC3339, tt.at)
+			window, until := activeBlackout(windows, at)
+			if tt.window == "" {
+				if window != nil {
+					t.Fatalf("Expected no blackout, got %q until %s", window.Name, until)
+				}
+				return
+			}
+			if window == nil || window.Name != tt.window {
+				t.Fatalf("Expected blackout %q, got %+v", tt.window, window)
+			}
+			if got := until.UTC().Format(time.RFC3339); got != tt.until {
+				t.Errorf("Expected blackout until %s, got %s", tt.until, got)
+			}
+		})
+	}
+
+	always := []BlackoutWindow{{Name: "always", Start: "00:00", End: "24:00"}}
+	if err := always[0].parse(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if _, until := activeBlackout(always, now); until.Sub(now) < maxBlackout || until.Sub(now) > maxBlackout+24*time.Hour {
+		t.Errorf("Expected a window covering every day to end after %s, got %s", maxBlackout, until.Sub(now))
+	}
+}
+
+func TestBlackoutWindowConfig(t *testing.T) {
+	for _, window := range []string{
+		`{"name": "a", "start": "8:00", "end": "18:00"}`,
+		`{"name": "a", "start": "08:00", "end": "24:01"}`,
+		`{"name": "a", "start": "08:00", "end": "08:00"}`,
+		`{"name": "a", "days": ["monday"], "start": "08:00", "end": "18:00"}`,
+		`{"name": "a", "month_days": [0], "start": "08:00", "end": "18:00"}`,
+		`{"name": "a", "start": "08:00", "end": "18:00", "timezone": "Mars/Olympus"}`,
+		`{"start": "08:00", "end": "18:00"}`,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(`{"blackout_windows": [`+window+`]}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("Expected an error for blackout window %s", window)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// Discovery source kinds that can be given a time budget
+const (
+	SourceFileSystem = "filesystem"
+	SourceIndex      = "index"
+	SourceContainers = "containers"
+	SourceRegistry   = "registry"
+)
+
+// sourceKinds are the discovery sources source_budgets may name
+var sourceKinds = []string{SourceFileSystem, SourceIndex, SourceContainers, SourceRegistry}
+
+// errBudgetExceeded is returned when a discovery source ran out of time
+var errBudgetExceeded = errors.New("time budget exceeded")
+
+// SourceTiming reports how long a discovery source ran
+type SourceTiming struct {
+	Source         string `json:"source"`
+	Duration       string `json:"duration"`
+	Budget         string `json:"budget,omitempty"`
+	BudgetExceeded bool   `json:"budget_exceeded,omitempty"`
+}
+
+// containerStorageDirs returns the directories container engines keep image
+// layers and container file systems in. With a containers budget, the walk
+// leaves them for last and walks them within that budget.
+func containerStorageDirs() []string {
+	var dirs []string
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			dirs = append(dirs, filepath.Join(dir, "docker"))
+		}
+	case "darwin":
+		// Docker Desktop keeps its storage in a disk image of its VM
+	default:
+		dirs = append(dirs, "/var/lib/docker", "/var/lib/containers", "/var/lib/containerd", "/var/lib/rancher/k3s/agent/containerd")
+		if home, err := os.UserHomeDir(); err == nil {
+			dirs = append(dirs, filepath.Join(home, ".local", "share", "containers"))
+		}
+	}
+	return dirs
+}
+
+// deferredDirs collects the directories the walk workers leave for later
+type deferredDirs struct {
+	mu   sync.Mutex
+	dirs []string
+}
+
+// add defers a directory
+func (d *deferredDirs) add(dir string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dirs = append(d.dirs, dir)
+}
+
+// list returns the deferred directories in lexical order
+func (d *deferredDirs) list() []string {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Sorted(slices.Values(d.dirs))
+}
+
+// defersContainerStorage reports whether the walk leaves a directory for the
+// containers budget. A resumed walk would not know the directories left, so
+// the containers budget does not apply with -checkpoint.
+func (f *JavaFinder) defersContainerStorage(path string) bool {
+	return f.deferred != nil && f.budgets[SourceContainers] > 0 && f.checkpoint == nil && slices.Contains(f.containerStorage, path)
+}
+
+// walkContainerStorage walks the container storage directories the walk of
+// the start paths left, limited like the start path they are below
+func (f *JavaFinder) walkContainerStorage(ctx context.Context, emit func(*JavaResult) error) error {
+	for _, dir := range f.deferred.list() {
+		// The depth is counted from the storage directory, not from the start path
+		storage := *f
+		storage.startPath = dir
+		storage.roots, storage.rootDepths = nil, nil
+		storage.maxDepth = -1
+		if maxDepth := f.maxDepthOf(dir); maxDepth >= 0 {
+			storage.maxDepth = maxDepth - f.getPathDepth(dir)
+		}
+		storage.scanned, storage.excluded = 0, 0
+		storage.shard = nil // the shard applies to the top-level directories of the start path
+		storage.deferred = nil
+		err := storage.walkFileSystem(ctx, emit)
+		f.scanned += storage.scanned
+		f.excluded += storage.excluded
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registrySource lists the runtimes registered below the JavaSoft keys of the
+// Windows registry. It backs up a walk that ran out of its budget, the
+// registered runtimes the walk did not reach are still reported.
+type registrySource struct {
+	finder *JavaFinder
+	found  map[string]bool
+}
+
+// Name returns the name of the source
+func (r *registrySource) Name() string {
+	return SourceRegistry
+}
+
+// Candidates returns the java executables of the registered runtimes the walk
+// did not report and would not have skipped
+func (r *registrySource) Candidates(ctx context.Context) ([]string, error) {
+	var candidates []string
+	for _, java := range registryRuntimes() {
+		if !r.found[java] && !r.finder.excludedPath(java) {
+			candidates = append(candidates, java)
+		}
+	}
+	return candidates, nil
+}
+
+// withBudget runs fn limited to the time budget of the given source kind and
+// records the timing. A budget of 0 means unlimited.
+func (f *JavaFinder) withBudget(ctx context.Context, source, kind string, fn func(context.Context) error) error {
+	budget := f.budgets[kind]
+	budgetCtx, cancel := ctx, context.CancelFunc(func() {})
+	if budget > 0 {
+		budgetCtx, cancel = context.WithTimeout(ctx, budget)
+	}
+	defer cancel()
+
+	start := f.sys.Clock.Now()
+	err := fn(budgetCtx)
+
+	timing := SourceTiming{
+		Source:   source,
+		Duration: formatDurationISO8601(f.sys.Clock.Now().Sub(start)),
+	}
+	if budget > 0 {
+		timing.Budget = formatDurationISO8601(budget)
+	}
+	if ctx.Err() == nil && budgetCtx.Err() == context.DeadlineExceeded {
+		timing.BudgetExceeded = true
+		err = errBudgetExceeded
+		logf("Warning: %s exceeded its time budget of %s, results are incomplete\n", source, budget)
+	}
+	f.timings = append(f.timings, timing)
+
+	return err
+}
+
+// ignoreBudgetExceeded treats an exceeded budget as success, keeping partial results
+func ignoreBudgetExceeded(err error) error {
+	if err == errBudgetExceeded {
+		return nil
+	}
+	return err
+}
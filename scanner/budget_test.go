@@ -0,0 +1,52 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestContainerStorageBudget(t *testing.T) {
+	root := t.TempDir()
+	storage := filepath.Join(root, "var", "lib", "docker")
+	layer := createFakeJava(t, filepath.Join(storage, "overlay2", "abc", "diff", "opt", "java"))
+	host := createFakeJava(t, filepath.Join(root, "opt", "jdk"))
+
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.containerStorage = []string{storage}
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected %s and %s, got %v", host, layer, results)
+	}
+	if len(finder.timings) != 1 || finder.timings[0].Source != SourceFileSystem || finder.timings[0].Budget != "" {
+		t.Errorf("Expected an unlimited walk of the file system only, got %+v", finder.timings)
+	}
+
+	// With a containers budget, the storage is walked last and on its own
+	finder.budgets[SourceContainers] = time.Minute
+	results, err = finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[1].Path != layer {
+		t.Fatalf("Expected %s after %s, got %v", layer, host, results)
+	}
+	if len(finder.timings) != 2 || finder.timings[1].Source != SourceContainers {
+		t.Errorf("Expected a timing of the containers source, got %+v", finder.timings)
+	}
+}
+
+func TestSourceBudgetsConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeTestFile(t, path, `{"source_budgets": {"containers": "5m", "registry": "30s"}}`)
+	if _, err := LoadConfig(path); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	writeTestFile(t, path, `{"source_budgets": {"network": "5m"}}`)
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected an error for an unknown source")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Build tools that download JDKs on their own, and toolchains that bundle one
+const (
+	ProvisionerGradle        = "gradle"
+	ProvisionerMaven         = "maven"
+	ProvisionerIntelliJ      = "intellij"
+	ProvisionerAndroidStudio = "android-studio"
+	ProvisionerAndroidSDK    = "android-sdk"
+)
+
+// provisionerPatterns map the download locations of build tools to the tool name
+var provisionerPatterns = []struct {
+	pattern     string
+	provisioner string
+}{
+	// Gradle toolchain auto-provisioning
+	{"**/.gradle/jdks/**", ProvisionerGradle},
+	// Maven toolchain resolvers (e.g. toolchains-maven-plugin)
+	{"**/.m2/jdks/**", ProvisionerMaven},
+	// IntelliJ IDEA "Download JDK"
+	{"**/.jdks/**", ProvisionerIntelliJ},
+	{"/Users/*/Library/Java/JavaVirtualMachines/**", ProvisionerIntelliJ},
+	// JetBrains runtime bundled with Android Studio, jre before Android Studio 2022.2
+	{"**/android-studio/jbr/**", ProvisionerAndroidStudio},
+	{"**/android-studio/jre/**", ProvisionerAndroidStudio},
+	{"/snap/android-studio/*/jbr/**", ProvisionerAndroidStudio},
+	{"**/Toolbox/apps/AndroidStudio/**", ProvisionerAndroidStudio},
+	{"**/Android Studio*.app/Contents/jbr/**", ProvisionerAndroidStudio},
+	{"**/Android Studio*.app/Contents/jre/**", ProvisionerAndroidStudio},
+	{"**/Android/Android Studio/jbr/**", ProvisionerAndroidStudio},
+	{"**/Android/Android Studio/jre/**", ProvisionerAndroidStudio},
+	// Default Android SDK locations on Linux and Windows, macOS and of the sdkmanager packages
+	{"**/Android/Sdk/**", ProvisionerAndroidSDK},
+	{"**/Library/Android/sdk/**", ProvisionerAndroidSDK},
+	{"**/android-sdk*/**", ProvisionerAndroidSDK},
+}
+
+// androidSDKVariables point to the Android SDK if it is not in a default location
+var androidSDKVariables = []string{"ANDROID_HOME", "ANDROID_SDK_ROOT"}
+
+// gradleMarker is written by Gradle into every JDK it provisioned
+const gradleMarker = "provisioned.ok"
+
+// detectProvisioner returns the build tool that downloaded the runtime of a java
+// executable, or an empty string if it was not provisioned by a known build tool
+func detectProvisioner(javaPath string) string {
+	name := filepath.ToSlash(javaPath)
+	for _, p := range provisionerPatterns {
+		if matchGlob(p.pattern, name) {
+			return p.provisioner
+		}
+	}
+
+	for _, variable := range androidSDKVariables {
+		if sdk := os.Getenv(variable); sdk != "" && withinRoot(sdk, javaPath) {
+			return ProvisionerAndroidSDK
+		}
+	}
+
+	// Gradle can be configured to use another installation directory,
+	// but still leaves its marker next to the JDK home
+	dir := filepath.Dir(javaPath)
+	for i := 0; i < 4; i++ {
+		dir = filepath.Dir(dir)
+		if _, err := os.Stat(filepath.Join(dir, gradleMarker)); err == nil {
+			return ProvisionerGradle
+		}
+	}
+	return ""
+}
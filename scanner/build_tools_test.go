@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestDetectProvisioner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("paths under test are for Unix-like systems")
+	}
+	t.Setenv("ANDROID_HOME", "/srv/android")
+	t.Setenv("ANDROID_SDK_ROOT", "")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/home/dev/.gradle/jdks/temurin-17/bin/java", ProvisionerGradle},
+		{"/home/dev/.m2/jdks/jdk-21/bin/java", ProvisionerMaven},
+		{"/home/dev/.jdks/corretto-17/bin/java", ProvisionerIntelliJ},
+		{"/opt/android-studio/jbr/bin/java", ProvisionerAndroidStudio},
+		{"/home/dev/android-studio/jre/bin/java", ProvisionerAndroidStudio},
+		{"/snap/android-studio/161/jbr/bin/java", ProvisionerAndroidStudio},
+		{"/Applications/Android Studio.app/Contents/jbr/Contents/Home/bin/java", ProvisionerAndroidStudio},
+		{"/Applications/Android Studio Preview.app/Contents/jbr/Contents/Home/bin/java", ProvisionerAndroidStudio},
+		{"/home/dev/Android/Sdk/jdk/17/bin/java", ProvisionerAndroidSDK},
+		{"/Users/dev/Library/Android/sdk/jdk/bin/java", ProvisionerAndroidSDK},
+		{"/srv/android/jdk/bin/java", ProvisionerAndroidSDK},
+		{"/usr/lib/jvm/java-17/bin/java", ""},
+	}
+	for _, tt := range tests {
+		if got := detectProvisioner(filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("detectProvisioner(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestProvisionedRuntimeReported(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("ANDROID_HOME", "")
+	t.Setenv("ANDROID_SDK_ROOT", "")
+	// Gradle installed into a custom directory still leaves its marker next to the JDK home
+	gradle := createFakeJava(t, filepath.Join(root, "toolchains", "temurin-17", "jdk-17.0.9+9"))
+	writeTestFile(t, filepath.Join(root, "toolchains", "temurin-17", gradleMarker), "")
+	system := createFakeJava(t, filepath.Join(root, "jvm", "java-21"))
+
+	finder := NewJavaFinder(root, -1, false, false)
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output := buildJSONOutput(results, finder, time.Now())
+	if len(output.Runtimes) != 2 {
+		t.Fatalf("Expected %s and %s, got %v", gradle, system, results)
+	}
+	if output.Meta.BuildToolProvisioned != 1 {
+		t.Errorf("Expected 1 provisioned runtime, got %d", output.Meta.BuildToolProvisioned)
+	}
+	for _, runtime := range output.Runtimes {
+		want := map[string]string{gradle: ProvisionerGradle, system: ""}[runtime.JavaExecutable]
+		if runtime.ProvisionedBy != want {
+			t.Errorf("Expected %s to be provisioned by %q, got %q", runtime.JavaExecutable, want, runtime.ProvisionedBy)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Limits of the bytecode census, which samples rather than reads every class
+const (
+	censusMaxDepth    = 4   // directory levels below an application directory
+	censusMaxArchives = 100 // archives sampled per application directory
+	censusMaxClasses  = 10  // classes sampled per archive
+)
+
+// runtimeCollections are directories that hold runtimes side by side rather
+// than belonging to an application
+var runtimeCollections = []string{
+	"jvm", "java", "javavirtualmachines", ".jdks", "jdks", "toolchains", "candidates", "zulu", "eclipse adoptium",
+}
+
+// classMagic starts every class file
+const classMagic = 0xCAFEBABE
+
+// BytecodeCensus is the class file versions of the archives of an application directory
+type BytecodeCensus struct {
+	AppDir   string `json:"app_dir"`
+	Archives int    `json:"archives"`
+	Classes  int    `json:"classes"`
+
+	// Versions counts the sampled classes by the Java release they were compiled for
+	Versions map[string]int `json:"versions"`
+
+	// RequiredJava is the oldest Java release that can load every sampled class
+	RequiredJava int `json:"required_java"`
+
+	// Runtimes are the java executables of the runtimes the application directory belongs to
+	Runtimes []string `json:"runtimes"`
+}
+
+// takeBytecodeCensus samples the archives of the applications the runtimes
+// belong to. Directories without class files are left out.
+func takeBytecodeCensus(results []*JavaResult) []BytecodeCensus {
+	var census []BytecodeCensus
+	for _, result := range results {
+		appDir := applicationDir(result, results)
+		if appDir == "" {
+			continue
+		}
+		if i := slices.IndexFunc(census, func(c BytecodeCensus) bool { return sameHome(c.AppDir, appDir) }); i >= 0 {
+			census[i].Runtimes = append(census[i].Runtimes, result.Path)
+			continue
+		}
+		c := sampleBytecode(appDir)
+		if c.Classes == 0 {
+			continue
+		}
+		c.Runtimes = []string{result.Path}
+		census = append(census, c)
+	}
+	return census
+}
+
+// applicationDir returns the directory of the application a runtime belongs to:
+// the directory of the application bundling it, or else the parent of its home
+// unless that holds several runtimes or is a top-level directory
+func applicationDir(result *JavaResult, results []*JavaResult) string {
+	if result.Embedding != nil {
+		return result.Embedding.AppDir
+	}
+	home := filepath.Dir(filepath.Dir(result.Path))
+	if h, ok := findJavaHome(osSystem.FS, result.Path); ok {
+		home = h
+	}
+	parent := filepath.Dir(home)
+	if parent == filepath.Dir(parent) || filepath.Dir(parent) == filepath.Dir(filepath.Dir(parent)) {
+		return ""
+	}
+	if slices.Contains(runtimeCollections, strings.ToLower(filepath.Base(parent))) {
+		return ""
+	}
+	for _, other := range results {
+		if other != result && withinRoot(parent, other.Path) && !withinRoot(home, other.Path) {
+			return ""
+		}
+	}
+	return parent
+}
+
+// sampleBytecode reads up to censusMaxClasses classes of up to censusMaxArchives
+// archives below an application directory, skipping the runtimes in it
+func sampleBytecode(appDir string) BytecodeCensus {
+	c := BytecodeCensus{AppDir: appDir, Versions: make(map[string]int)}
+	filepath.WalkDir(appDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path == appDir {
+				return nil
+			}
+			rel, _ := filepath.Rel(appDir, path)
+			if strings.Count(rel, string(filepath.Separator)) >= censusMaxDepth || isRuntimeHome(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isLibraryArchive(d.Name()) {
+			return nil
+		}
+		if majors := archiveClassVersions(path); len(majors) > 0 {
+			c.Archives++
+			for _, major := range majors {
+				c.Classes++
+				release := javaRelease(major)
+				c.Versions[strconv.Itoa(release)]++
+				c.RequiredJava = max(c.RequiredJava, release)
+			}
+		}
+		if c.Archives >= censusMaxArchives {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return c
+}
+
+// isRuntimeHome reports whether a directory is a runtime, whose own archives are not sampled
+func isRuntimeHome(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, "release")); err == nil {
+		return true
+	}
+	info, err := os.Stat(filepath.Join(dir, "bin", javaExecutableName()))
+	return err == nil && !info.IsDir()
+}
+
+// archiveClassVersions returns the class file major versions of up to
+// censusMaxClasses classes of an archive. Classes for newer releases in
+// META-INF/versions of a multi-release jar and module-info are skipped, as
+// older runtimes do not load them.
+func archiveClassVersions(path string) []int {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil
+	}
+	defer archive.Close()
+	var majors []int
+	for _, entry := range archive.File {
+		name := entry.Name
+		if !strings.HasSuffix(name, ".class") || strings.HasPrefix(name, "META-INF/versions/") || strings.HasSuffix(name, "module-info.class") {
+			continue
+		}
+		if major, ok := classVersion(entry); ok {
+			majors = append(majors, major)
+		}
+		if len(majors) >= censusMaxClasses {
+			break
+		}
+	}
+	return majors
+}
+
+// classVersion reads the major version from the header of a class file
+func classVersion(entry *zip.File) (int, bool) {
+	reader, err := entry.Open()
+	if err != nil {
+		return 0, false
+	}
+	defer reader.Close()
+	var header [8]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil || binary.BigEndian.Uint32(header[:4]) != classMagic {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint16(header[6:])), true
+}
+
+// javaRelease maps a class file major version to the Java release introducing it,
+// e.g. 52 to 8 and 61 to 17
+func javaRelease(major int) int {
+	if major < 49 {
+		return 1
+	}
+	return major - 44
+}
+
+// requiredJava returns the highest Java release required by the applications a runtime belongs to
+func requiredJava(census []BytecodeCensus, javaPath string) int {
+	required := 0
+	for _, c := range census {
+		if slices.Contains(c.Runtimes, javaPath) {
+			required = max(required, c.RequiredJava)
+		}
+	}
+	return required
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// classFile returns the header of a class file compiled for a major version
+func classFile(major byte) string {
+	return string([]byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, major})
+}
+
+func TestBytecodeCensus(t *testing.T) {
+	dir := t.TempDir()
+	bundled := createFakeJava(t, filepath.Join(dir, "app", "jre"))
+	writeTestJar(t, filepath.Join(dir, "app", "lib", "core.jar"), map[string]string{
+		"com/acme/Main.class":                   classFile(61),
+		"com/acme/Util.class":                   classFile(52),
+		"META-INF/versions/21/com/acme/X.class": classFile(65),
+		"module-info.class":                     classFile(65),
+		"com/acme/messages.properties":          "",
+	})
+	writeTestJar(t, filepath.Join(dir, "app", "plugins", "legacy.jar"), map[string]string{"Legacy.class": classFile(50)})
+	writeTestJar(t, filepath.Join(dir, "app", "jre", "lib", "rt.jar"), map[string]string{"java/lang/Object.class": classFile(52)})
+
+	shared := createFakeJava(t, filepath.Join(dir, "jvm", "jdk-17"))
+	createFakeJava(t, filepath.Join(dir, "tools", "jdk-11"))
+	createFakeJava(t, filepath.Join(dir, "tools", "jdk-21"))
+	writeTestJar(t, filepath.Join(dir, "tools", "tool.jar"), map[string]string{"Tool.class": classFile(65)})
+
+	finder := NewJavaFinder(dir, -1, false, false)
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	census := takeBytecodeCensus(results)
+	want := []BytecodeCensus{{
+		AppDir:       filepath.Join(dir, "app"),
+		Archives:     2,
+		Classes:      3,
+		Versions:     map[string]int{"6": 1, "8": 1, "17": 1},
+		RequiredJava: 17,
+		Runtimes:     []string{bundled},
+	}}
+	if !reflect.DeepEqual(census, want) {
+		t.Errorf("Expected %+v, got %+v", want, census)
+	}
+	if required := requiredJava(census, bundled); required != 17 {
+		t.Errorf("Expected Java 17 required for %s, got %d", bundled, required)
+	}
+	if required := requiredJava(census, shared); required != 0 {
+		t.Errorf("Expected no application for the runtime in a collection, got Java %d", required)
+	}
+}
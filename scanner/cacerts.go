@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// CACertsInfo is the enrichment of the cacerts enricher
+type CACertsInfo struct {
+	File         string `json:"file"`
+	Format       string `json:"format"` // jks or pkcs12
+	Certificates int    `json:"certificates"`
+	Expired      int    `json:"expired"`
+	// NewestCertificate is the date the most recently issued root became valid.
+	// Certificate authorities rarely issue new roots, so it does not tell how
+	// old the set of trusted roots is; Expired and Distrusted do.
+	NewestCertificate string `json:"newest_certificate,omitempty"`
+
+	// Distrusted is the number of roots the OpenJDK removed for distrust
+	Distrusted int `json:"distrusted"`
+}
+
+// distrustedRoots are the common names of root certificates the OpenJDK
+// removed from its cacerts because their certificate authority lost the trust
+// of the browsers and the JDK: the Camerfirma roots, removed in 8u301, 11.0.12
+// and 17. A runtime that still trusts them has not been updated since.
+var distrustedRoots = []string{
+	"Chambers of Commerce Root",
+	"Chambers of Commerce Root - 2008",
+	"Global Chambersign Root - 2008",
+}
+
+// cacertsEnricher reports the trusted root certificates of a runtime, read from
+// lib/security/cacerts. Runtimes that are never updated keep trusting expired
+// and distrusted roots and fail on hosts that use newer ones.
+type cacertsEnricher struct {
+	now func() time.Time
+}
+
+// Name returns the name of the enricher
+func (c *cacertsEnricher) Name() string {
+	return "cacerts"
+}
+
+// Enrich returns the number of the certificates in the cacerts file and of those expired or distrusted
+func (c *cacertsEnricher) Enrich(result *JavaResult) (any, error) {
+	home := filepath.Dir(filepath.Dir(result.Path))
+	for _, rel := range []string{filepath.Join("lib", "security"), filepath.Join("jre", "lib", "security")} {
+		path := filepath.Join(home, rel, "cacerts")
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		info := &CACertsInfo{File: path, Format: "jks"}
+		var certs [][]byte
+		if len(data) >= 4 && (binary.BigEndian.Uint32(data) == 0xFEEDFEED || binary.BigEndian.Uint32(data) == 0xCECECECE) {
+			certs, err = jksCertificates(bytes.NewReader(data))
+		} else {
+			// Java 18 and later ship a password-less PKCS#12 file
+			info.Format = "pkcs12"
+			certs, err = pkcs12Certificates(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+
+		now := time.Now()
+		if c.now != nil {
+			now = c.now()
+		}
+		var newest time.Time
+		info.Certificates = len(certs)
+		for _, der := range certs {
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				// Some old roots are rejected by Go, e.g. for a negative serial number
+				continue
+			}
+			if now.After(cert.NotAfter) {
+				info.Expired++
+			}
+			if slices.Contains(distrustedRoots, cert.Subject.CommonName) {
+				info.Distrusted++
+			}
+			if cert.NotBefore.After(newest) {
+				newest = cert.NotBefore
+			}
+		}
+		if !newest.IsZero() {
+			info.NewestCertificate = newest.Format(time.DateOnly)
+		}
+		return info, nil
+	}
+	return nil, nil
+}
+
+// jksCertificates reads the certificates of a JKS or JCEKS keystore: a magic,
+// a version, the entry count and the entries, each with a tag, an alias and a
+// creation date, followed by a certificate (tag 2) or a key with its chain (tag 1)
+func jksCertificates(r io.Reader) ([][]byte, error) {
+	var header struct {
+		Magic   uint32
+		Version uint32
+		Count   uint32
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.Version != 1 && header.Version != 2 {
+		return nil, fmt.Errorf("unsupported keystore version %d", header.Version)
+	}
+
+	// readCert reads a certificate, version 1 keystores omit its type
+	readCert := func() ([]byte, error) {
+		if header.Version == 2 {
+			if _, err := readJavaUTF(r); err != nil {
+				return nil, err
+			}
+		}
+		return readBlock(r)
+	}
+
+	var certs [][]byte
+	for range header.Count {
+		var tag uint32
+		if err := binary.Read(r, binary.BigEndian, &tag); err != nil {
+			return nil, err
+		}
+		if _, err := readJavaUTF(r); err != nil {
+			return nil, err
+		}
+		var created int64
+		if err := binary.Read(r, binary.BigEndian, &created); err != nil {
+			return nil, err
+		}
+		switch tag {
+		case 1:
+			if _, err := readBlock(r); err != nil {
+				return nil, err
+			}
+			var chain uint32
+			if err := binary.Read(r, binary.BigEndian, &chain); err != nil {
+				return nil, err
+			}
+			for range chain {
+				if _, err := readCert(); err != nil {
+					return nil, err
+				}
+			}
+		case 2:
+			cert, err := readCert()
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		default:
+			return nil, fmt.Errorf("unsupported keystore entry %d", tag)
+		}
+	}
+	return certs, nil
+}
+
+// readBlock reads a byte array prefixed by its 32 bit length
+func readBlock(r io.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	if length > 1<<20 {
+		return nil, fmt.Errorf("keystore entry of %d bytes", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+var (
+	oidPKCS7Data = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidCertBag   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+)
+
+// pkcs12ContentInfo is a PKCS#7 ContentInfo, of which only unencrypted data is read
+type pkcs12ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+// pkcs12SafeBag is an entry of a PKCS#12 file
+type pkcs12SafeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue `asn1:"tag:0,explicit"`
+	Attributes asn1.RawValue `asn1:"optional"`
+}
+
+// pkcs12CertBag is the value of a safe bag holding a certificate
+type pkcs12CertBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+// pkcs12Certificates reads the certificates of a PKCS#12 file stored without
+// encryption, like the cacerts file of Java 18 and later. Encrypted contents are skipped.
+func pkcs12Certificates(data []byte) ([][]byte, error) {
+	var pfx struct {
+		Version  int
+		AuthSafe pkcs12ContentInfo
+		MacData  asn1.RawValue `asn1:"optional"`
+	}
+	if _, err := asn1.Unmarshal(data, &pfx); err != nil {
+		return nil, fmt.Errorf("neither a keystore nor a PKCS#12 file: %v", err)
+	}
+	if !pfx.AuthSafe.ContentType.Equal(oidPKCS7Data) {
+		return nil, fmt.Errorf("PKCS#12 file with signed contents")
+	}
+	authSafe, err := pkcs12Data(pfx.AuthSafe)
+	if err != nil {
+		return nil, err
+	}
+	var contents []pkcs12ContentInfo
+	if _, err := asn1.Unmarshal(authSafe, &contents); err != nil {
+		return nil, err
+	}
+
+	var certs [][]byte
+	for _, content := range contents {
+		if !content.ContentType.Equal(oidPKCS7Data) {
+			continue
+		}
+		safeContents, err := pkcs12Data(content)
+		if err != nil {
+			return nil, err
+		}
+		var bags []pkcs12SafeBag
+		if _, err := asn1.Unmarshal(safeContents, &bags); err != nil {
+			return nil, err
+		}
+		for _, bag := range bags {
+			if !bag.ID.Equal(oidCertBag) {
+				continue
+			}
+			var cert pkcs12CertBag
+			if _, err := asn1.Unmarshal(bag.Value.Bytes, &cert); err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert.Data)
+		}
+	}
+	return certs, nil
+}
+
+// pkcs12Data returns the octets of a data ContentInfo
+func pkcs12Data(content pkcs12ContentInfo) ([]byte, error) {
+	var octets []byte
+	if _, err := asn1.Unmarshal(content.Content.Bytes, &octets); err != nil {
+		return nil, err
+	}
+	return octets, nil
+}
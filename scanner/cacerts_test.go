@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCertificate creates a self-signed certificate valid in the given period
+func testCertificate(t *testing.T, notBefore, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// testJKS encodes certificates as trusted entries of a JKS keystore
+func testJKS(certs ...[]byte) string {
+	data := binary.BigEndian.AppendUint32(nil, 0xFEEDFEED)
+	data = binary.BigEndian.AppendUint32(data, 2)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(certs)))
+	for _, cert := range certs {
+		data = binary.BigEndian.AppendUint32(data, 2)
+		data = append(data, javaUTF("root")...)
+		data = binary.BigEndian.AppendUint64(data, 0)
+		data = append(data, javaUTF("X.509")...)
+		data = binary.BigEndian.AppendUint32(data, uint32(len(cert)))
+		data = append(data, cert...)
+	}
+	return string(data)
+}
+
+// testPKCS12 encodes certificates as an unencrypted PKCS#12 file
+func testPKCS12(t *testing.T, certs ...[]byte) string {
+	t.Helper()
+	mustMarshal := func(value any) []byte {
+		data, err := asn1.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	// explicit wraps a value in the [0] tag, which Marshal leaves to raw values
+	explicit := func(value []byte) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: value}
+	}
+	dataContent := func(octets []byte) pkcs12ContentInfo {
+		return pkcs12ContentInfo{ContentType: oidPKCS7Data, Content: explicit(mustMarshal(octets))}
+	}
+
+	var bags []pkcs12SafeBag
+	for _, cert := range certs {
+		bag := mustMarshal(pkcs12CertBag{ID: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}, Data: cert})
+		attributes := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+		bags = append(bags, pkcs12SafeBag{ID: oidCertBag, Value: explicit(bag), Attributes: attributes})
+	}
+	authSafe := mustMarshal([]pkcs12ContentInfo{dataContent(mustMarshal(bags))})
+	return string(mustMarshal(struct {
+		Version  int
+		AuthSafe pkcs12ContentInfo
+	}{3, dataContent(authSafe)}))
+}
+
+func TestCACertsEnricher(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	expired := testCertificate(t, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	current := testCertificate(t, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2041, 1, 1, 0, 0, 0, 0, time.UTC))
+	enricher := &cacertsEnricher{now: func() time.Time { return now }}
+
+	jdk8 := filepath.Join(t.TempDir(), "jdk1.8.0_202")
+	writeTestFile(t, filepath.Join(jdk8, "jre", "lib", "security", "cacerts"), testJKS(expired, current))
+	value, err := enricher.Enrich(&JavaResult{Path: filepath.Join(jdk8, "bin", "java")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := CACertsInfo{File: filepath.Join(jdk8, "jre", "lib", "security", "cacerts"), Format: "jks", Certificates: 2, Expired: 1, NewestCertificate: "2021-03-04"}
+	if info := value.(*CACertsInfo); *info != want {
+		t.Errorf("Expected %+v, got %+v", want, *info)
+	}
+
+	jdk21 := filepath.Join(t.TempDir(), "jdk-21")
+	writeTestFile(t, filepath.Join(jdk21, "lib", "security", "cacerts"), testPKCS12(t, current, expired))
+	value, err = enricher.Enrich(&JavaResult{Path: filepath.Join(jdk21, "bin", "java")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info := value.(*CACertsInfo); info.Format != "pkcs12" || info.Certificates != 2 || info.Expired != 1 || info.NewestCertificate != "2021-03-04" {
+		t.Errorf("Unexpected PKCS#12 cacerts %+v", info)
+	}
+
+	writeTestFile(t, filepath.Join(jdk21, "lib", "security", "cacerts"), "not a keystore")
+	if _, err := enricher.Enrich(&JavaResult{Path: filepath.Join(jdk21, "bin", "java")}); err == nil {
+		t.Error("Expected an error for an unreadable cacerts file")
+	}
+	if value, err := enricher.Enrich(&JavaResult{Path: filepath.Join(t.TempDir(), "bin", "java")}); value != nil || err != nil {
+		t.Errorf("Expected no enrichment without cacerts, got %v, %v", value, err)
+	}
+}
+
+func TestCACertsDistrusted(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Chambers of Commerce Root - 2008"},
+		NotBefore:    time.Date(2008, 8, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2038, 7, 31, 0, 0, 0, 0, time.UTC),
+		IsCA:         true,
+	}
+	camerfirma, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := testCertificate(t, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2041, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	jdk11 := filepath.Join(t.TempDir(), "jdk-11.0.2")
+	writeTestFile(t, filepath.Join(jdk11, "lib", "security", "cacerts"), testJKS(camerfirma, current))
+	enricher := &cacertsEnricher{now: func() time.Time { return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) }}
+	value, err := enricher.Enrich(&JavaResult{Path: filepath.Join(jdk11, "bin", "java")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := value.(*CACertsInfo); info.Distrusted != 1 || info.Expired != 0 {
+		t.Errorf("Expected the Camerfirma root to be distrusted, got %+v", info)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"unicode/utf8"
+)
+
+// defaultCaptureSize is the default limit of the probe output kept per stream
+const defaultCaptureSize = "4K"
+
+// ProbeOutput is the raw output of a probe, kept so that unexpected vendor
+// strings can be investigated later without running the binary again
+type ProbeOutput struct {
+	Stdout *CapturedStream `json:"stdout,omitempty"`
+	Stderr *CapturedStream `json:"stderr,omitempty"`
+}
+
+// CapturedStream is the beginning of an output stream and the hash of all of it
+type CapturedStream struct {
+	Text      string `json:"text"`
+	Size      int    `json:"size"`
+	SHA256    string `json:"sha256"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// captureProbeOutput keeps at most limit bytes of each stream, nil if both are empty
+func captureProbeOutput(stdout, stderr []byte, limit int) *ProbeOutput {
+	output := &ProbeOutput{
+		Stdout: captureStream(stdout, limit),
+		Stderr: captureStream(stderr, limit),
+	}
+	if output.Stdout == nil && output.Stderr == nil {
+		return nil
+	}
+	return output
+}
+
+// captureStream hashes data and truncates it to limit bytes without splitting a character
+func captureStream(data []byte, limit int) *CapturedStream {
+	if len(data) == 0 {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	stream := &CapturedStream{Size: len(data), SHA256: hex.EncodeToString(sum[:])}
+	if len(data) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(data[cut]) {
+			cut--
+		}
+		data = data[:cut]
+		stream.Truncated = true
+	}
+	stream.Text = string(data)
+	return stream
+}
+
+// size returns the number of bytes of captured text
+func (o *ProbeOutput) size() int {
+	if o == nil {
+		return 0
+	}
+	size := 0
+	for _, stream := range []*CapturedStream{o.Stdout, o.Stderr} {
+		if stream != nil {
+			size += len(stream.Text)
+		}
+	}
+	return size
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCaptureStream(t *testing.T) {
+	if captureStream(nil, 16) != nil {
+		t.Error("Expected no capture for empty output")
+	}
+
+	data := []byte("vendor: Ünknown")
+	sum := sha256.Sum256(data)
+	stream := captureStream(data, 9)
+	if !stream.Truncated || stream.Size != len(data) || stream.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("Unexpected capture %+v", stream)
+	}
+	// The cut must not split the two bytes of Ü
+	if stream.Text != "vendor: " {
+		t.Errorf("Expected truncation before the multi-byte character, got %q", stream.Text)
+	}
+
+	if stream := captureStream(data, 64); stream.Truncated || stream.Text != string(data) {
+		t.Errorf("Expected the complete output, got %+v", stream)
+	}
+}
+
+func TestEvaluateCapturesOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of java")
+	}
+	root := t.TempDir()
+	java := filepath.Join(root, "jdk", "bin", "java")
+	stderr := "Property settings:\n    java.vendor = Unexpected Vendor\n" + strings.Repeat("x", 100)
+	writeTestFile(t, java, "#!/bin/sh\necho 'hello'\necho '"+stderr+"' >&2\n")
+
+	finder := NewJavaFinder(root, -1, false, true)
+	result := finder.evaluateJava(context.Background(), java)
+	if result.Output != nil {
+		t.Errorf("Expected no captured output by default, got %+v", result.Output)
+	}
+
+	finder.captureBytes = 32
+	result = finder.evaluateJava(context.Background(), java)
+	if result.Output == nil || result.Output.Stdout == nil || result.Output.Stderr == nil {
+		t.Fatalf("Expected captured stdout and stderr, got %+v", result.Output)
+	}
+	if got := result.Output.Stdout.Text; got != "hello\n" {
+		t.Errorf("Expected stdout %q, got %q", "hello\n", got)
+	}
+	captured := result.Output.Stderr
+	if !captured.Truncated || len(captured.Text) != 32 || captured.Size != len(stderr)+1 {
+		t.Errorf("Expected stderr truncated to 32 of %d bytes, got %+v", len(stderr)+1, captured)
+	}
+
+	output := buildJSONOutput([]*JavaResult{&result}, finder, time.Now())
+	if output.Runtimes[0].ProbeOutput != result.Output {
+		t.Error("Expected the probe output in the JSON runtime")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build darwin
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// changeTime returns the time the inode of a file last changed, or the
+// modification time if the file system does not tell
+func changeTime(info os.FileInfo) time.Time {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(stat.Ctimespec.Unix())
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// changeTime returns the modification time, the change time of the inode is
+// not available on other systems
+func changeTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
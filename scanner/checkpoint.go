@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// checkpointVersion is the version of the checkpoint file format
+const checkpointVersion = 1
+
+// checkpointInterval is how often the walk is saved to the checkpoint file
+const checkpointInterval = 30 * time.Second
+
+// checkpointState is the content of a checkpoint file
+type checkpointState struct {
+	Version    int      `json:"version"`
+	StartPaths []string `json:"start_paths"`
+	Saved      string   `json:"saved"`
+	// Frontier holds the directories left to read below each start path whose
+	// walk has begun, in no particular order; it is empty for a completed one
+	Frontier map[string][]string `json:"frontier"`
+	// Scanned and Excluded count the directories below each start path so far
+	Scanned  map[string]int    `json:"scanned"`
+	Excluded map[string]int    `json:"excluded"`
+	Found    []checkpointFound `json:"found"`
+}
+
+// checkpointFound is a java executable found before the checkpoint, it is
+// evaluated again when the scan is resumed
+type checkpointFound struct {
+	Path       string `json:"path"`
+	ViaSymlink bool   `json:"via_symlink,omitempty"`
+}
+
+// scanCheckpoint saves the walk periodically (-checkpoint), so an interrupted
+// scan can continue where it left off (-resume). The walk frontier is the
+// directories queued for reading and those being read; a directory being read
+// is read again on resume, the runtimes found in it twice are reported once.
+type scanCheckpoint struct {
+	path string
+
+	mu       sync.Mutex
+	state    checkpointState
+	found    map[string]bool
+	root     string    // start path being walked
+	queue    *dirQueue // its directories left to read
+	scanned  atomic.Int64
+	excluded atomic.Int64
+	stopTick chan struct{}
+	stopped  chan struct{}
+
+	// mount is set for the scan of a share, whose mount point differs on resume
+	mount *checkpointMount
+}
+
+// checkpointMount maps the directory a share is mounted on to the URL of the
+// share. The checkpoint file stores the paths below the URL and the scan uses
+// them below the mount point.
+type checkpointMount struct {
+	local string
+	share string
+}
+
+// rebasePath returns path moved from below the directory from to below to
+func rebasePath(path, from, to string) string {
+	if path == from {
+		return to
+	}
+	rel, ok := strings.CutPrefix(path, strings.TrimSuffix(from, "/")+"/")
+	if !ok && filepath.Separator != '/' {
+		rel, ok = strings.CutPrefix(path, strings.TrimSuffix(from, string(filepath.Separator))+string(filepath.Separator))
+	}
+	if !ok {
+		return path
+	}
+	if strings.Contains(to, "://") {
+		return strings.TrimSuffix(to, "/") + "/" + filepath.ToSlash(rel)
+	}
+	return filepath.Join(to, filepath.FromSlash(rel))
+}
+
+// rebaseState returns a copy of a checkpoint with its paths moved from below
+// the directory from to below to. The start paths are not changed.
+func rebaseState(state checkpointState, from, to string) checkpointState {
+	moved := state
+	moved.Frontier = make(map[string][]string, len(state.Frontier))
+	for root, dirs := range state.Frontier {
+		rebased := make([]string, len(dirs))
+		for i, dir := range dirs {
+			rebased[i] = rebasePath(dir, from, to)
+		}
+		moved.Frontier[rebasePath(root, from, to)] = rebased
+	}
+	for _, m := range []*map[string]int{&moved.Scanned, &moved.Excluded} {
+		counts := make(map[string]int, len(*m))
+		for root, n := range *m {
+			counts[rebasePath(root, from, to)] = n
+		}
+		*m = counts
+	}
+	moved.Found = make([]checkpointFound, len(state.Found))
+	for i, found := range state.Found {
+		moved.Found[i] = checkpointFound{Path: rebasePath(found.Path, from, to), ViaSymlink: found.ViaSymlink}
+	}
+	return moved
+}
+
+// mountedAt records that the share the checkpoint is of is mounted on local,
+// and moves the paths of a loaded checkpoint there
+func (c *scanCheckpoint) mountedAt(local, share string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mount = &checkpointMount{local: local, share: share}
+	c.state = rebaseState(c.state, share, local)
+	clear(c.found)
+	for _, found := range c.state.Found {
+		c.found[found.Path] = true
+	}
+}
+
+// newCheckpoint starts a checkpoint file for a scan of the start paths
+func newCheckpoint(path string, startPaths []string) *scanCheckpoint {
+	return &scanCheckpoint{
+		path: path,
+		state: checkpointState{
+			Version:    checkpointVersion,
+			StartPaths: startPaths,
+			Frontier:   make(map[string][]string),
+			Scanned:    make(map[string]int),
+			Excluded:   make(map[string]int),
+		},
+		found: make(map[string]bool),
+	}
+}
+
+// loadCheckpoint reads the checkpoint file of an interrupted scan of the start
+// paths. The scan saves its progress to path from then on.
+func loadCheckpoint(file, path string, startPaths []string) (*scanCheckpoint, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var state checkpointState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %v", file, err)
+	}
+	c := newCheckpoint(path, startPaths)
+	c.state = state
+	if c.state.Version != checkpointVersion {
+		return nil, fmt.Errorf("checkpoint %s has version %d, this jfind resumes version %d", file, c.state.Version, checkpointVersion)
+	}
+	if !slices.Equal(c.state.StartPaths, startPaths) {
+		return nil, fmt.Errorf("checkpoint %s is of a scan of %v, not %v", file, c.state.StartPaths, startPaths)
+	}
+	for _, m := range []*map[string]int{&c.state.Scanned, &c.state.Excluded} {
+		if *m == nil {
+			*m = make(map[string]int)
+		}
+	}
+	if c.state.Frontier == nil {
+		c.state.Frontier = make(map[string][]string)
+	}
+	for _, found := range c.state.Found {
+		c.found[found.Path] = true
+	}
+	return c, nil
+}
+
+// resumeFound reports the runtimes found before the checkpoint again, unless
+// they are gone since
+func (f *JavaFinder) resumeFound(ctx context.Context, emit func(*JavaResult) error) error {
+	c := f.checkpoint
+	c.mu.Lock()
+	found := slices.Clone(c.state.Found)
+	c.mu.Unlock()
+	for _, runtime := range found {
+		if _, err := f.sys.FS.Lstat(runtime.Path); err != nil {
+			continue
+		}
+		result := f.newResult(ctx, runtime.Path)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result.ViaSymlink = result.ViaSymlink || runtime.ViaSymlink
+		if err := emit(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record passes the results of the walk on to emit and keeps their paths,
+// dropping those found before the checkpoint
+func (c *scanCheckpoint) record(emit func(*JavaResult) error) func(*JavaResult) error {
+	return func(result *JavaResult) error {
+		c.mu.Lock()
+		if c.found[result.Path] {
+			c.mu.Unlock()
+			return nil
+		}
+		c.found[result.Path] = true
+		c.state.Found = append(c.state.Found, checkpointFound{Path: result.Path, ViaSymlink: result.ViaSymlink})
+		c.mu.Unlock()
+		return emit(result)
+	}
+}
+
+// completed checks if the walk of a start path completed before the checkpoint
+func (c *scanCheckpoint) completed(root string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	frontier, ok := c.state.Frontier[root]
+	return ok && len(frontier) == 0
+}
+
+// begin tracks the walk of a start path by its queue of directories. If the
+// walk was begun before the checkpoint, it returns the directories left to read.
+func (c *scanCheckpoint) begin(root string, queue *dirQueue) (frontier []string, resumed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.root, c.queue = root, queue
+	c.scanned.Store(0)
+	c.excluded.Store(0)
+	frontier, resumed = c.state.Frontier[root]
+	return frontier, resumed
+}
+
+// end records the walk of the current start path as complete if it was
+func (c *scanCheckpoint) end(complete bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapshot()
+	if complete {
+		c.state.Frontier[c.root] = []string{}
+	}
+	c.root, c.queue = "", nil
+}
+
+// countDir counts a directory scanned (or excluded) below the current start path
+func (c *scanCheckpoint) countDir(excluded bool) {
+	if c == nil {
+		return
+	}
+	if excluded {
+		c.excluded.Add(1)
+	} else {
+		c.scanned.Add(1)
+	}
+}
+
+// snapshot records the frontier and counters of the current start path, c.mu is held
+func (c *scanCheckpoint) snapshot() {
+	if c.queue == nil {
+		return
+	}
+	c.state.Frontier[c.root] = c.queue.snapshot()
+	c.state.Scanned[c.root] += int(c.scanned.Swap(0))
+	c.state.Excluded[c.root] += int(c.excluded.Swap(0))
+}
+
+// save writes the checkpoint file
+func (c *scanCheckpoint) save(now time.Time) error {
+	c.mu.Lock()
+	c.snapshot()
+	c.state.Saved = now.UTC().Format(time.RFC3339)
+	state := c.state
+	if c.mount != nil {
+		state = rebaseState(state, c.mount.local, c.mount.share)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// totals returns the directories scanned and excluded below all start paths
+// before the walk was resumed
+func (c *scanCheckpoint) totals() (scanned, excluded int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, n := range c.state.Scanned {
+		scanned += n
+	}
+	for _, n := range c.state.Excluded {
+		excluded += n
+	}
+	return scanned, excluded
+}
+
+// scannedBefore returns the directories scanned below a start path before the
+// walk was resumed
+func (c *scanCheckpoint) scannedBefore(root string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state.Scanned[root]
+}
+
+// startSaving saves the checkpoint every interval until stopSaving is called
+func (c *scanCheckpoint) startSaving(clock Clock, interval time.Duration) {
+	c.stopTick, c.stopped = make(chan struct{}), make(chan struct{})
+	go func(stop <-chan struct{}) {
+		defer close(c.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.save(clock.Now()); err != nil {
+					logf("Warning: %v\n", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}(c.stopTick)
+}
+
+// stopSaving stops the periodic saves. A scan that completed removes the
+// checkpoint file, an interrupted one saves it a last time.
+func (c *scanCheckpoint) stopSaving(clock Clock, complete bool) error {
+	close(c.stopTick)
+	<-c.stopped
+	if complete {
+		if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return c.save(clock.Now())
+}
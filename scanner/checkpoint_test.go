@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	fsys := fakeFileSystem{}
+	var want []string
+	for _, dir := range []string{"a", "b", "c"} {
+		path := filepath.Join(root, dir, "jdk", "bin", javaExecutableName())
+		fsys.add(path, 0755, "")
+		want = append(want, path)
+	}
+	file := filepath.Join(t.TempDir(), "scan.checkpoint")
+
+	// The walk reads c and b first and is interrupted while reading a
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.SetSystem(System{FS: stallingFS{fakeFileSystem: fsys, stalled: filepath.Join(root, "a"), stop: ctx.Done()}})
+	finder.workers = 1
+	finder.checkpoint = newCheckpoint(file, finder.startPaths())
+	results, err := finder.FindContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !finder.timedOut {
+		t.Fatalf("Expected 2 results before the timeout, got %v", results)
+	}
+
+	resumed, err := loadCheckpoint(file, file, finder.startPaths())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frontier := resumed.state.Frontier[root]; !slices.Equal(frontier, []string{filepath.Join(root, "a")}) {
+		t.Errorf("Expected the directory being read in the frontier, got %v", frontier)
+	}
+
+	finder = NewJavaFinder(root, -1, false, false)
+	finder.SetSystem(System{FS: fsys})
+	finder.workers = 1
+	finder.checkpoint = resumed
+	results, err = finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, result := range results {
+		paths = append(paths, result.Path)
+	}
+	if !slices.Equal(paths, want) {
+		t.Errorf("Expected the runtimes found before and after the checkpoint once, got %v", paths)
+	}
+
+	complete := NewJavaFinder(root, -1, false, false)
+	complete.SetSystem(System{FS: fsys})
+	if _, err := complete.Find(); err != nil {
+		t.Fatal(err)
+	}
+	if finder.scanned != complete.scanned {
+		t.Errorf("Expected %d directories scanned in total, got %d", complete.scanned, finder.scanned)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("Expected the checkpoint to be removed after the scan completed, got %v", err)
+	}
+}
+
+func TestLoadCheckpointOtherScan(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scan.checkpoint")
+	checkpoint := newCheckpoint(file, []string{"/opt"})
+	if err := checkpoint.save(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCheckpoint(file, file, []string{"/srv"}); err == nil || !strings.Contains(err.Error(), "/opt") {
+		t.Errorf("Expected a checkpoint of another scan to be rejected, got %v", err)
+	}
+}
+
+func TestDirQueueSnapshot(t *testing.T) {
+	queue := newDirQueue()
+	for _, path := range []string{"/srv", "/opt/a", "/opt/a/b", "/opt/c"} {
+		queue.push(queuedDir{path: filepath.FromSlash(path)})
+	}
+	// /opt/c is being read, its subdirectory /opt/c/d is queued
+	for {
+		dir, _ := queue.pop()
+		if dir.path == filepath.FromSlash("/opt/c") {
+			break
+		}
+		queue.finish(dir)
+	}
+	queue.push(queuedDir{path: filepath.FromSlash("/opt/c/d")})
+	if got := queue.snapshot(); !slices.Equal(got, []string{filepath.FromSlash("/opt/a"), filepath.FromSlash("/opt/c"), filepath.FromSlash("/srv")}) {
+		t.Errorf("Expected the directories left to read without those below another, got %v", got)
+	}
+}
+
+func TestCheckpointOfShare(t *testing.T) {
+	share := "smb://nas01/apps"
+	first, second := filepath.FromSlash("/tmp/jfind-share-1"), filepath.FromSlash("/tmp/jfind-share-2")
+	file := filepath.Join(t.TempDir(), "scan.checkpoint")
+
+	// The checkpoint stores the paths below the URL of the share
+	c := newCheckpoint(file, []string{share})
+	c.mountedAt(first, share)
+	c.state.Frontier[first] = []string{filepath.Join(first, "opt", "a")}
+	c.state.Scanned[first] = 7
+	c.state.Found = []checkpointFound{{Path: filepath.Join(first, "jdk", "bin", "java")}}
+	if err := c.save(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(file)
+	if strings.Contains(string(data), "jfind-share-1") || !strings.Contains(string(data), share+"/opt/a") {
+		t.Errorf("Expected the paths below the share URL, got %s", data)
+	}
+
+	// It resumes on another mount point
+	resumed, err := loadCheckpoint(file, file, []string{share})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed.mountedAt(second, share)
+	if frontier := resumed.state.Frontier[second]; !slices.Equal(frontier, []string{filepath.Join(second, "opt", "a")}) {
+		t.Errorf("Expected the frontier below the new mount point, got %v", resumed.state.Frontier)
+	}
+	if resumed.scannedBefore(second) != 7 || !resumed.found[filepath.Join(second, "jdk", "bin", "java")] {
+		t.Errorf("Expected the progress below the new mount point, got %+v", resumed.state)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxPostSize is the largest POST body sent in one request, below the
+// common 8 MiB request limit of reverse proxies
+const defaultMaxPostSize = "4M"
+
+// ChunkInfo marks a report that was split into several POST requests. All
+// chunks carry the metadata of the full scan; the collector reassembles them by
+// scan_id once the chunk with complete set has arrived.
+type ChunkInfo struct {
+	Sequence int  `json:"sequence"` // 1-based position of the chunk
+	Complete bool `json:"complete"` // set on the last chunk only
+}
+
+// parsePostSize parses -max-post-size, where 0 disables chunking
+func parsePostSize(size string) (int64, error) {
+	if strings.TrimSpace(size) == "0" {
+		return 0, nil
+	}
+	return parseByteSize(size)
+}
+
+// chunkReport splits a report into chunks whose JSON encoding stays below
+// maxSize bytes. A single runtime larger than maxSize still gets a chunk of
+// its own, so the collector can reject it with a meaningful error.
+func chunkReport(output JSONOutput, maxSize int64) ([][]byte, error) {
+	all, err := json.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize <= 0 || int64(len(all)) <= maxSize {
+		return [][]byte{all}, nil
+	}
+
+	// Size of a chunk without runtimes, using the largest chunk marker
+	header := output
+	header.Runtimes = []JavaRuntimeJSON{}
+	header.Meta.Chunk = &ChunkInfo{Sequence: len(output.Runtimes), Complete: true}
+	empty, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups [][]JavaRuntimeJSON
+	var current []JavaRuntimeJSON
+	size := int64(len(empty))
+	for _, runtime := range output.Runtimes {
+		encoded, err := json.Marshal(runtime)
+		if err != nil {
+			return nil, err
+		}
+		// Runtimes are separated by a comma
+		runtimeSize := int64(len(encoded)) + 1
+		if len(current) > 0 && size+runtimeSize > maxSize {
+			groups = append(groups, current)
+			current = nil
+			size = int64(len(empty))
+		}
+		current = append(current, runtime)
+		size += runtimeSize
+	}
+	groups = append(groups, current)
+
+	chunks := make([][]byte, 0, len(groups))
+	for i, group := range groups {
+		chunk := output
+		chunk.Runtimes = group
+		chunk.Meta.Chunk = &ChunkInfo{Sequence: i + 1, Complete: i == len(groups)-1}
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, data)
+	}
+	return chunks, nil
+}
+
+// postChunked posts a report in as many requests as maxPostSize requires
+func postChunked(output JSONOutput, client *http.Client, url string, maxPostSize int64) error {
+	chunks, err := chunkReport(output, maxPostSize)
+	if err != nil {
+		return fmt.Errorf("failed to generate JSON output: %v", err)
+	}
+	if len(chunks) == 1 {
+		logf("Posting JSON to %s...\n", url)
+		return sendJSON(client, chunks[0], url)
+	}
+	logf("Posting JSON to %s in %d chunks...\n", url, len(chunks))
+	for i, chunk := range chunks {
+		if err := sendJSON(client, chunk, url); err != nil {
+			return fmt.Errorf("chunk %d of %d: %v", i+1, len(chunks), err)
+		}
+	}
+	return nil
+}
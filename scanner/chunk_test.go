@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testReport returns a report with n runtimes
+func testReport(n int) JSONOutput {
+	output := JSONOutput{Meta: MetaInfo{ScanID: "scan-1", ComputerName: "build-farm-01", CountResult: n}}
+	for i := 0; i < n; i++ {
+		output.Runtimes = append(output.Runtimes, JavaRuntimeJSON{
+			JavaExecutable: fmt.Sprintf("/opt/agents/%03d/jdk-17/bin/java", i),
+		})
+	}
+	return output
+}
+
+func TestChunkReport(t *testing.T) {
+	output := testReport(100)
+
+	chunks, err := chunkReport(output, 0)
+	if err != nil || len(chunks) != 1 {
+		t.Fatalf("Expected a single chunk without limit, got %d (%v)", len(chunks), err)
+	}
+	var single JSONOutput
+	if err := json.Unmarshal(chunks[0], &single); err != nil || single.Meta.Chunk != nil {
+		t.Errorf("Expected an unmarked report, got %+v (%v)", single.Meta.Chunk, err)
+	}
+
+	const maxSize = 1024
+	chunks, err = chunkReport(output, maxSize)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("Expected several chunks, got %d", len(chunks))
+	}
+
+	seen := 0
+	for i, data := range chunks {
+		if len(data) > maxSize {
+			t.Errorf("Chunk %d has %d bytes, more than %d", i+1, len(data), maxSize)
+		}
+		var chunk JSONOutput
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if chunk.Meta.Chunk == nil || chunk.Meta.Chunk.Sequence != i+1 {
+			t.Errorf("Expected sequence %d, got %+v", i+1, chunk.Meta.Chunk)
+		}
+		if last := i == len(chunks)-1; chunk.Meta.Chunk.Complete != last {
+			t.Errorf("Expected complete=%v on chunk %d", last, i+1)
+		}
+		if chunk.Meta.ScanID != "scan-1" || chunk.Meta.CountResult != 100 {
+			t.Errorf("Expected the metadata of the full scan, got %+v", chunk.Meta)
+		}
+		for _, rt := range chunk.Runtimes {
+			if want := output.Runtimes[seen].JavaExecutable; rt.JavaExecutable != want {
+				t.Errorf("Expected %s, got %s", want, rt.JavaExecutable)
+			}
+			seen++
+		}
+	}
+	if seen != 100 {
+		t.Errorf("Expected 100 runtimes in all chunks, got %d", seen)
+	}
+}
+
+func TestPostChunked(t *testing.T) {
+	var sequences []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var chunk JSONOutput
+		if err := json.Unmarshal(body, &chunk); err != nil || chunk.Meta.Chunk == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		sequences = append(sequences, chunk.Meta.Chunk.Sequence)
+	}))
+	defer server.Close()
+
+	if err := postChunked(testReport(50), server.Client(), server.URL, 2048); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i, seq := range sequences {
+		if seq != i+1 {
+			t.Fatalf("Expected chunks in order, got %v", sequences)
+		}
+	}
+	if len(sequences) < 2 {
+		t.Errorf("Expected several requests, got %v", sequences)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Supported CI systems for the -ci output mode
+const (
+	CIGitHub = "github"
+	CIGitLab = "gitlab"
+	CIAzure  = "azure"
+)
+
+// Severity of a policy violation
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Violation represents a policy finding for a single Java runtime
+type Violation struct {
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+
+	// Finding is the name used to acknowledge the violation, see Acknowledgment
+	Finding string `json:"finding,omitempty"`
+}
+
+// isCIMode checks if mode is a supported CI system
+func isCIMode(mode string) bool {
+	return mode == CIGitHub || mode == CIGitLab || mode == CIAzure
+}
+
+// findViolations applies the built-in policy to the runtimes of a scan
+func findViolations(output *JSONOutput) []Violation {
+	var violations []Violation
+	for _, rt := range output.Runtimes {
+		switch {
+		case rt.RequireLicense != nil && *rt.RequireLicense:
+			violations = append(violations, Violation{
+				Severity: SeverityError,
+				Path:     rt.JavaExecutable,
+				Title:    "Oracle Java requires a commercial license",
+				Finding:  FindingOracleJDK,
+				Message:  fmt.Sprintf("%s %s requires a commercial license", rt.JavaVendor, rt.JavaVersion),
+			})
+		case rt.IsOracle:
+			violations = append(violations, Violation{
+				Severity: SeverityWarning,
+				Path:     rt.JavaExecutable,
+				Title:    "Oracle Java detected",
+				Finding:  FindingOracleJDK,
+				Message:  fmt.Sprintf("%s %s detected", rt.JavaVendor, rt.JavaVersion),
+			})
+		case rt.ExecFailed:
+			violations = append(violations, Violation{
+				Severity: SeverityWarning,
+				Path:     rt.JavaExecutable,
+				Title:    "Java executable could not be evaluated",
+				Finding:  FindingExecFailed,
+				Message:  "Failed to execute java -version",
+			})
+		case rt.ArchMismatch:
+			violations = append(violations, Violation{
+				Severity: SeverityWarning,
+				Path:     rt.JavaExecutable,
+				Title:    "Java executable built for another architecture",
+				Finding:  FindingArchMismatch,
+				Message:  fmt.Sprintf("%s runtime cannot run on this host", rt.BinaryArch),
+			})
+		}
+	}
+	if d := output.DefaultRuntime; d != nil {
+		for _, warning := range d.Warnings {
+			violations = append(violations, Violation{
+				Severity: SeverityWarning,
+				Path:     d.PathJava,
+				Title:    "Inconsistent default Java runtime",
+				Finding:  FindingDefaultJava,
+				Message:  warning,
+			})
+		}
+	}
+	if env := output.JavaEnv; env != nil {
+		for _, injected := range env.Injected {
+			violations = append(violations, Violation{
+				Severity: SeverityWarning,
+				Title:    "Java options injected by the environment",
+				Finding:  FindingJavaEnvInject,
+				Message:  fmt.Sprintf("%s injects %s into every JVM", injected.Variable, injected.Option),
+			})
+		}
+	}
+	return dropAcknowledged(violations, output)
+}
+
+// writeCIReport writes the violations in the format of the given CI system and
+// returns the exit code for the pipeline step (1 if there are errors)
+func writeCIReport(w io.Writer, mode string, violations []Violation) int {
+	exitCode := 0
+	for _, v := range violations {
+		if v.Severity == SeverityError {
+			exitCode = 1
+		}
+	}
+
+	switch mode {
+	case CIGitHub:
+		writeGitHubAnnotations(w, violations)
+	case CIGitLab:
+		writeGitLabCodeQuality(w, violations)
+	case CIAzure:
+		writeAzureLogIssues(w, violations, exitCode != 0)
+	}
+
+	logf("%d policy violation(s) found\n", len(violations))
+	return exitCode
+}
+
+// writeGitHubAnnotations emits GitHub Actions workflow commands
+func writeGitHubAnnotations(w io.Writer, violations []Violation) {
+	for _, v := range violations {
+		fmt.Fprintf(w, "::%s file=%s,title=%s::%s\n",
+			v.Severity, escapeGitHubProperty(v.Path), escapeGitHubProperty(v.Title), escapeGitHubData(v.Message))
+	}
+}
+
+// escapeGitHubData escapes the message part of a workflow command
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a property value of a workflow command
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// gitLabIssue is an entry of a GitLab code quality report
+type gitLabIssue struct {
+	Description string `json:"description"`
+	CheckName   string `json:"check_name"`
+	Fingerprint string `json:"fingerprint"`
+	Severity    string `json:"severity"`
+	Location    struct {
+		Path  string `json:"path"`
+		Lines struct {
+			Begin int `json:"begin"`
+		} `json:"lines"`
+	} `json:"location"`
+}
+
+// writeGitLabCodeQuality emits a GitLab code quality report (to be stored as
+// artifacts:reports:codequality) and a readable summary to stderr
+func writeGitLabCodeQuality(w io.Writer, violations []Violation) {
+	issues := make([]gitLabIssue, 0, len(violations))
+	for _, v := range violations {
+		issue := gitLabIssue{
+			Description: v.Title + ": " + v.Message,
+			CheckName:   v.Title,
+			Severity:    "minor",
+		}
+		if v.Severity == SeverityError {
+			issue.Severity = "major"
+		}
+		sum := sha256.Sum256([]byte(v.Path + "\x00" + v.Title))
+		issue.Fingerprint = hex.EncodeToString(sum[:])
+		issue.Location.Path = v.Path
+		issue.Location.Lines.Begin = 1
+		issues = append(issues, issue)
+
+		logf("%s: %s (%s)\n", strings.ToUpper(string(v.Severity)), v.Message, v.Path)
+	}
+
+	data, _ := json.MarshalIndent(issues, "", "  ")
+	w.Write(data)
+	fmt.Fprintln(w)
+}
+
+// writeAzureLogIssues emits Azure Pipelines logging commands
+func writeAzureLogIssues(w io.Writer, violations []Violation, failed bool) {
+	for _, v := range violations {
+		fmt.Fprintf(w, "##vso[task.logissue type=%s;sourcepath=%s]%s: %s\n",
+			v.Severity, escapeAzureProperty(v.Path), escapeAzureData(v.Title), escapeAzureData(v.Message))
+	}
+	if failed {
+		fmt.Fprintln(w, "##vso[task.complete result=Failed;]Java policy violations found")
+	}
+}
+
+// escapeAzureData escapes the message part of a logging command
+func escapeAzureData(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAzureProperty escapes a property value of a logging command
+func escapeAzureProperty(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A", ";", "%3B", "]", "%5D").Replace(s)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFindViolations(t *testing.T) {
+	output := JSONOutput{
+		Runtimes: []JavaRuntimeJSON{
+			{JavaExecutable: "/opt/oracle8/bin/java", JavaVendor: "Oracle Corporation", IsOracle: true, VersionMajor: 8, VersionUpdate: 351},
+			{JavaExecutable: "/opt/oracle21/bin/java", JavaVendor: "Oracle Corporation", IsOracle: true, VersionMajor: 21},
+			{JavaExecutable: "/opt/temurin/bin/java", JavaVendor: "Eclipse Adoptium", VersionMajor: 17},
+		},
+	}
+	for i := range output.Runtimes {
+		output.Runtimes[i].checkLicenseRequirement()
+	}
+
+	violations := findViolations(&output)
+	if len(violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %d", len(violations))
+	}
+	if violations[0].Severity != SeverityError || violations[1].Severity != SeverityWarning {
+		t.Errorf("Unexpected severities: %s, %s", violations[0].Severity, violations[1].Severity)
+	}
+
+	var buf bytes.Buffer
+	if code := writeCIReport(&buf, CIGitHub, violations); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.HasPrefix(buf.String(), "::error file=/opt/oracle8/bin/java,") {
+		t.Errorf("Unexpected GitHub annotation: %s", buf.String())
+	}
+}
+
+func TestEscapeGitHubProperty(t *testing.T) {
+	got := escapeGitHubProperty(`C:\Program Files\Java, 100%`)
+	want := `C%3A\Program Files\Java%2C 100%25`
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,407 @@
+// Package client is a typed client of the jfind collector API, for tools that
+// submit reports or query the inventory without hand-rolling HTTP calls.
+//
+// The types mirror the JSON the collector routes in src/jfind_svc/routes
+// return. The collector builds its responses by hand, so its OpenAPI document
+// at /openapi.json describes the parameters but not the responses; keep this
+// package in step with the routes when they change.
+//
+//	c := client.New("http://collector:8000")
+//	scans, err := c.ScansByComputer(ctx, "build-01", 5)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the collector API below BaseURL, e.g. http://collector:8000
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	// LabelFilter restricts the queries over all computers to those with all these
+	// labels, e.g. {"env": "prod"}, see WithLabels
+	LabelFilter map[string]string
+}
+
+// New creates a client with a timeout of one minute per request
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// WithLabels returns a copy of the client whose queries over all computers
+// only return computers with all the labels
+func (c *Client) WithLabels(labels map[string]string) *Client {
+	labeled := *c
+	labeled.LabelFilter = labels
+	return &labeled
+}
+
+// APIError is a response of the collector with an error status
+type APIError struct {
+	StatusCode int
+	Detail     string
+}
+
+// Error returns the status code and the detail message of the collector
+func (e *APIError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("collector returned HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("collector returned HTTP %d: %s", e.StatusCode, e.Detail)
+}
+
+// Health is the response of the health check
+type Health struct {
+	Hostname  string `json:"hostname"`
+	ProcessID int    `json:"process_id"`
+	Timestamp string `json:"timestamp"`
+}
+
+// SubmitResult is the response to a submitted report
+type SubmitResult struct {
+	// Result is "ok" once the report is saved, "pending" while chunks of a split report are
+	// missing and "queued" for an encrypted report, which is processed in the background
+	Result            string `json:"result"`
+	ScanID            int    `json:"scan_id,omitempty"`
+	Chunk             int    `json:"chunk,omitempty"`
+	EncryptedReportID int    `json:"encrypted_report_id,omitempty"`
+}
+
+// Pending checks if the collector waits for further chunks of the report
+func (r *SubmitResult) Pending() bool {
+	return r.Result == "pending"
+}
+
+// Scan is a saved report with its runtimes
+type Scan struct {
+	Meta     ScanMeta  `json:"meta"`
+	Runtimes []Runtime `json:"result"`
+}
+
+// ScanMeta is the metadata of a saved report. ScanID is the ID assigned by the
+// collector, ScanUUID the ID generated by the scanner.
+type ScanMeta struct {
+	ScanID        int               `json:"scan_id"`
+	ScanUUID      string            `json:"scan_uuid,omitempty"`
+	ReportPhase   string            `json:"report_phase,omitempty"`
+	ScanTimestamp string            `json:"scan_ts"`
+	ComputerName  string            `json:"computer_name"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	UserName      string            `json:"user_name"`
+	ScanDuration  string            `json:"scan_duration"`
+	HasOracleJDK  bool              `json:"has_oracle_jdk"`
+	CountResult   int               `json:"count_result"`
+	ScannedDirs   int               `json:"scanned_dirs"`
+}
+
+// Runtime is a Java runtime of a saved report
+type Runtime struct {
+	JavaExecutable    string `json:"java_executable"`
+	JavaRuntime       string `json:"java_runtime,omitempty"`
+	JavaVendor        string `json:"java_vendor,omitempty"`
+	IsOracle          bool   `json:"is_oracle"`
+	JavaVersion       string `json:"java_version,omitempty"`
+	JavaVersionMajor  *int   `json:"java_version_major,omitempty"`
+	JavaVersionUpdate *int   `json:"java_version_update,omitempty"`
+}
+
+// OracleRuntime is an Oracle runtime and the report it was found in
+type OracleRuntime struct {
+	ScanID       int    `json:"scan_id"`
+	ComputerName string `json:"computer_name"`
+	Runtime
+}
+
+// UpdateDrift is a computer running several update levels of the same distribution and major version
+type UpdateDrift struct {
+	ComputerName     string   `json:"computer_name"`
+	JavaVendor       string   `json:"java_vendor"`
+	JavaVersionMajor int      `json:"java_version_major"`
+	Versions         []string `json:"versions"`
+	Paths            []string `json:"paths"`
+}
+
+// RemovedRuntime is a runtime that disappeared from the newest report of its computer.
+// The times are in UTC without time zone, e.g. 2024-03-01T12:00:00.
+type RemovedRuntime struct {
+	ComputerName   string `json:"computer_name"`
+	JavaExecutable string `json:"java_executable"`
+	JavaVendor     string `json:"java_vendor"`
+	JavaVersion    string `json:"java_version"`
+	IsOracle       bool   `json:"is_oracle"`
+	FirstSeen      string `json:"first_seen"`
+	LastSeen       string `json:"last_seen"`
+	RemovedAt      string `json:"removed_at"`
+}
+
+// Acknowledgment is an acknowledged finding that no longer alerts. An empty
+// Finding, Host or Path matches any; Host and Path are glob patterns.
+type Acknowledgment struct {
+	ID      string `json:"id,omitempty"`
+	Finding string `json:"finding,omitempty"`
+	Host    string `json:"host,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Note    string `json:"note"`
+	Ticket  string `json:"ticket,omitempty"`
+	Expires string `json:"expires,omitempty"`
+}
+
+// Label is a label key with its values and the number of computers carrying each
+type Label struct {
+	Key    string `json:"key"`
+	Values []struct {
+		Value     string `json:"value"`
+		Computers int    `json:"computers"`
+	} `json:"values"`
+}
+
+// LabelSummary aggregates the latest scans of the computers with one value of a
+// label. Value is nil for the computers without the label.
+type LabelSummary struct {
+	Value           *string        `json:"value"`
+	Computers       int            `json:"computers"`
+	Runtimes        int            `json:"runtimes"`
+	OracleComputers int            `json:"oracle_computers"`
+	Ratings         map[string]int `json:"ratings"`
+}
+
+// RemovedOptions filter the removed runtimes, zero values use the defaults of the collector
+type RemovedOptions struct {
+	OracleOnly bool
+	Days       int
+	Limit      int
+}
+
+// Health checks that the collector is up
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var health Health
+	if err := c.do(ctx, http.MethodGet, "/health", nil, nil, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// SubmitReport posts a report of the scanner, any value that encodes to the
+// JSON document of jfind -json
+func (c *Client) SubmitReport(ctx context.Context, report any) (*SubmitResult, error) {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode report: %v", err)
+	}
+	var result SubmitResult
+	if err := c.do(ctx, http.MethodPost, "/api/jfind", nil, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// LatestScans returns the newest reports of all computers
+func (c *Client) LatestScans(ctx context.Context, limit int) ([]Scan, error) {
+	var scans []Scan
+	err := c.do(ctx, http.MethodGet, "/api/jfind/scans", c.labelQuery(limitQuery(limit)), nil, &scans)
+	return scans, err
+}
+
+// Scan returns the report with the ID assigned by the collector, an *APIError with status 404 if there is none
+func (c *Client) Scan(ctx context.Context, id int) (*Scan, error) {
+	var scans []Scan
+	query := url.Values{"scan_id": {strconv.Itoa(id)}}
+	if err := c.do(ctx, http.MethodGet, "/api/jfind", query, nil, &scans); err != nil {
+		return nil, err
+	}
+	if len(scans) == 0 {
+		return nil, &APIError{StatusCode: http.StatusNotFound}
+	}
+	return &scans[0], nil
+}
+
+// ScansByComputer returns the newest reports of a computer
+func (c *Client) ScansByComputer(ctx context.Context, computerName string, limit int) ([]Scan, error) {
+	var scans []Scan
+	err := c.do(ctx, http.MethodGet, "/api/jfind/computer/"+url.PathEscape(computerName), limitQuery(limit), nil, &scans)
+	return scans, err
+}
+
+// OracleRuntimes returns the Oracle runtimes of all reports
+func (c *Client) OracleRuntimes(ctx context.Context, limit int) ([]OracleRuntime, error) {
+	var runtimes []OracleRuntime
+	err := c.do(ctx, http.MethodGet, "/api/jfind/oracle", c.labelQuery(limitQuery(limit)), nil, &runtimes)
+	return runtimes, err
+}
+
+// HasOracleJDK checks if a computer has an Oracle JDK, nil if the collector has no records of it
+func (c *Client) HasOracleJDK(ctx context.Context, computerName string) (*bool, error) {
+	var status struct {
+		HasOracle string `json:"has_oracle"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/jfind/oracle/"+url.PathEscape(computerName), nil, nil, &status); err != nil {
+		return nil, err
+	}
+	switch status.HasOracle {
+	case "true", "false":
+		hasOracle := status.HasOracle == "true"
+		return &hasOracle, nil
+	case "unknown":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unexpected has_oracle value %q", status.HasOracle)
+}
+
+// UpdateDrift returns the computers running several update levels of the same major version
+func (c *Client) UpdateDrift(ctx context.Context) ([]UpdateDrift, error) {
+	var drift []UpdateDrift
+	err := c.do(ctx, http.MethodGet, "/api/jfind/update-drift", c.labelQuery(nil), nil, &drift)
+	return drift, err
+}
+
+// RemovedRuntimes returns the runtimes that disappeared from the newest report of their computer
+func (c *Client) RemovedRuntimes(ctx context.Context, opts RemovedOptions) ([]RemovedRuntime, error) {
+	query := limitQuery(opts.Limit)
+	if opts.OracleOnly {
+		query.Set("oracle", "true")
+	}
+	if opts.Days > 0 {
+		query.Set("days", strconv.Itoa(opts.Days))
+	}
+	var runtimes []RemovedRuntime
+	err := c.do(ctx, http.MethodGet, "/api/jfind/removed", c.labelQuery(query), nil, &runtimes)
+	return runtimes, err
+}
+
+// Labels returns the labels the computers carry as of their newest reports
+func (c *Client) Labels(ctx context.Context) ([]Label, error) {
+	var labels []Label
+	err := c.do(ctx, http.MethodGet, "/api/jfind/labels", nil, nil, &labels)
+	return labels, err
+}
+
+// LabelSummary aggregates the latest scans of the computers by the values of a label
+func (c *Client) LabelSummary(ctx context.Context, key string) ([]LabelSummary, error) {
+	var summary []LabelSummary
+	err := c.do(ctx, http.MethodGet, "/api/jfind/labels/"+url.PathEscape(key), c.labelQuery(nil), nil, &summary)
+	return summary, err
+}
+
+// Acknowledgments returns the acknowledged findings, only those in effect for
+// computerName unless it is empty
+func (c *Client) Acknowledgments(ctx context.Context, computerName string) ([]Acknowledgment, error) {
+	query := url.Values{}
+	if computerName != "" {
+		query.Set("computer_name", computerName)
+	}
+	var acks []Acknowledgment
+	err := c.do(ctx, http.MethodGet, "/api/jfind/acknowledgments", query, nil, &acks)
+	return acks, err
+}
+
+// AddAcknowledgment acknowledges a finding and returns it with the ID assigned by the collector
+func (c *Client) AddAcknowledgment(ctx context.Context, ack Acknowledgment) (*Acknowledgment, error) {
+	body, err := json.Marshal(ack)
+	if err != nil {
+		return nil, err
+	}
+	var created Acknowledgment
+	if err := c.do(ctx, http.MethodPost, "/api/jfind/acknowledgments", nil, body, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// DeleteAcknowledgment withdraws an acknowledgment, so the finding alerts again
+func (c *Client) DeleteAcknowledgment(ctx context.Context, id string) error {
+	var result struct {
+		Result string `json:"result"`
+	}
+	return c.do(ctx, http.MethodDelete, "/api/jfind/acknowledgments/"+url.PathEscape(id), nil, nil, &result)
+}
+
+// limitQuery sets the limit parameter unless it is 0, which keeps the default of the collector
+func limitQuery(limit int) url.Values {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	return query
+}
+
+// labelQuery adds the label filter of the client to the query parameters
+func (c *Client) labelQuery(query url.Values) url.Values {
+	if query == nil {
+		query = url.Values{}
+	}
+	keys := make([]string, 0, len(c.LabelFilter))
+	for key := range c.LabelFilter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		query.Add("label", key+"="+c.LabelFilter[key])
+	}
+	return query
+}
+
+// do sends a request and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body []byte, out any) error {
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		// FastAPI reports errors as {"detail": ...}, a string or a list of validation errors
+		var errBody struct {
+			Detail json.RawMessage `json:"detail"`
+		}
+		if json.Unmarshal(data, &errBody) == nil && len(errBody.Detail) > 0 {
+			var detail string
+			if json.Unmarshal(errBody.Detail, &detail) != nil {
+				detail = string(errBody.Detail)
+			}
+			apiErr.Detail = detail
+		}
+		return apiErr
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestServer serves canned collector responses and records the last request
+func newTestServer(t *testing.T, last **http.Request, lastBody *[]byte) *Client {
+	t.Helper()
+	routes := map[string]string{
+		"POST /api/jfind":                     `{"result": "ok", "scan_id": 42}`,
+		"GET /api/jfind/scans":                `[{"meta": {"scan_id": 42, "scan_uuid": "0b7e", "scan_ts": "2024-03-01T12:00:00", "computer_name": "build-01", "count_result": 1}, "result": [{"java_executable": "/usr/bin/java", "is_oracle": true, "java_version_major": 17}]}]`,
+		"GET /api/jfind":                      `[]`,
+		"GET /api/jfind/computer/build 01":    `[]`,
+		"GET /api/jfind/oracle":               `[{"scan_id": 42, "computer_name": "build-01", "java_executable": "/usr/bin/java", "is_oracle": true}]`,
+		"GET /api/jfind/oracle/build-01":      `{"computer_name": "build-01", "has_oracle": "unknown"}`,
+		"GET /api/jfind/update-drift":         `[{"computer_name": "build-01", "java_vendor": "Eclipse Adoptium", "java_version_major": 17, "versions": ["17.0.8", "17.0.10"], "paths": ["/a", "/b"]}]`,
+		"GET /api/jfind/removed":              `[{"computer_name": "build-01", "java_executable": "/opt/jdk/bin/java", "is_oracle": true, "removed_at": "2024-03-02T00:00:00"}]`,
+		"GET /api/jfind/acknowledgments":      `[{"id": "3", "finding": "oracle_jdk", "host": "build-*", "note": "licensed", "ticket": "LIC-123"}]`,
+		"POST /api/jfind/acknowledgments":     `{"id": "4", "finding": "oracle_jdk", "note": "licensed"}`,
+		"DELETE /api/jfind/acknowledgments/4": `{"result": "ok"}`,
+		"GET /api/jfind/labels":               `[{"key": "env", "values": [{"value": "prod", "computers": 2}]}]`,
+		"GET /api/jfind/labels/env":           `[{"value": "prod", "computers": 2, "runtimes": 5, "oracle_computers": 1, "ratings": {"red": 1, "green": 1}}, {"value": null, "computers": 1}]`,
+		"GET /health":                         `{"hostname": "collector", "process_id": 7, "timestamp": "2024-03-01T12:00:00"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*last = r
+		*lastBody, _ = io.ReadAll(r.Body)
+		if r.URL.Query().Get("scan_id") == "404" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail": "Scan with ID 404 not found"}`))
+			return
+		}
+		response, ok := routes[r.Method+" "+r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return New(server.URL + "/")
+}
+
+func TestClient(t *testing.T) {
+	var last *http.Request
+	var lastBody []byte
+	c := newTestServer(t, &last, &lastBody)
+	ctx := context.Background()
+
+	result, err := c.SubmitReport(ctx, map[string]any{"meta": map[string]string{"computer_name": "build-01"}})
+	if err != nil || result.ScanID != 42 || result.Pending() {
+		t.Fatalf("Unexpected submit result %+v, %v", result, err)
+	}
+	var posted map[string]any
+	if err := json.Unmarshal(lastBody, &posted); err != nil || last.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected the report as JSON, got %q", lastBody)
+	}
+
+	scans, err := c.LatestScans(ctx, 5)
+	if err != nil || len(scans) != 1 || last.URL.Query().Get("limit") != "5" {
+		t.Fatalf("Unexpected scans %+v, %v", scans, err)
+	}
+	if rt := scans[0].Runtimes[0]; !rt.IsOracle || rt.JavaVersionMajor == nil || *rt.JavaVersionMajor != 17 || rt.JavaVersionUpdate != nil {
+		t.Errorf("Unexpected runtime %+v", rt)
+	}
+
+	if _, err := c.ScansByComputer(ctx, "build 01", 0); err != nil || last.URL.Query().Has("limit") {
+		t.Errorf("Expected the default limit, got %v, %v", last.URL, err)
+	}
+
+	oracle, err := c.OracleRuntimes(ctx, 0)
+	if err != nil || len(oracle) != 1 || oracle[0].ComputerName != "build-01" || oracle[0].JavaExecutable != "/usr/bin/java" {
+		t.Errorf("Unexpected Oracle runtimes %+v, %v", oracle, err)
+	}
+
+	if hasOracle, err := c.HasOracleJDK(ctx, "build-01"); err != nil || hasOracle != nil {
+		t.Errorf("Expected unknown, got %v, %v", hasOracle, err)
+	}
+
+	drift, err := c.UpdateDrift(ctx)
+	if err != nil || len(drift) != 1 || len(drift[0].Versions) != 2 {
+		t.Errorf("Unexpected drift %+v, %v", drift, err)
+	}
+
+	removed, err := c.RemovedRuntimes(ctx, RemovedOptions{OracleOnly: true, Days: 30})
+	if err != nil || len(removed) != 1 {
+		t.Errorf("Unexpected removed runtimes %+v, %v", removed, err)
+	}
+	if q := last.URL.Query(); q.Get("oracle") != "true" || q.Get("days") != "30" || q.Has("limit") {
+		t.Errorf("Unexpected query %s", last.URL.RawQuery)
+	}
+
+	acks, err := c.Acknowledgments(ctx, "build-01")
+	if err != nil || len(acks) != 1 || acks[0].Ticket != "LIC-123" || last.URL.Query().Get("computer_name") != "build-01" {
+		t.Errorf("Unexpected acknowledgments %+v, %v", acks, err)
+	}
+	created, err := c.AddAcknowledgment(ctx, Acknowledgment{Finding: "oracle_jdk", Note: "licensed"})
+	if err != nil || created.ID != "4" {
+		t.Errorf("Unexpected acknowledgment %+v, %v", created, err)
+	}
+	if err := c.DeleteAcknowledgment(ctx, created.ID); err != nil {
+		t.Error(err)
+	}
+
+	labels, err := c.Labels(ctx)
+	if err != nil || len(labels) != 1 || labels[0].Key != "env" || labels[0].Values[0].Computers != 2 {
+		t.Errorf("Unexpected labels %+v, %v", labels, err)
+	}
+	prod := c.WithLabels(map[string]string{"env": "prod", "datacenter": "fra1"})
+	if _, err := prod.LatestScans(ctx, 5); err != nil {
+		t.Error(err)
+	}
+	if q := last.URL.Query(); q.Get("limit") != "5" || strings.Join(q["label"], ",") != "datacenter=fra1,env=prod" {
+		t.Errorf("Expected the label filter, got %s", last.URL.RawQuery)
+	}
+	summary, err := prod.LabelSummary(ctx, "env")
+	if err != nil || len(summary) != 2 || *summary[0].Value != "prod" || summary[0].Ratings["red"] != 1 || summary[1].Value != nil {
+		t.Errorf("Unexpected label summary %+v, %v", summary, err)
+	}
+	if c.LabelFilter != nil {
+		t.Error("Expected WithLabels to leave the client as it is")
+	}
+
+	if health, err := c.Health(ctx); err != nil || health.ProcessID != 7 {
+		t.Errorf("Unexpected health %+v, %v", health, err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	var last *http.Request
+	var lastBody []byte
+	c := newTestServer(t, &last, &lastBody)
+
+	_, err := c.Scan(context.Background(), 404)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Detail != "Scan with ID 404 not found" {
+		t.Errorf("Expected a 404 error with detail, got %v", err)
+	}
+
+	// An empty list is not found either
+	if _, err := c.Scan(context.Background(), 1); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 error for an empty result, got %v", err)
+	}
+}
+
+// apiOperation is an endpoint of the collector the client uses, with the query
+// parameters it may send
+type apiOperation struct {
+	Method string   `json:"method"`
+	Path   string   `json:"path"`
+	Query  []string `json:"query"`
+}
+
+// matches reports whether a request is sent to the endpoint, whose path may
+// have {parameters}
+func (op apiOperation) matches(r *http.Request) bool {
+	pattern := regexp.MustCompile(`\\\{[^}]+\\\}`).ReplaceAllString(regexp.QuoteMeta(op.Path), `[^/]+`)
+	return op.Method == r.Method && regexp.MustCompile("^"+pattern+"$").MatchString(r.URL.Path)
+}
+
+// apiSpec lists the operations of the collector the client uses and the fields
+// of the bodies it sends. It is shared with the collector's test_client_api.py,
+// which checks it against the OpenAPI schema of the collector.
+type apiSpec struct {
+	Operations []apiOperation      `json:"operations"`
+	Schemas    map[string][]string `json:"schemas"`
+}
+
+func TestClientConformsToSpec(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "testdata", "client_api.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec apiSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		w.Write([]byte("null"))
+	}))
+	defer server.Close()
+
+	// Every method with every option, the responses do not matter
+	ctx := context.Background()
+	c := New(server.URL).WithLabels(map[string]string{"env": "prod"})
+	c.Health(ctx)
+	c.SubmitReport(ctx, map[string]any{})
+	c.Scan(ctx, 42)
+	c.LatestScans(ctx, 5)
+	c.ScansByComputer(ctx, "build-01", 5)
+	c.OracleRuntimes(ctx, 5)
+	c.HasOracleJDK(ctx, "build-01")
+	c.UpdateDrift(ctx)
+	c.RemovedRuntimes(ctx, RemovedOptions{OracleOnly: true, Days: 30, Limit: 5})
+	c.Labels(ctx)
+	c.LabelSummary(ctx, "env")
+	c.Acknowledgments(ctx, "build-01")
+	c.AddAcknowledgment(ctx, Acknowledgment{Note: "licensed"})
+	c.DeleteAcknowledgment(ctx, "4")
+
+	used := make(map[int]bool)
+	for _, r := range requests {
+		op := slices.IndexFunc(spec.Operations, func(op apiOperation) bool { return op.matches(r) })
+		if op < 0 {
+			t.Errorf("%s %s is not in the spec", r.Method, r.URL.Path)
+			continue
+		}
+		used[op] = true
+		for key := range r.URL.Query() {
+			if !slices.Contains(spec.Operations[op].Query, key) {
+				t.Errorf("%s %s sends query parameter %s, which is not in the spec", r.Method, r.URL.Path, key)
+			}
+		}
+	}
+	for i, op := range spec.Operations {
+		if !used[i] {
+			t.Errorf("%s %s of the spec is not used by the client", op.Method, op.Path)
+		}
+	}
+
+	var fields []string
+	ack := reflect.TypeOf(Acknowledgment{})
+	for i := 0; i < ack.NumField(); i++ {
+		fields = append(fields, strings.Split(ack.Field(i).Tag.Get("json"), ",")[0])
+	}
+	slices.Sort(fields)
+	if !slices.Equal(fields, spec.Schemas["Acknowledgment"]) {
+		t.Errorf("Expected the fields %v of Acknowledgment, got %v", spec.Schemas["Acknowledgment"], fields)
+	}
+}
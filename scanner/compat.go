@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// legacySchema is the -compat level of the original flat report, which
+// collectors that predate schema_version parse
+const legacySchema = 1
+
+// legacyRuntimeJSON is a runtime of the original report
+type legacyRuntimeJSON struct {
+	JavaExecutable string `json:"java_executable"`
+	JavaRuntime    string `json:"java_runtime,omitempty"`
+	JavaVendor     string `json:"java_vendor,omitempty"`
+	IsOracle       bool   `json:"is_oracle,omitempty"`
+	JavaVersion    string `json:"java_version,omitempty"`
+	VersionMajor   int    `json:"java_version_major,omitempty"`
+	VersionUpdate  int    `json:"java_version_update,omitempty"`
+	ExecFailed     bool   `json:"exec_failed,omitempty"`
+	RequireLicense *bool  `json:"require_license"`
+}
+
+// legacyMetaInfo is the metadata of the original report
+type legacyMetaInfo struct {
+	ScanTimestamp string `json:"scan_ts"`
+	ComputerName  string `json:"computer_name"`
+	UserName      string `json:"user_name"`
+	ScanDuration  string `json:"scan_duration"`
+	HasOracleJDK  bool   `json:"has_oracle_jdk"`
+	CountResult   int    `json:"count_result"`
+	ScannedDirs   int    `json:"scanned_dirs"`
+}
+
+// legacyJSONOutput is the original report, with only the fields that existed
+// before the schema was versioned
+type legacyJSONOutput struct {
+	Meta     legacyMetaInfo      `json:"meta"`
+	Runtimes []legacyRuntimeJSON `json:"result"`
+}
+
+// legacyReport converts a report to the original schema, dropping everything added since
+func legacyReport(output JSONOutput) legacyJSONOutput {
+	legacy := legacyJSONOutput{
+		Meta: legacyMetaInfo{
+			ScanTimestamp: output.Meta.ScanTimestamp,
+			ComputerName:  output.Meta.ComputerName,
+			UserName:      output.Meta.UserName,
+			ScanDuration:  output.Meta.ScanDuration,
+			HasOracleJDK:  output.Meta.HasOracleJDK,
+			CountResult:   output.Meta.CountResult,
+			ScannedDirs:   output.Meta.ScannedDirs,
+		},
+		Runtimes: make([]legacyRuntimeJSON, 0, len(output.Runtimes)),
+	}
+	for _, runtime := range output.Runtimes {
+		legacy.Runtimes = append(legacy.Runtimes, legacyRuntimeJSON{
+			JavaExecutable: runtime.JavaExecutable,
+			JavaRuntime:    runtime.JavaRuntime,
+			JavaVendor:     runtime.JavaVendor,
+			IsOracle:       runtime.IsOracle,
+			JavaVersion:    runtime.JavaVersion,
+			VersionMajor:   runtime.VersionMajor,
+			VersionUpdate:  runtime.VersionUpdate,
+			ExecFailed:     runtime.ExecFailed,
+			RequireLicense: runtime.RequireLicense,
+		})
+	}
+	return legacy
+}
+
+// reportLegacyJSON writes or posts a report in the original schema. It is
+// always posted in one request, since old collectors cannot reassemble chunks.
+func reportLegacyJSON(output JSONOutput, client *http.Client, url string) error {
+	legacy := legacyReport(output)
+	if client != nil {
+		jsonData, err := json.Marshal(legacy)
+		if err != nil {
+			return fmt.Errorf("failed to generate JSON output: %v", err)
+		}
+		logf("Posting JSON to %s...\n", url)
+		return sendJSON(client, jsonData, url)
+	}
+	jsonData, err := json.MarshalIndent(legacy, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to generate JSON output: %v", err)
+	}
+	os.Stdout.Write(append(jsonData, '\n'))
+	return nil
+}
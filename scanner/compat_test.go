@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLegacyReport(t *testing.T) {
+	result := &JavaResult{
+		Path:      "/opt/jdk-17/bin/java",
+		Evaluated: true,
+		Status:    ProbeOK,
+		Properties: &JavaProperties{
+			Version:     "17.0.9",
+			Vendor:      "Oracle Corporation",
+			RuntimeName: "Java(TM) SE Runtime Environment",
+			Major:       17,
+			Update:      9,
+		},
+	}
+	finder := NewJavaFinder("/opt", -1, false, true)
+	finder.scanned = 12
+	output := buildJSONOutput([]*JavaResult{result}, finder, time.Now())
+
+	data, err := json.Marshal(legacyReport(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Meta   map[string]any   `json:"meta"`
+		Result []map[string]any `json:"result"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	meta := slices.Sorted(maps.Keys(report.Meta))
+	if want := []string{"computer_name", "count_result", "has_oracle_jdk", "scan_duration", "scan_ts", "scanned_dirs", "user_name"}; !slices.Equal(meta, want) {
+		t.Errorf("Expected only the original meta fields, got %v", meta)
+	}
+	if report.Meta["scanned_dirs"] != float64(12) || report.Meta["has_oracle_jdk"] != output.Meta.HasOracleJDK {
+		t.Errorf("Expected the counters of the scan, got %v", report.Meta)
+	}
+	if len(report.Result) != 1 {
+		t.Fatalf("Expected one runtime, got %v", report.Result)
+	}
+	for key := range report.Result[0] {
+		switch key {
+		case "java_executable", "java_runtime", "java_vendor", "is_oracle", "java_version",
+			"java_version_major", "java_version_update", "exec_failed", "require_license":
+		default:
+			t.Errorf("Expected only the original runtime fields, got %s", key)
+		}
+	}
+	if runtime := report.Result[0]; runtime["java_version"] != "17.0.9" || runtime["java_version_major"] != float64(17) {
+		t.Errorf("Expected the version of the runtime, got %v", runtime)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+// Evidence that makes a result more or less likely to be a working Java runtime
+const (
+	EvidenceExecutableFormat = "executable_format"
+	EvidenceReleaseFile      = "release_file"
+	EvidenceEvaluated        = "evaluated"
+	EvidenceProbeFailed      = "probe_failed"
+	EvidenceSystemPath       = "system_path"
+	EvidenceEphemeralPath    = "ephemeral_path"
+	EvidenceMissingLibraries = "missing_libraries"
+	EvidenceBuildToolManaged = "build_tool_managed"
+)
+
+const (
+	// baseConfidence is the score of a file named java with execute permission
+	baseConfidence = 20
+
+	// Minimum scores of the confidence levels
+	confidenceHigh   = 70
+	confidenceMedium = 40
+)
+
+// evidenceWeights is the score each piece of evidence adds to the base confidence
+var evidenceWeights = map[string]int{
+	EvidenceExecutableFormat: 25,
+	EvidenceReleaseFile:      20,
+	EvidenceEvaluated:        30,
+	EvidenceProbeFailed:      -20,
+	EvidenceSystemPath:       5,
+	EvidenceBuildToolManaged: 5,
+	EvidenceEphemeralPath:    -10,
+	EvidenceMissingLibraries: -15,
+}
+
+// scoreConfidence rates from 0 to 100 how certain it is that a result is a real,
+// working Java runtime and returns the evidence the score is based on
+func scoreConfidence(result *JavaResult) (int, []string) {
+	var evidence []string
+	if result.Binary != nil {
+		evidence = append(evidence, EvidenceExecutableFormat)
+	}
+	if result.Release["JAVA_VERSION"] != "" {
+		evidence = append(evidence, EvidenceReleaseFile)
+	}
+	switch {
+	case result.Status == ProbeOK && result.Properties != nil && result.Properties.Version != "":
+		evidence = append(evidence, EvidenceEvaluated)
+	case result.Status == ProbeFailed || result.Status == ProbeCrashed:
+		evidence = append(evidence, EvidenceProbeFailed)
+	}
+	switch result.PathClass {
+	case PathClassSystem:
+		evidence = append(evidence, EvidenceSystemPath)
+	case PathClassEphemeral:
+		evidence = append(evidence, EvidenceEphemeralPath)
+	}
+	if result.Provisioner != "" {
+		evidence = append(evidence, EvidenceBuildToolManaged)
+	}
+	if len(result.DependencyIssues) > 0 {
+		evidence = append(evidence, EvidenceMissingLibraries)
+	}
+
+	score := baseConfidence
+	for _, e := range evidence {
+		score += evidenceWeights[e]
+	}
+	return min(max(score, 0), 100), evidence
+}
+
+// confidenceLevel maps a confidence score to high, medium or low
+func confidenceLevel(score int) string {
+	switch {
+	case score >= confidenceHigh:
+		return "high"
+	case score >= confidenceMedium:
+		return "medium"
+	default:
+		return "low"
+	}
+}
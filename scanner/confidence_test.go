@@ -0,0 +1,49 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"jfind/internal/fakejdk"
+)
+
+func TestScoreConfidence(t *testing.T) {
+	root := t.TempDir()
+	working := fakejdk.Create(t, filepath.Join(root, "jdk-17"), fakejdk.JDK{Version: "17.0.9", Vendor: "Eclipse Adoptium"})
+	failing := fakejdk.Create(t, filepath.Join(root, "broken"), fakejdk.JDK{Version: "17.0.9", ExitCode: 1, NoRelease: true})
+	script := createFakeJava(t, filepath.Join(root, "downloads"))
+
+	finder := NewJavaFinder(root, -1, false, true)
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scores := make(map[string]int)
+	for _, result := range results {
+		scores[result.Path] = result.Confidence
+	}
+
+	if level := confidenceLevel(scores[working]); level != "high" {
+		t.Errorf("Expected high confidence for an evaluated JDK, got %d (%s)", scores[working], level)
+	}
+	if scores[failing] >= scores[working] {
+		t.Errorf("Expected a failing JDK to score below a working one, got %d and %d", scores[failing], scores[working])
+	}
+	if level := confidenceLevel(scores[script]); level != "low" {
+		t.Errorf("Expected low confidence for a script named java, got %d (%s)", scores[script], level)
+	}
+}
+
+func TestScoreConfidenceBounds(t *testing.T) {
+	score, evidence := scoreConfidence(&JavaResult{
+		Status:           ProbeCrashed,
+		PathClass:        PathClassEphemeral,
+		DependencyIssues: []string{"missing shared library libjli.so"},
+	})
+	if score != 0 {
+		t.Errorf("Expected score to be clamped to 0, got %d", score)
+	}
+	if len(evidence) != 3 {
+		t.Errorf("Expected 3 pieces of evidence, got %v", evidence)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config represents the optional JSON configuration file passed with -config
+type Config struct {
+	// Version is the version of the configuration format, older ones are migrated on load
+	Version int `json:"version,omitempty"`
+
+	// PathRules are evaluated before the built-in rules, first match wins
+	PathRules []PathRule `json:"path_rules,omitempty"`
+
+	// EvalCommand is the command template to evaluate java executables (see -eval-cmd)
+	EvalCommand string `json:"eval_command,omitempty"`
+
+	// SourceBudgets limit the time per discovery source, e.g. {"filesystem": "10m"}
+	SourceBudgets map[string]string `json:"source_budgets,omitempty"`
+
+	// RequiredModules replace the modules checked with -modules
+	RequiredModules []string `json:"required_modules,omitempty"`
+
+	// Enrichers is the chain of enrichers run on every result (see -enrich)
+	Enrichers []string `json:"enrichers,omitempty"`
+
+	// CVEFeed is the path of the vulnerability feed used by the cve enricher
+	CVEFeed string `json:"cve_feed,omitempty"`
+
+	// VendorAPI configures the cache and rate limit of the online enrichers, e.g. adoptium
+	VendorAPI *VendorAPI `json:"vendor_api,omitempty"`
+
+	// TZDataLatest is the newest tzdata release or the URL of a file holding it,
+	// older runtimes are outdated (tzdata enricher)
+	TZDataLatest string `json:"tzdata_latest,omitempty"`
+
+	// TrustedPaths and UntrustedPaths are globs of the locations where -eval may run
+	// runtimes and where it only reads their release file
+	TrustedPaths   []string `json:"trusted_paths,omitempty"`
+	UntrustedPaths []string `json:"untrusted_paths,omitempty"`
+
+	// Schedule is when jfind serve scans, the -cron, -interval and -once flags override it
+	Schedule *Schedule `json:"schedule,omitempty"`
+
+	// BlackoutWindows defer the scheduled scans of jfind serve
+	BlackoutWindows []BlackoutWindow `json:"blackout_windows,omitempty"`
+
+	// Hostname overrides the computer name of the reports, IdentitySources are the
+	// sources of the computer name tried in order if it is not set (see -identity)
+	Hostname        string   `json:"hostname,omitempty"`
+	IdentitySources []string `json:"identity_sources,omitempty"`
+
+	// Labels group the host in the collector, e.g. {"datacenter": "fra1", "env": "prod"};
+	// CloudLabels adds the tags of the cloud instance (see -label and -cloud-labels)
+	Labels      map[string]string `json:"labels,omitempty"`
+	CloudLabels bool              `json:"cloud_labels,omitempty"`
+
+	// Retention limits the disk space used by the reports and history of jfind serve
+	Retention Retention `json:"retention"`
+
+	// Replacements is the path of replacement rules tried before the built-in ones
+	Replacements string `json:"replacements,omitempty"`
+
+	// ReleaseCatalog is the path or URL of the newest releases of the distributions,
+	// which replace the built-in ones (see latest_available)
+	ReleaseCatalog string `json:"release_catalog,omitempty"`
+
+	// Quarantine is the policy of jfind serve -enforce quarantine
+	Quarantine *QuarantinePolicy `json:"quarantine,omitempty"`
+
+	// AgentConfigs are globs of service configurations searched for Java agents in
+	// addition to the built-in ones (see -agents)
+	AgentConfigs []string `json:"agent_configs,omitempty"`
+
+	// VulnerableLibraries is the path of library rules used in addition to the built-in ones (see -libs)
+	VulnerableLibraries string `json:"vulnerable_libraries,omitempty"`
+
+	// LicenseCosts enable the cost_estimate section of the reports
+	LicenseCosts *LicenseCosts `json:"license_costs,omitempty"`
+
+	// Trigger is the HTTP listener of jfind serve on which other systems start
+	// scans of the ScanProfiles
+	Trigger      *TriggerListener       `json:"trigger,omitempty"`
+	ScanProfiles map[string]ScanProfile `json:"scan_profiles,omitempty"`
+
+	budgets map[string]time.Duration
+	api     *vendorAPI
+}
+
+// LoadConfig reads and parses a JSON configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %v", path, err)
+	}
+	if data, _, _, err = migrateConfig(data); err != nil {
+		return nil, fmt.Errorf("failed to migrate config %s: %v", path, err)
+	}
+
+	cfg := &Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %v", path, err)
+	}
+
+	for i, rule := range cfg.PathRules {
+		if !rule.Class.valid() {
+			return nil, fmt.Errorf("invalid class %q in path rule %d of %s", rule.Class, i+1, path)
+		}
+	}
+
+	if cfg.Schedule != nil {
+		if _, err := cfg.Schedule.newScheduler(defaultScanInterval); err != nil {
+			return nil, fmt.Errorf("%v in %s", err, path)
+		}
+	}
+
+	for i := range cfg.BlackoutWindows {
+		if err := cfg.BlackoutWindows[i].parse(); err != nil {
+			return nil, fmt.Errorf("%v in %s", err, path)
+		}
+	}
+
+	if len(cfg.IdentitySources) > 0 {
+		if _, err := parseIdentitySources(strings.Join(cfg.IdentitySources, ",")); err != nil {
+			return nil, fmt.Errorf("%v in %s", err, path)
+		}
+	}
+
+	if err := cfg.Retention.parse(); err != nil {
+		return nil, fmt.Errorf("%v in %s", err, path)
+	}
+
+	if cfg.Quarantine != nil {
+		if err := cfg.Quarantine.parse(); err != nil {
+			return nil, fmt.Errorf("%v in %s", err, path)
+		}
+	}
+
+	if cfg.LicenseCosts != nil {
+		if err := cfg.LicenseCosts.parse(); err != nil {
+			return nil, fmt.Errorf("%v in %s", err, path)
+		}
+	}
+
+	if cfg.VendorAPI != nil {
+		if err := cfg.VendorAPI.parse(); err != nil {
+			return nil, fmt.Errorf("%v in %s", err, path)
+		}
+	}
+
+	if cfg.Trigger != nil {
+		if err := cfg.Trigger.parse(); err != nil {
+			return nil, fmt.Errorf("%v in %s", err, path)
+		}
+	}
+	for name, profile := range cfg.ScanProfiles {
+		if err := profile.parse(name); err != nil {
+			return nil, fmt.Errorf("%v in %s", err, path)
+		}
+	}
+
+	cfg.budgets = make(map[string]time.Duration)
+	for source, value := range cfg.SourceBudgets {
+		if !slices.Contains(sourceKinds, source) {
+			return nil, fmt.Errorf("unknown source %q in source_budgets of %s", source, path)
+		}
+		budget, err := time.ParseDuration(value)
+		if err != nil || budget < 0 {
+			return nil, fmt.Errorf("invalid budget %q for source %s in %s", value, source, path)
+		}
+		cfg.budgets[source] = budget
+	}
+
+	return cfg, nil
+}
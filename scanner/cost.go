@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// License metrics of Oracle Java SE subscriptions
+const (
+	CostMetricEmployee  = "employee"  // Java SE Universal Subscription, every employee of the organization
+	CostMetricProcessor = "processor" // legacy Java SE Subscription, per processor of the host
+	CostMetricNUP       = "nup"       // legacy Java SE Subscription, per named user plus
+)
+
+// Scopes of a cost estimate, an organization wide estimate must be counted once and not per host
+const (
+	CostScopeOrganization = "organization"
+	CostScopeHost         = "host"
+)
+
+// Monthly list prices in USD of the Oracle Java SE subscriptions, used unless unit_price is set
+const (
+	listPriceProcessor  = 25.00
+	listPriceNUP        = 2.50
+	defaultCoreFactor   = 0.5 // Oracle core factor of x86 and x64 processors
+	defaultCostCurrency = "USD"
+)
+
+// employeePriceTiers are the monthly list prices per employee of the Java SE
+// Universal Subscription by the number of employees, in ascending order
+var employeePriceTiers = []struct {
+	minEmployees int
+	price        float64
+}{
+	{1, 15.00},
+	{1000, 12.00},
+	{3000, 10.50},
+	{10000, 8.25},
+	{20000, 6.75},
+	{30000, 5.70},
+	{40000, 5.25},
+}
+
+// LicenseCosts are the license metrics used to estimate the Oracle Java SE subscription cost
+type LicenseCosts struct {
+	// Metric is employee, processor or nup
+	Metric string `json:"metric"`
+
+	// Employees is the number of employees of the organization for the employee metric
+	Employees int `json:"employees,omitempty"`
+
+	// Processors overrides the processors of the host for the processor metric, by default
+	// the logical CPUs multiplied by CoreFactor and rounded up
+	Processors float64 `json:"processors,omitempty"`
+	CoreFactor float64 `json:"core_factor,omitempty"`
+
+	// NamedUsers is the number of named users of the host for the nup metric
+	NamedUsers int `json:"named_users,omitempty"`
+
+	// UnitPrice is the monthly price per unit, by default the list price of the metric
+	UnitPrice float64 `json:"unit_price,omitempty"`
+	Currency  string  `json:"currency,omitempty"`
+}
+
+// CostEstimate is the potential Oracle Java SE subscription cost of a host
+type CostEstimate struct {
+	Metric       string  `json:"metric"`
+	Scope        string  `json:"scope"`
+	Units        float64 `json:"units"`
+	UnitPrice    float64 `json:"unit_price"`
+	Currency     string  `json:"currency"`
+	MonthlyCost  float64 `json:"monthly_cost"`
+	AnnualCost   float64 `json:"annual_cost"`
+	Licensed     int     `json:"licensed_runtimes"`
+	Acknowledged int     `json:"acknowledged_runtimes,omitempty"`
+}
+
+// parse validates the license metrics and fills in the defaults
+func (c *LicenseCosts) parse() error {
+	switch c.Metric {
+	case CostMetricEmployee:
+		if c.Employees <= 0 {
+			return fmt.Errorf("license_costs with metric %s require employees", c.Metric)
+		}
+	case CostMetricProcessor:
+		if c.Processors < 0 || c.CoreFactor < 0 {
+			return fmt.Errorf("invalid processors or core_factor of license_costs")
+		}
+		if c.CoreFactor == 0 {
+			c.CoreFactor = defaultCoreFactor
+		}
+	case CostMetricNUP:
+		if c.NamedUsers <= 0 {
+			return fmt.Errorf("license_costs with metric %s require named_users", c.Metric)
+		}
+	default:
+		return fmt.Errorf("invalid metric %q of license_costs, must be %s, %s or %s",
+			c.Metric, CostMetricEmployee, CostMetricProcessor, CostMetricNUP)
+	}
+	if c.UnitPrice < 0 {
+		return fmt.Errorf("invalid unit_price %v of license_costs", c.UnitPrice)
+	}
+	if c.Currency == "" {
+		c.Currency = defaultCostCurrency
+	}
+	return nil
+}
+
+// unitPrice returns the configured monthly price per unit or the list price of the metric
+func (c *LicenseCosts) unitPrice() float64 {
+	if c.UnitPrice > 0 {
+		return c.UnitPrice
+	}
+	switch c.Metric {
+	case CostMetricEmployee:
+		price := employeePriceTiers[0].price
+		for _, tier := range employeePriceTiers {
+			if c.Employees >= tier.minEmployees {
+				price = tier.price
+			}
+		}
+		return price
+	case CostMetricProcessor:
+		return listPriceProcessor
+	default:
+		return listPriceNUP
+	}
+}
+
+// units returns the number of licensed units of the metric on a host with the given logical CPUs
+func (c *LicenseCosts) units(cpus int) float64 {
+	switch c.Metric {
+	case CostMetricEmployee:
+		return float64(c.Employees)
+	case CostMetricProcessor:
+		if c.Processors > 0 {
+			return c.Processors
+		}
+		return math.Ceil(float64(cpus) * c.CoreFactor)
+	default:
+		return float64(c.NamedUsers)
+	}
+}
+
+// estimate returns the cost estimate of the Oracle runtimes of a report, runtimes
+// whose oracle_jdk finding is acknowledged do not count as licensed
+func (c *LicenseCosts) estimate(output *JSONOutput, cpus int) *CostEstimate {
+	if c == nil {
+		return nil
+	}
+	estimate := &CostEstimate{
+		Metric:    c.Metric,
+		Scope:     CostScopeHost,
+		Units:     c.units(cpus),
+		UnitPrice: c.unitPrice(),
+		Currency:  c.Currency,
+	}
+	if c.Metric == CostMetricEmployee {
+		estimate.Scope = CostScopeOrganization
+	}
+	for _, rt := range output.Runtimes {
+		if rt.RequireLicense == nil || !*rt.RequireLicense {
+			continue
+		}
+		if findAcknowledgment(output.Acknowledgments, FindingOracleJDK, rt.JavaExecutable) != nil {
+			estimate.Acknowledged++
+		} else {
+			estimate.Licensed++
+		}
+	}
+	estimate.price()
+	return estimate
+}
+
+// price computes the costs, which are zero unless a runtime requires a license
+func (e *CostEstimate) price() {
+	e.MonthlyCost, e.AnnualCost = 0, 0
+	if e.Licensed > 0 {
+		e.MonthlyCost = math.Round(e.Units*e.UnitPrice*100) / 100
+		e.AnnualCost = math.Round(e.Units*e.UnitPrice*12*100) / 100
+	}
+}
+
+// merge adds the runtimes of the estimate of another shard of the same host
+func (e *CostEstimate) merge(other *CostEstimate) *CostEstimate {
+	if e == nil {
+		if other == nil {
+			return nil
+		}
+		merged := *other
+		return &merged
+	}
+	if other != nil {
+		e.Licensed += other.Licensed
+		e.Acknowledged += other.Acknowledged
+		e.price()
+	}
+	return e
+}
+
+// printCostEstimate prints the cost estimate for text output
+func printCostEstimate(estimate *CostEstimate) {
+	if estimate == nil {
+		return
+	}
+	printf("Estimated Oracle Java SE subscription: %s %s per year for %d runtimes requiring a license (%s metric, %s units at %s per month)\n",
+		strconv.FormatFloat(estimate.AnnualCost, 'f', 2, 64), estimate.Currency, estimate.Licensed, estimate.Metric,
+		strconv.FormatFloat(estimate.Units, 'f', -1, 64), strconv.FormatFloat(estimate.UnitPrice, 'f', 2, 64))
+	if estimate.Scope == CostScopeOrganization {
+		printf("Note: the employee metric covers the whole organization, count it once and not per host\n")
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLicenseCostsParse(t *testing.T) {
+	for _, valid := range []LicenseCosts{
+		{Metric: CostMetricEmployee, Employees: 250},
+		{Metric: CostMetricProcessor},
+		{Metric: CostMetricNUP, NamedUsers: 25, UnitPrice: 2, Currency: "EUR"},
+	} {
+		if err := valid.parse(); err != nil {
+			t.Errorf("Unexpected error for %+v: %v", valid, err)
+		}
+	}
+	for _, invalid := range []LicenseCosts{
+		{Metric: "socket"},
+		{Metric: CostMetricEmployee},
+		{Metric: CostMetricNUP},
+		{Metric: CostMetricProcessor, CoreFactor: -1},
+		{Metric: CostMetricProcessor, UnitPrice: -5},
+	} {
+		if err := invalid.parse(); err == nil {
+			t.Errorf("Expected an error for %+v", invalid)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeTestFile(t, path, `{"license_costs": {"metric": "processor"}}`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LicenseCosts.CoreFactor != defaultCoreFactor || cfg.LicenseCosts.Currency != "USD" {
+		t.Errorf("Expected the defaults to be filled in, got %+v", cfg.LicenseCosts)
+	}
+	writeTestFile(t, path, `{"license_costs": {"metric": "employee"}}`)
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected an error for the employee metric without employees")
+	}
+}
+
+func TestEmployeePriceTiers(t *testing.T) {
+	for employees, price := range map[int]float64{1: 15, 999: 15, 1000: 12, 5000: 10.50, 45000: 5.25} {
+		costs := LicenseCosts{Metric: CostMetricEmployee, Employees: employees}
+		if got := costs.unitPrice(); got != price {
+			t.Errorf("Expected %v per employee for %d employees, got %v", price, employees, got)
+		}
+	}
+}
+
+func TestCostEstimate(t *testing.T) {
+	required, free := true, false
+	output := &JSONOutput{
+		Runtimes: []JavaRuntimeJSON{
+			{JavaExecutable: "/opt/oracle/jdk-11/bin/java", IsOracle: true, RequireLicense: &required},
+			{JavaExecutable: "/opt/app/jdk8/bin/java", IsOracle: true, RequireLicense: &required},
+			{JavaExecutable: "/opt/oracle/jdk-21/bin/java", IsOracle: true, RequireLicense: &free},
+			{JavaExecutable: "/usr/lib/jvm/temurin-17/bin/java"},
+		},
+		Acknowledgments: []Acknowledgment{{Finding: FindingOracleJDK, Path: "/opt/oracle/**", Note: "licensed"}},
+	}
+
+	var none *LicenseCosts
+	if none.estimate(output, 8) != nil {
+		t.Error("Expected no estimate without license costs")
+	}
+
+	costs := &LicenseCosts{Metric: CostMetricProcessor}
+	if err := costs.parse(); err != nil {
+		t.Fatal(err)
+	}
+	estimate := costs.estimate(output, 6)
+	if estimate.Licensed != 1 || estimate.Acknowledged != 1 || estimate.Scope != CostScopeHost {
+		t.Errorf("Expected one licensed and one acknowledged runtime, got %+v", estimate)
+	}
+	if estimate.Units != 3 || estimate.MonthlyCost != 75 || estimate.AnnualCost != 900 {
+		t.Errorf("Expected 3 processors at 25 per month, got %+v", estimate)
+	}
+
+	costs = &LicenseCosts{Metric: CostMetricEmployee, Employees: 1500, Currency: "EUR"}
+	estimate = costs.estimate(&JSONOutput{Runtimes: output.Runtimes[2:]}, 6)
+	if estimate.Licensed != 0 || estimate.AnnualCost != 0 || estimate.Scope != CostScopeOrganization {
+		t.Errorf("Expected no cost without runtimes requiring a license, got %+v", estimate)
+	}
+
+	// A shard with a licensed runtime prices the merged estimate
+	merged := estimate.merge(costs.estimate(&JSONOutput{Runtimes: output.Runtimes[:1]}, 6))
+	if merged.Licensed != 1 || merged.AnnualCost != 1500*12*12 {
+		t.Errorf("Expected the merged estimate to be priced, got %+v", merged)
+	}
+}
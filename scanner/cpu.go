@@ -0,0 +1,124 @@
+package main
+
+import "time"
+
+// cpuQuarter returns the quarter of a CPU released in a year and month. Critical
+// Patch Updates are numbered year*4 plus 0 for January, 1 for April, 2 for July
+// and 3 for October.
+func cpuQuarter(year int, month time.Month) int {
+	return year*4 + int(month-1)/3
+}
+
+// cpuDate returns the release date of a CPU in January, April, July or October:
+// the Tuesday closest to the 17th until 2024 and the third Tuesday since 2025.
+// The two only differ when the 17th is a Friday.
+func cpuDate(quarter int) time.Time {
+	year, month := quarter/4, time.Month(quarter%4*3+1)
+	if year < 2025 {
+		day := time.Date(year, month, 17, 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, (int(time.Tuesday)-int(day.Weekday())+10)%7-3)
+	}
+	day := time.Date(year, month, 15, 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, (int(time.Tuesday)-int(day.Weekday())+7)%7)
+}
+
+// latestCPU returns the quarter of the newest CPU released at a time
+func latestCPU(now time.Time) int {
+	quarter := cpuQuarter(now.Year(), now.Month())
+	if now.Before(cpuDate(quarter)) {
+		quarter--
+	}
+	return quarter
+}
+
+// cpuUpdate is the first update of a major version that contains a CPU
+type cpuUpdate struct {
+	update  int
+	quarter int
+}
+
+// irregularCPUUpdates are the CPU updates of the versions that predate the
+// regular numbering, newest first. Later Java 7 and 8 CPUs add 10 to the
+// update, e.g. 8u131 for April 2017 and 8u141 for July; builds in between,
+// like 8u202 or the OpenJDK 8u292, belong to the CPU before them.
+var irregularCPUUpdates = map[int][]cpuUpdate{
+	7: {
+		{101, cpuQuarter(2016, time.April)},
+		{95, cpuQuarter(2016, time.January)},
+		{91, cpuQuarter(2015, time.October)},
+		{85, cpuQuarter(2015, time.July)},
+		{79, cpuQuarter(2015, time.April)},
+		{75, cpuQuarter(2015, time.January)},
+		{71, cpuQuarter(2014, time.October)},
+		{65, cpuQuarter(2014, time.July)},
+		{55, cpuQuarter(2014, time.April)},
+		{51, cpuQuarter(2014, time.January)},
+		{45, cpuQuarter(2013, time.October)},
+	},
+	8: {
+		{121, cpuQuarter(2017, time.January)},
+		{111, cpuQuarter(2016, time.October)},
+		{101, cpuQuarter(2016, time.July)},
+		{91, cpuQuarter(2016, time.April)},
+		{71, cpuQuarter(2016, time.January)},
+		{65, cpuQuarter(2015, time.October)},
+		{51, cpuQuarter(2015, time.July)},
+		{45, cpuQuarter(2015, time.April)},
+		{31, cpuQuarter(2015, time.January)},
+		{25, cpuQuarter(2014, time.October)},
+		{11, cpuQuarter(2014, time.July)},
+		{5, cpuQuarter(2014, time.April)},
+		{0, cpuQuarter(2014, time.January)}, // GA in March 2014
+	},
+	9: {
+		{4, cpuQuarter(2018, time.January)},
+		{1, cpuQuarter(2017, time.October)},
+		{0, cpuQuarter(2017, time.July)},
+	},
+}
+
+// versionCPU maps a version to the quarter of the newest CPU it contains.
+// Since Java 10, a feature release in March contains the January CPU and one in
+// September the July CPU, and every update adds the next CPU, e.g. 17.0.9 is
+// July 2021 plus 9 quarters, October 2023.
+func versionCPU(major, update int) (int, bool) {
+	if major >= 10 {
+		return cpuQuarter(2018, time.January) + (major-10)*2 + update, true
+	}
+	updates, ok := irregularCPUUpdates[major]
+	if !ok {
+		return 0, false
+	}
+	if update > updates[0].update {
+		return updates[0].quarter + (update-updates[0].update)/10, true
+	}
+	for _, u := range updates {
+		if update >= u.update {
+			return u.quarter, true
+		}
+	}
+	return 0, false
+}
+
+// PatchLevel is the CPU a runtime corresponds to and how far behind it is
+type PatchLevel struct {
+	CPU         string // release date of the newest CPU the runtime contains
+	LagQuarters int    // CPUs released since
+}
+
+// patchLevel maps a runtime to its CPU by version, or by the JAVA_VERSION_DATE
+// of its release file for versions without a known numbering. A version newer
+// than the latest CPU, e.g. an early access build, is not behind.
+func patchLevel(major, update int, releaseDate string, now time.Time) (*PatchLevel, bool) {
+	quarter, ok := versionCPU(major, update)
+	if !ok {
+		released, err := time.Parse(time.DateOnly, releaseDate)
+		if err != nil {
+			return nil, false
+		}
+		quarter = latestCPU(released)
+	}
+	latest := latestCPU(now)
+	quarter = min(quarter, latest)
+	return &PatchLevel{CPU: cpuDate(quarter).Format(time.DateOnly), LagQuarters: latest - quarter}, true
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCPUDate(t *testing.T) {
+	for quarter, want := range map[int]string{
+		cpuQuarter(2023, time.October): "2023-10-17",
+		cpuQuarter(2025, time.January): "2025-01-21",
+		cpuQuarter(2024, time.July):    "2024-07-16",
+		cpuQuarter(2015, time.July):    "2015-07-14", // the 17th was a Friday
+	} {
+		if got := cpuDate(quarter).Format(time.DateOnly); got != want {
+			t.Errorf("Expected the CPU of quarter %d on %s, got %s", quarter, want, got)
+		}
+	}
+	if latestCPU(time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC)) != cpuQuarter(2025, time.January) {
+		t.Error("Expected the January CPU the day before the April CPU")
+	}
+	if latestCPU(time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC)) != cpuQuarter(2025, time.April) {
+		t.Error("Expected the April CPU on its release day")
+	}
+}
+
+func TestPatchLevel(t *testing.T) {
+	now := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		major, update int
+		releaseDate   string
+		cpu           string
+		lag           int
+	}{
+		{17, 9, "", "2023-10-17", 7},
+		{21, 8, "", "2025-07-15", 0},
+		{11, 28, "", "2025-07-15", 0},
+		{22, 2, "", "2024-07-16", 4},
+		{8, 202, "", "2019-01-15", 26}, // 8u201 was the CPU, 8u202 the PSU
+		{8, 292, "", "2021-04-20", 17}, // OpenJDK numbering
+		{8, 461, "", "2025-07-15", 0},  // current
+		{8, 60, "", "2015-07-14", 40},  // between CPUs
+		{7, 351, "", "2022-07-19", 12}, // extended support
+		{9, 4, "", "2018-01-16", 30},   // irregular numbering
+		{30, 0, "", "2025-07-15", 0},   // newer than the latest CPU
+		{7, 17, "", "", 0},             // before the quarterly CPUs
+		{0, 0, "2024-05-01", "2024-04-16", 5},
+	} {
+		level, ok := patchLevel(tt.major, tt.update, tt.releaseDate, now)
+		if tt.cpu == "" {
+			if ok {
+				t.Errorf("Expected no patch level for %d update %d, got %+v", tt.major, tt.update, level)
+			}
+			continue
+		}
+		if !ok || level.CPU != tt.cpu || level.LagQuarters != tt.lag {
+			t.Errorf("Expected %d update %d at the CPU of %s, %d behind, got %+v", tt.major, tt.update, tt.cpu, tt.lag, level)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// providerServiceFile declares the java.security.Provider implementations of a jar
+const providerServiceFile = "META-INF/services/java.security.Provider"
+
+// jdkProviderNames are the providers shipped with the JDKs, listed by name since Java 9
+var jdkProviderNames = []string{
+	"SUN", "SunRsaSign", "SunEC", "SunJSSE", "SunJCE", "SunJGSS", "SunSASL", "XMLDSig", "SunPCSC",
+	"JdkLDAP", "JdkSASL", "SunMSCAPI", "SunPKCS11", "Apple", "OracleUcrypto",
+}
+
+// jdkProviderPackages are the packages of the provider classes shipped with the
+// JDKs, listed by class name up to Java 8, including those of IBM runtimes
+var jdkProviderPackages = []string{
+	"sun.", "com.sun.", "com.oracle.security.", "apple.security.", "org.jcp.xml.dsig.internal.", "com.ibm.",
+}
+
+// jdkExtensions are the jars in lib/ext of a Java 8 runtime that come with it
+var jdkExtensions = []string{
+	"access-bridge.jar", "access-bridge-32.jar", "access-bridge-64.jar", "cldrdata.jar", "dnsns.jar",
+	"jaccess.jar", "jfxrt.jar", "localedata.jar", "meta-index", "nashorn.jar", "sunec.jar",
+	"sunjce_provider.jar", "sunmscapi.jar", "sunpkcs11.jar", "ucrypto.jar", "zipfs.jar",
+}
+
+// cryptoVendors recognizes third-party providers by class or module name prefix and by jar name
+var cryptoVendors = []struct {
+	vendor  string
+	prefix  string
+	jarName string
+}{
+	{"Bouncy Castle", "org.bouncycastle", "bc"},
+	{"nCipher", "com.ncipher", "ncipher"},
+	{"nCipher", "com.ncipher", "kmjava"},
+	{"Thales Luna", "com.safenetinc", "luna"},
+	{"RSA BSAFE", "com.rsa.jsafe", "cryptoj"},
+	{"IAIK", "iaik.", "iaik"},
+	{"Conscrypt", "org.conscrypt", "conscrypt"},
+	{"Amazon Corretto Crypto Provider", "com.amazon.corretto.crypto", "amazoncorrettocryptoprovider"},
+	{"wolfSSL", "com.wolfssl", "wolfcrypt"},
+}
+
+// CryptoProvider is a provider registered in java.security, in order of preference
+type CryptoProvider struct {
+	Priority   int    `json:"priority"`
+	Name       string `json:"name"`
+	ThirdParty bool   `json:"third_party,omitempty"`
+	Vendor     string `json:"vendor,omitempty"`
+}
+
+// CryptoExtension is a jar added to the extension directory of a runtime
+type CryptoExtension struct {
+	File      string   `json:"file"`
+	Vendor    string   `json:"vendor,omitempty"`
+	Providers []string `json:"providers,omitempty"`
+}
+
+// CryptoInfo is the enrichment of the crypto enricher
+type CryptoInfo struct {
+	SecurityFile string            `json:"security_file,omitempty"`
+	Providers    []CryptoProvider  `json:"providers"`
+	Extensions   []CryptoExtension `json:"extensions,omitempty"`
+	AddedModules []string          `json:"added_modules,omitempty"`
+	ThirdParty   bool              `json:"third_party"`
+}
+
+// cryptoEnricher inventories the crypto providers of a runtime: the providers
+// registered in java.security, the jars added to lib/ext (up to Java 8) and the
+// modules linked into the image from a module path
+type cryptoEnricher struct{}
+
+// Name returns the name of the enricher
+func (c *cryptoEnricher) Name() string {
+	return "crypto"
+}
+
+// Enrich returns the crypto providers of the runtime
+func (c *cryptoEnricher) Enrich(result *JavaResult) (any, error) {
+	info := &CryptoInfo{Providers: []CryptoProvider{}}
+	if path, ok := findSecurityFile(result.Path); ok {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		props, err := parseSecurityProperties(bufio.NewScanner(file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		info.SecurityFile = path
+		info.Providers = securityProviders(props)
+	}
+
+	home := filepath.Dir(filepath.Dir(result.Path))
+	for _, dir := range []string{filepath.Join(home, "lib", "ext"), filepath.Join(home, "jre", "lib", "ext")} {
+		extensions, err := extensionJars(dir)
+		if err != nil {
+			return nil, err
+		}
+		info.Extensions = append(info.Extensions, extensions...)
+	}
+	info.AddedModules = addedModules(releaseModules(result.Release))
+
+	if info.SecurityFile == "" && len(info.Extensions) == 0 && len(info.AddedModules) == 0 {
+		return nil, nil
+	}
+	info.ThirdParty = len(info.Extensions) > 0 || len(info.AddedModules) > 0 ||
+		slices.ContainsFunc(info.Providers, func(p CryptoProvider) bool { return p.ThirdParty })
+	return info, nil
+}
+
+// securityProviders returns the security.provider.N entries of java.security
+// ordered by N, the first field of the value being the name or class
+func securityProviders(props map[string]string) []CryptoProvider {
+	providers := []CryptoProvider{}
+	for key, value := range props {
+		n, ok := strings.CutPrefix(key, "security.provider.")
+		if !ok {
+			continue
+		}
+		priority, err := strconv.Atoi(n)
+		fields := strings.Fields(value)
+		if err != nil || len(fields) == 0 {
+			continue
+		}
+		provider := CryptoProvider{Priority: priority, Name: fields[0]}
+		provider.ThirdParty = !isJDKProvider(provider.Name)
+		if provider.ThirdParty {
+			provider.Vendor = cryptoVendor(provider.Name, "")
+		}
+		providers = append(providers, provider)
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Priority < providers[j].Priority })
+	return providers
+}
+
+// isJDKProvider reports whether a provider name or class comes with the JDK
+func isJDKProvider(name string) bool {
+	if slices.Contains(jdkProviderNames, name) {
+		return true
+	}
+	for _, prefix := range jdkProviderPackages {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cryptoVendor returns the vendor of a provider class or module name or of a jar, if known
+func cryptoVendor(name, jar string) string {
+	jar = strings.ToLower(jar)
+	for _, v := range cryptoVendors {
+		if (name != "" && strings.HasPrefix(name, v.prefix)) || (jar != "" && strings.HasPrefix(jar, v.jarName)) {
+			return v.vendor
+		}
+	}
+	return ""
+}
+
+// extensionJars returns the jars of an extension directory that do not come with
+// the JDK, with the providers they declare as services
+func extensionJars(dir string) ([]CryptoExtension, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var extensions []CryptoExtension
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(name), ".jar") || slices.Contains(jdkExtensions, name) {
+			continue
+		}
+		extension := CryptoExtension{File: filepath.Join(dir, name)}
+		extension.Providers, _ = jarProviders(extension.File)
+		for _, provider := range extension.Providers {
+			if extension.Vendor = cryptoVendor(provider, ""); extension.Vendor != "" {
+				break
+			}
+		}
+		if extension.Vendor == "" {
+			extension.Vendor = cryptoVendor("", name)
+		}
+		extensions = append(extensions, extension)
+	}
+	return extensions, nil
+}
+
+// jarProviders returns the provider classes a jar declares in its service file
+func jarProviders(path string) ([]string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	for _, file := range archive.File {
+		if file.Name != providerServiceFile {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		data, err := io.ReadAll(io.LimitReader(reader, 64*1024))
+		if err != nil {
+			return nil, err
+		}
+		var providers []string
+		for _, line := range strings.Split(string(data), "\n") {
+			line, _, _ = strings.Cut(line, "#")
+			if line = strings.TrimSpace(line); line != "" {
+				providers = append(providers, line)
+			}
+		}
+		return providers, nil
+	}
+	return nil, nil
+}
+
+// addedModules returns the modules of a runtime image that are not part of a
+// JDK, i.e. were linked into the image from a module path
+func addedModules(modules []string) []string {
+	var added []string
+	for _, module := range modules {
+		if !isPlatformModule(module) {
+			added = append(added, module)
+		}
+	}
+	return added
+}
+
+// isPlatformModule reports whether a module is part of the JDKs of any vendor
+func isPlatformModule(module string) bool {
+	for _, prefix := range []string{"java.", "jdk.", "javafx.", "oracle.", "openj9.", "com.ibm.", "com.sun.", "sun."} {
+		if strings.HasPrefix(module, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTestJar creates a jar with the given entries
+func writeTestJar(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+	for name, content := range entries {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCryptoEnricher(t *testing.T) {
+	jdk8 := filepath.Join(t.TempDir(), "jdk1.8.0_392")
+	writeTestFile(t, filepath.Join(jdk8, "jre", "lib", "security", "java.security"), `security.provider.1=sun.security.provider.Sun
+security.provider.2=org.bouncycastle.jce.provider.BouncyCastleProvider
+security.provider.10=sun.security.pkcs11.SunPKCS11 ${java.home}/lib/security/nss.cfg
+security.provider.3=com.ncipher.provider.km.nCipherKM
+`)
+	writeTestJar(t, filepath.Join(jdk8, "jre", "lib", "ext", "bcprov-jdk18on-1.78.jar"), map[string]string{
+		providerServiceFile: "# Bouncy Castle\norg.bouncycastle.jce.provider.BouncyCastleProvider\n",
+	})
+	writeTestJar(t, filepath.Join(jdk8, "jre", "lib", "ext", "sunjce_provider.jar"), map[string]string{
+		providerServiceFile: "com.sun.crypto.provider.SunJCE\n",
+	})
+	writeTestJar(t, filepath.Join(jdk8, "jre", "lib", "ext", "nCipherKM.jar"), map[string]string{"META-INF/MANIFEST.MF": ""})
+
+	value, err := (&cryptoEnricher{}).Enrich(&JavaResult{Path: filepath.Join(jdk8, "bin", "java")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	info := value.(*CryptoInfo)
+	wantProviders := []CryptoProvider{
+		{Priority: 1, Name: "sun.security.provider.Sun"},
+		{Priority: 2, Name: "org.bouncycastle.jce.provider.BouncyCastleProvider", ThirdParty: true, Vendor: "Bouncy Castle"},
+		{Priority: 3, Name: "com.ncipher.provider.km.nCipherKM", ThirdParty: true, Vendor: "nCipher"},
+		{Priority: 10, Name: "sun.security.pkcs11.SunPKCS11"},
+	}
+	if !reflect.DeepEqual(info.Providers, wantProviders) {
+		t.Errorf("Expected providers %+v, got %+v", wantProviders, info.Providers)
+	}
+	wantExtensions := []CryptoExtension{
+		{File: filepath.Join(jdk8, "jre", "lib", "ext", "bcprov-jdk18on-1.78.jar"), Vendor: "Bouncy Castle",
+			Providers: []string{"org.bouncycastle.jce.provider.BouncyCastleProvider"}},
+		{File: filepath.Join(jdk8, "jre", "lib", "ext", "nCipherKM.jar"), Vendor: "nCipher"},
+	}
+	if !reflect.DeepEqual(info.Extensions, wantExtensions) {
+		t.Errorf("Expected extensions %+v, got %+v", wantExtensions, info.Extensions)
+	}
+	if !info.ThirdParty {
+		t.Error("Expected third-party providers to be reported")
+	}
+
+	// Since Java 9 providers come from modules linked into the image
+	jdk17 := filepath.Join(t.TempDir(), "jdk-17")
+	writeTestFile(t, filepath.Join(jdk17, "conf", "security", "java.security"), "security.provider.1=SUN\nsecurity.provider.2=SunRsaSign\n")
+	value, err = (&cryptoEnricher{}).Enrich(&JavaResult{
+		Path:    filepath.Join(jdk17, "bin", "java"),
+		Release: map[string]string{"MODULES": "java.base java.logging jdk.crypto.ec org.bouncycastle.provider"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	info = value.(*CryptoInfo)
+	if want := []string{"org.bouncycastle.provider"}; !reflect.DeepEqual(info.AddedModules, want) || !info.ThirdParty {
+		t.Errorf("Expected the added module %v, got %+v", want, info)
+	}
+	if len(info.Providers) != 2 || info.Providers[0].ThirdParty || info.Providers[1].ThirdParty {
+		t.Errorf("Expected only JDK providers, got %+v", info.Providers)
+	}
+
+	// Nothing is reported without a java.security file
+	if value, err := (&cryptoEnricher{}).Enrich(&JavaResult{Path: filepath.Join(t.TempDir(), "bin", "java")}); value != nil || err != nil {
+		t.Errorf("Expected no enrichment, got %v, %v", value, err)
+	}
+}
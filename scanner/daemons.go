@@ -0,0 +1,99 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Toolchains whose long-running daemons keep a JDK in use
+const (
+	DaemonGradle = "gradle"
+	DaemonKotlin = "kotlin"
+	DaemonMaven  = "maven"
+)
+
+// Daemon is a running build daemon and the java executable it runs on
+type Daemon struct {
+	Toolchain      string `json:"toolchain"`
+	PID            int    `json:"pid"`
+	JavaExecutable string `json:"java_executable"`
+	Version        string `json:"version,omitempty"`
+}
+
+// processInfo is a running process as listed by listProcesses
+type processInfo struct {
+	pid         int
+	executable  string // may be empty if the OS does not tell
+	commandLine string
+}
+
+// daemonMainClasses map the main class of a daemon to its toolchain
+var daemonMainClasses = map[string]string{
+	"org.gradle.launcher.daemon.bootstrap.GradleDaemon": DaemonGradle,
+	"org.jetbrains.kotlin.daemon.KotlinCompileDaemon":   DaemonKotlin,
+	"org.mvndaemon.mvnd.daemon.DaemonMain":              DaemonMaven,
+}
+
+// daemonVersions find the toolchain version in the class path of a daemon
+var daemonVersions = map[string]*regexp.Regexp{
+	DaemonGradle: regexp.MustCompile(`gradle-(?:launcher|daemon-main)-(\d[\w.-]*?)\.jar`),
+	DaemonKotlin: regexp.MustCompile(`kotlin-(?:daemon|compiler-embeddable|compiler)-(\d[\w.-]*?)\.jar`),
+	DaemonMaven:  regexp.MustCompile(`mvnd-daemon-(\d[\w.-]*?)\.jar`),
+}
+
+// detectDaemons returns the running Gradle, Kotlin and Maven daemons
+func detectDaemons() ([]Daemon, error) {
+	processes, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+	var daemons []Daemon
+	for _, p := range processes {
+		if daemon, ok := parseDaemon(p); ok {
+			daemons = append(daemons, daemon)
+		}
+	}
+	return daemons, nil
+}
+
+// parseDaemon recognizes a build daemon by the main class on its command line
+func parseDaemon(p processInfo) (Daemon, bool) {
+	fields := strings.Fields(p.commandLine)
+	for _, field := range fields {
+		toolchain, ok := daemonMainClasses[field]
+		if !ok {
+			continue
+		}
+		daemon := Daemon{Toolchain: toolchain, PID: p.pid, JavaExecutable: p.executable}
+		if daemon.JavaExecutable == "" && len(fields) > 0 {
+			daemon.JavaExecutable = strings.Trim(fields[0], `"`)
+		}
+		if m := daemonVersions[toolchain].FindStringSubmatch(p.commandLine); m != nil {
+			daemon.Version = m[1]
+		}
+		return daemon, true
+	}
+	return Daemon{}, false
+}
+
+// daemonsUsing returns the toolchains of the daemons running on a java executable
+func daemonsUsing(daemons []Daemon, javaPath string) []string {
+	if len(daemons) == 0 {
+		return nil
+	}
+	resolved, err := filepath.EvalSymlinks(javaPath)
+	if err != nil {
+		resolved = javaPath
+	}
+	var toolchains []string
+	for _, daemon := range daemons {
+		if sameHome(daemon.JavaExecutable, javaPath) || sameHome(daemon.JavaExecutable, resolved) {
+			if !slices.Contains(toolchains, daemon.Toolchain) {
+				toolchains = append(toolchains, daemon.Toolchain)
+			}
+		}
+	}
+	return toolchains
+}
@@ -0,0 +1,63 @@
+//go:build !windows
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// listProcesses returns the running processes with their command lines
+func listProcesses() ([]processInfo, error) {
+	if runtime.GOOS != "linux" {
+		return listProcessesPS()
+	}
+
+	dirs, err := filepath.Glob("/proc/[0-9]*")
+	if err != nil {
+		return nil, err
+	}
+	var processes []processInfo
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(filepath.Base(dir))
+		if err != nil {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+		if err != nil || len(cmdline) == 0 {
+			continue
+		}
+		// The executable link can only be read for processes of the same user
+		exe, _ := os.Readlink(filepath.Join(dir, "exe"))
+		processes = append(processes, processInfo{
+			pid:         pid,
+			executable:  strings.TrimSuffix(exe, " (deleted)"),
+			commandLine: string(bytes.ReplaceAll(bytes.TrimRight(cmdline, "\x00"), []byte{0}, []byte{' '})),
+		})
+	}
+	return processes, nil
+}
+
+// listProcessesPS lists the processes with ps, which does not tell the executable
+func listProcessesPS() ([]processInfo, error) {
+	output, err := exec.Command("ps", "-axww", "-o", "pid=,command=").Output()
+	if err != nil {
+		return nil, err
+	}
+	var processes []processInfo
+	for _, line := range strings.Split(string(output), "\n") {
+		pid, command, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(pid); err == nil {
+			processes = append(processes, processInfo{pid: n, commandLine: strings.TrimSpace(command)})
+		}
+	}
+	return processes, nil
+}
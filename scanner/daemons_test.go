@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestParseDaemon(t *testing.T) {
+	tests := []struct {
+		name    string
+		process processInfo
+		want    Daemon
+		ok      bool
+	}{
+		{
+			"gradle",
+			processInfo{pid: 4242, executable: "/usr/lib/jvm/java-17/bin/java", commandLine: "/usr/lib/jvm/java-17/bin/java " +
+				"-Xmx2g -cp /home/dev/.gradle/wrapper/dists/gradle-8.5-bin/5t9huq95ubn472n8rpzujfbqh/gradle-8.5/lib/gradle-launcher-8.5.jar " +
+				"org.gradle.launcher.daemon.bootstrap.GradleDaemon 8.5"},
+			Daemon{Toolchain: DaemonGradle, PID: 4242, JavaExecutable: "/usr/lib/jvm/java-17/bin/java", Version: "8.5"},
+			true,
+		},
+		{
+			"kotlin without executable",
+			processInfo{pid: 4343, commandLine: "/opt/android-studio/jbr/bin/java -cp " +
+				"/home/dev/.gradle/caches/modules-2/files-2.1/org.jetbrains.kotlin/kotlin-compiler-embeddable/1.9.22/x/kotlin-compiler-embeddable-1.9.22.jar " +
+				"org.jetbrains.kotlin.daemon.KotlinCompileDaemon --daemon-runFilesPath /tmp/kotlin"},
+			Daemon{Toolchain: DaemonKotlin, PID: 4343, JavaExecutable: "/opt/android-studio/jbr/bin/java", Version: "1.9.22"},
+			true,
+		},
+		{
+			"application",
+			processInfo{pid: 1, commandLine: "/usr/bin/java -jar app.jar"},
+			Daemon{},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDaemon(tt.process)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v (%v), got %+v (%v)", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestDaemonsUsing(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+	root := t.TempDir()
+	java := createFakeJava(t, filepath.Join(root, "jdk-17"))
+	link := filepath.Join(root, "current")
+	if err := os.Symlink(filepath.Join(root, "jdk-17"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	// The OS reports the resolved path of the executable
+	java, err := filepath.EvalSymlinks(java)
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemons := []Daemon{
+		{Toolchain: DaemonGradle, PID: 1, JavaExecutable: java},
+		{Toolchain: DaemonGradle, PID: 2, JavaExecutable: java},
+		{Toolchain: DaemonKotlin, PID: 3, JavaExecutable: java},
+		{Toolchain: DaemonMaven, PID: 4, JavaExecutable: "/usr/bin/java"},
+	}
+	want := []string{DaemonGradle, DaemonKotlin}
+	if got := daemonsUsing(daemons, filepath.Join(link, "bin", "java")); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := daemonsUsing(nil, java); got != nil {
+		t.Errorf("Expected no daemons, got %q", got)
+	}
+}
+
+func TestListProcesses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("only java processes are listed on Windows")
+	}
+	processes, err := listProcesses()
+	if err != nil {
+		t.Skipf("can't list processes: %v", err)
+	}
+	for _, p := range processes {
+		if p.pid == os.Getpid() {
+			return
+		}
+	}
+	t.Errorf("Expected the test process among %d processes", len(processes))
+}
@@ -0,0 +1,45 @@
+//go:build windows
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+// win32Process holds the properties of a Win32_Process instance
+type win32Process struct {
+	ProcessId      int
+	ExecutablePath string
+	CommandLine    string
+}
+
+// listProcesses returns the running processes with their command lines from WMI
+func listProcesses() ([]processInfo, error) {
+	script := "Get-CimInstance Win32_Process -Filter \"Name = 'java.exe' OR Name = 'javaw.exe'\" | " +
+		"Select-Object ProcessId,ExecutablePath,CommandLine | ConvertTo-Json -Compress"
+	output, err := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", script).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %v", err)
+	}
+	if len(output) == 0 {
+		return nil, nil
+	}
+
+	// ConvertTo-Json returns an object instead of an array for a single process
+	var list []win32Process
+	if err := json.Unmarshal(output, &list); err != nil {
+		var single win32Process
+		if err := json.Unmarshal(output, &single); err != nil {
+			return nil, fmt.Errorf("failed to parse process list: %v", err)
+		}
+		list = []win32Process{single}
+	}
+
+	processes := make([]processInfo, 0, len(list))
+	for _, p := range list {
+		processes = append(processes, processInfo{pid: p.ProcessId, executable: p.ExecutablePath, commandLine: p.CommandLine})
+	}
+	return processes, nil
+}
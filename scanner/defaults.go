@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DefaultRuntime compares the runtimes selected by PATH, JAVA_HOME and the
+// default mechanism of the operating system
+type DefaultRuntime struct {
+	PathJava    string   `json:"path_java,omitempty"`    // java executable found in PATH
+	PathHome    string   `json:"path_home,omitempty"`    // runtime home of PathJava after resolving symlinks
+	JavaHome    string   `json:"java_home,omitempty"`    // value of JAVA_HOME
+	OSMechanism string   `json:"os_mechanism,omitempty"` // alternatives, java_home or registry
+	OSDefault   string   `json:"os_default,omitempty"`   // runtime home selected by OSMechanism
+	Consistent  bool     `json:"consistent"`
+	Warnings    []string `json:"warnings,omitempty"`
+
+	// PathStub is set if PathJava is a launcher stub of the operating system,
+	// whose PathHome is the runtime the stub selects
+	PathStub bool `json:"path_stub,omitempty"`
+}
+
+// Default runtime mechanisms of the operating systems
+const (
+	MechanismAlternatives = "alternatives"
+	MechanismJavaHome     = "java_home"
+	MechanismRegistry     = "registry"
+)
+
+// detectDefaultRuntime checks which runtime each default mechanism selects
+func detectDefaultRuntime() *DefaultRuntime {
+	pathJava, _ := exec.LookPath(javaExecutableName())
+	mechanism, osHome := osDefaultJavaHome()
+	return checkDefaultRuntime(pathJava, os.Getenv("JAVA_HOME"), mechanism, osHome)
+}
+
+// checkDefaultRuntime resolves the runtime homes and reports where they disagree.
+// Empty arguments mean the mechanism selects nothing and are not compared.
+func checkDefaultRuntime(pathJava, javaHome, mechanism, osHome string) *DefaultRuntime {
+	d := &DefaultRuntime{PathJava: pathJava, JavaHome: javaHome, OSMechanism: mechanism, OSDefault: osHome}
+
+	type selection struct {
+		name string
+		home string
+	}
+	var selected []selection
+	if pathJava != "" {
+		if d.PathStub = isLauncherStub(runtime.GOOS, pathJava); d.PathStub {
+			d.PathHome = stubSelection(runtime.GOOS, javaHome, osHome)
+		} else {
+			d.PathHome = runtimeHome(pathJava)
+		}
+		if d.PathHome != "" {
+			selected = append(selected, selection{"PATH", d.PathHome})
+		}
+	}
+	if javaHome != "" {
+		java := filepath.Join(javaHome, "bin", javaExecutableName())
+		if _, err := os.Stat(java); err != nil {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("JAVA_HOME %s does not contain bin/%s", javaHome, javaExecutableName()))
+		} else {
+			selected = append(selected, selection{"JAVA_HOME", runtimeHome(java)})
+		}
+	}
+	if osHome != "" {
+		selected = append(selected, selection{mechanism, runtimeHome(filepath.Join(osHome, "bin", javaExecutableName()))})
+	}
+
+	for i := 1; i < len(selected); i++ {
+		if !sameHome(selected[0].home, selected[i].home) {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("%s selects %s but %s selects %s",
+				selected[0].name, selected[0].home, selected[i].name, selected[i].home))
+		}
+	}
+	d.Consistent = len(d.Warnings) == 0
+	return d
+}
+
+// isLauncherStub reports whether a java executable is a stub of the operating
+// system rather than part of a runtime: /usr/bin/java on macOS, and on Windows
+// the javapath directory of the Oracle installers and the java.exe copied to
+// System32 by old ones. Resolving them does not lead to a runtime home.
+func isLauncherStub(goos, java string) bool {
+	switch goos {
+	case "darwin":
+		return filepath.Clean(java) == "/usr/bin/java"
+	case "windows":
+		dir := strings.ToLower(strings.ReplaceAll(java, `\`, "/"))
+		dir = dir[:max(strings.LastIndex(dir, "/"), 0)]
+		base := dir[strings.LastIndex(dir, "/")+1:]
+		return base == "javapath" || strings.HasPrefix(base, "javapath_target_") ||
+			strings.HasSuffix(dir, "/windows/system32") || strings.HasSuffix(dir, "/windows/syswow64")
+	}
+	return false
+}
+
+// stubSelection returns the runtime home a launcher stub starts: the macOS stub
+// honors JAVA_HOME before /usr/libexec/java_home, the Windows stubs use the
+// registry only
+func stubSelection(goos, javaHome, osHome string) string {
+	if goos == "darwin" && javaHome != "" {
+		return javaHome
+	}
+	return osHome
+}
+
+// runtimeHome returns the home directory of a java executable after resolving
+// symlinks. The jre directory of a Java 8 JDK belongs to the JDK around it.
+func runtimeHome(java string) string {
+	if resolved, err := filepath.EvalSymlinks(java); err == nil {
+		java = resolved
+	}
+	home := filepath.Dir(filepath.Dir(java))
+	if strings.EqualFold(filepath.Base(home), "jre") {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(home), "bin", javaExecutableName())); err == nil {
+			home = filepath.Dir(home)
+		}
+	}
+	return home
+}
+
+// sameHome compares two runtime homes, ignoring case on Windows
+func sameHome(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// javaExecutableName returns the file name of the java launcher on this system
+func javaExecutableName() string {
+	if runtime.GOOS == "windows" {
+		return "java.exe"
+	}
+	return "java"
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// alternativesLink is the java link managed by update-alternatives on Linux
+const alternativesLink = "/etc/alternatives/java"
+
+// osDefaultJavaHome returns the runtime home selected by update-alternatives
+// on Linux or /usr/libexec/java_home on macOS
+func osDefaultJavaHome() (string, string) {
+	switch runtime.GOOS {
+	case "darwin":
+		output, err := exec.Command("/usr/libexec/java_home").Output()
+		if err != nil {
+			return MechanismJavaHome, ""
+		}
+		return MechanismJavaHome, strings.TrimSpace(string(output))
+	case "linux":
+		java, err := filepath.EvalSymlinks(alternativesLink)
+		if err != nil {
+			return "", ""
+		}
+		return MechanismAlternatives, filepath.Dir(filepath.Dir(java))
+	}
+	return "", ""
+}
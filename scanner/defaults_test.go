@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckDefaultRuntime(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+	dir := t.TempDir()
+	jdk17 := filepath.Join(dir, "jdk-17")
+	jdk21 := filepath.Join(dir, "jdk-21")
+	for _, home := range []string{jdk17, jdk21} {
+		writeTestFile(t, filepath.Join(home, "bin", "java"), "")
+	}
+	link := filepath.Join(dir, "usr-bin-java")
+	if err := os.Symlink(filepath.Join(jdk17, "bin", "java"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	d := checkDefaultRuntime(link, jdk17, MechanismAlternatives, jdk17)
+	if !d.Consistent || d.PathHome != jdk17 {
+		t.Errorf("Expected consistent defaults resolving to %s, got %+v", jdk17, d)
+	}
+
+	d = checkDefaultRuntime(link, jdk21, MechanismAlternatives, jdk17)
+	if d.Consistent || len(d.Warnings) != 1 || !strings.Contains(d.Warnings[0], "JAVA_HOME selects "+jdk21) {
+		t.Errorf("Expected JAVA_HOME warning, got %+v", d)
+	}
+
+	d = checkDefaultRuntime("", filepath.Join(dir, "missing"), "", "")
+	if d.Consistent || len(d.Warnings) != 1 || !strings.Contains(d.Warnings[0], "does not contain") {
+		t.Errorf("Expected invalid JAVA_HOME warning, got %+v", d)
+	}
+
+	// Nothing selected is not an inconsistency
+	if d := checkDefaultRuntime("", "", "", ""); !d.Consistent {
+		t.Errorf("Expected no warnings without any default, got %+v", d)
+	}
+}
+
+func TestRuntimeHomeJava8(t *testing.T) {
+	jdk := filepath.Join(t.TempDir(), "jdk1.8.0_402")
+	writeTestFile(t, filepath.Join(jdk, "bin", javaExecutableName()), "")
+	writeTestFile(t, filepath.Join(jdk, "jre", "bin", javaExecutableName()), "")
+
+	if home := runtimeHome(filepath.Join(jdk, "jre", "bin", javaExecutableName())); home != jdk {
+		t.Errorf("Expected embedded jre to belong to %s, got %s", jdk, home)
+	}
+}
+
+func TestLauncherStubs(t *testing.T) {
+	for _, test := range []struct {
+		goos string
+		java string
+		stub bool
+	}{
+		{"darwin", "/usr/bin/java", true},
+		{"darwin", "/Library/Java/JavaVirtualMachines/jdk-21.jdk/Contents/Home/bin/java", false},
+		{"windows", `C:\Program Files\Common Files\Oracle\Java\javapath\java.exe`, true},
+		{"windows", `C:\ProgramData\Oracle\Java\javapath_target_1234567\java.exe`, true},
+		{"windows", `C:\WINDOWS\system32\java.exe`, true},
+		{"windows", `C:\Program Files\Java\jdk-21\bin\java.exe`, false},
+		{"linux", "/usr/bin/java", false},
+	} {
+		if stub := isLauncherStub(test.goos, test.java); stub != test.stub {
+			t.Errorf("Expected %s on %s to be a stub: %v, got %v", test.java, test.goos, test.stub, stub)
+		}
+	}
+
+	// The macOS stub starts JAVA_HOME if set, the Windows stubs the registry default
+	if home := stubSelection("darwin", "/opt/jdk-17", "/opt/jdk-21"); home != "/opt/jdk-17" {
+		t.Errorf("Expected the macOS stub to honor JAVA_HOME, got %s", home)
+	}
+	if home := stubSelection("darwin", "", "/opt/jdk-21"); home != "/opt/jdk-21" {
+		t.Errorf("Expected the macOS stub to start the java_home default, got %s", home)
+	}
+	if home := stubSelection("windows", `C:\jdk-17`, `C:\jdk-21`); home != `C:\jdk-21` {
+		t.Errorf("Expected the Windows stub to start the registry default, got %s", home)
+	}
+}
+
+func TestDefaultRuntimeDetectedOnce(t *testing.T) {
+	finder := NewJavaFinder(t.TempDir(), -1, false, false)
+	finder.defaultRuntime = &DefaultRuntime{PathJava: "/opt/jdk-21/bin/java", Consistent: true}
+	if output := buildJSONOutput(nil, finder, time.Now()); output.DefaultRuntime != finder.defaultRuntime {
+		t.Errorf("Expected the report to use the detected default runtime, got %+v", output.DefaultRuntime)
+	}
+}
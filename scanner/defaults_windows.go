@@ -0,0 +1,62 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// javaSoftKeys are the registry keys the Windows java launcher consults, JRE first
+var javaSoftKeys = []string{
+	`SOFTWARE\JavaSoft\JRE`,
+	`SOFTWARE\JavaSoft\Java Runtime Environment`,
+	`SOFTWARE\JavaSoft\JDK`,
+	`SOFTWARE\JavaSoft\Java Development Kit`,
+}
+
+// osDefaultJavaHome returns the JavaHome of the CurrentVersion registered
+// below HKLM\SOFTWARE\JavaSoft
+func osDefaultJavaHome() (string, string) {
+	for _, key := range javaSoftKeys {
+		version, ok := readRegistryString(key, "CurrentVersion")
+		if !ok {
+			continue
+		}
+		if home, ok := readRegistryString(key+`\`+version, "JavaHome"); ok {
+			return MechanismRegistry, home
+		}
+	}
+	return MechanismRegistry, ""
+}
+
+// readRegistryString reads a string value below HKEY_LOCAL_MACHINE
+func readRegistryString(key, name string) (string, bool) {
+	keyPtr, err := syscall.UTF16PtrFromString(key)
+	if err != nil {
+		return "", false
+	}
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return "", false
+	}
+
+	var handle syscall.Handle
+	if err := syscall.RegOpenKeyEx(syscall.HKEY_LOCAL_MACHINE, keyPtr, 0, syscall.KEY_READ, &handle); err != nil {
+		return "", false
+	}
+	defer syscall.RegCloseKey(handle)
+
+	var valueType, size uint32
+	if err := syscall.RegQueryValueEx(handle, namePtr, nil, &valueType, nil, &size); err != nil || size == 0 {
+		return "", false
+	}
+	if valueType != syscall.REG_SZ && valueType != syscall.REG_EXPAND_SZ {
+		return "", false
+	}
+	buf := make([]uint16, size/2)
+	if err := syscall.RegQueryValueEx(handle, namePtr, nil, &valueType, (*byte)(unsafe.Pointer(&buf[0])), &size); err != nil {
+		return "", false
+	}
+	return syscall.UTF16ToString(buf), true
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package main
+
+import "syscall"
+
+// diskFree returns the bytes available to unprivileged users on the file system of path
+func diskFree(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceExW = kernel32.NewProc("GetDiskFreeSpaceExW")
+
+// diskFree returns the bytes available to the calling user on the volume of path
+func diskFree(path string) (int64, error) {
+	ptr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	if ok, _, err := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(ptr)), uintptr(unsafe.Pointer(&available)), 0, 0); ok == 0 {
+		return 0, err
+	}
+	return int64(available), nil
+}
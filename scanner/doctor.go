@@ -0,0 +1,414 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Statuses of the checks of jfind doctor
+const (
+	CheckPass = "ok"
+	CheckWarn = "warn"
+	CheckFail = "FAIL"
+)
+
+// defaultDataMaxAge is how old enrichment data may get before doctor warns:
+// the critical patch updates of Java are released every quarter
+const defaultDataMaxAge = 90 * 24 * time.Hour
+
+// DoctorCheck is one item of the checklist of jfind doctor
+type DoctorCheck struct {
+	Check  string `json:"check"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// DoctorReport is the checklist of jfind doctor
+type DoctorReport struct {
+	Version string        `json:"jfind_version"`
+	Host    string        `json:"host"`
+	Checks  []DoctorCheck `json:"checks"`
+	Passed  bool          `json:"passed"`
+}
+
+// doctor checks the environment of the scans of a host: the options are those
+// of the scans, so the same problems show up as when the agent runs
+type doctor struct {
+	configFile string
+	enrich     string
+	url        string
+	encryptKey string
+	acks       string
+	paths      []string
+	useIndex   bool
+	useMFT     bool
+	wmi        bool
+	lockFile   string
+	maxAge     time.Duration
+	timeout    time.Duration
+
+	now      func() time.Time
+	elevated func() bool
+	report   DoctorReport
+}
+
+// add appends a check to the report
+func (d *doctor) add(check, status, format string, args ...any) {
+	d.report.Checks = append(d.report.Checks, DoctorCheck{Check: check, Status: status, Detail: fmt.Sprintf(format, args...)})
+	if status == CheckFail {
+		d.report.Passed = false
+	}
+}
+
+// run runs all checks that apply to the options, in the order in which a scan
+// needs what they check
+func (d *doctor) run() DoctorReport {
+	d.report = DoctorReport{Version: jfindVersion(), Host: getComputerName(), Passed: true}
+	cfg := d.checkConfig()
+	d.checkEnrichers(cfg)
+	d.checkData(cfg)
+	d.checkPrivileges()
+	d.checkSources()
+	d.checkLock()
+	d.checkCollector()
+	d.checkAcknowledgments()
+	return d.report
+}
+
+// checkConfig validates the configuration file and returns it, the defaults if
+// there is none or it is invalid
+func (d *doctor) checkConfig() *Config {
+	if d.configFile == "" {
+		d.add("config", CheckPass, "no configuration file, using the defaults")
+		return &Config{}
+	}
+	cfg, err := LoadConfig(d.configFile)
+	if err != nil {
+		d.add("config", CheckFail, "%v", err)
+		return &Config{}
+	}
+	d.add("config", CheckPass, "%s is valid", d.configFile)
+	return cfg
+}
+
+// checkEnrichers creates the enrichers of -enrich or of the configuration,
+// which reads the data files they need
+func (d *doctor) checkEnrichers(cfg *Config) {
+	list := cfg.Enrichers
+	if d.enrich != "" {
+		list = strings.Split(d.enrich, ",")
+	}
+	if len(list) == 0 {
+		return
+	}
+	if _, err := newEnrichers(list, cfg); err != nil {
+		d.add("enrichers", CheckFail, "%v", err)
+		return
+	}
+	d.add("enrichers", CheckPass, "%s", strings.Join(list, ", "))
+}
+
+// checkData checks that the enrichment data can be read and is younger than
+// the maximum age
+func (d *doctor) checkData(cfg *Config) {
+	switch source := cfg.ReleaseCatalog; {
+	case source == "":
+		if age := d.now().Sub(builtinReleasesDate); age > d.maxAge {
+			d.add("release-catalog", CheckWarn, "the built-in catalog is %d days old, configure release_catalog for newer releases", int(age.Hours()/24))
+		} else {
+			d.add("release-catalog", CheckPass, "built-in catalog of %s", builtinReleasesDate.Format(time.DateOnly))
+		}
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		if _, err := loadReleaseCatalog(source, cfg.vendorAPI()); err != nil {
+			d.add("release-catalog", CheckWarn, "%v", err)
+		} else {
+			d.add("release-catalog", CheckPass, "%s", redactString(source))
+		}
+	default:
+		d.checkDataFile("release-catalog", source, func(path string) error {
+			_, err := loadReleaseCatalog(path, nil)
+			return err
+		})
+	}
+	if cfg.CVEFeed != "" {
+		d.checkDataFile("cve-feed", cfg.CVEFeed, func(string) error {
+			_, err := newCVEEnricher(cfg)
+			return err
+		})
+	}
+	if cfg.VulnerableLibraries != "" {
+		d.checkDataFile("library-rules", cfg.VulnerableLibraries, func(path string) error {
+			_, err := loadLibraryRules(path)
+			return err
+		})
+	}
+	if cfg.Replacements != "" {
+		d.checkDataFile("replacements", cfg.Replacements, func(path string) error {
+			_, err := loadReplacementRules(path)
+			return err
+		})
+	}
+	if cfg.VendorAPI != nil {
+		d.checkVendorCache(cfg.vendorAPI())
+	}
+}
+
+// checkDataFile loads a data file and checks when it was last updated
+func (d *doctor) checkDataFile(check, path string, load func(string) error) {
+	info, err := os.Stat(path)
+	if err == nil {
+		err = load(path)
+	}
+	switch {
+	case err != nil:
+		d.add(check, CheckFail, "%v", err)
+	case d.now().Sub(info.ModTime()) > d.maxAge:
+		d.add(check, CheckWarn, "%s was last updated %s, %d days ago", path, info.ModTime().Format(time.DateOnly), int(d.now().Sub(info.ModTime()).Hours()/24))
+	default:
+		d.add(check, CheckPass, "%s, updated %s", path, info.ModTime().Format(time.DateOnly))
+	}
+}
+
+// checkVendorCache checks that the responses of the vendor APIs can be cached,
+// and in offline mode that there are some
+func (d *doctor) checkVendorCache(api *vendorAPI) {
+	dir := api.config.CacheDir
+	if dir == "" {
+		d.add("vendor-api", CheckWarn, "no cache directory, every scan queries the vendor APIs")
+		return
+	}
+	if err := checkWritable(dir); err != nil {
+		d.add("vendor-api", CheckWarn, "responses cannot be cached: %v", err)
+		return
+	}
+	if api.config.Offline {
+		cached, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		if len(cached) == 0 {
+			d.add("vendor-api", CheckWarn, "offline with no cached responses in %s", dir)
+			return
+		}
+		d.add("vendor-api", CheckPass, "offline with %d cached responses in %s", len(cached), dir)
+		return
+	}
+	d.add("vendor-api", CheckPass, "responses cached in %s", dir)
+}
+
+// checkWritable creates the directory if needed and a file in it
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, ".jfind-doctor-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// checkPrivileges checks if jfind runs with the rights the sources need
+func (d *doctor) checkPrivileges() {
+	if d.elevated() {
+		d.add("privileges", CheckPass, "running as %s", elevatedUser)
+		return
+	}
+	var need []string
+	if d.useMFT {
+		need = append(need, "-use-mft")
+	}
+	if d.wmi {
+		need = append(need, "-wmi")
+	}
+	if len(need) > 0 {
+		d.add("privileges", CheckFail, "%s require running as %s", strings.Join(need, " and "), elevatedUser)
+		return
+	}
+	d.add("privileges", CheckWarn, "not running as %s, directories of other users may be unreadable and their runtimes missed", elevatedUser)
+}
+
+// checkSources checks that the start paths can be read and the index, MFT
+// and WMI sources are available
+func (d *doctor) checkSources() {
+	for _, path := range d.paths {
+		if _, err := os.ReadDir(path); err != nil {
+			d.add("path", CheckFail, "%v", err)
+			continue
+		}
+		d.add("path", CheckPass, "%s is readable", path)
+	}
+	root := "."
+	if len(d.paths) > 0 {
+		root = d.paths[0]
+	}
+	if absRoot, err := filepath.Abs(root); err == nil {
+		root = absRoot
+	}
+	if d.useIndex {
+		if source, err := newIndexSource(root); err != nil {
+			d.add("index", CheckFail, "%v", err)
+		} else {
+			d.add("index", CheckPass, "%s is available", source.Name())
+		}
+	}
+	if d.useMFT {
+		if _, err := newMFTSource(root); err != nil {
+			d.add("mft", CheckFail, "%v", err)
+		} else {
+			d.add("mft", CheckPass, "volume of %s", root)
+		}
+	}
+	if d.wmi {
+		if runtime.GOOS != "windows" {
+			d.add("wmi", CheckFail, "WMI is not supported on %s", runtime.GOOS)
+		} else {
+			d.add("wmi", CheckPass, "results are published to %s", wmiNamespace)
+		}
+	}
+}
+
+// checkLock checks that the lock file can be created and is not held by a scan
+// that runs too long or hangs, without taking the lock
+func (d *doctor) checkLock() {
+	if err := os.MkdirAll(filepath.Dir(d.lockFile), 0755); err != nil {
+		d.add("lock", CheckFail, "failed to create the directory of the lock file: %v", err)
+		return
+	}
+	file, err := openLockFile(d.lockFile)
+	if err != nil {
+		d.add("lock", CheckFail, "failed to open lock file: %v", err)
+		return
+	}
+	defer file.Close()
+	switch err := tryLockFile(file); err {
+	case nil:
+		unlockFile(file)
+		d.add("lock", CheckPass, "%s is free", d.lockFile)
+	case errLockHeld:
+		d.add("lock", CheckWarn, "%v", &ScanLockedError{Path: d.lockFile, Holder: readLockHolder(file)})
+	default:
+		d.add("lock", CheckFail, "failed to lock %s: %v", d.lockFile, err)
+	}
+}
+
+// checkCollector asks the collector for its endpoint without posting a report.
+// A collector that only accepts POST there answers 405, which shows it is
+// reachable as well.
+func (d *doctor) checkCollector() {
+	if d.encryptKey != "" {
+		if _, err := loadPayloadKey(d.encryptKey); err != nil {
+			d.add("encrypt-key", CheckFail, "%v", err)
+		} else {
+			d.add("encrypt-key", CheckPass, "%s is a valid key", d.encryptKey)
+		}
+	}
+	if d.url == "" {
+		return
+	}
+	target := redactString(d.url)
+	client := &http.Client{Timeout: d.timeout}
+	start := d.now()
+	resp, err := client.Get(d.url)
+	if err != nil {
+		// The error of the client repeats the URL with its credentials
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		d.add("collector", CheckFail, "cannot reach %s: %v", target, err)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	elapsed := d.now().Sub(start).Round(time.Millisecond)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden ||
+		resp.StatusCode == http.StatusProxyAuthRequired:
+		d.add("collector", CheckFail, "%s rejected the credentials: %s", target, resp.Status)
+	case resp.StatusCode == http.StatusNotFound:
+		d.add("collector", CheckFail, "%s returned %s, check the path of -url", target, resp.Status)
+	case resp.StatusCode < 400 || resp.StatusCode == http.StatusMethodNotAllowed:
+		d.add("collector", CheckPass, "%s is reachable (%s in %v)", target, resp.Status, elapsed)
+	default:
+		d.add("collector", CheckFail, "%s returned %s", target, resp.Status)
+	}
+}
+
+// checkAcknowledgments loads the acknowledged findings of -acks
+func (d *doctor) checkAcknowledgments() {
+	if d.acks == "" {
+		return
+	}
+	acks, err := loadAcknowledgments(d.acks, d.report.Host, d.now())
+	if err != nil {
+		d.add("acks", CheckFail, "%s", redactString(err.Error()))
+		return
+	}
+	d.add("acks", CheckPass, "%d acknowledgments apply to %s", len(acks), d.report.Host)
+}
+
+// print writes the checklist as text
+func (r *DoctorReport) print(w io.Writer) {
+	fmt.Fprintf(w, "jfind %s on %s (%s/%s)\n", r.Version, r.Host, runtime.GOOS, runtime.GOARCH)
+	counts := make(map[string]int)
+	for _, c := range r.Checks {
+		fmt.Fprintf(w, "[%-4s] %-16s %s\n", c.Status, c.Check, c.Detail)
+		counts[c.Status]++
+	}
+	fmt.Fprintf(w, "%d passed, %d warnings, %d failed\n", counts[CheckPass], counts[CheckWarn], counts[CheckFail])
+}
+
+// runDoctor implements the doctor command, which checks that scans can run and
+// report on this host, and returns the exit code: 0 if all checks passed,
+// possibly with warnings, 1 if a check failed and 2 on usage errors
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	d := &doctor{now: time.Now, elevated: isElevated}
+	var paths pathList
+	fs.StringVar(&d.configFile, "config", "", "Path to the JSON configuration file of the scans")
+	fs.StringVar(&d.enrich, "enrich", "", "Comma separated list of enrichers of the scans (default those of the configuration)")
+	fs.StringVar(&d.url, "url", defaultPostURL, "URL the scans post to, empty to skip the collector")
+	fs.StringVar(&d.encryptKey, "encrypt-key", "", "Public key of the collector the reports are encrypted to")
+	fs.StringVar(&d.acks, "acks", "", "Acknowledged findings of the scans, a JSON file or the acknowledgments URL of the collector")
+	fs.Var(&paths, "path", "Start path of the scans (repeatable)")
+	fs.BoolVar(&d.useIndex, "use-index", false, "Check the file name index used by -use-index")
+	fs.BoolVar(&d.useMFT, "use-mft", false, "Check the MFT enumeration used by -use-mft")
+	fs.BoolVar(&d.wmi, "wmi", false, "Check the WMI publishing of -wmi")
+	fs.StringVar(&d.lockFile, "lock-file", defaultLockFile(), "Lock file of the scans")
+	fs.DurationVar(&d.maxAge, "max-age", defaultDataMaxAge, "Warn about enrichment data older than this")
+	fs.DurationVar(&d.timeout, "timeout", 10*time.Second, "Timeout of the connection to the collector")
+	jsonOutput := fs.Bool("json", false, "Output the checklist in JSON format")
+	fs.Usage = func() {
+		logf("Usage: jfind doctor [options]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		if err == nil {
+			fs.Usage()
+		}
+		return 2
+	}
+	d.paths = paths
+
+	report := d.run()
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+	} else {
+		report.print(os.Stdout)
+	}
+	if !report.Passed {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// elevatedUser names the user with full access to the file system
+const elevatedUser = "root"
+
+// isElevated checks if jfind runs as root
+func isElevated() bool {
+	return os.Geteuid() == 0
+}
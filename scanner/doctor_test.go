@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDoctor(t *testing.T) {
+	dir := t.TempDir()
+	catalog := filepath.Join(dir, "releases.json")
+	writeTestFile(t, catalog, `[{"distribution": "Eclipse Temurin", "major": 21, "latest": "21.0.10"}]`)
+	old := time.Now().Add(-200 * 24 * time.Hour)
+	if err := os.Chtimes(catalog, old, old); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "config.json")
+	writeTestFile(t, config, `{"version": 2, "release_catalog": "`+filepath.ToSlash(catalog)+`", "cve_feed": "`+filepath.ToSlash(filepath.Join(dir, "missing.json"))+`"}`)
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/jfind":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer collector.Close()
+
+	newDoctor := func() *doctor {
+		return &doctor{
+			configFile: config,
+			url:        collector.URL + "/api/jfind",
+			paths:      []string{dir},
+			lockFile:   filepath.Join(dir, "jfind.lock"),
+			maxAge:     defaultDataMaxAge,
+			timeout:    time.Second,
+			now:        time.Now,
+			elevated:   func() bool { return false },
+		}
+	}
+	statuses := func(report DoctorReport) map[string]string {
+		statuses := make(map[string]string)
+		for _, c := range report.Checks {
+			statuses[c.Check] = c.Status
+		}
+		return statuses
+	}
+
+	report := newDoctor().run()
+	want := map[string]string{
+		"config":          CheckPass,
+		"release-catalog": CheckWarn,
+		"cve-feed":        CheckFail,
+		"privileges":      CheckWarn,
+		"path":            CheckPass,
+		"lock":            CheckPass,
+		"collector":       CheckPass,
+	}
+	if got := statuses(report); report.Passed || len(got) != len(want) {
+		t.Fatalf("Expected the checks %v to fail, got %+v", want, report.Checks)
+	} else {
+		for check, status := range want {
+			if got[check] != status {
+				t.Errorf("Expected %s to be %s, got %+v", check, status, report.Checks)
+			}
+		}
+	}
+
+	// Rejected credentials, a held lock and sources that need administrator rights
+	d := newDoctor()
+	d.configFile = ""
+	d.url = collector.URL + "/other"
+	d.wmi = true
+	lock, err := acquireScanLock(d.lockFile, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.release()
+	report = d.run()
+	got := statuses(report)
+	if got["collector"] != CheckFail || got["privileges"] != CheckFail || got["lock"] != CheckWarn || got["release-catalog"] != CheckWarn {
+		t.Errorf("Expected the collector and privileges checks to fail and the lock to be held, got %+v", report.Checks)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// elevatedUser names the user with full access to the file system
+const elevatedUser = "administrator"
+
+// isElevated checks if jfind runs with administrator rights, which opening a
+// physical drive requires
+func isElevated() bool {
+	drive, err := os.Open(`\\.\PHYSICALDRIVE0`)
+	if err != nil {
+		return false
+	}
+	drive.Close()
+	return true
+}
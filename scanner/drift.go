@@ -0,0 +1,65 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+)
+
+// UpdateDrift reports several update levels of the same distribution and major
+// version on one host, which usually means an update was installed next to the
+// old one instead of replacing it
+type UpdateDrift struct {
+	Vendor   string   `json:"vendor"`
+	Major    int      `json:"major"`
+	Versions []string `json:"versions"`
+	Paths    []string `json:"paths"`
+}
+
+// findUpdateDrift groups the evaluated runtimes by vendor and major version and
+// returns every group with more than one distinct version
+func findUpdateDrift(results []*JavaResult) []UpdateDrift {
+	type key struct {
+		vendor string
+		major  int
+	}
+	groups := make(map[key]*UpdateDrift)
+	var order []key
+	for _, result := range results {
+		props := result.Properties
+		if props == nil || props.Major == 0 {
+			continue
+		}
+		k := key{props.Vendor, props.Major}
+		group, ok := groups[k]
+		if !ok {
+			group = &UpdateDrift{Vendor: props.Vendor, Major: props.Major}
+			groups[k] = group
+			order = append(order, k)
+		}
+		if !slices.Contains(group.Versions, props.Version) {
+			group.Versions = append(group.Versions, props.Version)
+		}
+		group.Paths = append(group.Paths, result.Path)
+	}
+
+	var drift []UpdateDrift
+	for _, k := range order {
+		group := groups[k]
+		if len(group.Versions) < 2 {
+			continue
+		}
+		slices.SortFunc(group.Versions, compareJavaVersions)
+		drift = append(drift, *group)
+	}
+	return drift
+}
+
+// compareJavaVersions orders version strings by their update number
+func compareJavaVersions(a, b string) int {
+	_, updateA := parseJavaVersion(a)
+	_, updateB := parseJavaVersion(b)
+	if c := cmp.Compare(updateA, updateB); c != 0 {
+		return c
+	}
+	return cmp.Compare(a, b)
+}
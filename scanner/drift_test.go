@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindUpdateDrift(t *testing.T) {
+	result := func(path, vendor, version string) *JavaResult {
+		props := &JavaProperties{Version: version, Vendor: vendor}
+		props.Major, props.Update = parseJavaVersion(version)
+		return &JavaResult{Path: path, Properties: props}
+	}
+	results := []*JavaResult{
+		result("/opt/jdk-17.0.9/bin/java", "Eclipse Adoptium", "17.0.9"),
+		result("/opt/jdk-17.0.2/bin/java", "Eclipse Adoptium", "17.0.2"),
+		result("/usr/lib/jvm/17/bin/java", "Eclipse Adoptium", "17.0.9"),
+		result("/opt/zulu-17/bin/java", "Azul Systems, Inc.", "17.0.4"),
+		result("/opt/jdk-21/bin/java", "Eclipse Adoptium", "21.0.1"),
+		{Path: "/opt/broken/bin/java"},
+	}
+
+	drift := findUpdateDrift(results)
+	want := []UpdateDrift{{
+		Vendor:   "Eclipse Adoptium",
+		Major:    17,
+		Versions: []string{"17.0.2", "17.0.9"},
+		Paths:    []string{"/opt/jdk-17.0.9/bin/java", "/opt/jdk-17.0.2/bin/java", "/usr/lib/jvm/17/bin/java"},
+	}}
+	if !reflect.DeepEqual(drift, want) {
+		t.Errorf("Expected %+v, got %+v", want, drift)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Installer and launcher wrappers that ship a private JRE with an application
+const (
+	WrapperInstall4j = "install4j"
+	WrapperLaunch4j  = "launch4j"
+	WrapperJPackage  = "jpackage"
+
+	// Applications recognized by the heuristics of -embedded
+	WrapperJetBrains = "jetbrains"
+	WrapperEclipse   = "eclipse"
+	WrapperAppBundle = "app-bundle"
+	WrapperBundled   = "bundled"
+)
+
+// Embedding describes the application a bundled runtime belongs to
+type Embedding struct {
+	Wrapper     string `json:"wrapper"`
+	Application string `json:"application"`
+	AppDir      string `json:"app_dir"`
+
+	// Launcher is the executable of a jpackage application and RuntimeHome its
+	// runtime, which usually has no java executable of its own
+	Launcher    string `json:"launcher,omitempty"`
+	RuntimeHome string `json:"runtime_home,omitempty"`
+}
+
+// install4jAppName extracts the application name from .install4j/i4jparams.conf
+var install4jAppName = regexp.MustCompile(`applicationName="([^"]+)"`)
+
+// launch4jMarker is contained in the header of every launch4j executable
+var launch4jMarker = []byte("launch4j")
+
+// launch4jHeaderSize is how much of an executable is searched for the marker;
+// the wrapper header comes first, followed by the application jar
+const launch4jHeaderSize = 512 * 1024
+
+// detectEmbedding checks if a java executable is the private runtime of an
+// application packaged with jpackage, Install4j or launch4j. The application
+// directory is the parent of the runtime home or the one above it (e.g.
+// app/lib/jre).
+func detectEmbedding(javaPath string) *Embedding {
+	home := filepath.Dir(filepath.Dir(javaPath))
+	if h, ok := findJavaHome(osSystem.FS, javaPath); ok {
+		home = h
+	}
+	if e := detectJPackage(home); e != nil {
+		return e
+	}
+
+	appDir := filepath.Dir(home)
+	for i := 0; i < 2 && appDir != filepath.Dir(appDir); i++ {
+		if e := detectInstall4j(appDir); e != nil {
+			return e
+		}
+		if e := detectLaunch4j(appDir); e != nil {
+			return e
+		}
+		appDir = filepath.Dir(appDir)
+	}
+	return nil
+}
+
+// detectInstall4j recognizes an Install4j installation by its .install4j directory
+func detectInstall4j(appDir string) *Embedding {
+	if info, err := os.Stat(filepath.Join(appDir, ".install4j")); err != nil || !info.IsDir() {
+		return nil
+	}
+	name := filepath.Base(appDir)
+	if data, err := os.ReadFile(filepath.Join(appDir, ".install4j", "i4jparams.conf")); err == nil {
+		if m := install4jAppName.FindSubmatch(data); m != nil {
+			name = string(m[1])
+		}
+	}
+	return &Embedding{Wrapper: WrapperInstall4j, Application: name, AppDir: appDir}
+}
+
+// detectLaunch4j recognizes a launch4j wrapped application by its .l4j.ini
+// file or by the launch4j header of an executable next to the runtime
+func detectLaunch4j(appDir string) *Embedding {
+	entries, err := os.ReadDir(appDir)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		lower := strings.ToLower(name)
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(lower, ".l4j.ini") {
+			return &Embedding{Wrapper: WrapperLaunch4j, Application: name[:len(name)-len(".l4j.ini")], AppDir: appDir}
+		}
+		if strings.HasSuffix(lower, ".exe") && hasLaunch4jHeader(filepath.Join(appDir, name)) {
+			return &Embedding{Wrapper: WrapperLaunch4j, Application: name[:len(name)-len(".exe")], AppDir: appDir}
+		}
+	}
+	return nil
+}
+
+// hasLaunch4jHeader checks if an executable was generated by launch4j
+func hasLaunch4jHeader(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	header, err := io.ReadAll(io.LimitReader(file, launch4jHeaderSize))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(bytes.ToLower(header), launch4jMarker)
+}
+
+// detectJPackage recognizes the runtime of an application packaged with
+// jpackage by the .cfg files of its launchers in the app directory next to the
+// runtime: lib/runtime and lib/app with the launchers in bin on Linux, runtime
+// and app with the launchers beside them on Windows, and
+// Contents/runtime/Contents/Home and Contents/app with the launchers in
+// Contents/MacOS on macOS. The application is named after the first launcher.
+func detectJPackage(home string) *Embedding {
+	var contents, launchers, appDir string
+	switch {
+	case filepath.Base(home) == "runtime":
+		contents = filepath.Dir(home)
+		launchers, appDir = contents, contents
+		if filepath.Base(contents) == "lib" {
+			appDir = filepath.Dir(contents)
+			launchers = filepath.Join(appDir, "bin")
+		}
+	case filepath.Base(home) == "Home" && filepath.Base(filepath.Dir(filepath.Dir(home))) == "runtime":
+		contents = filepath.Dir(filepath.Dir(filepath.Dir(home)))
+		launchers, appDir = filepath.Join(contents, "MacOS"), filepath.Dir(contents)
+	default:
+		return nil
+	}
+
+	entries, err := os.ReadDir(filepath.Join(contents, "app"))
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".cfg")
+		if !ok || entry.IsDir() || !isJPackageConfig(filepath.Join(contents, "app", entry.Name())) {
+			continue
+		}
+		for _, launcher := range []string{filepath.Join(launchers, name), filepath.Join(launchers, name+".exe")} {
+			if info, err := os.Stat(launcher); err == nil && !info.IsDir() {
+				return &Embedding{Wrapper: WrapperJPackage, Application: name, AppDir: appDir, Launcher: launcher, RuntimeHome: home}
+			}
+		}
+	}
+	return nil
+}
+
+// isJPackageConfig checks if a .cfg file is the configuration of a jpackage
+// launcher, which starts with its [Application] section
+func isJPackageConfig(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return line == "[Application]"
+		}
+	}
+	return false
+}
+
+// hasJavaExecutable checks if a runtime home has a java executable of its own,
+// which the walk finds by itself
+func hasJavaExecutable(home string) bool {
+	info, err := os.Stat(filepath.Join(home, "bin", javaExecutableName()))
+	return err == nil && isExecutable(info)
+}
+
+// newLauncherResult reports the runtime of a jpackage application that has no
+// java executable under the launcher of the application. Running the launcher
+// would start the application, so the runtime is evaluated from its release
+// file only.
+func (f *JavaFinder) newLauncherResult(app *Embedding) *JavaResult {
+	result := &JavaResult{Path: app.Launcher, Embedding: app}
+	result.Release, _ = readReleaseFile(f.sys.FS, app.RuntimeHome)
+	if f.evaluate {
+		result.Evaluated = true
+		result.Status = ProbeNotExecuted
+		if result.Properties = releaseProperties(result.Release); result.Properties != nil {
+			result.Status = ProbeStatic
+		}
+	}
+	result.PathClass = f.classifier.classify(app.Launcher)
+	if f.checkModules {
+		f.checkRuntimeModules(result)
+	}
+	result.Replacement = recommendReplacement(f.replacements, result, f.sys.Clock.Now())
+	result.Latest = f.releases.latest(result)
+	result.Confidence, result.Evidence = scoreConfidence(result)
+	f.enrich(result)
+	return result
+}
+
+// bundledHomeNames are the directory names of the private runtimes of
+// applications, matched with a release file by the last heuristic of -embedded
+var bundledHomeNames = []string{"jre", "jbr", "runtime"}
+
+// appBundleName extracts the name of a macOS application from its Info.plist
+var appBundleName = regexp.MustCompile(`<key>CFBundleName</key>\s*<string>([^<]+)</string>`)
+
+// detectBundledApplication applies the heuristics of -embedded to a java
+// executable that no installer wrapper claimed: the JetBrains Runtime of an
+// IDE, the runtime of an Eclipse installation, any runtime inside a macOS
+// application bundle and, last, a runtime home named like a private runtime
+// whose parent holds the jar files of an application.
+func detectBundledApplication(javaPath string) *Embedding {
+	home := filepath.Dir(filepath.Dir(javaPath))
+	if h, ok := findJavaHome(osSystem.FS, javaPath); ok {
+		home = h
+	}
+	for _, detect := range []func(string) *Embedding{detectJetBrains, detectEclipse, detectAppBundle, detectBundledRuntime} {
+		if e := detect(home); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// detectJetBrains recognizes the JetBrains Runtime of an IDE by the
+// product-info.json of the IDE, next to the jbr directory on Linux and Windows
+// and in Contents/Resources of the bundle with Contents/jbr/Contents/Home on
+// macOS. IDEs before 2022 named the directory jre or jre64.
+func detectJetBrains(home string) *Embedding {
+	var appDir, productInfo string
+	switch {
+	case slices.Contains([]string{"jbr", "jre", "jre64"}, filepath.Base(home)):
+		appDir = filepath.Dir(home)
+		productInfo = filepath.Join(appDir, "product-info.json")
+	case filepath.Base(home) == "Home" && filepath.Base(filepath.Dir(filepath.Dir(home))) == "jbr":
+		contents := filepath.Dir(filepath.Dir(filepath.Dir(home)))
+		appDir = filepath.Dir(contents)
+		productInfo = filepath.Join(contents, "Resources", "product-info.json")
+	default:
+		return nil
+	}
+	data, err := os.ReadFile(productInfo)
+	if err != nil {
+		return nil
+	}
+	var product struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(data, &product) != nil || product.Name == "" {
+		return nil
+	}
+	return &Embedding{Wrapper: WrapperJetBrains, Application: product.Name, AppDir: appDir}
+}
+
+// detectEclipse recognizes the runtime of an Eclipse installation, e.g. jre or
+// a JustJ plugin below plugins, by the .eclipseproduct file of the installation
+// up to three directories above the runtime home. On macOS the installation is
+// Contents/Eclipse of the bundle, which is the application directory then.
+func detectEclipse(home string) *Embedding {
+	dir := filepath.Dir(home)
+	for i := 0; i < 3 && dir != filepath.Dir(dir); i++ {
+		data, err := os.ReadFile(filepath.Join(dir, ".eclipseproduct"))
+		if err != nil {
+			dir = filepath.Dir(dir)
+			continue
+		}
+		name := "Eclipse"
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(line), "name="); ok && value != "" {
+				name = value
+			}
+		}
+		appDir := dir
+		if filepath.Base(dir) == "Eclipse" && filepath.Base(filepath.Dir(dir)) == "Contents" {
+			appDir = filepath.Dir(filepath.Dir(dir))
+		}
+		return &Embedding{Wrapper: WrapperEclipse, Application: name, AppDir: appDir}
+	}
+	return nil
+}
+
+// detectAppBundle recognizes a runtime inside the Contents of a macOS
+// application bundle, named by CFBundleName of its Info.plist
+func detectAppBundle(home string) *Embedding {
+	for dir := home; dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if !strings.HasSuffix(dir, ".app") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "Contents", "Info.plist"))
+		if err != nil {
+			return nil
+		}
+		name := strings.TrimSuffix(filepath.Base(dir), ".app")
+		if m := appBundleName.FindSubmatch(data); m != nil {
+			name = string(m[1])
+		}
+		return &Embedding{Wrapper: WrapperAppBundle, Application: name, AppDir: dir}
+	}
+	return nil
+}
+
+// detectBundledRuntime recognizes a private runtime by its release file and
+// name, e.g. app/jre, if the directory above is no JDK, whose jre it would be,
+// and holds jar files directly or in lib or app
+func detectBundledRuntime(home string) *Embedding {
+	if !slices.Contains(bundledHomeNames, strings.ToLower(filepath.Base(home))) {
+		return nil
+	}
+	appDir := filepath.Dir(home)
+	if _, err := os.Stat(filepath.Join(home, "release")); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(appDir, "release")); err == nil {
+		return nil
+	}
+	for _, dir := range []string{appDir, filepath.Join(appDir, "lib"), filepath.Join(appDir, "app")} {
+		if jars, _ := filepath.Glob(filepath.Join(dir, "*.jar")); len(jars) > 0 {
+			return &Embedding{Wrapper: WrapperBundled, Application: filepath.Base(appDir), AppDir: appDir}
+		}
+	}
+	return nil
+}
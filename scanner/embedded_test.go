@@ -0,0 +1,191 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestFile creates a file and its parent directories
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectEmbeddingInstall4j(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "acme-suite")
+	writeTestFile(t, filepath.Join(app, ".install4j", "i4jparams.conf"),
+		`<config><general applicationName="Acme Suite" applicationVersion="4.2" /></config>`)
+	java := filepath.Join(app, "jre", "bin", "java")
+	writeTestFile(t, java, "")
+
+	e := detectEmbedding(java)
+	if e == nil {
+		t.Fatal("Expected Install4j embedding")
+	}
+	if e.Wrapper != WrapperInstall4j || e.Application != "Acme Suite" || e.AppDir != app {
+		t.Errorf("Unexpected embedding %+v", e)
+	}
+}
+
+func TestDetectEmbeddingLaunch4j(t *testing.T) {
+	// Runtime two levels below the application directory
+	app := t.TempDir()
+	writeTestFile(t, filepath.Join(app, "Tool.exe"), "MZ\x90\x00 launch4j header")
+	writeTestFile(t, filepath.Join(app, "Other.exe"), "MZ\x90\x00 plain executable")
+	java := filepath.Join(app, "lib", "jre", "bin", "java.exe")
+	writeTestFile(t, java, "")
+
+	e := detectEmbedding(java)
+	if e == nil || e.Wrapper != WrapperLaunch4j || e.Application != "Tool" {
+		t.Errorf("Expected launch4j embedding of Tool, got %+v", e)
+	}
+
+	// The .l4j.ini file also identifies the wrapper
+	app = t.TempDir()
+	writeTestFile(t, filepath.Join(app, "Viewer.l4j.ini"), "-Xmx512m\n")
+	java = filepath.Join(app, "jre", "bin", "java.exe")
+	writeTestFile(t, java, "")
+	if e := detectEmbedding(java); e == nil || e.Application != "Viewer" {
+		t.Errorf("Expected launch4j embedding of Viewer, got %+v", e)
+	}
+}
+
+func TestDetectEmbeddingStandalone(t *testing.T) {
+	java := filepath.Join(t.TempDir(), "jdk-21", "bin", "java")
+	writeTestFile(t, java, "")
+	if e := detectEmbedding(java); e != nil {
+		t.Errorf("Expected no embedding for standalone JDK, got %+v", e)
+	}
+}
+
+func TestDetectJPackage(t *testing.T) {
+	root := t.TempDir()
+	config := "[Application]\napp.classpath=$APPDIR/acme.jar\napp.mainclass=com.acme.Main\n\n[JavaOptions]\njava-options=-Djpackage.app-version=2.1\n"
+
+	// Linux layout with the native commands stripped from the runtime
+	app := filepath.Join(root, "opt", "acme")
+	writeTestFile(t, filepath.Join(app, "bin", "Acme"), "")
+	writeTestFile(t, filepath.Join(app, "lib", "app", "Acme.cfg"), config)
+	writeTestFile(t, filepath.Join(app, "lib", "runtime", "release"), "JAVA_VERSION=\"21.0.2\"\nIMPLEMENTOR=\"Eclipse Adoptium\"\n")
+
+	// macOS bundle whose runtime kept its java executable
+	bundle := filepath.Join(root, "Applications", "Viewer.app")
+	writeTestFile(t, filepath.Join(bundle, "Contents", "MacOS", "Viewer"), "")
+	writeTestFile(t, filepath.Join(bundle, "Contents", "app", "Viewer.cfg"), config)
+	home := filepath.Join(bundle, "Contents", "runtime", "Contents", "Home")
+	createFakeJava(t, home)
+
+	// A runtime directory whose .cfg is not the configuration of a launcher
+	other := filepath.Join(root, "tool")
+	writeTestFile(t, filepath.Join(other, "Tool"), "")
+	writeTestFile(t, filepath.Join(other, "app", "Tool.cfg"), "verbose=true\n")
+	writeTestFile(t, filepath.Join(other, "runtime", "release"), "JAVA_VERSION=\"17\"\n")
+
+	finder := NewJavaFinder(root, -1, false, true)
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected the runtimes of Acme and Viewer, got %d results", len(results))
+	}
+	for _, result := range results {
+		e := result.Embedding
+		switch {
+		case e == nil || e.Wrapper != WrapperJPackage:
+			t.Errorf("Expected %s to be embedded with jpackage, got %+v", result.Path, e)
+		case e.Application == "Acme":
+			want := Embedding{Wrapper: WrapperJPackage, Application: "Acme", AppDir: app,
+				Launcher: filepath.Join(app, "bin", "Acme"), RuntimeHome: filepath.Join(app, "lib", "runtime")}
+			if *e != want || result.Path != want.Launcher {
+				t.Errorf("Expected %+v at the launcher, got %+v at %s", want, *e, result.Path)
+			}
+			if result.Status != ProbeStatic || result.Properties == nil || result.Properties.Major != 21 {
+				t.Errorf("Expected Java 21 from the release file, got %v %+v", result.Status, result.Properties)
+			}
+		case e.Application == "Viewer":
+			if e.AppDir != bundle || e.RuntimeHome != home || result.Path != filepath.Join(home, "bin", javaExecutableName()) {
+				t.Errorf("Unexpected embedding %+v of %s", *e, result.Path)
+			}
+		default:
+			t.Errorf("Unexpected embedding %+v", *e)
+		}
+	}
+}
+
+func TestDetectBundledApplication(t *testing.T) {
+	root := t.TempDir()
+	java := javaExecutableName()
+	release := "JAVA_VERSION=\"17.0.10\"\n"
+
+	idea := filepath.Join(root, "idea-IU-241.14494.240")
+	writeTestFile(t, filepath.Join(idea, "product-info.json"), `{"name": "IntelliJ IDEA", "version": "2024.1"}`)
+	writeTestFile(t, filepath.Join(idea, "jbr", "release"), release)
+
+	goland := filepath.Join(root, "Applications", "GoLand.app")
+	writeTestFile(t, filepath.Join(goland, "Contents", "Resources", "product-info.json"), `{"name": "GoLand"}`)
+	writeTestFile(t, filepath.Join(goland, "Contents", "jbr", "Contents", "Home", "release"), release)
+
+	eclipse := filepath.Join(root, "eclipse")
+	writeTestFile(t, filepath.Join(eclipse, ".eclipseproduct"), "name=Eclipse Platform\nid=org.eclipse.platform\n")
+	justj := filepath.Join(eclipse, "plugins", "org.eclipse.justj.openjdk.hotspot.jre.full.linux.x86_64_17.0.10.v20240120-1143", "jre")
+	writeTestFile(t, filepath.Join(justj, "release"), release)
+
+	viewer := filepath.Join(root, "Applications", "Viewer.app")
+	writeTestFile(t, filepath.Join(viewer, "Contents", "Info.plist"), "<dict>\n  <key>CFBundleName</key>\n  <string>Image Viewer</string>\n</dict>")
+	writeTestFile(t, filepath.Join(viewer, "Contents", "PlugIns", "jre.bundle", "Contents", "Home", "release"), release)
+
+	acme := filepath.Join(root, "opt", "acme")
+	writeTestFile(t, filepath.Join(acme, "lib", "acme.jar"), "")
+	writeTestFile(t, filepath.Join(acme, "jre", "release"), release)
+
+	// A JDK 9+ keeps jars in lib but has no jre directory of an application
+	jdk := filepath.Join(root, "usr", "lib", "jvm", "jdk-17")
+	writeTestFile(t, filepath.Join(jdk, "release"), release)
+	writeTestFile(t, filepath.Join(jdk, "lib", "jrt-fs.jar"), "")
+	// A jre of its own next to a release file belongs to a JDK
+	writeTestFile(t, filepath.Join(root, "jdk-old", "release"), release)
+	writeTestFile(t, filepath.Join(root, "jdk-old", "jre", "release"), release)
+	writeTestFile(t, filepath.Join(root, "jdk-old", "lib", "tools.jar"), "")
+
+	tests := []struct {
+		home string
+		want *Embedding
+	}{
+		{filepath.Join(idea, "jbr"), &Embedding{Wrapper: WrapperJetBrains, Application: "IntelliJ IDEA", AppDir: idea}},
+		{filepath.Join(goland, "Contents", "jbr", "Contents", "Home"), &Embedding{Wrapper: WrapperJetBrains, Application: "GoLand", AppDir: goland}},
+		{justj, &Embedding{Wrapper: WrapperEclipse, Application: "Eclipse Platform", AppDir: eclipse}},
+		{filepath.Join(viewer, "Contents", "PlugIns", "jre.bundle", "Contents", "Home"), &Embedding{Wrapper: WrapperAppBundle, Application: "Image Viewer", AppDir: viewer}},
+		{filepath.Join(acme, "jre"), &Embedding{Wrapper: WrapperBundled, Application: "acme", AppDir: acme}},
+		{jdk, nil},
+		{filepath.Join(root, "jdk-old", "jre"), nil},
+	}
+	for _, tt := range tests {
+		got := detectBundledApplication(filepath.Join(tt.home, "bin", java))
+		if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.home, tt.want, got)
+		}
+	}
+
+	// Only -embedded applies the heuristics
+	createFakeJava(t, filepath.Join(acme, "jre"))
+	finder := NewJavaFinder(acme, -1, false, false)
+	if results, err := finder.Find(); err != nil || len(results) != 1 || results[0].Embedding != nil {
+		t.Fatalf("Expected the runtime without embedding, got %v (%v)", results, err)
+	}
+	finder.embedded = true
+	results, err := finder.Find()
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected the runtime of acme, got %v (%v)", results, err)
+	}
+	if output := buildJSONOutput(results, finder, time.Now()); !output.Runtimes[0].Embedded || output.Runtimes[0].EmbeddedIn.AppDir != acme {
+		t.Errorf("Expected the runtime to be embedded in %s, got %+v", acme, output.Runtimes[0].EmbeddedIn)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Enricher adds information to a result after discovery and evaluation. The
+// value returned by Enrich is stored under the name of the enricher in the
+// enrichments of the result, so enrichers never touch each other's fields.
+// A nil value means the enricher has nothing to say about the runtime.
+type Enricher interface {
+	Name() string
+	Enrich(result *JavaResult) (any, error)
+}
+
+// enricherFactories creates the built-in enrichers by name
+var enricherFactories = map[string]func(cfg *Config) (Enricher, error){
+	"eol":      func(cfg *Config) (Enricher, error) { return &eolEnricher{}, nil },
+	"license":  func(cfg *Config) (Enricher, error) { return &licenseEnricher{}, nil },
+	"hash":     func(cfg *Config) (Enricher, error) { return &hashEnricher{}, nil },
+	"cve":      newCVEEnricher,
+	"tls":      func(cfg *Config) (Enricher, error) { return &tlsEnricher{}, nil },
+	"crypto":   func(cfg *Config) (Enricher, error) { return &cryptoEnricher{}, nil },
+	"cacerts":  func(cfg *Config) (Enricher, error) { return &cacertsEnricher{}, nil },
+	"tzdata":   newTZDataEnricher,
+	"adoptium": newAdoptiumEnricher,
+}
+
+// enricherNames returns the names of the built-in enrichers
+func enricherNames() []string {
+	return slices.Sorted(maps.Keys(enricherFactories))
+}
+
+// newEnrichers creates the enrichers from a comma separated list of names,
+// keeping the order of the list
+func newEnrichers(list []string, cfg *Config) ([]Enricher, error) {
+	var enrichers []Enricher
+	seen := make(map[string]bool)
+	for _, name := range list {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		factory, ok := enricherFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown enricher %q (available: %s)", name, strings.Join(enricherNames(), ", "))
+		}
+		enricher, err := factory(cfg)
+		if err != nil {
+			return nil, err
+		}
+		enrichers = append(enrichers, enricher)
+	}
+	return enrichers, nil
+}
+
+// enrich runs the enricher chain on a result. A failing enricher is reported
+// in verbose mode and doesn't stop the others.
+func (f *JavaFinder) enrich(result *JavaResult) {
+	for _, enricher := range f.enrichers {
+		value, err := enricher.Enrich(result)
+		if err != nil {
+			if f.verbose {
+				logf("Enricher %s failed for %s: %v\n", enricher.Name(), result.Path, err)
+			}
+			continue
+		}
+		if value == nil {
+			continue
+		}
+		if result.Enrichments == nil {
+			result.Enrichments = make(map[string]any)
+		}
+		result.Enrichments[enricher.Name()] = value
+	}
+}
+
+// runtimeVersion returns the version and vendor of a runtime, preferring the
+// evaluated properties over the release file
+func runtimeVersion(result *JavaResult) (version, vendor string) {
+	if result.Properties != nil && result.Properties.Version != "" {
+		return result.Properties.Version, result.Properties.Vendor
+	}
+	return result.Release["JAVA_VERSION"], result.Release["IMPLEMENTOR"]
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// failingEnricher is an enricher that always fails
+type failingEnricher struct{}
+
+func (e failingEnricher) Name() string { return "failing" }
+
+func (e failingEnricher) Enrich(result *JavaResult) (any, error) {
+	return nil, fmt.Errorf("unavailable")
+}
+
+func TestEnricherChain(t *testing.T) {
+	root := t.TempDir()
+	javaPath := createFakeJava(t, filepath.Join(root, "jdk-17"))
+	release := "JAVA_VERSION=\"17.0.9\"\nIMPLEMENTOR=\"Eclipse Adoptium\"\n"
+	if err := os.WriteFile(filepath.Join(root, "jdk-17", "release"), []byte(release), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	finder := NewJavaFinder(root, -1, false, false)
+	enrichers, err := newEnrichers([]string{"eol", "license", "hash"}, &Config{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	finder.enrichers = append(enrichers, failingEnricher{})
+
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != javaPath {
+		t.Fatalf("Expected %s, got %v", javaPath, results)
+	}
+
+	enrichments := results[0].Enrichments
+	if eol, ok := enrichments["eol"].(*EOLInfo); !ok || eol.EOLDate != eolDates[17] {
+		t.Errorf("Expected eol date %s, got %v", eolDates[17], enrichments["eol"])
+	}
+	if license, ok := enrichments["license"].(*LicenseInfo); !ok || license.License != "GPLv2+CPE" || license.Commercial {
+		t.Errorf("Expected GPL license, got %v", enrichments["license"])
+	}
+	if hash, ok := enrichments["hash"].(*HashInfo); !ok || hash.Size != int64(len("#!/bin/sh\n")) {
+		t.Errorf("Expected hash of the executable, got %v", enrichments["hash"])
+	}
+	if _, ok := enrichments["failing"]; ok {
+		t.Errorf("Expected no enrichment from a failing enricher")
+	}
+}
+
+func TestNewEnrichersUnknown(t *testing.T) {
+	if _, err := newEnrichers([]string{"eol", "nope"}, &Config{}); err == nil {
+		t.Error("Expected error for unknown enricher")
+	}
+	if _, err := newEnrichers([]string{"cve"}, &Config{}); err == nil {
+		t.Error("Expected error for cve enricher without feed")
+	}
+}
+
+func TestEOLEnricher(t *testing.T) {
+	now := func() time.Time { return time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC) }
+	enricher := &eolEnricher{now: now}
+
+	tests := []struct {
+		version string
+		isEOL   bool
+	}{
+		{"1.8.0_402", false},
+		{"20.0.2", true},
+		{"21.0.4", false},
+	}
+	for _, tt := range tests {
+		result := &JavaResult{Properties: &JavaProperties{Version: tt.version}}
+		value, err := enricher.Enrich(result)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tt.version, err)
+		}
+		if info := value.(*EOLInfo); info.IsEOL != tt.isEOL {
+			t.Errorf("Expected is_eol %v for %s, got %v", tt.isEOL, tt.version, info.IsEOL)
+		}
+	}
+
+	if value, _ := enricher.Enrich(&JavaResult{}); value != nil {
+		t.Errorf("Expected no enrichment without version, got %v", value)
+	}
+}
+
+func TestLicenseEnricher(t *testing.T) {
+	tests := []struct {
+		version     string
+		runtimeName string
+		license     string
+		commercial  bool
+	}{
+		{"1.8.0_202", "Java(TM) SE Runtime Environment", "BCL", false},
+		{"1.8.0_401", "Java(TM) SE Runtime Environment", "OTN", true},
+		{"17.0.12", "Java(TM) SE Runtime Environment", "NFTC", false},
+		{"21.0.4", "OpenJDK Runtime Environment", "GPLv2+CPE", false},
+	}
+	for _, tt := range tests {
+		result := &JavaResult{Properties: &JavaProperties{
+			Version:     tt.version,
+			Vendor:      "Oracle Corporation",
+			RuntimeName: tt.runtimeName,
+		}}
+		value, _ := (&licenseEnricher{}).Enrich(result)
+		want := &LicenseInfo{License: tt.license, Commercial: tt.commercial}
+		if !reflect.DeepEqual(value, want) {
+			t.Errorf("Expected %v for %s, got %v", want, tt.version, value)
+		}
+	}
+}
+
+func TestCVEEnricher(t *testing.T) {
+	feed := filepath.Join(t.TempDir(), "feed.json")
+	content := `[
+		{"id": "CVE-2024-20918", "major": 17, "fixed_update": 10},
+		{"id": "CVE-2024-21147", "major": 17, "fixed_update": 12},
+		{"id": "CVE-2024-21147", "major": 21, "fixed_update": 4}
+	]`
+	if err := os.WriteFile(feed, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	enricher, err := newCVEEnricher(&Config{CVEFeed: feed})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	value, _ := enricher.Enrich(&JavaResult{Properties: &JavaProperties{Version: "17.0.10"}})
+	if !reflect.DeepEqual(value, []string{"CVE-2024-21147"}) {
+		t.Errorf("Expected CVE-2024-21147, got %v", value)
+	}
+}
+
+func TestCVEEnricherNone(t *testing.T) {
+	feed := filepath.Join(t.TempDir(), "feed.json")
+	writeTestFile(t, feed, `[{"id": "CVE-2024-20918", "major": 17, "fixed_update": 10}]`)
+	enricher, err := newCVEEnricher(&Config{CVEFeed: feed})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A runtime without known vulnerabilities has no cve enrichment
+	finder := NewJavaFinder(t.TempDir(), -1, false, false)
+	finder.enrichers = []Enricher{enricher}
+	result := &JavaResult{Properties: &JavaProperties{Version: "17.0.10"}}
+	finder.enrich(result)
+	if value, ok := result.Enrichments["cve"]; ok {
+		t.Errorf("Expected no cve enrichment, got %v", value)
+	}
+}
+
+func TestTLSEnricher(t *testing.T) {
+	security := `# comment
+jdk.tls.disabledAlgorithms=SSLv3, TLSv1, RC4, DES, MD5withRSA, \
+    DH keySize < 1024, EC keySize < 224, 3DES_EDE_CBC, anon, NULL, \
+    include jdk.disabled.namedCurves
+`
+	jdk17 := filepath.Join(t.TempDir(), "jdk-17")
+	writeTestFile(t, filepath.Join(jdk17, "conf", "security", "java.security"), security)
+	result := &JavaResult{
+		Path:    filepath.Join(jdk17, "bin", "java"),
+		Release: map[string]string{"JAVA_VERSION": "17.0.9"},
+	}
+
+	value, err := (&tlsEnricher{}).Enrich(result)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	info := value.(*TLSInfo)
+	if want := []string{"TLSv1.1", "TLSv1.2", "TLSv1.3"}; !reflect.DeepEqual(info.EnabledProtocols, want) {
+		t.Errorf("Expected enabled %v, got %v", want, info.EnabledProtocols)
+	}
+	if !info.LegacyTLSEnabled {
+		t.Error("Expected TLSv1.1 to count as legacy")
+	}
+
+	// Java 8 before 8u261 has no TLS 1.3 and keeps java.security in jre/lib
+	jdk8 := filepath.Join(t.TempDir(), "jdk1.8.0_202")
+	writeTestFile(t, filepath.Join(jdk8, "jre", "lib", "security", "java.security"),
+		"jdk.tls.disabledAlgorithms=SSLv3, TLSv1, TLSv1.1, RC4\n")
+	result = &JavaResult{
+		Path:    filepath.Join(jdk8, "bin", "java"),
+		Release: map[string]string{"JAVA_VERSION": "1.8.0_202"},
+	}
+	value, err = (&tlsEnricher{}).Enrich(result)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	info = value.(*TLSInfo)
+	if want := []string{"TLSv1.2"}; !reflect.DeepEqual(info.EnabledProtocols, want) || info.LegacyTLSEnabled {
+		t.Errorf("Expected only TLSv1.2 enabled, got %+v", info)
+	}
+}
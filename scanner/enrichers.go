@@ -0,0 +1,201 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// eolDates are the end of community support dates of each major version as
+// published for Eclipse Temurin. Feature releases end with the next release.
+var eolDates = map[int]string{
+	8:  "2030-12-31",
+	9:  "2018-03-31",
+	10: "2018-09-30",
+	11: "2027-10-31",
+	12: "2019-09-30",
+	13: "2020-03-31",
+	14: "2020-09-30",
+	15: "2021-03-31",
+	16: "2021-09-30",
+	17: "2029-10-31",
+	18: "2022-09-30",
+	19: "2023-03-31",
+	20: "2023-09-30",
+	21: "2029-12-31",
+	22: "2024-09-30",
+	23: "2025-03-31",
+	24: "2025-09-30",
+	25: "2031-09-30",
+	26: "2026-09-30",
+}
+
+// EOLInfo is the enrichment of the eol enricher
+type EOLInfo struct {
+	EOLDate string `json:"eol_date"`
+	IsEOL   bool   `json:"is_eol"`
+}
+
+// eolEnricher reports the end of life date of the major version of a runtime
+type eolEnricher struct {
+	now func() time.Time
+}
+
+// Name returns the name of the enricher
+func (e *eolEnricher) Name() string {
+	return "eol"
+}
+
+// Enrich looks up the end of life date of the runtime
+func (e *eolEnricher) Enrich(result *JavaResult) (any, error) {
+	version, _ := runtimeVersion(result)
+	major, _ := parseJavaVersion(version)
+	date, ok := eolDates[major]
+	if !ok {
+		return nil, nil
+	}
+	eol, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if e.now != nil {
+		now = e.now()
+	}
+	return &EOLInfo{EOLDate: date, IsEOL: now.After(eol)}, nil
+}
+
+// LicenseInfo is the enrichment of the license enricher
+type LicenseInfo struct {
+	License    string `json:"license"`
+	Commercial bool   `json:"commercial"`
+}
+
+// licenseEnricher reports the license a runtime is distributed under
+type licenseEnricher struct{}
+
+// Name returns the name of the enricher
+func (l *licenseEnricher) Name() string {
+	return "license"
+}
+
+// Enrich determines the license from the vendor and version of the runtime
+func (l *licenseEnricher) Enrich(result *JavaResult) (any, error) {
+	version, vendor := runtimeVersion(result)
+	if version == "" {
+		return nil, nil
+	}
+	if !strings.Contains(vendor, "Oracle") {
+		return &LicenseInfo{License: "GPLv2+CPE"}, nil
+	}
+
+	// Oracle OpenJDK builds report the same vendor but are GPL licensed
+	if result.Properties != nil && strings.Contains(result.Properties.RuntimeName, "OpenJDK") {
+		return &LicenseInfo{License: "GPLv2+CPE"}, nil
+	}
+
+	major, update := parseJavaVersion(version)
+	runtime := JavaRuntimeJSON{IsOracle: true, VersionMajor: major, VersionUpdate: update}
+	runtime.checkLicenseRequirement()
+	commercial := *runtime.RequireLicense
+	switch {
+	case major >= 17 && !commercial:
+		return &LicenseInfo{License: "NFTC"}, nil
+	case commercial:
+		return &LicenseInfo{License: "OTN", Commercial: true}, nil
+	default:
+		return &LicenseInfo{License: "BCL"}, nil
+	}
+}
+
+// HashInfo is the enrichment of the hash enricher
+type HashInfo struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// hashEnricher computes the checksum of the java executable, which identifies
+// a build independently of where it is installed
+type hashEnricher struct{}
+
+// Name returns the name of the enricher
+func (h *hashEnricher) Name() string {
+	return "hash"
+}
+
+// Enrich hashes the java executable
+func (h *hashEnricher) Enrich(result *JavaResult) (any, error) {
+	file, err := os.Open(result.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, err
+	}
+	return &HashInfo{SHA256: hex.EncodeToString(hash.Sum(nil)), Size: size}, nil
+}
+
+// CVEEntry is a vulnerability of a major version fixed in an update release
+type CVEEntry struct {
+	ID          string `json:"id"`
+	Major       int    `json:"major"`
+	FixedUpdate int    `json:"fixed_update"`
+}
+
+// cveEnricher lists the known vulnerabilities of a runtime from a local feed
+// file, so the scan works on hosts without internet access
+type cveEnricher struct {
+	entries []CVEEntry
+}
+
+// newCVEEnricher loads the feed configured with cve_feed
+func newCVEEnricher(cfg *Config) (Enricher, error) {
+	if cfg.CVEFeed == "" {
+		return nil, fmt.Errorf("the cve enricher requires cve_feed in the configuration")
+	}
+	data, err := os.ReadFile(cfg.CVEFeed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CVE feed %s: %v", cfg.CVEFeed, err)
+	}
+	var entries []CVEEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse CVE feed %s: %v", cfg.CVEFeed, err)
+	}
+	return &cveEnricher{entries: entries}, nil
+}
+
+// Name returns the name of the enricher
+func (c *cveEnricher) Name() string {
+	return "cve"
+}
+
+// Enrich returns the IDs of all vulnerabilities fixed after the runtime's
+// update, nothing if there are none
+func (c *cveEnricher) Enrich(result *JavaResult) (any, error) {
+	version, _ := runtimeVersion(result)
+	if version == "" {
+		return nil, nil
+	}
+	major, update := parseJavaVersion(version)
+
+	var ids []string
+	for _, entry := range c.entries {
+		if entry.Major == major && update < entry.FixedUpdate {
+			ids = append(ids, entry.ID)
+		}
+	}
+	if ids == nil {
+		return nil, nil
+	}
+	return ids, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Runtime environments jfind itself can run in
+const (
+	EnvBareMetal = "bare-metal"
+	EnvVM        = "vm"
+	EnvContainer = "container"
+)
+
+// vmVendors are substrings of DMI/BIOS vendor and product names of hypervisors
+var vmVendors = []string{
+	"vmware", "virtualbox", "kvm", "qemu", "xen", "virtual machine", "hyper-v",
+	"amazon ec2", "google compute engine", "parallels", "bhyve", "openstack",
+}
+
+// detectRuntimeEnvironment determines whether jfind runs on bare metal, in a
+// virtual machine or in a container
+func detectRuntimeEnvironment() string {
+	switch runtime.GOOS {
+	case "linux":
+		if isLinuxContainer() {
+			return EnvContainer
+		}
+		for _, name := range []string{"sys_vendor", "product_name"} {
+			if data, err := os.ReadFile("/sys/class/dmi/id/" + name); err == nil && isVMVendor(string(data)) {
+				return EnvVM
+			}
+		}
+		if data, err := os.ReadFile("/proc/cpuinfo"); err == nil && strings.Contains(string(data), " hypervisor") {
+			return EnvVM
+		}
+	case "darwin":
+		output, err := exec.Command("sysctl", "-n", "kern.hv_vmm_present").Output()
+		if err == nil && strings.TrimSpace(string(output)) == "1" {
+			return EnvVM
+		}
+	case "windows":
+		if os.Getenv("CONTAINER_SANDBOX_MOUNT_POINT") != "" {
+			return EnvContainer
+		}
+		output, err := exec.Command("reg", "query", `HKLM\HARDWARE\DESCRIPTION\System\BIOS`).Output()
+		if err == nil && isVMVendor(string(output)) {
+			return EnvVM
+		}
+	}
+	return EnvBareMetal
+}
+
+// isLinuxContainer checks the usual markers left by container runtimes
+func isLinuxContainer() bool {
+	for _, marker := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(marker); err == nil {
+			return true
+		}
+	}
+	if os.Getenv("container") != "" || os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return true
+	}
+	if data, err := os.ReadFile("/proc/1/cgroup"); err == nil {
+		content := string(data)
+		for _, name := range []string{"docker", "kubepods", "containerd", "libpod", "lxc"} {
+			if strings.Contains(content, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isVMVendor checks if a hardware description names a hypervisor
+func isVMVendor(description string) bool {
+	description = strings.ToLower(description)
+	for _, vendor := range vmVendors {
+		if strings.Contains(description, vendor) {
+			return true
+		}
+	}
+	return false
+}
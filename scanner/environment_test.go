@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestIsVMVendor(t *testing.T) {
+	tests := []struct {
+		description string
+		vm          bool
+	}{
+		{"VMware, Inc.\n", true},
+		{"QEMU Standard PC (Q35 + ICH9, 2009)", true},
+		{"Microsoft Corporation Virtual Machine", true},
+		{"Amazon EC2", true},
+		{"Dell Inc.", false},
+		{"LENOVO", false},
+	}
+	for _, tt := range tests {
+		if vm := isVMVendor(tt.description); vm != tt.vm {
+			t.Errorf("isVMVendor(%q) = %v, want %v", tt.description, vm, tt.vm)
+		}
+	}
+}
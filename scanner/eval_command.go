@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// javaPlaceholder is replaced by the path of the java executable being evaluated
+const javaPlaceholder = "{java}"
+
+// defaultEvalCommand is the command used to evaluate a java executable
+const defaultEvalCommand = javaPlaceholder + " -XshowSettings:properties -version"
+
+// evalCommand is a parsed evaluation command template, e.g.
+// `nice -n 19 timeout 30 {java} -Xshare:off -XshowSettings:properties -version`
+type evalCommand struct {
+	args []string
+}
+
+// parseEvalCommand splits a command template into arguments. Arguments are separated
+// by whitespace and can be quoted with single or double quotes; a backslash escapes
+// the next character outside of single quotes. The template is never passed to a shell.
+func parseEvalCommand(template string) (*evalCommand, error) {
+	args, err := splitCommandLine(template)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("evaluation command is empty")
+	}
+
+	found := false
+	for _, arg := range args {
+		if strings.Contains(arg, javaPlaceholder) {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("evaluation command %q does not contain %s", template, javaPlaceholder)
+	}
+
+	return &evalCommand{args: args}, nil
+}
+
+// build returns the program and arguments to evaluate javaPath. The path is
+// substituted after splitting, so it always stays within its argument.
+func (c *evalCommand) build(javaPath string) (string, []string) {
+	args := make([]string, len(c.args))
+	for i, arg := range c.args {
+		args[i] = strings.ReplaceAll(arg, javaPlaceholder, javaPath)
+	}
+	return args[0], args[1:]
+}
+
+// buildProbe returns the program and arguments to run javaPath with other java
+// arguments, keeping everything up to the java executable, e.g. a wrapper like nice
+func (c *evalCommand) buildProbe(javaPath string, javaArgs ...string) (string, []string) {
+	var args []string
+	for _, arg := range c.args {
+		args = append(args, strings.ReplaceAll(arg, javaPlaceholder, javaPath))
+		if strings.Contains(arg, javaPlaceholder) {
+			break
+		}
+	}
+	args = append(args, javaArgs...)
+	return args[0], args[1:]
+}
+
+// String returns the template of the command
+func (c *evalCommand) String() string {
+	quoted := make([]string, len(c.args))
+	for i, arg := range c.args {
+		quoted[i] = quoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// splitCommandLine splits a command line into arguments using POSIX shell like quoting
+func splitCommandLine(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("unterminated escape in %q", s)
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// quoteArg quotes an argument for display if it contains characters that would be split
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\r'\"\\") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
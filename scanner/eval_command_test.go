@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{`{java} -version`, []string{"{java}", "-version"}},
+		{`nice -n 19  {java}`, []string{"nice", "-n", "19", "{java}"}},
+		{`'/opt/my tools/wrap' "{java}" -Dx='a b'`, []string{"/opt/my tools/wrap", "{java}", "-Dx=a b"}},
+		{`sandbox --arg\ with\ spaces {java}`, []string{"sandbox", "--arg with spaces", "{java}"}},
+		{`{java} -Dempty=""`, []string{"{java}", "-Dempty="}},
+		{`{java} ""`, []string{"{java}", ""}},
+	}
+
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.input)
+		if err != nil {
+			t.Errorf("splitCommandLine(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := splitCommandLine(`{java} "unterminated`); err == nil {
+		t.Error("Expected error for unterminated quote")
+	}
+}
+
+func TestEvalCommandBuild(t *testing.T) {
+	cmd, err := parseEvalCommand(`timeout 30 {java} -XshowSettings:properties -version`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, args := cmd.build("/opt/my jdk/bin/java")
+	if name != "timeout" {
+		t.Errorf("Expected program timeout, got %s", name)
+	}
+	want := []string{"30", "/opt/my jdk/bin/java", "-XshowSettings:properties", "-version"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected args %q, got %q", want, args)
+	}
+
+	if _, err := parseEvalCommand("nice -n 19"); err == nil {
+		t.Error("Expected error for template without placeholder")
+	}
+}
+
+func TestEvalCommandBuildProbe(t *testing.T) {
+	cmd, err := parseEvalCommand(`timeout 30 {java} -Xshare:off -XshowSettings:properties -version`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The wrapper is kept, the java arguments are replaced
+	name, args := cmd.buildProbe("/opt/jdk/bin/java", "-verbose:sizes", "-version")
+	want := []string{"30", "/opt/jdk/bin/java", "-verbose:sizes", "-version"}
+	if name != "timeout" || !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected timeout %q, got %s %q", want, name, args)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// exclusions are the glob patterns of directories not to walk, from repeated
+// -exclude flags. Patterns with a slash are matched against the whole path, so
+// "**/node_modules" skips every node_modules directory; patterns without one,
+// like ".git", are matched against the directory name only.
+type exclusions []string
+
+func (e *exclusions) String() string {
+	return strings.Join(*e, " ")
+}
+
+func (e *exclusions) Set(value string) error {
+	if value = strings.TrimSpace(value); value != "" {
+		*e = append(*e, expandPattern(value))
+	}
+	return nil
+}
+
+// match returns the first pattern a directory matches
+func (e exclusions) match(path string) (string, bool) {
+	slashed := filepath.ToSlash(path)
+	for _, pattern := range e {
+		name := slashed
+		if !strings.Contains(pattern, "/") {
+			name = filepath.Base(path)
+		}
+		if matchGlob(pattern, name) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// defaultPruneDirs is the -prune-dirs list unless overridden
+const defaultPruneDirs = ".git,node_modules,.m2/repository,Trash"
+
+// pruneSet is the -prune-dirs list of directories not to walk. Unlike the
+// exclusions it is a map from the directory name to the entries with that
+// name, so it stays fast with thousands of entries. An entry with a slash,
+// like .m2/repository, names the last directories of a path.
+type pruneSet map[string][]string
+
+// parsePruneDirs parses a comma separated -prune-dirs list, empty prunes nothing
+func parsePruneDirs(list string) pruneSet {
+	prune := make(pruneSet)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.Trim(filepath.ToSlash(strings.TrimSpace(entry)), "/")
+		if entry == "" {
+			continue
+		}
+		name := entry[strings.LastIndex(entry, "/")+1:]
+		prune[name] = append(prune[name], entry)
+	}
+	return prune
+}
+
+// match returns the entry a directory matches
+func (p pruneSet) match(path string) (string, bool) {
+	entries, ok := p[filepath.Base(path)]
+	if !ok {
+		return "", false
+	}
+	slashed := filepath.ToSlash(path)
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") || strings.HasSuffix(slashed, "/"+entry) {
+			return entry, true
+		}
+	}
+	return "", false
+}
+
+// excludedPath reports whether a path is below a directory the walk skips by
+// -exclude or -prune-dirs, for sources that list paths without walking
+func (f *JavaFinder) excludedPath(path string) bool {
+	root := f.rootOf(path)
+	for dir := filepath.Dir(path); dir != root && withinRoot(root, dir); dir = filepath.Dir(dir) {
+		if _, ok := f.exclude.match(dir); ok {
+			return true
+		}
+		if _, ok := f.prune.match(dir); ok {
+			return true
+		}
+	}
+	return false
+}
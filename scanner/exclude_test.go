@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExclusionsMatch(t *testing.T) {
+	var exclude exclusions
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&exclude, "exclude", "")
+	if err := fs.Parse([]string{"-exclude", "**/node_modules", "-exclude", ".git", "-exclude", "/mnt/*"}); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/home/alice/app/node_modules": "**/node_modules",
+		"/home/alice/app/.git":         ".git",
+		"/mnt/nas":                     "/mnt/*",
+		"/mnt/nas/jdk":                 "",
+		"/home/alice/node_modules.bak": "",
+	} {
+		if pattern, _ := exclude.match(filepath.FromSlash(path)); pattern != want {
+			t.Errorf("Expected %s to match %q, got %q", path, want, pattern)
+		}
+	}
+}
+
+func TestFindExclude(t *testing.T) {
+	dir := t.TempDir()
+	createFakeJava(t, filepath.Join(dir, "opt", "jdk-21"))
+	createFakeJava(t, filepath.Join(dir, "app", "node_modules", "jre"))
+	createFakeJava(t, filepath.Join(dir, "lib", "node_modules", "jre"))
+	createFakeJava(t, filepath.Join(dir, "src", ".git", "jdk"))
+
+	for _, workers := range []int{1, 4} {
+		finder := NewJavaFinder(dir, -1, false, false)
+		finder.exclude = exclusions{"**/node_modules", ".git"}
+		finder.workers = workers
+		results, err := finder.Find()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].Path != filepath.Join(dir, "opt", "jdk-21", "bin", "java") {
+			t.Errorf("Expected only the runtime outside the excluded directories with %d workers, got %v", workers, results)
+		}
+		if meta := buildJSONOutput(results, finder, time.Now()).Meta; meta.ExcludedDirs != 3 {
+			t.Errorf("Expected 3 excluded directories with %d workers, got %d", workers, meta.ExcludedDirs)
+		}
+	}
+}
+
+func TestPruneSetMatch(t *testing.T) {
+	prune := parsePruneDirs(defaultPruneDirs + ", build/ ,,")
+	for path, want := range map[string]string{
+		"/home/alice/app/node_modules":       "node_modules",
+		"/home/alice/.m2/repository":         ".m2/repository",
+		"/srv/repository":                    "",
+		"/home/alice/.local/share/Trash":     "Trash",
+		"/home/alice/project/build":          "build",
+		"/home/alice/project/node_modules.d": "",
+	} {
+		if entry, _ := prune.match(filepath.FromSlash(path)); entry != want {
+			t.Errorf("Expected %s to match %q, got %q", path, want, entry)
+		}
+	}
+	if prune := parsePruneDirs(""); len(prune) != 0 {
+		t.Errorf("Expected an empty list to prune nothing, got %v", prune)
+	}
+}
+
+func TestFindPruneDirs(t *testing.T) {
+	dir := t.TempDir()
+	createFakeJava(t, filepath.Join(dir, "opt", "jdk-21"))
+	createFakeJava(t, filepath.Join(dir, "app", "node_modules", "jre"))
+	createFakeJava(t, filepath.Join(dir, "home", ".m2", "repository", "jdk"))
+	createFakeJava(t, filepath.Join(dir, "srv", "repository", "jdk-17"))
+
+	finder := NewJavaFinder(dir, -1, false, false)
+	finder.prune = parsePruneDirs(defaultPruneDirs)
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Path != filepath.Join(dir, "opt", "jdk-21", "bin", "java") {
+		t.Errorf("Expected the runtimes outside the pruned directories, got %v", results)
+	}
+	if meta := buildJSONOutput(results, finder, time.Now()).Meta; meta.ExcludedDirs != 2 {
+		t.Errorf("Expected 2 pruned directories, got %d", meta.ExcludedDirs)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ExplainStep is one check the finder applies to a path during discovery
+type ExplainStep struct {
+	Check  string `json:"check"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// Explanation tells why a path would or wouldn't be discovered
+type Explanation struct {
+	Path        string        `json:"path"`
+	StartPath   string        `json:"start_path"`
+	Discovered  bool          `json:"discovered"`
+	Steps       []ExplainStep `json:"steps"`
+	PathClass   PathClass     `json:"path_class,omitempty"`
+	Provisioner string        `json:"provisioned_by,omitempty"`
+}
+
+// explain applies the checks of walkFileSystem to a single path. The checks stop
+// at the first one failing, as the walk would never get further.
+func (f *JavaFinder) explain(path string) *Explanation {
+	e := &Explanation{Path: path, StartPath: f.startPath}
+	step := func(check string, passed bool, format string, a ...interface{}) bool {
+		e.Steps = append(e.Steps, ExplainStep{Check: check, Passed: passed, Detail: fmt.Sprintf(format, a...)})
+		return passed
+	}
+
+	if !withinRoot(f.startPath, path) {
+		step("root", false, "not below the start path %s", f.startPath)
+		return e
+	}
+	rel, _ := filepath.Rel(f.startPath, path)
+	step("root", true, "below the start path %s as %s", f.startPath, rel)
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		step("exists", false, "%v", err)
+		return e
+	}
+	step("exists", true, "%s", info.Mode())
+
+	// Every directory between the start path and the file must be walked into
+	dir := filepath.Dir(path)
+	var ancestors []string
+	for ; dir != f.startPath && len(dir) > len(f.startPath); dir = filepath.Dir(dir) {
+		ancestors = append([]string{dir}, ancestors...)
+	}
+	for _, dir := range append([]string{f.startPath}, ancestors...) {
+		dirInfo, err := os.Lstat(dir)
+		if err != nil {
+			step("directory", false, "%s: %v", dir, err)
+			return e
+		}
+		// filepath.Walk doesn't follow symbolic links below the start path
+		if dir != f.startPath && dirInfo.Mode()&os.ModeSymlink != 0 {
+			step("directory", false, "%s is a symbolic link, which is not followed", dir)
+			return e
+		}
+		if f.skipVirtualFS && dir != f.startPath {
+			if fsType, ok := virtualFileSystem(dir); ok {
+				step("directory", false, "%s is a %s file system, which is skipped in containers", dir, fsType)
+				return e
+			}
+		}
+		handle, err := os.Open(dir)
+		if err == nil {
+			_, err = handle.Readdirnames(1)
+			handle.Close()
+		}
+		if err != nil && err != io.EOF {
+			step("directory", false, "%s cannot be read: %v", dir, err)
+			return e
+		}
+	}
+	step("directory", true, "all %d directories from the start path can be walked", len(ancestors)+1)
+
+	depth := f.getPathDepth(path)
+	if maxDepth := f.maxDepthOf(path); maxDepth >= 0 {
+		if !step("depth", depth <= maxDepth, "depth %d, maximum %d", depth, maxDepth) {
+			return e
+		}
+	} else {
+		step("depth", true, "depth %d, unlimited", depth)
+	}
+
+	if info.IsDir() {
+		step("name", false, "is a directory")
+		return e
+	}
+	if !step("name", f.matchesName(info.Name()), "file name %s", info.Name()) {
+		return e
+	}
+	if !step("executable", isExecutable(info), "mode %s", info.Mode()) {
+		return e
+	}
+
+	e.Discovered = true
+	e.PathClass = f.classifier.classify(path)
+	e.Provisioner = detectProvisioner(path)
+	return e
+}
+
+// print writes the explanation as text
+func (e *Explanation) print(w io.Writer) {
+	fmt.Fprintf(w, "Explaining %s\n", e.Path)
+	for _, s := range e.Steps {
+		status := "ok"
+		if !s.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "[%-4s] %-10s %s\n", status, s.Check, s.Detail)
+	}
+	if !e.Discovered {
+		fmt.Fprintf(w, "Result: would not be discovered\n")
+		return
+	}
+	fmt.Fprintf(w, "Result: would be discovered (path class %s", e.PathClass)
+	if e.Provisioner != "" {
+		fmt.Fprintf(w, ", provisioned by %s", e.Provisioner)
+	}
+	fmt.Fprintf(w, ")\n")
+}
+
+// runExplain implements the explain command and returns the exit code:
+// 0 if the path would be discovered, 1 if not and 2 on usage errors
+func runExplain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	startPath := fs.String("path", ".", "Start path for searching")
+	maxDepth := fs.Int("depth", -1, "Maximum depth to search (-1 for unlimited)")
+	configFile := fs.String("config", "", "Path to a JSON configuration file")
+	jsonOutput := fs.Bool("json", false, "Output the explanation in JSON format")
+	fs.Usage = func() {
+		logf("Usage: jfind explain [options] <path to java>\n")
+		fs.PrintDefaults()
+	}
+
+	// Allow options before and after the path
+	var paths []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		paths = append(paths, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(paths) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg := &Config{}
+	if *configFile != "" {
+		var err error
+		if cfg, err = LoadConfig(*configFile); err != nil {
+			logf("Error: %v\n", err)
+			return 2
+		}
+	}
+	absStart, err := filepath.Abs(*startPath)
+	if err != nil {
+		logf("Error resolving path: %v\n", err)
+		return 2
+	}
+	absPath, err := filepath.Abs(paths[0])
+	if err != nil {
+		logf("Error resolving path: %v\n", err)
+		return 2
+	}
+
+	finder := NewJavaFinder(absStart, *maxDepth, false, false)
+	finder.classifier = newPathClassifier(cfg.PathRules)
+	finder.skipVirtualFS = detectRuntimeEnvironment() == EnvContainer
+
+	explanation := finder.explain(absPath)
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(explanation, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+	} else {
+		explanation.print(os.Stdout)
+	}
+	if !explanation.Discovered {
+		return 1
+	}
+	return 0
+}
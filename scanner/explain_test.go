@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	root := t.TempDir()
+	java := createFakeJava(t, filepath.Join(root, "opt", "jdk-17"))
+	outside := createFakeJava(t, t.TempDir())
+
+	failedCheck := func(e *Explanation) string {
+		for _, step := range e.Steps {
+			if !step.Passed {
+				return step.Check
+			}
+		}
+		return ""
+	}
+
+	finder := NewJavaFinder(root, -1, false, false)
+	if e := finder.explain(java); !e.Discovered || failedCheck(e) != "" {
+		t.Errorf("Expected %s to be discovered, got %+v", java, e.Steps)
+	}
+	if e := finder.explain(outside); e.Discovered || failedCheck(e) != "root" {
+		t.Errorf("Expected root check to fail for %s, got %+v", outside, e.Steps)
+	}
+	if e := finder.explain(filepath.Join(root, "missing", "bin", "java")); failedCheck(e) != "exists" {
+		t.Errorf("Expected exists check to fail, got %+v", e.Steps)
+	}
+	if e := finder.explain(filepath.Dir(java)); failedCheck(e) != "name" {
+		t.Errorf("Expected name check to fail for a directory, got %+v", e.Steps)
+	}
+
+	finder.maxDepth = 3
+	if e := finder.explain(java); e.Discovered || failedCheck(e) != "depth" {
+		t.Errorf("Expected depth check to fail, got %+v", e.Steps)
+	}
+
+	// The explanation must agree with the walk
+	finder.maxDepth = 4
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 1 || !finder.explain(java).Discovered {
+		t.Errorf("Expected explain and walk to agree, got %d results", len(results))
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	finder.maxDepth = -1
+	if err := os.Chmod(java, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if e := finder.explain(java); failedCheck(e) != "executable" {
+		t.Errorf("Expected executable check to fail, got %+v", e.Steps)
+	}
+
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(filepath.Join(root, "opt"), link); err != nil {
+		t.Fatal(err)
+	}
+	if e := finder.explain(filepath.Join(link, "jdk-17", "bin", "java")); failedCheck(e) != "directory" {
+		t.Errorf("Expected directory check to fail below a symbolic link, got %+v", e.Steps)
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// StepUnchanged is the result of a change that is not needed
+const StepUnchanged = "unchanged"
+
+// DefaultChange is a change of one default mechanism made by jfind fix-default
+type DefaultChange struct {
+	Mechanism string `json:"mechanism"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to"`
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+}
+
+// FixDefaultResult is the report of jfind fix-default
+type FixDefaultResult struct {
+	Use     string          `json:"use"`
+	DryRun  bool            `json:"dry_run"`
+	Before  *DefaultRuntime `json:"before"`
+	Changes []DefaultChange `json:"changes"`
+	After   *DefaultRuntime `json:"after"`
+}
+
+// failed reports whether any change failed
+func (r *FixDefaultResult) failed() bool {
+	for _, change := range r.Changes {
+		if change.Result == StepFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultFixer points the default mechanisms of the operating system at a runtime
+type defaultFixer struct {
+	dryRun bool
+
+	// environmentFile is the system wide environment on Linux, /etc/environment
+	environmentFile string
+
+	// runCommand runs update-alternatives, nil if it is not installed
+	runCommand func(name string, args ...string) ([]byte, error)
+}
+
+// newDefaultFixer creates a fixer for the mechanisms of this host
+func newDefaultFixer(dryRun bool) *defaultFixer {
+	f := &defaultFixer{dryRun: dryRun, environmentFile: "/etc/environment"}
+	if _, err := exec.LookPath("update-alternatives"); err == nil {
+		f.runCommand = func(name string, args ...string) ([]byte, error) {
+			return exec.Command(name, args...).CombinedOutput()
+		}
+	}
+	return f
+}
+
+// change records a change and makes it unless this is a dry run or nothing changes
+func (f *defaultFixer) change(mechanism, from, to string, apply func() error) DefaultChange {
+	c := DefaultChange{Mechanism: mechanism, From: from, To: to}
+	switch {
+	case from != "" && sameHome(from, to):
+		c.Result = StepUnchanged
+	case f.dryRun:
+		c.Result = StepPlanned
+	default:
+		if err := apply(); err != nil {
+			c.Result, c.Error = StepFailed, err.Error()
+		} else {
+			c.Result = StepDone
+		}
+	}
+	return c
+}
+
+// manual records a change that must be made by hand, unless nothing changes
+func (f *defaultFixer) manual(mechanism, from, to, instructions string) DefaultChange {
+	if from != "" && sameHome(from, to) {
+		return DefaultChange{Mechanism: mechanism, From: from, To: to, Result: StepUnchanged}
+	}
+	return DefaultChange{Mechanism: mechanism, From: from, To: to, Result: StepManual, Error: instructions}
+}
+
+// fixAlternatives selects the alternative of the runtime, registering it first if needed
+func (f *defaultFixer) fixAlternatives(home, current string) DefaultChange {
+	if f.runCommand == nil {
+		return DefaultChange{Mechanism: MechanismAlternatives, From: current, To: home, Result: StepManual,
+			Error: "update-alternatives is not installed"}
+	}
+	java := filepath.Join(home, "bin", "java")
+	registered := false
+	if out, err := f.runCommand("update-alternatives", "--list", "java"); err == nil {
+		for _, alternative := range strings.Fields(string(out)) {
+			if sameHome(runtimeHome(alternative), home) {
+				java, registered = alternative, true
+				break
+			}
+		}
+	}
+	return f.change(MechanismAlternatives, current, home, func() error {
+		if !registered {
+			if out, err := f.runCommand("update-alternatives", "--install", "/usr/bin/java", "java", java, "1"); err != nil {
+				return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+			}
+		}
+		if out, err := f.runCommand("update-alternatives", "--set", "java", java); err != nil {
+			return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	})
+}
+
+// fixEnvironmentFile sets JAVA_HOME in the system wide environment file
+func (f *defaultFixer) fixEnvironmentFile(home string) DefaultChange {
+	current, _ := readEnvironmentFile(f.environmentFile, "JAVA_HOME")
+	return f.change("JAVA_HOME", current, home, func() error {
+		return updateEnvironmentFile(f.environmentFile, "JAVA_HOME", home)
+	})
+}
+
+// readEnvironmentFile returns a variable of an environment file of NAME=value lines
+func readEnvironmentFile(path, name string) (string, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+	value, found := "", false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if key, v, ok := strings.Cut(line, "="); ok && strings.TrimSpace(strings.TrimPrefix(key, "export ")) == name {
+			value, found = strings.Trim(strings.TrimSpace(v), `"'`), true
+		}
+	}
+	return value, found
+}
+
+// updateEnvironmentFile sets a variable in an environment file, replacing every
+// assignment of it and keeping all other lines
+func updateEnvironmentFile(path, name, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	assignment := fmt.Sprintf("%s=%q", name, value)
+	var lines []string
+	replaced := false
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if key, _, ok := strings.Cut(strings.TrimSpace(line), "="); ok && strings.TrimSpace(strings.TrimPrefix(key, "export ")) == name {
+			if !replaced {
+				lines = append(lines, assignment)
+				replaced = true
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if !replaced {
+		lines = append(lines, assignment)
+	}
+	if len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+
+	// Replace the file atomically, a half written environment breaks every login
+	tmp := path + ".jfind-tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// print writes the result as text
+func (r *FixDefaultResult) print(w io.Writer) {
+	printState := func(title string, d *DefaultRuntime) {
+		fmt.Fprintf(w, "%s:\n", title)
+		fmt.Fprintf(w, "  PATH:      %s\n", valueOrNone(d.PathHome))
+		fmt.Fprintf(w, "  JAVA_HOME: %s\n", valueOrNone(d.JavaHome))
+		if d.OSMechanism != "" {
+			fmt.Fprintf(w, "  %-10s %s\n", d.OSMechanism+":", valueOrNone(d.OSDefault))
+		}
+		for _, warning := range d.Warnings {
+			fmt.Fprintf(w, "  Warning: %s\n", warning)
+		}
+	}
+	printState("Before", r.Before)
+	fmt.Fprintf(w, "Changes:\n")
+	for _, c := range r.Changes {
+		fmt.Fprintf(w, "  [%s] %s: %s -> %s", c.Result, c.Mechanism, valueOrNone(c.From), c.To)
+		if c.Error != "" {
+			fmt.Fprintf(w, " (%s)", c.Error)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	printState("After", r.After)
+}
+
+// valueOrNone returns the value or (none) if it is empty
+func valueOrNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+// runFixDefault implements the fix-default command and returns the exit code:
+// 0 if the default runtime is consistent afterwards, 1 if not and 2 on usage errors
+func runFixDefault(args []string) int {
+	fs := flag.NewFlagSet("fix-default", flag.ContinueOnError)
+	use := fs.String("use", "", "Home directory of the approved runtime to make the system default")
+	dryRun := fs.Bool("dry-run", false, "Only report the changes that would be made")
+	jsonOutput := fs.Bool("json", false, "Output the result in JSON format")
+	fs.Usage = func() {
+		logf("Usage: jfind fix-default -use <runtime home> [options]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *use == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	home, err := filepath.Abs(*use)
+	if err != nil {
+		logf("Error resolving path: %v\n", err)
+		return 2
+	}
+	if resolved, err := filepath.EvalSymlinks(home); err == nil {
+		home = resolved
+	}
+	if info, err := os.Stat(filepath.Join(home, "bin", javaExecutableName())); err != nil || info.IsDir() {
+		logf("Error: %s is not a runtime home, bin/%s is missing\n", home, javaExecutableName())
+		return 2
+	}
+
+	result := &FixDefaultResult{Use: home, DryRun: *dryRun, Before: detectDefaultRuntime()}
+	result.Changes = newDefaultFixer(*dryRun).fix(home)
+
+	// This process still has the old JAVA_HOME, so the new one is taken from the changes
+	javaHome := os.Getenv("JAVA_HOME")
+	for _, change := range result.Changes {
+		if change.Mechanism == "JAVA_HOME" && change.Result == StepDone {
+			javaHome = change.To
+		}
+	}
+	pathJava, _ := exec.LookPath(javaExecutableName())
+	mechanism, osHome := osDefaultJavaHome()
+	result.After = checkDefaultRuntime(pathJava, javaHome, mechanism, osHome)
+
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(result, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+	} else {
+		result.print(os.Stdout)
+	}
+	if result.failed() || !result.After.Consistent {
+		return 1
+	}
+	return 0
+}
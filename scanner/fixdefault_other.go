@@ -0,0 +1,27 @@
+//go:build !windows
+
+package main
+
+import (
+	"path/filepath"
+	"runtime"
+)
+
+// fix points update-alternatives and JAVA_HOME in /etc/environment at the runtime
+// on Linux. The default of macOS cannot be changed and is reported as manual.
+func (f *defaultFixer) fix(home string) []DefaultChange {
+	_, current := osDefaultJavaHome()
+	if current != "" {
+		current = runtimeHome(filepath.Join(current, "bin", javaExecutableName()))
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return []DefaultChange{f.fixAlternatives(home, current), f.fixEnvironmentFile(home)}
+	case "darwin":
+		return []DefaultChange{
+			f.manual(MechanismJavaHome, current, home, "java_home selects the highest installed version, JAVA_HOME overrides it"),
+			f.manual("JAVA_HOME", "", home, "set JAVA_HOME in the shell profiles or with launchctl setenv"),
+		}
+	}
+	return []DefaultChange{f.manual("JAVA_HOME", "", home, "set JAVA_HOME in the system environment")}
+}
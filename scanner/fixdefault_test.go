@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUpdateEnvironmentFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "environment")
+	writeTestFile(t, path, "PATH=\"/usr/local/bin:/usr/bin\"\nJAVA_HOME=/opt/old\nLANG=C\nexport JAVA_HOME='/opt/older'\n")
+
+	if value, ok := readEnvironmentFile(path, "JAVA_HOME"); !ok || value != "/opt/older" {
+		t.Errorf("Expected the last assignment, got %q", value)
+	}
+	if err := updateEnvironmentFile(path, "JAVA_HOME", "/usr/lib/jvm/temurin-17"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	want := "PATH=\"/usr/local/bin:/usr/bin\"\nJAVA_HOME=\"/usr/lib/jvm/temurin-17\"\nLANG=C\n"
+	if string(data) != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, data)
+	}
+
+	missing := filepath.Join(t.TempDir(), "environment")
+	if err := updateEnvironmentFile(missing, "JAVA_HOME", "/opt/jdk"); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := readEnvironmentFile(missing, "JAVA_HOME"); !ok || value != "/opt/jdk" {
+		t.Errorf("Expected the variable in a new file, got %q", value)
+	}
+}
+
+func TestFixAlternatives(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("update-alternatives only exists on Linux")
+	}
+	dir := t.TempDir()
+	home := filepath.Join(dir, "temurin-17")
+	createFakeJava(t, home)
+	other := filepath.Join(dir, "oracle-8")
+	createFakeJava(t, other)
+
+	var calls []string
+	listed := ""
+	fixer := &defaultFixer{runCommand: func(name string, args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "--list" {
+			return []byte(listed), nil
+		}
+		return nil, nil
+	}}
+
+	// A registered runtime is selected
+	listed = filepath.Join(other, "bin", "java") + "\n" + filepath.Join(home, "bin", "java") + "\n"
+	change := fixer.fixAlternatives(home, other)
+	if change.Result != StepDone || !slices.Contains(calls, "--set java "+filepath.Join(home, "bin", "java")) {
+		t.Errorf("Expected the alternative to be set, got %+v after %v", change, calls)
+	}
+	if slices.ContainsFunc(calls, func(call string) bool { return strings.HasPrefix(call, "--install") }) {
+		t.Errorf("Expected no registration of a registered runtime, got %v", calls)
+	}
+
+	// Any other runtime is registered first
+	calls, listed = nil, filepath.Join(other, "bin", "java")
+	if change := fixer.fixAlternatives(home, other); change.Result != StepDone || len(calls) != 3 ||
+		calls[1] != "--install /usr/bin/java java "+filepath.Join(home, "bin", "java")+" 1" {
+		t.Errorf("Expected the runtime to be registered and set, got %+v after %v", change, calls)
+	}
+
+	if change := fixer.fixAlternatives(home, home); change.Result != StepUnchanged {
+		t.Errorf("Expected no change of the selected runtime, got %+v", change)
+	}
+
+	fixer.dryRun = true
+	calls = nil
+	if change := fixer.fixAlternatives(home, other); change.Result != StepPlanned || len(calls) != 1 {
+		t.Errorf("Expected a dry run to only list the alternatives, got %+v after %v", change, calls)
+	}
+
+	fixer.dryRun = false
+	fixer.runCommand = func(name string, args ...string) ([]byte, error) {
+		if args[0] == "--list" {
+			return nil, nil
+		}
+		return []byte("permission denied"), errors.New("exit status 2")
+	}
+	if change := fixer.fixAlternatives(home, other); change.Result != StepFailed || !strings.Contains(change.Error, "permission denied") {
+		t.Errorf("Expected the failure to be reported, got %+v", change)
+	}
+}
+
+func TestFixEnvironmentFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "environment")
+	writeTestFile(t, path, "JAVA_HOME=/opt/old\n")
+	fixer := &defaultFixer{environmentFile: path, dryRun: true}
+
+	if change := fixer.fixEnvironmentFile("/opt/new"); change.Result != StepPlanned || change.From != "/opt/old" {
+		t.Errorf("Expected a planned change, got %+v", change)
+	}
+	if value, _ := readEnvironmentFile(path, "JAVA_HOME"); value != "/opt/old" {
+		t.Errorf("Expected a dry run to leave the file alone, got %q", value)
+	}
+	fixer.dryRun = false
+	if change := fixer.fixEnvironmentFile("/opt/new"); change.Result != StepDone {
+		t.Errorf("Expected the change to be made, got %+v", change)
+	}
+	if change := fixer.fixEnvironmentFile("/opt/new"); change.Result != StepUnchanged {
+		t.Errorf("Expected no second change, got %+v", change)
+	}
+}
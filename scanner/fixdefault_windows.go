@@ -0,0 +1,121 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32            = syscall.NewLazyDLL("advapi32.dll")
+	procRegCreateKeyExW = advapi32.NewProc("RegCreateKeyExW")
+	procRegSetValueExW  = advapi32.NewProc("RegSetValueExW")
+
+	user32                  = syscall.NewLazyDLL("user32.dll")
+	procSendMessageTimeoutW = user32.NewProc("SendMessageTimeoutW")
+)
+
+// systemEnvironmentKey holds the system environment variables below HKEY_LOCAL_MACHINE
+const systemEnvironmentKey = `SYSTEM\CurrentControlSet\Control\Session Manager\Environment`
+
+// fix registers the runtime as the CurrentVersion below HKLM\SOFTWARE\JavaSoft
+// and sets JAVA_HOME in the system environment
+func (f *defaultFixer) fix(home string) []DefaultChange {
+	_, current := osDefaultJavaHome()
+	changes := []DefaultChange{f.change(MechanismRegistry, current, home, func() error { return registerJavaSoft(home) })}
+
+	javaHome, _ := readRegistryString(systemEnvironmentKey, "JAVA_HOME")
+	changes = append(changes, f.change("JAVA_HOME", javaHome, home, func() error {
+		if err := writeRegistryString(systemEnvironmentKey, "JAVA_HOME", home); err != nil {
+			return err
+		}
+		broadcastEnvironmentChange()
+		return nil
+	}))
+	return changes
+}
+
+// registerJavaSoft makes the runtime the CurrentVersion of the first JavaSoft key
+// in use, or of the JDK or JRE key if none is
+func registerJavaSoft(home string) error {
+	release, err := readReleaseFile(osSystem.FS, home)
+	if err != nil {
+		return fmt.Errorf("failed to read the version of %s: %v", home, err)
+	}
+	major, _ := parseJavaVersion(release["JAVA_VERSION"])
+	if major == 0 {
+		return fmt.Errorf("unknown version of %s", home)
+	}
+	version := strconv.Itoa(major)
+	if major <= 8 {
+		version = "1." + version
+	}
+
+	key := ""
+	for _, candidate := range javaSoftKeys {
+		if _, ok := readRegistryString(candidate, "CurrentVersion"); ok {
+			key = candidate
+			break
+		}
+	}
+	if key == "" {
+		key = javaSoftKeys[0]
+		if _, err := os.Stat(filepath.Join(home, "bin", "javac.exe")); err == nil {
+			key = `SOFTWARE\JavaSoft\JDK`
+		}
+	}
+	if err := writeRegistryString(key+`\`+version, "JavaHome", home); err != nil {
+		return err
+	}
+	return writeRegistryString(key, "CurrentVersion", version)
+}
+
+// writeRegistryString creates a key below HKEY_LOCAL_MACHINE if needed and sets a string value
+func writeRegistryString(key, name, value string) error {
+	keyPtr, err := syscall.UTF16PtrFromString(key)
+	if err != nil {
+		return err
+	}
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	data, err := syscall.UTF16FromString(value)
+	if err != nil {
+		return err
+	}
+
+	var handle syscall.Handle
+	r, _, _ := procRegCreateKeyExW.Call(uintptr(syscall.HKEY_LOCAL_MACHINE), uintptr(unsafe.Pointer(keyPtr)), 0, 0, 0,
+		uintptr(syscall.KEY_WRITE), 0, uintptr(unsafe.Pointer(&handle)), 0)
+	if r != 0 {
+		return fmt.Errorf("failed to open HKLM\\%s: %v", key, syscall.Errno(r))
+	}
+	defer syscall.RegCloseKey(handle)
+
+	r, _, _ = procRegSetValueExW.Call(uintptr(handle), uintptr(unsafe.Pointer(namePtr)), 0, uintptr(syscall.REG_SZ),
+		uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)*2))
+	if r != 0 {
+		return fmt.Errorf("failed to set HKLM\\%s\\%s: %v", key, name, syscall.Errno(r))
+	}
+	return nil
+}
+
+// broadcastEnvironmentChange tells running programs such as Explorer to reload the environment
+func broadcastEnvironmentChange() {
+	const (
+		hwndBroadcast    = 0xffff
+		wmSettingChange  = 0x001A
+		smtoAbortIfHung  = 0x0002
+		broadcastTimeout = 5000
+	)
+	environment, _ := syscall.UTF16PtrFromString("Environment")
+	var result uintptr
+	procSendMessageTimeoutW.Call(hwndBroadcast, wmSettingChange, 0, uintptr(unsafe.Pointer(environment)),
+		smtoAbortIfHung, broadcastTimeout, uintptr(unsafe.Pointer(&result)))
+}
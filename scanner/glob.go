@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// expandPattern resolves a leading "~" and environment variables in a path pattern
+// and normalizes it to forward slashes
+func expandPattern(pattern string) string {
+	if pattern == "~" || strings.HasPrefix(pattern, "~/") || strings.HasPrefix(pattern, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			pattern = home + pattern[1:]
+		}
+	}
+	pattern = os.ExpandEnv(pattern)
+	return filepath.ToSlash(pattern)
+}
+
+// matchGlob reports whether a path matches a glob pattern. Both are expected to use
+// forward slashes. In addition to the path.Match syntax, a "**" segment matches any
+// number of path segments (including none). Matching is case-insensitive on Windows.
+func matchGlob(pattern, name string) bool {
+	if runtime.GOOS == "windows" {
+		pattern = strings.ToLower(pattern)
+		name = strings.ToLower(name)
+	}
+	return matchSegments(splitSegments(pattern), splitSegments(name))
+}
+
+// splitSegments splits a slash separated path into its segments
+func splitSegments(p string) []string {
+	return strings.Split(strings.TrimSuffix(p, "/"), "/")
+}
+
+// matchSegments matches pattern segments against path segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse consecutive "**" and try every possible split
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
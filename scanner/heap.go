@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Container support of a HotSpot runtime, from the UseContainerSupport flag
+const (
+	ContainerSupportEnabled     = "enabled"
+	ContainerSupportDisabled    = "disabled"    // the flag exists but is switched off, e.g. in JAVA_TOOL_OPTIONS
+	ContainerSupportUnavailable = "unavailable" // before 8u191 and Java 10, the heap is sized from the host memory
+)
+
+// parseFlagsFinal parses the output of -XX:+PrintFlagsFinal into flag values, e.g.
+//
+//	size_t MaxHeapSize                              = 4164943872                                {product} {ergonomic}
+//	 uintx MaxHeapSize                              := 4164943872                          {product}
+func parseFlagsFinal(output string) map[string]string {
+	flags := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || (fields[2] != "=" && fields[2] != ":=") {
+			continue
+		}
+		flags[fields[1]] = fields[3]
+	}
+	return flags
+}
+
+// probeHeapFlags reads the default heap sizes and the container support of a
+// HotSpot runtime from -XX:+PrintFlagsFinal. OpenJ9 reports its heap defaults
+// with its own probe and has no such flags.
+func (f *JavaFinder) probeHeapFlags(ctx context.Context, result *JavaResult) {
+	if result.Status != ProbeOK || result.Properties == nil || result.Properties.isOpenJ9() {
+		return
+	}
+	output, err := f.runJava(ctx, result.Path, "-XX:+PrintFlagsFinal", "-version")
+	if err != nil {
+		return
+	}
+	applyHeapFlags(result.Properties, parseFlagsFinal(output), runtime.GOOS)
+}
+
+// applyHeapFlags sets the heap defaults and container support of the flags.
+// UseContainerSupport only exists on Linux, so its absence means no support only there.
+func applyHeapFlags(props *JavaProperties, flags map[string]string, goos string) {
+	if size, err := strconv.ParseInt(flags["InitialHeapSize"], 10, 64); err == nil {
+		props.InitialHeap = size
+	}
+	if size, err := strconv.ParseInt(flags["MaxHeapSize"], 10, 64); err == nil {
+		props.MaxHeap = size
+	}
+	switch value, ok := flags["UseContainerSupport"]; {
+	case ok && value == "true":
+		props.ContainerSupport = ContainerSupportEnabled
+	case ok:
+		props.ContainerSupport = ContainerSupportDisabled
+	case goos == "linux" && len(flags) > 0:
+		props.ContainerSupport = ContainerSupportUnavailable
+	}
+}
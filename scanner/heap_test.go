@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestApplyHeapFlags(t *testing.T) {
+	jdk17 := `[Global flags]
+      int ActiveProcessorCount                     = -1                                        {product} {default}
+   size_t InitialHeapSize                          = 130023424                                 {product} {ergonomic}
+   size_t MaxHeapSize                              = 2061500416                                {product} {ergonomic}
+     bool UseContainerSupport                      = true                                      {product} {default}
+openjdk version "17.0.9" 2023-10-17
+`
+	props := &JavaProperties{}
+	applyHeapFlags(props, parseFlagsFinal(jdk17), "linux")
+	if props.InitialHeap != 130023424 || props.MaxHeap != 2061500416 || props.ContainerSupport != ContainerSupportEnabled {
+		t.Errorf("Unexpected properties of Java 17: %+v", props)
+	}
+
+	// 8u181 predates container support, the heap follows the host memory
+	jdk8 := `[Global flags]
+    uintx InitialHeapSize                          := 264241152                           {product}
+    uintx MaxHeapSize                              := 4206886912                          {product}
+     bool UseCGroupMemoryLimitForHeap               = false                               {experimental}
+java version "1.8.0_181"
+`
+	props = &JavaProperties{}
+	applyHeapFlags(props, parseFlagsFinal(jdk8), "linux")
+	if props.MaxHeap != 4206886912 || props.ContainerSupport != ContainerSupportUnavailable {
+		t.Errorf("Unexpected properties of Java 8u181: %+v", props)
+	}
+
+	props = &JavaProperties{}
+	applyHeapFlags(props, parseFlagsFinal(jdk8), "windows")
+	if props.ContainerSupport != "" {
+		t.Errorf("Expected no container support on Windows, got %q", props.ContainerSupport)
+	}
+
+	props = &JavaProperties{}
+	applyHeapFlags(props, map[string]string{"UseContainerSupport": "false"}, "linux")
+	if props.ContainerSupport != ContainerSupportDisabled {
+		t.Errorf("Expected disabled container support, got %q", props.ContainerSupport)
+	}
+	props = &JavaProperties{}
+	applyHeapFlags(props, parseFlagsFinal("Unrecognized VM option 'PrintFlagsFinal'\n"), "linux")
+	if props.ContainerSupport != "" || props.MaxHeap != 0 {
+		t.Errorf("Expected nothing without flags, got %+v", props)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// historyRetention is how long a runtime that is gone stays in the history
+const historyRetention = 90 * 24 * time.Hour
+
+// HistoryEntry is a runtime seen by an earlier scan
+type HistoryEntry struct {
+	JavaExecutable string `json:"java_executable"`
+	JavaVendor     string `json:"java_vendor,omitempty"`
+	JavaVersion    string `json:"java_version,omitempty"`
+	IsOracle       bool   `json:"is_oracle,omitempty"`
+	FirstSeen      string `json:"first_seen"`
+	LastSeen       string `json:"last_seen"`
+	Present        bool   `json:"present"`
+}
+
+// runtimeHistory remembers the runtimes of all scans in a local file, so that
+// runtimes installed and removed again between two reports are not lost
+type runtimeHistory struct {
+	path    string
+	entries map[string]*HistoryEntry
+}
+
+// loadHistory reads a history file; a missing file starts an empty history
+func loadHistory(path string) (*runtimeHistory, error) {
+	h := &runtimeHistory{path: path, entries: make(map[string]*HistoryEntry)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history %s: %v", path, err)
+	}
+	var entries []*HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse history %s: %v", path, err)
+	}
+	for _, entry := range entries {
+		h.entries[entry.JavaExecutable] = entry
+	}
+	return h, nil
+}
+
+// record updates the history with the results of a scan of the roots. Runtimes
+// outside of the roots or the shard were not looked for and keep their state.
+func (h *runtimeHistory) record(results []*JavaResult, roots []string, s *shard, now time.Time) {
+	ts := now.UTC().Format(time.RFC3339)
+	found := make(map[string]bool)
+	for _, result := range results {
+		found[result.Path] = true
+		entry, ok := h.entries[result.Path]
+		if !ok {
+			entry = &HistoryEntry{JavaExecutable: result.Path, FirstSeen: ts}
+			h.entries[result.Path] = entry
+		}
+		entry.LastSeen = ts
+		entry.Present = true
+		if version, vendor := runtimeVersion(result); version != "" {
+			entry.JavaVersion, entry.JavaVendor = version, vendor
+			entry.IsOracle = strings.Contains(vendor, "Oracle")
+		}
+	}
+
+	for path, entry := range h.entries {
+		scanned := slices.ContainsFunc(roots, func(root string) bool {
+			return withinRoot(root, path) && s.ownsPath(root, path)
+		})
+		if found[path] || !scanned {
+			continue
+		}
+		entry.Present = false
+		if lastSeen, err := time.Parse(time.RFC3339, entry.LastSeen); err == nil && now.Sub(lastSeen) > historyRetention {
+			delete(h.entries, path)
+		}
+	}
+}
+
+// save writes the history, replacing the file atomically
+func (h *runtimeHistory) save() error {
+	entries := make([]*HistoryEntry, 0, len(h.entries))
+	for _, entry := range h.entries {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b *HistoryEntry) int { return strings.Compare(a.JavaExecutable, b.JavaExecutable) })
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write history: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write history: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write history: %v", err)
+	}
+	return os.Rename(tmp.Name(), h.path)
+}
+
+// departed returns the runtimes that were seen before but are gone now
+func (h *runtimeHistory) departed() []HistoryEntry {
+	if h == nil {
+		return nil
+	}
+	var gone []HistoryEntry
+	for _, entry := range h.entries {
+		if !entry.Present {
+			gone = append(gone, *entry)
+		}
+	}
+	slices.SortFunc(gone, func(a, b HistoryEntry) int { return strings.Compare(a.JavaExecutable, b.JavaExecutable) })
+	return gone
+}
+
+// apply adds first_seen and last_seen to the runtimes of a report and lists
+// the runtimes that have disappeared
+func (h *runtimeHistory) apply(output *JSONOutput) {
+	if h == nil {
+		return
+	}
+	for i := range output.Runtimes {
+		if entry, ok := h.entries[output.Runtimes[i].JavaExecutable]; ok {
+			output.Runtimes[i].FirstSeen = entry.FirstSeen
+			output.Runtimes[i].LastSeen = entry.LastSeen
+		}
+	}
+	output.Departed = h.departed()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRuntimeHistory(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	path := filepath.Join(dir, "history.json")
+	oracle := &JavaResult{
+		Path:    filepath.Join(root, "tmp", "jdk1.8.0_202", "bin", "java"),
+		Release: map[string]string{"JAVA_VERSION": "1.8.0_202", "IMPLEMENTOR": "Oracle Corporation"},
+	}
+	temurin := &JavaResult{Path: filepath.Join(root, "opt", "jdk-21", "bin", "java")}
+	outside := &JavaResult{Path: filepath.Join(dir, "other", "jdk-17", "bin", "java")}
+
+	history, err := loadHistory(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	monday := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	history.record([]*JavaResult{oracle, temurin}, []string{root}, nil, monday)
+	history.record([]*JavaResult{outside}, []string{filepath.Join(dir, "other")}, nil, monday)
+	if err := history.save(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The Oracle JDK was extracted and deleted again before the next scan
+	history, err = loadHistory(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tuesday := monday.Add(24 * time.Hour)
+	history.record([]*JavaResult{temurin}, []string{root}, nil, tuesday)
+
+	output := JSONOutput{Runtimes: []JavaRuntimeJSON{{JavaExecutable: temurin.Path}}}
+	history.apply(&output)
+	if rt := output.Runtimes[0]; rt.FirstSeen != "2025-03-03T09:00:00Z" || rt.LastSeen != "2025-03-04T09:00:00Z" {
+		t.Errorf("Unexpected first/last seen %s %s", rt.FirstSeen, rt.LastSeen)
+	}
+	if len(output.Departed) != 1 {
+		t.Fatalf("Expected only the Oracle JDK to be departed, got %+v", output.Departed)
+	}
+	if gone := output.Departed[0]; gone.JavaExecutable != oracle.Path || !gone.IsOracle || gone.LastSeen != "2025-03-03T09:00:00Z" {
+		t.Errorf("Unexpected departed entry %+v", gone)
+	}
+
+	// Departed runtimes are forgotten after the retention period
+	history.record([]*JavaResult{temurin}, []string{root}, nil, monday.Add(historyRetention+time.Hour))
+	if gone := history.departed(); len(gone) != 0 {
+		t.Errorf("Expected the Oracle JDK to be forgotten, got %+v", gone)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// catalog maps the English format strings of the text output to a translation.
+// Translations may reorder arguments with explicit indexes such as %[2]s.
+type catalog map[string]string
+
+// catalogs holds the translations of the human-readable output. JSON, CI
+// annotations and log messages on stderr are never translated.
+var catalogs = map[string]catalog{
+	"de": {
+		"Java executable: %s\n":                              "Java-Programm: %s\n",
+		"Path class: %s\n":                                   "Pfadklasse: %s\n",
+		"Provisioned by: %s\n":                               "Bereitgestellt durch: %s\n",
+		"Warning: 32-bit runtime (%s) on 64-bit host\n":      "Warnung: 32-Bit-Laufzeitumgebung (%s) auf 64-Bit-System\n",
+		"Dependency issue: %s\n":                             "Abhängigkeitsproblem: %s\n",
+		"Modules: %d\n":                                      "Module: %d\n",
+		"Compatibility warning: %s\n":                        "Kompatibilitätswarnung: %s\n",
+		"Enrichment %s: %s\n":                                "Anreicherung %s: %s\n",
+		"JVM crashed during evaluation\n":                    "JVM ist bei der Auswertung abgestürzt\n",
+		"Failed to execute: %v\n":                            "Ausführung fehlgeschlagen: %v\n",
+		"Exit code: %d\n":                                    "Exitcode: %d\n",
+		"Java version: %s\n":                                 "Java-Version: %s\n",
+		"Java vendor: %s\n":                                  "Java-Hersteller: %s\n",
+		"Java runtime name: %s\n":                            "Name der Java-Laufzeitumgebung: %s\n",
+		"Java major version: %d\n":                           "Java-Hauptversion: %d\n",
+		"Java update version: %d\n":                          "Java-Updateversion: %d\n",
+		"Patch level: CPU of %s, %d CPUs behind\n":           "Patch-Stand: CPU vom %s, %d CPUs im Rückstand\n",
+		"Untrusted location, not executed\n":                 "Nicht vertrauenswürdiger Ort, nicht ausgeführt\n",
+		"Java VM: %s %s\n":                                   "Java-VM: %s %s\n",
+		"Default heap: %d bytes initial, %d bytes maximum\n": "Standard-Heap: %d Bytes initial, %d Bytes maximal\n",
+		"Architecture mismatch: %v\n":                        "Architektur passt nicht: %v\n",
+		"Confidence: %d (%s)\n":                              "Konfidenz: %d (%s)\n",
+		"Embedded in: %s (%s)\n":                             "Eingebettet in: %s (%s)\n",
+		"Reached via symbolic link\n":                        "Über symbolischen Link gefunden\n",
+		"Alias: %s\n":                                        "Alias: %s\n",
+		"Same file at: %s\n":                                 "Dieselbe Datei unter: %s\n",
+		"Packaged in: %s\n":                                  "Gepackt in: %s\n",
+		"Running %s daemon (pid %d): %s\n":                   "Laufender %s-Daemon (PID %d): %s\n",
+		"Probe stderr: %d bytes, SHA-256 %s\n":               "Probe-stderr: %d Bytes, SHA-256 %s\n",
+		"Warning: Oracle JDK detected\n":                     "Warnung: Oracle JDK gefunden\n",
+		"Acknowledged: Oracle JDK detected (%s)\n":           "Bestätigt: Oracle JDK gefunden (%s)\n",
+		"Runtime no longer present: %s (last seen %s)\n":     "Laufzeitumgebung nicht mehr vorhanden: %s (zuletzt gesehen %s)\n",
+		"Warning: inconsistent default runtime: %s\n":        "Warnung: uneinheitliche Standard-Laufzeitumgebung: %s\n",
+		"Warning: %s injects %s into every JVM\n":            "Warnung: %s fügt %s in jede JVM ein\n",
+		"Warning: vulnerable library %s %s in %s: %s\n":      "Warnung: verwundbare Bibliothek %s %s in %s: %s\n",
+		"Mitigated library %s %s in %s: %s\n":                "Entschärfte Bibliothek %s %s in %s: %s\n",
+		"Java agent %s attached to every JVM by %s\n":        "Java-Agent %s wird jeder JVM hinzugefügt von %s\n",
+		"Java agent %s attached to %s by %s\n":               "Java-Agent %s wird %s hinzugefügt von %s\n",
+		"Java agent %s configured in %s\n":                   "Java-Agent %s konfiguriert in %s\n",
+		"Warning: Java agent %s does not exist\n":            "Warnung: Java-Agent %s existiert nicht\n",
+		"Recommended replacement: %s %s (%s)\n":              "Empfohlener Ersatz: %s %s (%s)\n",
+		"Latest available: %s %s, %d updates behind\n":       "Neueste verfügbare Version: %s %s, %d Updates zurück\n",
+		"Estimated Oracle Java SE subscription: %s %s per year for %d runtimes requiring a license (%s metric, %s units at %s per month)\n": "Geschätztes Oracle Java SE Abonnement: %s %s pro Jahr für %d lizenzpflichtige Laufzeitumgebungen (Metrik %s, %s Einheiten zu %s pro Monat)\n",
+		"Note: the employee metric covers the whole organization, count it once and not per host\n":                                         "Hinweis: die Mitarbeitermetrik umfasst die ganze Organisation, sie wird einmal und nicht pro Host gezählt\n",
+		"Warning: %d update levels of %s %d installed: %s\n":                                                                                "Warnung: %d Update-Stände von %s %d installiert: %s\n",
+
+		"Container support: %s\n": "Container-Unterstützung: %s\n",
+		"Warning: %s is not container-aware and sizes its heap from the host memory\n": "Warnung: %s erkennt keine Container und bemisst seinen Heap nach dem Speicher des Hosts\n",
+		"Startup: %s ms median of %d runs (min %s ms, max %s ms)\n":                    "Startzeit: %s ms Median von %d Läufen (min. %s ms, max. %s ms)\n",
+		"Startup benchmark failed after %d runs: %s\n":                                 "Startzeitmessung nach %d Läufen fehlgeschlagen: %s\n",
+		"Applications in %s require Java %d (%d classes in %d archives sampled)\n":     "Anwendungen in %s benötigen Java %d (%d Klassen in %d Archiven geprüft)\n",
+		"Warning: %s is older than Java %d required by its applications\n":             "Warnung: %s ist älter als das von seinen Anwendungen benötigte Java %d\n",
+
+		"Java VM: %s\n": "Java-VM: %s\n",
+	},
+	"fr": {
+		"Java executable: %s\n":                              "Exécutable Java : %s\n",
+		"Path class: %s\n":                                   "Classe de chemin : %s\n",
+		"Provisioned by: %s\n":                               "Fourni par : %s\n",
+		"Warning: 32-bit runtime (%s) on 64-bit host\n":      "Avertissement : environnement d'exécution 32 bits (%s) sur un hôte 64 bits\n",
+		"Dependency issue: %s\n":                             "Problème de dépendance : %s\n",
+		"Modules: %d\n":                                      "Modules : %d\n",
+		"Compatibility warning: %s\n":                        "Avertissement de compatibilité : %s\n",
+		"Enrichment %s: %s\n":                                "Enrichissement %s : %s\n",
+		"JVM crashed during evaluation\n":                    "La JVM a planté pendant l'évaluation\n",
+		"Failed to execute: %v\n":                            "Échec de l'exécution : %v\n",
+		"Exit code: %d\n":                                    "Code de sortie : %d\n",
+		"Java version: %s\n":                                 "Version de Java : %s\n",
+		"Java vendor: %s\n":                                  "Fournisseur de Java : %s\n",
+		"Java runtime name: %s\n":                            "Nom de l'environnement d'exécution Java : %s\n",
+		"Java major version: %d\n":                           "Version majeure de Java : %d\n",
+		"Java update version: %d\n":                          "Version de mise à jour de Java : %d\n",
+		"Patch level: CPU of %s, %d CPUs behind\n":           "Niveau de correctifs : CPU du %s, %d CPU de retard\n",
+		"Untrusted location, not executed\n":                 "Emplacement non fiable, non exécuté\n",
+		"Java VM: %s %s\n":                                   "VM Java : %s %s\n",
+		"Default heap: %d bytes initial, %d bytes maximum\n": "Tas par défaut : %d octets initial, %d octets maximum\n",
+		"Architecture mismatch: %v\n":                        "Architecture incompatible : %v\n",
+		"Confidence: %d (%s)\n":                              "Confiance : %d (%s)\n",
+		"Embedded in: %s (%s)\n":                             "Intégré dans : %s (%s)\n",
+		"Reached via symbolic link\n":                        "Atteint par un lien symbolique\n",
+		"Alias: %s\n":                                        "Alias : %s\n",
+		"Same file at: %s\n":                                 "Même fichier sous : %s\n",
+		"Packaged in: %s\n":                                  "Empaqueté dans : %s\n",
+		"Running %s daemon (pid %d): %s\n":                   "Démon %s en cours d'exécution (PID %d) : %s\n",
+		"Probe stderr: %d bytes, SHA-256 %s\n":               "Sortie d'erreur de la sonde : %d octets, SHA-256 %s\n",
+		"Warning: Oracle JDK detected\n":                     "Avertissement : JDK Oracle détecté\n",
+		"Acknowledged: Oracle JDK detected (%s)\n":           "Accepté : JDK Oracle détecté (%s)\n",
+		"Runtime no longer present: %s (last seen %s)\n":     "Environnement d'exécution disparu : %s (vu pour la dernière fois %s)\n",
+		"Warning: inconsistent default runtime: %s\n":        "Avertissement : environnement d'exécution par défaut incohérent : %s\n",
+		"Warning: %s injects %s into every JVM\n":            "Avertissement : %s injecte %s dans chaque JVM\n",
+		"Warning: vulnerable library %s %s in %s: %s\n":      "Avertissement : bibliothèque vulnérable %s %s dans %s : %s\n",
+		"Mitigated library %s %s in %s: %s\n":                "Bibliothèque atténuée %s %s dans %s : %s\n",
+		"Java agent %s attached to every JVM by %s\n":        "Agent Java %s attaché à chaque JVM par %s\n",
+		"Java agent %s attached to %s by %s\n":               "Agent Java %s attaché à %s par %s\n",
+		"Java agent %s configured in %s\n":                   "Agent Java %s configuré dans %s\n",
+		"Warning: Java agent %s does not exist\n":            "Avertissement : l'agent Java %s n'existe pas\n",
+		"Recommended replacement: %s %s (%s)\n":              "Remplacement recommandé : %s %s (%s)\n",
+		"Latest available: %s %s, %d updates behind\n":       "Dernière version disponible : %s %s, %d mises à jour de retard\n",
+		"Estimated Oracle Java SE subscription: %s %s per year for %d runtimes requiring a license (%s metric, %s units at %s per month)\n": "Abonnement Oracle Java SE estimé : %s %s par an pour %d environnements soumis à licence (métrique %s, %s unités à %s par mois)\n",
+		"Note: the employee metric covers the whole organization, count it once and not per host\n":                                         "Remarque : la métrique par employé couvre toute l'organisation, à compter une fois et non par hôte\n",
+		"Warning: %d update levels of %s %d installed: %s\n":                                                                                "Avertissement : %d niveaux de mise à jour de %s %d installés : %s\n",
+
+		"Container support: %s\n": "Prise en charge des conteneurs : %s\n",
+		"Warning: %s is not container-aware and sizes its heap from the host memory\n": "Avertissement : %s ne prend pas en charge les conteneurs et dimensionne son tas selon la mémoire de l'hôte\n",
+		"Startup: %s ms median of %d runs (min %s ms, max %s ms)\n":                    "Démarrage : %s ms médiane de %d exécutions (min %s ms, max %s ms)\n",
+		"Startup benchmark failed after %d runs: %s\n":                                 "Mesure du démarrage échouée après %d exécutions : %s\n",
+		"Applications in %s require Java %d (%d classes in %d archives sampled)\n":     "Les applications dans %s nécessitent Java %d (%d classes dans %d archives échantillonnées)\n",
+		"Warning: %s is older than Java %d required by its applications\n":             "Avertissement : %s est antérieur à Java %d requis par ses applications\n",
+
+		"Java VM: %s\n": "VM Java : %s\n",
+	},
+	"ja": {
+		"Java executable: %s\n":                              "Java実行ファイル: %s\n",
+		"Path class: %s\n":                                   "パス分類: %s\n",
+		"Provisioned by: %s\n":                               "プロビジョニング元: %s\n",
+		"Warning: 32-bit runtime (%s) on 64-bit host\n":      "警告: 64ビットホスト上の32ビットランタイム (%s)\n",
+		"Dependency issue: %s\n":                             "依存関係の問題: %s\n",
+		"Modules: %d\n":                                      "モジュール数: %d\n",
+		"Compatibility warning: %s\n":                        "互換性の警告: %s\n",
+		"Enrichment %s: %s\n":                                "付加情報 %s: %s\n",
+		"JVM crashed during evaluation\n":                    "評価中にJVMがクラッシュしました\n",
+		"Failed to execute: %v\n":                            "実行に失敗しました: %v\n",
+		"Exit code: %d\n":                                    "終了コード: %d\n",
+		"Java version: %s\n":                                 "Javaバージョン: %s\n",
+		"Java vendor: %s\n":                                  "Javaベンダー: %s\n",
+		"Java runtime name: %s\n":                            "Javaランタイム名: %s\n",
+		"Java major version: %d\n":                           "Javaメジャーバージョン: %d\n",
+		"Java update version: %d\n":                          "Javaアップデートバージョン: %d\n",
+		"Patch level: CPU of %s, %d CPUs behind\n":           "パッチレベル: %s のCPU、%d 回分のCPUが未適用\n",
+		"Untrusted location, not executed\n":                 "信頼されていない場所のため実行しません\n",
+		"Java VM: %s %s\n":                                   "Java VM: %s %s\n",
+		"Default heap: %d bytes initial, %d bytes maximum\n": "デフォルトヒープ: 初期 %d バイト, 最大 %d バイト\n",
+		"Architecture mismatch: %v\n":                        "アーキテクチャの不一致: %v\n",
+		"Confidence: %d (%s)\n":                              "信頼度: %d (%s)\n",
+		"Embedded in: %s (%s)\n":                             "組み込み先: %s (%s)\n",
+		"Reached via symbolic link\n":                        "シンボリックリンク経由で検出\n",
+		"Alias: %s\n":                                        "エイリアス: %s\n",
+		"Same file at: %s\n":                                 "同一ファイル: %s\n",
+		"Packaged in: %s\n":                                  "アーカイブ内: %s\n",
+		"Running %s daemon (pid %d): %s\n":                   "実行中の%sデーモン (PID %d): %s\n",
+		"Probe stderr: %d bytes, SHA-256 %s\n":               "プローブの標準エラー出力: %d バイト, SHA-256 %s\n",
+		"Warning: Oracle JDK detected\n":                     "警告: Oracle JDKが検出されました\n",
+		"Acknowledged: Oracle JDK detected (%s)\n":           "承認済み: Oracle JDKが検出されました (%s)\n",
+		"Runtime no longer present: %s (last seen %s)\n":     "存在しなくなったランタイム: %s (最終確認 %s)\n",
+		"Warning: inconsistent default runtime: %s\n":        "警告: 既定のランタイムが一致しません: %s\n",
+		"Warning: %s injects %s into every JVM\n":            "警告: %sがすべてのJVMに%sを注入します\n",
+		"Warning: vulnerable library %s %s in %s: %s\n":      "警告: 脆弱なライブラリ %s %s（%s）: %s\n",
+		"Mitigated library %s %s in %s: %s\n":                "緩和済みのライブラリ %s %s（%s）: %s\n",
+		"Java agent %s attached to every JVM by %s\n":        "Javaエージェント%sはすべてのJVMに適用されます（設定元: %s）\n",
+		"Java agent %s attached to %s by %s\n":               "Javaエージェント%sは%sに適用されます（設定元: %s）\n",
+		"Java agent %s configured in %s\n":                   "Javaエージェント%sの設定: %s\n",
+		"Warning: Java agent %s does not exist\n":            "警告: Javaエージェント%sは存在しません\n",
+		"Recommended replacement: %s %s (%s)\n":              "推奨される置き換え: %s %s (%s)\n",
+		"Latest available: %s %s, %d updates behind\n":       "入手可能な最新版: %s %s（%d 回の更新遅れ）\n",
+		"Estimated Oracle Java SE subscription: %s %s per year for %d runtimes requiring a license (%s metric, %s units at %s per month)\n": "Oracle Java SEサブスクリプションの見積もり: 年間%s %s、ライセンスが必要なランタイム%d個（%sメトリック、%s単位、月額%s）\n",
+		"Note: the employee metric covers the whole organization, count it once and not per host\n":                                         "注意: 従業員メトリックは組織全体が対象です。ホストごとではなく一度だけ数えてください\n",
+		"Warning: %d update levels of %s %d installed: %s\n":                                                                                "警告: %[2]s %[3]d の%[1]d種類のアップデートレベルがインストールされています: %[4]s\n",
+
+		"Container support: %s\n": "コンテナサポート: %s\n",
+		"Warning: %s is not container-aware and sizes its heap from the host memory\n": "警告: %sはコンテナを認識せず、ホストのメモリからヒープサイズを決定します\n",
+		"Startup: %s ms median of %d runs (min %s ms, max %s ms)\n":                    "起動時間: %s ms（%d回の中央値、最小 %s ms、最大 %s ms）\n",
+		"Startup benchmark failed after %d runs: %s\n":                                 "起動時間の計測が%d回目の後に失敗しました: %s\n",
+		"Applications in %s require Java %d (%d classes in %d archives sampled)\n":     "%sのアプリケーションにはJava %dが必要です（%d個のクラス、%d個のアーカイブを調査）\n",
+		"Warning: %s is older than Java %d required by its applications\n":             "警告: %sはアプリケーションに必要なJava %dより古いです\n",
+
+		"Java VM: %s\n": "Java VM: %s\n",
+	},
+}
+
+// messages is the catalog of the selected language, nil for English
+var messages catalog
+
+// languages returns the supported output languages
+func languages() []string {
+	return append([]string{"en"}, slices.Sorted(maps.Keys(catalogs))...)
+}
+
+// setLanguage selects the language of the human-readable output
+func setLanguage(lang string) error {
+	lang = strings.ToLower(lang)
+	if lang == "" || lang == "en" {
+		messages = nil
+		return nil
+	}
+	c, ok := catalogs[lang]
+	if !ok {
+		return fmt.Errorf("unsupported language '%s' (use %s)", lang, strings.Join(languages(), ", "))
+	}
+	messages = c
+	return nil
+}
+
+// tr translates a format string of the text output, falling back to English
+func tr(format string) string {
+	if translated, ok := messages[format]; ok {
+		return translated
+	}
+	return format
+}
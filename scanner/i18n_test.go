@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// formatVerb matches the verbs used in the text output
+var formatVerb = regexp.MustCompile(`%[sdv]`)
+
+func TestCatalogs(t *testing.T) {
+	reference := catalogs["de"]
+	for lang, c := range catalogs {
+		if len(c) != len(reference) {
+			t.Errorf("Catalog %s has %d messages, de has %d", lang, len(c), len(reference))
+		}
+		for format, translated := range c {
+			if _, ok := reference[format]; !ok {
+				t.Errorf("Message %q of catalog %s is missing in de", format, lang)
+			}
+
+			// The translation must consume the same arguments
+			var args []interface{}
+			for _, verb := range formatVerb.FindAllString(format, -1) {
+				if verb == "%d" {
+					args = append(args, 1)
+				} else {
+					args = append(args, "x")
+				}
+			}
+			if out := fmt.Sprintf(translated, args...); strings.Contains(out, "%!") {
+				t.Errorf("Translation %q of catalog %s doesn't match its arguments: %s", translated, lang, out)
+			}
+			if strings.HasSuffix(format, "\n") != strings.HasSuffix(translated, "\n") {
+				t.Errorf("Translation %q of catalog %s must keep the trailing newline", translated, lang)
+			}
+		}
+	}
+}
+
+func TestSetLanguage(t *testing.T) {
+	defer setLanguage("en")
+
+	if err := setLanguage("DE"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := tr("Java version: %s\n"); got != "Java-Version: %s\n" {
+		t.Errorf("Expected German translation, got %q", got)
+	}
+	if got := tr("untranslated\n"); got != "untranslated\n" {
+		t.Errorf("Expected fallback to English, got %q", got)
+	}
+
+	if err := setLanguage("xx"); err == nil {
+		t.Error("Expected error for unsupported language")
+	}
+	if err := setLanguage("en"); err != nil || tr("Java version: %s\n") != "Java version: %s\n" {
+		t.Errorf("Expected English, got %q (%v)", tr("Java version: %s\n"), err)
+	}
+}
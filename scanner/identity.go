@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identity sources, tried after the -hostname flag and the hostname of the configuration
+const (
+	IdentityFlag   = "flag"
+	IdentityConfig = "config"
+	IdentityCloud  = "cloud"
+	IdentityFQDN   = "fqdn"
+	IdentityOS     = "os"
+)
+
+// defaultIdentitySources keeps the names reported by earlier versions, so that
+// the collector does not see every host again under a new name
+var defaultIdentitySources = []string{IdentityOS}
+
+// cloudMetadataURL is the link-local address of the AWS, Azure and GCP instance metadata services
+const cloudMetadataURL = "http://169.254.169.254"
+
+// cloudMetadataTimeout bounds every metadata request, outside a cloud nothing answers
+const cloudMetadataTimeout = time.Second
+
+// maxMetadataResponse limits the responses of the metadata services, which are
+// names and tags
+const maxMetadataResponse = 64 << 10
+
+// identityResolver determines the computer name reported for the host. The
+// first source that yields a name wins; the name is resolved once per process.
+type identityResolver struct {
+	override   string
+	configured string
+	sources    []string
+
+	// goos, cloudURL and the functions are replaced in tests
+	goos       string
+	cloudURL   string
+	hostname   func() (string, error)
+	readFile   func(path string) ([]byte, error)
+	command    func(name string, args ...string) ([]byte, error)
+	lookupFQDN func(host string) (string, error)
+
+	once   sync.Once
+	name   string
+	source string
+}
+
+// hostIdentity resolves the computer name of reports and support bundles
+var hostIdentity = newIdentityResolver("", "", nil)
+
+// newIdentityResolver creates a resolver for the -hostname flag, the hostname of the
+// configuration and a list of sources, nil for the default sources
+func newIdentityResolver(override, configured string, sources []string) *identityResolver {
+	if sources == nil {
+		sources = defaultIdentitySources
+	}
+	return &identityResolver{
+		override:   override,
+		configured: configured,
+		sources:    sources,
+		goos:       runtime.GOOS,
+		cloudURL:   cloudMetadataURL,
+		hostname:   os.Hostname,
+		readFile:   os.ReadFile,
+		command:    func(name string, args ...string) ([]byte, error) { return exec.Command(name, args...).Output() },
+		lookupFQDN: lookupFQDN,
+	}
+}
+
+// parseIdentitySources splits a comma separated list of identity sources
+func parseIdentitySources(list string) ([]string, error) {
+	var sources []string
+	for _, source := range strings.Split(list, ",") {
+		source = strings.TrimSpace(source)
+		switch source {
+		case IdentityCloud, IdentityFQDN, IdentityOS:
+			sources = append(sources, source)
+		default:
+			return nil, fmt.Errorf("unknown identity source %q (use %s, %s or %s)", source, IdentityCloud, IdentityFQDN, IdentityOS)
+		}
+	}
+	return sources, nil
+}
+
+// resolve returns the computer name and the source it came from, "unknown" if no source yields a name
+func (r *identityResolver) resolve() (string, string) {
+	r.once.Do(func() {
+		r.name, r.source = "unknown", ""
+		switch {
+		case r.override != "":
+			r.name, r.source = r.override, IdentityFlag
+			return
+		case r.configured != "":
+			r.name, r.source = r.configured, IdentityConfig
+			return
+		}
+		for _, source := range r.sources {
+			var name string
+			var err error
+			switch source {
+			case IdentityCloud:
+				name, err = r.cloudName()
+			case IdentityFQDN:
+				if name, err = r.hostname(); err == nil {
+					name, err = r.lookupFQDN(name)
+				}
+			case IdentityOS:
+				name, err = r.osName()
+			}
+			if name = strings.TrimSpace(name); err == nil && name != "" {
+				r.name, r.source = name, source
+				return
+			}
+		}
+	})
+	return r.name, r.source
+}
+
+// osName asks the operating system: the computer name set in the sharing
+// preferences on macOS, /etc/hostname on Linux and the host name otherwise
+func (r *identityResolver) osName() (string, error) {
+	switch r.goos {
+	case "darwin":
+		if output, err := r.command("scutil", "--get", "ComputerName"); err == nil && len(strings.TrimSpace(string(output))) > 0 {
+			return string(output), nil
+		}
+	case "linux":
+		if data, err := r.readFile("/etc/hostname"); err == nil && len(strings.TrimSpace(string(data))) > 0 {
+			return string(data), nil
+		}
+	}
+	return r.hostname()
+}
+
+// cloudName queries the instance metadata services of AWS (IMDSv2), GCP and Azure in turn
+func (r *identityResolver) cloudName() (string, error) {
+	get := newMetadataClient(r.cloudURL).get
+
+	// AWS
+	if _, token, err := get(http.MethodPut, "/latest/api/token", map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"}); err == nil {
+		if _, name, err := get(http.MethodGet, "/latest/meta-data/local-hostname", map[string]string{"X-aws-ec2-metadata-token": token}); err == nil {
+			return name, nil
+		}
+	} else if isTimeout(err) {
+		// Nothing listens on the link-local address, so there is no point in asking again
+		return "", err
+	}
+	// GCP answers with its flavor header, which tells it apart from other services
+	if resp, name, err := get(http.MethodGet, "/computeMetadata/v1/instance/name", map[string]string{"Metadata-Flavor": "Google"}); err == nil && resp.Header.Get("Metadata-Flavor") == "Google" {
+		return name, nil
+	}
+	// Azure
+	if _, name, err := get(http.MethodGet, "/metadata/instance/compute/name?api-version=2021-02-01&format=text", map[string]string{"Metadata": "true"}); err == nil {
+		return name, nil
+	}
+	return "", fmt.Errorf("no cloud metadata service found")
+}
+
+// metadataClient requests the instance metadata service of the cloud the host runs in
+type metadataClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func newMetadataClient(baseURL string) *metadataClient {
+	return &metadataClient{
+		baseURL: baseURL,
+		client: &http.Client{
+			Timeout:   cloudMetadataTimeout,
+			Transport: &http.Transport{Proxy: nil},
+		},
+	}
+}
+
+// get requests a path of the metadata service and returns the response and its trimmed body
+func (c *metadataClient) get(method, path string, headers map[string]string) (*http.Response, string, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataResponse))
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("metadata service returned %s", resp.Status)
+	}
+	return resp, strings.TrimSpace(string(body)), nil
+}
+
+// isTimeout checks if err is a network timeout
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// lookupFQDN resolves the fully qualified domain name of host by a reverse
+// lookup of its addresses, as hostname -f does
+func lookupFQDN(host string) (string, error) {
+	if strings.Contains(host, ".") {
+		return host, nil
+	}
+	addrs, err := net.LookupHost(host)
+	if err != nil {
+		return "", err
+	}
+	prefix := strings.ToLower(host) + "."
+	for _, addr := range addrs {
+		names, err := net.LookupAddr(addr)
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			name = strings.TrimSuffix(name, ".")
+			if strings.HasPrefix(strings.ToLower(name), prefix) {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no fully qualified name found for %s", host)
+}
+
+// getComputerName returns the name of the host in reports and support bundles
+func getComputerName() string {
+	name, _ := hostIdentity.resolve()
+	return name
+}
+
+// configureIdentity sets up the resolver of the computer name from the -hostname
+// and -identity flags and the configuration, the flags take precedence
+func configureIdentity(override, sourceList string, cfg *Config) error {
+	sources := cfg.IdentitySources
+	if sourceList != "" {
+		var err error
+		if sources, err = parseIdentitySources(sourceList); err != nil {
+			return err
+		}
+	}
+	hostIdentity = newIdentityResolver(override, cfg.Hostname, sources)
+	return nil
+}
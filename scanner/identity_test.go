@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestIdentityResolver creates a resolver for goos that finds nothing unless told otherwise
+func newTestIdentityResolver(goos string, sources []string) *identityResolver {
+	r := newIdentityResolver("", "", sources)
+	r.goos = goos
+	r.cloudURL = "http://127.0.0.1:1"
+	r.hostname = func() (string, error) { return "", errors.New("no hostname") }
+	r.readFile = func(string) ([]byte, error) { return nil, errors.New("no file") }
+	r.command = func(string, ...string) ([]byte, error) { return nil, errors.New("no command") }
+	r.lookupFQDN = func(string) (string, error) { return "", errors.New("no fqdn") }
+	return r
+}
+
+func TestIdentityOSPerPlatform(t *testing.T) {
+	tests := []struct {
+		goos     string
+		file     string
+		computer string
+		want     string
+	}{
+		{goos: "linux", file: "build-01\n", want: "build-01"},
+		{goos: "linux", file: "\n", want: "host-api"},
+		{goos: "darwin", computer: "Jane's MacBook\n", want: "Jane's MacBook"},
+		{goos: "darwin", want: "host-api"},
+		{goos: "windows", file: "ignored", computer: "ignored", want: "host-api"},
+	}
+	for _, tt := range tests {
+		r := newTestIdentityResolver(tt.goos, nil)
+		r.hostname = func() (string, error) { return "host-api", nil }
+		if tt.file != "" {
+			r.readFile = func(path string) ([]byte, error) {
+				if path != "/etc/hostname" {
+					t.Errorf("Unexpected file %s", path)
+				}
+				return []byte(tt.file), nil
+			}
+		}
+		if tt.computer != "" {
+			r.command = func(name string, args ...string) ([]byte, error) {
+				if name != "scutil" {
+					t.Errorf("Unexpected command %s", name)
+				}
+				return []byte(tt.computer), nil
+			}
+		}
+		if name, source := r.resolve(); name != tt.want || source != IdentityOS {
+			t.Errorf("%s: expected %q from os, got %q from %q", tt.goos, tt.want, name, source)
+		}
+	}
+}
+
+func TestIdentityPrecedence(t *testing.T) {
+	r := newTestIdentityResolver("linux", []string{IdentityFQDN, IdentityOS})
+	r.hostname = func() (string, error) { return "db1", nil }
+	r.lookupFQDN = func(host string) (string, error) { return host + ".example.com", nil }
+	if name, source := r.resolve(); name != "db1.example.com" || source != IdentityFQDN {
+		t.Errorf("Expected the FQDN, got %q from %q", name, source)
+	}
+
+	r = newTestIdentityResolver("linux", []string{IdentityFQDN, IdentityOS})
+	r.hostname = func() (string, error) { return "db1", nil }
+	if name, source := r.resolve(); name != "db1" || source != IdentityOS {
+		t.Errorf("Expected to fall back to the OS, got %q from %q", name, source)
+	}
+
+	r = newTestIdentityResolver("linux", nil)
+	r.configured = "configured"
+	if name, source := r.resolve(); name != "configured" || source != IdentityConfig {
+		t.Errorf("Expected the configured name, got %q from %q", name, source)
+	}
+	r = newTestIdentityResolver("linux", nil)
+	r.override, r.configured = "flag", "configured"
+	if name, source := r.resolve(); name != "flag" || source != IdentityFlag {
+		t.Errorf("Expected the flag to win, got %q from %q", name, source)
+	}
+
+	r = newTestIdentityResolver("linux", nil)
+	if name, source := r.resolve(); name != "unknown" || source != "" {
+		t.Errorf("Expected unknown, got %q from %q", name, source)
+	}
+}
+
+func TestIdentityCloud(t *testing.T) {
+	tests := []struct {
+		cloud   string
+		handler http.HandlerFunc
+	}{
+		{"aws", func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+				w.Write([]byte("token"))
+			case r.URL.Path == "/latest/meta-data/local-hostname" && r.Header.Get("X-aws-ec2-metadata-token") == "token":
+				w.Write([]byte("ip-10-0-0-1.ec2.internal"))
+			default:
+				http.NotFound(w, r)
+			}
+		}},
+		{"gcp", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/computeMetadata/v1/instance/name" && r.Header.Get("Metadata-Flavor") == "Google" {
+				w.Header().Set("Metadata-Flavor", "Google")
+				w.Write([]byte("ip-10-0-0-1.ec2.internal\n"))
+				return
+			}
+			http.NotFound(w, r)
+		}},
+		{"azure", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/metadata/instance/compute/name" && r.Header.Get("Metadata") == "true" {
+				w.Write([]byte("ip-10-0-0-1.ec2.internal"))
+				return
+			}
+			http.NotFound(w, r)
+		}},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(tt.handler)
+		r := newTestIdentityResolver("linux", []string{IdentityCloud, IdentityOS})
+		r.cloudURL = server.URL
+		if name, source := r.resolve(); name != "ip-10-0-0-1.ec2.internal" || source != IdentityCloud {
+			t.Errorf("%s: expected the instance name, got %q from %q", tt.cloud, name, source)
+		}
+		server.Close()
+	}
+}
+
+func TestParseIdentitySources(t *testing.T) {
+	sources, err := parseIdentitySources("cloud, fqdn,os")
+	if err != nil || len(sources) != 3 || sources[0] != IdentityCloud || sources[2] != IdentityOS {
+		t.Errorf("Unexpected sources %v, %v", sources, err)
+	}
+	if _, err := parseIdentitySources("dns"); err == nil {
+		t.Error("Expected an error for an unknown source")
+	}
+}
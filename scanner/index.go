@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// candidateSource lists possible java executables without walking the file system.
+// Every candidate is verified by the finder before it becomes a result.
+type candidateSource interface {
+	Name() string
+	Candidates(ctx context.Context) ([]string, error)
+}
+
+// locateIndex queries the plocate/mlocate database on Linux and other Unix systems
+type locateIndex struct {
+	command string
+}
+
+// Name returns the name of the index
+func (l *locateIndex) Name() string {
+	return "index:" + filepath.Base(l.command)
+}
+
+// Candidates returns all indexed files named java
+func (l *locateIndex) Candidates(ctx context.Context) ([]string, error) {
+	// -b matches the basename only, the regex limits hits to exactly "java"
+	return runIndexQuery(ctx, l.command, "-b", "-r", "^java$")
+}
+
+// everythingIndex queries the voidtools Everything index on Windows via its es.exe CLI
+type everythingIndex struct {
+	command string
+}
+
+// Name returns the name of the index
+func (e *everythingIndex) Name() string {
+	return "index:everything"
+}
+
+// Candidates returns all indexed files named java.exe
+func (e *everythingIndex) Candidates(ctx context.Context) ([]string, error) {
+	return runIndexQuery(ctx, e.command, "-w", "java.exe")
+}
+
+// spotlightIndex queries the Spotlight metadata index on macOS
+type spotlightIndex struct {
+	command string
+	root    string
+}
+
+// Name returns the name of the index
+func (s *spotlightIndex) Name() string {
+	return "index:spotlight"
+}
+
+// Candidates returns all indexed files named java plus the launchers of all
+// indexed JDK bundles, which Spotlight treats as opaque packages
+func (s *spotlightIndex) Candidates(ctx context.Context) ([]string, error) {
+	paths, err := runIndexQuery(ctx, s.command, "-onlyin", s.root, "kMDItemFSName == 'java'")
+	if err != nil {
+		return nil, err
+	}
+
+	bundles, err := runIndexQuery(ctx, s.command, "-onlyin", s.root, "kMDItemFSName == '*.jdk'")
+	if err != nil {
+		return nil, err
+	}
+	for _, bundle := range bundles {
+		paths = append(paths, filepath.Join(bundle, "Contents", "Home", "bin", "java"))
+	}
+	return paths, nil
+}
+
+// pathListSource checks the paths listed by another tool, e.g. from locate or a
+// CMDB, instead of walking (-paths-from)
+type pathListSource struct {
+	paths []string
+}
+
+// Name returns the name of the source
+func (p *pathListSource) Name() string {
+	return "paths-from"
+}
+
+// Candidates returns the listed java executables and the java executables of
+// the listed directories
+func (p *pathListSource) Candidates(ctx context.Context) ([]string, error) {
+	var candidates []string
+	for _, path := range p.paths {
+		candidates = append(candidates, listedCandidates(path)...)
+	}
+	return candidates, nil
+}
+
+// listedCandidates returns the java executables to check for a listed path: a
+// file itself, or the launchers of a runtime home, a bin directory or a macOS
+// JDK bundle. A path that does not exist is returned as is, to be reported.
+func listedCandidates(path string) []string {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return []string{path}
+	}
+	name := javaExecutableName()
+	var candidates []string
+	for _, candidate := range []string{
+		filepath.Join(path, name),
+		filepath.Join(path, "bin", name),
+		filepath.Join(path, "jre", "bin", name),
+		filepath.Join(path, "Contents", "Home", "bin", name),
+	} {
+		if _, err := os.Lstat(candidate); err == nil {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// newPathListSource reads the paths of -paths-from, one per line, from a file or
+// from stdin for -. Empty lines and lines starting with # are skipped, relative
+// paths are relative to the working directory.
+func newPathListSource(file string) (*pathListSource, error) {
+	in := os.Stdin
+	if file != "-" {
+		var err error
+		if in, err = os.Open(file); err != nil {
+			return nil, fmt.Errorf("failed to read paths: %v", err)
+		}
+		defer in.Close()
+	}
+	source := &pathListSource{}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 64*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		path, err := filepath.Abs(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %v", line, err)
+		}
+		source.paths = append(source.paths, path)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read paths: %v", err)
+	}
+	return source, nil
+}
+
+// newIndexSource returns the file name index available on this system
+func newIndexSource(root string) (candidateSource, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if path, err := exec.LookPath("es.exe"); err == nil {
+			return &everythingIndex{command: path}, nil
+		}
+		return nil, fmt.Errorf("Everything command line interface (es.exe) not found in PATH")
+	case "darwin":
+		if path, err := exec.LookPath("mdfind"); err == nil {
+			return &spotlightIndex{command: path, root: root}, nil
+		}
+		return nil, fmt.Errorf("Spotlight command line interface (mdfind) not found")
+	default:
+		for _, name := range []string{"plocate", "locate"} {
+			if path, err := exec.LookPath(name); err == nil {
+				return &locateIndex{command: path}, nil
+			}
+		}
+		return nil, fmt.Errorf("neither plocate nor locate found in PATH")
+	}
+}
+
+// runIndexQuery runs an index query command and returns one path per output line
+func runIndexQuery(ctx context.Context, name string, args ...string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		// locate exits with 1 if nothing was found
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 && stderr.Len() == 0 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s failed: %v %s", filepath.Base(name), err, strings.TrimSpace(stderr.String()))
+	}
+
+	var paths []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, scanner.Err()
+}
+
+// findFromSource verifies the candidates of a source against the file system and
+// the finder settings, emitting every java executable that would have been found
+// by walking the start path
+func (f *JavaFinder) findFromSource(ctx context.Context, source candidateSource, emit func(*JavaResult) error) error {
+	candidates, err := source.Candidates(ctx)
+	if err != nil {
+		return err
+	}
+	if f.verbose {
+		logf("%s returned %d candidates\n", source.Name(), len(candidates))
+	}
+
+	seen := make(map[string]bool)
+	for _, path := range candidates {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		// Listed paths are checked wherever they are
+		depth := 0
+		if _, listed := source.(*pathListSource); !listed {
+			if !withinRoot(f.startPath, path) {
+				f.trace.event(source.Name(), TraceOutsideRoot, path, 0, "")
+				continue
+			}
+			depth = f.getPathDepth(path)
+			if !f.shard.ownsPath(f.startPath, path) {
+				f.trace.event(source.Name(), TraceSkippedShard, path, depth, f.shard.String())
+				continue
+			}
+			if maxDepth := f.maxDepthOf(path); maxDepth >= 0 && depth > maxDepth {
+				f.trace.event(source.Name(), TraceSkippedDepth, path, depth, fmt.Sprintf("max depth %d", maxDepth))
+				continue
+			}
+		}
+
+		// The index may be outdated, so check that the file still exists
+		info, err := os.Stat(path)
+		if err != nil {
+			f.trace.event(source.Name(), TraceStale, path, depth, err.Error())
+			if f.verbose {
+				logf("Skipping stale index entry %s: %v\n", path, err)
+			}
+			continue
+		}
+		if info.IsDir() || !f.matchesName(info.Name()) || !isExecutable(info) {
+			f.trace.event(source.Name(), TraceNotExecutable, path, depth, info.Mode().String())
+			continue
+		}
+		f.trace.event(source.Name(), TraceMatched, path, depth, "")
+		f.progress.match()
+
+		result := f.newResult(ctx, path)
+		if err := ctx.Err(); err != nil {
+			// The probes were cut short, the result would be misleading
+			return err
+		}
+		if err := emit(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
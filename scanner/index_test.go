@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// staticSource is a candidate source returning a fixed list of paths
+type staticSource []string
+
+func (s staticSource) Name() string { return "index:static" }
+
+func (s staticSource) Candidates(ctx context.Context) ([]string, error) { return s, nil }
+
+func TestFindFromSource(t *testing.T) {
+	root := t.TempDir()
+	inside := createFakeJava(t, filepath.Join(root, "jdk-17"))
+	outside := createFakeJava(t, t.TempDir())
+
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.index = staticSource{
+		inside,
+		outside,
+		filepath.Join(root, "deleted", "bin", "java"),
+	}
+
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != inside {
+		t.Fatalf("Expected only %s, got %v", inside, results)
+	}
+	if finder.discovery != "index:static" {
+		t.Errorf("Expected discovery source index:static, got %s", finder.discovery)
+	}
+
+	// The executable is two levels below the root
+	finder.maxDepth = 2
+	if results, _ := finder.Find(); len(results) != 0 {
+		t.Errorf("Expected no results with depth 2, got %d", len(results))
+	}
+}
+
+func TestPathListSource(t *testing.T) {
+	dir := t.TempDir()
+	jdk := filepath.Join(dir, "jdk-21")
+	java := createFakeJava(t, jdk)
+	other := createFakeJava(t, filepath.Join(t.TempDir(), "jre-8"))
+	list := filepath.Join(dir, "paths.txt")
+	content := "# runtimes from the CMDB\n" + jdk + "\n\n" + other + "\r\n" + filepath.Join(dir, "gone") + "\n" + dir + "\n"
+	if err := os.WriteFile(list, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source, err := newPathListSource(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The start path does not limit the listed paths, and nothing is walked
+	finder := NewJavaFinder(filepath.Join(dir, "elsewhere"), 0, false, false)
+	finder.index = source
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, result := range results {
+		paths = append(paths, result.Path)
+	}
+	if want := []string{java, other}; !slices.Equal(paths, want) && !slices.Equal(paths, []string{other, java}) {
+		t.Errorf("Expected %v, got %v", want, paths)
+	}
+	if finder.discovery != "paths-from" || finder.scanned != 0 {
+		t.Errorf("Expected the listed paths to be checked without a walk, got %s with %d directories", finder.discovery, finder.scanned)
+	}
+
+	if _, err := newPathListSource(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("Expected a missing list to be an error")
+	}
+}
+
+func TestSpotlightIndex(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake mdfind is a shell script")
+	}
+	root := t.TempDir()
+	bundle := filepath.Join(root, "Library", "Java", "JavaVirtualMachines", "temurin-21.jdk")
+	bundled := createFakeJava(t, filepath.Join(bundle, "Contents", "Home"))
+	brewed := createFakeJava(t, filepath.Join(root, "opt", "homebrew", "opt", "openjdk"))
+
+	// Spotlight finds the java of the bundle by name as well, it is reported once
+	mdfind := filepath.Join(t.TempDir(), "mdfind")
+	writeTestFile(t, mdfind, `#!/bin/sh
+[ "$1" = -onlyin ] && [ "$2" = "`+root+`" ] || exit 2
+case "$3" in
+*.jdk*) echo "`+bundle+`" ;;
+*) echo "`+brewed+`"; echo "`+bundled+`" ;;
+esac
+`)
+
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.index = &spotlightIndex{command: mdfind, root: root}
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var paths []string
+	for _, result := range results {
+		paths = append(paths, result.Path)
+	}
+	slices.Sort(paths)
+	if want := []string{bundled, brewed}; !slices.Equal(paths, want) {
+		t.Errorf("Expected %v, got %v", want, paths)
+	}
+	if finder.discovery != "index:spotlight" {
+		t.Errorf("Expected discovery source index:spotlight, got %s", finder.discovery)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"jfind/internal/fakejdk"
+)
+
+func TestMain(m *testing.M) {
+	fakejdk.RunStub()
+	os.Exit(m.Run())
+}
+
+func TestEvaluateFakeJDKs(t *testing.T) {
+	root := t.TempDir()
+	temurin := fakejdk.Create(t, filepath.Join(root, "temurin-17"), fakejdk.JDK{
+		Version: "17.0.9",
+		Vendor:  "Eclipse Adoptium",
+	})
+	oracle := fakejdk.Create(t, filepath.Join(root, "jdk1.8.0_401"), fakejdk.JDK{
+		Version:     "1.8.0_401",
+		Vendor:      "Oracle Corporation",
+		RuntimeName: "Java(TM) SE Runtime Environment",
+		Layout:      fakejdk.LayoutJDK8,
+	})
+	crashing := fakejdk.Create(t, filepath.Join(root, "crashing"), fakejdk.JDK{Version: "11.0.2", Crash: true})
+	failing := fakejdk.Create(t, filepath.Join(root, "failing"), fakejdk.JDK{Version: "21.0.1", ExitCode: 1})
+
+	finder := NewJavaFinder(root, -1, false, true)
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	byPath := make(map[string]*JavaResult)
+	for _, result := range results {
+		byPath[result.Path] = result
+	}
+	// The Java 8 JDK has a second launcher in jre/bin
+	if len(results) != 5 {
+		t.Errorf("Expected 5 results, got %d", len(results))
+	}
+
+	if r := byPath[temurin]; r == nil || r.Status != ProbeOK || r.Properties.Major != 17 || r.Properties.Update != 9 {
+		t.Errorf("Expected evaluated Temurin 17.0.9, got %+v", r)
+	}
+	if r := byPath[oracle]; r == nil || r.Properties == nil || r.Properties.Vendor != "Oracle Corporation" || r.Properties.Update != 401 {
+		t.Errorf("Expected evaluated Oracle 1.8.0_401, got %+v", r)
+	}
+	if r := byPath[crashing]; r == nil || r.Status != ProbeCrashed {
+		t.Errorf("Expected crashed probe, got %+v", r)
+	}
+	if r := byPath[failing]; r == nil || r.Status != ProbeFailed || r.ReturnCode != 1 {
+		t.Errorf("Expected failed probe with exit code 1, got %+v", r)
+	}
+
+	output := buildJSONOutput(results, finder, time.Now())
+	if !output.Meta.HasOracleJDK {
+		t.Error("Expected has_oracle_jdk")
+	}
+}
+
+func TestModulesFromFakeJDK(t *testing.T) {
+	root := t.TempDir()
+	jlinked := fakejdk.Create(t, filepath.Join(root, "app-runtime"), fakejdk.JDK{
+		Version: "17.0.9",
+		Vendor:  "Eclipse Adoptium",
+		Modules: []string{"java.base", "java.logging"},
+	})
+
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.checkModules = true
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != jlinked {
+		t.Fatalf("Expected %s, got %v", jlinked, results)
+	}
+	if len(results[0].Modules) != 2 || len(results[0].CompatWarnings) != 2 {
+		t.Errorf("Expected 2 modules and 2 warnings, got %v and %v", results[0].Modules, results[0].CompatWarnings)
+	}
+}
@@ -0,0 +1,212 @@
+// Package fakejdk creates fake Java installations for integration tests.
+//
+// A fake JDK has the directory layout and release file of a real one, and its
+// java launcher is a copy of the running test binary. When the copy is started
+// it prints the canned -XshowSettings:properties output of the fake JDK instead
+// of running the tests. Test packages enable this by calling RunStub first thing
+// in TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		fakejdk.RunStub()
+//		os.Exit(m.Run())
+//	}
+package fakejdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Layout is the directory layout of a fake JDK
+type Layout int
+
+const (
+	// LayoutJDK is a Java 9+ JDK with bin/java below the home directory
+	LayoutJDK Layout = iota
+	// LayoutJDK8 is a Java 8 JDK with launchers in bin and jre/bin
+	LayoutJDK8
+	// LayoutMacBundle is a macOS bundle with the home in Contents/Home
+	LayoutMacBundle
+)
+
+// stubFile holds the canned behavior next to the java launcher of a fake JDK
+const stubFile = "fakejdk.json"
+
+// fatalErrorMarker is printed by HotSpot when the JVM crashes
+const fatalErrorMarker = "A fatal error has been detected by the Java Runtime Environment"
+
+// JDK describes a fake Java installation
+type JDK struct {
+	Version     string   // java.version, e.g. "17.0.9" or "1.8.0_402"
+	Vendor      string   // java.vendor
+	RuntimeName string   // java.runtime.name, defaults to "OpenJDK Runtime Environment"
+	Implementor string   // IMPLEMENTOR of the release file, defaults to Vendor
+	Modules     []string // MODULES of the release file, defaults to java.base and java.se for Java 9+
+	Layout      Layout
+	NoRelease   bool   // don't write a release file
+	ExitCode    int    // exit code of the launcher
+	Stderr      string // replaces the generated -XshowSettings:properties output
+	Crash       bool   // simulate a JVM crash including an hs_err_pid file
+}
+
+// stub is the behavior of a java launcher stored in stubFile
+type stub struct {
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+	Crash    bool   `json:"crash"`
+}
+
+// major returns the major version of the fake JDK
+func (j JDK) major() int {
+	version := strings.TrimPrefix(j.Version, "1.")
+	end := strings.IndexAny(version, "._+-")
+	if end != -1 {
+		version = version[:end]
+	}
+	major, _ := strconv.Atoi(version)
+	return major
+}
+
+// Properties returns the -XshowSettings:properties -version output of the fake JDK
+func (j JDK) Properties() string {
+	if j.Stderr != "" {
+		return j.Stderr
+	}
+	runtimeName := j.RuntimeName
+	if runtimeName == "" {
+		runtimeName = "OpenJDK Runtime Environment"
+	}
+
+	var b strings.Builder
+	b.WriteString("Property settings:\n")
+	fmt.Fprintf(&b, "    java.runtime.name = %s\n", runtimeName)
+	fmt.Fprintf(&b, "    java.vendor = %s\n", j.Vendor)
+	fmt.Fprintf(&b, "    java.version = %s\n", j.Version)
+	fmt.Fprintf(&b, "    os.name = %s\n", runtime.GOOS)
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "openjdk version \"%s\"\n", j.Version)
+	fmt.Fprintf(&b, "%s (build %s)\n", runtimeName, j.Version)
+	return b.String()
+}
+
+// Release returns the content of the release file of the fake JDK
+func (j JDK) Release() string {
+	implementor := j.Implementor
+	if implementor == "" {
+		implementor = j.Vendor
+	}
+	modules := j.Modules
+	if modules == nil && j.major() >= 9 {
+		modules = []string{"java.base", "java.se"}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "IMPLEMENTOR=%q\n", implementor)
+	fmt.Fprintf(&b, "JAVA_VERSION=%q\n", j.Version)
+	if len(modules) > 0 {
+		fmt.Fprintf(&b, "MODULES=%q\n", strings.Join(modules, " "))
+	}
+	return b.String()
+}
+
+// Create creates the fake JDK in dir and returns the path of its main java launcher
+func Create(t testing.TB, dir string, jdk JDK) string {
+	t.Helper()
+
+	home := dir
+	if jdk.Layout == LayoutMacBundle {
+		home = filepath.Join(dir, "Contents", "Home")
+	}
+	binDirs := []string{filepath.Join(home, "bin")}
+	if jdk.Layout == LayoutJDK8 {
+		binDirs = append(binDirs, filepath.Join(home, "jre", "bin"))
+	}
+
+	data, err := json.Marshal(stub{Stderr: jdk.Properties(), ExitCode: jdk.ExitCode, Crash: jdk.Crash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, binDir := range binDirs {
+		if err := os.MkdirAll(binDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := installLauncher(filepath.Join(binDir, launcherName())); err != nil {
+			t.Fatalf("failed to install fake java launcher: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(binDir, stubFile), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !jdk.NoRelease {
+		if err := os.WriteFile(filepath.Join(home, "release"), []byte(jdk.Release()), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(binDirs[0], launcherName())
+}
+
+// launcherName returns the file name of the java launcher on this platform
+func launcherName() string {
+	if runtime.GOOS == "windows" {
+		return "java.exe"
+	}
+	return "java"
+}
+
+// installLauncher copies the running test binary to path. It is not hard
+// linked, since jfind reports hard links of one file as one runtime.
+func installLauncher(path string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(exe)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// RunStub acts as the java launcher of a fake JDK and exits if the test binary
+// was started as one. Otherwise it returns immediately.
+func RunStub() {
+	if filepath.Base(os.Args[0]) != launcherName() {
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(os.Args[0]), stubFile))
+	if err != nil {
+		return
+	}
+	var s stub
+	if err := json.Unmarshal(data, &s); err != nil {
+		fmt.Fprintf(os.Stderr, "fakejdk: invalid %s: %v\n", stubFile, err)
+		os.Exit(1)
+	}
+
+	if s.Crash {
+		// HotSpot writes the crash report into the working directory
+		report := fmt.Sprintf("hs_err_pid%d.log", os.Getpid())
+		os.WriteFile(report, []byte(fatalErrorMarker+"\n"), 0644)
+		fmt.Fprintf(os.Stderr, "#\n# %s:\n#\n", fatalErrorMarker)
+		os.Exit(134)
+	}
+	fmt.Fprint(os.Stderr, s.Stderr)
+	os.Exit(s.ExitCode)
+}
@@ -0,0 +1,64 @@
+package fakejdk
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCreateLayouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		jdk     JDK
+		java    string
+		release string
+	}{
+		{"jdk", JDK{Version: "21.0.1", Vendor: "Eclipse Adoptium"}, "bin", "release"},
+		{"jdk8", JDK{Version: "1.8.0_402", Vendor: "Azul Systems, Inc.", Layout: LayoutJDK8}, "bin", "release"},
+		{"bundle", JDK{Version: "17.0.9", Vendor: "Amazon.com Inc.", Layout: LayoutMacBundle},
+			filepath.Join("Contents", "Home", "bin"), filepath.Join("Contents", "Home", "release")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			java := Create(t, dir, tt.jdk)
+			if want := filepath.Join(dir, tt.java, launcherName()); java != want {
+				t.Errorf("Expected launcher %s, got %s", want, java)
+			}
+			info, err := os.Stat(java)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+				t.Errorf("Expected %s to be executable", java)
+			}
+			release, err := os.ReadFile(filepath.Join(dir, tt.release))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(release), `JAVA_VERSION="`+tt.jdk.Version+`"`) {
+				t.Errorf("Expected JAVA_VERSION in release file, got %s", release)
+			}
+		})
+	}
+
+	dir := t.TempDir()
+	Create(t, dir, JDK{Version: "1.8.0_402", Layout: LayoutJDK8})
+	if _, err := os.Stat(filepath.Join(dir, "jre", "bin", launcherName())); err != nil {
+		t.Errorf("Expected JRE launcher: %v", err)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	jdk8 := JDK{Version: "1.8.0_402", Vendor: "Oracle Corporation"}
+	if release := jdk8.Release(); strings.Contains(release, "MODULES") {
+		t.Errorf("Expected no modules for Java 8, got %s", release)
+	}
+
+	jlinked := JDK{Version: "17.0.9", Vendor: "Eclipse Adoptium", Modules: []string{"java.base"}}
+	if release := jlinked.Release(); !strings.Contains(release, `MODULES="java.base"`) {
+		t.Errorf("Expected java.base only, got %s", release)
+	}
+}
@@ -0,0 +1,550 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxIPCRequestSize limits request frames; requests are tiny commands
+const maxIPCRequestSize = 64 << 10
+
+// IPC commands understood by jfind serve
+const (
+	IPCPing      = "ping"
+	IPCInventory = "inventory"
+	IPCRescan    = "rescan"
+)
+
+// IPCRequest is a request frame of the local IPC protocol. Every frame is a
+// 4-byte big-endian length followed by that many bytes of JSON.
+type IPCRequest struct {
+	Command string `json:"command"`
+	Profile string `json:"profile,omitempty"` // inventory of the latest triggered scan of a profile
+}
+
+// IPCResponse is a response frame of the local IPC protocol
+type IPCResponse struct {
+	Status   string      `json:"status"` // ok or error
+	Error    string      `json:"error,omitempty"`
+	Scanning bool        `json:"scanning"`
+	Deferral *Deferral   `json:"deferral,omitempty"`
+	Report   *JSONOutput `json:"report,omitempty"`
+}
+
+// ipcListener accepts local IPC connections on a Unix socket or Windows named pipe
+type ipcListener interface {
+	Accept() (io.ReadWriteCloser, error)
+	Close() error
+}
+
+// readFrame reads one length-prefixed frame
+func readFrame(r io.Reader, maxSize uint32) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	if length > maxSize {
+		return nil, fmt.Errorf("frame of %d bytes exceeds %d", length, maxSize)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// writeFrame writes one length-prefixed frame
+func writeFrame(w io.Writer, data []byte) error {
+	if err := binary.Write(w, binary.BigEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// inventory holds the report of the latest completed scan, and of the latest
+// triggered scan of each profile that covered only part of the host
+type inventory struct {
+	mu       sync.RWMutex
+	report   *JSONOutput
+	profiles map[string]*JSONOutput
+	scanning bool
+	deferral *Deferral
+	rescan   chan struct{}
+}
+
+// newInventory returns an empty inventory
+func newInventory() *inventory {
+	return &inventory{profiles: make(map[string]*JSONOutput), rescan: make(chan struct{}, 1)}
+}
+
+// handle answers the requests of one connection until the client closes it
+func (inv *inventory) handle(conn io.ReadWriteCloser) error {
+	defer conn.Close()
+	for {
+		data, err := readFrame(conn, maxIPCRequestSize)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var request IPCRequest
+		var response IPCResponse
+		if err := json.Unmarshal(data, &request); err != nil {
+			response = IPCResponse{Status: "error", Error: fmt.Sprintf("invalid request: %v", err)}
+		} else {
+			response = inv.respond(request)
+		}
+		encoded, err := json.Marshal(response)
+		if err != nil {
+			return err
+		}
+		if err := writeFrame(conn, encoded); err != nil {
+			return err
+		}
+	}
+}
+
+// respond executes a single request
+func (inv *inventory) respond(request IPCRequest) IPCResponse {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	response := IPCResponse{Status: "ok", Scanning: inv.scanning, Deferral: inv.deferral}
+	switch request.Command {
+	case IPCPing:
+	case IPCInventory:
+		if request.Profile != "" {
+			report, ok := inv.profiles[request.Profile]
+			if !ok {
+				return IPCResponse{Status: "error", Error: fmt.Sprintf("no scan of profile %q has completed yet", request.Profile), Scanning: inv.scanning, Deferral: inv.deferral}
+			}
+			response.Report = report
+			break
+		}
+		if inv.report == nil {
+			return IPCResponse{Status: "error", Error: "no scan has completed yet", Scanning: inv.scanning, Deferral: inv.deferral}
+		}
+		response.Report = inv.report
+	case IPCRescan:
+		// A rescan already queued covers this request as well
+		select {
+		case inv.rescan <- struct{}{}:
+		default:
+		}
+	default:
+		return IPCResponse{Status: "error", Error: fmt.Sprintf("unknown command %q", request.Command), Scanning: inv.scanning}
+	}
+	return response
+}
+
+// setScanning marks a scan as running
+func (inv *inventory) setScanning(scanning bool) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.scanning = scanning
+}
+
+// setDeferral records why the next scheduled scan is waiting, nil when it is not
+func (inv *inventory) setDeferral(deferral *Deferral) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.deferral = deferral
+}
+
+// update replaces the report after a scan
+func (inv *inventory) update(report *JSONOutput) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.report = report
+	inv.scanning = false
+}
+
+// updateProfile keeps the report of a scan of part of the host by its profile,
+// leaving the report of the host as it is
+func (inv *inventory) updateProfile(profile string, report *JSONOutput) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.profiles[profile] = report
+	inv.scanning = false
+}
+
+// serveIPC accepts connections until the listener is closed
+func serveIPC(listener ipcListener, inv *inventory, verbose bool) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			if err := inv.handle(conn); err != nil && verbose {
+				logf("IPC connection failed: %v\n", err)
+			}
+		}()
+	}
+}
+
+// runServe implements the serve command, which keeps the inventory of the
+// latest scan available to local agents over a Unix socket or named pipe
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	startPath := fs.String("path", ".", "Start path for searching")
+	maxDepth := fs.Int("depth", -1, "Maximum depth to search (-1 for unlimited)")
+	evaluate := fs.Bool("eval", false, "Evaluate found java executables")
+	configFile := fs.String("config", "", "Path to a JSON configuration file")
+	address := fs.String("socket", defaultIPCAddress, "Unix socket path or Windows named pipe name to listen on")
+	interval := fs.Duration("interval", defaultScanInterval, "Time between scans")
+	cron := fs.String("cron", "", "Scan at the times of a cron expression instead of every interval, e.g. \"30 2 * * mon-fri\" or @daily")
+	timezone := fs.String("timezone", "", "IANA time zone of -cron (default local time zone)")
+	splay := fs.Duration("splay", 0, "Delay every scan by a random time up to this long")
+	once := fs.Bool("once", false, "Scan once and exit, for systemd timers and other schedulers")
+	verbose := fs.Bool("verbose", false, "Enable verbose output")
+	historyFile := fs.String("history", "", "Keep the runtimes of all scans in this file and report first_seen, last_seen and departed runtimes")
+	background := fs.Bool("background", false, "Run with the lowest I/O and CPU priority of the operating system")
+	hostname := fs.String("hostname", "", "Report this computer name instead of resolving it")
+	identity := fs.String("identity", "", "Comma separated sources of the computer name tried in order: cloud, fqdn, os (default os)")
+	var labels labelFlag
+	fs.Var(&labels, "label", "Group label of the host in the reports as key=value, e.g. env=prod (repeatable)")
+	cloudLabels := fs.Bool("cloud-labels", false, "Add the tags of the cloud instance to the labels of the reports")
+	acksSource := fs.String("acks", "", "Acknowledged findings from a JSON file or the acknowledgments URL of the collector, reloaded before every scan")
+	reportDir := fs.String("report-dir", "", "Write the report of every scan to this directory, pruned according to the retention")
+	sealReports := fs.Bool("seal-reports", false, "Compress and encrypt the reports of -report-dir with a key of this host and user, see jfind read-report")
+	enforce := fs.String("enforce", EnforceReport, "What to do with unapproved runtimes: report, or quarantine per the quarantine policy of the configuration")
+	lockFile := fs.String("lock-file", defaultLockFile(), "Lock file that keeps scans of this host from running at the same time")
+	fs.Usage = func() {
+		logf("Usage: jfind serve [options]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		if err == nil {
+			fs.Usage()
+		}
+		return 2
+	}
+	if *interval <= 0 {
+		logf("Error: -interval must be positive\n")
+		return 2
+	}
+	if *sealReports && *reportDir == "" {
+		logf("Error: -seal-reports requires -report-dir\n")
+		return 2
+	}
+
+	cfg := &Config{}
+	if *configFile != "" {
+		var err error
+		if cfg, err = LoadConfig(*configFile); err != nil {
+			logf("Error: %v\n", err)
+			return 2
+		}
+	}
+	if err := cfg.Retention.parse(); err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	// The flags override the schedule of the configuration
+	schedule := Schedule{}
+	if cfg.Schedule != nil {
+		schedule = *cfg.Schedule
+	}
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	if given["cron"] && given["interval"] || given["cron"] && *once || given["interval"] && *once {
+		logf("Error: only one of -cron, -interval and -once can be given\n")
+		return 2
+	}
+	switch {
+	case given["cron"]:
+		schedule = Schedule{Cron: *cron, Timezone: schedule.Timezone, Splay: schedule.Splay}
+	case given["interval"]:
+		schedule = Schedule{Interval: interval.String(), Splay: schedule.Splay}
+	case *once:
+		schedule = Schedule{Once: true, Splay: schedule.Splay}
+	}
+	if given["timezone"] {
+		schedule.Timezone = *timezone
+	}
+	if given["splay"] {
+		schedule.Splay = splay.String()
+	}
+	sched, err := schedule.newScheduler(*interval)
+	if err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	if err := configureIdentity(*hostname, *identity, cfg); err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	if err := configureLabels(labels, *cloudLabels, cfg); err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	absPath, err := filepath.Abs(*startPath)
+	if err != nil {
+		logf("Error resolving path: %v\n", err)
+		return 2
+	}
+	var ledger *quarantineLedger
+	switch *enforce {
+	case EnforceReport:
+	case EnforceQuarantine:
+		if cfg.Quarantine == nil {
+			logf("Error: -enforce quarantine requires a quarantine policy in the configuration\n")
+			return 2
+		}
+		if ledger, err = loadQuarantineLedger(cfg.Quarantine.State); err != nil {
+			logf("Error: %v\n", err)
+			return 2
+		}
+	default:
+		logf("Error: invalid -enforce %q, must be %s or %s\n", *enforce, EnforceReport, EnforceQuarantine)
+		return 2
+	}
+	replacements, err := loadReplacementRules(cfg.Replacements)
+	if err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	if releases, err := loadReleaseCatalog(cfg.ReleaseCatalog, cfg.vendorAPI()); releases == nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	var store *reportStore
+	if *reportDir != "" {
+		if store, err = newReportStore(*reportDir, cfg.Retention); err != nil {
+			logf("Error: %v\n", err)
+			return 2
+		}
+		if *sealReports {
+			if store.key, err = spoolKey(true); err != nil {
+				logf("Error: %v\n", err)
+				return 2
+			}
+		}
+	}
+	var priority string
+	if *background {
+		if priority, err = enterBackgroundMode(); err != nil {
+			logf("Warning: %v, running with normal priority\n", err)
+		}
+	}
+	environment := detectRuntimeEnvironment()
+	var history *runtimeHistory
+	if *historyFile != "" {
+		if history, err = loadHistory(*historyFile); err != nil {
+			logf("Error: %v\n", err)
+			return 2
+		}
+	}
+
+	// A single scan has nobody to serve the inventory to
+	inv := newInventory()
+	if !schedule.Once {
+		listener, err := listenIPC(*address)
+		if err != nil {
+			logf("Error: %v\n", err)
+			return 1
+		}
+		defer listener.Close()
+		logf("Serving inventory on %s\n", *address)
+		go serveIPC(listener, inv, *verbose)
+	}
+	var triggers *triggerQueue
+	var pending <-chan *TriggeredScan
+	if cfg.Trigger != nil && !schedule.Once {
+		token, err := readTriggerToken(cfg.Trigger.TokenFile)
+		if err != nil {
+			logf("Error: %v\n", err)
+			return 2
+		}
+		triggers = newTriggerQueue(token, cfg.ScanProfiles)
+		stop, err := serveTriggers(cfg.Trigger, triggers)
+		if err != nil {
+			logf("Error: %v\n", err)
+			return 1
+		}
+		defer stop()
+		pending = triggers.pending
+		logf("Listening for triggered scans on %s\n", cfg.Trigger.Address)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	var deferral *Deferral
+	var lastAcks []Acknowledgment
+	requested := false
+	var triggered *TriggeredScan
+	due := sched.first(time.Now())
+	for {
+		if wait := time.Until(due); !requested && wait > 0 {
+			if *verbose {
+				logf("Next scan at %s\n", due.Format(time.RFC3339))
+			}
+			select {
+			case <-time.After(wait):
+			case <-inv.rescan:
+				requested = true
+			case triggered = <-pending:
+				requested = true
+			case <-interrupt:
+				return 0
+			}
+		}
+		// Blackout windows defer scheduled scans, a rescan requested over IPC or
+		// a trigger runs anyway
+		if now := time.Now(); !requested {
+			if window, until := activeBlackout(cfg.BlackoutWindows, now); window != nil {
+				if deferral == nil {
+					deferral = &Deferral{ScheduledAt: now.UTC().Format(time.RFC3339)}
+				}
+				deferral.Reason = fmt.Sprintf("blackout window %q", window.Name)
+				deferral.Until = until.UTC().Format(time.RFC3339)
+				inv.setDeferral(deferral)
+				logf("Scan deferred until %s: %s\n", until.Format(time.RFC3339), deferral.Reason)
+				select {
+				case <-time.After(until.Sub(now)):
+				case <-inv.rescan:
+					requested = true
+				case triggered = <-pending:
+					requested = true
+				case <-interrupt:
+					return 0
+				}
+				continue
+			}
+		}
+
+		inv.setDeferral(nil)
+		// A manual scan may be running, it is waited for until the next scan is
+		// due; a single scan fails like a manual one
+		var lockWait time.Duration
+		if next, ok := sched.next(time.Now()); ok {
+			lockWait = time.Until(next)
+		}
+		lock, err := acquireScanLock(*lockFile, lockWait)
+		var locked *ScanLockedError
+		if errors.As(err, &locked) && schedule.Once {
+			logf("Error: %v\n", err)
+			return 1
+		} else if errors.As(err, &locked) {
+			logf("Warning: scan postponed: %v\n", err)
+			continue
+		} else if err != nil {
+			logf("Warning: %v, scanning without the lock\n", err)
+		}
+		inv.setScanning(true)
+		startTime := time.Now()
+		paths, depth, eval := []string{absPath}, *maxDepth, *evaluate
+		// A profile narrowing the paths or the depth sees only part of the host
+		partial := false
+		if triggered != nil {
+			paths, depth, eval = triggers.profile(triggered, paths, depth, eval)
+			partial = !slices.Equal(paths, []string{absPath}) || depth != *maxDepth
+			triggers.start(triggered)
+			if *verbose {
+				logf("Running triggered scan %s\n", triggered.ScanID)
+			}
+		}
+		finder := NewJavaFinder(paths[0], depth, *verbose, eval)
+		if len(paths) > 1 {
+			finder.roots = paths
+		}
+		if triggered != nil {
+			finder.scanID = triggered.ScanID
+		}
+		if ledger != nil {
+			finder.quarantined = ledger.paths()
+		}
+		finder.environment = environment
+		finder.skipVirtualFS = environment == EnvContainer
+		finder.classifier = newPathClassifier(cfg.PathRules)
+		finder.costs = cfg.LicenseCosts
+		finder.replacements = replacements
+		// A fetched catalog is fetched again when the cached one expires
+		releases, err := loadReleaseCatalog(cfg.ReleaseCatalog, cfg.vendorAPI())
+		if err != nil {
+			logf("Warning: %v\n", err)
+		}
+		if releases != nil {
+			finder.releases = releases
+		}
+		finder.history = history
+		finder.background = priority
+		if *acksSource != "" {
+			// Keep the previous acknowledgments if the collector cannot be reached
+			if acks, err := loadAcknowledgments(*acksSource, getComputerName(), time.Now()); err != nil {
+				logf("Warning: %v\n", err)
+			} else {
+				lastAcks = acks
+			}
+			finder.acks = lastAcks
+		}
+		results, err := finder.Find()
+		if err := lock.release(); err != nil {
+			logf("Warning: %v\n", err)
+		}
+		if err != nil {
+			logf("Warning: scan failed: %v\n", err)
+		} else if history != nil {
+			// Runtimes below the depth of a profile were not looked for
+			roots := paths
+			if depth != *maxDepth {
+				roots = nil
+			}
+			history.record(results, roots, nil, time.Now())
+			if err := saveHistoryGuarded(history, cfg.Retention.minFree); err != nil {
+				logf("Warning: %v\n", err)
+			}
+		}
+		finder.defaultRuntime = detectDefaultRuntime()
+		report := buildJSONOutput(results, finder, startTime)
+		report.Meta.Deferral = deferral
+		if ledger != nil {
+			if err := ledger.enforce(cfg.Quarantine, &report, time.Now()); err != nil {
+				logf("Warning: %v\n", err)
+			}
+			report.Quarantined = ledger.list()
+		}
+		if store != nil {
+			if path, err := store.write(&report, time.Now()); err != nil {
+				logf("Warning: report not written: %v\n", err)
+			} else if *verbose {
+				logf("Report written to %s\n", path)
+			}
+		}
+		deferral = nil
+		requested = false
+		if partial {
+			inv.updateProfile(triggered.Profile, &report)
+		} else {
+			inv.update(&report)
+		}
+		if triggered != nil {
+			triggers.finish(triggered, len(results), err)
+			triggered = nil
+		}
+		if *verbose {
+			logf("Scan completed with %d results\n", len(results))
+		}
+
+		next, ok := sched.next(time.Now())
+		if !ok && err != nil {
+			return 1
+		} else if !ok {
+			return 0
+		}
+		due = next
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// defaultIPCAddress is the Unix socket jfind serve listens on, macOS has no /run
+var defaultIPCAddress = func() string {
+	if runtime.GOOS == "darwin" {
+		return "/var/run/jfind/jfind.sock"
+	}
+	return "/run/jfind/jfind.sock"
+}()
+
+// unixListener serves the IPC protocol on a Unix domain socket
+type unixListener struct {
+	net.Listener
+}
+
+// Accept waits for the next connection
+func (l unixListener) Accept() (io.ReadWriteCloser, error) {
+	return l.Listener.Accept()
+}
+
+// listenIPC listens on a Unix socket that only the owner can connect to. Its
+// directory is created, /run is a tmpfs that starts out empty after a boot.
+// A socket left behind by an earlier process is replaced.
+func listenIPC(path string) (ipcListener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of %s: %v", path, err)
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict access to %s: %v", path, err)
+	}
+	return unixListener{listener}, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// ipcCall sends a request frame and decodes the response frame
+func ipcCall(t *testing.T, conn net.Conn, command string) IPCResponse {
+	t.Helper()
+	request, _ := json.Marshal(IPCRequest{Command: command})
+	if err := writeFrame(conn, request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := readFrame(conn, 1<<20)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var response IPCResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return response
+}
+
+func TestInventoryProtocol(t *testing.T) {
+	inv := newInventory()
+	client, server := net.Pipe()
+	defer client.Close()
+	go inv.handle(server)
+
+	if r := ipcCall(t, client, IPCInventory); r.Status != "error" {
+		t.Errorf("Expected an error before the first scan, got %+v", r)
+	}
+
+	inv.update(&JSONOutput{Meta: MetaInfo{ScanID: "scan-1", CountResult: 1}})
+	r := ipcCall(t, client, IPCInventory)
+	if r.Status != "ok" || r.Report == nil || r.Report.Meta.ScanID != "scan-1" {
+		t.Errorf("Expected the latest report, got %+v", r)
+	}
+
+	if r := ipcCall(t, client, IPCRescan); r.Status != "ok" || len(inv.rescan) != 1 {
+		t.Errorf("Expected a queued rescan, got %+v", r)
+	}
+	// A second request while one is queued is merged into it
+	ipcCall(t, client, IPCRescan)
+	if len(inv.rescan) != 1 {
+		t.Errorf("Expected one queued rescan, got %d", len(inv.rescan))
+	}
+
+	if r := ipcCall(t, client, "shutdown"); r.Status != "error" {
+		t.Errorf("Expected an error for an unknown command, got %+v", r)
+	}
+}
+
+func TestInventoryProfiles(t *testing.T) {
+	inv := newInventory()
+	client, server := net.Pipe()
+	defer client.Close()
+	go inv.handle(server)
+
+	inv.update(&JSONOutput{Meta: MetaInfo{ScanID: "scan-1", CountResult: 3}})
+	inv.updateProfile("patched", &JSONOutput{Meta: MetaInfo{ScanID: "scan-2", CountResult: 1}})
+
+	// The scan of a profile does not replace the inventory of the host
+	if r := ipcCall(t, client, IPCInventory); r.Report == nil || r.Report.Meta.ScanID != "scan-1" {
+		t.Errorf("Expected the report of the host, got %+v", r)
+	}
+
+	for profile, want := range map[string]string{"patched": "scan-2", "other": ""} {
+		request, _ := json.Marshal(IPCRequest{Command: IPCInventory, Profile: profile})
+		if err := writeFrame(client, request); err != nil {
+			t.Fatal(err)
+		}
+		data, err := readFrame(client, 1<<20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r IPCResponse
+		if err := json.Unmarshal(data, &r); err != nil {
+			t.Fatal(err)
+		}
+		if want == "" && r.Status != "error" {
+			t.Errorf("Expected an error for profile %s without a scan, got %+v", profile, r)
+		} else if want != "" && (r.Report == nil || r.Report.Meta.ScanID != want) {
+			t.Errorf("Expected report %s of profile %s, got %+v", want, profile, r)
+		}
+	}
+}
+
+func TestReadFrameLimit(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go writeFrame(client, make([]byte, 100))
+	if _, err := readFrame(server, 10); err == nil {
+		t.Error("Expected an error for an oversized frame")
+	}
+}
+
+func TestListenIPCCreatesDirectory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipes are used on Windows")
+	}
+	// As /run/jfind after a boot, the directory of the socket does not exist yet
+	path := filepath.Join(t.TempDir(), "run", "jfind", "jfind.sock")
+	listener, err := listenIPC(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	listener.Close()
+}
+
+func TestListenIPCUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipes are used on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "jfind.sock")
+	listener, err := listenIPC(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer listener.Close()
+	inv := newInventory()
+	go serveIPC(listener, inv, false)
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer conn.Close()
+	if r := ipcCall(t, conn, IPCPing); r.Status != "ok" {
+		t.Errorf("Expected ok, got %+v", r)
+	}
+}
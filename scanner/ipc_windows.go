@@ -0,0 +1,134 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// defaultIPCAddress is the named pipe jfind serve listens on
+const defaultIPCAddress = `\\.\pipe\jfind`
+
+const (
+	pipeAccessDuplex       = 0x3
+	pipeTypeByte           = 0x0
+	pipeWait               = 0x0
+	pipeRejectRemote       = 0x8
+	pipeUnlimitedInstances = 255
+	pipeBufferSize         = 64 << 10
+	errorPipeConnected     = syscall.Errno(535)
+	sddlRevision1          = 1
+)
+
+// pipeSDDL grants full access to LocalSystem, administrators and the owner of
+// the pipe only. The default security descriptor of a named pipe also lets
+// Everyone and anonymous clients read it.
+const pipeSDDL = "D:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;GA;;;OW)"
+
+var (
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procCreateNamedPipeW = kernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe = kernel32.NewProc("ConnectNamedPipe")
+	procDisconnectPipe   = kernel32.NewProc("DisconnectNamedPipe")
+
+	procConvertSDDLToSD = advapi32.NewProc("ConvertStringSecurityDescriptorToSecurityDescriptorW")
+)
+
+// pipeListener serves the IPC protocol on a Windows named pipe. Every
+// connection gets its own pipe instance.
+type pipeListener struct {
+	name    *uint16
+	sa      *syscall.SecurityAttributes
+	mu      sync.Mutex
+	pending syscall.Handle
+	closed  bool
+}
+
+// pipeConn is a connected pipe instance
+type pipeConn struct {
+	*os.File
+	handle syscall.Handle
+}
+
+// Close disconnects the client and releases the pipe instance
+func (c pipeConn) Close() error {
+	procDisconnectPipe.Call(uintptr(c.handle))
+	return c.File.Close()
+}
+
+// listenIPC creates the named pipe with the security descriptor of pipeSDDL;
+// remote clients are rejected. The descriptor lives as long as the process.
+func listenIPC(name string) (ipcListener, error) {
+	ptr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	sddl, err := syscall.UTF16PtrFromString(pipeSDDL)
+	if err != nil {
+		return nil, err
+	}
+	var descriptor uintptr
+	if ok, _, err := procConvertSDDLToSD.Call(uintptr(unsafe.Pointer(sddl)), sddlRevision1, uintptr(unsafe.Pointer(&descriptor)), 0); ok == 0 {
+		return nil, fmt.Errorf("failed to create the security descriptor of %s: %v", name, err)
+	}
+	sa := &syscall.SecurityAttributes{SecurityDescriptor: descriptor}
+	sa.Length = uint32(unsafe.Sizeof(*sa))
+	l := &pipeListener{name: ptr, sa: sa}
+	if l.pending, err = l.createInstance(); err != nil {
+		return nil, fmt.Errorf("failed to create named pipe %s: %v", name, err)
+	}
+	return l, nil
+}
+
+// createInstance creates the next instance of the pipe
+func (l *pipeListener) createInstance() (syscall.Handle, error) {
+	handle, _, err := procCreateNamedPipeW.Call(
+		uintptr(unsafe.Pointer(l.name)),
+		pipeAccessDuplex,
+		pipeTypeByte|pipeWait|pipeRejectRemote,
+		pipeUnlimitedInstances,
+		pipeBufferSize, pipeBufferSize, 0, uintptr(unsafe.Pointer(l.sa)))
+	if syscall.Handle(handle) == syscall.InvalidHandle {
+		return syscall.InvalidHandle, err
+	}
+	return syscall.Handle(handle), nil
+}
+
+// Accept waits for a client to connect to the pending instance
+func (l *pipeListener) Accept() (io.ReadWriteCloser, error) {
+	l.mu.Lock()
+	handle := l.pending
+	closed := l.closed
+	l.mu.Unlock()
+	if closed {
+		return nil, fmt.Errorf("listener closed")
+	}
+
+	ok, _, err := procConnectNamedPipe.Call(uintptr(handle), 0)
+	if ok == 0 && err != errorPipeConnected {
+		return nil, err
+	}
+
+	next, err := l.createInstance()
+	if err != nil {
+		syscall.CloseHandle(handle)
+		return nil, err
+	}
+	l.mu.Lock()
+	l.pending = next
+	l.mu.Unlock()
+	return pipeConn{File: os.NewFile(uintptr(handle), "pipe"), handle: handle}, nil
+}
+
+// Close stops accepting connections
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	return syscall.CloseHandle(l.pending)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Modes of -java-env
+const (
+	JavaEnvRedacted = "redacted"
+	JavaEnvHashed   = "hashed"
+	JavaEnvOff      = "off"
+)
+
+// javaOptionVariables are the environment variables every JVM started on the host picks up
+var javaOptionVariables = []string{"JAVA_TOOL_OPTIONS", "_JAVA_OPTIONS", "JDK_JAVA_OPTIONS", "CLASSPATH"}
+
+// injectionOptions load code into every JVM when set in an option variable
+var injectionOptions = []string{"-javaagent:", "-agentpath:", "-agentlib:", "-Xbootclasspath"}
+
+// JavaEnvironment is the set of Java related environment variables jfind ran with
+type JavaEnvironment struct {
+	Variables map[string]string `json:"variables"`
+	Injected  []InjectedOption  `json:"injected_options,omitempty"`
+}
+
+// InjectedOption is an agent or boot class path forced on every JVM by an environment variable
+type InjectedOption struct {
+	Variable string `json:"variable"`
+	Option   string `json:"option"`
+}
+
+// collectJavaEnvironment reads the Java option variables. In redacted mode secrets
+// are removed from the values, in hashed mode only the SHA-256 of each value is kept.
+// It returns nil if none of the variables is set.
+func collectJavaEnvironment(mode string) *JavaEnvironment {
+	if mode == JavaEnvOff {
+		return nil
+	}
+	env := &JavaEnvironment{Variables: make(map[string]string)}
+	for _, name := range javaOptionVariables {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if mode == JavaEnvHashed {
+			sum := sha256.Sum256([]byte(value))
+			env.Variables[name] = "sha256:" + hex.EncodeToString(sum[:])
+		} else {
+			env.Variables[name] = redactString(value)
+		}
+		if name == "CLASSPATH" {
+			continue
+		}
+		for _, option := range strings.Fields(value) {
+			for _, prefix := range injectionOptions {
+				if !strings.HasPrefix(option, prefix) {
+					continue
+				}
+				if mode == JavaEnvHashed {
+					option = strings.TrimSuffix(prefix, ":")
+				}
+				env.Injected = append(env.Injected, InjectedOption{Variable: name, Option: redactString(option)})
+			}
+		}
+	}
+	if len(env.Variables) == 0 {
+		return nil
+	}
+	return env
+}
+
+// validJavaEnvMode checks the value of -java-env
+func validJavaEnvMode(mode string) error {
+	switch mode {
+	case JavaEnvRedacted, JavaEnvHashed, JavaEnvOff:
+		return nil
+	}
+	return fmt.Errorf("invalid -java-env mode %q (use %s, %s or %s)", mode, JavaEnvRedacted, JavaEnvHashed, JavaEnvOff)
+}
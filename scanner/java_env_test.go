@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCollectJavaEnvironment(t *testing.T) {
+	t.Setenv("JAVA_TOOL_OPTIONS", "-javaagent:/opt/apm/agent.jar=token=s3cr3t -Dhttp.proxyPassword=pa55")
+	t.Setenv("_JAVA_OPTIONS", "-Xmx2g")
+	t.Setenv("JDK_JAVA_OPTIONS", "")
+	t.Setenv("CLASSPATH", "/opt/lib/*")
+
+	env := collectJavaEnvironment(JavaEnvRedacted)
+	if env == nil {
+		t.Fatal("Expected the Java environment")
+	}
+	want := map[string]string{
+		"JAVA_TOOL_OPTIONS": "-javaagent:/opt/apm/agent.jar=token=REDACTED -Dhttp.proxyPassword=REDACTED",
+		"_JAVA_OPTIONS":     "-Xmx2g",
+		"JDK_JAVA_OPTIONS":  "",
+		"CLASSPATH":         "/opt/lib/*",
+	}
+	if !reflect.DeepEqual(env.Variables, want) {
+		t.Errorf("Expected %q, got %q", want, env.Variables)
+	}
+	injected := []InjectedOption{{Variable: "JAVA_TOOL_OPTIONS", Option: "-javaagent:/opt/apm/agent.jar=token=REDACTED"}}
+	if !reflect.DeepEqual(env.Injected, injected) {
+		t.Errorf("Expected %+v, got %+v", injected, env.Injected)
+	}
+
+	hashed := collectJavaEnvironment(JavaEnvHashed)
+	if value := hashed.Variables["JAVA_TOOL_OPTIONS"]; !strings.HasPrefix(value, "sha256:") || strings.Contains(value, "agent") {
+		t.Errorf("Expected a hash, got %q", value)
+	}
+	if len(hashed.Injected) != 1 || hashed.Injected[0].Option != "-javaagent" {
+		t.Errorf("Expected only the injected option name, got %+v", hashed.Injected)
+	}
+
+	if collectJavaEnvironment(JavaEnvOff) != nil {
+		t.Error("Expected no Java environment when off")
+	}
+	if validJavaEnvMode("plain") == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
+
+func TestCIJavaEnvironmentViolations(t *testing.T) {
+	t.Setenv("JAVA_TOOL_OPTIONS", "-agentpath:/opt/profiler/libagent.so")
+	output := JSONOutput{JavaEnv: collectJavaEnvironment(JavaEnvRedacted)}
+	violations := findViolations(&output)
+	if len(violations) != 1 || violations[0].Severity != SeverityWarning ||
+		violations[0].Message != "JAVA_TOOL_OPTIONS injects -agentpath:/opt/profiler/libagent.so into every JVM" {
+		t.Errorf("Unexpected violations %+v", violations)
+	}
+}
@@ -13,6 +13,17 @@ type JavaProperties struct {
 	RuntimeName string
 	Major       int
 	Update      int
+
+	VMName    string // e.g. "OpenJDK 64-Bit Server VM" or "Eclipse OpenJ9 VM"
+	VMVersion string // e.g. "openj9-0.40.0" for OpenJ9
+
+	// Default heap sizes in bytes, known for OpenJ9 (see openJ9HeapDefaults) and
+	// for HotSpot with -heap (see probeHeapFlags)
+	InitialHeap int64
+	MaxHeap     int64
+
+	// ContainerSupport is the state of UseContainerSupport, only known with -heap
+	ContainerSupport string
 }
 
 // ParseJavaProperties parses the output of java -XshowSettings:properties -version
@@ -38,10 +49,18 @@ func ParseJavaProperties(input string) *JavaProperties {
 				props.Vendor = value
 			case "java.runtime.name":
 				props.RuntimeName = value
+			case "java.vm.name":
+				props.VMName = value
+			case "java.vm.version":
+				props.VMVersion = value
 			}
 		}
 	}
 
+	// Fill in what the properties didn't tell from the -version banner, e.g.
+	// for IBM J9 builds that don't support -XshowSettings
+	parseVersionBanner(props, input)
+
 	// Parse version components
 	if props.Version != "" {
 		props.Major, props.Update = parseJavaVersion(props.Version)
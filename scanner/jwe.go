@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// joseContentType is the media type of a report encrypted as compact JWE
+const joseContentType = "application/jose"
+
+// jweEncoding is the content encryption of the reports, the key is agreed with ECDH-ES
+const jweEncoding = "A256GCM"
+
+// payloadKey is the X25519 public key of the collector the reports are encrypted to
+type payloadKey struct {
+	public *ecdh.PublicKey
+	kid    string
+}
+
+// jwk is the JSON Web Key of an X25519 public key
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid,omitempty"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// loadPayloadKey reads the public key of the collector from a PEM file
+// (openssl pkey -pubout) or a JSON Web Key
+func loadPayloadKey(path string) (*payloadKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload key: %v", err)
+	}
+	key, err := parsePayloadKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload key %s: %v", path, err)
+	}
+	return key, nil
+}
+
+// parsePayloadKey parses a PEM encoded public key or a JSON Web Key
+func parsePayloadKey(data []byte) (*payloadKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		public, ok := parsed.(*ecdh.PublicKey)
+		if !ok || public.Curve() != ecdh.X25519() {
+			return nil, fmt.Errorf("not an X25519 public key")
+		}
+		return &payloadKey{public: public, kid: jwkThumbprint(public)}, nil
+	}
+
+	var key jwk
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("neither PEM nor JSON Web Key: %v", err)
+	}
+	if key.Kty != "OKP" || key.Crv != "X25519" {
+		return nil, fmt.Errorf("not an X25519 key (kty %q, crv %q)", key.Kty, key.Crv)
+	}
+	x, err := b64.DecodeString(key.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x: %v", err)
+	}
+	public, err := ecdh.X25519().NewPublicKey(x)
+	if err != nil {
+		return nil, err
+	}
+	return &payloadKey{public: public, kid: jwkThumbprint(public)}, nil
+}
+
+// jwkThumbprint is the RFC 7638 thumbprint of the key, which the collector
+// uses to pick the matching private key
+func jwkThumbprint(public *ecdh.PublicKey) string {
+	// Members in lexicographic order without whitespace
+	canonical := fmt.Sprintf(`{"crv":"X25519","kty":"OKP","x":"%s"}`, b64.EncodeToString(public.Bytes()))
+	sum := sha256.Sum256([]byte(canonical))
+	return b64.EncodeToString(sum[:])
+}
+
+// encryptPayload encrypts plaintext to the key as compact JWE with ECDH-ES
+// key agreement (RFC 7518, section 4.6) and AES-256-GCM
+func encryptPayload(plaintext []byte, key *payloadKey) (string, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	shared, err := ephemeral.ECDH(key.public)
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(map[string]any{
+		"alg": "ECDH-ES",
+		"enc": jweEncoding,
+		"kid": key.kid,
+		"cty": "application/json",
+		"epk": jwk{Kty: "OKP", Crv: "X25519", X: b64.EncodeToString(ephemeral.PublicKey().Bytes())},
+	})
+	if err != nil {
+		return "", err
+	}
+	protected := b64.EncodeToString(header)
+
+	block, err := aes.NewCipher(concatKDF(shared, jweEncoding, 256))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", err
+	}
+	// The protected header is the additional authenticated data
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	// Direct key agreement has no encrypted key, so the second part is empty
+	return strings.Join([]string{protected, "", b64.EncodeToString(iv), b64.EncodeToString(ciphertext), b64.EncodeToString(tag)}, "."), nil
+}
+
+// concatKDF derives the content encryption key from the shared secret with the
+// Concat KDF of NIST SP 800-56A, without PartyUInfo and PartyVInfo
+func concatKDF(shared []byte, algorithm string, keyBits int) []byte {
+	var otherInfo bytes.Buffer
+	binary.Write(&otherInfo, binary.BigEndian, uint32(len(algorithm)))
+	otherInfo.WriteString(algorithm)
+	binary.Write(&otherInfo, binary.BigEndian, uint32(0)) // PartyUInfo
+	binary.Write(&otherInfo, binary.BigEndian, uint32(0)) // PartyVInfo
+	binary.Write(&otherInfo, binary.BigEndian, uint32(keyBits))
+
+	// One round of SHA-256 yields the 256 bits needed
+	h := sha256.New()
+	binary.Write(h, binary.BigEndian, uint32(1))
+	h.Write(shared)
+	h.Write(otherInfo.Bytes())
+	return h.Sum(nil)[:keyBits/8]
+}
+
+// encryptingTransport encrypts the JSON bodies of requests to the collector
+type encryptingTransport struct {
+	next http.RoundTripper
+	key  *payloadKey
+}
+
+// RoundTrip replaces a JSON request body by its JWE
+func (t *encryptingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Header.Get("Content-Type") != "application/json" {
+		return t.next.RoundTrip(req)
+	}
+	plaintext, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	token, err := encryptPayload(plaintext, t.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt report: %v", err)
+	}
+	// RoundTrip must not modify the original request
+	encrypted := req.Clone(req.Context())
+	encrypted.Header.Set("Content-Type", joseContentType)
+	encrypted.Body = io.NopCloser(strings.NewReader(token))
+	encrypted.ContentLength = int64(len(token))
+	encrypted.GetBody = nil
+	return t.next.RoundTrip(encrypted)
+}
+
+// withPayloadEncryption wraps the transport of client to encrypt reports to key
+func withPayloadEncryption(client *http.Client, key *payloadKey) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	encrypting := *client
+	encrypting.Transport = &encryptingTransport{next: next, key: key}
+	return &encrypting
+}
+
+// encryptedPostSize is the largest report that stays below maxPostSize once
+// encrypted: base64 grows it by a third, the header adds a few hundred bytes
+func encryptedPostSize(maxPostSize int64) int64 {
+	if maxPostSize <= 0 {
+		return maxPostSize
+	}
+	return max(1, maxPostSize*3/4-1024)
+}
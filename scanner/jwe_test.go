@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// decryptPayload is the collector side of encryptPayload
+func decryptPayload(t *testing.T, token string, private *ecdh.PrivateKey) ([]byte, error) {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 || parts[1] != "" {
+		t.Fatalf("Expected a compact JWE with direct key agreement, got %q", token)
+	}
+	headerJSON, _ := b64.DecodeString(parts[0])
+	var header struct {
+		Alg string `json:"alg"`
+		Enc string `json:"enc"`
+		Kid string `json:"kid"`
+		Epk jwk    `json:"epk"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Alg != "ECDH-ES" || header.Enc != "A256GCM" || header.Kid != jwkThumbprint(private.PublicKey()) {
+		t.Errorf("Unexpected header %s", headerJSON)
+	}
+	x, _ := b64.DecodeString(header.Epk.X)
+	ephemeral, err := ecdh.X25519().NewPublicKey(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := private.ECDH(ephemeral)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := aes.NewCipher(concatKDF(shared, "A256GCM", 256))
+	gcm, _ := cipher.NewGCM(block)
+	iv, _ := b64.DecodeString(parts[2])
+	ciphertext, _ := b64.DecodeString(parts[3])
+	tag, _ := b64.DecodeString(parts[4])
+	return gcm.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+}
+
+func newTestPayloadKey(t *testing.T) (*ecdh.PrivateKey, string) {
+	t.Helper()
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(private.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "collector.pub")
+	writeTestFile(t, path, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	return private, path
+}
+
+func TestEncryptPayload(t *testing.T) {
+	private, path := newTestPayloadKey(t)
+	key, err := loadPayloadKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := encryptPayload([]byte(`{"meta":{}}`), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := decryptPayload(t, token, private)
+	if err != nil || string(plaintext) != `{"meta":{}}` {
+		t.Errorf("Unexpected plaintext %q, %v", plaintext, err)
+	}
+
+	// Every payload gets its own ephemeral key and IV
+	if other, _ := encryptPayload([]byte(`{"meta":{}}`), key); other == token {
+		t.Error("Expected different tokens for the same payload")
+	}
+
+	tampered := strings.Split(token, ".")
+	tampered[3] = b64.EncodeToString([]byte("{}"))
+	if _, err := decryptPayload(t, strings.Join(tampered, "."), private); err == nil {
+		t.Error("Expected the tampered payload to fail authentication")
+	}
+}
+
+func TestParsePayloadKey(t *testing.T) {
+	private, path := newTestPayloadKey(t)
+	fromPEM, err := loadPayloadKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwkJSON, _ := json.Marshal(jwk{Kty: "OKP", Crv: "X25519", X: b64.EncodeToString(private.PublicKey().Bytes())})
+	fromJWK, err := parsePayloadKey(jwkJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromPEM.kid != fromJWK.kid || !fromPEM.public.Equal(fromJWK.public) {
+		t.Error("Expected the same key from PEM and JWK")
+	}
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if _, err := parsePayloadKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})); err == nil {
+		t.Error("Expected an error for a P-256 key")
+	}
+	if _, err := parsePayloadKey([]byte(`{"kty":"RSA"}`)); err == nil {
+		t.Error("Expected an error for an RSA JWK")
+	}
+}
+
+func TestEncryptingTransport(t *testing.T) {
+	private, path := newTestPayloadKey(t)
+	key, err := loadPayloadKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contentType string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte(`{"result": "queued"}`))
+	}))
+	defer server.Close()
+
+	client := withPayloadEncryption(http.DefaultClient, key)
+	if err := sendJSON(client, []byte(`{"result":[]}`), server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if contentType != joseContentType {
+		t.Errorf("Expected %s, got %s", joseContentType, contentType)
+	}
+	if plaintext, err := decryptPayload(t, string(body), private); err != nil || string(plaintext) != `{"result":[]}` {
+		t.Errorf("Unexpected plaintext %q, %v", plaintext, err)
+	}
+	if http.DefaultClient.Transport != nil {
+		t.Error("Expected the default client to be left alone")
+	}
+}
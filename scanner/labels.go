@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Limits of labels, which the collector indexes
+const (
+	maxLabelKey   = 63
+	maxLabelValue = 255
+)
+
+// gcpLabelsAttribute is the custom metadata attribute of a GCP instance with
+// its labels, since the metadata server does not expose the instance labels
+const gcpLabelsAttribute = "jfind-labels"
+
+// labelFlag collects repeated -label key=value flags
+type labelFlag map[string]string
+
+func (l *labelFlag) String() string {
+	return formatLabels(*l)
+}
+
+func (l *labelFlag) Set(value string) error {
+	key, val, err := parseLabel(value)
+	if err != nil {
+		return err
+	}
+	if *l == nil {
+		*l = make(labelFlag)
+	}
+	(*l)[key] = val
+	return nil
+}
+
+// parseLabel splits a label given as key=value
+func parseLabel(s string) (string, string, error) {
+	key, value, ok := strings.Cut(s, "=")
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if !ok {
+		return "", "", fmt.Errorf("invalid label %q, use key=value", s)
+	}
+	if err := validateLabel(key, value); err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+// parseLabelList splits comma separated key=value labels, e.g. datacenter=fra1,env=prod
+func parseLabelList(list string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, label := range strings.Split(list, ",") {
+		if strings.TrimSpace(label) == "" {
+			continue
+		}
+		key, value, err := parseLabel(label)
+		if err != nil {
+			return nil, err
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// validateLabel checks that a key consists of letters, digits and - _ . / :
+// and that neither key nor value exceed the limits of the collector
+func validateLabel(key, value string) error {
+	if key == "" || len(key) > maxLabelKey {
+		return fmt.Errorf("invalid label key %q, must have 1 to %d characters", key, maxLabelKey)
+	}
+	for _, c := range key {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune("-_./:", c) {
+			return fmt.Errorf("invalid label key %q, use letters, digits and - _ . / :", key)
+		}
+	}
+	if len(value) > maxLabelValue {
+		return fmt.Errorf("value of label %s exceeds %d characters", key, maxLabelValue)
+	}
+	if strings.ContainsFunc(value, unicode.IsControl) {
+		return fmt.Errorf("value of label %s contains control characters", key)
+	}
+	return nil
+}
+
+// formatLabels lists labels as key=value, sorted by key
+func formatLabels(labels map[string]string) string {
+	var list []string
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		list = append(list, key+"="+labels[key])
+	}
+	return strings.Join(list, ",")
+}
+
+// hostLabeler determines the labels of the reports of the host. Tags of the
+// cloud instance come first, the labels of the configuration and of -label
+// override them. The labels are resolved once per process.
+type hostLabeler struct {
+	configured map[string]string
+	cloud      bool
+
+	// cloudURL is replaced in tests
+	cloudURL string
+
+	once   sync.Once
+	labels map[string]string
+}
+
+// hostLabels resolves the labels of reports
+var hostLabels = &hostLabeler{}
+
+// configureLabels sets up the labels of reports from the configuration and the
+// -label and -cloud-labels flags, the flags take precedence
+func configureLabels(flags labelFlag, cloud bool, cfg *Config) error {
+	configured := make(map[string]string)
+	for key, value := range cfg.Labels {
+		if err := validateLabel(key, value); err != nil {
+			return err
+		}
+		configured[key] = value
+	}
+	maps.Copy(configured, flags)
+	hostLabels = &hostLabeler{configured: configured, cloud: cloud || cfg.CloudLabels, cloudURL: cloudMetadataURL}
+	return nil
+}
+
+// resolve returns the labels of the host, nil if it has none
+func (l *hostLabeler) resolve() map[string]string {
+	l.once.Do(func() {
+		labels := make(map[string]string)
+		if l.cloud {
+			// Tags that are no valid labels are left out, the instance has many more
+			for key, value := range cloudTags(l.cloudURL) {
+				if validateLabel(key, value) == nil {
+					labels[key] = value
+				}
+			}
+		}
+		maps.Copy(labels, l.configured)
+		if len(labels) > 0 {
+			l.labels = labels
+		}
+	})
+	return l.labels
+}
+
+// cloudTags reads the tags of the cloud instance: the instance tags of AWS, if
+// their access from the instance metadata is allowed, the tags of Azure, and
+// the jfind-labels attribute of GCP as key=value,key=value. Outside of a
+// cloud, it returns nil.
+func cloudTags(baseURL string) map[string]string {
+	get := newMetadataClient(baseURL).get
+
+	// AWS
+	if _, token, err := get(http.MethodPut, "/latest/api/token", map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"}); err == nil {
+		headers := map[string]string{"X-aws-ec2-metadata-token": token}
+		_, keys, err := get(http.MethodGet, "/latest/meta-data/tags/instance", headers)
+		if err != nil {
+			return nil
+		}
+		tags := make(map[string]string)
+		for _, key := range strings.Fields(keys) {
+			if _, value, err := get(http.MethodGet, "/latest/meta-data/tags/instance/"+url.PathEscape(key), headers); err == nil {
+				tags[key] = value
+			}
+		}
+		return tags
+	} else if isTimeout(err) {
+		return nil
+	}
+	// GCP
+	if resp, list, err := get(http.MethodGet, "/computeMetadata/v1/instance/attributes/"+gcpLabelsAttribute, map[string]string{"Metadata-Flavor": "Google"}); err == nil && resp.Header.Get("Metadata-Flavor") == "Google" {
+		tags, _ := parseLabelList(list)
+		return tags
+	}
+	// Azure
+	if _, list, err := get(http.MethodGet, "/metadata/instance/compute/tagsList?api-version=2021-02-01", map[string]string{"Metadata": "true"}); err == nil {
+		var azureTags []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		}
+		if json.Unmarshal([]byte(list), &azureTags) != nil {
+			return nil
+		}
+		tags := make(map[string]string)
+		for _, tag := range azureTags {
+			tags[tag.Name] = tag.Value
+		}
+		return tags
+	}
+	return nil
+}
+
+// mergeLabels keeps the labels all reports agree on
+func mergeLabels(reports []*JSONOutput) map[string]string {
+	if len(reports) == 0 {
+		return nil
+	}
+	merged := maps.Clone(reports[0].Meta.Labels)
+	for _, report := range reports[1:] {
+		for key, value := range merged {
+			if other, ok := report.Meta.Labels[key]; !ok || other != value {
+				delete(merged, key)
+			}
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
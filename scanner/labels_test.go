@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLabelFlag(t *testing.T) {
+	var labels labelFlag
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&labels, "label", "")
+	if err := fs.Parse([]string{"-label", "env=prod", "-label", "datacenter = fra1", "-label", "env=staging", "-label", "owner="}); err != nil {
+		t.Fatal(err)
+	}
+	if want := (labelFlag{"env": "staging", "datacenter": "fra1", "owner": ""}); !maps.Equal(labels, want) {
+		t.Errorf("Expected %v, got %v", want, labels)
+	}
+	if got := labels.String(); got != "datacenter=fra1,env=staging,owner=" {
+		t.Errorf("Expected the labels sorted by key, got %s", got)
+	}
+
+	for _, invalid := range []string{"env", "=prod", "team name=a", "env=a\nb", string(make([]byte, maxLabelKey+1)) + "=x"} {
+		if _, _, err := parseLabel(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestHostLabels(t *testing.T) {
+	tests := []struct {
+		cloud   string
+		handler http.HandlerFunc
+	}{
+		{"aws", func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+				w.Write([]byte("token"))
+			case r.Header.Get("X-aws-ec2-metadata-token") != "token":
+				http.NotFound(w, r)
+			case r.URL.Path == "/latest/meta-data/tags/instance":
+				w.Write([]byte("env\nteam\nName"))
+			case r.URL.Path == "/latest/meta-data/tags/instance/env":
+				w.Write([]byte("staging"))
+			case r.URL.Path == "/latest/meta-data/tags/instance/team":
+				w.Write([]byte("payments"))
+			case r.URL.Path == "/latest/meta-data/tags/instance/Name":
+				w.Write([]byte("ip-10-0-0-1"))
+			default:
+				http.NotFound(w, r)
+			}
+		}},
+		{"gcp", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/computeMetadata/v1/instance/attributes/jfind-labels" && r.Header.Get("Metadata-Flavor") == "Google" {
+				w.Header().Set("Metadata-Flavor", "Google")
+				w.Write([]byte("env=staging, team=payments,Name=ip-10-0-0-1"))
+				return
+			}
+			http.NotFound(w, r)
+		}},
+		{"azure", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/metadata/instance/compute/tagsList" && r.Header.Get("Metadata") == "true" {
+				w.Write([]byte(`[{"name": "env", "value": "staging"}, {"name": "team", "value": "payments"}, {"name": "Name", "value": "ip-10-0-0-1"}, {"name": "cost center", "value": "x"}]`))
+				return
+			}
+			http.NotFound(w, r)
+		}},
+	}
+	cfg := &Config{Labels: map[string]string{"datacenter": "fra1", "env": "prod"}, CloudLabels: true}
+	for _, tt := range tests {
+		server := httptest.NewServer(tt.handler)
+		if err := configureLabels(labelFlag{"datacenter": "fra2"}, false, cfg); err != nil {
+			t.Fatal(err)
+		}
+		hostLabels.cloudURL = server.URL
+		// The configuration overrides the tags, and the flags the configuration;
+		// tags that are no valid labels are left out
+		want := map[string]string{"datacenter": "fra2", "env": "prod", "team": "payments", "Name": "ip-10-0-0-1"}
+		if got := hostLabels.resolve(); !maps.Equal(got, want) {
+			t.Errorf("%s: expected %v, got %v", tt.cloud, want, got)
+		}
+		server.Close()
+	}
+
+	if err := configureLabels(nil, false, &Config{Labels: map[string]string{"bad key": "x"}}); err == nil {
+		t.Error("Expected an invalid label of the configuration to be rejected")
+	}
+	if err := configureLabels(nil, false, &Config{}); err != nil || hostLabels.resolve() != nil {
+		t.Errorf("Expected no labels without any configured, got %v", hostLabels.resolve())
+	}
+}
+
+func TestMergeLabels(t *testing.T) {
+	reports := []*JSONOutput{
+		{Meta: MetaInfo{Labels: map[string]string{"env": "prod", "datacenter": "fra1", "team": "a"}}},
+		{Meta: MetaInfo{Labels: map[string]string{"env": "prod", "datacenter": "fra1", "team": "b"}}},
+		{Meta: MetaInfo{Labels: map[string]string{"env": "prod"}}},
+	}
+	if got := mergeLabels(reports); !maps.Equal(got, map[string]string{"env": "prod"}) {
+		t.Errorf("Expected only the labels all reports agree on, got %v", got)
+	}
+	if got := mergeLabels(reports[:2]); !maps.Equal(got, map[string]string{"env": "prod", "datacenter": "fra1"}) {
+		t.Errorf("Expected the labels of both shards, got %v", got)
+	}
+}
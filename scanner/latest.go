@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Distributions of the release catalog
+const (
+	DistributionTemurin    = "Eclipse Temurin"
+	DistributionZulu       = "Azul Zulu"
+	DistributionCorretto   = "Amazon Corretto"
+	DistributionMicrosoft  = "Microsoft Build of OpenJDK"
+	DistributionLiberica   = "BellSoft Liberica"
+	DistributionRedHat     = "Red Hat build of OpenJDK"
+	DistributionSapMachine = "SapMachine"
+	DistributionSemeru     = "IBM Semeru"
+	DistributionOracleJDK  = "Oracle JDK"
+)
+
+// distributionVendors identify the distribution of a runtime by a part of its
+// vendor, the java.vendor property or the IMPLEMENTOR of the release file
+var distributionVendors = []struct {
+	vendor       string
+	distribution string
+}{
+	{"Eclipse Adoptium", DistributionTemurin},
+	{"Azul", DistributionZulu},
+	{"Amazon", DistributionCorretto},
+	{"Microsoft", DistributionMicrosoft},
+	{"BellSoft", DistributionLiberica},
+	{"Red Hat", DistributionRedHat},
+	{"SAP SE", DistributionSapMachine},
+	{"IBM", DistributionSemeru},
+	{"International Business Machines", DistributionSemeru},
+	{"Oracle", DistributionOracleJDK},
+}
+
+// CatalogRelease is the newest release of a distribution and major version.
+// Latest is its java.version, e.g. 17.0.17 or 1.8.0_472.
+type CatalogRelease struct {
+	Distribution string `json:"distribution"`
+	Major        int    `json:"major"`
+	Latest       string `json:"latest"`
+}
+
+// LatestRelease is the newest release of the distribution and major version of
+// a runtime and how many update releases the runtime is behind it
+type LatestRelease struct {
+	Distribution  string `json:"distribution"`
+	Version       string `json:"version"`
+	UpdatesBehind int    `json:"updates_behind"`
+}
+
+// builtinReleasesDate is the date of the critical patch update of the
+// built-in releases, after which they go stale
+var builtinReleasesDate = time.Date(2025, time.October, 21, 0, 0, 0, 0, time.UTC)
+
+// builtinReleases is the release catalog known to this build, as of the
+// critical patch update of October 2025. Oracle numbers the updates of Java 8
+// one below the OpenJDK builds.
+var builtinReleases = []CatalogRelease{
+	{DistributionTemurin, 8, "1.8.0_472"}, {DistributionTemurin, 11, "11.0.29"}, {DistributionTemurin, 17, "17.0.17"},
+	{DistributionTemurin, 21, "21.0.9"}, {DistributionTemurin, 25, "25.0.1"},
+	{DistributionZulu, 8, "1.8.0_472"}, {DistributionZulu, 11, "11.0.29"}, {DistributionZulu, 17, "17.0.17"},
+	{DistributionZulu, 21, "21.0.9"}, {DistributionZulu, 25, "25.0.1"},
+	{DistributionCorretto, 8, "1.8.0_472"}, {DistributionCorretto, 11, "11.0.29"}, {DistributionCorretto, 17, "17.0.17"},
+	{DistributionCorretto, 21, "21.0.9"}, {DistributionCorretto, 25, "25.0.1"},
+	{DistributionMicrosoft, 11, "11.0.29"}, {DistributionMicrosoft, 17, "17.0.17"}, {DistributionMicrosoft, 21, "21.0.9"},
+	{DistributionMicrosoft, 25, "25.0.1"},
+	{DistributionLiberica, 8, "1.8.0_472"}, {DistributionLiberica, 11, "11.0.29"}, {DistributionLiberica, 17, "17.0.17"},
+	{DistributionLiberica, 21, "21.0.9"}, {DistributionLiberica, 25, "25.0.1"},
+	{DistributionRedHat, 8, "1.8.0_472"}, {DistributionRedHat, 11, "11.0.29"}, {DistributionRedHat, 17, "17.0.17"},
+	{DistributionRedHat, 21, "21.0.9"}, {DistributionRedHat, 25, "25.0.1"},
+	{DistributionSapMachine, 11, "11.0.29"}, {DistributionSapMachine, 17, "17.0.17"}, {DistributionSapMachine, 21, "21.0.9"},
+	{DistributionSapMachine, 25, "25.0.1"},
+	{DistributionSemeru, 8, "1.8.0_472"}, {DistributionSemeru, 11, "11.0.29"}, {DistributionSemeru, 17, "17.0.17"},
+	{DistributionSemeru, 21, "21.0.9"}, {DistributionSemeru, 25, "25.0.1"},
+	{DistributionOracleJDK, 8, "1.8.0_471"}, {DistributionOracleJDK, 11, "11.0.29"}, {DistributionOracleJDK, 17, "17.0.17"},
+	{DistributionOracleJDK, 21, "21.0.9"}, {DistributionOracleJDK, 25, "25.0.1"},
+}
+
+// releaseCatalog maps a distribution and major version to its newest release
+type releaseCatalog map[releaseKey]CatalogRelease
+
+type releaseKey struct {
+	distribution string
+	major        int
+}
+
+// defaultReleaseCatalog is the catalog of the built-in releases
+var defaultReleaseCatalog = newReleaseCatalog(builtinReleases)
+
+// newReleaseCatalog indexes releases, a later one of the same distribution and
+// major version replaces an earlier one
+func newReleaseCatalog(releases []CatalogRelease) releaseCatalog {
+	catalog := make(releaseCatalog, len(releases))
+	for _, release := range releases {
+		catalog[releaseKey{release.Distribution, release.Major}] = release
+	}
+	return catalog
+}
+
+// loadReleaseCatalog reads the releases of a JSON file or of an http(s) URL,
+// fetched through the vendor API cache, which replace the built-in releases of
+// the same distribution and major version. An empty source yields the built-in
+// catalog, as does a URL that cannot be fetched, together with the error.
+func loadReleaseCatalog(source string, api *vendorAPI) (releaseCatalog, error) {
+	if source == "" {
+		return defaultReleaseCatalog, nil
+	}
+	var data []byte
+	var err error
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		if data, err = api.get(source); err != nil {
+			return defaultReleaseCatalog, fmt.Errorf("failed to fetch release catalog, using the built-in one: %v", err)
+		}
+	} else if data, err = os.ReadFile(source); err != nil {
+		return nil, fmt.Errorf("failed to read release catalog %s: %v", source, err)
+	}
+
+	var releases []CatalogRelease
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse release catalog %s: %v", source, err)
+	}
+	for i, release := range releases {
+		major, _ := parseJavaVersion(release.Latest)
+		if release.Distribution == "" || release.Major == 0 || major != release.Major {
+			return nil, fmt.Errorf("release %d of catalog %s requires distribution, major and the latest version of the major", i+1, source)
+		}
+	}
+	return newReleaseCatalog(slices.Concat(builtinReleases, releases)), nil
+}
+
+// runtimeDistribution identifies the distribution of a runtime by its vendor,
+// empty if it is unknown. OpenJDK builds of Oracle are not the Oracle JDK.
+func runtimeDistribution(result *JavaResult) string {
+	_, vendor := runtimeVersion(result)
+	for _, d := range distributionVendors {
+		if !strings.Contains(vendor, d.vendor) {
+			continue
+		}
+		if d.distribution == DistributionOracleJDK {
+			if result.Properties != nil && strings.Contains(result.Properties.RuntimeName, "OpenJDK") ||
+				result.Properties == nil && result.Release["BUILD_TYPE"] != "commercial" {
+				return ""
+			}
+		}
+		return d.distribution
+	}
+	return ""
+}
+
+// latest returns the newest release of the distribution and major version of
+// a runtime, nil if the catalog does not know them
+func (c releaseCatalog) latest(result *JavaResult) *LatestRelease {
+	version, _ := runtimeVersion(result)
+	major, update := parseJavaVersion(version)
+	release, ok := c[releaseKey{runtimeDistribution(result), major}]
+	if !ok || major == 0 {
+		return nil
+	}
+	_, latest := parseJavaVersion(release.Latest)
+	return &LatestRelease{
+		Distribution:  release.Distribution,
+		Version:       release.Latest,
+		UpdatesBehind: updatesBehind(major, update, latest),
+	}
+}
+
+// updatesBehind counts the update releases between two updates of a major
+// version. The quarterly updates of Java 8 and before are numbered in steps of
+// 10, those of later versions one by one.
+func updatesBehind(major, update, latest int) int {
+	behind := latest - update
+	if major <= 8 {
+		behind = (behind + 9) / 10
+	}
+	return max(behind, 0)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLatestRelease(t *testing.T) {
+	tests := []struct {
+		name   string
+		result JavaResult
+		want   *LatestRelease
+	}{
+		{"temurin", JavaResult{Properties: &JavaProperties{Version: "17.0.9", Vendor: "Eclipse Adoptium"}},
+			&LatestRelease{DistributionTemurin, "17.0.17", 8}},
+		{"up to date", JavaResult{Properties: &JavaProperties{Version: "21.0.9", Vendor: "Amazon.com Inc."}},
+			&LatestRelease{DistributionCorretto, "21.0.9", 0}},
+		{"java 8 from the release file", JavaResult{Release: map[string]string{"JAVA_VERSION": "1.8.0_402", "IMPLEMENTOR": "Azul Systems, Inc."}},
+			&LatestRelease{DistributionZulu, "1.8.0_472", 7}},
+		{"oracle jdk", JavaResult{Properties: &JavaProperties{Version: "1.8.0_202", Vendor: "Oracle Corporation", RuntimeName: "Java(TM) SE Runtime Environment"}},
+			&LatestRelease{DistributionOracleJDK, "1.8.0_471", 27}},
+		{"oracle openjdk", JavaResult{Properties: &JavaProperties{Version: "21.0.2", Vendor: "Oracle Corporation", RuntimeName: "OpenJDK Runtime Environment"}}, nil},
+		{"unknown vendor", JavaResult{Properties: &JavaProperties{Version: "17.0.9", Vendor: "Private Build"}}, nil},
+		{"major without updates", JavaResult{Properties: &JavaProperties{Version: "22.0.2", Vendor: "Eclipse Adoptium"}}, nil},
+	}
+	for _, tt := range tests {
+		got := defaultReleaseCatalog.latest(&tt.result)
+		if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestLoadReleaseCatalog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "catalog.json")
+	catalog := `[{"distribution": "Eclipse Temurin", "major": 17, "latest": "17.0.18"}, {"distribution": "Debian", "major": 17, "latest": "17.0.18"}]`
+	if err := os.WriteFile(path, []byte(catalog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	releases, err := loadReleaseCatalog(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := releases[releaseKey{DistributionTemurin, 17}].Latest; got != "17.0.18" {
+		t.Errorf("Expected the catalog to replace the built-in release, got %s", got)
+	}
+	if got := releases[releaseKey{DistributionTemurin, 21}].Latest; got != "21.0.9" {
+		t.Errorf("Expected the built-in release of Java 21, got %s", got)
+	}
+
+	// A fetched catalog is cached, without it the built-in one is used
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(catalog))
+	}))
+	api := newVendorAPI(&VendorAPI{CacheDir: filepath.Join(dir, "cache")})
+	if releases, err := loadReleaseCatalog(server.URL+"/catalog.json", api); err != nil || releases[releaseKey{DistributionTemurin, 17}].Latest != "17.0.18" {
+		t.Errorf("Expected the fetched catalog, got %v", err)
+	}
+	server.Close()
+	api = newVendorAPI(&VendorAPI{CacheDir: filepath.Join(dir, "cache")})
+	api.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	if releases, err := loadReleaseCatalog(server.URL+"/catalog.json", api); err != nil || releases[releaseKey{DistributionTemurin, 17}].Latest != "17.0.18" {
+		t.Errorf("Expected the stale cached catalog, got %v", err)
+	}
+	if releases, err := loadReleaseCatalog(server.URL+"/other.json", api); err == nil || releases[releaseKey{DistributionTemurin, 17}].Latest != "17.0.17" {
+		t.Errorf("Expected the built-in catalog and an error, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"distribution": "Eclipse Temurin", "major": 17, "latest": "21.0.1"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadReleaseCatalog(path, nil); err == nil {
+		t.Error("Expected a release of another major version to be rejected")
+	}
+}
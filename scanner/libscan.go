@@ -0,0 +1,321 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// LibraryRule describes the vulnerable versions of a Java library. A version is
+// vulnerable if it is at least Introduced and below Fixed; an empty bound is open.
+type LibraryRule struct {
+	// Group is the Maven group ID, empty to match the artifact of any group
+	Group    string `json:"group,omitempty"`
+	Artifact string `json:"artifact"`
+
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+
+	Advisories []string `json:"advisories"`
+
+	// Class is the vulnerable class; the advisories of the rule are reported
+	// as mitigated for an archive without it
+	Class string `json:"class,omitempty"`
+}
+
+// LibraryFinding is a vulnerable library found next to the runtimes
+type LibraryFinding struct {
+	Path       string   `json:"path"`
+	Entry      string   `json:"entry,omitempty"` // library nested in the archive, e.g. WEB-INF/lib/log4j-core-2.14.1.jar
+	Group      string   `json:"group,omitempty"`
+	Artifact   string   `json:"artifact"`
+	Version    string   `json:"version"`
+	Fixed      string   `json:"fixed_version,omitempty"`
+	Advisories []string `json:"advisories"`
+	Mitigated  bool     `json:"mitigated,omitempty"` // all advisories are mitigated
+
+	// MitigatedAdvisories are the advisories whose vulnerable class was removed
+	MitigatedAdvisories []string `json:"mitigated_advisories,omitempty"`
+}
+
+// openAdvisories returns the advisories of a finding that are not mitigated
+func (f *LibraryFinding) openAdvisories() []string {
+	return slices.DeleteFunc(slices.Clone(f.Advisories), func(id string) bool {
+		return slices.Contains(f.MitigatedAdvisories, id)
+	})
+}
+
+// defaultLibraryRules are the libraries looked for with -libs
+var defaultLibraryRules = []LibraryRule{
+	// Removing JndiLookup mitigates the lookups of Log4Shell, not the
+	// recursion of CVE-2021-45105 or the JDBC appender of CVE-2021-44832
+	{Group: "org.apache.logging.log4j", Artifact: "log4j-core", Fixed: "2.3.1",
+		Advisories: []string{"CVE-2021-44228", "CVE-2021-45046"},
+		Class:      "org/apache/logging/log4j/core/lookup/JndiLookup.class"},
+	{Group: "org.apache.logging.log4j", Artifact: "log4j-core", Introduced: "2.4", Fixed: "2.12.2",
+		Advisories: []string{"CVE-2021-44228", "CVE-2021-45046"},
+		Class:      "org/apache/logging/log4j/core/lookup/JndiLookup.class"},
+	{Group: "org.apache.logging.log4j", Artifact: "log4j-core", Introduced: "2.13", Fixed: "2.16.0",
+		Advisories: []string{"CVE-2021-44228", "CVE-2021-45046"},
+		Class:      "org/apache/logging/log4j/core/lookup/JndiLookup.class"},
+	{Group: "org.apache.logging.log4j", Artifact: "log4j-core", Fixed: "2.3.1", Advisories: []string{"CVE-2021-45105"}},
+	{Group: "org.apache.logging.log4j", Artifact: "log4j-core", Introduced: "2.4", Fixed: "2.12.3", Advisories: []string{"CVE-2021-45105"}},
+	{Group: "org.apache.logging.log4j", Artifact: "log4j-core", Introduced: "2.13", Fixed: "2.17.0", Advisories: []string{"CVE-2021-45105"}},
+	{Group: "org.apache.logging.log4j", Artifact: "log4j-core", Fixed: "2.3.2", Advisories: []string{"CVE-2021-44832"}},
+	{Group: "org.apache.logging.log4j", Artifact: "log4j-core", Introduced: "2.4", Fixed: "2.12.4", Advisories: []string{"CVE-2021-44832"}},
+	{Group: "org.apache.logging.log4j", Artifact: "log4j-core", Introduced: "2.13", Fixed: "2.17.1", Advisories: []string{"CVE-2021-44832"}},
+	{Group: "log4j", Artifact: "log4j", Advisories: []string{"CVE-2019-17571", "CVE-2021-4104", "CVE-2022-23305"}},
+	{Group: "org.apache.struts", Artifact: "struts2-core", Fixed: "2.3.32", Advisories: []string{"CVE-2017-5638"}},
+	{Group: "org.apache.struts", Artifact: "struts2-core", Introduced: "2.5", Fixed: "2.5.10.1", Advisories: []string{"CVE-2017-5638"}},
+	{Group: "org.apache.struts", Artifact: "struts2-core", Fixed: "2.5.33", Advisories: []string{"CVE-2023-50164"}},
+	{Group: "org.apache.struts", Artifact: "struts2-core", Introduced: "6.0.0", Fixed: "6.3.0.2", Advisories: []string{"CVE-2023-50164"}},
+	{Group: "org.springframework", Artifact: "spring-beans", Fixed: "5.2.20", Advisories: []string{"CVE-2022-22965"}},
+	{Group: "org.springframework", Artifact: "spring-beans", Introduced: "5.3.0", Fixed: "5.3.18", Advisories: []string{"CVE-2022-22965"}},
+	{Group: "org.apache.commons", Artifact: "commons-text", Introduced: "1.5", Fixed: "1.10.0", Advisories: []string{"CVE-2022-42889"}},
+	{Group: "commons-collections", Artifact: "commons-collections", Fixed: "3.2.2", Advisories: []string{"CVE-2015-7501"}},
+}
+
+// loadLibraryRules reads the library rules of a JSON file, which are used in
+// addition to the built-in rules. An empty path yields the built-in rules.
+func loadLibraryRules(path string) ([]LibraryRule, error) {
+	if path == "" {
+		return defaultLibraryRules, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vulnerable libraries %s: %v", path, err)
+	}
+	var rules []LibraryRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse vulnerable libraries %s: %v", path, err)
+	}
+	for i, rule := range rules {
+		if rule.Artifact == "" || len(rule.Advisories) == 0 {
+			return nil, fmt.Errorf("library %d of %s requires artifact and advisories", i+1, path)
+		}
+	}
+	return append(rules, defaultLibraryRules...), nil
+}
+
+// archiveName splits the file name of a Maven artifact into artifact and version
+var archiveName = regexp.MustCompile(`^(.+?)-(\d[\w.+-]*?)\.(?:jar|war|ear)$`)
+
+// isLibraryArchive reports whether a file is a Java archive the library scan reads
+func isLibraryArchive(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jar", ".war", ".ear":
+		return true
+	}
+	return false
+}
+
+// libraryScanner fingerprints the Java archives found by the walk
+type libraryScanner struct {
+	rules []LibraryRule
+
+	mu       sync.Mutex // the walk workers inspect archives concurrently
+	findings []LibraryFinding
+}
+
+// inspect fingerprints an archive by its file name, the Maven metadata it
+// contains and the names of the libraries nested in WEB-INF/lib, BOOT-INF/lib
+// or lib of an application archive. Unreadable archives are skipped.
+func (s *libraryScanner) inspect(file string) {
+	name := path.Base(strings.ReplaceAll(file, `\`, "/"))
+	archive, err := zip.OpenReader(file)
+	if err != nil {
+		if m := archiveName.FindStringSubmatch(name); m != nil {
+			s.check(file, "", "", m[1], m[2], nil)
+		}
+		return
+	}
+	defer archive.Close()
+
+	// The Maven metadata identifies renamed and repackaged libraries
+	found := false
+	for _, entry := range archive.File {
+		if !strings.HasPrefix(entry.Name, "META-INF/maven/") || path.Base(entry.Name) != "pom.properties" {
+			continue
+		}
+		props := readPomProperties(entry)
+		if props["artifactId"] != "" && props["version"] != "" {
+			s.check(file, "", props["groupId"], props["artifactId"], props["version"], archive)
+			found = true
+		}
+	}
+	if m := archiveName.FindStringSubmatch(name); m != nil && !found {
+		s.check(file, "", "", m[1], m[2], archive)
+	}
+
+	for _, entry := range archive.File {
+		dir, base := path.Split(entry.Name)
+		if dir != "WEB-INF/lib/" && dir != "BOOT-INF/lib/" && dir != "lib/" {
+			continue
+		}
+		if m := archiveName.FindStringSubmatch(base); m != nil {
+			s.check(file, entry.Name, "", m[1], m[2], nil)
+		}
+	}
+}
+
+// check records a finding if a library version matches the rules. The archive
+// is searched for the vulnerable class if it is the library itself.
+func (s *libraryScanner) check(file, entry, group, artifact, version string, archive *zip.ReadCloser) {
+	var finding *LibraryFinding
+	for _, rule := range s.rules {
+		if !rule.matches(group, artifact, version) {
+			continue
+		}
+		if finding == nil {
+			finding = &LibraryFinding{Path: file, Entry: entry, Group: group, Artifact: artifact, Version: version}
+			if finding.Group == "" {
+				finding.Group = rule.Group
+			}
+		}
+		for _, id := range rule.Advisories {
+			if !slices.Contains(finding.Advisories, id) {
+				finding.Advisories = append(finding.Advisories, id)
+			}
+		}
+		if rule.Fixed != "" && compareLibraryVersions(rule.Fixed, finding.Fixed) > 0 {
+			finding.Fixed = rule.Fixed
+		}
+		if rule.Class != "" && archive != nil && !slices.ContainsFunc(archive.File, func(f *zip.File) bool { return f.Name == rule.Class }) {
+			for _, id := range rule.Advisories {
+				if !slices.Contains(finding.MitigatedAdvisories, id) {
+					finding.MitigatedAdvisories = append(finding.MitigatedAdvisories, id)
+				}
+			}
+		}
+	}
+	if finding == nil {
+		return
+	}
+	finding.Mitigated = len(finding.openAdvisories()) == 0
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.findings {
+		if existing.Path == file && existing.Entry == entry && existing.Artifact == artifact && existing.Version == version {
+			return
+		}
+	}
+	s.findings = append(s.findings, *finding)
+}
+
+// matches reports whether a library version is vulnerable according to the rule
+func (r *LibraryRule) matches(group, artifact, version string) bool {
+	if r.Artifact != artifact || (group != "" && r.Group != "" && r.Group != group) {
+		return false
+	}
+	if r.Introduced != "" && compareLibraryVersions(version, r.Introduced) < 0 {
+		return false
+	}
+	return r.Fixed == "" || compareLibraryVersions(version, r.Fixed) < 0
+}
+
+// readPomProperties reads the key=value pairs of a pom.properties entry
+func readPomProperties(entry *zip.File) map[string]string {
+	props := make(map[string]string)
+	reader, err := entry.Open()
+	if err != nil {
+		return props
+	}
+	defer reader.Close()
+	scanner := bufio.NewScanner(io.LimitReader(reader, 64*1024))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if key, value, ok := strings.Cut(line, "="); ok && !strings.HasPrefix(line, "#") {
+			props[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return props
+}
+
+// compareLibraryVersions orders Maven versions by their numeric parts. A
+// qualifier such as beta1 or rc2 sorts before the release it precedes, and an
+// empty version before any other.
+func compareLibraryVersions(a, b string) int {
+	ta, tb := versionTokens(a), versionTokens(b)
+	for i := 0; i < len(ta) || i < len(tb); i++ {
+		switch {
+		case i >= len(ta):
+			return qualifierOrder(tb[i])
+		case i >= len(tb):
+			return -qualifierOrder(ta[i])
+		}
+		na, errA := strconv.Atoi(ta[i])
+		nb, errB := strconv.Atoi(tb[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return cmp.Compare(na, nb)
+			}
+		case errA == nil:
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if c := strings.Compare(strings.ToLower(ta[i]), strings.ToLower(tb[i])); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// qualifierOrder is -1 if a version ending with the token sorts before the one without it
+func qualifierOrder(token string) int {
+	if _, err := strconv.Atoi(token); err == nil {
+		return -1
+	}
+	if strings.EqualFold(token, "sp") {
+		return -1
+	}
+	return 1
+}
+
+// versionTokens splits a version into runs of digits and of letters, dropping
+// the qualifiers that mark a release, such as 5.3.18.RELEASE
+func versionTokens(version string) []string {
+	var tokens []string
+	current := ""
+	flush := func() {
+		switch strings.ToLower(current) {
+		case "", "final", "ga", "release":
+		default:
+			tokens = append(tokens, current)
+		}
+		current = ""
+	}
+	for _, r := range version {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if current != "" && unicode.IsDigit(r) != unicode.IsDigit(rune(current[len(current)-1])) {
+			flush()
+		}
+		current += string(r)
+	}
+	flush()
+	return tokens
+}
+
+// location describes where a vulnerable library was found
+func (f *LibraryFinding) location() string {
+	if f.Entry != "" {
+		return f.Path + "!/" + f.Entry
+	}
+	return f.Path
+}
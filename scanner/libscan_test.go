@@ -0,0 +1,116 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompareLibraryVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.14.1", "2.17.1", -1},
+		{"2.17.1", "2.17.1", 0},
+		{"2.17.10", "2.17.9", 1},
+		{"2.17.1-rc1", "2.17.1", -1},
+		{"2.0-beta9", "2.0", -1},
+		{"2.0-beta9", "2.3.2", -1},
+		{"6.3.0.2", "6.3.0", 1},
+		{"5.3.18.RELEASE", "5.3.18", 0},
+		{"1.10.0", "", 1},
+	}
+	for _, tt := range tests {
+		if got := compareLibraryVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareLibraryVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLibraryScan(t *testing.T) {
+	dir := t.TempDir()
+	jndi := "org/apache/logging/log4j/core/lookup/JndiLookup.class"
+	writeTestJar(t, filepath.Join(dir, "app", "lib", "log4j-core-2.14.1.jar"), map[string]string{jndi: ""})
+	writeTestJar(t, filepath.Join(dir, "patched", "lib", "log4j-core-2.14.1.jar"), map[string]string{"META-INF/MANIFEST.MF": ""})
+	writeTestJar(t, filepath.Join(dir, "app", "lib", "log4j-core-2.17.1.jar"), map[string]string{jndi: ""})
+	writeTestJar(t, filepath.Join(dir, "app", "lib", "logging.jar"), map[string]string{
+		"META-INF/maven/org.apache.logging.log4j/log4j-core/pom.properties": "#Created by Maven\ngroupId=org.apache.logging.log4j\nartifactId=log4j-core\nversion=2.12.1\n",
+		jndi: "",
+	})
+	writeTestJar(t, filepath.Join(dir, "webapps", "shop.war"), map[string]string{
+		"WEB-INF/lib/struts2-core-2.5.30.jar": "",
+		"WEB-INF/lib/commons-text-1.10.0.jar": "",
+	})
+	writeTestFile(t, filepath.Join(dir, "broken", "commons-collections-3.2.1.jar"), "not a zip")
+
+	finder := NewJavaFinder(dir, -1, false, false)
+	finder.libraries = &libraryScanner{rules: defaultLibraryRules}
+	if _, err := finder.Find(); err != nil {
+		t.Fatal(err)
+	}
+	log4jCVEs := []string{"CVE-2021-44228", "CVE-2021-45046", "CVE-2021-45105", "CVE-2021-44832"}
+	want := []LibraryFinding{
+		{Path: filepath.Join(dir, "app", "lib", "log4j-core-2.14.1.jar"), Group: "org.apache.logging.log4j", Artifact: "log4j-core",
+			Version: "2.14.1", Fixed: "2.17.1", Advisories: log4jCVEs},
+		{Path: filepath.Join(dir, "app", "lib", "logging.jar"), Group: "org.apache.logging.log4j", Artifact: "log4j-core",
+			Version: "2.12.1", Fixed: "2.12.4", Advisories: log4jCVEs},
+		{Path: filepath.Join(dir, "broken", "commons-collections-3.2.1.jar"), Group: "commons-collections", Artifact: "commons-collections",
+			Version: "3.2.1", Fixed: "3.2.2", Advisories: []string{"CVE-2015-7501"}},
+		{Path: filepath.Join(dir, "patched", "lib", "log4j-core-2.14.1.jar"), Group: "org.apache.logging.log4j", Artifact: "log4j-core",
+			Version: "2.14.1", Fixed: "2.17.1", Advisories: log4jCVEs, MitigatedAdvisories: log4jCVEs[:2]},
+		{Path: filepath.Join(dir, "webapps", "shop.war"), Entry: "WEB-INF/lib/struts2-core-2.5.30.jar", Group: "org.apache.struts",
+			Artifact: "struts2-core", Version: "2.5.30", Fixed: "2.5.33", Advisories: []string{"CVE-2023-50164"}},
+	}
+	if got := finder.libraries.findings; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected\n%+v\ngot\n%+v", want, got)
+	}
+}
+
+func TestLoadLibraryRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "libraries.json")
+	writeTestFile(t, path, `[{"group": "com.fasterxml.jackson.core", "artifact": "jackson-databind", "fixed": "2.9.10.8", "advisories": ["CVE-2020-36518"]}]`)
+	rules, err := loadLibraryRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != len(defaultLibraryRules)+1 || !rules[0].matches("", "jackson-databind", "2.9.10") {
+		t.Errorf("Expected the rules of the file and the built-in rules, got %+v", rules)
+	}
+
+	writeTestFile(t, path, `[{"artifact": "jackson-databind"}]`)
+	if _, err := loadLibraryRules(path); err == nil {
+		t.Error("Expected an error for a rule without advisories")
+	}
+}
+
+func TestLibraryRulesLog4jMitigation(t *testing.T) {
+	dir := t.TempDir()
+	// 2.12.2 fixed Log4Shell but not CVE-2021-45105 and CVE-2021-44832
+	writeTestJar(t, filepath.Join(dir, "lib", "log4j-core-2.12.2.jar"), map[string]string{"META-INF/MANIFEST.MF": ""})
+	writeTestJar(t, filepath.Join(dir, "lib", "log4j-core-2.16.0.jar"), map[string]string{"META-INF/MANIFEST.MF": ""})
+	writeTestJar(t, filepath.Join(dir, "lib", "struts2-core-2.5.10.jar"), map[string]string{"META-INF/MANIFEST.MF": ""})
+
+	finder := NewJavaFinder(dir, -1, false, false)
+	finder.libraries = &libraryScanner{rules: defaultLibraryRules}
+	if _, err := finder.Find(); err != nil {
+		t.Fatal(err)
+	}
+	want := []LibraryFinding{
+		{Path: filepath.Join(dir, "lib", "log4j-core-2.12.2.jar"), Group: "org.apache.logging.log4j", Artifact: "log4j-core",
+			Version: "2.12.2", Fixed: "2.12.4", Advisories: []string{"CVE-2021-45105", "CVE-2021-44832"}},
+		{Path: filepath.Join(dir, "lib", "log4j-core-2.16.0.jar"), Group: "org.apache.logging.log4j", Artifact: "log4j-core",
+			Version: "2.16.0", Fixed: "2.17.1", Advisories: []string{"CVE-2021-45105", "CVE-2021-44832"}},
+		{Path: filepath.Join(dir, "lib", "struts2-core-2.5.10.jar"), Group: "org.apache.struts", Artifact: "struts2-core",
+			Version: "2.5.10", Fixed: "2.5.33", Advisories: []string{"CVE-2017-5638", "CVE-2023-50164"}},
+	}
+	if got := finder.libraries.findings; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected\n%+v\ngot\n%+v", want, got)
+	}
+
+	// Only all advisories mitigated make the finding mitigated
+	patched := LibraryFinding{Advisories: []string{"CVE-2021-44228", "CVE-2021-45046"}, MitigatedAdvisories: []string{"CVE-2021-44228", "CVE-2021-45046"}}
+	if open := patched.openAdvisories(); len(open) != 0 {
+		t.Errorf("Expected no open advisories, got %v", open)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// cgroupRoot is where the cgroup file systems are mounted
+const cgroupRoot = "/sys/fs/cgroup"
+
+// errBufferFull stops a scan when the buffered results reach the memory limit
+var errBufferFull = errors.New("result buffer full")
+
+// errEnoughResults stops a scan when it found the results of -max-results
+var errEnoughResults = errors.New("enough results")
+
+// ResourceLimits reports the limits jfind detected and applied to itself
+type ResourceLimits struct {
+	CPUs        float64 `json:"cpus,omitempty"`         // CPU quota of the cgroup in cores
+	MemoryBytes int64   `json:"memory_bytes,omitempty"` // memory limit of the cgroup
+	MaxMemory   int64   `json:"max_memory,omitempty"`   // -max-memory
+	GoMaxProcs  int     `json:"gomaxprocs"`
+}
+
+// detectCgroupLimits reads the CPU and memory limits of the cgroup jfind runs
+// in, supporting cgroup v2 and v1. Zero means unlimited or unknown.
+func detectCgroupLimits() (cpus float64, memory int64) {
+	if runtime.GOOS != "linux" {
+		return 0, 0
+	}
+	v2Dir, v1Dirs := selfCgroupPaths("/proc/self/cgroup")
+
+	for _, dir := range []string{filepath.Join(cgroupRoot, v2Dir), cgroupRoot} {
+		if data, err := os.ReadFile(filepath.Join(dir, "cpu.max")); err == nil && cpus == 0 {
+			cpus = parseCPUMax(string(data))
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "memory.max")); err == nil && memory == 0 {
+			memory = parseMemoryLimit(string(data))
+		}
+	}
+
+	if cpus == 0 {
+		for _, dir := range []string{filepath.Join(cgroupRoot, "cpu", v1Dirs["cpu"]), filepath.Join(cgroupRoot, "cpu")} {
+			quota, err1 := readInt(filepath.Join(dir, "cpu.cfs_quota_us"))
+			period, err2 := readInt(filepath.Join(dir, "cpu.cfs_period_us"))
+			if err1 == nil && err2 == nil && quota > 0 && period > 0 {
+				cpus = float64(quota) / float64(period)
+				break
+			}
+		}
+	}
+	if memory == 0 {
+		for _, dir := range []string{filepath.Join(cgroupRoot, "memory", v1Dirs["memory"]), filepath.Join(cgroupRoot, "memory")} {
+			if data, err := os.ReadFile(filepath.Join(dir, "memory.limit_in_bytes")); err == nil {
+				if memory = parseMemoryLimit(string(data)); memory > 0 {
+					break
+				}
+			}
+		}
+	}
+	return cpus, memory
+}
+
+// selfCgroupPaths parses /proc/self/cgroup and returns the cgroup v2 path and
+// the cgroup v1 paths per controller
+func selfCgroupPaths(path string) (string, map[string]string) {
+	v1 := make(map[string]string)
+	file, err := os.Open(path)
+	if err != nil {
+		return "", v1
+	}
+	defer file.Close()
+
+	v2 := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			v2 = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			v1[controller] = parts[2]
+		}
+	}
+	return v2, v1
+}
+
+// parseCPUMax parses the cgroup v2 cpu.max file, e.g. "50000 100000" for half a core
+func parseCPUMax(content string) float64 {
+	fields := strings.Fields(content)
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0
+	}
+	quota, err1 := strconv.ParseFloat(fields[0], 64)
+	period, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil || period <= 0 {
+		return 0
+	}
+	return quota / period
+}
+
+// parseMemoryLimit parses memory.max (v2) or memory.limit_in_bytes (v1). cgroup
+// v1 reports "unlimited" as a huge page aligned number.
+func parseMemoryLimit(content string) int64 {
+	value, err := strconv.ParseInt(strings.TrimSpace(content), 10, 64)
+	if err != nil || value <= 0 || value >= math.MaxInt64/2 {
+		return 0
+	}
+	return value
+}
+
+// readInt reads a file containing a single integer
+func readInt(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// parseByteSize parses sizes like 512M, 2G or 1048576
+func parseByteSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30} {
+		if trimmed, ok := strings.CutSuffix(strings.TrimSuffix(s, "B"), suffix); ok {
+			s, multiplier = trimmed, m
+			break
+		}
+	}
+	value, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 512M or 2G)", size)
+	}
+	return value * multiplier, nil
+}
+
+// applyResourceLimits adapts the Go runtime to the cgroup limits and -max-memory:
+// GOMAXPROCS follows the CPU quota, and the garbage collector starts working hard
+// before the memory limit is reached instead of getting the process OOM-killed
+func applyResourceLimits(maxMemory int64) ResourceLimits {
+	cpus, memory := detectCgroupLimits()
+	limits := ResourceLimits{CPUs: cpus, MemoryBytes: memory, MaxMemory: maxMemory}
+
+	if cpus > 0 {
+		procs := max(1, int(math.Ceil(cpus)))
+		if procs < runtime.GOMAXPROCS(0) {
+			runtime.GOMAXPROCS(procs)
+		}
+	}
+	limits.GoMaxProcs = runtime.GOMAXPROCS(0)
+
+	soft := memory
+	if maxMemory > 0 && (soft == 0 || maxMemory < soft) {
+		soft = maxMemory
+	}
+	if soft > 0 {
+		// Leave headroom for memory not managed by the Go runtime
+		debug.SetMemoryLimit(soft / 10 * 8)
+	}
+	return limits
+}
+
+// resultSize estimates the memory held by a buffered result
+func resultSize(result *JavaResult) int64 {
+	size := int64(512 + len(result.Path) + len(result.StdErr) + result.Output.size())
+	for _, s := range result.Modules {
+		size += int64(len(s))
+	}
+	for key, value := range result.Release {
+		size += int64(len(key) + len(value))
+	}
+	return size
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseCgroupLimits(t *testing.T) {
+	if cpus := parseCPUMax("50000 100000\n"); cpus != 0.5 {
+		t.Errorf("Expected 0.5 CPUs, got %v", cpus)
+	}
+	if cpus := parseCPUMax("max 100000\n"); cpus != 0 {
+		t.Errorf("Expected unlimited CPUs, got %v", cpus)
+	}
+	if memory := parseMemoryLimit("268435456\n"); memory != 256<<20 {
+		t.Errorf("Expected 256M, got %d", memory)
+	}
+	for _, unlimited := range []string{"max\n", "9223372036854771712\n"} {
+		if memory := parseMemoryLimit(unlimited); memory != 0 {
+			t.Errorf("Expected unlimited memory for %q, got %d", unlimited, memory)
+		}
+	}
+}
+
+func TestSelfCgroupPaths(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cgroup")
+	content := "12:cpu,cpuacct:/kubepods/pod1\n4:memory:/kubepods/pod1\n0::/kubepods.slice/pod1\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	v2, v1 := selfCgroupPaths(path)
+	if v2 != "/kubepods.slice/pod1" {
+		t.Errorf("Expected cgroup v2 path, got %q", v2)
+	}
+	if v1["cpu"] != "/kubepods/pod1" || v1["memory"] != "/kubepods/pod1" {
+		t.Errorf("Expected cgroup v1 paths, got %v", v1)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{"1024": 1024, "512M": 512 << 20, "2g": 2 << 30, "64KB": 64 << 10}
+	for s, want := range tests {
+		if got, err := parseByteSize(s); err != nil || got != want {
+			t.Errorf("Expected %d for %s, got %d (%v)", want, s, got, err)
+		}
+	}
+	for _, invalid := range []string{"", "M", "-1G", "lots"} {
+		if _, err := parseByteSize(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestFindMaxResultBytes(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 10; i++ {
+		createFakeJava(t, filepath.Join(root, fmt.Sprintf("jdk-%d", i)))
+	}
+
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.maxResultBytes = 3000
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !finder.truncated || len(results) == 0 || len(results) >= 10 {
+		t.Errorf("Expected truncated results, got %d (truncated %v)", len(results), finder.truncated)
+	}
+}
+
+func TestFindMaxResults(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 10; i++ {
+		createFakeJava(t, filepath.Join(root, fmt.Sprintf("jdk-%d", i)))
+	}
+
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.maxResults = 3
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 3 || !finder.limited {
+		t.Errorf("Expected 3 results, got %d (limited %v)", len(results), finder.limited)
+	}
+	if output := buildJSONOutput(results, finder, time.Now()); output.Meta.ResultLimit != 3 {
+		t.Errorf("Expected result_limit 3, got %d", output.Meta.ResultLimit)
+	}
+
+	// Results below -min-confidence do not count
+	finder.minConfidence = 101
+	if results, err = finder.Find(); err != nil || len(results) != 10 || finder.limited {
+		t.Errorf("Expected all 10 results, got %d (limited %v, %v)", len(results), finder.limited, err)
+	}
+}
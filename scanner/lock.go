@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// lockPollInterval is how often a scan waiting for the lock tries again
+const lockPollInterval = 250 * time.Millisecond
+
+// errLockHeld is returned by tryLockFile if another process holds the lock
+var errLockHeld = errors.New("lock held by another process")
+
+// lockHolder identifies the scan holding the lock, it is written to the lock file
+type lockHolder struct {
+	PID     int    `json:"pid"`
+	Started string `json:"started"`
+	Command string `json:"command"`
+}
+
+// ScanLockedError is returned if another scan holds the lock
+type ScanLockedError struct {
+	Path   string
+	Holder *lockHolder // nil if the lock file could not be read
+}
+
+func (e *ScanLockedError) Error() string {
+	if e.Holder == nil {
+		return fmt.Sprintf("another scan holds the lock %s", e.Path)
+	}
+	return fmt.Sprintf("another scan is running (pid %d, started %s), it holds the lock %s", e.Holder.PID, e.Holder.Started, e.Path)
+}
+
+// scanLock keeps other scans from running at the same time. It is an advisory
+// lock of the operating system on the lock file, so it is released when jfind
+// exits, also if it crashes; a lock file left behind is not stale by itself.
+type scanLock struct {
+	file *os.File
+	// Stale is the holder a crashed scan left in the lock file, nil if none
+	Stale *lockHolder
+}
+
+// defaultLockFile returns the lock file shared by the scans of a host. It is
+// in a directory only root or the administrators can create files in, see
+// systemLockDir, so no other user can take the lock before their scans. The
+// scans of other users cannot open that file and lock one of their own.
+func defaultLockFile() string {
+	if isElevated() {
+		return filepath.Join(systemLockDir(), "jfind.lock")
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "jfind", "jfind.lock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("jfind-%d.lock", os.Getuid()))
+}
+
+// acquireScanLock takes the lock, waiting up to wait for another scan to finish
+func acquireScanLock(path string, wait time.Duration) (*scanLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of the lock file: %w", err)
+	}
+	file, err := openLockFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		err = tryLockFile(file)
+		if err != errLockHeld || !time.Now().Before(deadline) {
+			break
+		}
+		time.Sleep(min(lockPollInterval, time.Until(deadline)))
+	}
+	if err == errLockHeld {
+		holder := readLockHolder(file)
+		file.Close()
+		return nil, &ScanLockedError{Path: path, Holder: holder}
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	lock := &scanLock{file: file, Stale: readLockHolder(file)}
+	data, _ := json.Marshal(lockHolder{
+		PID:     os.Getpid(),
+		Started: time.Now().UTC().Format(time.RFC3339),
+		Command: strings.Join(os.Args, " "),
+	})
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt(append(data, '\n'), 0)
+	}
+	return lock, nil
+}
+
+// readLockHolder reads the holder written to a lock file, nil if there is none
+func readLockHolder(file *os.File) *lockHolder {
+	data := make([]byte, 4096)
+	n, _ := file.ReadAt(data, 0)
+	var holder lockHolder
+	if n == 0 || json.Unmarshal(data[:n], &holder) != nil || holder.PID == 0 {
+		return nil
+	}
+	return &holder
+}
+
+// release empties the lock file and releases the lock. The file is kept, as
+// removing it could let a waiting scan lock a file that is gone.
+func (l *scanLock) release() error {
+	if l == nil {
+		return nil
+	}
+	l.file.Truncate(0)
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
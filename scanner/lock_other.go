@@ -0,0 +1,51 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// systemLockDir is the directory of the lock file of root's scans, below a
+// directory only root can create files in
+func systemLockDir() string {
+	if runtime.GOOS == "linux" {
+		return "/run/jfind"
+	}
+	return "/var/run/jfind"
+}
+
+// openLockFile opens or creates a lock file without following a symbolic
+// link, and refuses a file that another user created for jfind to write to
+func openLockFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|syscall.O_NOFOLLOW, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); !info.Mode().IsRegular() || ok && int(stat.Uid) != os.Geteuid() {
+		file.Close()
+		return nil, fmt.Errorf("%s is not a file owned by this user", path)
+	}
+	return file, nil
+}
+
+// tryLockFile takes an exclusive flock on file without blocking
+func tryLockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return errLockHeld
+	}
+	return err
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestScanLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jfind.lock")
+	lock, err := acquireScanLock(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock.Stale != nil {
+		t.Errorf("Expected no stale holder in a new lock file, got %+v", lock.Stale)
+	}
+
+	_, err = acquireScanLock(path, 0)
+	var locked *ScanLockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("Expected the lock to be held, got %v", err)
+	}
+	if locked.Holder == nil || locked.Holder.PID != os.Getpid() {
+		t.Errorf("Expected the holder to be this process, got %+v", locked.Holder)
+	}
+
+	// A waiting scan gets the lock once the running one releases it
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		lock.release()
+	}()
+	second, err := acquireScanLock(path, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Expected to get the lock after waiting, got %v", err)
+	}
+	if second.Stale != nil {
+		t.Errorf("Expected a released lock not to be stale, got %+v", second.Stale)
+	}
+	if err := second.release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanLockStale(t *testing.T) {
+	// A scan that crashed leaves its holder behind, but not the lock
+	path := filepath.Join(t.TempDir(), "jfind.lock")
+	writeTestFile(t, path, `{"pid":4242,"started":"2025-03-01T02:00:00Z","command":"jfind -path /"}`+"\n")
+	lock, err := acquireScanLock(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.release()
+	if lock.Stale == nil || lock.Stale.PID != 4242 {
+		t.Errorf("Expected the holder of the crashed scan, got %+v", lock.Stale)
+	}
+	if holder := readLockHolder(lock.file); holder == nil || holder.PID != os.Getpid() {
+		t.Errorf("Expected the lock file to name this process, got %+v", holder)
+	}
+}
+
+func TestScanLockRefusesLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires a privilege on Windows")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "passwd")
+	writeTestFile(t, target, "root:x:0:0::/root:/bin/sh\n")
+	path := filepath.Join(dir, "jfind.lock")
+	if err := os.Symlink(target, path); err != nil {
+		t.Fatal(err)
+	}
+	if lock, err := acquireScanLock(path, 0); err == nil {
+		lock.release()
+		t.Fatal("Expected a lock file that is a symbolic link to be refused")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "root:x:0:0::/root:/bin/sh\n" {
+		t.Errorf("Expected the target of the link to be left alone, got %q", data)
+	}
+}
+
+func TestDefaultLockFile(t *testing.T) {
+	path := defaultLockFile()
+	if isElevated() {
+		if filepath.Dir(path) != systemLockDir() {
+			t.Errorf("Expected the lock file of an administrator in %s, got %s", systemLockDir(), path)
+		}
+	} else if filepath.Dir(path) == os.TempDir() && filepath.Base(path) == "jfind.lock" {
+		t.Errorf("Expected a lock file of the user, got the shared %s", path)
+	}
+}
@@ -0,0 +1,118 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	lockfileExclusiveLock   = 0x2
+	lockfileFailImmediately = 0x1
+	errorLockViolation      = syscall.Errno(33)
+)
+
+const (
+	seFileObject             = 1
+	ownerSecurityInformation = 0x1
+)
+
+var (
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+var procGetSecurityInfo = advapi32.NewProc("GetSecurityInfo")
+
+// administratorOwners are the owners of the files an administrator creates:
+// the Administrators group or, for a service, LocalSystem
+var administratorOwners = []string{"S-1-5-32-544", "S-1-5-18"}
+
+// systemLockDir is the directory of the lock file of the administrators' scans
+func systemLockDir() string {
+	dir := os.Getenv("ProgramData")
+	if dir == "" {
+		dir = `C:\ProgramData`
+	}
+	return filepath.Join(dir, "jfind")
+}
+
+// openLockFile opens or creates a lock file, and refuses a link or a file that
+// another user created for jfind to write to
+func openLockFile(path string) (*os.File, error) {
+	if info, err := os.Lstat(path); err == nil && !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a file", path)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := fileOwner(file)
+	if err == nil {
+		var user string
+		if user, err = currentUserSID(); err == nil && owner != user && !(isElevated() && slices.Contains(administratorOwners, owner)) {
+			err = fmt.Errorf("%s is owned by %s, not by this user", path, owner)
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// fileOwner returns the SID of the owner of an open file
+func fileOwner(file *os.File) (string, error) {
+	var owner *syscall.SID
+	var descriptor syscall.Handle
+	r, _, _ := procGetSecurityInfo.Call(file.Fd(), seFileObject, ownerSecurityInformation, uintptr(unsafe.Pointer(&owner)), 0, 0, 0, uintptr(unsafe.Pointer(&descriptor)))
+	if r != 0 {
+		return "", syscall.Errno(r)
+	}
+	defer syscall.LocalFree(descriptor)
+	return owner.String()
+}
+
+// currentUserSID returns the SID of the user jfind runs as
+func currentUserSID() (string, error) {
+	token, err := syscall.OpenCurrentProcessToken()
+	if err != nil {
+		return "", err
+	}
+	defer token.Close()
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	return user.User.Sid.String()
+}
+
+// lockRange is the byte range locked, beyond the holder written to the file:
+// a locked range cannot be read by other processes
+func lockRange() *syscall.Overlapped {
+	return &syscall.Overlapped{OffsetHigh: 1}
+}
+
+// tryLockFile locks file exclusively with LockFileEx without blocking
+func tryLockFile(file *os.File) error {
+	r, _, err := procLockFileEx.Call(file.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(lockRange())))
+	if r != 0 {
+		return nil
+	}
+	if err == errorLockViolation {
+		return errLockHeld
+	}
+	return err
+}
+
+func unlockFile(file *os.File) error {
+	if r, _, err := procUnlockFileEx.Call(file.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(lockRange()))); r == 0 {
+		return err
+	}
+	return nil
+}
@@ -2,18 +2,24 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"maps"
 	"net"
 	"net/http"
 	"os"
-	"os/exec"
+	"os/signal"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -23,38 +29,276 @@ const (
 
 // JavaFinder represents a finder for Java executables
 type JavaFinder struct {
-	startPath string
-	maxDepth  int // -1 means unlimited
+	startPath string // the start path being walked, see roots
+	maxDepth  int    // -1 means unlimited
 	verbose   bool
 	evaluate  bool
 	scanned   int
+
+	// workers is the number of directories read concurrently, up to 1 walks sequentially
+	workers int
+
+	// exclude and prune are the directories not to walk, excluded counts those
+	// skipped
+	exclude  exclusions
+	prune    pruneSet
+	excluded int
+
+	// followSymlinks descends into symbolic links to directories, links tracks
+	// them during a walk
+	followSymlinks bool
+	links          *linkTracker
+
+	// skipNetworkMounts and oneFilesystem prune mount points in addition to
+	// the pseudo file systems, mounts selects them during a walk
+	skipNetworkMounts bool
+	oneFilesystem     bool
+	mounts            *mountPruner
+
+	// checkpoint saves the progress of the walk and holds that of the scan it
+	// resumes, nil unless -checkpoint or -resume is used
+	checkpoint *scanCheckpoint
+
+	// baseline is the previous scan an incremental scan starts from (-baseline)
+	baseline *scanBaseline
+
+	// roots are the start paths of a scan of several, walked one after the other,
+	// rootStats the directories scanned below each. rootDepths are the maximum
+	// depths of start paths given with their own, e.g. -path /opt:4.
+	roots      []string
+	rootStats  []RootStats
+	rootDepths map[string]int
+
+	classifier *pathClassifier
+	evalCmd    *evalCommand
+	index      candidateSource
+	discovery  string
+	budgets    map[string]time.Duration
+	timings    []SourceTiming
+
+	// containerStorage are the directories of container engines, deferred
+	// those the walk left for the containers budget
+	containerStorage []string
+	deferred         *deferredDirs
+
+	// skipVirtualFS prunes kernel pseudo file systems, e.g. /proc of a
+	// host mounted into the container jfind runs in
+	skipVirtualFS bool
+	environment   string
+
+	checkModules    bool
+	requiredModules []string
+
+	enrichers []Enricher
+	trace     *tracer
+	history   *runtimeHistory
+	scanID    string
+	shard     *shard
+
+	// noExec evaluates runtimes from their release file instead of running them,
+	// throttle spaces out the probes that are run
+	noExec     bool
+	throttle   *probeThrottle
+	evalMode   string
+	realtimeAV string
+
+	// trust restricts execution to trusted locations, nil runs every runtime
+	trust *evalTrust
+
+	// share is the remote share scanned instead of a local path, bandwidth limits
+	// the estimated bytes read from it per second
+	share     *remoteShare
+	bandwidth *bandwidthLimiter
+
+	// remoteHost is the host evaluated over SSH by remote-eval, whose runtimes these are
+	remoteHost string
+
+	// sys is the clock, file system, process execution and host name lookup, see SetSystem
+	sys System
+
+	// failures are the paths whose processing panicked, shared by the workers
+	failures *scanErrors
+
+	// progress counts the walk for the -progress line, nil without it
+	progress *scanProgress
+
+	// captureBytes keeps up to this many bytes of the raw probe output, 0 disables it
+	captureBytes int
+
+	// background is the OS priority reduction in effect, see enterBackgroundMode
+	background string
+
+	// daemons are the build daemons running on this host, if -daemons was used
+	daemons []Daemon
+
+	// agents are the Java agents configured on this host, if -agents was used
+	agents []JavaAgent
+
+	// libraries fingerprints the Java archives passed by the walk, if -libs was used
+	libraries *libraryScanner
+
+	// appServers collects the application servers passed by the walk, if -app-servers was used
+	appServers *appServerScanner
+
+	// heapProbe reads the heap defaults and container support with -XX:+PrintFlagsFinal
+	heapProbe bool
+
+	// benchmarkRuns is how often java -version is timed per runtime, 0 disables the benchmark
+	benchmarkRuns int
+
+	// census is the bytecode census of the applications of the runtimes, if -bytecode was used
+	census []BytecodeCensus
+
+	// javaEnv is the -java-env mode, empty means redacted
+	javaEnv string
+
+	// acks are the acknowledged findings of this host, see -acks
+	acks []Acknowledgment
+
+	// replacements are the rules of the recommended replacements
+	replacements []ReplacementRule
+
+	// releases is the catalog of the newest release of each distribution
+	releases releaseCatalog
+
+	// costs estimate the subscription cost of the Oracle runtimes, nil if not configured
+	costs *LicenseCosts
+
+	// maxResultBytes limits the memory of the results buffered by Find, 0 is unlimited
+	maxResultBytes int64
+	truncated      bool
+	timedOut       bool // FindContext returned partial results at the deadline
+	limits         *ResourceLimits
+	usage          *usageMeter // resources used by the scan, measured from its start
+
+	// maxResults stops Find once this many results with at least minConfidence
+	// are found, 0 is unlimited
+	maxResults    int
+	minConfidence int
+	limited       bool // Find stopped at maxResults
+	walkFailed    bool // Find returned an error, the results are partial
+
+	// names are the file names looked for in addition to java, see -names and -name-pattern
+	names *nameMatcher
+
+	// embedded recognizes the private runtimes of applications by the
+	// heuristics of -embedded, beyond the installer wrappers
+	embedded bool
+
+	// archives looks into the zip files and tarballs passed by the walk for
+	// runtimes that were never extracted, see -scan-archives
+	archives bool
+
+	// quarantined are the java executables jfind serve made non-executable,
+	// which the walk keeps reporting, see isQuarantined
+	quarantined map[string]bool
+
+	// defaultRuntime is the default runtime of this host, detected once per
+	// scan before the reports are built, nil if not detected
+	defaultRuntime *DefaultRuntime
 }
 
 // JavaResult represents the result of evaluating a Java executable
 type JavaResult struct {
-	Path       string
-	Properties *JavaProperties
-	StdErr     string
-	ReturnCode int
-	Error      error
-	Evaluated  bool
+	Path             string
+	MatchedName      string // file name that matched -names or -name-pattern, if given
+	Properties       *JavaProperties
+	StdErr           string
+	ReturnCode       int
+	Error            error
+	Evaluated        bool
+	PathClass        PathClass
+	Provisioner      string
+	ViaSymlink       bool     // reached through a symbolic link to a directory (-follow-symlinks)
+	Aliases          []string // other paths that are symbolic links to the same executable
+	Paths            []string // all paths of the executable without links, if hard links or bind mounts make it more than one
+	Status           ProbeStatus
+	Binary           *binaryInfo
+	DependencyIssues []string
+	Modules          []string
+	CompatWarnings   []string
+	Release          map[string]string // key/value pairs of the release file, if any
+	Enrichments      map[string]any    // values added by enrichers, keyed by enricher name
+	Embedding        *Embedding        // application the runtime is bundled with, if any
+	Archive          string            // archive the runtime is packaged in, see -scan-archives
+	Replacement      *Replacement      // recommended replacement of an Oracle or end of life runtime
+	Latest           *LatestRelease    // newest release of the distribution of the runtime, if known
+	Startup          *StartupBenchmark // startup latency, if -benchmark-startup was used
+	Trust            *TrustDecision    // whether the location allowed running it, if trust rules are configured
+	Output           *ProbeOutput      // raw probe output, if captured
+	Confidence       int               // 0 to 100, see scoreConfidence
+	Evidence         []string          // evidence the confidence is based on
 }
 
 // JavaRuntimeJSON represents a single Java runtime for JSON output
 type JavaRuntimeJSON struct {
-	JavaExecutable string `json:"java_executable"`
-	JavaRuntime    string `json:"java_runtime,omitempty"`
-	JavaVendor     string `json:"java_vendor,omitempty"`
-	IsOracle       bool   `json:"is_oracle,omitempty"`
-	JavaVersion    string `json:"java_version,omitempty"`
-	VersionMajor   int    `json:"java_version_major,omitempty"`
-	VersionUpdate  int    `json:"java_version_update,omitempty"`
-	ExecFailed     bool   `json:"exec_failed,omitempty"`
-	RequireLicense *bool  `json:"require_license"`
+	JavaExecutable   string     `json:"java_executable"`
+	MatchedName      string     `json:"matched_name,omitempty"`
+	JavaRuntime      string     `json:"java_runtime,omitempty"`
+	JavaVendor       string     `json:"java_vendor,omitempty"`
+	IsOracle         bool       `json:"is_oracle,omitempty"`
+	JavaVersion      string     `json:"java_version,omitempty"`
+	VersionMajor     int        `json:"java_version_major,omitempty"`
+	VersionUpdate    int        `json:"java_version_update,omitempty"`
+	ReleaseDate      string     `json:"release_date,omitempty"` // JAVA_VERSION_DATE of the release file, Java 10 and later
+	CPURelease       string     `json:"cpu_release,omitempty"`  // release date of the newest Critical Patch Update the runtime contains
+	PatchLagQuarters *int       `json:"patch_lag_quarters,omitempty"`
+	JavaVM           string     `json:"java_vm,omitempty"`
+	JavaVMVersion    string     `json:"java_vm_version,omitempty"`
+	InitialHeap      int64      `json:"default_initial_heap,omitempty"`
+	MaxHeap          int64      `json:"default_max_heap,omitempty"`
+	ContainerSupport string     `json:"container_support,omitempty"`
+	ExecFailed       bool       `json:"exec_failed,omitempty"`
+	RequireLicense   *bool      `json:"require_license"`
+	PathClass        string     `json:"path_class,omitempty"`
+	ProvisionedBy    string     `json:"provisioned_by,omitempty"`
+	ProbeStatus      string     `json:"probe_status,omitempty"`
+	BinaryArch       string     `json:"binary_arch,omitempty"`
+	Is32Bit          bool       `json:"is_32bit,omitempty"`
+	ArchMismatch     bool       `json:"arch_mismatch,omitempty"`
+	DependencyIssues []string   `json:"dependency_issues,omitempty"`
+	Modules          []string   `json:"modules,omitempty"`
+	CompatWarnings   []string   `json:"compat_warnings,omitempty"`
+	Embedded         bool       `json:"embedded,omitempty"`
+	Packaged         bool       `json:"packaged,omitempty"`
+	Archive          string     `json:"archive,omitempty"`
+	Quarantined      bool       `json:"quarantined,omitempty"`
+	EmbeddedIn       *Embedding `json:"embedded_in,omitempty"`
+	ViaSymlink       bool       `json:"via_symlink,omitempty"`
+	Aliases          []string   `json:"aliases,omitempty"`
+	Paths            []string   `json:"paths,omitempty"`
+	Daemons          []string   `json:"daemons,omitempty"`
+	JavaAgents       []string   `json:"java_agents,omitempty"`
+	AppServers       []string   `json:"app_servers,omitempty"`
+	RequiredJava     int        `json:"required_java,omitempty"`
+	FirstSeen        string     `json:"first_seen,omitempty"`
+	LastSeen         string     `json:"last_seen,omitempty"`
+
+	Acknowledgments []Acknowledgment `json:"acknowledgments,omitempty"`
+
+	RecommendedReplacement *Replacement `json:"recommended_replacement,omitempty"`
+
+	LatestAvailable *LatestRelease `json:"latest_available,omitempty"`
+
+	StartupBenchmark *StartupBenchmark `json:"startup_benchmark,omitempty"`
+
+	// EvalTrust is the trust level of the location of the runtime, see trusted_paths
+	EvalTrust *TrustDecision `json:"eval_trust,omitempty"`
+
+	ProbeOutput *ProbeOutput `json:"probe_output,omitempty"`
+
+	Enrichments map[string]any `json:"enrichments,omitempty"`
+
+	Confidence         int      `json:"confidence"`
+	ConfidenceLevel    string   `json:"confidence_level"`
+	ConfidenceEvidence []string `json:"confidence_evidence,omitempty"`
 }
 
 // MetaInfo represents metadata about the scan
 type MetaInfo struct {
+	SchemaVersion int    `json:"schema_version,omitempty"`
+	ScanID        string `json:"scan_id"`
+	ReportPhase   string `json:"report_phase,omitempty"`
 	ScanTimestamp string `json:"scan_ts"`
 	ComputerName  string `json:"computer_name"`
 	UserName      string `json:"user_name"`
@@ -62,12 +306,69 @@ type MetaInfo struct {
 	HasOracleJDK  bool   `json:"has_oracle_jdk"`
 	CountResult   int    `json:"count_result"`
 	ScannedDirs   int    `json:"scanned_dirs"`
+	ExcludedDirs  int    `json:"excluded_dirs,omitempty"`
+
+	ComputerNameSource   string            `json:"computer_name_source,omitempty"`
+	Labels               map[string]string `json:"labels,omitempty"`
+	PathClasses          map[PathClass]int `json:"path_classes,omitempty"`
+	BuildToolProvisioned int               `json:"build_tool_provisioned,omitempty"`
+	DiscoverySource      string            `json:"discovery_source,omitempty"`
+	SourceTimings        []SourceTiming    `json:"source_timings,omitempty"`
+	RuntimeEnvironment   string            `json:"runtime_environment,omitempty"`
+	EvalMode             string            `json:"eval_mode,omitempty"`
+	RealtimeAV           string            `json:"realtime_av,omitempty"`
+	Background           string            `json:"background,omitempty"`
+	Shard                string            `json:"shard,omitempty"`
+	Roots                []RootStats       `json:"roots,omitempty"`
+	Share                string            `json:"share,omitempty"`
+	RemoteHost           string            `json:"remote_host,omitempty"`
+	ResourceLimits       *ResourceLimits   `json:"resource_limits,omitempty"`
+	ResourceUsage        *ResourceUsage    `json:"resource_usage,omitempty"`
+	ResultsTruncated     bool              `json:"results_truncated,omitempty"`
+	ResultLimit          int               `json:"result_limit,omitempty"`
+	TimedOut             bool              `json:"timed_out,omitempty"`
+	MergedShards         []string          `json:"merged_shards,omitempty"`
+	Chunk                *ChunkInfo        `json:"chunk,omitempty"`
+	UpdateDrift          []UpdateDrift     `json:"update_drift,omitempty"`
+	ScanErrors           []ScanError       `json:"scan_errors,omitempty"`
+	PrunedMounts         []PrunedMount     `json:"pruned_mounts,omitempty"`
+	Baseline             *BaselineInfo     `json:"baseline,omitempty"`
+	Deferral             *Deferral         `json:"deferral,omitempty"`
+
+	// ScanRoots are the start paths of the scan and Complete whether it looked at
+	// everything below them, see complete. A collector only takes a runtime under
+	// the roots of a complete scan for removed when the scan did not report it.
+	ScanRoots []string `json:"scan_roots,omitempty"`
+	Complete  bool     `json:"complete"`
 }
 
 // JSONOutput represents the root JSON output structure
 type JSONOutput struct {
-	Meta     MetaInfo          `json:"meta"`
-	Runtimes []JavaRuntimeJSON `json:"result"`
+	Meta           MetaInfo          `json:"meta"`
+	DefaultRuntime *DefaultRuntime   `json:"default_runtime,omitempty"`
+	JavaEnv        *JavaEnvironment  `json:"java_environment,omitempty"`
+	Runtimes       []JavaRuntimeJSON `json:"result"`
+	Departed       []HistoryEntry    `json:"departed_runtimes,omitempty"`
+	Daemons        []Daemon          `json:"daemons,omitempty"`
+	JavaAgents     []JavaAgent       `json:"java_agents,omitempty"`
+
+	// BytecodeCensus is the class file versions of the applications of the runtimes, see -bytecode
+	BytecodeCensus []BytecodeCensus `json:"bytecode_census,omitempty"`
+
+	// VulnerableLibraries are the vulnerable Java libraries found by the walk, see -libs
+	VulnerableLibraries []LibraryFinding `json:"vulnerable_libraries,omitempty"`
+
+	// AppServers are the application servers found by the walk and their runtimes, see -app-servers
+	AppServers []AppServer `json:"app_servers,omitempty"`
+
+	// Acknowledgments are the accepted findings of this host, see -acks
+	Acknowledgments []Acknowledgment `json:"acknowledgments,omitempty"`
+
+	// Quarantined are the java executables jfind serve -enforce quarantine made non-executable
+	Quarantined []QuarantineEntry `json:"quarantined,omitempty"`
+
+	// CostEstimate is the potential Oracle Java SE subscription cost, see license_costs
+	CostEstimate *CostEstimate `json:"cost_estimate,omitempty"`
 }
 
 // NewJavaFinder creates a new JavaFinder instance
@@ -77,6 +378,18 @@ func NewJavaFinder(startPath string, maxDepth int, verbose bool, evaluate bool)
 		maxDepth:  maxDepth,
 		verbose:   verbose,
 		evaluate:  evaluate,
+
+		classifier:      newPathClassifier(nil),
+		requiredModules: defaultRequiredModules,
+		replacements:    defaultReplacementRules,
+		releases:        defaultReleaseCatalog,
+		evalCmd:         &evalCommand{args: []string{javaPlaceholder, "-XshowSettings:properties", "-version"}},
+		budgets:         make(map[string]time.Duration),
+		scanID:          newScanID(),
+		sys:             osSystem,
+		failures:        &scanErrors{},
+
+		containerStorage: containerStorageDirs(),
 	}
 }
 
@@ -85,9 +398,15 @@ func logf(format string, a ...interface{}) {
 	fmt.Fprintf(os.Stderr, format, a...)
 }
 
-// printf prints to stdout
+// printf prints to stdout in the language selected with -lang
 func printf(format string, a ...interface{}) {
-	fmt.Printf(format, a...)
+	fmt.Printf(tr(format), a...)
+}
+
+// withinRoot checks if path is root or below it
+func withinRoot(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
 }
 
 // isExecutable checks if a file is executable based on the operating system
@@ -108,9 +427,17 @@ func isJavaExecutable(name string) bool {
 	return name == "java"
 }
 
-// getPathDepth returns the depth of a path relative to the start path
+// maxDepthOf returns the maximum depth below the start path of a path, -1 for unlimited
+func (f *JavaFinder) maxDepthOf(path string) int {
+	if depth, ok := f.rootDepths[f.rootOf(path)]; ok {
+		return depth
+	}
+	return f.maxDepth
+}
+
+// getPathDepth returns the depth of a path relative to its start path
 func (f *JavaFinder) getPathDepth(path string) int {
-	relPath, err := filepath.Rel(f.startPath, path)
+	relPath, err := filepath.Rel(f.rootOf(path), path)
 	if err != nil {
 		return 0
 	}
@@ -121,37 +448,159 @@ func (f *JavaFinder) getPathDepth(path string) int {
 }
 
 // evaluateJava runs java -version and returns the result
-func (f *JavaFinder) evaluateJava(javaPath string) JavaResult {
+func (f *JavaFinder) evaluateJava(ctx context.Context, javaPath string) JavaResult {
 	result := JavaResult{
 		Path:      javaPath,
 		Evaluated: true,
 	}
 
-	cmd := exec.Command(javaPath, "-XshowSettings:properties", "-version")
-	var stderr bytes.Buffer
+	f.throttle.wait()
+	name, args := f.evalCmd.build(javaPath)
+	cmd := probeCommand(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
-	result.Error = cmd.Run()
-	result.ReturnCode = 0
-	if exitError, ok := result.Error.(*exec.ExitError); ok {
-		result.ReturnCode = exitError.ExitCode()
+
+	// Run the probe in a scratch directory so crash files don't end up in our CWD
+	sandbox, err := newProbeSandbox()
+	if err != nil {
+		result.Error = fmt.Errorf("failed to create probe sandbox: %v", err)
+		result.Status = ProbeFailed
+		return result
+	}
+	defer sandbox.cleanup()
+	sandbox.apply(cmd)
+	if f.background != "" {
+		backgroundCommand(cmd)
 	}
 
+	result.Error = f.runProbe(cmd)
+	result.ReturnCode = exitCode(result.Error)
+
 	result.StdErr = stderr.String()
-	if result.Error == nil && result.ReturnCode == 0 {
+	if f.captureBytes > 0 {
+		result.Output = captureProbeOutput(stdout.Bytes(), stderr.Bytes(), f.captureBytes)
+	}
+	switch {
+	case sandbox.crashed() || strings.Contains(result.StdErr, fatalErrorMarker):
+		result.Status = ProbeCrashed
+		if result.Error == nil {
+			result.Error = fmt.Errorf("JVM crashed")
+		}
+	case result.Error == nil && result.ReturnCode == 0:
+		result.Status = ProbeOK
 		result.Properties = ParseJavaProperties(result.StdErr)
+	default:
+		result.Status = ProbeFailed
+	}
+	f.evaluateOpenJ9(ctx, &result)
+	if f.heapProbe {
+		f.probeHeapFlags(ctx, &result)
 	}
 
 	return result
 }
 
-// printResult prints the results of evaluating a Java executable
-func printResult(result *JavaResult) {
+// runJava runs an additional probe of javaPath with the given java arguments
+// and returns its combined output
+func (f *JavaFinder) runJava(ctx context.Context, javaPath string, javaArgs ...string) (string, error) {
+	f.throttle.wait()
+	name, args := f.evalCmd.buildProbe(javaPath, javaArgs...)
+	cmd := probeCommand(ctx, name, args...)
+
+	sandbox, err := newProbeSandbox()
+	if err != nil {
+		return "", err
+	}
+	defer sandbox.cleanup()
+	sandbox.apply(cmd)
+	if f.background != "" {
+		backgroundCommand(cmd)
+	}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = f.runProbe(cmd)
+	return output.String(), err
+}
+
+// printResult prints the results of evaluating a Java executable, the patch
+// level as of now
+func printResult(result *JavaResult, acks []Acknowledgment, now time.Time) {
 	printf("Java executable: %s\n", result.Path)
+	if result.PathClass != "" {
+		printf("Path class: %s\n", result.PathClass)
+	}
+	if result.Provisioner != "" {
+		printf("Provisioned by: %s\n", result.Provisioner)
+	}
+	if result.Embedding != nil {
+		printf("Embedded in: %s (%s)\n", result.Embedding.Application, result.Embedding.Wrapper)
+	}
+	if result.Archive != "" {
+		printf("Packaged in: %s\n", result.Archive)
+	}
+	if result.ViaSymlink {
+		printf("Reached via symbolic link\n")
+	}
+	for _, alias := range result.Aliases {
+		printf("Alias: %s\n", alias)
+	}
+	for _, path := range result.Paths {
+		if path != result.Path {
+			printf("Same file at: %s\n", path)
+		}
+	}
+	printf("Confidence: %d (%s)\n", result.Confidence, confidenceLevel(result.Confidence))
+	if result.Binary != nil && result.Binary.is32BitOnHost() {
+		printf("Warning: 32-bit runtime (%s) on 64-bit host\n", result.Binary.Arch)
+	}
+	for _, issue := range result.DependencyIssues {
+		printf("Dependency issue: %s\n", issue)
+	}
+	if len(result.Modules) > 0 {
+		printf("Modules: %d\n", len(result.Modules))
+	}
+	for _, warning := range result.CompatWarnings {
+		printf("Compatibility warning: %s\n", warning)
+	}
+	if result.Output != nil && result.Output.Stderr != nil {
+		printf("Probe stderr: %d bytes, SHA-256 %s\n", result.Output.Stderr.Size, result.Output.Stderr.SHA256)
+	}
+	for _, name := range slices.Sorted(maps.Keys(result.Enrichments)) {
+		value, _ := json.Marshal(result.Enrichments[name])
+		printf("Enrichment %s: %s\n", name, value)
+	}
+	if r := result.Replacement; r != nil {
+		printf("Recommended replacement: %s %s (%s)\n", r.Vendor, r.Version, r.URL)
+	}
+	if l := result.Latest; l != nil {
+		printf("Latest available: %s %s, %d updates behind\n", l.Distribution, l.Version, l.UpdatesBehind)
+	}
+	if b := result.Startup; b != nil {
+		if b.Error != "" {
+			printf("Startup benchmark failed after %d runs: %s\n", b.Runs, b.Error)
+		} else {
+			printf("Startup: %s ms median of %d runs (min %s ms, max %s ms)\n", formatMS(b.MedianMS), b.Runs, formatMS(b.MinMS), formatMS(b.MaxMS))
+		}
+	}
+
+	if result.Trust != nil && !result.Trust.Exec {
+		printf("Untrusted location, not executed\n")
+	}
 
 	if !result.Evaluated {
 		return
 	}
 
+	if result.Status == ProbeArchMismatch {
+		printf("Architecture mismatch: %v\n", result.Error)
+		return
+	}
+	if result.Status == ProbeCrashed {
+		printf("JVM crashed during evaluation\n")
+	}
 	if result.Error != nil || result.ReturnCode != 0 {
 		printf("Failed to execute: %v\n", result.Error)
 		if result.ReturnCode != 0 {
@@ -166,68 +615,553 @@ func printResult(result *JavaResult) {
 		printf("Java runtime name: %s\n", result.Properties.RuntimeName)
 		printf("Java major version: %d\n", result.Properties.Major)
 		printf("Java update version: %d\n", result.Properties.Update)
+		if level, ok := patchLevel(result.Properties.Major, result.Properties.Update, result.Release["JAVA_VERSION_DATE"], now); ok {
+			printf("Patch level: CPU of %s, %d CPUs behind\n", level.CPU, level.LagQuarters)
+		}
+		if result.Properties.VMName != "" {
+			if result.Properties.VMVersion != "" {
+				printf("Java VM: %s %s\n", result.Properties.VMName, result.Properties.VMVersion)
+			} else {
+				printf("Java VM: %s\n", result.Properties.VMName)
+			}
+		}
+		if result.Properties.MaxHeap > 0 {
+			printf("Default heap: %d bytes initial, %d bytes maximum\n", result.Properties.InitialHeap, result.Properties.MaxHeap)
+		}
+		if result.Properties.ContainerSupport != "" {
+			printf("Container support: %s\n", result.Properties.ContainerSupport)
+		}
 
 		if strings.Contains(result.Properties.Vendor, "Oracle") {
-			printf("Warning: Oracle JDK detected\n")
+			if ack := findAcknowledgment(acks, FindingOracleJDK, result.Path); ack != nil {
+				printf("Acknowledged: Oracle JDK detected (%s)\n", ack)
+			} else {
+				printf("Warning: Oracle JDK detected\n")
+			}
 		}
 	}
 }
 
 // Find searches for java executables starting from the specified path
 func (f *JavaFinder) Find() ([]*JavaResult, error) {
+	return f.FindContext(context.Background())
+}
+
+// walkGracePeriod is how long FindContext waits for the walk to stop after ctx
+// is done, replaced by tests
+var walkGracePeriod = 5 * time.Second
+
+// FindContext searches like Find until ctx is done. If its deadline passes, the
+// results found so far are returned and reported as timed out. A walk stuck in
+// a file system call that does not return, such as on a hung NFS mount, is
+// given up after a grace period. The walk works on a copy of the finder that
+// is handed back when it ends, so an abandoned walk cannot race with the report:
+// its counters and roots are left out instead.
+func (f *JavaFinder) FindContext(ctx context.Context) ([]*JavaResult, error) {
+	var mu sync.Mutex
+	var results []*JavaResult
+	var buffered int64
+	var counted int
+	var truncated, limited bool
+	f.truncated = false
+	f.limited = false
+	f.timedOut = false
+	f.walkFailed = false
+	walker := new(JavaFinder)
+	*walker = *f
+	done := make(chan error, 1)
+	go func() {
+		done <- walker.walk(ctx, func(result *JavaResult) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if walker.maxResultBytes > 0 {
+				// The raw probe output is not reported, only the parsed properties
+				result.StdErr = ""
+				buffered += resultSize(result)
+				if buffered > walker.maxResultBytes {
+					truncated = true
+					return errBufferFull
+				}
+			}
+			if walker.maxResults > 0 && counted >= walker.maxResults {
+				// Probes still running when the limit was reached
+				return errEnoughResults
+			}
+			results = append(results, result)
+			if result.Confidence >= walker.minConfidence {
+				counted++
+			}
+			if walker.maxResults > 0 && counted >= walker.maxResults {
+				limited = true
+				return errEnoughResults
+			}
+			return nil
+		})
+	}()
+
+	var err error
+	abandoned := false
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		select {
+		case err = <-done:
+		case <-time.After(walkGracePeriod):
+			logf("Warning: the walk did not stop within %v, a file system is not responding\n", walkGracePeriod)
+			err = ctx.Err()
+			abandoned = true
+		}
+	}
+	if abandoned {
+		// The walk still runs and owns its copy of the finder
+		f.scanned, f.excluded = 0, 0
+		f.rootStats = nil
+		f.timings = nil
+		f.mounts = nil
+		f.links = nil
+	} else {
+		*f = *walker
+	}
+	mu.Lock()
+	results = slices.Clone(results)
+	f.truncated, f.limited = truncated, limited
+	mu.Unlock()
+
+	if err == errBufferFull {
+		logf("Warning: stopped after %d results, the memory limit for buffered results was reached\n", len(results))
+		err = nil
+	}
+	if err == errEnoughResults {
+		if f.verbose {
+			logf("Stopped after %d results (-max-results %d)\n", counted, f.maxResults)
+		}
+		err = nil
+	}
+	if ctx.Err() == context.DeadlineExceeded && (err == nil || errors.Is(err, context.DeadlineExceeded)) {
+		f.timedOut = true
+		err = nil
+	}
+	if f.queuedWalk() {
+		f.sortWalkOrder(results)
+	}
+	f.walkFailed = err != nil
+	return collapseAliases(results), err
+}
+
+// Stream searches for java executables like Find, but delivers each result as soon
+// as it is found. The results channel is closed when the scan is complete; the
+// error channel then yields at most one error. Cancelling ctx stops the scan early.
+func (f *JavaFinder) Stream(ctx context.Context) (<-chan *JavaResult, <-chan error) {
+	results := make(chan *JavaResult)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		defer close(results)
+
+		err := f.walk(ctx, func(result *JavaResult) error {
+			select {
+			case results <- result:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errc <- err
+		}
+	}()
+
+	return results, errc
+}
+
+// walk traverses the start path and calls emit for every java executable found.
+// The walk stops when ctx is done or emit returns an error. Each discovery source
+// runs within its time budget; if the budget is exceeded the results found so far
+// are kept.
+func (f *JavaFinder) walk(ctx context.Context, emit func(*JavaResult) error) error {
 	f.scanned = 0 // Reset counter
+	f.excluded = 0
+	f.resetLinks()
+	f.resetMounts()
+	f.failures.reset()
+	f.baseline.reset()
+	f.usage = startUsage()
+	f.discovery = "filesystem"
+	f.timings = nil
+	f.deferred = &deferredDirs{}
+
+	if f.index != nil {
+		// Listed paths replace the walk, there is nothing to fall back to
+		_, listed := f.index.(*pathListSource)
+		if f.verbose && !listed {
+			logf("Querying %s for java in %s\n", f.index.Name(), f.startPath)
+		}
+		emitted := 0
+		err := f.withBudget(ctx, f.index.Name(), SourceIndex, func(ctx context.Context) error {
+			return f.findFromSource(ctx, f.index, func(result *JavaResult) error {
+				emitted++
+				return emit(result)
+			})
+		})
+		// Only fall back if nothing was reported yet, to avoid duplicates
+		if err == nil || ctx.Err() != nil || emitted > 0 || listed {
+			f.discovery = f.index.Name()
+			return ignoreBudgetExceeded(err)
+		}
+		logf("Warning: %v, falling back to scanning the file system\n", err)
+	}
+
+	// The registry looks for the runtimes a walk cut short by its budget did not report
+	found := make(map[string]bool)
+	report := emit
+	emit = func(result *JavaResult) error {
+		found[result.Path] = true
+		return report(result)
+	}
+	walkEmit := emit
+	if f.checkpoint != nil {
+		scanned, excluded := f.checkpoint.totals()
+		f.scanned, f.excluded = scanned, excluded
+		if err := f.resumeFound(ctx, emit); err != nil {
+			return err
+		}
+		walkEmit = f.checkpoint.record(emit)
+		f.checkpoint.startSaving(f.sys.Clock, checkpointInterval)
+	}
+	walked := f.sys.Clock.Now()
+	err := f.withBudget(ctx, SourceFileSystem, SourceFileSystem, func(ctx context.Context) error {
+		return f.walkRoots(ctx, walkEmit)
+	})
+	if f.baseline != nil && err == nil && ctx.Err() == nil {
+		if saveErr := f.baseline.saveIndex(walked, f.names.String()); saveErr != nil {
+			logf("Warning: %v\n", saveErr)
+		}
+	}
+	if f.checkpoint != nil {
+		if saveErr := f.checkpoint.stopSaving(f.sys.Clock, err == nil && ctx.Err() == nil); saveErr != nil {
+			logf("Warning: %v\n", saveErr)
+		}
+	}
+	exceeded := err == errBudgetExceeded
+	if err = ignoreBudgetExceeded(err); err == nil && len(f.deferred.list()) > 0 {
+		err = ignoreBudgetExceeded(f.withBudget(ctx, SourceContainers, SourceContainers, func(ctx context.Context) error {
+			return f.walkContainerStorage(ctx, emit)
+		}))
+	}
+	if err == nil && exceeded && runtime.GOOS == "windows" {
+		registry := &registrySource{finder: f, found: found}
+		err = ignoreBudgetExceeded(f.withBudget(ctx, SourceRegistry, SourceRegistry, func(ctx context.Context) error {
+			return f.findFromSource(ctx, registry, emit)
+		}))
+	}
+	return err
+}
+
+// walkFileSystem walks the directory tree below the start path
+func (f *JavaFinder) walkFileSystem(ctx context.Context, emit func(*JavaResult) error) error {
 	if f.verbose {
 		logf("Start looking for java in %s (scanning subdirectories)\n", f.startPath)
 	}
-	var results []*JavaResult
 
-	err := filepath.Walk(f.startPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			if os.IsPermission(err) {
-				if f.verbose {
-					logf("Permission denied: %s\n", path)
-				}
-				return filepath.SkipDir
+	if f.checkpoint.completed(f.startPath) {
+		return nil
+	}
+	if f.queuedWalk() {
+		return f.walkConcurrently(ctx, emit)
+	}
+	return walkFS(f.treeFS(), f.startPath, func(path string, info os.FileInfo, err error) error {
+		return f.visit(ctx, path, info, err, emit)
+	})
+}
+
+// visit decides about one path of the walk like a filepath.WalkFunc: it counts
+// and prunes directories and emits the java executables. A panic skips the path
+// and is reported as a scan error, the walk goes on with the next one.
+func (f *JavaFinder) visit(ctx context.Context, path string, info os.FileInfo, err error, emit func(*JavaResult) error) (visitErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			f.recovered(path, StageWalk, r)
+			f.trace.event(SourceFileSystem, TracePanic, path, f.getPathDepth(path), fmt.Sprint(r))
+			visitErr = nil
+			if info != nil && info.IsDir() {
+				visitErr = filepath.SkipDir
 			}
-			// Skip other errors but log them in verbose mode
+		}
+	}()
+	return f.visitPath(ctx, path, info, err, emit)
+}
+
+// visitPath does the work of visit
+func (f *JavaFinder) visitPath(ctx context.Context, path string, info os.FileInfo, err error, emit func(*JavaResult) error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	f.bandwidth.wait(shareEntryBytes)
+
+	depth := f.getPathDepth(path)
+	if err != nil {
+		if os.IsPermission(err) {
+			f.trace.event(SourceFileSystem, TracePermissionDenied, path, depth, err.Error())
 			if f.verbose {
-				logf("Error accessing %s: %v\n", path, err)
+				logf("Permission denied: %s\n", path)
 			}
-			return nil
+			return filepath.SkipDir
+		}
+		// Skip other errors but log them in verbose mode
+		f.trace.event(SourceFileSystem, TraceError, path, depth, err.Error())
+		if f.verbose {
+			logf("Error accessing %s: %v\n", path, err)
+		}
+		return nil
+	}
+
+	if f.skipVirtualFS && info.IsDir() && path != f.startPath {
+		if fsType, ok := virtualFileSystem(path); ok {
+			f.trace.event(SourceFileSystem, TraceSkippedVirtualFS, path, depth, fsType)
+			if f.verbose {
+				logf("Skipping %s file system: %s\n", fsType, path)
+			}
+			return filepath.SkipDir
 		}
+	}
 
-		// Print directory being scanned in verbose mode
-		if f.verbose && info.IsDir() {
-			logf("Scanning: %s\n", path)
+	if info.IsDir() && path != f.startPath {
+		if entry, ok := f.prune.match(path); ok {
+			f.excluded++
+			f.checkpoint.countDir(true)
+			f.trace.event(SourceFileSystem, TraceExcluded, path, depth, "prune-dirs "+entry)
+			if f.verbose {
+				logf("Pruned by %s: %s\n", entry, path)
+			}
+			return filepath.SkipDir
+		}
+		if pattern, ok := f.exclude.match(path); ok {
+			f.excluded++
+			f.checkpoint.countDir(true)
+			f.trace.event(SourceFileSystem, TraceExcluded, path, depth, pattern)
+			if f.verbose {
+				logf("Excluded by %s: %s\n", pattern, path)
+			}
+			return filepath.SkipDir
 		}
+	}
 
-		// Count directories as we scan
+	// Check depth before counting, a directory below the limit is not scanned
+	if maxDepth := f.maxDepthOf(path); maxDepth >= 0 && depth > maxDepth {
+		if info.IsDir() || f.matchesName(info.Name()) {
+			f.trace.event(SourceFileSystem, TraceSkippedDepth, path, depth, fmt.Sprintf("max depth %d", maxDepth))
+		}
 		if info.IsDir() {
-			f.scanned++
+			return filepath.SkipDir
+		}
+		return nil
+	}
+
+	// Container storage is walked last, within the containers budget
+	if info.IsDir() && path != f.startPath && f.defersContainerStorage(path) && (depth != 1 || f.shard.owns(info.Name())) {
+		f.deferred.add(path)
+		f.trace.event(SourceFileSystem, TraceDeferred, path, depth, SourceContainers)
+		return filepath.SkipDir
+	}
+
+	// Print directory being scanned in verbose mode
+	if f.verbose && info.IsDir() {
+		logf("Scanning: %s\n", path)
+	}
+
+	// Count directories as we scan
+	if info.IsDir() {
+		f.scanned++
+		f.checkpoint.countDir(false)
+		f.progress.dir(path)
+	}
+	if info.IsDir() {
+		if depth == 1 && !f.shard.owns(info.Name()) {
+			f.trace.event(SourceFileSystem, TraceSkippedShard, path, depth, f.shard.String())
+			return filepath.SkipDir
+		}
+		if !f.links.enter(path, info) {
+			f.trace.event(SourceFileSystem, TraceSymlinkLoop, path, depth, "")
+			if f.verbose {
+				logf("Skipping link to a directory already scanned: %s\n", path)
+			}
+			return filepath.SkipDir
 		}
+		f.trace.event(SourceFileSystem, TraceEntered, path, depth, "")
+		return nil
+	}
+
+	// Application servers are recognized by their files, e.g. bin/catalina.sh
+	if f.appServers != nil && isAppServerSignature(info.Name()) {
+		f.appServers.inspect(path)
+	}
 
-		// Check depth
-		if f.maxDepth >= 0 && f.getPathDepth(path) > f.maxDepth {
-			if info.IsDir() {
-				return filepath.SkipDir
+	// Fingerprint Java archives while passing by, saving a second walk
+	if f.libraries != nil && isLibraryArchive(info.Name()) {
+		f.libraries.inspect(path)
+		return nil
+	}
+
+	// JDK distributions kept as archives are found by their entries
+	if f.archives && isRuntimeArchive(info.Name()) {
+		if archiveTooLarge(info.Name(), info.Size()) {
+			f.trace.event(SourceFileSystem, TraceSkippedSize, path, depth, fmt.Sprint(info.Size()))
+			return nil
+		}
+		results, err := f.newArchiveResults(path)
+		if err != nil {
+			f.trace.event(SourceFileSystem, TraceError, path, depth, err.Error())
+			if f.verbose {
+				logf("Error reading archive %s: %v\n", path, err)
 			}
 			return nil
 		}
-
-		// Check if file is executable and named 'java' or 'java.exe' depending on OS
-		if !info.IsDir() && isJavaExecutable(info.Name()) && isExecutable(info) {
-			if f.evaluate {
-				result := f.evaluateJava(path)
-				results = append(results, &result)
-			} else {
-				results = append(results, &JavaResult{Path: path})
+		for _, result := range results {
+			f.trace.event(SourceFileSystem, TraceMatched, result.Path, depth, "archive")
+			f.progress.match()
+			if err := emit(result); err != nil {
+				return err
 			}
 		}
+		return nil
+	}
 
+	// The runtime of a jpackage application usually has no java executable, it
+	// is found by its release file and reported under the launcher
+	if info.Name() == "release" {
+		home := filepath.Dir(path)
+		if app := detectJPackage(home); app != nil && !hasJavaExecutable(home) {
+			f.trace.event(SourceFileSystem, TraceMatched, app.Launcher, depth, WrapperJPackage)
+			f.progress.match()
+			return emit(f.newLauncherResult(app))
+		}
 		return nil
-	})
+	}
+
+	// Check if file is executable and named 'java' or 'java.exe' depending on OS,
+	// or one of the names of -names and -name-pattern
+	if f.matchesName(info.Name()) {
+		if !isExecutable(info) && !f.isQuarantined(path) {
+			f.trace.event(SourceFileSystem, TraceNotExecutable, path, depth, info.Mode().String())
+			return nil
+		}
+		f.trace.event(SourceFileSystem, TraceMatched, path, depth, "")
+		f.progress.match()
+		f.bandwidth.wait(shareRuntimeBytes)
+		result := f.newResult(ctx, path)
+		if err := ctx.Err(); err != nil {
+			// The probes were cut short, the result would be misleading
+			return err
+		}
+		return emit(result)
+	}
 
-	return results, err
+	return nil
+}
+
+// newResult inspects and optionally evaluates a java executable that was found.
+// If that panics, the runtime is reported as failed with the panic as error and
+// a scan error.
+func (f *JavaFinder) newResult(ctx context.Context, path string) (result *JavaResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			f.recovered(path, StageEvaluate, r)
+			result = &JavaResult{Path: path, Evaluated: f.evaluate, Status: ProbeFailed, Error: fmt.Errorf("internal error: %v", r)}
+			result.PathClass = f.classifier.classify(path)
+		}
+	}()
+	return f.inspectResult(ctx, path)
+}
+
+// inspectResult does the work of newResult
+func (f *JavaFinder) inspectResult(ctx context.Context, path string) *JavaResult {
+	binary, _ := inspectBinary(path)
+
+	var trust *TrustDecision
+	if f.trust != nil && !f.noExec {
+		trust = f.trust.decide(path)
+		if f.verbose && !trust.Exec {
+			reason := "outside the trusted paths"
+			if trust.Pattern != "" {
+				reason = "below " + trust.Pattern
+			}
+			logf("Evaluating untrusted %s statically (%s)\n", path, reason)
+		}
+	}
+
+	launcher := isJavaLauncher(filepath.Base(path))
+	var result JavaResult
+	switch {
+	case f.evaluate && (f.noExec || !launcher || trust != nil && !trust.Exec || f.isQuarantined(path)):
+		result = JavaResult{Path: path, Evaluated: true, Status: ProbeNotExecuted}
+	case f.evaluate && binary != nil && binary.archSupport() == archUnsupported:
+		// Don't even try, exec would only fail with a generic format error
+		result = JavaResult{Path: path, Evaluated: true, Status: ProbeArchMismatch, Error: binary.archMismatchError()}
+	case f.evaluate:
+		if carried, ok := f.carry(path); ok {
+			result = carried
+			break
+		}
+		result = f.evaluateJava(ctx, path)
+		if result.Status == ProbeFailed && binary != nil && binary.archSupport() == archEmulated {
+			// The emulation layer is probably not installed
+			result.Status = ProbeArchMismatch
+			result.Error = fmt.Errorf("%v (%v)", binary.archMismatchError(), result.Error)
+		}
+	default:
+		result = JavaResult{Path: path}
+	}
+	runnable := binary == nil || binary.archSupport() != archUnsupported
+	if f.benchmarkRuns > 0 && result.Startup == nil && !f.noExec && launcher && (trust == nil || trust.Exec) && runnable && result.Status != ProbeArchMismatch && result.Status != ProbeCrashed {
+		result.Startup = f.benchmarkStartup(ctx, path, f.benchmarkRuns)
+	}
+	result.Trust = trust
+	result.ViaSymlink = f.links.reached(path)
+	if f.names != nil {
+		result.MatchedName = filepath.Base(path)
+	}
+	result.PathClass = f.classifier.classify(path)
+	result.Provisioner = detectProvisioner(path)
+	result.Embedding = detectEmbedding(path)
+	if result.Embedding == nil && f.embedded {
+		result.Embedding = detectBundledApplication(path)
+	}
+	if binary != nil {
+		result.Binary = binary
+		result.DependencyIssues = binary.dependencyIssues(path)
+	}
+	if home, ok := findJavaHome(f.sys.FS, path); ok {
+		result.Release, _ = readReleaseFile(f.sys.FS, home)
+	}
+	if result.Status == ProbeNotExecuted {
+		if result.Properties = releaseProperties(result.Release); result.Properties != nil {
+			result.Status = ProbeStatic
+		}
+	}
+	if f.checkModules {
+		f.checkRuntimeModules(&result)
+	}
+	result.Replacement = recommendReplacement(f.replacements, &result, f.sys.Clock.Now())
+	result.Latest = f.releases.latest(&result)
+	result.Confidence, result.Evidence = scoreConfidence(&result)
+	f.enrich(&result)
+	return &result
+}
+
+// checkRuntimeModules reads the module list from the release file of a runtime
+// and checks it for the required modules
+func (f *JavaFinder) checkRuntimeModules(result *JavaResult) {
+	if result.Release == nil {
+		return
+	}
+
+	major, _ := parseJavaVersion(result.Release["JAVA_VERSION"])
+	if result.Properties != nil && result.Properties.Major > 0 {
+		major = result.Properties.Major
+	}
+	result.Modules = releaseModules(result.Release)
+	result.CompatWarnings = moduleWarnings(result.Modules, major, f.requiredModules)
 }
 
 // formatDurationISO8601 formats a duration according to ISO8601 with millisecond precision
@@ -259,39 +1193,174 @@ func formatDurationISO8601(d time.Duration) string {
 	return result.String()
 }
 
-func getComputerName() string {
-	switch runtime.GOOS {
-	case "darwin":
-		cmd := exec.Command("scutil", "--get", "ComputerName")
-		output, err := cmd.Output()
-		if err == nil {
-			return strings.TrimSpace(string(output))
-		}
-	case "windows":
-		cmd := exec.Command("cmd", "/c", "hostname")
-		output, err := cmd.Output()
-		if err == nil {
-			return strings.TrimSpace(string(output))
-		}
-	case "linux":
-		// Try to read from /etc/hostname first
-		if data, err := os.ReadFile("/etc/hostname"); err == nil {
-			return strings.TrimSpace(string(data))
-		}
-		// Fallback to hostname command
-		cmd := exec.Command("hostname")
-		output, err := cmd.Output()
-		if err == nil {
-			return strings.TrimSpace(string(output))
-		}
-	}
-	return "unknown"
-}
+// buildJSONOutput converts the scan results into the JSON document structure
+func buildJSONOutput(results []*JavaResult, finder *JavaFinder, startTime time.Time) JSONOutput {
+	// Get meta information
+	currentUser, _ := user.Current()
+	username := "unknown"
+	if currentUser != nil {
+		username = currentUser.Username
+	}
 
-// sendJSON sends the JSON payload to the specified URL via HTTP POST
-func sendJSON(jsonData []byte, url string) error {
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
+	hasOracle := false
+	now := finder.sys.Clock.Now()
+	duration := formatDurationISO8601(now.Sub(startTime))
+	output := JSONOutput{
+		Meta: MetaInfo{
+			SchemaVersion: reportSchemaVersion,
+			ScanID:        finder.scanID,
+			ScanTimestamp: now.UTC().Format(time.RFC3339),
+			UserName:      username,
+			ScanDuration:  duration,
+			HasOracleJDK:  false,
+			CountResult:   len(results),
+			ScannedDirs:   finder.scanned,
+			ExcludedDirs:  finder.excluded,
+			PathClasses:   make(map[PathClass]int),
+
+			DiscoverySource:    finder.discovery,
+			SourceTimings:      finder.timings,
+			RuntimeEnvironment: finder.environment,
+			EvalMode:           finder.evalMode,
+			RealtimeAV:         finder.realtimeAV,
+			UpdateDrift:        findUpdateDrift(results),
+			ScanErrors:         finder.failures.list(),
+			PrunedMounts:       finder.mounts.list(),
+			Baseline:           finder.baseline.info(),
+		},
+		DefaultRuntime: finder.defaultRuntime,
+		JavaEnv:        collectJavaEnvironment(finder.javaEnv),
+		Daemons:        finder.daemons,
+		JavaAgents:     finder.agents,
+		Runtimes:       make([]JavaRuntimeJSON, 0),
+	}
+	output.Meta.ComputerName, output.Meta.ComputerNameSource = finder.sys.Host.ComputerName()
+	output.Meta.Labels = hostLabels.resolve()
+	for _, stats := range finder.rootStats {
+		for _, result := range results {
+			if finder.rootOf(result.Path) == stats.Path {
+				stats.CountResult++
+			}
+		}
+		output.Meta.Roots = append(output.Meta.Roots, stats)
+	}
+	if finder.share != nil {
+		output.Meta.Share = finder.share.String()
+	}
+	output.Meta.RemoteHost = finder.remoteHost
+	if finder.share != nil || finder.remoteHost != "" {
+		// The default runtime and environment are those of this host, not of the scanned one
+		output.DefaultRuntime = nil
+		output.JavaEnv = nil
+	}
+	output.BytecodeCensus = finder.census
+	if finder.libraries != nil {
+		output.VulnerableLibraries = finder.libraries.findings
+	}
+
+	for _, result := range results {
+		runtime := JavaRuntimeJSON{
+			JavaExecutable:   result.Path,
+			MatchedName:      result.MatchedName,
+			PathClass:        string(result.PathClass),
+			ProbeStatus:      string(result.Status),
+			DependencyIssues: result.DependencyIssues,
+			Modules:          result.Modules,
+			CompatWarnings:   result.CompatWarnings,
+			Embedded:         result.Embedding != nil,
+			EmbeddedIn:       result.Embedding,
+			Packaged:         result.Archive != "",
+			Archive:          result.Archive,
+			ViaSymlink:       result.ViaSymlink,
+			Aliases:          result.Aliases,
+			Paths:            result.Paths,
+			Daemons:          daemonsUsing(finder.daemons, result.Path),
+			JavaAgents:       agentsAttachedTo(finder.agents, result.Path),
+			RequiredJava:     requiredJava(finder.census, result.Path),
+			ProbeOutput:      result.Output,
+			ReleaseDate:      result.Release["JAVA_VERSION_DATE"],
+			Enrichments:      result.Enrichments,
+
+			RecommendedReplacement: result.Replacement,
+			LatestAvailable:        result.Latest,
+			StartupBenchmark:       result.Startup,
+			EvalTrust:              result.Trust,
+
+			Confidence:         result.Confidence,
+			ConfidenceLevel:    confidenceLevel(result.Confidence),
+			ConfidenceEvidence: result.Evidence,
+		}
+		if result.Binary != nil {
+			runtime.BinaryArch = result.Binary.Arch
+			runtime.Is32Bit = result.Binary.is32BitOnHost()
+			runtime.ArchMismatch = result.Binary.archSupport() == archUnsupported || result.Status == ProbeArchMismatch
+		}
+		output.Meta.PathClasses[result.PathClass]++
+		if result.Provisioner != "" {
+			runtime.ProvisionedBy = result.Provisioner
+			output.Meta.BuildToolProvisioned++
+		}
+
+		if result.Evaluated && result.Properties != nil && result.Error == nil && result.ReturnCode == 0 {
+			runtime.JavaVersion = result.Properties.Version
+			runtime.JavaVendor = result.Properties.Vendor
+			runtime.JavaRuntime = result.Properties.RuntimeName
+			runtime.IsOracle = strings.Contains(result.Properties.Vendor, "Oracle")
+			runtime.VersionMajor = result.Properties.Major
+			runtime.VersionUpdate = result.Properties.Update
+			runtime.JavaVM = result.Properties.VMName
+			runtime.JavaVMVersion = result.Properties.VMVersion
+			runtime.InitialHeap = result.Properties.InitialHeap
+			runtime.MaxHeap = result.Properties.MaxHeap
+			runtime.ContainerSupport = result.Properties.ContainerSupport
+			// A packaged runtime is not installed and needs no license
+			if runtime.IsOracle && !runtime.Packaged {
+				hasOracle = true
+			}
+		} else if result.Evaluated && result.Status != ProbeArchMismatch && (result.Error != nil || result.ReturnCode != 0) {
+			runtime.ExecFailed = true
+		}
+
+		runtime.checkLicenseRequirement()
+		if level, ok := patchLevel(runtime.VersionMajor, runtime.VersionUpdate, runtime.ReleaseDate, now); ok && runtime.JavaVersion != "" {
+			runtime.CPURelease = level.CPU
+			runtime.PatchLagQuarters = &level.LagQuarters
+		}
+
+		output.Runtimes = append(output.Runtimes, runtime)
+	}
+
+	// Update hasOracle after scanning all results
+	output.Meta.HasOracleJDK = hasOracle
+
+	output.AppServers = pairAppServers(finder.appServers.list(), output.Runtimes)
+	for i := range output.Runtimes {
+		output.Runtimes[i].AppServers = appServersUsing(output.AppServers, output.Runtimes[i].JavaExecutable)
+	}
+
+	if finder.shard != nil {
+		output.Meta.Shard = finder.shard.String()
+	}
+	output.Meta.ResourceLimits = finder.limits
+	output.Meta.ResourceUsage = finder.usage.report()
+	output.Meta.ResultsTruncated = finder.truncated
+	if finder.limited {
+		output.Meta.ResultLimit = finder.maxResults
+	}
+	output.Meta.TimedOut = finder.timedOut
+	output.Meta.ScanRoots = finder.startPaths()
+	output.Meta.Complete = finder.complete()
+	output.Meta.Background = finder.background
+	finder.history.apply(&output)
+	applyAcknowledgments(&output, finder.acks)
+	output.CostEstimate = finder.costs.estimate(&output, runtime.NumCPU())
+	return output
+}
+
+// sendJSON sends the JSON payload to the specified URL via HTTP POST
+func sendJSON(client *http.Client, jsonData []byte, url string) error {
+	resp, err := client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
 		// Check if it's a connection error
 		if netErr, ok := err.(*net.OpError); ok {
 			return fmt.Errorf("failed to connect to server at %s: %v", url, netErr)
@@ -318,113 +1387,886 @@ func sendJSON(jsonData []byte, url string) error {
 	return nil
 }
 
+// reportJSON posts the report to url if client is set, split into chunks of at
+// most maxPostSize bytes, or writes it to stdout
+func reportJSON(output JSONOutput, client *http.Client, url string, maxPostSize int64) error {
+	if client != nil {
+		return postChunked(output, client, url, maxPostSize)
+	}
+	jsonData, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to generate JSON output: %v", err)
+	}
+	os.Stdout.Write(append(jsonData, '\n'))
+	return nil
+}
+
 func main() {
-	var startPath string
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "explain":
+			os.Exit(runExplain(os.Args[2:]))
+		case "merge":
+			os.Exit(runMerge(os.Args[2:]))
+		case "support-bundle":
+			os.Exit(runSupportBundle(os.Args[2:]))
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:]))
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		case "remediate":
+			os.Exit(runRemediate(os.Args[2:]))
+		case "fix-default":
+			os.Exit(runFixDefault(os.Args[2:]))
+		case "quarantine":
+			os.Exit(runQuarantine(os.Args[2:]))
+		case "remote-eval":
+			os.Exit(runRemoteEval(os.Args[2:]))
+		case "migrate-report":
+			os.Exit(runMigrateReport(os.Args[2:]))
+		case "migrate-config":
+			os.Exit(runMigrateConfig(os.Args[2:]))
+		case "read-report":
+			os.Exit(runReadReport(os.Args[2:]))
+		}
+	}
+
+	var startPaths pathList
 	var maxDepth int
 	var verbose bool
 	var evaluate bool
 	var jsonOutput bool
 	var doPost bool
 	var postURL string
+	var configFile string
+	var ciMode string
+	var evalCmd string
+	var useIndex bool
+	var pathsFrom string
+	var useMFT bool
+	var checkModules bool
+	var enrich string
+	var recordFile string
+	var replayFile string
+	var traceFile string
+	var lang string
+	var minConfidence int
+	var twoPhase bool
+	var shardSpec string
+	var maxMemory string
+	var maxPostSize string
+	var publishToWMI bool
+	var historyFile string
+	var policyFiles string
+	var opaCommand string
+	var noExec bool
+	var avAware string
+	var captureOutput string
+	var background bool
+	var daemons bool
+	var agents bool
+	var libs bool
+	var appServers bool
+	var bytecode bool
+	var benchmarkRuns int
+	var heapProbe bool
+	var javaEnv string
+	var hostname string
+	var identity string
+	var labels labelFlag
+	var cloudLabels bool
+	var encryptKey string
+	var acksSource string
+	var workers int
+	var shareCredentials string
+	var shareBandwidth string
+	var exclude exclusions
+	var pruneDirs string
+	var names, namePattern string
+	var embedded bool
+	var scanArchives bool
+	var compat int
+	var timeout time.Duration
+	var checkpointFile, resumeFile string
+	var watch bool
+	var progress bool
+	var baselineFile string
+	var lockFile string
+	var lockWait time.Duration
+	var force bool
+	var followSymlinks bool
+	var skipNetworkMounts, oneFilesystem bool
+	var maxResults int
+	var firstOnly bool
 
-	flag.StringVar(&startPath, "path", ".", "Start path for searching")
+	flag.Var(&startPaths, "path", "Start path for searching, repeated or comma separated for several (default \".\"), with its own maximum depth as path:depth, e.g. /opt:4, or a remote share: smb://[user@]server/share[/dir] or nfs://server/export")
 	flag.IntVar(&maxDepth, "depth", -1, "Maximum depth to search (-1 for unlimited)")
+	flag.BoolVar(&followSymlinks, "follow-symlinks", false, "Descend into symbolic links to directories, scanning each directory only once")
+	flag.BoolVar(&skipNetworkMounts, "skip-network-mounts", false, "Do not descend into NFS, SMB and other network file systems mounted below the start path")
+	flag.BoolVar(&oneFilesystem, "one-filesystem", false, "Do not descend into any file system mounted below the start path")
+	flag.Var(&exclude, "exclude", "Skip directories matching this glob pattern, e.g. **/node_modules or .git (repeatable)")
+	flag.StringVar(&pruneDirs, "prune-dirs", defaultPruneDirs, "Skip directories with these names, comma separated, an entry with a slash matching the end of the path (empty prunes none)")
+	flag.StringVar(&names, "names", "", "Also report executables with these file names, comma separated, e.g. javaw.exe,javac,jlink")
+	flag.StringVar(&namePattern, "name-pattern", "", "Also report executables whose file name matches this regular expression, e.g. zulu-.*java")
 	flag.BoolVar(&verbose, "verbose", false, "Enable verbose output")
+	flag.BoolVar(&progress, "progress", false, "Show the directories scanned per second, the current path, the elapsed time and the java executables found on stderr while scanning")
 	flag.BoolVar(&evaluate, "eval", false, "Evaluate found java executables")
 	flag.BoolVar(&jsonOutput, "json", false, "Output results in JSON format")
 	flag.BoolVar(&doPost, "post", false, "Post JSON output to server (implies --json)")
 	flag.StringVar(&postURL, "url", defaultPostURL, "URL to post JSON output to (only used with --post)")
+	flag.StringVar(&configFile, "config", "", "Path to a JSON configuration file")
+	flag.StringVar(&ciMode, "ci", "", "Report policy violations as CI annotations: github, gitlab or azure (implies --eval)")
+	flag.StringVar(&evalCmd, "eval-cmd", "", "Command template to evaluate java executables (default \""+defaultEvalCommand+"\")")
+	flag.StringVar(&pathsFrom, "paths-from", "", "Check the directories and java executables listed one per line in this file, - for stdin, instead of scanning directories")
+	flag.BoolVar(&useIndex, "use-index", false, "Query the file name index (plocate/mlocate, Everything, Spotlight) instead of scanning directories")
+	flag.BoolVar(&useMFT, "use-mft", false, "Enumerate files from the NTFS Master File Table (Windows, requires admin rights)")
+	flag.BoolVar(&checkModules, "modules", false, "Report the modules of each runtime and warn about missing required modules")
+	flag.StringVar(&enrich, "enrich", "", "Comma separated list of enrichers to run on every result: "+strings.Join(enricherNames(), ", "))
+	flag.StringVar(&recordFile, "record", "", "Record the HTTP interactions with the server to a transport log (only used with --post)")
+	flag.StringVar(&replayFile, "replay", "", "Replay the server responses from a transport log instead of connecting (only used with --post)")
+	flag.StringVar(&traceFile, "trace", "", "Write every decision of the walk as newline delimited JSON to this file")
+	flag.StringVar(&lang, "lang", "en", "Language of the text output: "+strings.Join(languages(), ", "))
+	flag.IntVar(&minConfidence, "min-confidence", 0, "Only report results with at least this confidence (0-100)")
+	flag.BoolVar(&twoPhase, "two-phase", false, "Report the well-known locations first, then the full scan (requires --json or --post)")
+	flag.StringVar(&shardSpec, "shard", "", "Only scan shard i of n of the top-level directories, e.g. 2/4 (merge the reports with 'jfind merge')")
+	flag.StringVar(&maxMemory, "max-memory", "", "Limit the memory used for buffered results and tune the garbage collector, e.g. 256M")
+	flag.StringVar(&maxPostSize, "max-post-size", defaultMaxPostSize, "Split reports larger than this into several POST requests, 0 to disable (only used with --post)")
+	flag.BoolVar(&publishToWMI, "wmi", false, "Publish the results as WMI instances of "+wmiRuntimeClass+" in "+wmiNamespace+" (Windows, requires admin rights)")
+	flag.StringVar(&historyFile, "history", "", "Keep the runtimes of all scans in this file and report first_seen, last_seen and departed runtimes")
+	flag.StringVar(&policyFiles, "policy", "", "Comma separated list of Rego policy files evaluated with an external opa executable in addition to the built-in policy (requires --ci)")
+	flag.StringVar(&opaCommand, "opa", "opa", "Open Policy Agent executable used for --policy")
+	flag.BoolVar(&noExec, "no-exec", false, "Evaluate runtimes from their release file without executing them (implies --eval)")
+	flag.StringVar(&avAware, "av-aware", "", "If real-time antivirus is active, evaluate without executing (no-exec) or throttle the probes (throttle)")
+	flag.StringVar(&captureOutput, "capture-output", "", "Keep up to this many bytes of the raw output of each probe and its SHA-256 hash, e.g. "+defaultCaptureSize+" (only used with --eval)")
+	flag.BoolVar(&background, "background", false, "Run with the lowest I/O and CPU priority of the operating system")
+	flag.BoolVar(&daemons, "daemons", false, "Report running Gradle, Kotlin and Maven daemons and the runtimes they use")
+	flag.BoolVar(&agents, "agents", false, "Report the Java agents configured in JAVA_TOOL_OPTIONS, _JAVA_OPTIONS and service configurations and the runtimes they attach to")
+	flag.BoolVar(&embedded, "embedded", false, "Also recognize the private runtimes of JetBrains IDEs, Eclipse, macOS application bundles and applications shipping a jre next to their jars as embedded")
+	flag.BoolVar(&appServers, "app-servers", false, "Report the Tomcat, WildFly, JBoss EAP, WebLogic, WebSphere and Jetty installations passed by the walk and the runtimes they are configured to use")
+	flag.BoolVar(&scanArchives, "scan-archives", false, "Look into the .zip, .tar.gz and .tgz files passed by the walk for JDK distributions that were never extracted and report them as packaged")
+	flag.BoolVar(&libs, "libs", false, "Fingerprint the Java archives passed by the walk and report vulnerable libraries such as log4j-core before 2.17.1")
+	flag.BoolVar(&bytecode, "bytecode", false, "Sample the class files of the applications next to the runtimes and report the Java release they require")
+	flag.BoolVar(&heapProbe, "heap", false, "Report the default heap size and container support of each runtime from -XX:+PrintFlagsFinal (only used with --eval)")
+	flag.IntVar(&benchmarkRuns, "benchmark-startup", 0, "Time java -version this many times per runtime and report the median startup latency")
+	flag.StringVar(&javaEnv, "java-env", JavaEnvRedacted, "How to report JAVA_TOOL_OPTIONS, _JAVA_OPTIONS, JDK_JAVA_OPTIONS and CLASSPATH: redacted (secrets removed), hashed or off")
+	flag.StringVar(&hostname, "hostname", "", "Report this computer name instead of resolving it")
+	flag.StringVar(&identity, "identity", "", "Comma separated sources of the computer name tried in order: cloud, fqdn, os (default os)")
+	flag.Var(&labels, "label", "Group label of the host in the report as key=value, e.g. env=prod (repeatable)")
+	flag.BoolVar(&cloudLabels, "cloud-labels", false, "Add the tags of the cloud instance to the labels of the report")
+	flag.StringVar(&encryptKey, "encrypt-key", "", "Encrypt reports to the X25519 public key of the collector in this PEM or JWK file (only used with --post)")
+	flag.StringVar(&acksSource, "acks", "", "Acknowledged findings from a JSON file or the acknowledgments URL of the collector, which no longer alert")
+	flag.IntVar(&workers, "workers", 0, "Number of directories read and runtimes evaluated concurrently (default GOMAXPROCS, 1 with -first or -max-results, 1 walks sequentially)")
+	flag.StringVar(&shareCredentials, "share-credentials", "", "File with the username=, password= and domain= of an smb:// share (only used with a share path)")
+	flag.StringVar(&shareBandwidth, "share-bandwidth", "", "Limit the bytes read from a share per second, e.g. 1M (only used with a share path)")
+	flag.IntVar(&compat, "compat", 0, "Emit the report in an older schema version for collectors that cannot parse the current one: 1 is the original flat schema (requires --json or --post)")
+	flag.StringVar(&lockFile, "lock-file", defaultLockFile(), "Lock file that keeps scans of this host from running at the same time")
+	flag.DurationVar(&lockWait, "wait", 0, "If another scan is running, wait this long for it to finish instead of exiting, e.g. 30m")
+	flag.BoolVar(&force, "force", false, "Scan even if another scan is running")
+	flag.StringVar(&baselineFile, "baseline", "", "Report of a previous scan to scan incrementally from: only directories changed since are read and unchanged runtimes are not probed again (requires --eval)")
+	flag.StringVar(&checkpointFile, "checkpoint", "", "Save the progress of the walk to this file periodically, so an interrupted scan can be resumed")
+	flag.StringVar(&resumeFile, "resume", "", "Continue the scan saved in this checkpoint file, saving the progress to it (or -checkpoint)")
+	flag.BoolVar(&watch, "watch", false, "After the scan, watch the start paths and report again whenever a java executable appears or disappears, until interrupted (implies --json)")
+	flag.IntVar(&maxResults, "max-results", 0, "Stop the scan once this many java executables are found (0 is no limit)")
+	flag.BoolVar(&firstOnly, "first", false, "Stop the scan at the first java executable found, same as -max-results 1")
+	flag.DurationVar(&timeout, "timeout", 0, "Stop the scan after this long, e.g. 30m, and report the runtimes found so far with timed_out (0 is no limit)")
 	flag.Parse()
 
-	if doPost {
-		jsonOutput = true
+	var priority string
+	if background {
+		var err error
+		if priority, err = enterBackgroundMode(); err != nil {
+			logf("Warning: %v, running with normal priority\n", err)
+		}
 	}
 
-	// Convert relative path to absolute
-	absPath, err := filepath.Abs(startPath)
+	cfg := &Config{}
+	if configFile != "" {
+		var err error
+		if cfg, err = LoadConfig(configFile); err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := setLanguage(lang); err != nil {
+		logf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if pathsFrom != "" && (len(startPaths) > 0 || useIndex || useMFT || twoPhase || shardSpec != "" || checkpointFile != "" || resumeFile != "" || watch) {
+		logf("Error: -paths-from checks the listed paths only, it cannot be combined with -path, -use-index, -use-mft, -two-phase, -shard, -checkpoint, -resume or -watch\n")
+		os.Exit(1)
+	}
+	if firstOnly {
+		if maxResults > 1 {
+			logf("Error: -first is -max-results 1, it cannot be combined with -max-results %d\n", maxResults)
+			os.Exit(1)
+		}
+		maxResults = 1
+	}
+	if maxResults < 0 {
+		logf("Error: -max-results must not be negative\n")
+		os.Exit(1)
+	}
+	if maxResults > 0 && (watch || checkpointFile != "" || resumeFile != "" || historyFile != "") {
+		logf("Error: -max-results and -first stop the scan early, they cannot be combined with -watch, -checkpoint, -resume or -history\n")
+		os.Exit(1)
+	}
+	nameMatcher, err := newNameMatcher(names, namePattern)
 	if err != nil {
-		logf("Error resolving path: %v\n", err)
+		logf("Error: %v\n", err)
 		os.Exit(1)
 	}
-
-	logf("Start scanning (platform '%s') from path '%s'\n", runtime.GOOS, absPath)
-	finder := NewJavaFinder(absPath, maxDepth, verbose, evaluate)
-	startTime := time.Now()
-	results, err := finder.Find()
+	if nameMatcher != nil && (useIndex || useMFT) {
+		logf("Error: -names and -name-pattern apply to the walk and -paths-from, the indexes of -use-index and -use-mft only know java\n")
+		os.Exit(1)
+	}
+	if scanArchives && (useIndex || useMFT) {
+		logf("Error: -scan-archives looks into the archives passed by the walk, it cannot be combined with -use-index or -use-mft\n")
+		os.Exit(1)
+	}
+	if len(startPaths) == 0 {
+		startPaths = pathList{"."}
+	}
+	// A start path given as path:depth has a maximum depth of its own
+	pathDepths := make(map[int]int)
+	for i, path := range startPaths {
+		if path, depth, ok := rootDepth(path); ok {
+			startPaths[i], pathDepths[i] = path, depth
+		}
+	}
+	share, err := parseShareURL(startPaths[0])
 	if err != nil {
-		logf("Error during search: %v\n", err)
+		logf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(startPaths) > 1 && (share != nil || slices.ContainsFunc(startPaths[1:], func(path string) bool { return strings.Contains(path, "://") })) {
+		logf("Error: a share is scanned on its own, not together with other start paths\n")
+		os.Exit(1)
+	}
+	if share != nil && hostname == "" {
+		// The runtimes belong to the host exporting the share
+		hostname = share.Host
+	}
+	if err := configureIdentity(hostname, identity, cfg); err != nil {
+		logf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := configureLabels(labels, cloudLabels, cfg); err != nil {
+		logf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	var acks []Acknowledgment
+	if acksSource != "" {
+		var err error
+		if acks, err = loadAcknowledgments(acksSource, getComputerName(), time.Now()); err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := validJavaEnvMode(javaEnv); err != nil {
+		logf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if doPost || watch {
+		jsonOutput = true
+	}
+	if watch && (ciMode != "" || twoPhase || shardSpec != "" || useIndex || useMFT || checkpointFile != "" || resumeFile != "") {
+		logf("Error: -watch cannot be combined with -ci, -two-phase, -shard, -use-index, -use-mft, -checkpoint or -resume\n")
+		os.Exit(1)
+	}
+	if twoPhase && (!jsonOutput || ciMode != "") {
+		logf("Error: -two-phase requires -json or -post\n")
+		os.Exit(1)
+	}
+	if compat == reportSchemaVersion {
+		compat = 0
+	}
+	switch {
+	case compat != 0 && compat != legacySchema:
+		logf("Error: unknown -compat schema version %d\n", compat)
+		os.Exit(1)
+	case compat != 0 && (!jsonOutput || ciMode != ""):
+		logf("Error: -compat requires -json or -post\n")
+		os.Exit(1)
+	case compat != 0 && twoPhase:
+		// The original schema has no report phase, the preliminary report would be taken for a scan
+		logf("Error: -compat %d cannot be combined with -two-phase\n", compat)
+		os.Exit(1)
+	}
+	if timeout < 0 {
+		logf("Error: -timeout must not be negative\n")
+		os.Exit(1)
+	}
+	if lockWait < 0 {
+		logf("Error: -wait must not be negative\n")
+		os.Exit(1)
+	}
+	if noExec {
+		evaluate = true
+	}
+	if benchmarkRuns < 0 {
+		logf("Error: -benchmark-startup requires a positive number of runs\n")
+		os.Exit(1)
+	}
+	if workers < 0 {
+		logf("Error: -workers requires a positive number of workers\n")
+		os.Exit(1)
+	}
+	if avAware != "" && avAware != AVAwareNoExec && avAware != AVAwareThrottle {
+		logf("Error: unsupported -av-aware mode '%s' (use no-exec or throttle)\n", avAware)
+		os.Exit(1)
+	}
+	var policy *regoPolicy
+	if policyFiles != "" {
+		if ciMode == "" {
+			logf("Error: -policy requires -ci\n")
+			os.Exit(1)
+		}
+		var err error
+		if policy, err = newRegoPolicy(strings.Split(policyFiles, ","), opaCommand); err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if ciMode != "" {
+		if !isCIMode(ciMode) {
+			logf("Error: unsupported CI mode '%s' (use github, gitlab or azure)\n", ciMode)
+			os.Exit(1)
+		}
+		evaluate = true
+	}
+	if baselineFile != "" && !evaluate {
+		logf("Error: -baseline carries probe results forward, it requires -eval\n")
+		os.Exit(1)
+	}
+	if share != nil && watch {
+		logf("Error: a share is mounted for the scan only, it cannot be watched\n")
+		os.Exit(1)
+	}
+	if share != nil {
+		if twoPhase || useIndex || useMFT || daemons || agents || bytecode || appServers {
+			logf("Error: -two-phase, -use-index, -use-mft, -daemons, -agents, -bytecode and -app-servers describe this host and cannot be used with a share path\n")
+			os.Exit(1)
+		}
+		// The runtimes of another host are never executed, they may not even run here
+		noExec = noExec || evaluate
+	} else if shareCredentials != "" || shareBandwidth != "" {
+		logf("Error: -share-credentials and -share-bandwidth require a share path\n")
 		os.Exit(1)
 	}
 
-	if jsonOutput {
-		// Get meta information
-		currentUser, _ := user.Current()
-		username := "unknown"
-		if currentUser != nil {
-			username = currentUser.Username
+	// Convert relative paths to absolute
+	var absPaths []string
+	rootDepths := make(map[string]int)
+	for i, path := range startPaths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			logf("Error resolving path: %v\n", err)
+			os.Exit(1)
 		}
+		absPaths = append(absPaths, absPath)
+		if depth, ok := pathDepths[i]; ok {
+			if _, seen := rootDepths[absPath]; !seen {
+				rootDepths[absPath] = depth
+			}
+		}
+	}
+	if share != nil {
+		absPaths = []string{share.String()}
+		if depth, ok := pathDepths[0]; ok {
+			// The share is mounted elsewhere, its depth is that of the scan
+			maxDepth = depth
+		}
+	}
+	absPaths, dropped := normalizeRoots(absPaths)
+	for _, path := range dropped {
+		logf("Warning: %s is already scanned as part of another start path\n", path)
+	}
+	if len(absPaths) > 1 && (useIndex || useMFT) {
+		logf("Error: -use-index and -use-mft take a single start path\n")
+		os.Exit(1)
+	}
+	if (checkpointFile != "" || resumeFile != "") && (useIndex || useMFT) {
+		logf("Error: -checkpoint and -resume save the walk of the file system, they cannot be combined with -use-index or -use-mft\n")
+		os.Exit(1)
+	}
+	absPath := absPaths[0]
 
-		hasOracle := false
-		duration := formatDurationISO8601(time.Since(startTime))
-		output := JSONOutput{
-			Meta: MetaInfo{
-				ScanTimestamp: time.Now().UTC().Format(time.RFC3339),
-				ComputerName:  getComputerName(),
-				UserName:      username,
-				ScanDuration:  duration,
-				HasOracleJDK:  false,
-				CountResult:   len(results),
-				ScannedDirs:   finder.scanned,
-			},
-			Runtimes: make([]JavaRuntimeJSON, 0),
+	var maxMemoryBytes int64
+	if maxMemory != "" {
+		if maxMemoryBytes, err = parseByteSize(maxMemory); err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
 		}
+	}
+	limits := applyResourceLimits(maxMemoryBytes)
+	maxPostBytes, err := parsePostSize(maxPostSize)
+	if err != nil {
+		logf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
-		for _, result := range results {
-			runtime := JavaRuntimeJSON{
-				JavaExecutable: result.Path,
+	logf("Start scanning (platform '%s') from path '%s'\n", runtime.GOOS, strings.Join(absPaths, "', '"))
+	finder := NewJavaFinder(absPath, maxDepth, verbose, evaluate)
+	if len(absPaths) > 1 {
+		finder.roots = absPaths
+	}
+	finder.rootDepths = rootDepths
+	finder.limits = &limits
+	finder.background = priority
+	finder.javaEnv = javaEnv
+	finder.acks = acks
+	finder.maxResultBytes = maxMemoryBytes / 2
+	finder.maxResults = maxResults
+	finder.minConfidence = minConfidence
+	finder.workers = workers
+	finder.exclude = exclude
+	finder.prune = parsePruneDirs(pruneDirs)
+	finder.names = nameMatcher
+	finder.embedded = embedded
+	finder.archives = scanArchives
+	finder.followSymlinks = followSymlinks
+	if baselineFile != "" {
+		if finder.baseline, err = loadBaseline(baselineFile); err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	finder.skipNetworkMounts = skipNetworkMounts
+	finder.oneFilesystem = oneFilesystem
+	if workers == 0 {
+		finder.workers = limits.GoMaxProcs
+		if maxResults > 0 {
+			// Concurrent workers find and probe runtimes in any order, which would
+			// decide what -first and -max-results report
+			finder.workers = 1
+		}
+	}
+	finder.share = share
+	if shareBandwidth != "" {
+		rate, err := parseByteSize(shareBandwidth)
+		if err != nil || rate <= 0 {
+			logf("Error: invalid -share-bandwidth %s\n", shareBandwidth)
+			os.Exit(1)
+		}
+		finder.bandwidth = &bandwidthLimiter{rate: rate}
+	}
+	finder.environment = detectRuntimeEnvironment()
+	finder.skipVirtualFS = finder.environment == EnvContainer
+	if verbose {
+		logf("Runtime environment: %s\n", finder.environment)
+		logf("Resource limits: %.2f CPUs, %d bytes memory, GOMAXPROCS %d\n", limits.CPUs, limits.MemoryBytes, limits.GoMaxProcs)
+	}
+	finder.classifier = newPathClassifier(cfg.PathRules)
+	finder.trust = newEvalTrust(cfg.TrustedPaths, cfg.UntrustedPaths)
+	finder.costs = cfg.LicenseCosts
+	if finder.replacements, err = loadReplacementRules(cfg.Replacements); err != nil {
+		logf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if finder.releases, err = loadReleaseCatalog(cfg.ReleaseCatalog, cfg.vendorAPI()); err != nil {
+		if finder.releases == nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		logf("Warning: %v\n", err)
+	}
+	if evaluate {
+		finder.evalMode = EvalModeExec
+		if avAware != "" {
+			finder.realtimeAV = detectRealtimeAV()
+		}
+		switch {
+		case noExec || (finder.realtimeAV != "" && avAware == AVAwareNoExec):
+			finder.noExec = true
+			finder.evalMode = EvalModeNoExec
+		case finder.realtimeAV != "" && avAware == AVAwareThrottle:
+			finder.throttle = &probeThrottle{interval: avThrottleInterval}
+			finder.evalMode = EvalModeThrottled
+		}
+		if finder.realtimeAV != "" {
+			logf("Real-time antivirus detected (%s), evaluation mode %s\n", finder.realtimeAV, finder.evalMode)
+		}
+		if captureOutput != "" {
+			captureBytes, err := parseByteSize(captureOutput)
+			if err != nil {
+				logf("Error: %v\n", err)
+				os.Exit(1)
 			}
+			finder.captureBytes = int(captureBytes)
+		}
+	}
+	finder.benchmarkRuns = benchmarkRuns
+	finder.heapProbe = heapProbe
+	if finder.noExec && benchmarkRuns > 0 {
+		logf("Warning: startup benchmark skipped, runtimes are not executed\n")
+	}
+	finder.checkModules = checkModules
+	if len(cfg.RequiredModules) > 0 {
+		finder.requiredModules = cfg.RequiredModules
+	}
+	enricherList := cfg.Enrichers
+	if enrich != "" {
+		enricherList = strings.Split(enrich, ",")
+	}
+	if finder.enrichers, err = newEnrichers(enricherList, cfg); err != nil {
+		logf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	for source, budget := range cfg.budgets {
+		finder.budgets[source] = budget
+	}
+	if shardSpec != "" {
+		if finder.shard, err = parseShard(shardSpec); err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if evalCmd == "" {
+		evalCmd = cfg.EvalCommand
+	}
+	if evalCmd != "" {
+		if finder.evalCmd, err = parseEvalCommand(evalCmd); err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if verbose {
+			logf("Evaluation command: %s\n", finder.evalCmd)
+		}
+	}
+	if pathsFrom != "" {
+		if finder.index, err = newPathListSource(pathsFrom); err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if useMFT {
+		if finder.index, err = newMFTSource(absPath); err != nil {
+			logf("Warning: %v, scanning the file system\n", err)
+		}
+	} else if useIndex {
+		if finder.index, err = newIndexSource(absPath); err != nil {
+			logf("Warning: %v, scanning the file system\n", err)
+		}
+	}
+	if traceFile != "" {
+		file, err := os.Create(traceFile)
+		if err != nil {
+			logf("Error: failed to create trace file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		finder.trace = newTracer(file)
+	}
+	if historyFile != "" {
+		if finder.history, err = loadHistory(historyFile); err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if daemons {
+		if finder.daemons, err = detectDaemons(); err != nil {
+			logf("Warning: %v\n", err)
+		}
+	}
+	if libs {
+		rules, err := loadLibraryRules(cfg.VulnerableLibraries)
+		if err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		finder.libraries = &libraryScanner{rules: rules}
+	}
+	if appServers {
+		finder.appServers = &appServerScanner{}
+	}
+	if agents {
+		finder.agents = detectJavaAgents(slices.Concat(defaultAgentConfigs, cfg.AgentConfigs), javaEnv == JavaEnvRedacted)
+	}
+	var client *http.Client
+	closeLog := func() error { return nil }
+	if doPost {
+		if client, closeLog, err = newHTTPClient(recordFile, replayFile); err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if encryptKey != "" {
+			key, err := loadPayloadKey(encryptKey)
+			if err != nil {
+				logf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			client = withPayloadEncryption(client, key)
+			maxPostBytes = encryptedPostSize(maxPostBytes)
+		}
+	}
+	filter := func(results []*JavaResult) []*JavaResult {
+		if minConfidence <= 0 {
+			return results
+		}
+		return slices.DeleteFunc(results, func(result *JavaResult) bool {
+			return result.Confidence < minConfidence
+		})
+	}
 
-			if evaluate && result.Properties != nil && result.Error == nil && result.ReturnCode == 0 {
-				runtime.JavaVersion = result.Properties.Version
-				runtime.JavaVendor = result.Properties.Vendor
-				runtime.JavaRuntime = result.Properties.RuntimeName
-				runtime.IsOracle = strings.Contains(result.Properties.Vendor, "Oracle")
-				runtime.VersionMajor = result.Properties.Major
-				runtime.VersionUpdate = result.Properties.Update
-				if runtime.IsOracle {
-					hasOracle = true
+	// The lock is held until the scan is reported and released before jfind exits
+	var lock *scanLock
+	exit := func(code int) {
+		lock.release()
+		os.Exit(code)
+	}
+	if !force {
+		lock, err = acquireScanLock(lockFile, lockWait)
+		var locked *ScanLockedError
+		if errors.As(err, &locked) {
+			logf("Error: %v, use -wait to wait for it or -force to scan anyway\n", err)
+			os.Exit(1)
+		} else if err != nil {
+			logf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if lock.Stale != nil {
+			logf("Warning: recovered the lock of scan pid %d started %s, which did not finish\n", lock.Stale.PID, lock.Stale.Started)
+		}
+	}
+	defer lock.release()
+	startTime := finder.sys.Clock.Now()
+	if share == nil {
+		finder.defaultRuntime = detectDefaultRuntime()
+	}
+	if progress {
+		finder.progress = &scanProgress{}
+		finder.progress.start(os.Stderr, finder.sys.Clock, isTerminal(os.Stderr))
+	}
+	if twoPhase {
+		preliminary, err := finder.FindWellKnown()
+		if err != nil {
+			logf("Warning: quick scan of well-known locations failed: %v\n", err)
+		}
+		output := buildJSONOutput(filter(preliminary), finder, startTime)
+		output.Meta.ReportPhase = PhasePreliminary
+		output.Meta.Complete = false
+		if err := reportJSON(output, client, postURL, maxPostBytes); err != nil {
+			logf("Warning: failed to send preliminary report: %v\n", err)
+		}
+	}
+	var unmount func() error
+	if share != nil {
+		var root string
+		if root, unmount, err = mountShare(share, shareCredentials); err != nil {
+			logf("Error: %v\n", err)
+			exit(1)
+		}
+		if verbose {
+			logf("Mounted %s on %s\n", share, root)
+		}
+		finder.startPath = root
+	}
+	// The checkpoint of a share is kept by its URL, it is mounted elsewhere on resume
+	checkpointPaths := finder.startPaths()
+	if share != nil {
+		checkpointPaths = []string{share.String()}
+	}
+	if resumeFile != "" {
+		if checkpointFile == "" {
+			checkpointFile = resumeFile
+		}
+		if finder.checkpoint, err = loadCheckpoint(resumeFile, checkpointFile, checkpointPaths); err != nil {
+			logf("Error: %v\n", err)
+			if unmount != nil {
+				if err := unmount(); err != nil {
+					logf("Warning: %v\n", err)
 				}
-			} else if evaluate && (result.Error != nil || result.ReturnCode != 0) {
-				runtime.ExecFailed = true
 			}
-
-			runtime.checkLicenseRequirement()
-
-			output.Runtimes = append(output.Runtimes, runtime)
+			exit(1)
 		}
+		if verbose {
+			logf("Resuming the scan saved at %s\n", finder.checkpoint.state.Saved)
+		}
+	} else if checkpointFile != "" {
+		finder.checkpoint = newCheckpoint(checkpointFile, checkpointPaths)
+	}
+	if share != nil && finder.checkpoint != nil {
+		finder.checkpoint.mountedAt(finder.startPath, share.String())
+	}
+	scanCtx, cancelScan := context.Background(), context.CancelFunc(func() {})
+	if timeout > 0 {
+		scanCtx, cancelScan = context.WithTimeout(scanCtx, timeout)
+	}
+	if unmount != nil {
+		// An interrupt stops the scan instead of jfind, so the share is unmounted
+		var stop context.CancelFunc
+		scanCtx, stop = signal.NotifyContext(scanCtx, os.Interrupt, syscall.SIGTERM)
+		cancelTimeout := cancelScan
+		cancelScan = func() {
+			stop()
+			cancelTimeout()
+		}
+	}
+	results, err := finder.FindContext(scanCtx)
+	cancelScan()
+	finder.progress.finish()
+	if finder.timedOut {
+		logf("Warning: the scan timed out after %v, the results are incomplete\n", timeout)
+	}
+	if unmount != nil {
+		share.report(finder.startPath, results, finder.libraries, finder.classifier)
+		finder.startPath = share.String()
+		if err := unmount(); err != nil {
+			logf("Warning: %v\n", err)
+		}
+	}
+	if err := finder.trace.Err(); err != nil {
+		logf("Warning: failed to write trace: %v\n", err)
+	}
+	if err != nil {
+		logf("Error during search: %v\n", err)
+		exit(1)
+	}
+	if finder.history != nil {
+		finder.history.record(results, finder.startPaths(), finder.shard, finder.sys.Clock.Now())
+		if err := finder.history.save(); err != nil {
+			logf("Warning: %v\n", err)
+		}
+	}
+	found := results
+	results = filter(results)
+	if bytecode {
+		finder.census = takeBytecodeCensus(results)
+	}
 
-		// Update hasOracle after scanning all results
-		output.Meta.HasOracleJDK = hasOracle
-
-		jsonData, err := json.MarshalIndent(output, "", "  ")
-		if err != nil {
-			logf("Error generating JSON output: %v\n", err)
-			os.Exit(1)
+	if publishToWMI {
+		if err := publishWMI(buildJSONOutput(results, finder, startTime)); err != nil {
+			logf("Warning: failed to publish WMI instances: %v\n", err)
+		} else if verbose {
+			logf("Published %d runtimes to WMI namespace %s\n", len(results), wmiNamespace)
 		}
+	}
 
-		if doPost {
-			logf("Posting JSON to %s...\n", postURL)
-			if err := sendJSON(jsonData, postURL); err != nil {
+	switch {
+	case ciMode != "":
+		output := buildJSONOutput(results, finder, startTime)
+		violations := findViolations(&output)
+		if policy != nil {
+			regoViolations, err := policy.evaluate(&output)
+			if err != nil {
 				logf("Error: %v\n", err)
-				os.Exit(1)
+				exit(1)
 			}
+			violations = append(violations, dropAcknowledged(regoViolations, &output)...)
+		}
+		exit(writeCIReport(os.Stdout, ciMode, violations))
+	case jsonOutput:
+		output := buildJSONOutput(results, finder, startTime)
+		if twoPhase {
+			output.Meta.ReportPhase = PhaseFinal
+		}
+		var err error
+		if compat == legacySchema {
+			err = reportLegacyJSON(output, client, postURL)
 		} else {
-			os.Stdout.Write(jsonData)
+			err = reportJSON(output, client, postURL, maxPostBytes)
 		}
-	} else {
+		if err == nil && watch {
+			err = runWatch(finder, found, func(results []*JavaResult) error {
+				finder.scanID = newScanID()
+				output := buildJSONOutput(filter(results), finder, finder.sys.Clock.Now())
+				if compat == legacySchema {
+					return reportLegacyJSON(output, client, postURL)
+				}
+				return reportJSON(output, client, postURL, maxPostBytes)
+			})
+		}
+		closeLog()
+		if err != nil {
+			logf("Error: %v\n", err)
+			exit(1)
+		}
+	default:
 		for _, result := range results {
-			printResult(result)
+			printResult(result, finder.acks, finder.sys.Clock.Now())
 			printf("\n")
 		}
+		for _, drift := range findUpdateDrift(results) {
+			printf("Warning: %d update levels of %s %d installed: %s\n",
+				len(drift.Versions), drift.Vendor, drift.Major, strings.Join(drift.Versions, ", "))
+		}
+		for _, entry := range finder.history.departed() {
+			printf("Runtime no longer present: %s (last seen %s)\n", entry.JavaExecutable, entry.LastSeen)
+		}
+		if share == nil {
+			for _, warning := range finder.defaultRuntime.Warnings {
+				printf("Warning: inconsistent default runtime: %s\n", warning)
+			}
+			if env := collectJavaEnvironment(finder.javaEnv); env != nil {
+				for _, injected := range env.Injected {
+					printf("Warning: %s injects %s into every JVM\n", injected.Variable, injected.Option)
+				}
+			}
+		}
+		for _, daemon := range finder.daemons {
+			printf("Running %s daemon (pid %d): %s\n", daemon.Toolchain, daemon.PID, daemon.JavaExecutable)
+		}
+		for _, agent := range finder.agents {
+			switch {
+			case agent.Scope == AgentScopeGlobal:
+				printf("Java agent %s attached to every JVM by %s\n", agentLabel(agent), agent.Source)
+			case agent.JavaHome != "":
+				printf("Java agent %s attached to %s by %s\n", agentLabel(agent), agent.JavaHome, agent.Source)
+			default:
+				printf("Java agent %s configured in %s\n", agentLabel(agent), agent.Source)
+			}
+			if agent.Missing {
+				printf("Warning: Java agent %s does not exist\n", agent.Path)
+			}
+		}
+		if finder.appServers != nil {
+			output := buildJSONOutput(results, finder, startTime)
+			for _, server := range output.AppServers {
+				switch {
+				case server.JavaHome == "":
+					printf("Application server %s in %s runs on the default runtime\n", appServerLabel(server), server.Home)
+				case server.BundledJava && server.JavaHomeSource == "":
+					printf("Application server %s in %s runs on its bundled runtime %s\n", appServerLabel(server), server.Home, server.JavaHome)
+				default:
+					printf("Application server %s in %s runs on %s, set in %s\n", appServerLabel(server), server.Home, server.JavaHome, server.JavaHomeSource)
+				}
+				if server.OracleJDK {
+					printf("License audit: %s in %s runs on the Oracle JDK %s\n", appServerLabel(server), server.Home, server.JavaExecutable)
+				}
+			}
+		}
+		for _, result := range results {
+			if p := result.Properties; p != nil && finder.environment == EnvContainer &&
+				(p.ContainerSupport == ContainerSupportUnavailable || p.ContainerSupport == ContainerSupportDisabled) {
+				printf("Warning: %s is not container-aware and sizes its heap from the host memory\n", result.Path)
+			}
+		}
+		for _, c := range finder.census {
+			printf("Applications in %s require Java %d (%d classes in %d archives sampled)\n", c.AppDir, c.RequiredJava, c.Classes, c.Archives)
+		}
+		for _, result := range results {
+			required := requiredJava(finder.census, result.Path)
+			version, _ := runtimeVersion(result)
+			if major, _ := parseJavaVersion(version); required > 0 && major > 0 && major < required {
+				printf("Warning: %s is older than Java %d required by its applications\n", result.Path, required)
+			}
+		}
+		if finder.libraries != nil {
+			for _, lib := range finder.libraries.findings {
+				switch {
+				case lib.Mitigated:
+					printf("Mitigated library %s %s in %s: %s\n", lib.Artifact, lib.Version, lib.location(), strings.Join(lib.Advisories, ", "))
+				case len(lib.MitigatedAdvisories) > 0:
+					printf("Warning: vulnerable library %s %s in %s: %s (mitigated: %s)\n", lib.Artifact, lib.Version, lib.location(),
+						strings.Join(lib.openAdvisories(), ", "), strings.Join(lib.MitigatedAdvisories, ", "))
+				default:
+					printf("Warning: vulnerable library %s %s in %s: %s\n", lib.Artifact, lib.Version, lib.location(), strings.Join(lib.Advisories, ", "))
+				}
+			}
+		}
+		if finder.costs != nil {
+			output := buildJSONOutput(results, finder, startTime)
+			printCostEstimate(output.CostEstimate)
+		}
 	}
 }
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// loadReport reads a JSON report written with -json, or a sealed one of
+// jfind serve -seal-reports
+func loadReport(path string) (*JSONOutput, error) {
+	data, err := readReportFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report %s: %v", path, err)
+	}
+	// Reports of older versions are upgraded so they can be merged with current ones
+	if data, err = migrateReport(data, 0, reportSchemaVersion); err != nil {
+		return nil, fmt.Errorf("failed to migrate report %s: %v", path, err)
+	}
+	var report JSONOutput
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %v", path, err)
+	}
+	return &report, nil
+}
+
+// mergeReports combines the reports of the shards of one scan into a single
+// report. Runtimes found by several shards are only reported once.
+func mergeReports(reports []*JSONOutput) (JSONOutput, error) {
+	merged := JSONOutput{
+		Meta: MetaInfo{
+			SchemaVersion: reportSchemaVersion,
+			ScanID:        newScanID(),
+			ScanTimestamp: time.Now().UTC().Format(time.RFC3339),
+			PathClasses:   make(map[PathClass]int),
+		},
+		Runtimes: make([]JavaRuntimeJSON, 0),
+	}
+
+	var longest time.Duration
+	var computers, users []string
+	shardCount := 0
+	seen := make(map[string]bool)
+	for _, report := range reports {
+		meta := report.Meta
+		if meta.Shard != "" {
+			s, err := parseShard(meta.Shard)
+			if err != nil {
+				return merged, err
+			}
+			if shardCount != 0 && s.count != shardCount {
+				return merged, fmt.Errorf("cannot merge shards of %d and %d processes", shardCount, s.count)
+			}
+			shardCount = s.count
+			if slices.Contains(merged.Meta.MergedShards, meta.Shard) {
+				return merged, fmt.Errorf("shard %s given twice", meta.Shard)
+			}
+			merged.Meta.MergedShards = append(merged.Meta.MergedShards, meta.Shard)
+		}
+		if !slices.Contains(computers, meta.ComputerName) {
+			computers = append(computers, meta.ComputerName)
+		}
+		if !slices.Contains(users, meta.UserName) {
+			users = append(users, meta.UserName)
+		}
+		if d, err := parseDurationISO8601(meta.ScanDuration); err == nil && d > longest {
+			longest = d
+		}
+
+		merged.Meta.ScannedDirs += meta.ScannedDirs
+		merged.Meta.ExcludedDirs += meta.ExcludedDirs
+		merged.Meta.TimedOut = merged.Meta.TimedOut || meta.TimedOut
+		merged.Meta.ScanErrors = append(merged.Meta.ScanErrors, meta.ScanErrors...)
+		merged.Meta.PrunedMounts = append(merged.Meta.PrunedMounts, meta.PrunedMounts...)
+		merged.Meta.SourceTimings = append(merged.Meta.SourceTimings, meta.SourceTimings...)
+		if merged.DefaultRuntime == nil {
+			merged.DefaultRuntime = report.DefaultRuntime
+		}
+		if merged.JavaEnv == nil {
+			merged.JavaEnv = report.JavaEnv
+		}
+		if merged.Daemons == nil {
+			merged.Daemons = report.Daemons
+		}
+		if merged.JavaAgents == nil {
+			merged.JavaAgents = report.JavaAgents
+		}
+		merged.CostEstimate = merged.CostEstimate.merge(report.CostEstimate)
+		for _, c := range report.BytecodeCensus {
+			if !slices.ContainsFunc(merged.BytecodeCensus, func(m BytecodeCensus) bool { return m.AppDir == c.AppDir }) {
+				merged.BytecodeCensus = append(merged.BytecodeCensus, c)
+			}
+		}
+		for _, lib := range report.VulnerableLibraries {
+			if !slices.ContainsFunc(merged.VulnerableLibraries, func(l LibraryFinding) bool { return l.location() == lib.location() && l.Artifact == lib.Artifact }) {
+				merged.VulnerableLibraries = append(merged.VulnerableLibraries, lib)
+			}
+		}
+		for _, entry := range report.Quarantined {
+			if !slices.Contains(merged.Quarantined, entry) {
+				merged.Quarantined = append(merged.Quarantined, entry)
+			}
+		}
+		for _, ack := range report.Acknowledgments {
+			if !slices.Contains(merged.Acknowledgments, ack) {
+				merged.Acknowledgments = append(merged.Acknowledgments, ack)
+			}
+		}
+		if merged.Meta.DiscoverySource == "" {
+			merged.Meta.DiscoverySource = meta.DiscoverySource
+			merged.Meta.RuntimeEnvironment = meta.RuntimeEnvironment
+		}
+		for _, runtime := range report.Runtimes {
+			if seen[runtime.JavaExecutable] {
+				continue
+			}
+			seen[runtime.JavaExecutable] = true
+			merged.Runtimes = append(merged.Runtimes, runtime)
+		}
+	}
+
+	// Departed runtimes of one shard may be present in another
+	for _, report := range reports {
+		for _, entry := range report.Departed {
+			if !seen[entry.JavaExecutable] {
+				seen[entry.JavaExecutable] = true
+				merged.Departed = append(merged.Departed, entry)
+			}
+		}
+	}
+
+	merged.Meta.Labels = mergeLabels(reports)
+
+	var results []*JavaResult
+	for _, runtime := range merged.Runtimes {
+		merged.Meta.HasOracleJDK = merged.Meta.HasOracleJDK || runtime.IsOracle
+		merged.Meta.PathClasses[PathClass(runtime.PathClass)]++
+		if runtime.ProvisionedBy != "" {
+			merged.Meta.BuildToolProvisioned++
+		}
+		if runtime.VersionMajor > 0 {
+			results = append(results, &JavaResult{
+				Path: runtime.JavaExecutable,
+				Properties: &JavaProperties{
+					Version: runtime.JavaVersion,
+					Vendor:  runtime.JavaVendor,
+					Major:   runtime.VersionMajor,
+					Update:  runtime.VersionUpdate,
+				},
+			})
+		}
+	}
+	merged.Meta.UpdateDrift = findUpdateDrift(results)
+	merged.Meta.CountResult = len(merged.Runtimes)
+	merged.Meta.ComputerName = strings.Join(computers, ",")
+	merged.Meta.UserName = strings.Join(users, ",")
+	merged.Meta.ScanDuration = formatDurationISO8601(longest)
+
+	if shardCount > 0 && len(merged.Meta.MergedShards) != shardCount {
+		logf("Warning: merged %d of %d shards, the report is incomplete\n", len(merged.Meta.MergedShards), shardCount)
+	}
+	slices.Sort(merged.Meta.MergedShards)
+	return merged, nil
+}
+
+// parseDurationISO8601 parses the durations written by formatDurationISO8601
+func parseDurationISO8601(s string) (time.Duration, error) {
+	rest, ok := strings.CutPrefix(s, "PT")
+	if !ok || rest == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return time.ParseDuration(strings.ToLower(rest))
+}
+
+// runMerge implements the merge command and returns the exit code
+func runMerge(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	doPost := fs.Bool("post", false, "Post the merged report to server instead of writing it to stdout")
+	postURL := fs.String("url", defaultPostURL, "URL to post the merged report to (only used with --post)")
+	maxPostSize := fs.String("max-post-size", defaultMaxPostSize, "Split reports larger than this into several POST requests, 0 to disable (only used with --post)")
+	encryptKey := fs.String("encrypt-key", "", "Encrypt the merged report to the X25519 public key of the collector in this PEM or JWK file (only used with --post)")
+	fs.Usage = func() {
+		logf("Usage: jfind merge [options] <report.json>...\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	maxPostBytes, err := parsePostSize(*maxPostSize)
+	if err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+
+	var reports []*JSONOutput
+	for _, path := range fs.Args() {
+		report, err := loadReport(path)
+		if err != nil {
+			logf("Error: %v\n", err)
+			return 1
+		}
+		reports = append(reports, report)
+	}
+	merged, err := mergeReports(reports)
+	if err != nil {
+		logf("Error: %v\n", err)
+		return 1
+	}
+
+	var client *http.Client
+	if *doPost {
+		client = http.DefaultClient
+		if *encryptKey != "" {
+			key, err := loadPayloadKey(*encryptKey)
+			if err != nil {
+				logf("Error: %v\n", err)
+				return 2
+			}
+			client = withPayloadEncryption(client, key)
+			maxPostBytes = encryptedPostSize(maxPostBytes)
+		}
+	}
+	if err := reportJSON(merged, client, *postURL, maxPostBytes); err != nil {
+		logf("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
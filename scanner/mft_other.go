@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// newMFTSource is only supported on Windows
+func newMFTSource(root string) (candidateSource, error) {
+	return nil, fmt.Errorf("MFT enumeration is not supported on %s", runtime.GOOS)
+}
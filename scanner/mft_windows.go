@@ -0,0 +1,162 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+const (
+	// fsctlEnumUsnData enumerates the records of the NTFS Master File Table
+	fsctlEnumUsnData = 0x000900b3
+
+	// ntfsRootRecord is the MFT record number of the volume root directory
+	ntfsRootRecord = 5
+
+	// frnRecordMask strips the sequence number from a file reference number
+	frnRecordMask = 0x0000ffffffffffff
+)
+
+// mftEnumData is the MFT_ENUM_DATA_V0 input structure of FSCTL_ENUM_USN_DATA
+type mftEnumData struct {
+	StartFileReferenceNumber uint64
+	LowUsn                   int64
+	HighUsn                  int64
+}
+
+// mftEntry is a file name and its parent directory from the MFT
+type mftEntry struct {
+	parent uint64
+	name   string
+}
+
+// mftIndex enumerates all file names of an NTFS volume from its Master File Table,
+// which is much faster than walking the directories. It requires administrator rights.
+type mftIndex struct {
+	volume string // e.g. "C:"
+}
+
+// newMFTSource returns a candidate source reading the MFT of the volume of root
+func newMFTSource(root string) (candidateSource, error) {
+	volume := filepath.VolumeName(root)
+	if len(volume) != 2 || volume[1] != ':' {
+		return nil, fmt.Errorf("MFT enumeration requires a local drive, got %q", root)
+	}
+	return &mftIndex{volume: strings.ToUpper(volume)}, nil
+}
+
+// Name returns the name of the source
+func (m *mftIndex) Name() string {
+	return "mft"
+}
+
+// Candidates returns all files named java.exe on the volume
+func (m *mftIndex) Candidates(ctx context.Context) ([]string, error) {
+	path, err := syscall.UTF16PtrFromString(`\\.\` + m.volume)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := syscall.CreateFile(path, syscall.GENERIC_READ,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE, nil, syscall.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		if err == syscall.ERROR_ACCESS_DENIED {
+			return nil, fmt.Errorf("opening volume %s requires administrator rights", m.volume)
+		}
+		return nil, fmt.Errorf("failed to open volume %s: %v", m.volume, err)
+	}
+	defer syscall.CloseHandle(handle)
+
+	entries := make(map[uint64]mftEntry)
+	var matches []uint64
+
+	input := mftEnumData{HighUsn: math.MaxInt64}
+	buf := make([]byte, 1<<20)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var returned uint32
+		err := syscall.DeviceIoControl(handle, fsctlEnumUsnData,
+			(*byte)(unsafe.Pointer(&input)), uint32(unsafe.Sizeof(input)),
+			&buf[0], uint32(len(buf)), &returned, nil)
+		if err == syscall.ERROR_HANDLE_EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to enumerate MFT of %s: %v", m.volume, err)
+		}
+		if returned < 8 {
+			break
+		}
+
+		// The output starts with the reference number to continue from
+		input.StartFileReferenceNumber = binary.LittleEndian.Uint64(buf[0:8])
+		for offset := uint32(8); offset+60 <= returned; {
+			record := buf[offset:returned]
+			length := binary.LittleEndian.Uint32(record[0:4])
+			if length == 0 || length > uint32(len(record)) {
+				break
+			}
+			// Only USN_RECORD_V2 is produced for MFT_ENUM_DATA_V0 on NTFS
+			if binary.LittleEndian.Uint16(record[4:6]) == 2 {
+				frn := binary.LittleEndian.Uint64(record[8:16]) & frnRecordMask
+				parent := binary.LittleEndian.Uint64(record[16:24]) & frnRecordMask
+				nameLength := uint32(binary.LittleEndian.Uint16(record[56:58]))
+				nameOffset := uint32(binary.LittleEndian.Uint16(record[58:60]))
+				if nameOffset+nameLength <= length {
+					name := decodeUTF16(record[nameOffset : nameOffset+nameLength])
+					entries[frn] = mftEntry{parent: parent, name: name}
+					if strings.EqualFold(name, "java.exe") {
+						matches = append(matches, frn)
+					}
+				}
+			}
+			offset += length
+		}
+	}
+
+	var paths []string
+	for _, frn := range matches {
+		if path, ok := m.resolvePath(entries, frn); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// resolvePath builds the full path of an MFT record by following its parents
+func (m *mftIndex) resolvePath(entries map[uint64]mftEntry, frn uint64) (string, bool) {
+	var parts []string
+	for depth := 0; frn != ntfsRootRecord; depth++ {
+		entry, ok := entries[frn]
+		if !ok || depth > 512 {
+			// Orphaned or system record
+			return "", false
+		}
+		parts = append(parts, entry.name)
+		frn = entry.parent
+	}
+
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return m.volume + `\` + strings.Join(parts, `\`), true
+}
+
+// decodeUTF16 converts a little-endian UTF-16 byte slice to a string
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
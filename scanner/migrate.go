@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportSchemaVersion is the version of the JSON reports written by this
+// build, reported as schema_version. Reports without it are version 1.
+const reportSchemaVersion = 2
+
+// configVersion is the version of the configuration file format, stored as
+// version. Configurations without it are version 1.
+const configVersion = 2
+
+// migration upgrades a JSON document from one version to the next. Migrations
+// work on the generic document, so fields unknown to this build are kept.
+type migration struct {
+	from    int
+	migrate func(doc map[string]any) error
+}
+
+// reportMigrations upgrade reports to reportSchemaVersion, in order
+var reportMigrations = []migration{
+	{from: 1, migrate: migrateReportV1},
+}
+
+// configMigrations upgrade configuration files to configVersion, in order
+var configMigrations = []migration{
+	// Version 2 only introduced the version field
+	{from: 1, migrate: func(doc map[string]any) error { return nil }},
+}
+
+// migrateReportV1 adds the patch level of every runtime as of the scan, which
+// version 1 reports predate, so the collector can rate old reports as well
+func migrateReportV1(doc map[string]any) error {
+	meta, _ := doc["meta"].(map[string]any)
+	scanned, err := time.Parse(time.RFC3339, stringField(meta, "scan_ts"))
+	if err != nil {
+		return fmt.Errorf("invalid scan_ts: %v", err)
+	}
+	runtimes, _ := doc["result"].([]any)
+	for _, item := range runtimes {
+		runtime, ok := item.(map[string]any)
+		if !ok || runtime["cpu_release"] != nil {
+			continue
+		}
+		version := stringField(runtime, "java_version")
+		if version == "" {
+			continue
+		}
+		major, update := parseJavaVersion(version)
+		if level, ok := patchLevel(major, update, stringField(runtime, "release_date"), scanned); ok {
+			runtime["cpu_release"] = level.CPU
+			runtime["patch_lag_quarters"] = level.LagQuarters
+		}
+	}
+	return nil
+}
+
+// stringField returns a string field of a JSON object, empty if missing
+func stringField(doc map[string]any, key string) string {
+	s, _ := doc[key].(string)
+	return s
+}
+
+// documentVersion returns the version number stored at key of a JSON object, 1 if there is none
+func documentVersion(doc map[string]any, key string) (int, error) {
+	value, ok := doc[key]
+	if !ok || value == nil {
+		return 1, nil
+	}
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("invalid %s %v", key, value)
+	}
+	version, err := strconv.Atoi(n.String())
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid %s %v", key, value)
+	}
+	return version, nil
+}
+
+// runMigrations upgrades a document from version from to version to
+func runMigrations(doc map[string]any, migrations []migration, from, to int) error {
+	if from > to {
+		return fmt.Errorf("cannot downgrade from version %d to %d", from, to)
+	}
+	for version := from; version < to; version++ {
+		i := 0
+		for i < len(migrations) && migrations[i].from != version {
+			i++
+		}
+		if i == len(migrations) {
+			return fmt.Errorf("no migration from version %d", version)
+		}
+		if err := migrations[i].migrate(doc); err != nil {
+			return fmt.Errorf("migration from version %d failed: %v", version, err)
+		}
+	}
+	return nil
+}
+
+// decodeDocument parses a JSON object, keeping numbers as written
+func decodeDocument(data []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+	return doc, nil
+}
+
+// migrateReport upgrades a report from version from to version to. It returns
+// the report unchanged if it already has version to.
+func migrateReport(data []byte, from, to int) ([]byte, error) {
+	doc, err := decodeDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	meta, ok := doc["meta"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("not a jfind report, meta is missing")
+	}
+	version, err := documentVersion(meta, "schema_version")
+	if err != nil {
+		return nil, err
+	}
+	if from == 0 {
+		from = version
+	}
+	if version != from {
+		return nil, fmt.Errorf("report has schema version %d, not %d", version, from)
+	}
+	if version == to {
+		return data, nil
+	}
+	if to > reportSchemaVersion {
+		return nil, fmt.Errorf("unknown schema version %d, this build writes version %d", to, reportSchemaVersion)
+	}
+	if err := runMigrations(doc, reportMigrations, from, to); err != nil {
+		return nil, err
+	}
+	meta["schema_version"] = to
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// migrateConfig upgrades a configuration to configVersion in memory and
+// returns it with the version it had. changed reports whether a migration
+// transformed a setting rather than only the version, which is when
+// migrate-config rewrites the file.
+func migrateConfig(data []byte) (migrated []byte, version int, changed bool, err error) {
+	doc, err := decodeDocument(data)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	version, err = documentVersion(doc, "version")
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if version == configVersion {
+		return data, version, false, nil
+	}
+	if version > configVersion {
+		return nil, 0, false, fmt.Errorf("config version %d is newer than this build supports (%d)", version, configVersion)
+	}
+	before, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if err := runMigrations(doc, configMigrations, version, configVersion); err != nil {
+		return nil, 0, false, err
+	}
+	after, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	doc["version"] = configVersion
+	if migrated, err = json.MarshalIndent(doc, "", "  "); err != nil {
+		return nil, 0, false, err
+	}
+	return append(migrated, '\n'), version, !bytes.Equal(before, after), nil
+}
+
+// rewriteConfigFile replaces a configuration file by its migrated version and
+// keeps the old file next to it as <file>.v<version>.bak. It returns the name
+// of the backup.
+func rewriteConfigFile(path string, data, migrated []byte, version int) (string, error) {
+	// The configuration may hold credentials, the backup keeps its permissions
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if err := os.WriteFile(backup, data, mode); err != nil {
+		return "", err
+	}
+	tmp := path + ".jfind-tmp"
+	if err := os.WriteFile(tmp, migrated, mode); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return backup, nil
+}
+
+// runMigrateConfig implements the migrate-config command and returns the exit
+// code: 0 on success, 1 if the file cannot be migrated and 2 on usage errors.
+// The file is only rewritten if a migration changes a setting; a file that
+// only lacks the current version is left as is, as it is loaded the same.
+func runMigrateConfig(args []string) int {
+	fs := flag.NewFlagSet("migrate-config", flag.ContinueOnError)
+	fs.Usage = func() {
+		logf("Usage: jfind migrate-config <config.json>\n")
+		logf("Upgrades a configuration file to version %d, the previous file is kept as <file>.v<version>.bak\n", configVersion)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	path := fs.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logf("Error: failed to read config: %v\n", err)
+		return 1
+	}
+	migrated, version, changed, err := migrateConfig(data)
+	if err != nil {
+		logf("Error: %s: %v\n", path, err)
+		return 1
+	}
+	if !changed {
+		logf("Config %s is version %d and needs no changes for version %d\n", path, version, configVersion)
+		return 0
+	}
+	backup, err := rewriteConfigFile(path, data, migrated, version)
+	if err != nil {
+		logf("Error: failed to rewrite config %s: %v\n", path, err)
+		return 1
+	}
+	logf("Migrated config %s to version %d, the previous version is kept in %s\n", path, configVersion, backup)
+	return 0
+}
+
+// parseSchemaVersion parses a version argument like v1 or 1
+func parseSchemaVersion(s string) (int, error) {
+	version, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(s), "v"))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid schema version %q", s)
+	}
+	return version, nil
+}
+
+// runMigrateReport implements the migrate-report command and returns the exit
+// code: 0 on success, 1 if the report cannot be migrated and 2 on usage errors
+func runMigrateReport(args []string) int {
+	fs := flag.NewFlagSet("migrate-report", flag.ContinueOnError)
+	output := fs.String("o", "", "Write the migrated report to this file instead of stdout")
+	fs.Usage = func() {
+		logf("Usage: jfind migrate-report [options] <from> <to> <report.json>\n")
+		logf("Versions are given as v1, v2, ..., the current schema version is v%d\n", reportSchemaVersion)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 3 {
+		fs.Usage()
+		return 2
+	}
+	from, err := parseSchemaVersion(fs.Arg(0))
+	if err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	to, err := parseSchemaVersion(fs.Arg(1))
+	if err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+
+	data, err := readReportFile(fs.Arg(2))
+	if err != nil {
+		logf("Error: failed to read report: %v\n", err)
+		return 1
+	}
+	migrated, err := migrateReport(data, from, to)
+	if err != nil {
+		logf("Error: %s: %v\n", fs.Arg(2), err)
+		return 1
+	}
+	if !bytes.HasSuffix(migrated, []byte("\n")) {
+		migrated = append(migrated, '\n')
+	}
+	if *output == "" {
+		os.Stdout.Write(migrated)
+		return 0
+	}
+	if err := os.WriteFile(*output, migrated, 0644); err != nil {
+		logf("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
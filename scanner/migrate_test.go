@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateReport(t *testing.T) {
+	v1 := `{
+  "meta": {"scan_id": "abc", "scan_ts": "2025-08-01T00:00:00Z", "computer_name": "build01"},
+  "result": [
+    {"java_executable": "/opt/jdk-17/bin/java", "java_version": "17.0.9", "custom": "kept"},
+    {"java_executable": "/opt/unknown/bin/java"}
+  ]
+}`
+	data, err := migrateReport([]byte(v1), 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report JSONOutput
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Meta.SchemaVersion != 2 || report.Meta.ComputerName != "build01" {
+		t.Errorf("Expected schema version 2 with the meta kept, got %+v", report.Meta)
+	}
+	// The patch level is that of the scan, not of today
+	if runtime := report.Runtimes[0]; runtime.CPURelease != "2023-10-17" || runtime.PatchLagQuarters == nil || *runtime.PatchLagQuarters != 7 {
+		t.Errorf("Expected the patch level as of the scan, got %q", runtime.CPURelease)
+	}
+	if report.Runtimes[1].CPURelease != "" {
+		t.Errorf("Expected no patch level without a version, got %q", report.Runtimes[1].CPURelease)
+	}
+	if !strings.Contains(string(data), `"custom": "kept"`) {
+		t.Error("Expected unknown fields to be kept")
+	}
+
+	// Current reports are left alone
+	if again, err := migrateReport(data, 0, reportSchemaVersion); err != nil || string(again) != string(data) {
+		t.Errorf("Expected a current report to be unchanged (%v)", err)
+	}
+	if _, err := migrateReport(data, 1, 2); err == nil {
+		t.Error("Expected an error for a report of another version")
+	}
+	if _, err := migrateReport(data, 2, 1); err == nil {
+		t.Error("Expected an error for a downgrade")
+	}
+	if _, err := migrateReport([]byte(v1), 1, reportSchemaVersion+1); err == nil {
+		t.Error("Expected an error for an unknown version")
+	}
+}
+
+func TestMigrateConfigOnLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	v1 := `{"eval_command": "{java} -version"}`
+	writeTestFile(t, path, v1)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != configVersion || cfg.EvalCommand != "{java} -version" {
+		t.Errorf("Expected the config to be migrated, got %+v", cfg)
+	}
+	// Loading never writes the file or a backup
+	if data, _ := os.ReadFile(path); string(data) != v1 {
+		t.Errorf("Expected the config to be left untouched, got %q", data)
+	}
+	if _, err := os.Stat(path + ".v1.bak"); err == nil {
+		t.Error("Expected no backup when the config is loaded")
+	}
+
+	current := []byte(`{"version": 2}`)
+	if migrated, _, changed, err := migrateConfig(current); err != nil || changed || string(migrated) != string(current) {
+		t.Errorf("Expected a current config to be unchanged (%v)", err)
+	}
+	if _, _, _, err := migrateConfig([]byte(`{"version": 99}`)); err == nil {
+		t.Error("Expected an error for a config of a newer version")
+	}
+}
+
+func TestMigrateConfigCommand(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	v1 := `{"eval_command": "{java} -version"}`
+	writeTestFile(t, path, v1)
+	os.Chmod(path, 0600)
+
+	// The migration to version 2 only adds the version, the file is kept
+	if code := runMigrateConfig([]string{path}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if data, _ := os.ReadFile(path); string(data) != v1 {
+		t.Errorf("Expected a config without real changes to be left as is, got %q", data)
+	}
+
+	saved := configMigrations
+	t.Cleanup(func() { configMigrations = saved })
+	configMigrations = []migration{{from: 1, migrate: func(doc map[string]any) error {
+		doc["eval_command"] = "{java} -XshowSettings:properties -version"
+		return nil
+	}}}
+	if code := runMigrateConfig([]string{path}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != configVersion || cfg.EvalCommand != "{java} -XshowSettings:properties -version" {
+		t.Errorf("Expected the config to be rewritten, got %+v", cfg)
+	}
+	backup, err := os.ReadFile(path + ".v1.bak")
+	if err != nil || string(backup) != v1 {
+		t.Errorf("Expected the previous version as backup, got %q (%v)", backup, err)
+	}
+	if info, err := os.Stat(path + ".v1.bak"); err == nil && info.Mode().Perm() != 0600 && os.PathSeparator == '/' {
+		t.Errorf("Expected the backup to keep the permissions, got %v", info.Mode().Perm())
+	}
+	if code := runMigrateConfig(nil); code != 2 {
+		t.Errorf("Expected exit code 2 without a file, got %d", code)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// defaultRequiredModules are needed by most enterprise applications: java.se is
+// the full Java SE API and jdk.crypto.ec provides elliptic curve TLS cipher suites
+var defaultRequiredModules = []string{"java.se", "jdk.crypto.ec"}
+
+// mergedModules were folded into java.base from the given major version on
+var mergedModules = map[string]int{
+	"jdk.crypto.ec": 22,
+}
+
+// moduleWarnings returns a compatibility warning for every required module a
+// runtime doesn't contain. Runtimes without a module list are not checked.
+func moduleWarnings(modules []string, major int, required []string) []string {
+	if len(modules) == 0 {
+		return nil
+	}
+	var warnings []string
+	for _, module := range required {
+		if since, ok := mergedModules[module]; ok && major >= since {
+			continue
+		}
+		if !slices.Contains(modules, module) {
+			warnings = append(warnings, fmt.Sprintf("missing module %s", module))
+		}
+	}
+	return warnings
+}
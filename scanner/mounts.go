@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Reasons for pruning a mount point from the walk
+const (
+	PruneReasonPseudo        = "pseudo"
+	PruneReasonNetwork       = "network"
+	PruneReasonOneFilesystem = "one-filesystem"
+)
+
+// pseudoFSTypes are file systems without files of their own, or whose entries
+// trigger mounts when listed. They are always pruned.
+var pseudoFSTypes = []string{
+	"autofs", "binfmt_misc", "bpf", "cgroup", "cgroup2", "configfs", "debugfs", "devfs", "devpts",
+	"devtmpfs", "efivarfs", "fusectl", "hugetlbfs", "mqueue", "nsfs", "proc", "pstore",
+	"securityfs", "selinuxfs", "sysfs", "tracefs",
+}
+
+// networkFSTypes are file systems served by another host, pruned with -skip-network-mounts
+var networkFSTypes = []string{
+	"9p", "afpfs", "afs", "ceph", "cifs", "davfs", "fuse.gcsfuse", "fuse.glusterfs", "fuse.rclone",
+	"fuse.s3fs", "fuse.sshfs", "glusterfs", "gpfs", "lustre", "ncpfs", "nfs", "nfs4", "remote",
+	"smb3", "smbfs", "sshfs", "webdav",
+}
+
+// mountPoint is an entry of the mount table
+type mountPoint struct {
+	path   string
+	fsType string
+}
+
+// PrunedMount is a mount point the walk did not descend into
+type PrunedMount struct {
+	Path   string `json:"path"`
+	FSType string `json:"fs_type"`
+	Reason string `json:"reason"`
+}
+
+// parseProcMounts parses the mount table in the format of /proc/self/mounts
+func parseProcMounts(data string) []mountPoint {
+	var mounts []mountPoint
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		mounts = append(mounts, mountPoint{path: unescapeMountPath(fields[1]), fsType: fields[2]})
+	}
+	return mounts
+}
+
+// unescapeMountPath decodes the octal escapes of spaces, tabs, newlines and
+// backslashes in the paths of /proc/self/mounts
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+4 <= len(path) {
+			if n, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
+
+// mountPruner keeps the walk out of mount points: pseudo file systems always,
+// network file systems with -skip-network-mounts and every file system other
+// than that of the start path with -one-filesystem
+type mountPruner struct {
+	reasons map[string]PrunedMount // by mount point
+	starts  []string
+
+	mu     sync.Mutex
+	pruned []PrunedMount
+}
+
+// newMountPruner selects the mount points to prune below the start paths
+func newMountPruner(mounts []mountPoint, starts []string, skipNetwork, oneFilesystem bool) *mountPruner {
+	p := &mountPruner{reasons: make(map[string]PrunedMount), starts: starts}
+	for _, mount := range mounts {
+		path := filepath.Clean(mount.path)
+		if !slices.ContainsFunc(starts, func(start string) bool { return withinRoot(start, path) && start != path }) {
+			continue
+		}
+		var reason string
+		switch {
+		case slices.Contains(pseudoFSTypes, mount.fsType):
+			reason = PruneReasonPseudo
+		case skipNetwork && slices.Contains(networkFSTypes, mount.fsType):
+			reason = PruneReasonNetwork
+		case oneFilesystem:
+			reason = PruneReasonOneFilesystem
+		default:
+			// A file system mounted over a pruned one, e.g. by an autofs trigger, is walked
+			delete(p.reasons, path)
+			continue
+		}
+		// The last mount on a path hides the ones before it
+		p.reasons[path] = PrunedMount{Path: path, FSType: mount.fsType, Reason: reason}
+	}
+	return p
+}
+
+// prune checks if a directory is a mount point to prune and records it
+func (p *mountPruner) prune(path string) (PrunedMount, bool) {
+	if p == nil {
+		return PrunedMount{}, false
+	}
+	mount, ok := p.reasons[path]
+	if !ok {
+		return PrunedMount{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !slices.ContainsFunc(p.pruned, func(m PrunedMount) bool { return m.Path == path }) {
+		p.pruned = append(p.pruned, mount)
+	}
+	return mount, true
+}
+
+// list returns the mount points pruned so far, sorted by path
+func (p *mountPruner) list() []PrunedMount {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pruned := slices.Clone(p.pruned)
+	slices.SortFunc(pruned, func(a, b PrunedMount) int { return strings.Compare(a.Path, b.Path) })
+	return pruned
+}
+
+// readMountTable reads the mount table of the operating system, replaced by tests
+var readMountTable = readMounts
+
+// resetMounts reads the mount table at the start of a walk
+func (f *JavaFinder) resetMounts() {
+	f.mounts = nil
+	mounts, err := readMountTable()
+	if err != nil {
+		if f.verbose {
+			logf("Warning: cannot read the mount table, mount points are not pruned: %v\n", err)
+		}
+		return
+	}
+	f.mounts = newMountPruner(mounts, f.startPaths(), f.skipNetworkMounts, f.oneFilesystem)
+}
+
+// pruningFS leaves the mount points to prune out of directory listings. They
+// are not even listed, since a hung network mount blocks stat calls too.
+type pruningFS struct {
+	FileSystem
+	f *JavaFinder
+}
+
+func (fsys pruningFS) ReadDirNames(name string) ([]string, error) {
+	names, err := fsys.FileSystem.ReadDirNames(name)
+	return slices.DeleteFunc(names, func(entry string) bool {
+		path := filepath.Join(name, entry)
+		mount, ok := fsys.f.mounts.prune(path)
+		if ok {
+			depth := fsys.f.getPathDepth(path)
+			fsys.f.trace.event(SourceFileSystem, TracePrunedMount, path, depth, fmt.Sprintf("%s %s", mount.Reason, mount.FSType))
+			if fsys.f.verbose {
+				logf("Skipping %s mount point (%s): %s\n", mount.FSType, mount.Reason, path)
+			}
+		}
+		return ok
+	}), err
+}
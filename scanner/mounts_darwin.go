@@ -0,0 +1,38 @@
+//go:build darwin
+
+package main
+
+import "syscall"
+
+// mntNowait returns the cached statistics of getfsstat, without asking file
+// systems that may not respond
+const mntNowait = 2
+
+// readMounts reads the mounted file systems with getfsstat
+func readMounts() ([]mountPoint, error) {
+	n, err := syscall.Getfsstat(nil, mntNowait)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]syscall.Statfs_t, n)
+	if n, err = syscall.Getfsstat(stats, mntNowait); err != nil {
+		return nil, err
+	}
+	mounts := make([]mountPoint, 0, n)
+	for _, stat := range stats[:n] {
+		mounts = append(mounts, mountPoint{path: cString(stat.Mntonname[:]), fsType: cString(stat.Fstypename[:])})
+	}
+	return mounts, nil
+}
+
+// cString converts a NUL terminated C string
+func cString(chars []int8) string {
+	b := make([]byte, 0, len(chars))
+	for _, c := range chars {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return string(b)
+}
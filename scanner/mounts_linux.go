@@ -0,0 +1,14 @@
+//go:build linux
+
+package main
+
+import "os"
+
+// readMounts reads the mount table of the mount namespace of jfind
+func readMounts() ([]mountPoint, error) {
+	data, err := os.ReadFile("/proc/self/mounts")
+	if err != nil {
+		return nil, err
+	}
+	return parseProcMounts(string(data)), nil
+}
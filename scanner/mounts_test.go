@@ -0,0 +1,120 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseProcMounts(t *testing.T) {
+	table := `sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+/dev/sda1 / ext4 rw,relatime 0 0
+fileserver:/export/tools /mnt/shared\040tools nfs4 rw,relatime,vers=4.2 0 0
+
+`
+	want := []mountPoint{
+		{path: "/sys", fsType: "sysfs"},
+		{path: "/proc", fsType: "proc"},
+		{path: "/", fsType: "ext4"},
+		{path: "/mnt/shared tools", fsType: "nfs4"},
+	}
+	if got := parseProcMounts(table); !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestMountPruner(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	mounts := []mountPoint{
+		{path: root, fsType: "nfs"},
+		{path: filepath.Join(root, "proc"), fsType: "proc"},
+		{path: filepath.Join(root, "mnt", "nfs"), fsType: "nfs4"},
+		{path: filepath.Join(root, "data"), fsType: "xfs"},
+		{path: filepath.FromSlash("/elsewhere/proc"), fsType: "proc"},
+	}
+	tests := []struct {
+		name                       string
+		skipNetwork, oneFilesystem bool
+		want                       map[string]string
+	}{
+		{"default", false, false, map[string]string{"proc": PruneReasonPseudo}},
+		{"network", true, false, map[string]string{"proc": PruneReasonPseudo, "mnt/nfs": PruneReasonNetwork}},
+		{"one filesystem", false, true, map[string]string{"proc": PruneReasonPseudo, "mnt/nfs": PruneReasonOneFilesystem, "data": PruneReasonOneFilesystem}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pruner := newMountPruner(mounts, []string{root}, tt.skipNetwork, tt.oneFilesystem)
+			got := make(map[string]string)
+			for path, mount := range pruner.reasons {
+				rel, _ := filepath.Rel(root, path)
+				got[filepath.ToSlash(rel)] = mount.Reason
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected the mount points %v, got %v", tt.want, got)
+			}
+			for path, reason := range tt.want {
+				if got[path] != reason {
+					t.Errorf("Expected %s to be pruned as %s, got %q", path, reason, got[path])
+				}
+			}
+		})
+	}
+}
+
+func TestMountPrunerOverMounts(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	home := filepath.Join(root, "home")
+	mounts := []mountPoint{
+		{path: home, fsType: "autofs"},
+		{path: home, fsType: "ext4"},
+		{path: filepath.Join(root, "net"), fsType: "ext4"},
+		{path: filepath.Join(root, "net"), fsType: "autofs"},
+	}
+	pruner := newMountPruner(mounts, []string{root}, false, false)
+	if _, ok := pruner.prune(home); ok {
+		t.Errorf("Expected the file system mounted over the autofs trigger to be walked")
+	}
+	if mount, ok := pruner.prune(filepath.Join(root, "net")); !ok || mount.FSType != "autofs" {
+		t.Errorf("Expected the autofs trigger mounted last to be pruned, got %+v", mount)
+	}
+}
+
+func TestWalkPrunesMounts(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	local := filepath.Join(root, "opt", "jdk-17", "bin", javaExecutableName())
+	fsys := fakeFileSystem{}
+	fsys.add(local, 0755, "")
+	fsys.add(filepath.Join(root, "mnt", "nfs", "jdk-21", "bin", javaExecutableName()), 0755, "")
+	fsys.add(filepath.Join(root, "proc", "1", "root", "bin", javaExecutableName()), 0755, "")
+
+	defer func(read func() ([]mountPoint, error)) { readMountTable = read }(readMountTable)
+	readMountTable = func() ([]mountPoint, error) {
+		return []mountPoint{
+			{path: filepath.Join(root, "proc"), fsType: "proc"},
+			{path: filepath.Join(root, "mnt", "nfs"), fsType: "nfs4"},
+		}, nil
+	}
+	for _, workers := range []int{1, 4} {
+		finder := NewJavaFinder(root, -1, false, false)
+		finder.SetSystem(System{FS: fsys})
+		finder.workers = workers
+		finder.skipNetworkMounts = true
+		results, err := finder.Find()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].Path != local {
+			t.Errorf("Expected only the runtime outside of the mount points with %d workers, got %v", workers, results)
+		}
+		pruned := buildJSONOutput(results, finder, time.Now()).Meta.PrunedMounts
+		want := []PrunedMount{
+			{Path: filepath.Join(root, "mnt", "nfs"), FSType: "nfs4", Reason: PruneReasonNetwork},
+			{Path: filepath.Join(root, "proc"), FSType: "proc", Reason: PruneReasonPseudo},
+		}
+		if !slices.Equal(pruned, want) {
+			t.Errorf("Expected the pruned mount points %v, got %v", want, pruned)
+		}
+	}
+}
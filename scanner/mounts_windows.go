@@ -0,0 +1,41 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	procGetLogicalDrives = kernel32.NewProc("GetLogicalDrives")
+	procGetDriveTypeW    = kernel32.NewProc("GetDriveTypeW")
+)
+
+// driveRemote is the GetDriveType of a network drive
+const driveRemote = 4
+
+// readMounts lists the drives with their type. The walk does not cross into
+// other volumes, since volume mount folders are junctions, so only the start
+// path can be on a network drive; mapped drives are reported as remote.
+func readMounts() ([]mountPoint, error) {
+	mask, _, err := procGetLogicalDrives.Call()
+	if mask == 0 {
+		return nil, err
+	}
+	var mounts []mountPoint
+	for i := 0; i < 26; i++ {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		root := string(rune('A'+i)) + `:\`
+		fsType := "local"
+		if name, err := syscall.UTF16PtrFromString(root); err == nil {
+			if kind, _, _ := procGetDriveTypeW.Call(uintptr(unsafe.Pointer(name))); kind == driveRemote {
+				fsType = "remote"
+			}
+		}
+		mounts = append(mounts, mountPoint{path: root, fsType: fsType})
+	}
+	return mounts, nil
+}
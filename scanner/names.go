@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// nameMatcher matches the file names of -names and -name-pattern, which are
+// looked for in addition to java or java.exe, e.g. javaw.exe, javac, jlink or
+// launchers renamed by a vendor like zulu-java
+type nameMatcher struct {
+	names   map[string]bool
+	pattern *regexp.Regexp
+}
+
+// newNameMatcher parses a comma separated -names list and a -name-pattern
+// regular expression, which must match the whole file name. It returns nil if
+// both are empty.
+func newNameMatcher(names, pattern string) (*nameMatcher, error) {
+	m := &nameMatcher{names: make(map[string]bool)}
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			if strings.ContainsAny(name, `/\`) {
+				return nil, fmt.Errorf("invalid name %q in -names, use file names without directories", name)
+			}
+			m.names[name] = true
+		}
+	}
+	if pattern != "" {
+		var err error
+		if m.pattern, err = regexp.Compile("^(?:" + pattern + ")$"); err != nil {
+			return nil, fmt.Errorf("invalid -name-pattern: %v", err)
+		}
+	}
+	if len(m.names) == 0 && m.pattern == nil {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// match checks if a file name is one of the names or matches the pattern
+func (m *nameMatcher) match(name string) bool {
+	if m == nil {
+		return false
+	}
+	return m.names[name] || m.pattern != nil && m.pattern.MatchString(name)
+}
+
+// String returns the sorted names and the pattern, empty for a nil matcher
+func (m *nameMatcher) String() string {
+	if m == nil {
+		return ""
+	}
+	names := make([]string, 0, len(m.names))
+	for name := range m.names {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	spec := strings.Join(names, ",")
+	if m.pattern != nil {
+		spec += " " + m.pattern.String()
+	}
+	return spec
+}
+
+// matchesName checks if a file name is one of the executables the finder looks for
+func (f *JavaFinder) matchesName(name string) bool {
+	return isJavaExecutable(name) || f.names.match(name)
+}
+
+// isJavaLauncher checks if a file name is that of the java or javaw launcher,
+// the only executables the probes of -eval run. Other matches of -names and
+// -name-pattern, such as javac, the javaws GUI or whatever else the pattern
+// hits, are evaluated from the release file of their runtime instead.
+func isJavaLauncher(name string) bool {
+	if runtime.GOOS == "windows" {
+		return name == "java.exe" || name == "javaw.exe"
+	}
+	return name == "java" || name == "javaw"
+}
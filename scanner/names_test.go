@@ -0,0 +1,100 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNameMatcher(t *testing.T) {
+	m, err := newNameMatcher("javaw.exe, javac", "zulu-.*java")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"javaw.exe":     true,
+		"javac":         true,
+		"zulu-17-java":  true,
+		"zulu-17-javac": false, // the pattern matches the whole name
+		"javadoc":       false,
+	} {
+		if got := m.match(name); got != want {
+			t.Errorf("Expected match(%q) to be %v, got %v", name, want, got)
+		}
+	}
+	if m, err := newNameMatcher(" , ", ""); m != nil || err != nil {
+		t.Errorf("Expected no matcher without names, got %v (%v)", m, err)
+	}
+	if _, err := newNameMatcher("bin/javac", ""); err == nil {
+		t.Error("Expected an error for a name with a directory")
+	}
+	if _, err := newNameMatcher("", "java("); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func TestFindNames(t *testing.T) {
+	root := t.TempDir()
+	java := createFakeJava(t, filepath.Join(root, "jdk-17"))
+	javac := filepath.Join(root, "jdk-17", "bin", "javac")
+	renamed := filepath.Join(root, "opt", "zulu", "bin", "zulu-java")
+	writeTestFile(t, javac, "#!/bin/sh\n")
+	writeTestFile(t, renamed, "#!/bin/sh\n")
+	writeTestFile(t, filepath.Join(root, "jdk-17", "bin", "javadoc"), "#!/bin/sh\n")
+
+	finder := NewJavaFinder(root, -1, false, false)
+	results, err := finder.Find()
+	if err != nil || len(results) != 1 || results[0].MatchedName != "" {
+		t.Fatalf("Expected only java without -names, got %v (%v)", results, err)
+	}
+
+	if finder.names, err = newNameMatcher("javac", "zulu-.*"); err != nil {
+		t.Fatal(err)
+	}
+	results, err = finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := buildJSONOutput(results, finder, time.Now())
+	var found []string
+	for _, runtime := range output.Runtimes {
+		found = append(found, runtime.JavaExecutable+" "+runtime.MatchedName)
+	}
+	want := []string{java + " " + filepath.Base(java), javac + " javac", renamed + " zulu-java"}
+	slices.Sort(found)
+	slices.Sort(want)
+	if !slices.Equal(found, want) {
+		t.Errorf("Expected %v, got %v", want, found)
+	}
+}
+
+func TestEvaluateNamesStatically(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	home := filepath.Join(root, "opt", "jdk-17")
+	java := filepath.Join(home, "bin", javaExecutableName())
+	javac := filepath.Join(home, "bin", "javac")
+	fsys := fakeFileSystem{}
+	fsys.add(java, 0755, "")
+	fsys.add(javac, 0755, "")
+	fsys.add(filepath.Join(home, "release"), 0644, `JAVA_VERSION="17.0.9"`+"\n")
+
+	finder := NewJavaFinder(root, -1, false, true)
+	// Only the launcher answers the probe, javac would fail it
+	finder.SetSystem(System{FS: fsys, Exec: fakeExecutor{java: "    java.version = 17.0.9\n"}})
+	var err error
+	if finder.names, err = newNameMatcher("javac", ""); err != nil {
+		t.Fatal(err)
+	}
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(map[string]ProbeStatus)
+	for _, result := range results {
+		statuses[result.Path] = result.Status
+	}
+	if statuses[java] != ProbeOK || statuses[javac] != ProbeStatic {
+		t.Errorf("Expected java to be probed and javac to be evaluated from the release file, got %v", statuses)
+	}
+}
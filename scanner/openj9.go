@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// openJ9UnrecognizedOption is the error code of IBM J9 and OpenJ9 for unknown command line
+// options, e.g. -XshowSettings on IBM SDK 8
+const openJ9UnrecognizedOption = "JVMJ9VM007E"
+
+var (
+	// bannerVersion matches the first line of -version, e.g. `openjdk version "17.0.8.1" 2023-08-24`
+	bannerVersion = regexp.MustCompile(`^(?:openjdk|java) version "([^"]+)"`)
+
+	// bannerBuild matches the runtime and VM lines of -version, e.g.
+	// `IBM Semeru Runtime Open Edition 17.0.8.1 (build 17.0.8.1+1)` or
+	// `Eclipse OpenJ9 VM 17.0.8.1 (build openj9-0.40.0, JRE 17 Linux amd64-64-Bit ...`
+	bannerBuild = regexp.MustCompile(`^(.+?) \(build ([^,)]+)`)
+
+	// heapSize matches the heap lines of OpenJ9 -verbose:sizes, e.g. `-Xmx512M        memory maximum`
+	heapSize = regexp.MustCompile(`^-Xm([sx])(\d+[KMG]?)\s`)
+)
+
+// parseVersionBanner fills the properties that are still empty from the
+// -version banner printed after the properties
+func parseVersionBanner(props *JavaProperties, input string) {
+	var runtimeLine, vmLine, vmBuild string
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if m := bannerVersion.FindStringSubmatch(line); m != nil {
+			if props.Version == "" {
+				props.Version = m[1]
+			}
+			continue
+		}
+		if m := bannerBuild.FindStringSubmatch(line); m != nil {
+			if strings.Contains(m[1], " VM") {
+				vmLine, vmBuild = m[1], m[2]
+			} else if runtimeLine == "" {
+				runtimeLine = m[1]
+			}
+		}
+	}
+
+	if props.RuntimeName == "" && runtimeLine != "" {
+		props.RuntimeName = trimBannerVersion(runtimeLine)
+	}
+	if props.VMName == "" && vmLine != "" {
+		props.VMName = trimBannerVersion(vmLine)
+		props.VMVersion = vmBuild
+	}
+	if props.Vendor == "" {
+		props.Vendor = bannerVendor(props.RuntimeName, props.VMName)
+	}
+}
+
+// trimBannerVersion removes a trailing version number, e.g. the 17.0.8.1 of "Eclipse OpenJ9 VM 17.0.8.1"
+func trimBannerVersion(s string) string {
+	if idx := strings.LastIndex(s, " "); idx != -1 && s[idx+1] >= '0' && s[idx+1] <= '9' {
+		return s[:idx]
+	}
+	return s
+}
+
+// bannerVendor derives the vendor from the runtime and VM names of the banner
+func bannerVendor(runtimeName, vmName string) string {
+	switch {
+	case strings.Contains(runtimeName, "IBM") || strings.Contains(vmName, "IBM"):
+		return "IBM Corporation"
+	case strings.Contains(runtimeName, "AdoptOpenJDK"):
+		return "AdoptOpenJDK"
+	case strings.Contains(vmName, "Java HotSpot(TM)"):
+		return "Oracle Corporation"
+	}
+	return ""
+}
+
+// isOpenJ9 checks if the runtime uses the OpenJ9 or IBM J9 VM
+func (p *JavaProperties) isOpenJ9() bool {
+	return strings.Contains(p.VMName, "J9")
+}
+
+// openJ9HeapDefaults parses the default heap sizes from the output of -verbose:sizes
+func openJ9HeapDefaults(output string) (initial, max int64) {
+	for _, line := range strings.Split(output, "\n") {
+		m := heapSize.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		size, err := parseByteSize(m[2])
+		if err != nil {
+			continue
+		}
+		if m[1] == "s" {
+			initial = size
+		} else {
+			max = size
+		}
+	}
+	return initial, max
+}
+
+// evaluateOpenJ9 runs the probes specific to J9 based runtimes: the -version banner
+// if the evaluation command was rejected, and -verbose:sizes for the heap defaults
+func (f *JavaFinder) evaluateOpenJ9(ctx context.Context, result *JavaResult) {
+	if result.Status == ProbeFailed && strings.Contains(result.StdErr, openJ9UnrecognizedOption) {
+		output, err := f.runJava(ctx, result.Path, "-version")
+		if err != nil {
+			return
+		}
+		props := ParseJavaProperties(output)
+		if props.Version == "" {
+			return
+		}
+		result.Properties = props
+		result.Status = ProbeOK
+		result.Error = nil
+		result.ReturnCode = 0
+	}
+	if result.Status != ProbeOK || result.Properties == nil || !result.Properties.isOpenJ9() {
+		return
+	}
+	if output, err := f.runJava(ctx, result.Path, "-verbose:sizes", "-version"); err == nil {
+		result.Properties.InitialHeap, result.Properties.MaxHeap = openJ9HeapDefaults(output)
+	}
+}
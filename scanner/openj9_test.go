@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const semeruOutput = `Property settings:
+    java.runtime.name = IBM Semeru Runtime Open Edition
+    java.vendor = IBM Corporation
+    java.version = 17.0.8.1
+    java.vm.name = Eclipse OpenJ9 VM
+    java.vm.vendor = Eclipse OpenJ9
+    java.vm.version = openj9-0.40.0
+
+openjdk version "17.0.8.1" 2023-08-24
+IBM Semeru Runtime Open Edition 17.0.8.1 (build 17.0.8.1+1)
+Eclipse OpenJ9 VM 17.0.8.1 (build openj9-0.40.0, JRE 17 Linux amd64-64-Bit Compressed References 20230824_561 (JIT enabled, AOT enabled)
+OpenJ9   - d12d10c9e
+OMR      - e80bff83b
+JCL      - 8ac0ab4c69 based on jdk-17.0.8.1+1)
+`
+
+// ibmJava8Banner is the -version output of IBM SDK 8, which rejects -XshowSettings
+const ibmJava8Banner = `java version "1.8.0_381"
+Java(TM) SE Runtime Environment (build 8.0.8.10 - pxa6480sr8fp10-20230810_01(SR8 FP10))
+IBM J9 VM (build 2.9, JRE 1.8.0 Linux amd64-64-Bit Compressed References 20230721_58617 (JIT enabled, AOT enabled)
+OpenJ9   - 4d4f1cd
+OMR      - 9b7e9c3
+IBM      - 5b4e6e4)
+JCL - 20230714_01 based on Oracle jdk8u381-b09
+`
+
+const openJ9Sizes = `  -Xmca32K        RAM class segment increment
+  -Xmco128K       ROM class segment increment
+  -Xms8M          initial memory size
+  -Xmx3932M       memory maximum
+  -Xmn2M          new space size
+`
+
+func TestParseOpenJ9Properties(t *testing.T) {
+	props := ParseJavaProperties(semeruOutput)
+	if props.Version != "17.0.8.1" || props.Major != 17 || props.Update != 8 {
+		t.Errorf("Unexpected version %q (%d, %d)", props.Version, props.Major, props.Update)
+	}
+	if props.VMName != "Eclipse OpenJ9 VM" || props.VMVersion != "openj9-0.40.0" || !props.isOpenJ9() {
+		t.Errorf("Unexpected VM %q %q", props.VMName, props.VMVersion)
+	}
+	if props.RuntimeName != "IBM Semeru Runtime Open Edition" || props.Vendor != "IBM Corporation" {
+		t.Errorf("Unexpected runtime %q of %q", props.RuntimeName, props.Vendor)
+	}
+}
+
+func TestParseVersionBanner(t *testing.T) {
+	props := ParseJavaProperties(ibmJava8Banner)
+	if props.Version != "1.8.0_381" || props.Major != 8 || props.Update != 381 {
+		t.Errorf("Unexpected version %q (%d, %d)", props.Version, props.Major, props.Update)
+	}
+	if props.VMName != "IBM J9 VM" || props.VMVersion != "2.9" {
+		t.Errorf("Unexpected VM %q %q", props.VMName, props.VMVersion)
+	}
+	// The runtime name is the same as Oracle's, the vendor must not be
+	if props.RuntimeName != "Java(TM) SE Runtime Environment" || props.Vendor != "IBM Corporation" {
+		t.Errorf("Unexpected runtime %q of %q", props.RuntimeName, props.Vendor)
+	}
+}
+
+func TestOpenJ9HeapDefaults(t *testing.T) {
+	initial, max := openJ9HeapDefaults(openJ9Sizes)
+	if initial != 8<<20 || max != 3932<<20 {
+		t.Errorf("Expected 8M initial and 3932M maximum heap, got %d and %d", initial, max)
+	}
+}
+
+func TestEvaluateOpenJ9(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of java")
+	}
+	root := t.TempDir()
+	java := filepath.Join(root, "ibm-java-8", "bin", "java")
+	writeTestFile(t, java, `#!/bin/sh
+case "$1" in
+-XshowSettings:properties)
+	echo 'JVMJ9VM007E Command-line option unrecognised: -XshowSettings:properties' >&2
+	echo 'Error: Could not create the Java Virtual Machine.' >&2
+	exit 1;;
+-verbose:sizes)
+	printf '%s' '`+openJ9Sizes+`' >&2;;
+esac
+printf '%s' '`+ibmJava8Banner+`' >&2
+`)
+
+	finder := NewJavaFinder(root, -1, false, true)
+	result := finder.evaluateJava(context.Background(), java)
+	if result.Status != ProbeOK || result.Properties == nil {
+		t.Fatalf("Expected the banner to be evaluated, got %+v", result)
+	}
+	if p := result.Properties; p.Major != 8 || p.Update != 381 || p.Vendor != "IBM Corporation" {
+		t.Errorf("Unexpected properties %+v", p)
+	}
+	if result.Properties.MaxHeap != 3932<<20 {
+		t.Errorf("Expected the heap defaults of -verbose:sizes, got %d", result.Properties.MaxHeap)
+	}
+
+	output := buildJSONOutput([]*JavaResult{&result}, finder, time.Now())
+	if rt := output.Runtimes[0]; rt.ExecFailed || rt.IsOracle || rt.JavaVM != "IBM J9 VM" || rt.InitialHeap != 8<<20 {
+		t.Errorf("Unexpected runtime %+v", rt)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// Stages of a scan in which a panic is isolated
+const (
+	StageWalk     = "walk"     // deciding about a path of the walk
+	StageEvaluate = "evaluate" // inspecting and evaluating a java executable
+)
+
+// ScanError is a path whose processing panicked, e.g. on a malformed release
+// file. The scan went on without it, so the report may miss what is there.
+type ScanError struct {
+	Path  string `json:"path"`
+	Stage string `json:"stage"`
+	Error string `json:"error"`
+}
+
+// scanErrors collects the scan errors of the workers of a walk
+type scanErrors struct {
+	mu     sync.Mutex
+	errors []ScanError
+}
+
+func (e *scanErrors) reset() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.errors = nil
+	e.mu.Unlock()
+}
+
+// list returns the scan errors in the order they occurred
+func (e *scanErrors) list() []ScanError {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]ScanError(nil), e.errors...)
+}
+
+// recovered records a panic recovered while processing path. It is called by
+// the deferred recover handlers, so the stack logged in verbose mode is that of
+// the panic.
+func (f *JavaFinder) recovered(path, stage string, r any) {
+	if f.failures != nil {
+		f.failures.mu.Lock()
+		f.failures.errors = append(f.failures.errors, ScanError{Path: path, Stage: stage, Error: fmt.Sprint(r)})
+		f.failures.mu.Unlock()
+	}
+	logf("Warning: %s of %s failed with an internal error: %v\n", stage, path, r)
+	if f.verbose {
+		logf("%s\n", debug.Stack())
+	}
+}
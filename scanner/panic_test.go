@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// panickingInfo is a file whose mode cannot be read
+type panickingInfo struct{ fakeFileInfo }
+
+func (panickingInfo) Mode() os.FileMode { panic("malformed file mode") }
+
+// panickingFS returns a panickingInfo for one path
+type panickingFS struct {
+	fakeFileSystem
+	path string
+}
+
+func (fsys panickingFS) Lstat(name string) (os.FileInfo, error) {
+	info, err := fsys.fakeFileSystem.Lstat(name)
+	if name == fsys.path && err == nil {
+		return panickingInfo{info.(fakeFileInfo)}, nil
+	}
+	return info, err
+}
+
+// panickingExecutor panics when evaluating one path
+type panickingExecutor struct {
+	fakeExecutor
+	path string
+}
+
+func (e panickingExecutor) Run(cmd *exec.Cmd) error {
+	if cmd.Path == e.path {
+		panic("unexpected probe output")
+	}
+	return e.fakeExecutor.Run(cmd)
+}
+
+func TestPanicIsolation(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	good := filepath.Join(root, "a", "jdk-17", "bin", javaExecutableName())
+	malformed := filepath.Join(root, "b", "jdk-11", "bin", javaExecutableName())
+	crashing := filepath.Join(root, "c", "jdk-21", "bin", javaExecutableName())
+	fsys := fakeFileSystem{}
+	for _, path := range []string{good, malformed, crashing} {
+		fsys.add(path, 0755, "")
+	}
+
+	for _, workers := range []int{1, 4} {
+		finder := NewJavaFinder(root, -1, false, true)
+		finder.workers = workers
+		finder.SetSystem(System{
+			FS:   panickingFS{fsys, malformed},
+			Exec: panickingExecutor{fakeExecutor{good: "    java.version = 17.0.9\n"}, crashing},
+		})
+		results, err := finder.Find()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 2 || results[0].Path != good || results[0].Status != ProbeOK {
+			t.Fatalf("Expected the scan to go on after the panics with %d workers, got %v", workers, results)
+		}
+		if results[1].Path != crashing || results[1].Status != ProbeFailed || !strings.Contains(results[1].Error.Error(), "unexpected probe output") {
+			t.Errorf("Expected the runtime whose evaluation panicked to be reported as failed, got %+v", results[1])
+		}
+
+		errors := buildJSONOutput(results, finder, time.Now()).Meta.ScanErrors
+		slices.SortFunc(errors, func(a, b ScanError) int { return strings.Compare(a.Path, b.Path) })
+		if len(errors) != 2 || errors[0].Path != malformed || errors[0].Stage != StageWalk || errors[1].Path != crashing || errors[1].Stage != StageEvaluate {
+			t.Errorf("Expected a scan error for each panic with %d workers, got %+v", workers, errors)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"path/filepath"
+	"runtime"
+	"slices"
+)
+
+// PathClass describes what kind of location a runtime was found in
+type PathClass string
+
+const (
+	PathClassSystem     PathClass = "system"
+	PathClassUser       PathClass = "user"
+	PathClassBuildCache PathClass = "build_cache"
+	PathClassEphemeral  PathClass = "ephemeral"
+	PathClassUnknown    PathClass = "unknown"
+)
+
+// valid reports whether c is one of the known path classes
+func (c PathClass) valid() bool {
+	switch c {
+	case PathClassSystem, PathClassUser, PathClassBuildCache, PathClassEphemeral, PathClassUnknown:
+		return true
+	}
+	return false
+}
+
+// PathRule assigns a class to runtimes whose executable path matches Pattern.
+// Patterns are globs where "**" matches any number of directories, a leading
+// "~" is the user's home directory and environment variables are expanded.
+type PathRule struct {
+	Pattern string    `json:"pattern"`
+	Class   PathClass `json:"class"`
+}
+
+// defaultPathRules returns the built-in classification rules for the current OS.
+// More specific locations come first since the first matching rule wins.
+func defaultPathRules() []PathRule {
+	if runtime.GOOS == "windows" {
+		return []PathRule{
+			{Pattern: "**/.gradle/jdks/**", Class: PathClassBuildCache},
+			{Pattern: "**/.jdks/**", Class: PathClassBuildCache},
+			{Pattern: "**/.m2/jdks/**", Class: PathClassBuildCache},
+			{Pattern: "?:/Windows/Temp/**", Class: PathClassEphemeral},
+			{Pattern: "?:/Users/*/AppData/Local/Temp/**", Class: PathClassEphemeral},
+			{Pattern: "?:/Users/*/Downloads/**", Class: PathClassEphemeral},
+			{Pattern: "?:/Users/**", Class: PathClassUser},
+			{Pattern: "?:/Program Files/**", Class: PathClassSystem},
+			{Pattern: "?:/Program Files (x86)/**", Class: PathClassSystem},
+			{Pattern: "?:/ProgramData/**", Class: PathClassSystem},
+			{Pattern: "?:/Windows/**", Class: PathClassSystem},
+		}
+	}
+	return []PathRule{
+		{Pattern: "**/.gradle/jdks/**", Class: PathClassBuildCache},
+		{Pattern: "**/.jdks/**", Class: PathClassBuildCache},
+		{Pattern: "**/.m2/jdks/**", Class: PathClassBuildCache},
+		{Pattern: "/tmp/**", Class: PathClassEphemeral},
+		{Pattern: "/var/tmp/**", Class: PathClassEphemeral},
+		{Pattern: "/private/tmp/**", Class: PathClassEphemeral},
+		{Pattern: "/private/var/folders/**", Class: PathClassEphemeral},
+		{Pattern: "/var/folders/**", Class: PathClassEphemeral},
+		{Pattern: "/home/*/Downloads/**", Class: PathClassEphemeral},
+		{Pattern: "/Users/*/Downloads/**", Class: PathClassEphemeral},
+		{Pattern: "/home/**", Class: PathClassUser},
+		{Pattern: "/Users/**", Class: PathClassUser},
+		{Pattern: "/root/**", Class: PathClassUser},
+		{Pattern: "/usr/**", Class: PathClassSystem},
+		{Pattern: "/opt/**", Class: PathClassSystem},
+		{Pattern: "/etc/**", Class: PathClassSystem},
+		{Pattern: "/lib/**", Class: PathClassSystem},
+		{Pattern: "/Library/**", Class: PathClassSystem},
+		{Pattern: "/System/**", Class: PathClassSystem},
+	}
+}
+
+// pathClassifier assigns path classes using an ordered list of rules
+type pathClassifier struct {
+	rules []PathRule
+}
+
+// newPathClassifier creates a classifier that applies custom rules before the defaults
+func newPathClassifier(custom []PathRule) *pathClassifier {
+	var rules []PathRule
+	for _, rule := range slices.Concat(custom, defaultPathRules()) {
+		rules = append(rules, PathRule{Pattern: expandPattern(rule.Pattern), Class: rule.Class})
+	}
+	return &pathClassifier{rules: rules}
+}
+
+// classify returns the class of the first rule matching path, or PathClassUnknown
+func (c *pathClassifier) classify(path string) PathClass {
+	name := filepath.ToSlash(path)
+	for _, rule := range c.rules {
+		if matchGlob(rule.Pattern, name) {
+			return rule.Class
+		}
+	}
+	return PathClassUnknown
+}
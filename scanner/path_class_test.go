@@ -0,0 +1,57 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"/opt/**", "/opt/jdk/bin/java", true},
+		{"/opt/**", "/opt", true},
+		{"/opt/*/bin/java", "/opt/jdk/bin/java", true},
+		{"/opt/*/bin/java", "/opt/a/b/bin/java", false},
+		{"**/.gradle/jdks/**", "/home/dev/.gradle/jdks/temurin-17/bin/java", true},
+		{"**/node_modules", "/srv/app/node_modules", true},
+		{"/home/**", "/usr/bin/java", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPathClassifier(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("default rules under test are for Unix-like systems")
+	}
+
+	classifier := newPathClassifier([]PathRule{
+		{Pattern: "/opt/extracted/**", Class: PathClassEphemeral},
+	})
+
+	tests := []struct {
+		path string
+		want PathClass
+	}{
+		{"/usr/lib/jvm/java-17/bin/java", PathClassSystem},
+		{"/home/dev/.gradle/jdks/temurin-17/bin/java", PathClassBuildCache},
+		{"/home/dev/sdk/jdk-21/bin/java", PathClassUser},
+		{"/tmp/jdk/bin/java", PathClassEphemeral},
+		{"/opt/extracted/jdk/bin/java", PathClassEphemeral},
+		{"/opt/jdk/bin/java", PathClassSystem},
+		{"/srv/jdk/bin/java", PathClassUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := classifier.classify(tt.path); got != tt.want {
+			t.Errorf("classify(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ProbeStatus describes the outcome of evaluating a java executable
+type ProbeStatus string
+
+const (
+	ProbeOK      ProbeStatus = "ok"
+	ProbeFailed  ProbeStatus = "failed"
+	ProbeCrashed ProbeStatus = "crashed"
+
+	// ProbeArchMismatch means the binary was built for an architecture the host cannot run
+	ProbeArchMismatch ProbeStatus = "arch_mismatch"
+
+	// ProbeStatic means the runtime was evaluated from its release file without running it
+	ProbeStatic ProbeStatus = "static"
+
+	// ProbeNotExecuted means execution was disabled and the runtime has no release file
+	ProbeNotExecuted ProbeStatus = "not_executed"
+)
+
+// fatalErrorMarker is printed by HotSpot when the JVM crashes
+const fatalErrorMarker = "A fatal error has been detected by the Java Runtime Environment"
+
+// probeWaitDelay is how long a probe killed by the context may keep its output
+// open, e.g. through a child process that inherited it, before it is abandoned
+const probeWaitDelay = 2 * time.Second
+
+// probeCommand returns the command of a probe, which is killed when ctx is done
+func probeCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = probeWaitDelay
+	return cmd
+}
+
+// probeSandbox is a scratch working directory for a single probe. A crashing JVM
+// writes hs_err_pid*.log and replay_pid*.log files into its working directory,
+// which would otherwise end up wherever jfind was started.
+type probeSandbox struct {
+	dir  string
+	home string
+	tmp  string
+}
+
+// newProbeSandbox creates a temporary working directory with its own HOME and TMP
+func newProbeSandbox() (*probeSandbox, error) {
+	dir, err := os.MkdirTemp("", "jfind-probe-")
+	if err != nil {
+		return nil, err
+	}
+	s := &probeSandbox{
+		dir:  dir,
+		home: filepath.Join(dir, "home"),
+		tmp:  filepath.Join(dir, "tmp"),
+	}
+	for _, d := range []string{s.home, s.tmp} {
+		if err := os.Mkdir(d, 0700); err != nil {
+			s.cleanup()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// apply runs cmd inside the sandbox
+func (s *probeSandbox) apply(cmd *exec.Cmd) {
+	cmd.Dir = s.dir
+	overrides := map[string]string{
+		"HOME":   s.home,
+		"TMPDIR": s.tmp,
+		"TMP":    s.tmp,
+		"TEMP":   s.tmp,
+	}
+	if runtime.GOOS == "windows" {
+		overrides["USERPROFILE"] = s.home
+	}
+
+	env := make([]string, 0, len(os.Environ())+len(overrides))
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := overrides[strings.ToUpper(key)]; ok {
+			continue
+		}
+		env = append(env, kv)
+	}
+	for key, value := range overrides {
+		env = append(env, key+"="+value)
+	}
+	cmd.Env = env
+}
+
+// crashed checks if the JVM left a crash report in the sandbox
+func (s *probeSandbox) crashed() bool {
+	for _, pattern := range []string{"hs_err_pid*.log", "replay_pid*.log"} {
+		if matches, _ := filepath.Glob(filepath.Join(s.dir, pattern)); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanup removes the sandbox and everything the probe left in it
+func (s *probeSandbox) cleanup() {
+	os.RemoveAll(s.dir)
+}
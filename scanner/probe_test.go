@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"jfind/internal/fakejdk"
+)
+
+func TestProbeSandbox(t *testing.T) {
+	sandbox, err := newProbeSandbox()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("java", "-version")
+	sandbox.apply(cmd)
+	if cmd.Dir != sandbox.dir {
+		t.Errorf("Expected the probe to run in %s, got %s", sandbox.dir, cmd.Dir)
+	}
+	for _, kv := range []string{"HOME=" + sandbox.home, "TMPDIR=" + sandbox.tmp, "TMP=" + sandbox.tmp, "TEMP=" + sandbox.tmp} {
+		if !slices.Contains(cmd.Env, kv) {
+			t.Errorf("Expected %s in the environment of the probe", kv)
+		}
+	}
+
+	if sandbox.crashed() {
+		t.Error("Expected no crash in an empty sandbox")
+	}
+	writeTestFile(t, filepath.Join(sandbox.dir, "replay_pid42.log"), "")
+	if !sandbox.crashed() {
+		t.Error("Expected a crash with a replay file in the sandbox")
+	}
+	sandbox.cleanup()
+	if _, err := os.Stat(sandbox.dir); !os.IsNotExist(err) {
+		t.Errorf("Expected the sandbox to be removed, got %v", err)
+	}
+}
+
+func TestProbeCrashLeavesNoReport(t *testing.T) {
+	root := t.TempDir()
+	crashing := fakejdk.Create(t, filepath.Join(root, "crashing"), fakejdk.JDK{Version: "11.0.2", Crash: true})
+
+	// The crash report would be written where jfind was started
+	work, tmp := t.TempDir(), t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(work); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	finder := NewJavaFinder(root, -1, false, true)
+	if result := finder.newResult(context.Background(), crashing); result.Status != ProbeCrashed {
+		t.Errorf("Expected a crashed probe, got %+v", result)
+	}
+	for _, dir := range []string{work, tmp} {
+		if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+			t.Errorf("Expected nothing left in %s, got %v", dir, entries)
+		}
+	}
+}
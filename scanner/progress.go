@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Intervals of the -progress line: updated in place on a terminal, a line of
+// its own otherwise, e.g. in the log of a service
+const (
+	progressInterval    = 250 * time.Millisecond
+	progressLogInterval = 10 * time.Second
+)
+
+// progressWidth is the width the -progress line is kept to, so it fits on one
+// line of a terminal and can be overwritten
+const progressWidth = 79
+
+// scanProgress counts the walk for the -progress line. The workers share it,
+// the goroutine printing the line reads the counters while they scan.
+type scanProgress struct {
+	dirs  atomic.Int64
+	found atomic.Int64
+	path  atomic.Pointer[string]
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// dir counts a directory scanned
+func (p *scanProgress) dir(path string) {
+	if p == nil {
+		return
+	}
+	p.dirs.Add(1)
+	p.path.Store(&path)
+}
+
+// match counts a java executable found
+func (p *scanProgress) match() {
+	if p == nil {
+		return
+	}
+	p.found.Add(1)
+}
+
+// start prints the progress line to w until finish is called. On a terminal
+// the line is updated in place.
+func (p *scanProgress) start(w io.Writer, clock Clock, terminal bool) {
+	interval := progressLogInterval
+	if terminal {
+		interval = progressInterval
+	}
+	started := clock.Now()
+	p.stop = make(chan struct{})
+	p.done.Add(1)
+	go func() {
+		defer p.done.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last, lastDirs := started, int64(0)
+		for {
+			var stopped bool
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				stopped = true
+			}
+			now := clock.Now()
+			dirs := p.dirs.Load()
+			rate := 0.0
+			if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+				rate = float64(dirs-lastDirs) / elapsed
+			}
+			last, lastDirs = now, dirs
+			line := p.line(dirs, rate, now.Sub(started))
+			switch {
+			case terminal && stopped:
+				fmt.Fprintf(w, "\r%-*s\n", progressWidth, line)
+			case terminal:
+				fmt.Fprintf(w, "\r%-*s", progressWidth, line)
+			default:
+				fmt.Fprintln(w, line)
+			}
+			if stopped {
+				return
+			}
+		}
+	}()
+}
+
+// finish prints the progress line a last time and stops printing it
+func (p *scanProgress) finish() {
+	if p == nil || p.stop == nil {
+		return
+	}
+	close(p.stop)
+	p.done.Wait()
+	p.stop = nil
+}
+
+// line formats the progress, shortening the current path from the left to fit
+func (p *scanProgress) line(dirs int64, rate float64, elapsed time.Duration) string {
+	line := fmt.Sprintf("%s  %d dirs  %.0f dirs/s  %d java", elapsed.Round(time.Second), dirs, rate, p.found.Load())
+	path := p.path.Load()
+	if path == nil {
+		return line
+	}
+	room := progressWidth - len(line) - 2
+	current := []rune(*path)
+	if room < 4 {
+		return line
+	} else if len(current) > room {
+		current = append([]rune("..."), current[len(current)-room+3:]...)
+	}
+	return line + "  " + string(current)
+}
+
+// isTerminal checks if a file is a terminal, where the progress line is
+// updated in place
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0 && !strings.EqualFold(os.Getenv("TERM"), "dumb")
+}
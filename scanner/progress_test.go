@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanProgress(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	fsys := fakeFileSystem{}
+	fsys.add(filepath.Join(root, "opt", "jdk-17", "bin", javaExecutableName()), 0755, "")
+	fsys.add(filepath.Join(root, "opt", "jdk-21", "bin", javaExecutableName()), 0755, "")
+	fsys.add(filepath.Join(root, "home", "README"), 0644, "")
+
+	for _, workers := range []int{1, 4} {
+		finder := NewJavaFinder(root, -1, false, false)
+		finder.SetSystem(System{Clock: fixedClock(time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)), FS: fsys, Exec: fakeExecutor{}, Host: fakeHost("build01")})
+		finder.workers = workers
+		finder.progress = &scanProgress{}
+		var buf bytes.Buffer
+		finder.progress.start(&buf, finder.sys.Clock, false)
+		if _, err := finder.Find(); err != nil {
+			t.Fatal(err)
+		}
+		finder.progress.finish()
+		if dirs := finder.progress.dirs.Load(); dirs != int64(finder.scanned) || dirs != 7 {
+			t.Errorf("Expected the directories counted by the walk with %d workers, got %d of %d", workers, dirs, finder.scanned)
+		}
+		if line := strings.TrimSpace(buf.String()); !strings.HasPrefix(line, "0s  7 dirs  0 dirs/s  2 java  "+root) {
+			t.Errorf("Expected a last progress line, got %q", line)
+		}
+	}
+}
+
+func TestProgressLine(t *testing.T) {
+	p := &scanProgress{}
+	if got := p.line(0, 0, 0); got != "0s  0 dirs  0 dirs/s  0 java" {
+		t.Errorf("Expected the counters only before the first directory, got %q", got)
+	}
+	p.dir(filepath.FromSlash("/srv/" + strings.Repeat("deep/", 30) + "jdk"))
+	p.match()
+	line := p.line(12345, 812.4, 65*time.Second+400*time.Millisecond)
+	if !strings.HasPrefix(line, "1m5s  12345 dirs  812 dirs/s  1 java  ...") || !strings.HasSuffix(line, "jdk") || len(line) != progressWidth {
+		t.Errorf("Expected the path shortened from the left to fit, got %q", line)
+	}
+}
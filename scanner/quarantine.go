@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Enforcement modes of jfind serve
+const (
+	EnforceReport     = "report"     // only report unapproved runtimes
+	EnforceQuarantine = "quarantine" // make unapproved java executables non-executable
+)
+
+// QuarantinePolicy decides which runtimes jfind serve -enforce quarantine contains.
+// A runtime is unapproved if it has one of the findings and neither an
+// acknowledgment covers the finding nor its path matches an approved glob.
+type QuarantinePolicy struct {
+	// Findings make a runtime unapproved, default oracle_jdk
+	Findings []string `json:"findings,omitempty"`
+
+	// Approved are globs of java executables that are never quarantined
+	Approved []string `json:"approved,omitempty"`
+
+	// State is the file recording the quarantined executables and how to restore them
+	State string `json:"state"`
+}
+
+// QuarantineEntry is a java executable made non-executable, with what is needed to restore it
+type QuarantineEntry struct {
+	JavaExecutable string `json:"java_executable"`
+	Finding        string `json:"finding"`
+	Method         string `json:"method"`                  // chmod or acl
+	OriginalMode   string `json:"original_mode,omitempty"` // permissions before chmod, e.g. 0755
+	QuarantinedAt  string `json:"quarantined_at"`
+}
+
+// parse validates the policy and fills in the defaults
+func (p *QuarantinePolicy) parse() error {
+	if len(p.Findings) == 0 {
+		p.Findings = []string{FindingOracleJDK}
+	}
+	for _, finding := range p.Findings {
+		if !slices.Contains(findingNames, finding) {
+			return fmt.Errorf("unknown finding %q in quarantine (use %s)", finding, strings.Join(findingNames, ", "))
+		}
+	}
+	if p.State == "" {
+		return fmt.Errorf("quarantine requires a state file")
+	}
+	return nil
+}
+
+// approved reports whether a java executable matches an approved glob
+func (p *QuarantinePolicy) approved(javaPath string) bool {
+	for _, pattern := range p.Approved {
+		if matchGlob(expandPattern(pattern), filepath.ToSlash(javaPath)) {
+			return true
+		}
+	}
+	return false
+}
+
+// unapproved returns the first finding of the policy a runtime has that is not acknowledged
+func (p *QuarantinePolicy) unapproved(rt *JavaRuntimeJSON, acks []Acknowledgment) string {
+	if p.approved(rt.JavaExecutable) {
+		return ""
+	}
+	for _, finding := range runtimeFindings(rt) {
+		if slices.Contains(p.Findings, finding) && findAcknowledgment(acks, finding, rt.JavaExecutable) == nil {
+			return finding
+		}
+	}
+	return ""
+}
+
+// quarantineLedger is the state file of the quarantined java executables
+type quarantineLedger struct {
+	path    string
+	entries map[string]*QuarantineEntry
+}
+
+// loadQuarantineLedger reads the state file; a missing file starts an empty ledger
+func loadQuarantineLedger(path string) (*quarantineLedger, error) {
+	l := &quarantineLedger{path: path, entries: make(map[string]*QuarantineEntry)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantine state %s: %v", path, err)
+	}
+	var entries []*QuarantineEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse quarantine state %s: %v", path, err)
+	}
+	for _, entry := range entries {
+		l.entries[entry.JavaExecutable] = entry
+	}
+	return l, nil
+}
+
+// resolveExecutable returns the path a java executable resolves to, or the
+// path itself if it cannot be resolved. The ledger is keyed by it, so the
+// aliases of a runtime are quarantined once, with the mode of the executable.
+func resolveExecutable(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// lookup returns the key of the entry of a java executable, by its path or by
+// the path it resolves to, or "" if it is not quarantined
+func (l *quarantineLedger) lookup(javaPath string) string {
+	if _, ok := l.entries[javaPath]; ok {
+		return javaPath
+	}
+	if resolved := resolveExecutable(javaPath); l.entries[resolved] != nil {
+		return resolved
+	}
+	return ""
+}
+
+// paths returns the quarantined java executables, for the walk to keep
+// reporting them although they are no longer executable
+func (l *quarantineLedger) paths() map[string]bool {
+	paths := make(map[string]bool, len(l.entries))
+	for path := range l.entries {
+		paths[path] = true
+	}
+	return paths
+}
+
+// isQuarantined reports whether jfind serve quarantined a java executable. A
+// quarantined runtime is still installed: it is reported, evaluated statically
+// and flagged quarantined, rather than dropped from the report and recorded
+// as removed by the collector.
+func (f *JavaFinder) isQuarantined(path string) bool {
+	if len(f.quarantined) == 0 {
+		return false
+	}
+	return f.quarantined[path] || f.quarantined[resolveExecutable(path)]
+}
+
+// list returns the entries sorted by path
+func (l *quarantineLedger) list() []QuarantineEntry {
+	entries := make([]QuarantineEntry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		entries = append(entries, *entry)
+	}
+	slices.SortFunc(entries, func(a, b QuarantineEntry) int { return strings.Compare(a.JavaExecutable, b.JavaExecutable) })
+	return entries
+}
+
+// save writes the ledger, replacing the file atomically
+func (l *quarantineLedger) save() error {
+	data, err := json.MarshalIndent(l.list(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write quarantine state: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write quarantine state: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write quarantine state: %v", err)
+	}
+	return os.Rename(tmp.Name(), l.path)
+}
+
+// release restores a quarantined java executable and removes it from the ledger
+func (l *quarantineLedger) release(javaPath string) error {
+	key := l.lookup(javaPath)
+	if key == "" {
+		return fmt.Errorf("%s is not quarantined", javaPath)
+	}
+	if err := restoreExecutable(l.entries[key]); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to restore %s: %v", javaPath, err)
+	}
+	delete(l.entries, key)
+	return nil
+}
+
+// enforce quarantines the unapproved runtimes of a report and releases the
+// quarantined ones that have been approved or acknowledged since. The runtimes
+// that remain quarantined are flagged in the report. The ledger is saved
+// before it returns, so every change is recorded.
+func (l *quarantineLedger) enforce(policy *QuarantinePolicy, output *JSONOutput, now time.Time) error {
+	var errs []string
+	for path, entry := range l.entries {
+		approved := policy.approved(path) || !slices.Contains(policy.Findings, entry.Finding)
+		if approved || findAcknowledgment(output.Acknowledgments, entry.Finding, path) != nil {
+			if err := l.release(path); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	for i := range output.Runtimes {
+		rt := &output.Runtimes[i]
+		if l.lookup(rt.JavaExecutable) != "" {
+			rt.Quarantined = true
+			continue
+		}
+		finding := policy.unapproved(rt, output.Acknowledgments)
+		if finding == "" {
+			continue
+		}
+		// Chmod and icacls follow symbolic links, the entry is for the file they change
+		path := resolveExecutable(rt.JavaExecutable)
+		entry, err := quarantineExecutable(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to quarantine %s: %v", rt.JavaExecutable, err))
+			continue
+		}
+		entry.Finding = finding
+		entry.QuarantinedAt = now.UTC().Format(time.RFC3339)
+		l.entries[path] = entry
+		rt.Quarantined = true
+	}
+	if err := l.save(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// runQuarantine implements the quarantine command, which lists the quarantined
+// java executables of jfind serve and releases them
+func runQuarantine(args []string) int {
+	fs := flag.NewFlagSet("quarantine", flag.ContinueOnError)
+	state := fs.String("state", "", "Quarantine state file of jfind serve (state of quarantine in the configuration)")
+	configFile := fs.String("config", "", "Path to the JSON configuration file of jfind serve, to read the state file from")
+	releaseAll := fs.Bool("release-all", false, "Restore all quarantined java executables")
+	fs.Usage = func() {
+		logf("Usage: jfind quarantine [-state file | -config file] [-release-all | <java executable>...]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *state == "" && *configFile != "" {
+		cfg, err := LoadConfig(*configFile)
+		if err != nil {
+			logf("Error: %v\n", err)
+			return 2
+		}
+		if cfg.Quarantine != nil {
+			*state = cfg.Quarantine.State
+		}
+	}
+	if *state == "" {
+		fs.Usage()
+		return 2
+	}
+
+	ledger, err := loadQuarantineLedger(*state)
+	if err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	release := fs.Args()
+	if *releaseAll {
+		release = nil
+		for _, entry := range ledger.list() {
+			release = append(release, entry.JavaExecutable)
+		}
+	}
+	if len(release) == 0 && !*releaseAll {
+		for _, entry := range ledger.list() {
+			fmt.Printf("%s\t%s\t%s\n", entry.QuarantinedAt, entry.Finding, entry.JavaExecutable)
+		}
+		return 0
+	}
+
+	code := 0
+	for _, path := range release {
+		if err := ledger.release(path); err != nil {
+			logf("Error: %v\n", err)
+			code = 1
+			continue
+		}
+		logf("Released %s\n", path)
+	}
+	if err := ledger.save(); err != nil {
+		logf("Error: %v\n", err)
+		return 1
+	}
+	return code
+}
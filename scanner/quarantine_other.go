@@ -0,0 +1,31 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// quarantineExecutable removes the execute permissions of a java executable
+func quarantineExecutable(path string) (*QuarantineEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	mode := info.Mode().Perm()
+	if err := os.Chmod(path, mode&^0111); err != nil {
+		return nil, err
+	}
+	return &QuarantineEntry{JavaExecutable: path, Method: "chmod", OriginalMode: fmt.Sprintf("%04o", mode)}, nil
+}
+
+// restoreExecutable restores the permissions a java executable had before the quarantine
+func restoreExecutable(entry *QuarantineEntry) error {
+	mode, err := strconv.ParseUint(entry.OriginalMode, 8, 32)
+	if err != nil {
+		return fmt.Errorf("invalid original mode %q", entry.OriginalMode)
+	}
+	return os.Chmod(entry.JavaExecutable, os.FileMode(mode))
+}
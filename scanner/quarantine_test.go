@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestQuarantinePolicyParse(t *testing.T) {
+	policy := QuarantinePolicy{State: "/var/lib/jfind/quarantine.json"}
+	if err := policy.parse(); err != nil || len(policy.Findings) != 1 || policy.Findings[0] != FindingOracleJDK {
+		t.Errorf("Expected oracle_jdk by default, got %v, %v", policy.Findings, err)
+	}
+	for _, invalid := range []QuarantinePolicy{
+		{Findings: []string{"unapproved"}, State: "q.json"},
+		{Findings: []string{FindingExecFailed}},
+	} {
+		if err := invalid.parse(); err == nil {
+			t.Errorf("Expected an error for %+v", invalid)
+		}
+	}
+}
+
+func TestQuarantineEnforce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the quarantine uses icacls on Windows")
+	}
+	dir := t.TempDir()
+	oracle := createFakeJava(t, filepath.Join(dir, "oracle", "jdk1.8.0_381"))
+	approved := createFakeJava(t, filepath.Join(dir, "approved", "jdk-17"))
+	acknowledged := createFakeJava(t, filepath.Join(dir, "licensed", "jdk-11"))
+	temurin := createFakeJava(t, filepath.Join(dir, "temurin-17"))
+
+	required := true
+	output := &JSONOutput{
+		Runtimes: []JavaRuntimeJSON{
+			{JavaExecutable: oracle, IsOracle: true, RequireLicense: &required},
+			{JavaExecutable: approved, IsOracle: true},
+			{JavaExecutable: acknowledged, IsOracle: true},
+			{JavaExecutable: temurin},
+		},
+		Acknowledgments: []Acknowledgment{{Finding: FindingOracleJDK, Path: filepath.ToSlash(filepath.Dir(filepath.Dir(acknowledged))) + "/**", Note: "licensed"}},
+	}
+	policy := &QuarantinePolicy{Approved: []string{filepath.ToSlash(dir) + "/approved/**"}, State: filepath.Join(dir, "quarantine.json")}
+	if err := policy.parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger, _ := loadQuarantineLedger(policy.State)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := ledger.enforce(policy, output, now); err != nil {
+		t.Fatal(err)
+	}
+	entries := ledger.list()
+	if len(entries) != 1 || entries[0].JavaExecutable != oracle || entries[0].Finding != FindingOracleJDK ||
+		entries[0].OriginalMode != "0755" || entries[0].QuarantinedAt != "2025-03-01T12:00:00Z" {
+		t.Fatalf("Expected only the unapproved Oracle JDK to be quarantined, got %+v", entries)
+	}
+	for path, executable := range map[string]bool{oracle: false, approved: true, acknowledged: true, temurin: true} {
+		info, _ := os.Stat(path)
+		if isExecutable(info) != executable {
+			t.Errorf("Expected %s to be executable: %v, mode %s", path, executable, info.Mode())
+		}
+	}
+
+	// The state survives a restart, and an acknowledgment releases the runtime
+	ledger, err := loadQuarantineLedger(policy.State)
+	if err != nil || len(ledger.entries) != 1 {
+		t.Fatalf("Expected the quarantine state to be saved, got %v, %v", ledger, err)
+	}
+	output.Runtimes = nil
+	output.Acknowledgments = append(output.Acknowledgments, Acknowledgment{Finding: FindingOracleJDK, Path: filepath.ToSlash(oracle), Note: "bought"})
+	if err := ledger.enforce(policy, output, now); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(oracle); info.Mode().Perm() != 0755 || len(ledger.entries) != 0 {
+		t.Errorf("Expected the acknowledged runtime to be released, mode %s, %d entries", info.Mode(), len(ledger.entries))
+	}
+	if err := ledger.release(oracle); err == nil {
+		t.Error("Expected an error releasing a runtime that is not quarantined")
+	}
+}
+
+func TestQuarantineAliases(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the quarantine uses icacls on Windows")
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	oracle := createFakeJava(t, filepath.Join(dir, "oracle", "jdk1.8.0_381"))
+	link := filepath.Join(dir, "java")
+	if err := os.Symlink(oracle, link); err != nil {
+		t.Fatal(err)
+	}
+	policy := &QuarantinePolicy{State: filepath.Join(dir, "quarantine.json")}
+	if err := policy.parse(); err != nil {
+		t.Fatal(err)
+	}
+	ledger, _ := loadQuarantineLedger(policy.State)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// The alias and the executable it links to are one entry, with the mode of the executable
+	output := &JSONOutput{Runtimes: []JavaRuntimeJSON{{JavaExecutable: link, IsOracle: true}, {JavaExecutable: oracle, IsOracle: true}}}
+	if err := ledger.enforce(policy, output, now); err != nil {
+		t.Fatal(err)
+	}
+	entries := ledger.list()
+	if len(entries) != 1 || entries[0].JavaExecutable != oracle || entries[0].OriginalMode != "0755" {
+		t.Fatalf("Expected one entry for the resolved executable, got %+v", entries)
+	}
+	if !output.Runtimes[0].Quarantined || !output.Runtimes[1].Quarantined {
+		t.Errorf("Expected both paths to be flagged quarantined, got %+v", output.Runtimes)
+	}
+
+	// The walk keeps reporting the quarantined runtime, evaluated statically
+	finder := NewJavaFinder(dir, -1, false, true)
+	finder.quarantined = ledger.paths()
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found *JavaResult
+	for _, result := range results {
+		if result.Path == oracle {
+			found = result
+		}
+	}
+	if found == nil || found.Status != ProbeNotExecuted {
+		t.Errorf("Expected the quarantined runtime to be reported without executing it, got %+v", found)
+	}
+
+	// Releasing it by its alias restores the executable
+	if err := ledger.release(link); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(oracle); info.Mode().Perm() != 0755 || len(ledger.entries) != 0 {
+		t.Errorf("Expected the runtime to be released, mode %s, %d entries", info.Mode(), len(ledger.entries))
+	}
+}
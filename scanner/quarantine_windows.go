@@ -0,0 +1,35 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// everyoneSID is the well-known SID of the Everyone group
+const everyoneSID = "*S-1-1-0"
+
+// quarantineExecutable denies Everyone the right to execute a java executable
+func quarantineExecutable(path string) (*QuarantineEntry, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	if out, err := exec.Command("icacls", path, "/deny", everyoneSID+":(X)").CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("icacls failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return &QuarantineEntry{JavaExecutable: path, Method: "acl"}, nil
+}
+
+// restoreExecutable removes the deny entry of Everyone added by the quarantine
+func restoreExecutable(entry *QuarantineEntry) error {
+	if _, err := os.Stat(entry.JavaExecutable); err != nil {
+		return err
+	}
+	if out, err := exec.Command("icacls", entry.JavaExecutable, "/remove:d", everyoneSID).CombinedOutput(); err != nil {
+		return fmt.Errorf("icacls failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
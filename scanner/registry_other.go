@@ -0,0 +1,8 @@
+//go:build !windows
+
+package main
+
+// registryRuntimes returns nothing, only Windows has a registry
+func registryRuntimes() []string {
+	return nil
+}
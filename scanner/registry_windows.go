@@ -0,0 +1,19 @@
+//go:build windows
+
+package main
+
+import "path/filepath"
+
+// registryRuntimes returns the java executables of the runtimes registered
+// below the JavaSoft keys, one per version key
+func registryRuntimes() []string {
+	var javas []string
+	for _, key := range javaSoftKeys {
+		for _, version := range registrySubkeys(key) {
+			if home, ok := readRegistryString(key+`\`+version, "JavaHome"); ok {
+				javas = append(javas, filepath.Join(home, "bin", "java.exe"))
+			}
+		}
+	}
+	return javas
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// regoQuery is the rule a Rego policy defines. It is evaluated with the JSON
+// report as input and yields violations.
+const regoQuery = "data.jfind.violations"
+
+// regoTimeout limits an evaluation, so a hanging opa does not hold up the pipeline
+var regoTimeout = time.Minute
+
+// regoPolicy evaluates Rego policy files with an external Open Policy Agent
+// executable. jfind has no dependencies and does not embed a Rego evaluator,
+// -policy is only available where opa is installed.
+type regoPolicy struct {
+	opa   string
+	files []string
+}
+
+// newRegoPolicy checks that the policy files exist and opa can be found
+func newRegoPolicy(list []string, opa string) (*regoPolicy, error) {
+	var files []string
+	for _, file := range list {
+		if file = strings.TrimSpace(file); file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("policy %s: %v", file, err)
+		}
+		files = append(files, file)
+	}
+	path, err := exec.LookPath(opa)
+	if err != nil {
+		return nil, fmt.Errorf("Open Policy Agent (%s) not found, required for Rego policies: %v", opa, err)
+	}
+	return &regoPolicy{opa: path, files: files}, nil
+}
+
+// evaluate runs the policies against a report and returns their violations
+func (p *regoPolicy) evaluate(output *JSONOutput) ([]Violation, error) {
+	input, err := json.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"eval", "--format", "json", "--stdin-input"}
+	for _, file := range p.files {
+		args = append(args, "--data", file)
+	}
+	args = append(args, regoQuery)
+
+	ctx, cancel := context.WithTimeout(context.Background(), regoTimeout)
+	defer cancel()
+	cmd := probeCommand(ctx, p.opa, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	result, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("opa eval did not finish within %v", regoTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opa eval failed: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseOPAResult(result)
+}
+
+// parseOPAResult converts the output of opa eval --format json to violations.
+// The rule may yield objects with the fields of Violation or plain messages,
+// which become errors without a path. An undefined rule means no violations.
+func parseOPAResult(data []byte) ([]Violation, error) {
+	var result struct {
+		Result []struct {
+			Expressions []struct {
+				Value json.RawMessage `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse opa output: %v", err)
+	}
+
+	var violations []Violation
+	for _, r := range result.Result {
+		for _, expr := range r.Expressions {
+			var items []json.RawMessage
+			if err := json.Unmarshal(expr.Value, &items); err != nil {
+				return nil, fmt.Errorf("%s must be a set or array of violations: %v", regoQuery, err)
+			}
+			for _, item := range items {
+				v, err := parseRegoViolation(item)
+				if err != nil {
+					return nil, err
+				}
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations, nil
+}
+
+// parseRegoViolation converts one element of the violations rule
+func parseRegoViolation(item json.RawMessage) (Violation, error) {
+	var message string
+	if err := json.Unmarshal(item, &message); err == nil {
+		return Violation{Severity: SeverityError, Title: message, Message: message}, nil
+	}
+
+	var v Violation
+	if err := json.Unmarshal(item, &v); err != nil {
+		return v, fmt.Errorf("invalid violation %s: %v", item, err)
+	}
+	switch v.Severity {
+	case "":
+		v.Severity = SeverityError
+	case SeverityError, SeverityWarning:
+	default:
+		return v, fmt.Errorf("invalid severity %q in violation %s (use error or warning)", v.Severity, item)
+	}
+	if v.Title == "" {
+		v.Title = v.Message
+	}
+	if v.Message == "" {
+		v.Message = v.Title
+	}
+	return v, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOPAResult(t *testing.T) {
+	output := `{"result": [{"expressions": [{"value": [
+		{"severity": "warning", "path": "/opt/jdk-11/bin/java", "title": "Java 11 is end of life", "message": "Upgrade to 21"},
+		{"path": "/opt/jdk8/bin/java", "message": "Java 8 is not allowed"},
+		"No approved runtime found"
+	], "text": "data.jfind.violations"}]}]}`
+
+	violations, err := parseOPAResult([]byte(output))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(violations) != 3 {
+		t.Fatalf("Expected 3 violations, got %+v", violations)
+	}
+	if violations[0].Severity != SeverityWarning || violations[0].Title != "Java 11 is end of life" {
+		t.Errorf("Unexpected first violation %+v", violations[0])
+	}
+	if violations[1].Severity != SeverityError || violations[1].Title != "Java 8 is not allowed" {
+		t.Errorf("Expected defaults for severity and title, got %+v", violations[1])
+	}
+	if violations[2].Message != "No approved runtime found" || violations[2].Path != "" {
+		t.Errorf("Expected a message without path, got %+v", violations[2])
+	}
+
+	// Undefined rule
+	if violations, err := parseOPAResult([]byte(`{}`)); err != nil || violations != nil {
+		t.Errorf("Expected no violations, got %+v (%v)", violations, err)
+	}
+
+	if _, err := parseOPAResult([]byte(`{"result": [{"expressions": [{"value": [{"severity": "fatal"}]}]}]}`)); err == nil {
+		t.Error("Expected an error for an invalid severity")
+	}
+}
+
+func TestRegoPolicyEvaluate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of opa")
+	}
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "java.rego")
+	if err := os.WriteFile(policyFile, []byte("package jfind\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The fake opa saves its arguments and input and returns one violation
+	opa := filepath.Join(dir, "opa")
+	script := `#!/bin/sh
+echo "$@" > "` + dir + `/args"
+cat > "` + dir + `/input"
+echo '{"result": [{"expressions": [{"value": ["denied"]}]}]}'
+`
+	if err := os.WriteFile(opa, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := newRegoPolicy([]string{policyFile}, opa)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	violations, err := policy.evaluate(&JSONOutput{Meta: MetaInfo{ScanID: "scan-1"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Message != "denied" {
+		t.Errorf("Expected one violation, got %+v", violations)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if !strings.Contains(string(args), "--data "+policyFile) || !strings.Contains(string(args), regoQuery) {
+		t.Errorf("Unexpected opa arguments %s", args)
+	}
+	input, _ := os.ReadFile(filepath.Join(dir, "input"))
+	if !strings.Contains(string(input), `"scan_id":"scan-1"`) {
+		t.Errorf("Expected the report as input, got %s", input)
+	}
+}
+
+func TestRegoPolicyTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of opa")
+	}
+	dir := t.TempDir()
+	opa := filepath.Join(dir, "opa")
+	if err := os.WriteFile(opa, []byte("#!/bin/sh\nexec sleep 10\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(timeout time.Duration) { regoTimeout = timeout }(regoTimeout)
+	regoTimeout = 100 * time.Millisecond
+
+	policy, err := newRegoPolicy(nil, opa)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	start := time.Now()
+	if _, err := policy.evaluate(&JSONOutput{}); err == nil || !strings.Contains(err.Error(), "did not finish") {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the evaluation to be stopped, took %v", elapsed)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// findJavaHome returns the installation directory of a java executable, i.e. the
+// directory containing the release file. For Java 8 JDKs the launcher in jre/bin
+// belongs to the JDK home two levels up.
+func findJavaHome(fsys FileSystem, javaPath string) (string, bool) {
+	home := filepath.Dir(filepath.Dir(javaPath))
+	for _, dir := range []string{home, filepath.Dir(home)} {
+		if _, err := fsys.Stat(filepath.Join(dir, "release")); err == nil {
+			return dir, true
+		}
+	}
+	return home, false
+}
+
+// readReleaseFile reads the release file of a Java installation
+func readReleaseFile(fsys FileSystem, home string) (map[string]string, error) {
+	data, err := fsys.ReadFile(filepath.Join(home, "release"))
+	if err != nil {
+		return nil, err
+	}
+	return parseReleaseFile(string(data)), nil
+}
+
+// parseReleaseFile parses the KEY="value" lines of a Java release file
+func parseReleaseFile(content string) map[string]string {
+	release := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `"`)
+		}
+		release[strings.TrimSpace(key)] = value
+	}
+	return release
+}
+
+// releaseModules returns the modules listed in a release file, or nil for
+// runtimes without a module system (Java 8 and earlier)
+func releaseModules(release map[string]string) []string {
+	return strings.Fields(release["MODULES"])
+}
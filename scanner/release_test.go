@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReleaseFile(t *testing.T) {
+	content := `IMPLEMENTOR="Eclipse Adoptium"
+IMPLEMENTOR_VERSION="Temurin-17.0.9+9"
+JAVA_VERSION="17.0.9"
+MODULES="java.base java.logging java.se jdk.crypto.ec"
+# comment
+SOURCE=".:git:1234"
+`
+	release := parseReleaseFile(content)
+
+	if release["IMPLEMENTOR"] != "Eclipse Adoptium" {
+		t.Errorf("Expected implementor Eclipse Adoptium, got %s", release["IMPLEMENTOR"])
+	}
+	if release["JAVA_VERSION"] != "17.0.9" {
+		t.Errorf("Expected version 17.0.9, got %s", release["JAVA_VERSION"])
+	}
+	want := []string{"java.base", "java.logging", "java.se", "jdk.crypto.ec"}
+	if got := releaseModules(release); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected modules %v, got %v", want, got)
+	}
+}
+
+func TestModuleWarnings(t *testing.T) {
+	jlinked := []string{"java.base", "java.logging"}
+
+	warnings := moduleWarnings(jlinked, 17, defaultRequiredModules)
+	if len(warnings) != 2 {
+		t.Errorf("Expected 2 warnings for Java 17, got %v", warnings)
+	}
+
+	// jdk.crypto.ec is part of java.base since Java 22
+	warnings = moduleWarnings(jlinked, 22, defaultRequiredModules)
+	if len(warnings) != 1 || warnings[0] != "missing module java.se" {
+		t.Errorf("Expected only java.se warning for Java 22, got %v", warnings)
+	}
+
+	// Java 8 has no module list
+	if warnings := moduleWarnings(nil, 8, defaultRequiredModules); warnings != nil {
+		t.Errorf("Expected no warnings without module list, got %v", warnings)
+	}
+}
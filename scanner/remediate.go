@@ -0,0 +1,603 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Remediation actions of a plan
+const (
+	RemediateUnlink     = "unlink"     // remove the runtime from PATH and the alternatives
+	RemediateQuarantine = "quarantine" // unlink and rename the runtime home
+	RemediateUninstall  = "uninstall"  // unlink, back up and remove the runtime home
+)
+
+// Results of a step in the action log
+const (
+	StepPlanned = "planned" // dry run, the step would be taken
+	StepDone    = "done"
+	StepFailed  = "failed"
+	StepManual  = "manual" // the step must be taken by hand, e.g. a PATH entry
+)
+
+// quarantineSuffix is appended to the home of a quarantined runtime
+const quarantineSuffix = ".jfind-quarantine"
+
+// RemediationPlan is an approved list of remediation actions for one host
+type RemediationPlan struct {
+	Host       string              `json:"host"`
+	ApprovedBy string              `json:"approved_by"`
+	Ticket     string              `json:"ticket,omitempty"`
+	Expires    string              `json:"expires,omitempty"`
+	BackupDir  string              `json:"backup_dir,omitempty"`
+	Actions    []RemediationAction `json:"actions"`
+}
+
+// RemediationAction is one runtime to remediate, identified by its java executable
+type RemediationAction struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+
+	// SHA256 of the java executable, if set it must match before anything is changed
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// RemediationLogEntry is a line of the action log
+type RemediationLogEntry struct {
+	Time   string `json:"time"`
+	Ticket string `json:"ticket,omitempty"`
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	Step   string `json:"step"`
+	Target string `json:"target,omitempty"`
+	Backup string `json:"backup,omitempty"`
+	DryRun bool   `json:"dry_run"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// loadRemediationPlan reads a plan in YAML or JSON
+func loadRemediationPlan(path string) (*RemediationPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan %s: %v", path, err)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		values, err := parsePlanYAML(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse plan %s: %v", path, err)
+		}
+		data, _ = json.Marshal(values)
+	}
+	plan := &RemediationPlan{}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %v", path, err)
+	}
+	return plan, nil
+}
+
+// parsePlanYAML parses the YAML subset of a plan: top level scalars and a list of
+// mappings of scalars, e.g. "actions:" followed by "  - action: quarantine" items
+func parsePlanYAML(content string) (map[string]any, error) {
+	values := make(map[string]any)
+	var list *[]map[string]string
+	var item map[string]string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := stripYAMLComment(scanner.Text())
+		if strings.TrimSpace(line) == "" || strings.TrimSpace(line) == "---" {
+			continue
+		}
+		indented := line[0] == ' '
+		line = strings.TrimSpace(line)
+		if !indented && (list == nil || !strings.HasPrefix(line, "-")) {
+			key, value, err := parseYAMLPair(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+			list, item = nil, nil
+			if value == "" {
+				items := []map[string]string{}
+				values[key] = &items
+				list = &items
+				continue
+			}
+			values[key] = value
+			continue
+		}
+		if list == nil {
+			return nil, fmt.Errorf("line %d: unexpected indentation", n)
+		}
+		if rest, ok := strings.CutPrefix(line, "-"); ok {
+			item = make(map[string]string)
+			*list = append(*list, item)
+			line = strings.TrimSpace(rest)
+		}
+		if item == nil {
+			return nil, fmt.Errorf("line %d: expected a list item", n)
+		}
+		key, value, err := parseYAMLPair(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		item[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// parseYAMLPair parses a "key: value" line, unquoting the value
+func parseYAMLPair(line string) (key, value string, err error) {
+	key, value, ok := strings.Cut(line, ":")
+	if !ok || strings.TrimSpace(key) == "" {
+		return "", "", fmt.Errorf("expected key: value, got %q", line)
+	}
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		if value, err = strconv.Unquote(value); err != nil {
+			return "", "", err
+		}
+	} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	return strings.TrimSpace(key), value, nil
+}
+
+// stripYAMLComment removes a comment starting with # outside of quotes
+func stripYAMLComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return strings.TrimRight(line, " \t")
+}
+
+// validate checks the plan is approved for the host and still valid. Every action is
+// checked against the file system, so nothing is changed if any action is wrong.
+func (p *RemediationPlan) validate(host string, now time.Time) error {
+	if p.ApprovedBy == "" {
+		return fmt.Errorf("plan is not approved, approved_by is missing")
+	}
+	if p.Host == "" || !strings.EqualFold(p.Host, host) {
+		return fmt.Errorf("plan is for host %q, this is %q", p.Host, host)
+	}
+	if p.Expires != "" {
+		expires, err := time.ParseInLocation(time.DateOnly, p.Expires, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid expires %q", p.Expires)
+		}
+		if !now.Before(expires.AddDate(0, 0, 1)) {
+			return fmt.Errorf("plan expired on %s", p.Expires)
+		}
+	}
+	if len(p.Actions) == 0 {
+		return fmt.Errorf("plan has no actions")
+	}
+	for i, action := range p.Actions {
+		if err := action.validate(p.BackupDir); err != nil {
+			return fmt.Errorf("action %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// validate checks the action refers to an existing runtime with a safe home directory
+func (a *RemediationAction) validate(backupDir string) error {
+	switch a.Action {
+	case RemediateUnlink, RemediateQuarantine:
+	case RemediateUninstall:
+		if backupDir == "" {
+			return fmt.Errorf("%s requires backup_dir", a.Action)
+		}
+	default:
+		return fmt.Errorf("invalid action %q, must be %s, %s or %s", a.Action, RemediateUnlink, RemediateQuarantine, RemediateUninstall)
+	}
+	if !filepath.IsAbs(a.Path) || !isJavaExecutable(filepath.Base(a.Path)) {
+		return fmt.Errorf("path %q is not the absolute path of a java executable", a.Path)
+	}
+	info, err := os.Lstat(a.Path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", a.Path)
+	}
+	if a.SHA256 != "" {
+		sum, err := fileSHA256(a.Path)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(sum, a.SHA256) {
+			return fmt.Errorf("sha256 of %s is %s, the plan expects %s", a.Path, sum, a.SHA256)
+		}
+	}
+	home, err := remediationHome(osSystem.FS, a.Path)
+	if err != nil {
+		return err
+	}
+	if a.Action == RemediateUninstall {
+		backup, err := filepath.Abs(backupDir)
+		if err != nil {
+			return err
+		}
+		if withinRoot(home, backup) {
+			return fmt.Errorf("backup_dir %s is inside the runtime %s it would remove", backupDir, home)
+		}
+	}
+	return nil
+}
+
+// remediationHome returns the home directory of a java executable that an
+// action may rename or remove. It must be a runtime, with a release file or
+// the lib/modules or rt.jar of one next to bin/java, and neither a file system
+// root nor a directory shared by other programs, such as /usr for /usr/bin/java
+// or the home directory of a user for ~/bin/java.
+func remediationHome(fsys FileSystem, javaPath string) (string, error) {
+	home, found := findJavaHome(fsys, javaPath)
+	if filepath.Dir(home) == home {
+		return "", fmt.Errorf("refusing to remediate the file system root %s", home)
+	}
+	if slices.Contains(sharedDirectories(), home) {
+		return "", fmt.Errorf("refusing to remediate %s, which is shared by other programs", home)
+	}
+	if parent := filepath.Dir(home); slices.Contains(userDirectories(), parent) {
+		return "", fmt.Errorf("refusing to remediate the user directory %s", home)
+	}
+	if found {
+		return home, nil
+	}
+	if _, err := fsys.Stat(filepath.Join(home, "bin", filepath.Base(javaPath))); err != nil {
+		return "", fmt.Errorf("refusing to remediate %s, it has no release file and no bin/%s", home, filepath.Base(javaPath))
+	}
+	for _, marker := range []string{filepath.Join("lib", "modules"), filepath.Join("lib", "rt.jar"), filepath.Join("jre", "lib", "rt.jar")} {
+		if _, err := fsys.Stat(filepath.Join(home, marker)); err == nil {
+			return home, nil
+		}
+	}
+	return "", fmt.Errorf("refusing to remediate %s, it has neither a release file nor lib/modules or rt.jar of a runtime", home)
+}
+
+// sharedDirectories are directories holding many programs, whose bin directory
+// may contain a java executable or a link to one
+func sharedDirectories() []string {
+	dirs := []string{"/usr", "/usr/local", "/usr/lib", "/usr/lib/jvm", "/usr/share", "/opt", "/var", "/etc",
+		"/Library", "/Library/Java", "/Library/Java/JavaVirtualMachines", "/System", "/Applications"}
+	for _, variable := range []string{"SystemRoot", "ProgramFiles", "ProgramFiles(x86)", "ProgramW6432", "ProgramData"} {
+		if dir := os.Getenv(variable); dir != "" {
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+	}
+	dirs = append(dirs, "/root")
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Clean(home))
+	}
+	return append(dirs, userDirectories()...)
+}
+
+// userDirectories are the directories holding the home directories of users
+func userDirectories() []string {
+	dirs := []string{"/home", "/Users"}
+	if home, err := os.UserHomeDir(); err == nil {
+		if parent := filepath.Dir(filepath.Clean(home)); filepath.Dir(parent) != parent {
+			dirs = append(dirs, parent)
+		}
+	}
+	return dirs
+}
+
+// fileSHA256 returns the hex encoded SHA-256 of a file
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// remediator executes the actions of a plan, writing every step to the action log
+type remediator struct {
+	plan     *RemediationPlan
+	apply    bool
+	log      *json.Encoder
+	now      func() time.Time
+	pathDirs []string
+
+	// runCommand runs update-alternatives, nil if it is not installed
+	runCommand func(name string, args ...string) ([]byte, error)
+}
+
+// newRemediator creates a remediator for the PATH and alternatives of this host
+func newRemediator(plan *RemediationPlan, apply bool, log io.Writer) *remediator {
+	r := &remediator{plan: plan, apply: apply, log: json.NewEncoder(log), now: time.Now}
+	r.pathDirs = filepath.SplitList(os.Getenv("PATH"))
+	if _, err := exec.LookPath("update-alternatives"); err == nil {
+		r.runCommand = func(name string, args ...string) ([]byte, error) {
+			return exec.Command(name, args...).CombinedOutput()
+		}
+	}
+	return r
+}
+
+// record writes a step to the action log
+func (r *remediator) record(action *RemediationAction, step, target, backup, result string, err error) {
+	entry := RemediationLogEntry{
+		Time:   r.now().UTC().Format(time.RFC3339),
+		Ticket: r.plan.Ticket,
+		Action: action.Action,
+		Path:   action.Path,
+		Step:   step,
+		Target: target,
+		Backup: backup,
+		DryRun: !r.apply,
+		Result: result,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	r.log.Encode(entry)
+}
+
+// step records a step and runs it unless this is a dry run
+func (r *remediator) step(action *RemediationAction, name, target, backup string, run func() error) error {
+	if !r.apply {
+		r.record(action, name, target, backup, StepPlanned, nil)
+		return nil
+	}
+	if err := run(); err != nil {
+		r.record(action, name, target, backup, StepFailed, err)
+		return fmt.Errorf("%s of %s failed: %v", name, target, err)
+	}
+	r.record(action, name, target, backup, StepDone, nil)
+	return nil
+}
+
+// run executes all actions of the plan, stopping at the first failing step
+func (r *remediator) run() error {
+	for i := range r.plan.Actions {
+		if err := r.remediate(&r.plan.Actions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remediate executes the steps of one action
+func (r *remediator) remediate(action *RemediationAction) error {
+	home, err := remediationHome(osSystem.FS, action.Path)
+	if err != nil {
+		return err
+	}
+	if err := r.unlink(action, home); err != nil {
+		return err
+	}
+	switch action.Action {
+	case RemediateQuarantine:
+		target := home + quarantineSuffix
+		return r.step(action, "rename", home, target, func() error {
+			if _, err := os.Lstat(target); err == nil {
+				return fmt.Errorf("%s already exists", target)
+			}
+			return os.Rename(home, target)
+		})
+	case RemediateUninstall:
+		backup := filepath.Join(r.plan.BackupDir, fmt.Sprintf("%s-%s.zip", filepath.Base(home), r.now().UTC().Format(reportTimeFormat)))
+		if err := r.step(action, "backup", home, backup, func() error { return backupDirectory(home, backup) }); err != nil {
+			return err
+		}
+		return r.step(action, "remove", home, backup, func() error { return os.RemoveAll(home) })
+	}
+	return nil
+}
+
+// unlink removes the runtime from the alternatives and the symbolic links to it
+// from the PATH. PATH entries pointing into the runtime can only be reported.
+func (r *remediator) unlink(action *RemediationAction, home string) error {
+	if r.runCommand != nil {
+		out, err := r.runCommand("update-alternatives", "--list", "java")
+		if err == nil {
+			for _, alternative := range strings.Fields(string(out)) {
+				if !withinRoot(home, alternative) {
+					continue
+				}
+				err := r.step(action, "alternatives", alternative, "", func() error {
+					if out, err := r.runCommand("update-alternatives", "--remove", "java", alternative); err != nil {
+						return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, dir := range r.pathDirs {
+		if dir == "" {
+			continue
+		}
+		if withinRoot(home, dir) {
+			r.record(action, "path_entry", dir, "", StepManual, fmt.Errorf("remove %s from PATH", dir))
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Type()&os.ModeSymlink == 0 {
+				continue
+			}
+			link := filepath.Join(dir, entry.Name())
+			target, err := os.Readlink(link)
+			// Links managed by update-alternatives follow the alternatives
+			if err != nil || strings.HasPrefix(target, "/etc/alternatives/") {
+				continue
+			}
+			if resolved, err := filepath.EvalSymlinks(link); err != nil || !withinRoot(home, resolved) {
+				continue
+			}
+			// The log records the target, so the link can be restored
+			if err := r.step(action, "symlink", link, target, func() error { return os.Remove(link) }); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backupDirectory writes a directory to a zip archive, keeping modes and symbolic links
+func backupDirectory(dir, archive string) (err error) {
+	if err := os.MkdirAll(filepath.Dir(archive), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(archive, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(archive)
+		}
+	}()
+
+	zw := zip.NewWriter(file)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(filepath.Dir(dir), path)
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+			_, err = zw.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write([]byte(target))
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(w, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// runRemediate runs jfind remediate, which executes an approved remediation plan
+func runRemediate(args []string) int {
+	fs := flag.NewFlagSet("remediate", flag.ContinueOnError)
+	planFile := fs.String("plan", "", "Path to the approved remediation plan (YAML or JSON)")
+	apply := fs.Bool("apply", false, "Apply the plan, without it the steps are only logged (dry run)")
+	logFile := fs.String("log", "", "Append the action log to this file instead of writing it to stdout")
+	configFile := fs.String("config", "", "Path to a JSON configuration file")
+	fs.Usage = func() {
+		logf("Usage: jfind remediate -plan plan.yaml [-apply] [options]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *planFile == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg := &Config{}
+	if *configFile != "" {
+		var err error
+		if cfg, err = LoadConfig(*configFile); err != nil {
+			logf("Error: %v\n", err)
+			return 2
+		}
+	}
+	// The plan is bound to the identity of the host, a flag must not name another one
+	if err := configureIdentity("", "", cfg); err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	plan, err := loadRemediationPlan(*planFile)
+	if err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	if err := plan.validate(getComputerName(), time.Now()); err != nil {
+		logf("Error: invalid plan: %v\n", err)
+		return 2
+	}
+
+	var log io.Writer = os.Stdout
+	if *logFile != "" {
+		file, err := os.OpenFile(*logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			logf("Error: failed to open action log: %v\n", err)
+			return 2
+		}
+		defer file.Close()
+		log = file
+	}
+
+	if !*apply {
+		logf("Dry run of %d actions, use -apply to change the system\n", len(plan.Actions))
+	}
+	if err := newRemediator(plan, *apply, log).run(); err != nil {
+		logf("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
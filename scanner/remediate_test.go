@@ -0,0 +1,265 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPlanYAML = `# Approved in the change advisory board
+approved_by: jane.doe
+ticket: "CHG-1234"
+host: build-07
+backup_dir: %s
+actions:
+  - action: quarantine   # first
+    path: %s
+  - action: uninstall
+    path: '%s'
+`
+
+// createRemediationHome creates a runtime home with a release file and returns its java executable
+func createRemediationHome(t *testing.T, dir string) string {
+	t.Helper()
+	writeTestFile(t, filepath.Join(dir, "release"), `JAVA_VERSION="1.8.0_202"`+"\n")
+	return createFakeJava(t, dir)
+}
+
+// readRemediationLog parses the JSON lines of an action log
+func readRemediationLog(t *testing.T, log *bytes.Buffer) []RemediationLogEntry {
+	t.Helper()
+	var entries []RemediationLogEntry
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		var entry RemediationLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLoadRemediationPlan(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.yaml")
+	writeTestFile(t, path, strings.ReplaceAll(testPlanYAML, "%s", "/opt/x #1"))
+	plan, err := loadRemediationPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Unlike the quoted path, the plain backup_dir ends at the comment
+	if plan.ApprovedBy != "jane.doe" || plan.Ticket != "CHG-1234" || plan.BackupDir != "/opt/x" {
+		t.Errorf("Unexpected plan %+v", plan)
+	}
+	if len(plan.Actions) != 2 || plan.Actions[0].Action != RemediateQuarantine || plan.Actions[1].Path != "/opt/x #1" {
+		t.Errorf("Unexpected actions %+v", plan.Actions)
+	}
+
+	writeTestFile(t, path, `{"host": "h", "approved_by": "a", "actions": [{"action": "unlink", "path": "/x/bin/java"}]}`)
+	if plan, err := loadRemediationPlan(path); err != nil || plan.Actions[0].Action != RemediateUnlink {
+		t.Errorf("Unexpected JSON plan %+v, %v", plan, err)
+	}
+
+	for _, invalid := range []string{"host build-07", "  path: /x", "actions:\n  path: /x"} {
+		writeTestFile(t, path, invalid)
+		if _, err := loadRemediationPlan(path); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestValidateRemediationPlan(t *testing.T) {
+	java := createRemediationHome(t, filepath.Join(t.TempDir(), "jdk1.8.0_202"))
+	sum, _ := fileSHA256(java)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	valid := func() *RemediationPlan {
+		return &RemediationPlan{Host: "build-07", ApprovedBy: "jane.doe", Expires: "2025-03-01",
+			Actions: []RemediationAction{{Action: RemediateQuarantine, Path: java, SHA256: sum}}}
+	}
+	if err := valid().validate("BUILD-07", now); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	for name, change := range map[string]func(p *RemediationPlan){
+		"unapproved": func(p *RemediationPlan) { p.ApprovedBy = "" },
+		"other host": func(p *RemediationPlan) { p.Host = "db-01" },
+		"expired":    func(p *RemediationPlan) { p.Expires = "2025-02-28" },
+		"no actions": func(p *RemediationPlan) { p.Actions = nil },
+		"action":     func(p *RemediationPlan) { p.Actions[0].Action = "delete" },
+		"no backup":  func(p *RemediationPlan) { p.Actions[0].Action = RemediateUninstall },
+		"checksum":   func(p *RemediationPlan) { p.Actions[0].SHA256 = strings.Repeat("0", 64) },
+		"not java":   func(p *RemediationPlan) { p.Actions[0].Path = filepath.Join(filepath.Dir(java), "javac") },
+		"missing":    func(p *RemediationPlan) { p.Actions[0].Path = filepath.Join(t.TempDir(), "bin", filepath.Base(java)) },
+		"relative":   func(p *RemediationPlan) { p.Actions[0].Path = filepath.Join("bin", filepath.Base(java)) },
+		"top level": func(p *RemediationPlan) {
+			p.Actions[0].Path = filepath.Join(string(filepath.Separator), "bin", filepath.Base(java))
+		},
+		"bad expires": func(p *RemediationPlan) { p.Expires = "soon" },
+	} {
+		plan := valid()
+		change(plan)
+		if err := plan.validate("build-07", now); err == nil {
+			t.Errorf("Expected an error for the %s plan", name)
+		}
+	}
+}
+
+func TestRemediationHome(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix paths")
+	}
+	fsys := fakeFileSystem{}
+	fsys.add("/bin/java", 0755, "")
+	fsys.add("/release", 0644, "")
+	fsys.add("/usr/local/bin/java", 0755, "")
+	fsys.add("/usr/local/release", 0644, "")
+	fsys.add("/home/alice/bin/java", 0755, "")
+	fsys.add("/home/alice/lib/modules", 0644, "")
+	fsys.add("/srv/tools/bin/java", 0755, "")
+	fsys.add("/srv/jdk-21/bin/java", 0755, "")
+	fsys.add("/srv/jdk-21/lib/modules", 0644, "")
+	fsys.add("/srv/jdk1.8.0_202/jre/bin/java", 0755, "")
+	fsys.add("/srv/jdk1.8.0_202/jre/lib/rt.jar", 0644, "")
+	fsys.add("/srv/jdk-17/bin/java", 0755, "")
+	fsys.add("/srv/jdk-17/release", 0644, "")
+
+	for java, want := range map[string]string{
+		"/srv/jdk-21/bin/java":           "/srv/jdk-21",
+		"/srv/jdk1.8.0_202/jre/bin/java": "/srv/jdk1.8.0_202/jre",
+		"/srv/jdk-17/bin/java":           "/srv/jdk-17",
+		"/bin/java":                      "",
+		"/usr/local/bin/java":            "",
+		"/home/alice/bin/java":           "",
+		"/srv/tools/bin/java":            "",
+	} {
+		home, err := remediationHome(fsys, java)
+		if want == "" && err == nil {
+			t.Errorf("Expected %s to be refused, got home %s", java, home)
+		} else if want != "" && (err != nil || home != want) {
+			t.Errorf("Expected the home %s of %s, got %q: %v", want, java, home, err)
+		}
+	}
+
+	// The backup of an uninstalled runtime must survive its removal
+	home := filepath.Join(t.TempDir(), "jdk1.8.0_202")
+	plan := &RemediationPlan{Host: "h", ApprovedBy: "a", BackupDir: filepath.Join(home, "backup"),
+		Actions: []RemediationAction{{Action: RemediateUninstall, Path: createRemediationHome(t, home)}}}
+	if err := plan.validate("h", time.Now()); err == nil {
+		t.Error("Expected a backup_dir inside the runtime to be refused")
+	}
+}
+
+func TestRemediateDryRun(t *testing.T) {
+	home := filepath.Join(t.TempDir(), "jdk1.8.0_202")
+	java := createRemediationHome(t, home)
+	plan := &RemediationPlan{Host: "h", ApprovedBy: "a", BackupDir: t.TempDir(),
+		Actions: []RemediationAction{{Action: RemediateUninstall, Path: java}}}
+
+	var log bytes.Buffer
+	r := newRemediator(plan, false, &log)
+	r.pathDirs = nil
+	r.runCommand = nil
+	if err := r.run(); err != nil {
+		t.Fatal(err)
+	}
+	entries := readRemediationLog(t, &log)
+	if len(entries) != 2 || entries[0].Step != "backup" || entries[1].Step != "remove" {
+		t.Fatalf("Expected the backup and remove steps, got %+v", entries)
+	}
+	for _, entry := range entries {
+		if !entry.DryRun || entry.Result != StepPlanned {
+			t.Errorf("Expected a planned step, got %+v", entry)
+		}
+	}
+	if _, err := os.Stat(java); err != nil {
+		t.Errorf("Expected a dry run to leave the runtime alone: %v", err)
+	}
+}
+
+func TestRemediateApply(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+	dir := t.TempDir()
+	quarantined := filepath.Join(dir, "jdk-11")
+	uninstalled := filepath.Join(dir, "jdk1.8.0_202")
+	plan := &RemediationPlan{Host: "h", ApprovedBy: "a", Ticket: "CHG-1", BackupDir: filepath.Join(dir, "backup"),
+		Actions: []RemediationAction{
+			{Action: RemediateQuarantine, Path: createRemediationHome(t, quarantined)},
+			{Action: RemediateUninstall, Path: createRemediationHome(t, uninstalled)},
+		}}
+
+	pathDir := filepath.Join(dir, "usr-bin")
+	os.MkdirAll(pathDir, 0755)
+	link := filepath.Join(pathDir, "java")
+	if err := os.Symlink(plan.Actions[0].Path, link); err != nil {
+		t.Fatal(err)
+	}
+	os.Symlink("/etc/alternatives/java", filepath.Join(pathDir, "java8"))
+
+	var removed []string
+	var log bytes.Buffer
+	r := newRemediator(plan, true, &log)
+	r.now = func() time.Time { return time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC) }
+	r.pathDirs = []string{pathDir, filepath.Join(uninstalled, "bin")}
+	r.runCommand = func(name string, args ...string) ([]byte, error) {
+		if args[0] == "--list" {
+			return []byte(plan.Actions[1].Path + "\n/usr/lib/jvm/other/bin/java\n"), nil
+		}
+		removed = append(removed, args[2])
+		return nil, nil
+	}
+	if err := r.run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Errorf("Expected the PATH link to the quarantined runtime to be removed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(pathDir, "java8")); err != nil {
+		t.Errorf("Expected the link managed by the alternatives to be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(quarantined+quarantineSuffix, "release")); err != nil {
+		t.Errorf("Expected the runtime to be quarantined: %v", err)
+	}
+	if _, err := os.Stat(uninstalled); !os.IsNotExist(err) {
+		t.Errorf("Expected the runtime to be removed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != plan.Actions[1].Path {
+		t.Errorf("Expected the alternative of the uninstalled runtime to be removed, got %v", removed)
+	}
+
+	backup := filepath.Join(plan.BackupDir, "jdk1.8.0_202-20250301T120000.000Z.zip")
+	archive, err := zip.OpenReader(backup)
+	if err != nil {
+		t.Fatalf("Expected a backup: %v", err)
+	}
+	defer archive.Close()
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	if !strings.Contains(strings.Join(names, " "), "jdk1.8.0_202/bin/java") {
+		t.Errorf("Expected the java executable in the backup, got %v", names)
+	}
+
+	steps := make(map[string]string)
+	for _, entry := range readRemediationLog(t, &log) {
+		if entry.DryRun || entry.Ticket != "CHG-1" {
+			t.Errorf("Unexpected log entry %+v", entry)
+		}
+		steps[entry.Step] = entry.Result
+	}
+	for step, result := range map[string]string{"symlink": StepDone, "rename": StepDone, "alternatives": StepDone,
+		"path_entry": StepManual, "backup": StepDone, "remove": StepDone} {
+		if steps[step] != result {
+			t.Errorf("Expected step %s to be %s, got %q", step, result, steps[step])
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// defaultSSHCommand is the command remote-eval connects with. BatchMode fails
+// instead of prompting for a password, the key or agent must be set up.
+const defaultSSHCommand = "ssh -o BatchMode=yes -o ConnectTimeout=10"
+
+// sshConnectionFailed is the exit code of ssh itself failing, as opposed to the
+// remote command
+const sshConnectionFailed = 255
+
+// defaultRemoteProbeTimeout limits a single evaluation on the remote host, as
+// ConnectTimeout of ssh does not cover a remote command that hangs
+const defaultRemoteProbeTimeout = 2 * time.Minute
+
+// remoteEvaluator runs the evaluation command on another host over SSH
+type remoteEvaluator struct {
+	ssh     []string
+	host    string
+	evalCmd *evalCommand
+
+	// probeTimeout limits each evaluation, 0 is no limit
+	probeTimeout time.Duration
+}
+
+// validSSHDestination rejects destinations ssh would take as an option, such
+// as -oProxyCommand=..., as the destination comes before the -- that ends them
+func validSSHDestination(host string) error {
+	if host == "" || strings.HasPrefix(host, "-") || strings.ContainsFunc(host, unicode.IsSpace) {
+		return fmt.Errorf("invalid SSH destination %q", host)
+	}
+	return nil
+}
+
+// safeShellWord matches arguments the remote shell takes literally
+var safeShellWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes an argument for a POSIX shell. Unlike quoteArg, which is
+// only for display, it quotes everything the shell would expand.
+func shellQuote(arg string) string {
+	if safeShellWord.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// command returns the ssh command line evaluating javaPath. ssh passes the
+// remote command to the login shell of the user, so every argument is quoted.
+func (r *remoteEvaluator) command(javaPath string) (string, []string) {
+	name, args := r.evalCmd.build(javaPath)
+	remote := []string{shellQuote(name)}
+	for _, arg := range args {
+		remote = append(remote, shellQuote(arg))
+	}
+	command := append(append([]string{}, r.ssh[1:]...), r.host, "--", strings.Join(remote, " "))
+	return r.ssh[0], command
+}
+
+// evaluate runs the evaluation command for a java executable on the remote
+// host. ssh is killed when ctx is done or the evaluation takes longer than the
+// probe timeout. An error is returned if ssh could not connect, which would
+// fail the evaluation of every other path as well, and if ctx is done.
+func (r *remoteEvaluator) evaluate(ctx context.Context, javaPath string) (JavaResult, error) {
+	result := JavaResult{Path: javaPath, Evaluated: true}
+	probeCtx, cancel := ctx, context.CancelFunc(func() {})
+	if r.probeTimeout > 0 {
+		probeCtx, cancel = context.WithTimeout(ctx, r.probeTimeout)
+	}
+	defer cancel()
+	name, args := r.command(javaPath)
+	cmd := probeCommand(probeCtx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	result.Error = cmd.Run()
+	if exitError, ok := result.Error.(*exec.ExitError); ok {
+		result.ReturnCode = exitError.ExitCode()
+	}
+	result.StdErr = stderr.String()
+	switch {
+	case ctx.Err() != nil:
+		return result, ctx.Err()
+	case probeCtx.Err() != nil:
+		result.Error = fmt.Errorf("evaluation on %s timed out after %v", r.host, r.probeTimeout)
+		result.Status = ProbeFailed
+	case result.ReturnCode == sshConnectionFailed:
+		return result, fmt.Errorf("failed to connect to %s: %s", r.host, strings.TrimSpace(result.StdErr))
+	case result.Error != nil && result.ReturnCode == 0:
+		// ssh itself could not be started
+		return result, result.Error
+	case strings.Contains(result.StdErr, fatalErrorMarker):
+		result.Status = ProbeCrashed
+	case result.Error == nil:
+		result.Status = ProbeOK
+		result.Properties = ParseJavaProperties(result.StdErr)
+	default:
+		result.Status = ProbeFailed
+	}
+	return result, nil
+}
+
+// readCandidates reads the java executables to evaluate from a file with one
+// path per line or from a jfind JSON report, "-" reads standard input
+func readCandidates(file string) ([]string, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read candidates: %v", err)
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var report JSONOutput
+		if err := json.Unmarshal(trimmed, &report); err != nil {
+			return nil, fmt.Errorf("failed to parse report %s: %v", file, err)
+		}
+		var paths []string
+		for _, runtime := range report.Runtimes {
+			paths = append(paths, runtime.JavaExecutable)
+		}
+		return paths, nil
+	}
+
+	var paths []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			paths = append(paths, line)
+		}
+	}
+	return paths, scanner.Err()
+}
+
+// runRemoteEval implements the remote-eval command, which evaluates known java
+// executables on another host over SSH without walking its file system. It
+// returns 0 on success, 1 on failure and 2 on usage errors.
+func runRemoteEval(args []string) int {
+	fs := flag.NewFlagSet("remote-eval", flag.ContinueOnError)
+	host := fs.String("host", "", "SSH destination to evaluate the runtimes on, e.g. admin@appliance01")
+	sshCommand := fs.String("ssh", defaultSSHCommand, "SSH command and options")
+	evalCmd := fs.String("eval-cmd", "", "Command template to evaluate java executables on the remote host (default \""+defaultEvalCommand+"\")")
+	var labels labelFlag
+	fs.Var(&labels, "label", "Group label of the remote host in the report as key=value (repeatable)")
+	hostname := fs.String("hostname", "", "Report this computer name instead of the host name of -host")
+	jsonOutput := fs.Bool("json", false, "Output results in JSON format")
+	doPost := fs.Bool("post", false, "Post JSON output to server (implies --json)")
+	postURL := fs.String("url", defaultPostURL, "URL to post JSON output to (only used with --post)")
+	configFile := fs.String("config", "", "Path to a JSON configuration file")
+	timeout := fs.Duration("timeout", 0, "Stop after this long, e.g. 30m, and report the runtimes evaluated so far with timed_out (0 is no limit)")
+	probeTimeout := fs.Duration("probe-timeout", defaultRemoteProbeTimeout, "Limit of a single evaluation on the remote host (0 is no limit)")
+	recordFile := fs.String("record", "", "Record the HTTP interactions with the server to a transport log (only used with --post)")
+	replayFile := fs.String("replay", "", "Replay the server responses from a transport log instead of connecting (only used with --post)")
+	fs.Usage = func() {
+		logf("Usage: jfind remote-eval -host <destination> [options] <candidates file or report.json>...\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *host == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := validSSHDestination(*host); err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+
+	cfg := &Config{}
+	if *configFile != "" {
+		var err error
+		if cfg, err = LoadConfig(*configFile); err != nil {
+			logf("Error: %v\n", err)
+			return 2
+		}
+	}
+	ssh, err := splitCommandLine(*sshCommand)
+	if err != nil || len(ssh) == 0 {
+		logf("Error: invalid -ssh command %q\n", *sshCommand)
+		return 2
+	}
+	if *evalCmd == "" {
+		*evalCmd = cfg.EvalCommand
+	}
+	if *evalCmd == "" {
+		*evalCmd = defaultEvalCommand
+	}
+	command, err := parseEvalCommand(*evalCmd)
+	if err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	evaluator := &remoteEvaluator{ssh: ssh, host: *host, evalCmd: command, probeTimeout: *probeTimeout}
+
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, file := range fs.Args() {
+		paths, err := readCandidates(file)
+		if err != nil {
+			logf("Error: %v\n", err)
+			return 1
+		}
+		for _, path := range paths {
+			if !seen[path] {
+				seen[path] = true
+				candidates = append(candidates, path)
+			}
+		}
+	}
+
+	// The runtimes belong to the remote host, by the host name of the destination
+	remoteHost := *host
+	if _, name, ok := strings.Cut(remoteHost, "@"); ok {
+		remoteHost = name
+	}
+	if *hostname == "" {
+		*hostname = remoteHost
+	}
+	if err := configureIdentity(*hostname, "", cfg); err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+	// Tags of the local cloud instance would not describe the remote host
+	if err := configureLabels(labels, false, cfg); err != nil {
+		logf("Error: %v\n", err)
+		return 2
+	}
+
+	startTime := time.Now()
+	finder := NewJavaFinder("/", -1, false, true)
+	finder.classifier = newPathClassifier(cfg.PathRules)
+	finder.discovery = "candidates"
+	finder.evalMode = EvalModeRemote
+	finder.remoteHost = remoteHost
+	logf("Evaluating %d java executables on %s\n", len(candidates), *host)
+
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if *timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+	}
+	defer cancel()
+	var results []*JavaResult
+	for _, path := range candidates {
+		result, err := evaluator.evaluate(ctx, path)
+		if ctx.Err() != nil {
+			finder.timedOut = true
+			logf("Warning: remote-eval timed out after %v, %d of %d java executables evaluated\n", *timeout, len(results), len(candidates))
+			break
+		}
+		if err != nil {
+			logf("Error: %v\n", err)
+			return 1
+		}
+		result.PathClass = finder.classifier.classify(path)
+		result.Confidence, result.Evidence = scoreConfidence(&result)
+		results = append(results, &result)
+	}
+
+	if !*jsonOutput && !*doPost {
+		for _, result := range results {
+			printResult(result, nil, finder.sys.Clock.Now())
+			printf("\n")
+		}
+		return 0
+	}
+	var client *http.Client
+	if *doPost {
+		var closeLog func() error
+		if client, closeLog, err = newHTTPClient(*recordFile, *replayFile); err != nil {
+			logf("Error: %v\n", err)
+			return 1
+		}
+		defer closeLog()
+	}
+	if err := reportJSON(buildJSONOutput(results, finder, startTime), client, *postURL, 0); err != nil {
+		logf("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
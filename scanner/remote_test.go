@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestShellQuote(t *testing.T) {
+	for arg, want := range map[string]string{
+		"/opt/jdk-17/bin/java":         "/opt/jdk-17/bin/java",
+		"-XshowSettings:properties":    "-XshowSettings:properties",
+		"/opt/my jdk/bin/java":         "'/opt/my jdk/bin/java'",
+		"/opt/$(reboot)/bin/java":      "'/opt/$(reboot)/bin/java'",
+		"/opt/it's;here/bin/java":      `'/opt/it'\''s;here/bin/java'`,
+		"/opt/jdk*/bin/java":           "'/opt/jdk*/bin/java'",
+		"/opt/jdk-17/bin/java\nreboot": "'/opt/jdk-17/bin/java\nreboot'",
+	} {
+		if got := shellQuote(arg); got != want {
+			t.Errorf("Expected %s for %q, got %s", want, arg, got)
+		}
+	}
+}
+
+func TestRemoteEvaluate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ssh is a shell script")
+	}
+	dir := t.TempDir()
+	// The fake ssh runs the remote command with the local shell like sshd would
+	ssh := filepath.Join(dir, "ssh")
+	writeTestFile(t, ssh, "#!/bin/sh\nwhile [ \"$1\" != -- ]; do shift; done\nshift\nexec sh -c \"$1\"\n")
+	java := filepath.Join(dir, "jdk 17", "bin", "java")
+	writeTestFile(t, java, "#!/bin/sh\necho '    java.version = 17.0.9' >&2\necho '    java.vendor = Eclipse Adoptium' >&2\n")
+
+	command, _ := parseEvalCommand(defaultEvalCommand)
+	evaluator := &remoteEvaluator{ssh: []string{ssh, "-o", "BatchMode=yes"}, host: "admin@appliance", evalCmd: command}
+	name, args := evaluator.command(java)
+	if name != ssh || !slices.Equal(args[:4], []string{"-o", "BatchMode=yes", "admin@appliance", "--"}) {
+		t.Errorf("Expected the options and destination before the remote command, got %s %v", name, args)
+	}
+
+	result, err := evaluator.evaluate(context.Background(), java)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != ProbeOK || result.Properties == nil || result.Properties.Version != "17.0.9" {
+		t.Errorf("Expected the remote runtime to be evaluated, got %+v", result)
+	}
+	result, err = evaluator.evaluate(context.Background(), filepath.Join(dir, "missing", "bin", "java"))
+	if err != nil || result.Status != ProbeFailed {
+		t.Errorf("Expected a missing runtime to fail its probe only, got %+v (%v)", result, err)
+	}
+
+	writeTestFile(t, ssh, "#!/bin/sh\necho 'ssh: connect to host appliance port 22: Connection refused' >&2\nexit 255\n")
+	if _, err := evaluator.evaluate(context.Background(), java); err == nil {
+		t.Error("Expected an error if ssh cannot connect")
+	}
+}
+
+func TestReadCandidates(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "candidates.txt")
+	writeTestFile(t, list, "# exported from the index\n/opt/jdk-17/bin/java\n\n/usr/lib/jvm/java-11/bin/java\n")
+	report := filepath.Join(dir, "report.json")
+	writeTestFile(t, report, `{"meta": {}, "result": [{"java_executable": "/opt/jdk-21/bin/java"}]}`)
+
+	if paths, err := readCandidates(list); err != nil || !slices.Equal(paths, []string{"/opt/jdk-17/bin/java", "/usr/lib/jvm/java-11/bin/java"}) {
+		t.Errorf("Expected the paths of the list, got %v (%v)", paths, err)
+	}
+	if paths, err := readCandidates(report); err != nil || !slices.Equal(paths, []string{"/opt/jdk-21/bin/java"}) {
+		t.Errorf("Expected the runtimes of the report, got %v (%v)", paths, err)
+	}
+}
+
+func TestRemoteEvaluateTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ssh is a shell script")
+	}
+	dir := t.TempDir()
+	ssh := filepath.Join(dir, "ssh")
+	writeTestFile(t, ssh, "#!/bin/sh\nexec sleep 30\n")
+	command, _ := parseEvalCommand(defaultEvalCommand)
+	evaluator := &remoteEvaluator{ssh: []string{ssh}, host: "appliance", evalCmd: command, probeTimeout: 100 * time.Millisecond}
+
+	// A hanging evaluation fails only its own runtime
+	start := time.Now()
+	result, err := evaluator.evaluate(context.Background(), "/opt/jdk-17/bin/java")
+	if err != nil || result.Status != ProbeFailed || result.Error == nil {
+		t.Errorf("Expected the evaluation to time out, got %+v (%v)", result, err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected ssh to be killed at the probe timeout, took %v", elapsed)
+	}
+
+	// The end of the whole run stops the evaluation with an error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := evaluator.evaluate(ctx, "/opt/jdk-17/bin/java"); err == nil {
+		t.Error("Expected an error when the context is done")
+	}
+}
+
+func TestRemoteEvalRejectsOptionDestination(t *testing.T) {
+	for _, host := range []string{"-oProxyCommand=touch /tmp/pwned", "admin@appliance01 -v", ""} {
+		if err := validSSHDestination(host); err == nil {
+			t.Errorf("Expected %q to be rejected", host)
+		}
+	}
+	if err := validSSHDestination("admin@appliance01"); err != nil {
+		t.Error(err)
+	}
+	if code := runRemoteEval([]string{"-host", "-oProxyCommand=id", "candidates.txt"}); code != 2 {
+		t.Errorf("Expected a usage error for an option as destination, got %d", code)
+	}
+}
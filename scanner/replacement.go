@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reasons a replacement is recommended for a runtime
+const (
+	ReplaceOracle = "oracle" // Oracle runtime, replaced by an OpenJDK build of the same version
+	ReplaceEOL    = "eol"    // end of life runtime, replaced by a supported LTS version
+)
+
+// ReplacementRule maps runtimes to a drop-in replacement. Version and URL may
+// contain {major} and {update}, which expand to the version of the runtime.
+type ReplacementRule struct {
+	// Reason is oracle or eol, empty for both
+	Reason string `json:"reason,omitempty"`
+
+	// Majors are the major versions the rule applies to, empty for all
+	Majors []int `json:"majors,omitempty"`
+
+	Vendor  string `json:"vendor"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+// Replacement is the recommended replacement of a runtime
+type Replacement struct {
+	Vendor  string `json:"vendor"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+	Reason  string `json:"reason"`
+}
+
+// defaultReplacementRules replace Oracle runtimes by Temurin of the same update
+// level and end of life runtimes by the next supported LTS version
+var defaultReplacementRules = []ReplacementRule{
+	{Reason: ReplaceOracle, Majors: []int{8}, Vendor: "Eclipse Temurin", Version: "8u{update}", URL: "https://adoptium.net/temurin/archive/?version=8"},
+	{Reason: ReplaceOracle, Majors: []int{11, 17, 21, 25, 26}, Vendor: "Eclipse Temurin", Version: "{major}.0.{update}", URL: "https://adoptium.net/temurin/archive/?version={major}"},
+	{Reason: ReplaceOracle, Majors: []int{7}, Vendor: "Azul Zulu", Version: "7u{update}", URL: "https://www.azul.com/downloads/?version=java-7-lts&package=jdk"},
+	{Majors: []int{9, 10}, Vendor: "Amazon Corretto", Version: "11", URL: "https://docs.aws.amazon.com/corretto/latest/corretto-11-ug/downloads-list.html"},
+	{Majors: []int{12, 13, 14, 15, 16}, Vendor: "Amazon Corretto", Version: "17", URL: "https://docs.aws.amazon.com/corretto/latest/corretto-17-ug/downloads-list.html"},
+	{Majors: []int{18, 19, 20}, Vendor: "Eclipse Temurin", Version: "21", URL: "https://adoptium.net/temurin/releases/?version=21"},
+	{Majors: []int{8, 11, 17, 21, 22, 23, 24}, Vendor: "Eclipse Temurin", Version: "25", URL: "https://adoptium.net/temurin/releases/?version=25"},
+}
+
+// loadReplacementRules reads the replacement rules of a JSON file, which are
+// tried before the built-in rules. An empty path yields the built-in rules.
+func loadReplacementRules(path string) ([]ReplacementRule, error) {
+	if path == "" {
+		return defaultReplacementRules, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replacements %s: %v", path, err)
+	}
+	var rules []ReplacementRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse replacements %s: %v", path, err)
+	}
+	for i, rule := range rules {
+		if rule.Reason != "" && rule.Reason != ReplaceOracle && rule.Reason != ReplaceEOL {
+			return nil, fmt.Errorf("invalid reason %q in replacement %d of %s", rule.Reason, i+1, path)
+		}
+		if rule.Vendor == "" || rule.Version == "" {
+			return nil, fmt.Errorf("replacement %d of %s requires vendor and version", i+1, path)
+		}
+	}
+	return append(rules, defaultReplacementRules...), nil
+}
+
+// matches reports whether the rule applies to a runtime replaced for the given reason
+func (r *ReplacementRule) matches(reason string, major int) bool {
+	if r.Reason != "" && r.Reason != reason {
+		return false
+	}
+	return len(r.Majors) == 0 || slices.Contains(r.Majors, major)
+}
+
+// recommendReplacement returns the replacement of the first matching rule for an
+// Oracle or end of life runtime, nil for any other runtime or if no rule matches
+func recommendReplacement(rules []ReplacementRule, result *JavaResult, now time.Time) *Replacement {
+	version, vendor := runtimeVersion(result)
+	if version == "" {
+		return nil
+	}
+	major, update := parseJavaVersion(version)
+
+	var reasons []string
+	if strings.Contains(vendor, "Oracle") {
+		reasons = append(reasons, ReplaceOracle)
+	}
+	if date, ok := eolDates[major]; ok {
+		if eol, err := time.Parse(time.DateOnly, date); err == nil && now.After(eol) {
+			reasons = append(reasons, ReplaceEOL)
+		}
+	}
+
+	expand := strings.NewReplacer("{major}", strconv.Itoa(major), "{update}", strconv.Itoa(update))
+	for _, reason := range reasons {
+		for _, rule := range rules {
+			if rule.matches(reason, major) {
+				return &Replacement{
+					Vendor:  rule.Vendor,
+					Version: expand.Replace(rule.Version),
+					URL:     expand.Replace(rule.URL),
+					Reason:  reason,
+				}
+			}
+		}
+	}
+	return nil
+}
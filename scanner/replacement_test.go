@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecommendReplacement(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		version string
+		vendor  string
+		want    string
+		reason  string
+	}{
+		{"1.8.0_202", "Oracle Corporation", "Eclipse Temurin 8u202", ReplaceOracle},
+		{"17.0.13", "Oracle Corporation", "Eclipse Temurin 17.0.13", ReplaceOracle},
+		{"14.0.2", "Oracle Corporation", "Amazon Corretto 17", ReplaceOracle},
+		{"14.0.2", "AdoptOpenJDK", "Amazon Corretto 17", ReplaceEOL},
+		{"22.0.1", "Eclipse Adoptium", "Eclipse Temurin 25", ReplaceEOL},
+		{"17.0.9", "Eclipse Adoptium", "", ""},
+		{"", "", "", ""},
+	}
+	for _, tt := range tests {
+		result := &JavaResult{Properties: &JavaProperties{Version: tt.version, Vendor: tt.vendor}}
+		got := recommendReplacement(defaultReplacementRules, result, now)
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("Expected no replacement for %s %s, got %+v", tt.vendor, tt.version, got)
+			}
+			continue
+		}
+		if got == nil || got.Vendor+" "+got.Version != tt.want || got.Reason != tt.reason || got.URL == "" {
+			t.Errorf("Expected %s (%s) for %s %s, got %+v", tt.want, tt.reason, tt.vendor, tt.version, got)
+		}
+	}
+}
+
+func TestLoadReplacementRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replacements.json")
+	writeTestFile(t, path, `[{"reason": "oracle", "majors": [8], "vendor": "Amazon Corretto", "version": "8.{update}",
+		"url": "https://corretto.aws/downloads/latest/amazon-corretto-{major}-x64-linux-jdk.tar.gz"}]`)
+	rules, err := loadReplacementRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != len(defaultReplacementRules)+1 {
+		t.Errorf("Expected the rules of the file before the built-in ones, got %d rules", len(rules))
+	}
+
+	result := &JavaResult{Properties: &JavaProperties{Version: "1.8.0_381", Vendor: "Oracle Corporation"}}
+	got := recommendReplacement(rules, result, time.Now())
+	if got == nil || got.Version != "8.381" || got.URL != "https://corretto.aws/downloads/latest/amazon-corretto-8-x64-linux-jdk.tar.gz" {
+		t.Errorf("Expected the overridden replacement, got %+v", got)
+	}
+	result.Properties.Version = "11.0.20"
+	if got := recommendReplacement(rules, result, time.Now()); got == nil || got.Vendor != "Eclipse Temurin" {
+		t.Errorf("Expected the built-in replacement for 11, got %+v", got)
+	}
+
+	for _, invalid := range []string{
+		`{"vendor": "x"}`,
+		`[{"reason": "cost", "vendor": "x", "version": "1"}]`,
+		`[{"vendor": "x"}]`,
+	} {
+		writeTestFile(t, path, invalid)
+		if _, err := loadReplacementRules(path); err == nil {
+			t.Errorf("Expected an error for %s", invalid)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ResourceUsage reports the footprint of a scan, for checking that jfind stays
+// within what was agreed for shared hosts
+type ResourceUsage struct {
+	PeakRSSBytes int64  `json:"peak_rss_bytes,omitempty"` // highest resident memory of jfind so far
+	CPUTime      string `json:"cpu_time"`                 // user and system time of jfind and its probes
+	BytesRead    int64  `json:"bytes_read,omitempty"`     // bytes read by jfind, where the OS counts them
+	Processes    int64  `json:"processes"`                // java probes started
+}
+
+// processUsage is what the operating system counts for the jfind process
+type processUsage struct {
+	peakRSS   int64
+	cpuTime   time.Duration
+	bytesRead int64
+}
+
+// usageMeter measures the resources used since the start of a scan
+type usageMeter struct {
+	start     processUsage
+	processes atomic.Int64
+}
+
+// startUsage starts measuring the resources used by a scan
+func startUsage() *usageMeter {
+	return &usageMeter{start: readProcessUsage()}
+}
+
+// report returns the resources used since the scan started. Peak memory is
+// that of the process, it is not reset between scans of an agent.
+func (m *usageMeter) report() *ResourceUsage {
+	if m == nil {
+		return nil
+	}
+	now := readProcessUsage()
+	return &ResourceUsage{
+		PeakRSSBytes: now.peakRSS,
+		CPUTime:      formatDurationISO8601(now.cpuTime - m.start.cpuTime),
+		BytesRead:    now.bytesRead - m.start.bytesRead,
+		Processes:    m.processes.Load(),
+	}
+}
+
+// runProbe runs a probe of a java executable and counts it
+func (f *JavaFinder) runProbe(cmd *exec.Cmd) error {
+	if f.usage != nil {
+		f.usage.processes.Add(1)
+	}
+	return f.sys.Exec.Run(cmd)
+}
+
+// procSelfBytesRead returns the bytes read by the process from the rchar
+// counter of /proc/self/io on Linux, 0 if it is not available
+func procSelfBytesRead(path string) int64 {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "rchar:"); ok {
+			n, _ := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			return n
+		}
+	}
+	return 0
+}
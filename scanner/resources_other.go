@@ -0,0 +1,28 @@
+//go:build !windows
+
+package main
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// readProcessUsage reads the resource usage of jfind and its probes that have exited
+func readProcessUsage() processUsage {
+	var usage processUsage
+	var self, children syscall.Rusage
+	if syscall.Getrusage(syscall.RUSAGE_SELF, &self) == nil {
+		usage.peakRSS = int64(self.Maxrss)
+		if runtime.GOOS != "darwin" {
+			// Linux and the BSDs report kilobytes, macOS bytes
+			usage.peakRSS *= 1024
+		}
+		usage.cpuTime = time.Duration(self.Utime.Nano() + self.Stime.Nano())
+	}
+	if syscall.Getrusage(syscall.RUSAGE_CHILDREN, &children) == nil {
+		usage.cpuTime += time.Duration(children.Utime.Nano() + children.Stime.Nano())
+	}
+	usage.bytesRead = procSelfBytesRead("/proc/self/io")
+	return usage
+}
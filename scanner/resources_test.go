@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResourceUsage(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	fsys := fakeFileSystem{}
+	for _, jdk := range []string{"jdk-17", "jdk-21"} {
+		fsys.add(filepath.Join(root, jdk, "bin", javaExecutableName()), 0755, "")
+	}
+	finder := NewJavaFinder(root, -1, false, true)
+	finder.SetSystem(System{FS: fsys, Exec: fakeExecutor{}})
+	finder.workers = 1
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usage := buildJSONOutput(results, finder, time.Now()).Meta.ResourceUsage
+	if usage == nil {
+		t.Fatal("Expected the resource usage of the scan")
+	}
+	if usage.Processes != 2 {
+		t.Errorf("Expected a probe per runtime, got %d", usage.Processes)
+	}
+	if _, err := parseDurationISO8601(usage.CPUTime); err != nil {
+		t.Errorf("Expected an ISO 8601 CPU time, got %q: %v", usage.CPUTime, err)
+	}
+	if usage.PeakRSSBytes <= 0 || usage.BytesRead < 0 {
+		t.Errorf("Expected the peak memory of the process, got %+v", usage)
+	}
+}
+
+func TestProcSelfBytesRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "io")
+	writeTestFile(t, path, "rchar: 123456\nwchar: 789\nsyscr: 12\n")
+	if n := procSelfBytesRead(path); n != 123456 {
+		t.Errorf("Expected rchar, got %d", n)
+	}
+	if n := procSelfBytesRead(filepath.Join(os.TempDir(), "missing-io")); n != 0 {
+		t.Errorf("Expected 0 without the file, got %d", n)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Defaults of the retention of jfind serve -report-dir
+const (
+	defaultRetentionMaxBytes    = "64M"
+	defaultRetentionKeepReports = 1
+)
+
+// reportFilePrefix and reportTimeFormat name the report files, so that the
+// names sort in the order the reports were written
+const (
+	reportFilePrefix = "jfind-"
+	reportTimeFormat = "20060102T150405.000Z"
+)
+
+// Retention limits the disk space used by the files jfind serve writes
+type Retention struct {
+	// MaxBytes is the budget of all report files, e.g. 64M
+	MaxBytes string `json:"max_bytes,omitempty"`
+
+	// MinFree is the free space that must remain on the file system, e.g.
+	// 512M, no minimum if empty
+	MinFree string `json:"min_free,omitempty"`
+
+	// MaxReports limits the number of report files, 0 for no limit
+	MaxReports int `json:"max_reports,omitempty"`
+
+	// KeepReports is the number of newest reports that are not deleted to
+	// leave MinFree free, at least 1
+	KeepReports int `json:"keep_reports,omitempty"`
+
+	maxBytes int64
+	minFree  int64
+}
+
+// parse validates the retention and fills in the defaults
+func (r *Retention) parse() error {
+	if r.MaxBytes == "" {
+		r.MaxBytes = defaultRetentionMaxBytes
+	}
+	var err error
+	if r.maxBytes, err = parseByteSize(r.MaxBytes); err != nil {
+		return fmt.Errorf("invalid max_bytes of retention: %v", err)
+	}
+	if r.MinFree != "" {
+		if r.minFree, err = parseByteSize(r.MinFree); err != nil {
+			return fmt.Errorf("invalid min_free of retention: %v", err)
+		}
+	}
+	if r.MaxReports < 0 {
+		return fmt.Errorf("invalid max_reports %d of retention", r.MaxReports)
+	}
+	if r.KeepReports < 0 {
+		return fmt.Errorf("invalid keep_reports %d of retention", r.KeepReports)
+	}
+	if r.KeepReports == 0 {
+		r.KeepReports = defaultRetentionKeepReports
+	}
+	return nil
+}
+
+// reportStore keeps the reports of jfind serve as files in a directory,
+// pruning the oldest ones to stay within the retention
+type reportStore struct {
+	dir       string
+	retention Retention
+	freeSpace func(path string) (int64, error)
+
+	// key seals the reports if set, see sealReport
+	key []byte
+}
+
+// newReportStore creates the report directory if needed
+func newReportStore(dir string, retention Retention) (*reportStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create report directory: %v", err)
+	}
+	return &reportStore{dir: dir, retention: retention, freeSpace: diskFree}, nil
+}
+
+// storedReport is a report file in the report directory
+type storedReport struct {
+	path string
+	size int64
+}
+
+// list returns the report files, oldest first
+func (s *reportStore) list() ([]storedReport, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report directory: %v", err)
+	}
+	var reports []storedReport
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasPrefix(name, reportFilePrefix) ||
+			!strings.HasSuffix(name, ".json") && !strings.HasSuffix(name, ".json"+sealedReportExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		reports = append(reports, storedReport{path: filepath.Join(s.dir, name), size: info.Size()})
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].path < reports[j].path })
+	return reports, nil
+}
+
+// write stores a report taken at the given time and returns its path. Old
+// reports are pruned first; if the report does not fit even then, it is not
+// written at all.
+func (s *reportStore) write(report *JSONOutput, now time.Time) (string, error) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	name := reportFilePrefix + now.UTC().Format(reportTimeFormat) + ".json"
+	if s.key != nil {
+		if data, err = sealReport(data, s.key); err != nil {
+			return "", fmt.Errorf("failed to seal report: %v", err)
+		}
+		name += sealedReportExt
+	}
+	if err := s.prune(int64(len(data))); err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.dir, name)
+	tmp, err := os.CreateTemp(s.dir, ".report-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write report: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write report: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write report: %v", err)
+	}
+	return path, os.Rename(tmp.Name(), path)
+}
+
+// prune removes the oldest reports until a new report of the given size fits
+// into the budget and leaves the minimum free space on the file system. Only
+// as many reports as needed are removed, and none at all if the new report does
+// not fit even then. The KeepReports newest reports are not removed to free
+// space for min_free, only to stay within max_bytes and max_reports.
+func (s *reportStore) prune(incoming int64) error {
+	reports, err := s.list()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, report := range reports {
+		total += report.size
+	}
+	free, err := s.freeSpace(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to check free space: %v", err)
+	}
+
+	r := s.retention
+	n := 0 // number of oldest reports to remove
+	if r.MaxReports > 0 && len(reports) >= r.MaxReports {
+		n = len(reports) - r.MaxReports + 1
+	}
+	var removed int64
+	for _, report := range reports[:n] {
+		removed += report.size
+	}
+	for n < len(reports) && total-removed+incoming > r.maxBytes {
+		removed += reports[n].size
+		n++
+	}
+	// Space used by other files is no reason to give up the newest reports
+	for n < len(reports)-r.KeepReports && free+removed-incoming < r.minFree {
+		removed += reports[n].size
+		n++
+	}
+
+	if total-removed+incoming > r.maxBytes {
+		return fmt.Errorf("report of %d bytes exceeds the retention budget of %d bytes", incoming, r.maxBytes)
+	}
+	if free+removed-incoming < r.minFree {
+		return fmt.Errorf("only %d bytes free in %s, keeping at least %d", free, s.dir, r.minFree)
+	}
+	for _, report := range reports[:n] {
+		if err := os.Remove(report.path); err != nil {
+			return fmt.Errorf("failed to prune report: %v", err)
+		}
+	}
+	return nil
+}
+
+// checkFreeSpace checks that writing size bytes to dir leaves minFree bytes free
+func checkFreeSpace(dir string, size, minFree int64) error {
+	free, err := diskFree(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check free space: %v", err)
+	}
+	if free-size < minFree {
+		return fmt.Errorf("only %d bytes free in %s, keeping at least %d", free, dir, minFree)
+	}
+	return nil
+}
+
+// saveHistoryGuarded saves the history unless that leaves less than minFree bytes
+// free. The new file is written next to the old one before replacing it, so the
+// size of the old file is the estimate of the space needed.
+func saveHistoryGuarded(history *runtimeHistory, minFree int64) error {
+	var size int64
+	if info, err := os.Stat(history.path); err == nil {
+		size = info.Size()
+	}
+	if err := checkFreeSpace(filepath.Dir(history.path), size, minFree); err != nil {
+		return fmt.Errorf("history not saved: %v", err)
+	}
+	return history.save()
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestReportStore(t *testing.T, retention Retention, free int64) *reportStore {
+	t.Helper()
+	if err := retention.parse(); err != nil {
+		t.Fatal(err)
+	}
+	store, err := newReportStore(filepath.Join(t.TempDir(), "reports"), retention)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.freeSpace = func(string) (int64, error) { return free, nil }
+	return store
+}
+
+func reportNames(t *testing.T, store *reportStore) []string {
+	t.Helper()
+	reports, err := store.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, report := range reports {
+		names = append(names, filepath.Base(report.path))
+	}
+	return names
+}
+
+func TestReportStorePrunesOldest(t *testing.T) {
+	store := newTestReportStore(t, Retention{MaxReports: 2}, 1<<40)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if _, err := store.write(&JSONOutput{}, start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := reportNames(t, store)
+	want := []string{"jfind-20240301T130000.000Z.json", "jfind-20240301T140000.000Z.json"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, names)
+	}
+
+	// Files not written by jfind are left alone
+	other := filepath.Join(store.dir, "notes.txt")
+	writeTestFile(t, other, "keep")
+	if _, err := store.write(&JSONOutput{}, start.Add(3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("Expected %s to be kept: %v", other, err)
+	}
+}
+
+func TestReportStoreBudget(t *testing.T) {
+	store := newTestReportStore(t, Retention{MaxBytes: "1K"}, 1<<40)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	report := &JSONOutput{Meta: MetaInfo{ComputerName: strings.Repeat("h", 300)}}
+	for i := 0; i < 5; i++ {
+		if _, err := store.write(report, start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reports, _ := store.list()
+	var total int64
+	for _, r := range reports {
+		total += r.size
+	}
+	if total > 1024 || len(reports) == 0 {
+		t.Errorf("Expected the reports to fit into 1K, got %d files with %d bytes", len(reports), total)
+	}
+
+	huge := &JSONOutput{Meta: MetaInfo{ComputerName: strings.Repeat("h", 2048)}}
+	if _, err := store.write(huge, start.Add(time.Hour)); err == nil {
+		t.Error("Expected an error for a report exceeding the budget")
+	}
+}
+
+func TestReportStoreMinFree(t *testing.T) {
+	store := newTestReportStore(t, Retention{MinFree: "1M"}, 1<<20+100)
+	if _, err := store.write(&JSONOutput{Meta: MetaInfo{ComputerName: strings.Repeat("h", 200)}}, time.Now()); err == nil {
+		t.Error("Expected an error when the report would use the minimum free space")
+	}
+	if names := reportNames(t, store); len(names) != 0 {
+		t.Errorf("Expected no report to be written, got %v", names)
+	}
+}
+
+func TestRetentionParse(t *testing.T) {
+	r := Retention{}
+	if err := r.parse(); err != nil {
+		t.Fatal(err)
+	}
+	if r.maxBytes != 64<<20 || r.minFree != 0 || r.KeepReports != 1 {
+		t.Errorf("Unexpected defaults %d, %d, %d", r.maxBytes, r.minFree, r.KeepReports)
+	}
+	for _, invalid := range []Retention{{MaxBytes: "lots"}, {MinFree: "-1"}, {MaxReports: -1}, {KeepReports: -1}} {
+		if err := invalid.parse(); err == nil {
+			t.Errorf("Expected an error for %+v", invalid)
+		}
+	}
+}
+
+func TestReportStorePrunesOnlyWhatIsNeeded(t *testing.T) {
+	store := newTestReportStore(t, Retention{MaxBytes: "4K"}, 1<<40)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	report := &JSONOutput{Meta: MetaInfo{ComputerName: strings.Repeat("h", 700)}}
+	for i := 0; i < 6; i++ {
+		if _, err := store.write(report, start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reports, _ := store.list()
+	var total, size int64
+	for _, r := range reports {
+		total += r.size
+		size = r.size
+	}
+	// One more report would not fit, so no more than needed were removed
+	if total > 4096 || total+size <= 4096 {
+		t.Errorf("Expected only as many reports removed as needed, got %d files with %d bytes", len(reports), total)
+	}
+
+	// A report that does not fit at all removes nothing
+	before := reportNames(t, store)
+	huge := &JSONOutput{Meta: MetaInfo{ComputerName: strings.Repeat("h", 5000)}}
+	if _, err := store.write(huge, start.Add(time.Hour)); err == nil {
+		t.Error("Expected an error for a report exceeding the budget")
+	}
+	if after := reportNames(t, store); strings.Join(after, ",") != strings.Join(before, ",") {
+		t.Errorf("Expected no report to be removed, got %v instead of %v", after, before)
+	}
+}
+
+func TestReportStoreMinFreeKeepsReports(t *testing.T) {
+	store := newTestReportStore(t, Retention{KeepReports: 2}, 1<<40)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		if _, err := store.write(&JSONOutput{}, start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without min_free a full disk does not prune
+	store.freeSpace = func(string) (int64, error) { return 1 << 10, nil }
+	if _, err := store.write(&JSONOutput{}, start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if names := reportNames(t, store); len(names) != 5 {
+		t.Errorf("Expected no report to be pruned, got %v", names)
+	}
+
+	// With min_free, the reports that cannot free enough space are kept and
+	// the newest ones are never removed for it
+	store.retention.MinFree = "1M"
+	if err := store.retention.parse(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.write(&JSONOutput{}, start.Add(2*time.Hour)); err == nil {
+		t.Error("Expected an error when the minimum free space cannot be reached")
+	}
+	if names := reportNames(t, store); len(names) != 5 {
+		t.Errorf("Expected the reports to be kept, got %v", names)
+	}
+	store.freeSpace = func(string) (int64, error) { return 1<<20 - 1, nil }
+	if _, err := store.write(&JSONOutput{}, start.Add(3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	names := reportNames(t, store)
+	if len(names) < 3 || names[len(names)-2] != "jfind-20240301T130000.000Z.json" {
+		t.Errorf("Expected the newest reports to be kept, got %v", names)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RootStats are the counters of one start path of a scan of several
+type RootStats struct {
+	Path        string `json:"path"`
+	MaxDepth    *int   `json:"max_depth,omitempty"` // if given with the start path
+	ScannedDirs int    `json:"scanned_dirs"`
+	CountResult int    `json:"count_result"`
+}
+
+// pathList collects the start paths of repeated or comma separated -path flags
+type pathList []string
+
+func (p *pathList) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *pathList) Set(value string) error {
+	for _, path := range strings.Split(value, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			*p = append(*p, path)
+		}
+	}
+	return nil
+}
+
+// splitRootDepth splits the maximum depth off a start path given as path:depth,
+// e.g. /opt:4. Without one, it returns the path as it is and false.
+func splitRootDepth(value string) (string, int, bool) {
+	i := strings.LastIndexByte(value, ':')
+	if i <= 0 || i == len(value)-1 || strings.Trim(value[i+1:], "0123456789") != "" {
+		return value, 0, false
+	}
+	depth, err := strconv.Atoi(value[i+1:])
+	if err != nil {
+		return value, 0, false
+	}
+	return value[:i], depth, true
+}
+
+// rootDepth splits the maximum depth off a start path of the command line like
+// splitRootDepth, but only if the path without it exists and the start path
+// as it is does not, so /srv/app:8080 stays a directory named app:8080
+func rootDepth(value string) (string, int, bool) {
+	path, depth, ok := splitRootDepth(value)
+	if !ok || strings.Contains(value, "://") {
+		return path, depth, ok
+	}
+	if _, err := os.Stat(value); err == nil {
+		return value, 0, false
+	}
+	if _, err := os.Stat(path); err != nil {
+		return value, 0, false
+	}
+	return path, depth, true
+}
+
+// normalizeRoots drops duplicate start paths and those below another start path,
+// which would be walked twice. It returns the remaining paths in their order and
+// the dropped ones.
+func normalizeRoots(paths []string) (roots, dropped []string) {
+	for i, path := range paths {
+		covered := slices.ContainsFunc(paths, func(other string) bool {
+			return other != path && withinRoot(other, path)
+		})
+		if covered || slices.Contains(paths[:i], path) {
+			dropped = append(dropped, path)
+			continue
+		}
+		roots = append(roots, path)
+	}
+	return roots, dropped
+}
+
+// startPaths returns the start paths of the scan
+func (f *JavaFinder) startPaths() []string {
+	if len(f.roots) > 0 {
+		return f.roots
+	}
+	return []string{f.startPath}
+}
+
+// complete reports whether the scan looked at everything below its start
+// paths, so that a runtime it did not report is gone rather than skipped.
+// Scans limited by depth, -exclude, shards, listed paths, a result limit,
+// a time limit or a failed walk are not. -prune-dirs does not count, its
+// directories such as node_modules are on almost every host and do not hold
+// installed runtimes.
+func (f *JavaFinder) complete() bool {
+	if f.walkFailed || f.truncated || f.limited || f.timedOut || f.shard != nil || f.minConfidence > 0 {
+		return false
+	}
+	// The directories pruned are counted together with those excluded
+	if len(f.exclude) > 0 && f.excluded > 0 {
+		return false
+	}
+	if _, listed := f.index.(*pathListSource); listed {
+		return false
+	}
+	if f.maxDepth >= 0 || len(f.rootDepths) > 0 || len(f.failures.list()) > 0 {
+		return false
+	}
+	for _, timing := range f.timings {
+		if timing.BudgetExceeded {
+			return false
+		}
+	}
+	return true
+}
+
+// rootIndex returns the index of the start path a path is below, 0 if none
+func (f *JavaFinder) rootIndex(path string) int {
+	for i, root := range f.roots {
+		if withinRoot(root, path) {
+			return i
+		}
+	}
+	return 0
+}
+
+// rootOf returns the start path a path is below
+func (f *JavaFinder) rootOf(path string) string {
+	if len(f.roots) == 0 {
+		return f.startPath
+	}
+	return f.roots[f.rootIndex(path)]
+}
+
+// walkRoots walks each start path in turn and counts the directories below it
+func (f *JavaFinder) walkRoots(ctx context.Context, emit func(*JavaResult) error) error {
+	f.rootStats = nil
+	if len(f.roots) == 0 {
+		return f.walkFileSystem(ctx, emit)
+	}
+
+	start := f.startPath
+	defer func() { f.startPath = start }()
+	for _, root := range f.roots {
+		f.startPath = root
+		scanned := f.scanned - f.checkpoint.scannedBefore(root)
+		err := f.walkFileSystem(ctx, emit)
+		stats := RootStats{Path: root, ScannedDirs: f.scanned - scanned}
+		if depth, ok := f.rootDepths[root]; ok {
+			stats.MaxDepth = &depth
+		}
+		f.rootStats = append(f.rootStats, stats)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
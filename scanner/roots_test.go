@@ -0,0 +1,204 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPathList(t *testing.T) {
+	var paths pathList
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&paths, "path", "")
+	if err := fs.Parse([]string{"-path", "/opt,/usr/lib/jvm", "-path", "/home"}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(paths, pathList{"/opt", "/usr/lib/jvm", "/home"}) {
+		t.Errorf("Expected three start paths, got %v", paths)
+	}
+}
+
+func TestSplitRootDepth(t *testing.T) {
+	tests := []struct {
+		value string
+		path  string
+		depth int
+		ok    bool
+	}{
+		{"/opt:4", "/opt", 4, true},
+		{"/home:0", "/home", 0, true},
+		{"/opt", "/opt", 0, false},
+		{`C:\Program Files:2`, `C:\Program Files`, 2, true},
+		{`C:\`, `C:\`, 0, false},
+		{"/srv/app:v2", "/srv/app:v2", 0, false},
+		{"smb://filer:445/apps", "smb://filer:445/apps", 0, false},
+		{"smb://filer/apps:3", "smb://filer/apps", 3, true},
+		{":4", ":4", 0, false},
+		{"/opt:", "/opt:", 0, false},
+	}
+	for _, tt := range tests {
+		path, depth, ok := splitRootDepth(tt.value)
+		if path != tt.path || depth != tt.depth || ok != tt.ok {
+			t.Errorf("%s: expected %s, %d, %v, got %s, %d, %v", tt.value, tt.path, tt.depth, tt.ok, path, depth, ok)
+		}
+	}
+}
+
+func TestRootDepth(t *testing.T) {
+	dir := t.TempDir()
+	opt := filepath.Join(dir, "opt")
+	if err := os.Mkdir(opt, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if path, depth, ok := rootDepth(opt + ":4"); path != opt || depth != 4 || !ok {
+		t.Errorf("Expected %s with depth 4, got %s, %d, %v", opt, path, depth, ok)
+	}
+	// Neither the path without the depth exists nor the path as it is
+	missing := filepath.Join(dir, "srv", "app:8080")
+	if path, _, ok := rootDepth(missing); path != missing || ok {
+		t.Errorf("Expected %s as it is, got %s, %v", missing, path, ok)
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	app := filepath.Join(dir, "app:8080")
+	if err := os.Mkdir(app, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if path, _, ok := rootDepth(app); path != app || ok {
+		t.Errorf("Expected the directory %s, got %s, %v", app, path, ok)
+	}
+}
+
+func TestNormalizeRoots(t *testing.T) {
+	opt, home := filepath.FromSlash("/opt"), filepath.FromSlash("/home")
+	alice := filepath.Join(home, "alice")
+	roots, dropped := normalizeRoots([]string{alice, opt, home, opt})
+	if !slices.Equal(roots, []string{opt, home}) || !slices.Equal(dropped, []string{alice, opt}) {
+		t.Errorf("Expected nested and duplicate roots to be dropped, got %v and %v", roots, dropped)
+	}
+}
+
+func TestFindRoots(t *testing.T) {
+	dir := t.TempDir()
+	opt, jvm := filepath.Join(dir, "opt"), filepath.Join(dir, "usr", "lib", "jvm")
+	createFakeJava(t, filepath.Join(opt, "jdk-21"))
+	createFakeJava(t, filepath.Join(opt, "vendor", "jdk-17"))
+	createFakeJava(t, filepath.Join(jvm, "java-11"))
+	createFakeJava(t, filepath.Join(dir, "home", "jdk-8"))
+
+	for _, workers := range []int{1, 4} {
+		// The depth counts from each start path
+		finder := NewJavaFinder(jvm, 3, false, false)
+		finder.roots = []string{jvm, opt}
+		finder.workers = workers
+		results, err := finder.Find()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, result := range results {
+			paths = append(paths, result.Path)
+		}
+		want := []string{filepath.Join(jvm, "java-11", "bin", "java"), filepath.Join(opt, "jdk-21", "bin", "java")}
+		if !slices.Equal(paths, want) {
+			t.Errorf("Expected the runtimes of both roots within depth 3 with %d workers, got %v", workers, paths)
+		}
+		if finder.startPath != jvm {
+			t.Errorf("Expected the start path to be restored, got %s", finder.startPath)
+		}
+
+		output := buildJSONOutput(results, finder, time.Now())
+		if roots := output.Meta.Roots; len(roots) != 2 || roots[0].Path != jvm || roots[0].CountResult != 1 || roots[1].CountResult != 1 ||
+			roots[0].ScannedDirs+roots[1].ScannedDirs != output.Meta.ScannedDirs {
+			t.Errorf("Expected the counters per root, got %+v", roots)
+		}
+	}
+}
+
+func TestRootDepths(t *testing.T) {
+	dir := t.TempDir()
+	opt, home := filepath.Join(dir, "opt"), filepath.Join(dir, "home")
+	createFakeJava(t, filepath.Join(opt, "vendor", "jdk-17"))
+	createFakeJava(t, filepath.Join(home, "alice", "jdk-21"))
+	createFakeJava(t, filepath.Join(home, "jdk-11"))
+	createFakeJava(t, filepath.Join(home, "alice", "tools", "jdk-8"))
+
+	for _, workers := range []int{1, 4} {
+		// /opt:4 and /home:3 with a -depth of 2 for the others
+		finder := NewJavaFinder(opt, 2, false, false)
+		finder.roots = []string{opt, home}
+		finder.rootDepths = map[string]int{opt: 4, home: 3}
+		finder.workers = workers
+		results, err := finder.Find()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, result := range results {
+			paths = append(paths, result.Path)
+		}
+		want := []string{filepath.Join(opt, "vendor", "jdk-17", "bin", "java"), filepath.Join(home, "jdk-11", "bin", "java")}
+		if !slices.Equal(paths, want) {
+			t.Errorf("Expected the runtimes within the depth of each root with %d workers, got %v", workers, paths)
+		}
+
+		// Directories below the depth are not counted as scanned: opt, vendor, jdk-17 and bin,
+		// home, alice, jdk-21, its bin, tools, jdk-8, jdk-11 and its bin, but not the bin of jdk-8
+		output := buildJSONOutput(results, finder, time.Now())
+		if roots := output.Meta.Roots; len(roots) != 2 || roots[0].MaxDepth == nil || *roots[0].MaxDepth != 4 ||
+			roots[0].ScannedDirs != 4 || roots[1].ScannedDirs != 8 {
+			t.Errorf("Expected the depth and directories within it per root, got %+v", roots)
+		}
+	}
+}
+
+func TestScanComplete(t *testing.T) {
+	dir := t.TempDir()
+	createFakeJava(t, filepath.Join(dir, "jdk-17"))
+	createFakeJava(t, filepath.Join(dir, "jdk-21"))
+
+	for name, test := range map[string]struct {
+		setup    func(f *JavaFinder)
+		complete bool
+	}{
+		"full":        {func(f *JavaFinder) {}, true},
+		"depth":       {func(f *JavaFinder) { f.maxDepth = 1 }, false},
+		"max results": {func(f *JavaFinder) { f.maxResults = 1 }, false},
+		"excluded":    {func(f *JavaFinder) { f.exclude = exclusions{"jdk-17"} }, false},
+	} {
+		finder := NewJavaFinder(dir, -1, false, false)
+		test.setup(finder)
+		results, err := finder.Find()
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta := buildJSONOutput(results, finder, time.Now()).Meta
+		if meta.Complete != test.complete || !slices.Equal(meta.ScanRoots, []string{dir}) {
+			t.Errorf("Expected the %s scan of %s to be complete %v, got %v of %v", name, dir, test.complete, meta.Complete, meta.ScanRoots)
+		}
+	}
+}
+
+func TestScanCompleteWithDefaultPruneDirs(t *testing.T) {
+	dir := t.TempDir()
+	createFakeJava(t, filepath.Join(dir, "jdk-17"))
+	createFakeJava(t, filepath.Join(dir, "app", "node_modules", "bundled-jre"))
+
+	finder := NewJavaFinder(dir, -1, false, false)
+	finder.prune = parsePruneDirs(defaultPruneDirs)
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := buildJSONOutput(results, finder, time.Now()).Meta; !meta.Complete || meta.ExcludedDirs != 1 {
+		t.Errorf("Expected a complete scan with node_modules pruned, got complete %v with %d excluded", meta.Complete, meta.ExcludedDirs)
+	}
+}
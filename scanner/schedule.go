@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is when jfind serve scans: by a cron expression, at a fixed interval
+// or once, e.g. started by a systemd timer. Only one of Cron, Interval and Once
+// may be set; without any, jfind serve scans every -interval.
+type Schedule struct {
+	// Cron is a cron expression of five fields, minute hour day-of-month month
+	// day-of-week, or one of @hourly, @daily, @weekly, @monthly and @yearly
+	Cron string `json:"cron,omitempty"`
+
+	// Timezone is the IANA time zone of Cron, default is the local time zone
+	Timezone string `json:"timezone,omitempty"`
+
+	// Interval is the time between scans, e.g. 6h
+	Interval string `json:"interval,omitempty"`
+
+	// Splay delays every scan by a random time up to this long, so the hosts of
+	// a fleet do not all scan at the same time
+	Splay string `json:"splay,omitempty"`
+
+	// Once scans once and exits
+	Once bool `json:"once,omitempty"`
+}
+
+// scheduler decides when jfind serve scans
+type scheduler interface {
+	// first returns the time of the first scan of an agent started at start
+	first(start time.Time) time.Time
+
+	// next returns the time of the scan after one that ended at t, false if
+	// the agent exits instead
+	next(t time.Time) (time.Time, bool)
+}
+
+// defaultScanInterval is the time between the scans of jfind serve without a schedule
+const defaultScanInterval = 24 * time.Hour
+
+// newScheduler validates a schedule and returns its scheduler, which scans at
+// the given interval if the schedule sets neither cron, interval nor once
+func (s *Schedule) newScheduler(interval time.Duration) (scheduler, error) {
+	set := 0
+	for _, ok := range []bool{s.Cron != "", s.Interval != "", s.Once} {
+		if ok {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("schedule sets more than one of cron, interval and once")
+	}
+	if s.Timezone != "" && s.Cron == "" {
+		return nil, fmt.Errorf("schedule has a timezone but no cron expression")
+	}
+
+	var sched scheduler = onceSchedule{}
+	switch {
+	case s.Cron != "":
+		location := time.Local
+		if s.Timezone != "" {
+			var err error
+			if location, err = time.LoadLocation(s.Timezone); err != nil {
+				return nil, fmt.Errorf("invalid time zone of schedule: %v", err)
+			}
+		}
+		cron, err := parseCron(s.Cron, location)
+		if err != nil {
+			return nil, err
+		}
+		sched = cron
+	case s.Interval != "":
+		var err error
+		if interval, err = time.ParseDuration(s.Interval); err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid interval %q of schedule, use a positive duration like 6h", s.Interval)
+		}
+		fallthrough
+	case !s.Once:
+		sched = intervalSchedule(interval)
+	}
+
+	if s.Splay != "" {
+		splay, err := time.ParseDuration(s.Splay)
+		if err != nil || splay < 0 {
+			return nil, fmt.Errorf("invalid splay %q of schedule, use a duration like 30m", s.Splay)
+		}
+		if splay > 0 {
+			sched = splaySchedule{sched, splay}
+		}
+	}
+	return sched, nil
+}
+
+// intervalSchedule scans at start-up and then at a fixed interval after each scan
+type intervalSchedule time.Duration
+
+func (s intervalSchedule) first(start time.Time) time.Time { return start }
+
+func (s intervalSchedule) next(t time.Time) (time.Time, bool) {
+	return t.Add(time.Duration(s)), true
+}
+
+// onceSchedule scans at start-up and exits, for external schedulers
+type onceSchedule struct{}
+
+func (onceSchedule) first(start time.Time) time.Time { return start }
+
+func (onceSchedule) next(time.Time) (time.Time, bool) { return time.Time{}, false }
+
+// splaySchedule delays the scans of another schedule by a random time up to splay
+type splaySchedule struct {
+	scheduler
+	splay time.Duration
+}
+
+// splayRand returns a random duration below n, it is replaced in tests
+var splayRand = func(n time.Duration) time.Duration { return rand.N(n) }
+
+func (s splaySchedule) first(start time.Time) time.Time {
+	return s.scheduler.first(start).Add(splayRand(s.splay))
+}
+
+func (s splaySchedule) next(t time.Time) (time.Time, bool) {
+	next, ok := s.scheduler.next(t)
+	return next.Add(splayRand(s.splay)), ok
+}
+
+// cronSchedule scans at the times matching a cron expression. Unlike the
+// interval, it does not scan at start-up.
+type cronSchedule struct {
+	minute, hour, dom, month, dow cronField
+
+	// A day matches either field if both are restricted, as in cron
+	domAny, dowAny bool
+
+	location *time.Location
+}
+
+// cronField is a set of the values of a field, a bit per value
+type cronField uint64
+
+func (f cronField) has(v int) bool { return f&(1<<uint(v)) != 0 }
+
+// cronAliases are the shortcuts of common expressions
+var cronAliases = map[string]string{
+	"@yearly": "0 0 1 1 *", "@annually": "0 0 1 1 *", "@monthly": "0 0 1 * *",
+	"@weekly": "0 0 * * 0", "@daily": "0 0 * * *", "@midnight": "0 0 * * *", "@hourly": "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// maxCronSearch bounds the search for the next time, e.g. of 0 0 30 2 * that never matches
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// parseCron parses a cron expression of five fields with lists (1,15), ranges
+// (1-5), steps (*/15, 0-30/10) and the names of months and weekdays
+func parseCron(expr string, location *time.Location) (*cronSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	if alias, ok := cronAliases[strings.ToLower(spec)]; ok {
+		spec = alias
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q, use minute hour day-of-month month day-of-week", expr)
+	}
+	dayNames := make(map[string]int)
+	for name, day := range weekdayNames {
+		dayNames[name] = int(day)
+	}
+	// As in cron, a day field starting with * such as */2 restricts the other one
+	c := &cronSchedule{location: location, domAny: strings.HasPrefix(fields[2], "*"), dowAny: strings.HasPrefix(fields[4], "*")}
+	var err error
+	for _, field := range []struct {
+		value    *cronField
+		spec     string
+		min, max int
+		names    map[string]int
+	}{
+		{&c.minute, fields[0], 0, 59, nil},
+		{&c.hour, fields[1], 0, 23, nil},
+		{&c.dom, fields[2], 1, 31, nil},
+		{&c.month, fields[3], 1, 12, monthNames},
+		{&c.dow, fields[4], 0, 7, dayNames},
+	} {
+		if *field.value, err = parseCronField(field.spec, field.min, field.max, field.names); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", expr, err)
+		}
+	}
+	// 7 is Sunday as well
+	if c.dow.has(7) {
+		c.dow |= 1
+	}
+	if c.never() {
+		return nil, fmt.Errorf("cron expression %q never matches", expr)
+	}
+	return c, nil
+}
+
+// parseCronField parses the comma separated ranges of a field
+func parseCronField(spec string, min, max int, names map[string]int) (cronField, error) {
+	value := func(s string) (int, error) {
+		if v, ok := names[strings.ToLower(s)]; ok {
+			return v, nil
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil || v < min || v > max {
+			return 0, fmt.Errorf("%q is not between %d and %d", s, min, max)
+		}
+		return v, nil
+	}
+	var field cronField
+	for _, part := range strings.Split(spec, ",") {
+		span, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepSpec); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepSpec)
+			}
+		}
+		from, to := min, max
+		if span != "*" {
+			first, last, isRange := strings.Cut(span, "-")
+			var err error
+			if from, err = value(first); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if to, err = value(last); err != nil {
+					return 0, err
+				}
+			case hasStep:
+				// A single value with a step runs to the end, as in 5/15
+				to = max
+			default:
+				to = from
+			}
+			if to < from {
+				return 0, fmt.Errorf("invalid range %q", span)
+			}
+		}
+		for v := from; v <= to; v += step {
+			field |= 1 << uint(v)
+		}
+	}
+	return field, nil
+}
+
+// never checks if the expression cannot match, e.g. on February 30
+func (c *cronSchedule) never() bool {
+	_, ok := c.next(time.Date(2000, 1, 1, 0, 0, 0, 0, c.location))
+	return !ok
+}
+
+// matchesDay checks the day of month and weekday of a day
+func (c *cronSchedule) matchesDay(t time.Time) bool {
+	dom, dow := c.dom.has(t.Day()), c.dow.has(int(t.Weekday()))
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func (c *cronSchedule) first(start time.Time) time.Time {
+	next, _ := c.next(start)
+	return next
+}
+
+// next returns the first matching time after t. It follows the wall clock of
+// the time zone: times skipped when DST starts do not match, times repeated
+// when it ends match once.
+func (c *cronSchedule) next(after time.Time) (time.Time, bool) {
+	local := after.In(c.location)
+	t := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute()+1, 0, 0, c.location)
+	for limit := after.Add(maxCronSearch); t.Before(limit); {
+		var next time.Time
+		year, month, day := t.Date()
+		switch {
+		case !c.month.has(int(month)):
+			next = time.Date(year, month+1, 1, 0, 0, 0, 0, c.location)
+		case !c.matchesDay(t):
+			next = time.Date(year, month, day+1, 0, 0, 0, 0, c.location)
+		case !c.hour.has(t.Hour()):
+			next = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, c.location)
+		case !c.minute.has(t.Minute()):
+			next = time.Date(year, month, day, t.Hour(), t.Minute()+1, 0, 0, c.location)
+		case t.After(after):
+			return t, true
+		}
+		// The wall clock goes back when DST ends
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	return time.Time{}, false
+}
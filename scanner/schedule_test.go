@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronSchedule(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	tests := []struct {
+		name  string
+		cron  string
+		after string
+		next  string
+	}{
+		{"daily", "30 2 * * *", "2025-03-05T10:00:00+01:00", "2025-03-06T02:30:00+01:00"},
+		{"later today", "30 2,14 * * *", "2025-03-05T10:00:00+01:00", "2025-03-05T14:30:00+01:00"},
+		{"strictly after", "0 10 * * *", "2025-03-05T10:00:00+01:00", "2025-03-06T10:00:00+01:00"},
+		{"step", "*/15 * * * *", "2025-03-05T10:07:30+01:00", "2025-03-05T10:15:00+01:00"},
+		{"weekdays by name", "0 8 * * mon-fri", "2025-03-07T09:00:00+01:00", "2025-03-10T08:00:00+01:00"},
+		{"sunday as 7", "0 8 * * 7", "2025-03-07T09:00:00+01:00", "2025-03-09T08:00:00+01:00"},
+		{"day of month or weekday", "0 0 1 * sun", "2025-03-03T00:00:00+01:00", "2025-03-09T00:00:00+01:00"},
+		{"month by name", "0 0 1 jul *", "2025-03-05T10:00:00+01:00", "2025-07-01T00:00:00+02:00"},
+		{"leap day", "0 12 29 2 *", "2025-03-05T10:00:00+01:00", "2028-02-29T12:00:00+01:00"},
+		{"alias", "@weekly", "2025-03-05T10:00:00+01:00", "2025-03-09T00:00:00+01:00"},
+		// 02:30 does not exist on March 30 2025 and exists twice on October 26 2025
+		{"skipped by DST", "30 2 * * *", "2025-03-29T12:00:00+01:00", "2025-03-31T02:30:00+02:00"},
+		{"repeated by DST", "30 2 * * *", "2025-10-26T02:45:00+02:00", "2025-10-27T02:30:00+01:00"},
+		{"hourly across DST", "0 * * * *", "2025-10-26T02:30:00+02:00", "2025-10-26T03:00:00+01:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := parseCron(tt.cron, berlin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after, _ := time.Parse(time.RFC3339, tt.after)
+			next, ok := cron.next(after)
+			if got := next.Format(time.RFC3339); !ok || got != tt.next {
+				t.Errorf("Expected %s, got %s", tt.next, got)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"* * * *", "60 * * * *", "* * 0 * *", "* * * * mon-sun/0", "5-1 * * * *", "0 0 30 2 *", "@often"} {
+		if _, err := parseCron(invalid, time.UTC); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestCronStepOfAnyDay(t *testing.T) {
+	// Every other day of the month that is a Monday, not every other day and every Monday
+	cron, err := parseCron("0 0 */2 * mon", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, ok := cron.next(time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC); !ok || !next.Equal(want) {
+		t.Errorf("Expected %s, got %s", want, next)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	random := splayRand
+	splayRand = func(n time.Duration) time.Duration { return n / 2 }
+	t.Cleanup(func() { splayRand = random })
+	start := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		first    time.Time
+		next     time.Time
+	}{
+		{"default interval", Schedule{}, start, start.Add(defaultScanInterval)},
+		{"interval with splay", Schedule{Interval: "6h", Splay: "1h"}, start.Add(30 * time.Minute), start.Add(6*time.Hour + 30*time.Minute)},
+		{"cron", Schedule{Cron: "0 3 * * *", Timezone: "UTC"}, start.Add(17 * time.Hour), start.Add(17 * time.Hour)},
+		{"once", Schedule{Once: true}, start, time.Time{}},
+	}
+	for _, tt := range tests {
+		sched, err := tt.schedule.newScheduler(defaultScanInterval)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first := sched.first(start); !first.Equal(tt.first) {
+			t.Errorf("%s: expected the first scan at %s, got %s", tt.name, tt.first, first)
+		}
+		next, ok := sched.next(start)
+		if ok != !tt.next.IsZero() || ok && !next.Equal(tt.next) {
+			t.Errorf("%s: expected the next scan at %s, got %s (%v)", tt.name, tt.next, next, ok)
+		}
+	}
+
+	for _, invalid := range []Schedule{
+		{Cron: "@daily", Interval: "1h"},
+		{Interval: "1h", Once: true},
+		{Interval: "-1h"},
+		{Interval: "1h", Timezone: "UTC"},
+		{Cron: "@daily", Timezone: "Mars/Olympus_Mons"},
+		{Once: true, Splay: "soon"},
+	} {
+		if _, err := invalid.newScheduler(defaultScanInterval); err == nil {
+			t.Errorf("Expected %+v to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// shard selects the part of the top-level directories of the start path one
+// of several cooperating jfind processes scans
+type shard struct {
+	index int // 1-based
+	count int
+}
+
+// parseShard parses a shard given as "i/n", e.g. "2/4" for the second of four shards
+func parseShard(s string) (*shard, error) {
+	i, n, ok := strings.Cut(s, "/")
+	index, err1 := strconv.Atoi(i)
+	count, err2 := strconv.Atoi(n)
+	if !ok || err1 != nil || err2 != nil || count < 1 || index < 1 || index > count {
+		return nil, fmt.Errorf("invalid shard %q (use i/n with 1 <= i <= n)", s)
+	}
+	return &shard{index: index, count: count}, nil
+}
+
+// String returns the shard in i/n notation
+func (s *shard) String() string {
+	return fmt.Sprintf("%d/%d", s.index, s.count)
+}
+
+// owns checks if a top-level directory belongs to this shard. The assignment
+// only depends on the name, so processes on different machines agree on it.
+func (s *shard) owns(name string) bool {
+	if s == nil || s.count == 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return int(h.Sum32()%uint32(s.count)) == s.index-1
+}
+
+// ownsPath checks if a path below root belongs to this shard by its first path component
+func (s *shard) ownsPath(root, path string) bool {
+	if s == nil {
+		return true
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return true
+	}
+	top, _, _ := strings.Cut(rel, string(filepath.Separator))
+	return s.owns(top)
+}
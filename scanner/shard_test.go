@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseShard(t *testing.T) {
+	if s, err := parseShard("2/4"); err != nil || s.index != 2 || s.count != 4 {
+		t.Errorf("Expected shard 2 of 4, got %v (%v)", s, err)
+	}
+	for _, invalid := range []string{"", "2", "0/4", "5/4", "1/0", "a/b"} {
+		if _, err := parseShard(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestShardsPartitionScan(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 12; i++ {
+		createFakeJava(t, filepath.Join(root, fmt.Sprintf("dir%d", i), "jdk"))
+	}
+
+	full, err := NewJavaFinder(root, -1, false, false).Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var reports []*JSONOutput
+	total := 0
+	for i := 1; i <= 3; i++ {
+		finder := NewJavaFinder(root, -1, false, false)
+		finder.shard = &shard{index: i, count: 3}
+		results, err := finder.Find()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		total += len(results)
+		output := buildJSONOutput(results, finder, time.Now())
+		reports = append(reports, &output)
+	}
+	if total != len(full) {
+		t.Errorf("Expected the shards to find %d runtimes in total, got %d", len(full), total)
+	}
+
+	merged, err := mergeReports(reports)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if merged.Meta.CountResult != len(full) || len(merged.Meta.MergedShards) != 3 {
+		t.Errorf("Expected %d runtimes from 3 shards, got %d from %v",
+			len(full), merged.Meta.CountResult, merged.Meta.MergedShards)
+	}
+	if _, err := mergeReports(append(reports, reports[0])); err == nil {
+		t.Error("Expected error for a shard given twice")
+	}
+}
+
+func TestParseDurationISO8601(t *testing.T) {
+	for _, d := range []time.Duration{0, 1234 * time.Millisecond, 61 * time.Minute, 2*time.Hour + 3*time.Second} {
+		parsed, err := parseDurationISO8601(formatDurationISO8601(d))
+		if err != nil || parsed != d {
+			t.Errorf("Expected %v, got %v (%v)", d, parsed, err)
+		}
+	}
+}
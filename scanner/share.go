@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schemes of the remote shares jfind mounts itself
+const (
+	ShareSMB = "smb"
+	ShareNFS = "nfs"
+)
+
+// Estimated bytes transferred per entry of the walk and per runtime found on a
+// share, for -share-bandwidth. The walk only reads directory listings and file
+// attributes, a runtime adds its release file and the headers of its binary.
+const (
+	shareEntryBytes   = 256
+	shareRuntimeBytes = 64 << 10
+)
+
+// remoteShare is an SMB share or NFS export scanned without an agent on the
+// host that exports it, e.g. an appliance
+type remoteShare struct {
+	Scheme string
+	Host   string
+	Share  string // share name for SMB, export path for NFS
+	Path   string // directory below an SMB share to scan instead of its root
+	User   string // user name from the URL, e.g. DOMAIN;user
+}
+
+// parseShareURL parses a start path of the form smb://[user@]server/share[/dir]
+// or nfs://server/export. It returns nil for local paths.
+func parseShareURL(raw string) (*remoteShare, error) {
+	scheme, _, ok := strings.Cut(raw, "://")
+	if !ok || (scheme != ShareSMB && scheme != ShareNFS) {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid share %s: %v", raw, err)
+	}
+	if _, set := u.User.Password(); set {
+		return nil, fmt.Errorf("invalid share %s: pass the password with -share-credentials, not in the URL", u.Redacted())
+	}
+	share := &remoteShare{Scheme: scheme, Host: u.Host, User: u.User.Username()}
+	name := strings.Trim(path.Clean("/"+u.Path), "/")
+	if share.Host == "" || name == "" {
+		return nil, fmt.Errorf("invalid share %s: expected smb://server/share or nfs://server/export", raw)
+	}
+	if scheme == ShareSMB {
+		share.Share, share.Path, _ = strings.Cut(name, "/")
+	} else {
+		share.Share = "/" + name
+	}
+	return share, nil
+}
+
+// String returns the URL of the share without the user name
+func (s *remoteShare) String() string {
+	return s.reportPath("")
+}
+
+// reportPath returns the URL of a path below the scanned directory of the share
+func (s *remoteShare) reportPath(rel string) string {
+	name := strings.Trim(s.Share, "/")
+	if s.Path != "" {
+		name += "/" + s.Path
+	}
+	if rel = filepath.ToSlash(rel); rel != "" && rel != "." {
+		name += "/" + rel
+	}
+	return s.Scheme + "://" + s.Host + "/" + name
+}
+
+// report replaces the local paths below the mount point root by the URLs of the
+// share, so reports show where the runtimes really are. Runtimes are classified
+// by their path below the share rather than the temporary mount point.
+func (s *remoteShare) report(root string, results []*JavaResult, libraries *libraryScanner, classifier *pathClassifier) {
+	rel := func(local string) string {
+		r, err := filepath.Rel(root, local)
+		if err != nil {
+			return local
+		}
+		return r
+	}
+	for _, result := range results {
+		r := rel(result.Path)
+		result.PathClass = classifier.classify("/" + filepath.ToSlash(r))
+		result.Path = s.reportPath(r)
+		for i, alias := range result.Aliases {
+			result.Aliases[i] = s.reportPath(rel(alias))
+		}
+	}
+	if libraries != nil {
+		for i := range libraries.findings {
+			libraries.findings[i].Path = s.reportPath(rel(libraries.findings[i].Path))
+		}
+	}
+}
+
+// shareCredentials are the credentials of an SMB share, read from a file in the
+// format of mount.cifs: username=, password= and domain= lines
+type shareCredentials struct {
+	Username string
+	Password string
+	Domain   string
+}
+
+// loadShareCredentials reads a credentials file
+func loadShareCredentials(file string) (*shareCredentials, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read share credentials: %v", err)
+	}
+	defer f.Close()
+
+	creds := &shareCredentials{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "username", "user":
+			creds.Username = strings.TrimSpace(value)
+		case "password", "pass":
+			creds.Password = value // may start or end with spaces
+		case "domain", "dom", "workgroup":
+			creds.Domain = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read share credentials: %v", err)
+	}
+	// mount.cifs accepts the domain as part of the user name too
+	if creds.Domain == "" {
+		for _, sep := range []string{`\`, "/"} {
+			if domain, user, ok := strings.Cut(creds.Username, sep); ok {
+				creds.Domain, creds.Username = domain, user
+				break
+			}
+		}
+	}
+	return creds, nil
+}
+
+// bandwidthLimiter spaces out the reads of the walk so that it transfers no
+// more than rate bytes per second on average, see -share-bandwidth
+type bandwidthLimiter struct {
+	mu   sync.Mutex
+	rate int64
+	next time.Time
+}
+
+// wait blocks until n more bytes may be read. A nil limiter never waits.
+func (l *bandwidthLimiter) wait(n int64) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if d := time.Until(l.next); d > 0 {
+		time.Sleep(d)
+	}
+	l.next = time.Now().Add(time.Duration(n) * time.Second / time.Duration(l.rate))
+}
@@ -0,0 +1,78 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// mountShare mounts a share read-only, nosuid, nodev and noexec on a temporary
+// mount point with the mount helpers of the operating system, which requires
+// root on Linux. It returns the
+// directory to scan and a function that unmounts the share again.
+func mountShare(share *remoteShare, credentials string) (string, func() error, error) {
+	var name string
+	var args []string
+	switch {
+	case runtime.GOOS == "linux" && share.Scheme == ShareSMB:
+		name, args = "mount", []string{"-t", "cifs", "-o", cifsOptions(share, credentials), "//" + share.Host + "/" + share.Share}
+	case runtime.GOOS == "darwin" && share.Scheme == ShareSMB:
+		if credentials != "" {
+			return "", nil, fmt.Errorf("-share-credentials is not supported on macOS, store the password of %s in the keychain", share)
+		}
+		// -N takes the password from the keychain instead of prompting
+		user := ""
+		if share.User != "" {
+			user = share.User + "@"
+		}
+		name, args = "mount_smbfs", []string{"-N", "-o", "rdonly,nosuid,nodev,noexec", "//" + user + share.Host + "/" + share.Share}
+	case (runtime.GOOS == "linux" || runtime.GOOS == "darwin") && share.Scheme == ShareNFS:
+		name, args = "mount", []string{"-t", "nfs", "-o", "ro,soft,nosuid,nodev,noexec", share.Host + ":" + share.Share}
+	default:
+		return "", nil, fmt.Errorf("%s shares are not supported on %s", share.Scheme, runtime.GOOS)
+	}
+
+	dir, err := os.MkdirTemp("", "jfind-share-")
+	if err != nil {
+		return "", nil, err
+	}
+	if out, err := exec.Command(name, append(args, dir)...).CombinedOutput(); err != nil {
+		os.Remove(dir)
+		return "", nil, fmt.Errorf("failed to mount %s: %v: %s", share, err, strings.TrimSpace(string(out)))
+	}
+	unmount := func() error {
+		if out, err := exec.Command("umount", dir).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to unmount %s from %s: %v: %s", share, dir, err, strings.TrimSpace(string(out)))
+		}
+		return os.Remove(dir)
+	}
+	return filepath.Join(dir, filepath.FromSlash(share.Path)), unmount, nil
+}
+
+// cifsOptions returns the mount options of an SMB share. The password is only
+// ever passed in the credentials file, never on the command line. Nothing on
+// the share is executed, so it is mounted without setuid, devices and exec.
+func cifsOptions(share *remoteShare, credentials string) string {
+	options := []string{"ro", "nosuid", "nodev", "noexec"}
+	switch {
+	case credentials != "":
+		options = append(options, "credentials="+credentials)
+	case share.User != "":
+		domain, user, ok := strings.Cut(share.User, ";")
+		if !ok {
+			domain, user = "", share.User
+		}
+		options = append(options, "username="+user)
+		if domain != "" {
+			options = append(options, "domain="+domain)
+		}
+	default:
+		options = append(options, "guest")
+	}
+	return strings.Join(options, ",")
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCifsOptionsNoExec(t *testing.T) {
+	share, _ := parseShareURL("smb://nas01/apps")
+	options := strings.Split(cifsOptions(share, ""), ",")
+	for _, want := range []string{"ro", "nosuid", "nodev", "noexec"} {
+		if !slices.Contains(options, want) {
+			t.Errorf("Expected %s in the mount options, got %v", want, options)
+		}
+	}
+}
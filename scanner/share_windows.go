@@ -0,0 +1,87 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	mpr                      = syscall.NewLazyDLL("mpr.dll")
+	procWNetAddConnection2W  = mpr.NewProc("WNetAddConnection2W")
+	procWNetCancelConnection = mpr.NewProc("WNetCancelConnection2W")
+)
+
+const resourceTypeDisk = 1
+
+// netResource is the NETRESOURCEW structure of WNetAddConnection2W
+type netResource struct {
+	Scope       uint32
+	Type        uint32
+	DisplayType uint32
+	Usage       uint32
+	LocalName   *uint16
+	RemoteName  *uint16
+	Comment     *uint16
+	Provider    *uint16
+}
+
+// mountShare connects to a share by its UNC path without a drive letter. SMB
+// shares are connected with the credentials file if one is given, otherwise
+// with those of the current user; NFS exports require the Client for NFS
+// feature. It returns the directory to scan and a function that disconnects.
+func mountShare(share *remoteShare, credentials string) (string, func() error, error) {
+	unc := `\\` + share.Host + `\` + strings.ReplaceAll(strings.Trim(share.Share, "/"), "/", `\`)
+	root := unc
+	if share.Path != "" {
+		root += `\` + strings.ReplaceAll(share.Path, "/", `\`)
+	}
+	if share.Scheme != ShareSMB || credentials == "" && share.User == "" {
+		return root, func() error { return nil }, nil
+	}
+
+	var user, password string
+	if credentials != "" {
+		creds, err := loadShareCredentials(credentials)
+		if err != nil {
+			return "", nil, err
+		}
+		user, password = creds.Username, creds.Password
+		if creds.Domain != "" {
+			user = creds.Domain + `\` + user
+		}
+	} else {
+		user = strings.Replace(share.User, ";", `\`, 1)
+	}
+
+	remote, err := syscall.UTF16PtrFromString(unc)
+	if err != nil {
+		return "", nil, err
+	}
+	userPtr, err := syscall.UTF16PtrFromString(user)
+	if err != nil {
+		return "", nil, err
+	}
+	var passwordPtr *uint16 // nil uses the default password of the user
+	if password != "" {
+		if passwordPtr, err = syscall.UTF16PtrFromString(password); err != nil {
+			return "", nil, err
+		}
+	}
+	resource := netResource{Type: resourceTypeDisk, RemoteName: remote}
+	r, _, _ := procWNetAddConnection2W.Call(uintptr(unsafe.Pointer(&resource)), uintptr(unsafe.Pointer(passwordPtr)), uintptr(unsafe.Pointer(userPtr)), 0)
+	if r != 0 {
+		return "", nil, fmt.Errorf("failed to connect to %s: %v", share, syscall.Errno(r))
+	}
+	disconnect := func() error {
+		// Force the disconnect, files may still be open by the enrichers
+		if r, _, _ := procWNetCancelConnection.Call(uintptr(unsafe.Pointer(remote)), 0, 1); r != 0 {
+			return fmt.Errorf("failed to disconnect from %s: %v", share, syscall.Errno(r))
+		}
+		return nil
+	}
+	return root, disconnect, nil
+}
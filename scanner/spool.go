@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// sealedReportMagic starts a report sealed for the local report directory,
+// followed by the nonce and the AES-256-GCM encrypted, gzip compressed report
+const sealedReportMagic = "JFSEALED1"
+
+// sealedReportExt is the extension of sealed report files
+const sealedReportExt = ".sealed"
+
+// spoolKeySize is the size of the AES-256 key of sealed reports
+const spoolKeySize = 32
+
+// isSealedReport checks if data is a sealed report
+func isSealedReport(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(sealedReportMagic))
+}
+
+// sealReport compresses and encrypts a report with the key of the report directory
+func sealReport(report, key []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(report); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	aead, err := newSpoolCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed := make([]byte, len(sealedReportMagic)+aead.NonceSize(), len(sealedReportMagic)+aead.NonceSize()+compressed.Len()+aead.Overhead())
+	copy(sealed, sealedReportMagic)
+	nonce := sealed[len(sealedReportMagic):]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(sealed, nonce, compressed.Bytes(), []byte(sealedReportMagic)), nil
+}
+
+// openSealedReport decrypts and decompresses a sealed report
+func openSealedReport(sealed, key []byte) ([]byte, error) {
+	aead, err := newSpoolCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if !isSealedReport(sealed) || len(sealed) < len(sealedReportMagic)+aead.NonceSize() {
+		return nil, errors.New("not a sealed report")
+	}
+	nonce := sealed[len(sealedReportMagic) : len(sealedReportMagic)+aead.NonceSize()]
+	compressed, err := aead.Open(nil, nonce, sealed[len(sealedReportMagic)+aead.NonceSize():], []byte(sealedReportMagic))
+	if err != nil {
+		return nil, errors.New("sealed report cannot be decrypted with the key of this host and user")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
+
+func newSpoolCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// spoolKey returns the key of sealed reports from the key store of the
+// operating system, see readSpoolKey. With create, a missing key is generated.
+func spoolKey(create bool) ([]byte, error) {
+	key, err := readSpoolKey()
+	if err == nil && len(key) == spoolKeySize {
+		return key, nil
+	}
+	if err == nil {
+		// A new key would make the reports sealed with the stored one unreadable
+		return nil, fmt.Errorf("the stored report key has %d bytes instead of %d, remove it to generate a new one", len(key), spoolKeySize)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read the report key: %v", err)
+	}
+	if !create {
+		return nil, errors.New("no report key on this host for this user")
+	}
+	key = make([]byte, spoolKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := writeSpoolKey(key); err != nil {
+		return nil, fmt.Errorf("failed to store the report key: %v", err)
+	}
+	return key, nil
+}
+
+// readReportFile reads a report file, opening it if it is sealed
+func readReportFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !isSealedReport(data) {
+		return data, err
+	}
+	key, err := spoolKey(false)
+	if err != nil {
+		return nil, err
+	}
+	return openSealedReport(data, key)
+}
+
+// runReadReport prints a report of the report directory, also a sealed one
+func runReadReport(args []string) int {
+	fs := flag.NewFlagSet("read-report", flag.ContinueOnError)
+	fs.Usage = func() {
+		logf("Usage: jfind read-report <report>\n")
+		logf("Sealed reports of jfind serve -seal-reports are opened with the key of this host and user\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	data, err := readReportFile(fs.Arg(0))
+	if err != nil {
+		logf("Error: %s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	os.Stdout.Write(data)
+	return 0
+}
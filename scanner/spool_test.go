@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSealReport(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, spoolKeySize)
+	report := []byte(`{"meta": {"computer_name": "build01"}, "runtimes": []}`)
+	sealed, err := sealReport(report, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSealedReport(sealed) || bytes.Contains(sealed, []byte("build01")) {
+		t.Fatalf("Expected a sealed report without the plain text, got %q", sealed)
+	}
+	opened, err := openSealedReport(sealed, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, report) {
+		t.Errorf("Expected the original report, got %s", opened)
+	}
+
+	if _, err := openSealedReport(sealed, bytes.Repeat([]byte{8}, spoolKeySize)); err == nil {
+		t.Error("Expected a sealed report not to open with another key")
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := openSealedReport(sealed, key); err == nil {
+		t.Error("Expected a modified sealed report not to open")
+	}
+	if _, err := openSealedReport(report, key); err == nil {
+		t.Error("Expected a plain report not to open")
+	}
+}
+
+func TestReportStoreSealed(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("the report key is kept by the operating system")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	key, err := spoolKey(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := spoolKey(false); err != nil || !bytes.Equal(again, key) {
+		t.Fatalf("Expected the stored key, got %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "jfind", "report.key")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a key file readable by the user only, got %v", info)
+	}
+
+	store := newTestReportStore(t, Retention{MaxReports: 2}, 1<<40)
+	store.key = key
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		scanned := start.Add(time.Duration(i) * time.Hour)
+		report := &JSONOutput{Meta: MetaInfo{ComputerName: "build01", ScanTimestamp: scanned.Format(time.RFC3339)}}
+		if _, err := store.write(report, scanned); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := reportNames(t, store)
+	want := []string{"jfind-20240301T130000.000Z.json.sealed", "jfind-20240301T140000.000Z.json.sealed"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("Expected the sealed reports to be pruned like plain ones, got %v", names)
+	}
+
+	path := filepath.Join(store.dir, names[1])
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("build01")) {
+		t.Error("Expected the computer name not to be readable in the sealed report")
+	}
+	report, err := loadReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Meta.ComputerName != "build01" {
+		t.Errorf("Expected the sealed report to load, got %+v", report.Meta)
+	}
+}
+
+func TestSpoolKeyWrongLength(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("the report key is kept by the operating system")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	file := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "jfind", "report.key")
+	writeTestFile(t, file, "truncated")
+
+	// A damaged key is not silently replaced, which would lose the sealed reports
+	if _, err := spoolKey(true); err == nil {
+		t.Error("Expected an error for a key of the wrong length")
+	}
+	if data, _ := os.ReadFile(file); string(data) != "truncated" {
+		t.Errorf("Expected the stored key to be kept, got %q", data)
+	}
+}
@@ -0,0 +1,51 @@
+//go:build darwin
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strings"
+)
+
+// The report key is a generic password of the keychain of the user jfind runs as
+const (
+	spoolKeyService = "jfind"
+	spoolKeyAccount = "report-key"
+)
+
+// securityItemNotFound is the exit code of security if the item does not exist
+const securityItemNotFound = 44
+
+// readSpoolKey reads the report key from the keychain
+func readSpoolKey() ([]byte, error) {
+	output, err := exec.Command("security", "find-generic-password", "-s", spoolKeyService, "-a", spoolKeyAccount, "-w").Output()
+	if exitCode(err) == securityItemNotFound {
+		return nil, fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimSpace(string(output)))
+}
+
+// writeSpoolKey adds the report key to the keychain. The key is passed to an
+// interactive security on its standard input, as the arguments of a command
+// can be read by every user of the host.
+func writeSpoolKey(key []byte) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", spoolKeyService, spoolKeyAccount, hex.EncodeToString(key)))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	// security -i reports failed commands on stderr only
+	if stored, err := readSpoolKey(); err != nil || !bytes.Equal(stored, key) {
+		return fmt.Errorf("the key was not stored: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// spoolKeyFile returns the file of the report key. The kernel keyring does
+// not keep keys across reboots, so the key is a file only the user jfind runs
+// as can read, outside of the report directory.
+func spoolKeyFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "jfind", "report.key"), nil
+}
+
+// readSpoolKey reads the report key file
+func readSpoolKey() ([]byte, error) {
+	path, err := spoolKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// writeSpoolKey writes the report key file, readable by its owner only
+func writeSpoolKey(key []byte) error {
+	path, err := spoolKeyFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, key, 0600)
+}
@@ -0,0 +1,83 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// cryptprotectUIForbidden fails instead of prompting the user
+const cryptprotectUIForbidden = 0x1
+
+var (
+	crypt32                = syscall.NewLazyDLL("crypt32.dll")
+	procCryptProtectData   = crypt32.NewProc("CryptProtectData")
+	procCryptUnprotectData = crypt32.NewProc("CryptUnprotectData")
+	procLocalFree          = kernel32.NewProc("LocalFree")
+)
+
+// dataBlob is DATA_BLOB
+type dataBlob struct {
+	size uint32
+	data *byte
+}
+
+func newDataBlob(data []byte) *dataBlob {
+	if len(data) == 0 {
+		return &dataBlob{}
+	}
+	return &dataBlob{size: uint32(len(data)), data: &data[0]}
+}
+
+// bytes copies the data of a blob allocated by Windows and frees it
+func (b *dataBlob) bytes() []byte {
+	defer procLocalFree.Call(uintptr(unsafe.Pointer(b.data)))
+	return append([]byte(nil), unsafe.Slice(b.data, b.size)...)
+}
+
+// spoolKeyFile returns the file of the report key protected with DPAPI
+func spoolKeyFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "jfind", "report.key"), nil
+}
+
+// readSpoolKey reads the report key and unprotects it with DPAPI, which only
+// succeeds for the user that protected it
+func readSpoolKey() ([]byte, error) {
+	path, err := spoolKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	protected, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out dataBlob
+	if r, _, err := procCryptUnprotectData.Call(uintptr(unsafe.Pointer(newDataBlob(protected))), 0, 0, 0, 0, cryptprotectUIForbidden, uintptr(unsafe.Pointer(&out))); r == 0 {
+		return nil, err
+	}
+	return out.bytes(), nil
+}
+
+// writeSpoolKey protects the report key with DPAPI for the current user and
+// writes it to the key file
+func writeSpoolKey(key []byte) error {
+	path, err := spoolKeyFile()
+	if err != nil {
+		return err
+	}
+	var out dataBlob
+	if r, _, err := procCryptProtectData.Call(uintptr(unsafe.Pointer(newDataBlob(key))), 0, 0, 0, 0, cryptprotectUIForbidden, uintptr(unsafe.Pointer(&out))); r == 0 {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out.bytes(), 0600)
+}
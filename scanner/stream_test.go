@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// createFakeJava creates an executable file named like the java launcher below dir
+func createFakeJava(t *testing.T, dir string) string {
+	t.Helper()
+	name := "java"
+	if runtime.GOOS == "windows" {
+		name = "java.exe"
+	}
+	binDir := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(binDir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStream(t *testing.T) {
+	root := t.TempDir()
+	createFakeJava(t, filepath.Join(root, "jdk-17"))
+	createFakeJava(t, filepath.Join(root, "jdk-21"))
+
+	finder := NewJavaFinder(root, -1, false, false)
+	results, errc := finder.Stream(context.Background())
+
+	count := 0
+	for range results {
+		count++
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 results, got %d", count)
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	root := t.TempDir()
+	createFakeJava(t, filepath.Join(root, "jdk-17"))
+	createFakeJava(t, filepath.Join(root, "jdk-21"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finder := NewJavaFinder(root, -1, false, false)
+	results, errc := finder.Stream(ctx)
+
+	// Stop after the first result without receiving any further ones
+	<-results
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// stallingFS does not return from reading a directory until stop is closed, like a hung mount
+type stallingFS struct {
+	fakeFileSystem
+	stalled string
+	stop    <-chan struct{}
+}
+
+func (fsys stallingFS) ReadDirNames(name string) ([]string, error) {
+	if name == fsys.stalled {
+		<-fsys.stop
+	}
+	return fsys.fakeFileSystem.ReadDirNames(name)
+}
+
+func TestFindContextTimeout(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	jdk17 := filepath.Join(root, "jdk-17", "bin", javaExecutableName())
+	fsys := fakeFileSystem{}
+	fsys.add(jdk17, 0755, "")
+	fsys.add(filepath.Join(root, "nfs", "jdk-21", "bin", javaExecutableName()), 0755, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.SetSystem(System{FS: stallingFS{fakeFileSystem: fsys, stalled: filepath.Join(root, "nfs"), stop: ctx.Done()}})
+	finder.workers = 1
+	results, err := finder.FindContext(ctx)
+	if err != nil {
+		t.Fatalf("Expected the partial results without an error, got %v", err)
+	}
+	if len(results) != 1 || results[0].Path != jdk17 {
+		t.Errorf("Expected the runtime found before the timeout, got %v", results)
+	}
+	if output := buildJSONOutput(results, finder, time.Now()); !output.Meta.TimedOut {
+		t.Error("Expected the report to be marked as timed out")
+	}
+
+	// A scan that completes is not timed out
+	finder.SetSystem(System{FS: fsys})
+	if results, err := finder.FindContext(context.Background()); err != nil || len(results) != 2 || finder.timedOut {
+		t.Errorf("Expected both runtimes without a timeout, got %v, %v", results, err)
+	}
+}
+
+func TestFindContextAbandonsWalk(t *testing.T) {
+	saved := walkGracePeriod
+	walkGracePeriod = 50 * time.Millisecond
+	t.Cleanup(func() { walkGracePeriod = saved })
+
+	root := filepath.FromSlash("/fake")
+	fsys := fakeFileSystem{}
+	fsys.add(filepath.Join(root, "jdk-17", "bin", javaExecutableName()), 0755, "")
+	fsys.add(filepath.Join(root, "nfs", "jdk-21", "bin", javaExecutableName()), 0755, "")
+	hung := make(chan struct{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.SetSystem(System{FS: stallingFS{fakeFileSystem: fsys, stalled: filepath.Join(root, "nfs"), stop: hung}})
+	finder.workers = 1
+	if _, err := finder.FindContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The abandoned walk goes on with its own copy of the finder while the
+	// report is built, which leaves its counters out
+	close(hung)
+	output := buildJSONOutput(nil, finder, time.Now())
+	if !output.Meta.TimedOut || output.Meta.ScannedDirs != 0 || len(output.Meta.Roots) != 0 {
+		t.Errorf("Expected a timed out report without the counters of the walk, got %+v", output.Meta)
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// redacted replaces secrets in the files of a support bundle
+const redacted = "REDACTED"
+
+// secretKeyWords mark JSON keys whose string values are replaced
+var secretKeyWords = []string{"password", "passwd", "secret", "token", "credential", "apikey", "api_key", "private_key", "authorization"}
+
+// secretAssignment matches secrets passed as name=value, e.g. in JAVA_TOOL_OPTIONS
+var secretAssignment = regexp.MustCompile(`(?i)([\w.-]*(?:password|passwd|secret|token)[\w.-]*)=([^\s,;&]+)`)
+
+// urlCredentials matches the user information of a URL anywhere in a text,
+// e.g. in an error message logging the collector URL
+var urlCredentials = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/?#\s@]+@`)
+
+// javaEnvironment are the environment variables that influence which java runs and how
+var javaEnvironment = []string{"JAVA_HOME", "JDK_HOME", "JRE_HOME", "JAVA_TOOL_OPTIONS", "_JAVA_OPTIONS", "JDK_JAVA_OPTIONS", "CLASSPATH"}
+
+// EnvironmentFacts describes the host a support bundle was created on
+type EnvironmentFacts struct {
+	Created            string            `json:"created"`
+	JfindVersion       string            `json:"jfind_version"`
+	GoVersion          string            `json:"go_version"`
+	OS                 string            `json:"os"`
+	Arch               string            `json:"arch"`
+	ComputerName       string            `json:"computer_name"`
+	UserName           string            `json:"user_name"`
+	RuntimeEnvironment string            `json:"runtime_environment"`
+	CgroupCPUs         float64           `json:"cgroup_cpus,omitempty"`
+	CgroupMemory       int64             `json:"cgroup_memory_bytes,omitempty"`
+	DefaultRuntime     *DefaultRuntime   `json:"default_runtime"`
+	JavaEnvironment    map[string]string `json:"java_environment,omitempty"`
+	Path               []string          `json:"path"`
+	Notes              []string          `json:"notes,omitempty"`
+}
+
+// jfindVersion returns the module version and VCS revision jfind was built from
+func jfindVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			version += " (" + setting.Value + ")"
+		}
+	}
+	return version
+}
+
+// collectEnvironmentFacts gathers the facts of the current host
+func collectEnvironmentFacts() *EnvironmentFacts {
+	facts := &EnvironmentFacts{
+		Created:            time.Now().UTC().Format(time.RFC3339),
+		JfindVersion:       jfindVersion(),
+		GoVersion:          runtime.Version(),
+		OS:                 runtime.GOOS,
+		Arch:               runtime.GOARCH,
+		ComputerName:       getComputerName(),
+		UserName:           "unknown",
+		RuntimeEnvironment: detectRuntimeEnvironment(),
+		DefaultRuntime:     detectDefaultRuntime(),
+		JavaEnvironment:    make(map[string]string),
+		Path:               filepath.SplitList(os.Getenv("PATH")),
+	}
+	facts.CgroupCPUs, facts.CgroupMemory = detectCgroupLimits()
+	if currentUser, err := user.Current(); err == nil {
+		facts.UserName = currentUser.Username
+	}
+	for _, name := range javaEnvironment {
+		if value, ok := os.LookupEnv(name); ok {
+			facts.JavaEnvironment[name] = redactString(value)
+		}
+	}
+	return facts
+}
+
+// redactString removes secret assignments and the credentials of URLs
+func redactString(s string) string {
+	s = secretAssignment.ReplaceAllString(s, "$1="+redacted)
+	return urlCredentials.ReplaceAllString(s, "${1}"+redacted+"@")
+}
+
+// isSecretKey checks if a JSON key names a secret
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range secretKeyWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactValue replaces the secrets in a decoded JSON value
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if s, ok := item.(string); ok && s != "" && isSecretKey(key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	case string:
+		return redactString(v)
+	}
+	return value
+}
+
+// redactJSON replaces the secrets in a JSON document
+func redactJSON(data []byte) ([]byte, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(redactValue(value), "", "  ")
+}
+
+// redactLines redacts a log file line by line. Lines holding a JSON document,
+// as in trace and transport logs, are redacted by key.
+func redactLines(data []byte) []byte {
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var value any
+		if err := json.Unmarshal([]byte(line), &value); err == nil {
+			if encoded, err := json.Marshal(redactValue(value)); err == nil {
+				line = string(encoded)
+			}
+		} else {
+			line = redactString(line)
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// supportBundle collects the files of a support bundle in memory
+type supportBundle struct {
+	files map[string][]byte
+	order []string
+	facts *EnvironmentFacts
+}
+
+// add adds a file to the bundle
+func (b *supportBundle) add(name string, data []byte) {
+	if _, ok := b.files[name]; !ok {
+		b.order = append(b.order, name)
+	}
+	b.files[name] = data
+}
+
+// addFile reads a file into the bundle, redacting it with redact. A missing
+// file is noted in the environment facts instead of failing the bundle.
+func (b *supportBundle) addFile(name, path string, redact func([]byte) ([]byte, error)) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		data, err = redact(data)
+	}
+	if err != nil {
+		b.facts.Notes = append(b.facts.Notes, fmt.Sprintf("%s not included: %v", path, err))
+		logf("Warning: %s not included: %v\n", path, err)
+		return
+	}
+	b.add(name, data)
+}
+
+// write stores the bundle as a zip archive
+func (b *supportBundle) write(w io.Writer) error {
+	facts, err := json.MarshalIndent(b.facts, "", "  ")
+	if err != nil {
+		return err
+	}
+	archive := zip.NewWriter(w)
+	for _, name := range append([]string{"environment.json"}, b.order...) {
+		data := b.files[name]
+		if name == "environment.json" {
+			data = facts
+		}
+		entry, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// runSupportBundle implements the support-bundle command, which packs everything
+// needed to diagnose a missed or misreported runtime into one zip archive
+func runSupportBundle(args []string) int {
+	fs := flag.NewFlagSet("support-bundle", flag.ContinueOnError)
+	output := fs.String("o", "", "Archive to write (default jfind-support-<computer>-<timestamp>.zip)")
+	reportFile := fs.String("report", "", "JSON report of the scan to include")
+	traceFile := fs.String("trace", "", "Trace file of the scan to include (see -trace)")
+	configFile := fs.String("config", "", "Configuration file to include, secrets are redacted")
+	logFiles := fs.String("log", "", "Comma separated list of log files to include, e.g. stderr captures or transport logs")
+	scanPath := fs.String("path", "", "Run a traced scan of this path and include its report and trace")
+	maxDepth := fs.Int("depth", -1, "Maximum depth of the scan run with -path (-1 for unlimited)")
+	fs.Usage = func() {
+		logf("Usage: jfind support-bundle [options]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		if err == nil {
+			fs.Usage()
+		}
+		return 2
+	}
+
+	bundle := &supportBundle{files: make(map[string][]byte), facts: collectEnvironmentFacts()}
+	lines := func(data []byte) ([]byte, error) { return redactLines(data), nil }
+
+	if *reportFile != "" {
+		bundle.addFile("report.json", *reportFile, redactJSON)
+	}
+	if *traceFile != "" {
+		bundle.addFile("trace.ndjson", *traceFile, lines)
+	}
+	if *configFile != "" {
+		bundle.addFile("config.json", *configFile, redactJSON)
+	}
+	for _, path := range strings.Split(*logFiles, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			bundle.addFile("logs/"+filepath.Base(path), path, lines)
+		}
+	}
+	if *scanPath != "" {
+		if err := bundle.addScan(*scanPath, *maxDepth); err != nil {
+			logf("Error: %v\n", err)
+			return 1
+		}
+	}
+
+	name := *output
+	if name == "" {
+		name = fmt.Sprintf("jfind-support-%s-%s.zip", bundle.facts.ComputerName, time.Now().UTC().Format("20060102T150405Z"))
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		logf("Error: failed to create support bundle: %v\n", err)
+		return 1
+	}
+	if err := bundle.write(file); err != nil {
+		file.Close()
+		logf("Error: failed to write support bundle: %v\n", err)
+		return 1
+	}
+	if err := file.Close(); err != nil {
+		logf("Error: failed to write support bundle: %v\n", err)
+		return 1
+	}
+	logf("Support bundle written to %s\n", name)
+	return 0
+}
+
+// addScan scans a path with tracing enabled and adds the report and trace
+func (b *supportBundle) addScan(path string, maxDepth int) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("error resolving path: %v", err)
+	}
+	startTime := time.Now()
+	var trace bytes.Buffer
+	finder := NewJavaFinder(absPath, maxDepth, false, false)
+	finder.environment = b.facts.RuntimeEnvironment
+	finder.skipVirtualFS = finder.environment == EnvContainer
+	finder.trace = newTracer(&trace)
+	finder.defaultRuntime = b.facts.DefaultRuntime
+
+	results, err := finder.Find()
+	if err != nil {
+		b.facts.Notes = append(b.facts.Notes, fmt.Sprintf("scan of %s failed: %v", absPath, err))
+	}
+	report, err := json.MarshalIndent(buildJSONOutput(results, finder, startTime), "", "  ")
+	if err != nil {
+		return err
+	}
+	b.add("scan/report.json", report)
+	b.add("scan/trace.ndjson", redactLines(trace.Bytes()))
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// fileKey identifies a directory independent of the paths it is reachable by,
+// by its device and inode or their Windows equivalents
+type fileKey struct {
+	dev uint64
+	ino uint64
+}
+
+// linkTracker follows symbolic links to directories during the walk (-follow-symlinks).
+// Links to directories below the start paths are not followed, since the walk
+// reaches them by their own path. Outside of them, it remembers every directory
+// entered, so a link back to one of them does not walk in circles.
+type linkTracker struct {
+	starts []string // the start paths
+	roots  []string // the start paths with links resolved
+
+	mu      sync.Mutex
+	visited map[fileKey]bool
+	links   []string // the links followed
+}
+
+func newLinkTracker(roots []string) *linkTracker {
+	t := &linkTracker{starts: roots, visited: make(map[fileKey]bool)}
+	for _, root := range roots {
+		if resolved, err := filepath.EvalSymlinks(root); err == nil {
+			root = resolved
+		}
+		t.roots = append(t.roots, root)
+	}
+	return t
+}
+
+// linkedDirInfo is the target of a link to a directory under the name of the link
+type linkedDirInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (i linkedDirInfo) Name() string { return i.name }
+
+// followingFS resolves symbolic links to directories in Lstat, so the walk
+// descends into them
+type followingFS struct {
+	FileSystem
+	links *linkTracker
+}
+
+func (fsys followingFS) Lstat(name string) (os.FileInfo, error) {
+	info, err := fsys.FileSystem.Lstat(name)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return info, err
+	}
+	target, err := fsys.FileSystem.Stat(name)
+	if err != nil || !target.IsDir() {
+		// Dangling links and links to files are visited as they are
+		return info, nil
+	}
+	resolved, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return info, nil
+	}
+	inside := slices.ContainsFunc(fsys.links.roots, func(root string) bool { return withinRoot(root, resolved) })
+	if inside && !slices.Contains(fsys.links.starts, name) {
+		// The target is walked by its own path, or it is an ancestor of the link
+		return info, nil
+	}
+	if _, ok := fileIdentity(name, target); !ok {
+		// Without an identity a loop could not be told apart
+		return info, nil
+	}
+	fsys.links.mu.Lock()
+	fsys.links.links = append(fsys.links.links, name)
+	fsys.links.mu.Unlock()
+	return linkedDirInfo{FileInfo: target, name: info.Name()}, nil
+}
+
+// enter records that a directory is walked. It returns false for a link to a
+// directory that was entered before, which would walk in circles.
+func (t *linkTracker) enter(path string, info os.FileInfo) bool {
+	if t == nil {
+		return true
+	}
+	key, ok := fileIdentity(path, info)
+	if !ok {
+		return true
+	}
+	_, linked := info.(linkedDirInfo)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if linked && t.visited[key] {
+		return false
+	}
+	t.visited[key] = true
+	return true
+}
+
+// reached checks if a path is below a followed link
+func (t *linkTracker) reached(path string) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, link := range t.links {
+		if withinRoot(link, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// resetLinks starts tracking the links of a new walk if -follow-symlinks is used
+func (f *JavaFinder) resetLinks() {
+	f.links = nil
+	if f.followSymlinks {
+		f.links = newLinkTracker(f.startPaths())
+	}
+}
+
+// treeFS returns the file system the walk reads, following links to
+// directories if -follow-symlinks is used and leaving out the mount points
+// to prune
+func (f *JavaFinder) treeFS() FileSystem {
+	fsys := f.sys.FS
+	if f.links != nil {
+		fsys = followingFS{FileSystem: fsys, links: f.links}
+	}
+	if f.mounts != nil && len(f.mounts.reasons) > 0 {
+		fsys = pruningFS{FileSystem: fsys, f: f}
+	}
+	return fsys
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIdentity returns the device and inode of a file from its FileInfo
+func fileIdentity(path string, info os.FileInfo) (fileKey, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFollowSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires privileges on Windows")
+	}
+	dir := t.TempDir()
+	scan := filepath.Join(dir, "scan")
+	jvm := filepath.Join(scan, "usr", "lib", "jvm")
+	vendor := filepath.Join(dir, "vendor", "jdk-17")
+	createFakeJava(t, vendor)
+	createFakeJava(t, filepath.Join(jvm, "jdk-21"))
+	for link, target := range map[string]string{
+		filepath.Join(jvm, "default"):        vendor,                       // only reachable by the link
+		filepath.Join(jvm, "current"):        filepath.Join(jvm, "jdk-21"), // walked by its own path
+		filepath.Join(jvm, "loop"):           scan,                         // an ancestor
+		filepath.Join(jvm, "dangling"):       filepath.Join(dir, "missing"),
+		filepath.Join(vendor, "lib", "self"): vendor, // a loop outside the start path
+	} {
+		os.MkdirAll(filepath.Dir(link), 0755)
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, workers := range []int{1, 4} {
+		finder := NewJavaFinder(scan, -1, false, false)
+		finder.workers = workers
+		results, err := finder.Find()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].ViaSymlink {
+			t.Errorf("Expected links not to be followed by default with %d workers, got %v", workers, results)
+		}
+
+		finder.followSymlinks = true
+		if results, err = finder.Find(); err != nil {
+			t.Fatal(err)
+		}
+		via := make(map[string]bool)
+		for _, result := range results {
+			via[result.Path] = result.ViaSymlink
+		}
+		want := map[string]bool{
+			filepath.Join(jvm, "default", "bin", "java"): true,
+			filepath.Join(jvm, "jdk-21", "bin", "java"):  false,
+		}
+		if !maps.Equal(via, want) {
+			t.Errorf("Expected the runtime behind the link without walking in circles with %d workers, got %v", workers, via)
+		}
+	}
+
+	// A start path that is a link is followed as well
+	finder := NewJavaFinder(filepath.Join(jvm, "current"), -1, false, false)
+	finder.followSymlinks = true
+	results, err := finder.Find()
+	if err != nil || len(results) != 1 || !results[0].ViaSymlink {
+		t.Errorf("Expected the runtime below the linked start path, got %v (%v)", results, err)
+	}
+}
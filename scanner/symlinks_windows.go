@@ -0,0 +1,29 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIdentity returns the volume serial number and file index of a file. The
+// FileInfo of Windows does not carry them, so the file is opened.
+func fileIdentity(path string, info os.FileInfo) (fileKey, bool) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return fileKey{}, false
+	}
+	// Backup semantics are required to open directories
+	handle, err := syscall.CreateFile(name, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileKey{}, false
+	}
+	defer syscall.CloseHandle(handle)
+	var data syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(handle, &data); err != nil {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(data.VolumeSerialNumber), ino: uint64(data.FileIndexHigh)<<32 | uint64(data.FileIndexLow)}, true
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// FileSystem is the file system the finder walks and reads release files from
+type FileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	// ReadDirNames returns the names of the entries of a directory in any order
+	ReadDirNames(name string) ([]string, error)
+	ReadFile(name string) ([]byte, error)
+}
+
+// Executor runs the probes of java executables. The error of a command that
+// ran but failed has an ExitCode method, like *exec.ExitError.
+type Executor interface {
+	Run(cmd *exec.Cmd) error
+}
+
+// HostLookup names the computer a scan ran on
+type HostLookup interface {
+	// ComputerName returns the computer name of reports and where it came from
+	ComputerName() (name, source string)
+}
+
+// System is what the finder needs from the operating system. Tests and
+// embedders replace parts of it with fakes, see JavaFinder.SetSystem.
+type System struct {
+	Clock Clock
+	FS    FileSystem
+	Exec  Executor
+	Host  HostLookup
+}
+
+type osClock struct{}
+
+func (osClock) Now() time.Time { return time.Now() }
+
+type osFileSystem struct{}
+
+func (osFileSystem) Stat(name string) (os.FileInfo, error)  { return os.Stat(name) }
+func (osFileSystem) Lstat(name string) (os.FileInfo, error) { return os.Lstat(name) }
+func (osFileSystem) ReadFile(name string) ([]byte, error)   { return os.ReadFile(name) }
+
+func (osFileSystem) ReadDirNames(name string) ([]string, error) {
+	d, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	return d.Readdirnames(-1)
+}
+
+type osExecutor struct{}
+
+func (osExecutor) Run(cmd *exec.Cmd) error { return cmd.Run() }
+
+type osHost struct{}
+
+func (osHost) ComputerName() (string, string) { return hostIdentity.resolve() }
+
+// osSystem is the operating system jfind runs on
+var osSystem = System{Clock: osClock{}, FS: osFileSystem{}, Exec: osExecutor{}, Host: osHost{}}
+
+// SetSystem replaces the clock, file system, process execution and host name
+// lookup of the finder. Parts left nil use the operating system.
+func (f *JavaFinder) SetSystem(sys System) {
+	if sys.Clock == nil {
+		sys.Clock = osSystem.Clock
+	}
+	if sys.FS == nil {
+		sys.FS = osSystem.FS
+	}
+	if sys.Exec == nil {
+		sys.Exec = osSystem.Exec
+	}
+	if sys.Host == nil {
+		sys.Host = osSystem.Host
+	}
+	f.sys = sys
+}
+
+// exitCode returns the exit code of a command that ran but failed, 0 otherwise
+func exitCode(err error) int {
+	if exitErr, ok := err.(interface{ ExitCode() int }); ok {
+		return exitErr.ExitCode()
+	}
+	return 0
+}
+
+// readDirNames returns the sorted names of the entries of a directory
+func readDirNames(fsys FileSystem, dir string) ([]string, error) {
+	names, err := fsys.ReadDirNames(dir)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// walkFS walks the file tree below root like filepath.Walk, on fsys
+func walkFS(fsys FileSystem, root string, fn filepath.WalkFunc) error {
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkFSDir(fsys, root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkFSDir walks path and, if it is a directory, everything below it
+func walkFSDir(fsys FileSystem, path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	names, readErr := readDirNames(fsys, path)
+	if err := fn(path, info, readErr); err != nil || readErr != nil {
+		return err
+	}
+	for _, name := range names {
+		child := filepath.Join(path, name)
+		childInfo, err := fsys.Lstat(child)
+		if err != nil {
+			if err := fn(child, childInfo, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := walkFSDir(fsys, child, childInfo, fn); err != nil {
+			if !childInfo.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// fakeFileSystem holds the files and directories of a test in memory
+type fakeFileSystem map[string]fakeFile
+
+type fakeFile struct {
+	mode    os.FileMode
+	content string
+	modTime time.Time
+}
+
+type fakeFileInfo struct {
+	name string
+	file fakeFile
+}
+
+func (i fakeFileInfo) Name() string       { return i.name }
+func (i fakeFileInfo) Size() int64        { return int64(len(i.file.content)) }
+func (i fakeFileInfo) Mode() os.FileMode  { return i.file.mode }
+func (i fakeFileInfo) ModTime() time.Time { return i.file.modTime }
+func (i fakeFileInfo) IsDir() bool        { return i.file.mode.IsDir() }
+func (i fakeFileInfo) Sys() any           { return nil }
+
+// add adds a file and the directories above it
+func (m fakeFileSystem) add(path string, mode os.FileMode, content string) {
+	m[path] = fakeFile{mode: mode, content: content}
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		m[dir] = fakeFile{mode: fs.ModeDir | 0755}
+	}
+}
+
+func (m fakeFileSystem) Stat(name string) (os.FileInfo, error) { return m.Lstat(name) }
+
+func (m fakeFileSystem) Lstat(name string) (os.FileInfo, error) {
+	file, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+	}
+	return fakeFileInfo{name: filepath.Base(name), file: file}, nil
+}
+
+func (m fakeFileSystem) ReadDirNames(name string) ([]string, error) {
+	var names []string
+	for path := range m {
+		if path != name && filepath.Dir(path) == name {
+			names = append(names, filepath.Base(path))
+		}
+	}
+	return names, nil
+}
+
+func (m fakeFileSystem) ReadFile(name string) ([]byte, error) {
+	file, ok := m[name]
+	if !ok || file.mode.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return []byte(file.content), nil
+}
+
+type fakeExitError int
+
+func (e fakeExitError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e fakeExitError) ExitCode() int { return int(e) }
+
+// fakeExecutor answers probes with the properties of the runtimes by path
+type fakeExecutor map[string]string
+
+func (e fakeExecutor) Run(cmd *exec.Cmd) error {
+	properties, ok := e[cmd.Path]
+	if !ok {
+		return fakeExitError(1)
+	}
+	_, err := cmd.Stderr.Write([]byte(properties))
+	return err
+}
+
+type fakeHost string
+
+func (h fakeHost) ComputerName() (string, string) { return string(h), "test" }
+
+func TestFakeSystem(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	jdk17 := filepath.Join(root, "opt", "jdk-17", "bin", javaExecutableName())
+	broken := filepath.Join(root, "opt", "broken", "bin", javaExecutableName())
+	fsys := fakeFileSystem{}
+	fsys.add(jdk17, 0755, "")
+	fsys.add(filepath.Join(root, "opt", "jdk-17", "release"), 0644, `JAVA_VERSION="17.0.9"`+"\n")
+	fsys.add(broken, 0755, "")
+	fsys.add(filepath.Join(root, "opt", "README"), 0644, "")
+
+	finder := NewJavaFinder(root, -1, false, true)
+	finder.SetSystem(System{
+		Clock: fixedClock(time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)),
+		FS:    fsys,
+		Exec:  fakeExecutor{jdk17: "    java.version = 17.0.9\n    java.vendor = Eclipse Adoptium\n"},
+		Host:  fakeHost("build01"),
+	})
+	for _, workers := range []int{1, 4} {
+		finder.workers = workers
+		results, err := finder.Find()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 2 || results[0].Path != broken || results[1].Path != jdk17 {
+			t.Fatalf("Expected the runtimes of the fake file system with %d workers, got %v", workers, results)
+		}
+		if results[0].Status != ProbeFailed || results[0].ReturnCode != 1 {
+			t.Errorf("Expected the probe to fail with exit code 1, got %+v", results[0])
+		}
+		if results[1].Status != ProbeOK || results[1].Properties.Version != "17.0.9" || results[1].Release["JAVA_VERSION"] != "17.0.9" {
+			t.Errorf("Expected the fake probe output and release file, got %+v", results[1])
+		}
+
+		output := buildJSONOutput(results, finder, time.Date(2025, 8, 1, 11, 59, 30, 0, time.UTC))
+		if meta := output.Meta; meta.ScanTimestamp != "2025-08-01T12:00:00Z" || meta.ScanDuration != "PT30S" || meta.ComputerName != "build01" {
+			t.Errorf("Expected the time of the fake clock and the fake host name, got %+v", meta)
+		}
+		if lag := output.Runtimes[1].PatchLagQuarters; lag == nil || *lag != 7 {
+			t.Errorf("Expected the patch level as of the fake clock, got %v", lag)
+		}
+	}
+}
+
+func TestWalkFS(t *testing.T) {
+	fsys := fakeFileSystem{}
+	root := filepath.FromSlash("/fake")
+	for _, path := range []string{"b/skipped/file", "a/file", "b/file", "c"} {
+		fsys.add(filepath.Join(root, filepath.FromSlash(path)), 0644, "")
+	}
+	var visited []string
+	err := walkFS(fsys, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		visited = append(visited, filepath.ToSlash(rel))
+		if info.Name() == "skipped" {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(visited, " "); got != ". a a/file b b/file b/skipped c" {
+		t.Errorf("Expected the order of filepath.Walk, got %s", got)
+	}
+	if err := walkFS(fsys, filepath.Join(root, "missing"), func(path string, info os.FileInfo, err error) error { return err }); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the error of a missing root, got %v", err)
+	}
+}
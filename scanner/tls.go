@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// tlsProtocols are the protocol versions a runtime may support, oldest first
+var tlsProtocols = []string{"SSLv3", "TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}
+
+// legacyTLSProtocols are considered insecure by RFC 8996
+var legacyTLSProtocols = []string{"SSLv3", "TLSv1", "TLSv1.1"}
+
+// TLSInfo is the enrichment of the tls enricher
+type TLSInfo struct {
+	SecurityFile      string   `json:"security_file"`
+	EnabledProtocols  []string `json:"enabled_protocols"`
+	DisabledProtocols []string `json:"disabled_protocols"`
+	LegacyTLSEnabled  bool     `json:"legacy_tls_enabled"`
+}
+
+// tlsEnricher reports the TLS protocol versions a runtime enables by default,
+// read from its java.security file without running it. Protocols can still be
+// re-enabled per application with -Djdk.tls.client.protocols or a security
+// properties override, which a scan cannot see.
+type tlsEnricher struct{}
+
+// Name returns the name of the enricher
+func (t *tlsEnricher) Name() string {
+	return "tls"
+}
+
+// Enrich returns the enabled protocols of the runtime
+func (t *tlsEnricher) Enrich(result *JavaResult) (any, error) {
+	version, _ := runtimeVersion(result)
+	if version == "" {
+		return nil, nil
+	}
+	major, update := parseJavaVersion(version)
+
+	path, ok := findSecurityFile(result.Path)
+	if !ok {
+		return nil, fmt.Errorf("java.security not found")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	props, err := parseSecurityProperties(bufio.NewScanner(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	info := &TLSInfo{SecurityFile: path, EnabledProtocols: []string{}, DisabledProtocols: []string{}}
+	disabled := disabledAlgorithms(props["jdk.tls.disabledAlgorithms"])
+	for _, protocol := range supportedTLSProtocols(major, update) {
+		if slices.Contains(disabled, protocol) {
+			info.DisabledProtocols = append(info.DisabledProtocols, protocol)
+			continue
+		}
+		info.EnabledProtocols = append(info.EnabledProtocols, protocol)
+		if slices.Contains(legacyTLSProtocols, protocol) {
+			info.LegacyTLSEnabled = true
+		}
+	}
+	return info, nil
+}
+
+// supportedTLSProtocols returns the protocols implemented by a Java version.
+// TLS 1.3 was added in Java 11 and backported to 8u261.
+func supportedTLSProtocols(major, update int) []string {
+	if major >= 11 || (major == 8 && update >= 261) {
+		return tlsProtocols
+	}
+	return tlsProtocols[:len(tlsProtocols)-1]
+}
+
+// findSecurityFile locates java.security relative to a java executable. It is in
+// conf/security since Java 9 and in lib/security before.
+func findSecurityFile(javaPath string) (string, bool) {
+	home := filepath.Dir(filepath.Dir(javaPath))
+	for _, rel := range []string{
+		filepath.Join("conf", "security"),
+		filepath.Join("lib", "security"),
+		filepath.Join("jre", "lib", "security"),
+	} {
+		path := filepath.Join(home, rel, "java.security")
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// parseSecurityProperties parses a java.security file, joining lines continued
+// with a trailing backslash
+func parseSecurityProperties(scanner *bufio.Scanner) (map[string]string, error) {
+	props := make(map[string]string)
+	var logical strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if logical.Len() == 0 && (line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!")) {
+			continue
+		}
+		if continued, ok := strings.CutSuffix(line, `\`); ok {
+			logical.WriteString(continued)
+			continue
+		}
+		logical.WriteString(line)
+
+		key, value, found := strings.Cut(logical.String(), "=")
+		if found {
+			props[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		logical.Reset()
+	}
+	return props, scanner.Err()
+}
+
+// disabledAlgorithms returns the algorithm names of a disabledAlgorithms list,
+// dropping constraints such as "keySize < 1024" and include directives
+func disabledAlgorithms(value string) []string {
+	var names []string
+	for _, entry := range strings.Split(value, ",") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 || fields[0] == "include" {
+			continue
+		}
+		names = append(names, fields[0])
+	}
+	return names
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Trace event types, one per decision the finder makes about a path
+const (
+	TraceEntered          = "entered"
+	TraceSkippedDepth     = "skipped-by-depth"
+	TraceSkippedVirtualFS = "skipped-virtual-fs"
+	TraceSkippedShard     = "skipped-by-shard"
+	TraceSkippedSize      = "skipped-by-size"
+	TraceDeferred         = "deferred"
+	TraceExcluded         = "excluded"
+	TraceSymlinkLoop      = "symlink-loop"
+	TracePrunedMount      = "pruned-mount"
+	TracePanic            = "panic"
+	TracePermissionDenied = "permission-denied"
+	TraceError            = "error"
+	TraceMatched          = "matched"
+	TraceNotExecutable    = "not-executable"
+	TraceOutsideRoot      = "outside-root"
+	TraceStale            = "stale-index-entry"
+	TraceRemoved          = "removed"
+)
+
+// TraceEvent is one line of the trace written with -trace
+type TraceEvent struct {
+	Time   string `json:"ts"`
+	Source string `json:"source"`
+	Event  string `json:"event"`
+	Path   string `json:"path"`
+	Depth  int    `json:"depth"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// tracer writes trace events as newline delimited JSON. A nil tracer discards
+// all events, so callers don't need to check if tracing is enabled.
+type tracer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// newTracer returns a tracer writing to w
+func newTracer(w io.Writer) *tracer {
+	return &tracer{enc: json.NewEncoder(w)}
+}
+
+// event records a decision about a path. Write errors are kept and reported
+// by err, the scan itself goes on.
+func (t *tracer) event(source, event, path string, depth int, reason string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return
+	}
+	t.err = t.enc.Encode(TraceEvent{
+		Time:   time.Now().UTC().Format(time.RFC3339Nano),
+		Source: source,
+		Event:  event,
+		Path:   path,
+		Depth:  depth,
+		Reason: reason,
+	})
+}
+
+// Err returns the first error writing the trace
+func (t *tracer) Err() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
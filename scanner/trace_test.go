@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	root := t.TempDir()
+	found := createFakeJava(t, filepath.Join(root, "jdk-17"))
+	deep := createFakeJava(t, filepath.Join(root, "a", "b", "jdk-21"))
+	notExecutable := filepath.Join(root, "broken", "bin", "java")
+	if runtime.GOOS != "windows" {
+		createFakeJava(t, filepath.Join(root, "broken"))
+		if err := os.Chmod(notExecutable, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	finder := NewJavaFinder(root, 3, false, false)
+	finder.trace = newTracer(&buf)
+	if _, err := finder.Find(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := finder.trace.Err(); err != nil {
+		t.Fatalf("Unexpected trace error: %v", err)
+	}
+
+	events := make(map[string]string)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var event TraceEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Invalid trace line %s: %v", scanner.Text(), err)
+		}
+		events[event.Path] = event.Event
+	}
+
+	expected := map[string]string{
+		root:  TraceEntered,
+		found: TraceMatched,
+		filepath.Join(root, "a", "b", "jdk-21", "bin"): TraceSkippedDepth,
+	}
+	if runtime.GOOS != "windows" {
+		expected[notExecutable] = TraceNotExecutable
+	}
+	for path, event := range expected {
+		if events[path] != event {
+			t.Errorf("Expected %s for %s, got %q", event, path, events[path])
+		}
+	}
+	if _, ok := events[deep]; ok {
+		t.Errorf("Expected no event below a directory skipped by depth")
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Interaction is one recorded HTTP exchange with the collector
+type Interaction struct {
+	Time         string            `json:"time"`
+	Method       string            `json:"method"`
+	URL          string            `json:"url"`
+	RequestBody  string            `json:"request_body,omitempty"`
+	Status       int               `json:"status,omitempty"`
+	Header       map[string]string `json:"header,omitempty"`
+	ResponseBody string            `json:"response_body,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// recordingTransport passes requests on and appends every exchange as one JSON
+// line to a transport log
+type recordingTransport struct {
+	next http.RoundTripper
+	mu   sync.Mutex
+	w    io.Writer
+}
+
+// RoundTrip sends the request and records it together with the response
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	interaction := Interaction{
+		Time:   time.Now().UTC().Format(time.RFC3339Nano),
+		Method: req.Method,
+		URL:    req.URL.String(),
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		interaction.RequestBody = string(body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		interaction.Error = err.Error()
+	} else {
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return nil, readErr
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		interaction.Status = resp.StatusCode
+		interaction.Header = map[string]string{"Content-Type": resp.Header.Get("Content-Type")}
+		interaction.ResponseBody = string(body)
+	}
+
+	if writeErr := r.write(interaction); writeErr != nil {
+		logf("Warning: failed to record transport: %v\n", writeErr)
+	}
+	return resp, err
+}
+
+// write appends an interaction to the log
+func (r *recordingTransport) write(interaction Interaction) error {
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.w.Write(append(line, '\n'))
+	return err
+}
+
+// replayTransport answers requests from a transport log without a network
+// connection. Interactions are replayed in the order they were recorded.
+type replayTransport struct {
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// loadTransportLog reads a transport log written with -record
+func loadTransportLog(path string) ([]Interaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transport log %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var interactions []Interaction
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("invalid interaction in line %d of %s: %v", line, path, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transport log %s: %v", path, err)
+	}
+	return interactions, nil
+}
+
+// RoundTrip returns the next recorded response for the method and URL of the request
+func (r *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.interactions {
+		if interaction.Method != req.Method || interaction.URL != req.URL.String() {
+			continue
+		}
+		r.interactions = append(r.interactions[:i], r.interactions[i+1:]...)
+		if interaction.Error != "" {
+			return nil, fmt.Errorf("replayed error: %s", interaction.Error)
+		}
+
+		header := make(http.Header)
+		for key, value := range interaction.Header {
+			header.Set(key, value)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+			StatusCode:    interaction.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader([]byte(interaction.ResponseBody))),
+			ContentLength: int64(len(interaction.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction left for %s %s", req.Method, req.URL)
+}
+
+// newHTTPClient returns the client used to talk to the collector. With a record
+// path every exchange is appended to that file, with a replay path responses
+// come from a previously recorded file. The returned close function must be
+// called when the client is no longer used.
+func newHTTPClient(record, replay string) (*http.Client, func() error, error) {
+	noop := func() error { return nil }
+	switch {
+	case record != "" && replay != "":
+		return nil, noop, fmt.Errorf("-record and -replay cannot be used together")
+	case replay != "":
+		interactions, err := loadTransportLog(replay)
+		if err != nil {
+			return nil, noop, err
+		}
+		return &http.Client{Transport: &replayTransport{interactions: interactions}}, noop, nil
+	case record != "":
+		file, err := os.OpenFile(record, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, noop, fmt.Errorf("failed to open transport log %s: %v", record, err)
+		}
+		transport := &recordingTransport{next: http.DefaultTransport, w: file}
+		return &http.Client{Transport: transport}, file.Close, nil
+	default:
+		return http.DefaultClient, noop, nil
+	}
+}
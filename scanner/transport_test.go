@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	received := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result": "ok", "scan_id": 42}`))
+	}))
+	defer server.Close()
+
+	log := filepath.Join(t.TempDir(), "transport.log")
+	client, closeLog, err := newHTTPClient(log, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := sendJSON(client, []byte(`{"meta": {}}`), server.URL); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	closeLog()
+	if received != `{"meta": {}}` {
+		t.Errorf("Expected the server to receive the payload, got %q", received)
+	}
+
+	interactions, err := loadTransportLog(log)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(interactions) != 1 || interactions[0].Status != 200 || interactions[0].RequestBody != `{"meta": {}}` {
+		t.Fatalf("Expected one recorded interaction, got %+v", interactions)
+	}
+
+	// Replay works without the server
+	server.Close()
+	client, _, err = newHTTPClient("", log)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, err := client.Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 || string(body) != `{"result": "ok", "scan_id": 42}` {
+		t.Errorf("Expected replayed response, got %d %s", resp.StatusCode, body)
+	}
+
+	// Every interaction is replayed once
+	if _, err := client.Post(server.URL, "application/json", nil); err == nil {
+		t.Error("Expected error after all interactions were replayed")
+	}
+}
+
+func TestReplayServerError(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "transport.log")
+	client, closeLog, _ := newHTTPClient(log, "")
+	client.Transport.(*recordingTransport).write(Interaction{
+		Method:       "POST",
+		URL:          "http://collector/api/jfind",
+		Status:       422,
+		ResponseBody: "invalid",
+	})
+	closeLog()
+
+	client, _, err := newHTTPClient("", log)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = sendJSON(client, []byte("{}"), "http://collector/api/jfind")
+	if err == nil || err.Error() != "server returned 422 Unprocessable Entity: invalid" {
+		t.Errorf("Expected replayed server error, got %v", err)
+	}
+}
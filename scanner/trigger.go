@@ -0,0 +1,289 @@
+package main
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TriggerListener configures the HTTP listener of jfind serve on which an
+// external system, e.g. a patch orchestration, starts a scan by POSTing a
+// trigger instead of waiting for the schedule
+type TriggerListener struct {
+	// Address is the host and port to listen on, e.g. 127.0.0.1:8089
+	Address string `json:"address"`
+
+	// TokenFile holds the bearer token every request must send. The token is
+	// kept out of the configuration, which support bundles include.
+	TokenFile string `json:"token_file"`
+
+	// TLSCert and TLSKey are the PEM files of the certificate to serve HTTPS
+	// with, recommended unless the address is on the loopback interface
+	TLSCert string `json:"tls_cert,omitempty"`
+	TLSKey  string `json:"tls_key,omitempty"`
+}
+
+// ScanProfile is a named scan a trigger can start. Fields that are not set
+// are taken from the flags of jfind serve.
+type ScanProfile struct {
+	Paths []string `json:"paths,omitempty"`
+	Depth *int     `json:"depth,omitempty"`
+	Eval  *bool    `json:"eval,omitempty"`
+}
+
+// Statuses of a triggered scan
+const (
+	TriggerQueued    = "queued"
+	TriggerRunning   = "running"
+	TriggerCompleted = "completed"
+	TriggerFailed    = "failed"
+)
+
+const (
+	// maxTriggerQueue is how many triggered scans may wait for their turn
+	maxTriggerQueue = 8
+
+	// maxTriggeredScans is how many triggered scans are kept for status requests
+	maxTriggeredScans = 100
+
+	// minTriggerTokenLength keeps guessable tokens out
+	minTriggerTokenLength = 16
+)
+
+// TriggeredScan is a scan started by a trigger, as reported to its caller
+type TriggeredScan struct {
+	ScanID    string `json:"scan_id"`
+	Profile   string `json:"profile,omitempty"`
+	Status    string `json:"status"`
+	Triggered string `json:"triggered"`
+	Results   int    `json:"results,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// parse validates the configuration of the listener
+func (t *TriggerListener) parse() error {
+	if _, _, err := net.SplitHostPort(t.Address); err != nil {
+		return fmt.Errorf("invalid address %q of trigger, use host:port: %v", t.Address, err)
+	}
+	if t.TokenFile == "" {
+		return fmt.Errorf("trigger requires token_file, the listener does not accept unauthenticated triggers")
+	}
+	if (t.TLSCert == "") != (t.TLSKey == "") {
+		return fmt.Errorf("trigger requires both tls_cert and tls_key or neither")
+	}
+	return nil
+}
+
+// parse validates a profile and makes its paths absolute
+func (p *ScanProfile) parse(name string) error {
+	if name == "" {
+		return fmt.Errorf("scan profile without a name")
+	}
+	for i, path := range p.Paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("invalid path %q of scan profile %q: %v", path, name, err)
+		}
+		p.Paths[i] = abs
+	}
+	if p.Depth != nil && *p.Depth < -1 {
+		return fmt.Errorf("invalid depth %d of scan profile %q, use -1 for unlimited", *p.Depth, name)
+	}
+	return nil
+}
+
+// readTriggerToken reads the bearer token of the listener
+func readTriggerToken(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trigger token: %v", err)
+	}
+	token := []byte(strings.TrimSpace(string(data)))
+	if len(token) < minTriggerTokenLength {
+		return nil, fmt.Errorf("trigger token in %s is shorter than %d characters", path, minTriggerTokenLength)
+	}
+	return token, nil
+}
+
+// triggerQueue accepts the triggers of the listener and hands them to the
+// scan loop of jfind serve, which reports back how they went
+type triggerQueue struct {
+	token    []byte
+	profiles map[string]ScanProfile
+	pending  chan *TriggeredScan
+	now      func() time.Time
+
+	mu    sync.Mutex
+	scans map[string]*TriggeredScan
+	order []string // scan IDs, oldest first
+}
+
+// newTriggerQueue returns the queue of a listener with its token
+func newTriggerQueue(token []byte, profiles map[string]ScanProfile) *triggerQueue {
+	return &triggerQueue{
+		token:    token,
+		profiles: profiles,
+		pending:  make(chan *TriggeredScan, maxTriggerQueue),
+		now:      time.Now,
+		scans:    make(map[string]*TriggeredScan),
+	}
+}
+
+// handler serves POST /scans, which queues a scan and returns its ID, and
+// GET /scans/{id}, which returns the status of a triggered scan
+func (q *triggerQueue) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scans", q.trigger)
+	mux.HandleFunc("GET /scans/{id}", q.status)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !q.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="jfind"`)
+			writeTriggerError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// authorized checks the bearer token of a request in constant time
+func (q *triggerQueue) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), q.token) == 1
+}
+
+// trigger queues a scan of the profile named in the request body, the flags
+// of jfind serve without one
+func (q *triggerQueue) trigger(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Profile string `json:"profile"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxIPCRequestSize)).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		writeTriggerError(w, http.StatusBadRequest, fmt.Sprintf("invalid trigger: %v", err))
+		return
+	}
+	if _, ok := q.profiles[request.Profile]; request.Profile != "" && !ok {
+		writeTriggerError(w, http.StatusNotFound, fmt.Sprintf("unknown scan profile %q", request.Profile))
+		return
+	}
+
+	scan := &TriggeredScan{
+		ScanID:    newScanID(),
+		Profile:   request.Profile,
+		Status:    TriggerQueued,
+		Triggered: q.now().UTC().Format(time.RFC3339),
+	}
+	// The scan loop may pick the scan up right away, so it is known first
+	q.mu.Lock()
+	select {
+	case q.pending <- scan:
+	default:
+		q.mu.Unlock()
+		writeTriggerError(w, http.StatusTooManyRequests, fmt.Sprintf("%d triggered scans are waiting already", maxTriggerQueue))
+		return
+	}
+	q.scans[scan.ScanID] = scan
+	q.order = append(q.order, scan.ScanID)
+	if len(q.order) > maxTriggeredScans {
+		delete(q.scans, q.order[0])
+		q.order = q.order[1:]
+	}
+	response := *scan
+	q.mu.Unlock()
+	writeTriggerJSON(w, http.StatusAccepted, response)
+}
+
+// status reports a triggered scan
+func (q *triggerQueue) status(w http.ResponseWriter, r *http.Request) {
+	q.mu.Lock()
+	scan, ok := q.scans[r.PathValue("id")]
+	var response TriggeredScan
+	if ok {
+		response = *scan
+	}
+	q.mu.Unlock()
+	if !ok {
+		writeTriggerError(w, http.StatusNotFound, "unknown scan")
+		return
+	}
+	writeTriggerJSON(w, http.StatusOK, response)
+}
+
+// profile returns the paths, depth and evaluation of a triggered scan
+func (q *triggerQueue) profile(scan *TriggeredScan, paths []string, depth int, eval bool) ([]string, int, bool) {
+	profile := q.profiles[scan.Profile]
+	if len(profile.Paths) > 0 {
+		paths = profile.Paths
+	}
+	if profile.Depth != nil {
+		depth = *profile.Depth
+	}
+	if profile.Eval != nil {
+		eval = *profile.Eval
+	}
+	return paths, depth, eval
+}
+
+// start marks a triggered scan as running
+func (q *triggerQueue) start(scan *TriggeredScan) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	scan.Status = TriggerRunning
+}
+
+// finish records how a triggered scan ended
+func (q *triggerQueue) finish(scan *TriggeredScan, results int, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	scan.Status, scan.Results = TriggerCompleted, results
+	if err != nil {
+		scan.Status, scan.Error = TriggerFailed, err.Error()
+	}
+}
+
+// writeTriggerJSON writes a JSON response
+func writeTriggerJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeTriggerError writes an error response
+func writeTriggerError(w http.ResponseWriter, status int, message string) {
+	writeTriggerJSON(w, status, map[string]string{"error": message})
+}
+
+// serveTriggers listens for triggers until the listener fails
+func serveTriggers(cfg *TriggerListener, q *triggerQueue) (func() error, error) {
+	if cfg.TLSCert != "" {
+		if _, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey); err != nil {
+			return nil, fmt.Errorf("failed to load the certificate of the trigger listener: %v", err)
+		}
+	}
+	listener, err := net.Listen("tcp", cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for triggers on %s: %v", cfg.Address, err)
+	}
+	server := &http.Server{Handler: q.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		var err error
+		if cfg.TLSCert != "" {
+			err = server.ServeTLS(listener, cfg.TLSCert, cfg.TLSKey)
+		} else {
+			err = server.Serve(listener)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			logf("Warning: trigger listener stopped: %v\n", err)
+		}
+	}()
+	return server.Close, nil
+}
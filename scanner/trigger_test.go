@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTriggerQueue(t *testing.T) {
+	depth := 3
+	q := newTriggerQueue([]byte("0123456789abcdef"), map[string]ScanProfile{
+		"patched": {Paths: []string{"/opt", "/usr/lib/jvm"}, Depth: &depth},
+	})
+	server := httptest.NewServer(q.handler())
+	defer server.Close()
+
+	request := func(method, path, token, body string) (*http.Response, map[string]any) {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var response map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		return resp, response
+	}
+
+	for _, token := range []string{"", "0123456789abcdeX"} {
+		if resp, _ := request(http.MethodPost, "/scans", token, ""); resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("Expected token %q to be rejected, got %s", token, resp.Status)
+		}
+	}
+	if resp, response := request(http.MethodPost, "/scans", string(q.token), `{"profile": "unknown"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected an unknown profile to be rejected, got %s %v", resp.Status, response)
+	}
+
+	resp, response := request(http.MethodPost, "/scans", string(q.token), `{"profile": "patched"}`)
+	id, _ := response["scan_id"].(string)
+	if resp.StatusCode != http.StatusAccepted || id == "" || response["status"] != TriggerQueued {
+		t.Fatalf("Expected the scan to be queued, got %s %v", resp.Status, response)
+	}
+
+	// The scan loop picks the scan up and runs it with the profile
+	scan := <-q.pending
+	paths, gotDepth, eval := q.profile(scan, []string{"/"}, -1, true)
+	if len(paths) != 2 || gotDepth != 3 || !eval {
+		t.Errorf("Expected the paths and depth of the profile and the evaluation of the flags, got %v %d %v", paths, gotDepth, eval)
+	}
+	q.start(scan)
+	if _, response := request(http.MethodGet, "/scans/"+id, string(q.token), ""); response["status"] != TriggerRunning {
+		t.Errorf("Expected the scan to be running, got %v", response)
+	}
+	q.finish(scan, 2, errors.New("walk failed"))
+	if _, response := request(http.MethodGet, "/scans/"+id, string(q.token), ""); response["status"] != TriggerFailed || response["error"] != "walk failed" {
+		t.Errorf("Expected the scan to have failed, got %v", response)
+	}
+	if resp, _ := request(http.MethodGet, "/scans/unknown", string(q.token), ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected an unknown scan to be not found, got %s", resp.Status)
+	}
+
+	// A trigger without a profile scans the flags, until the queue is full
+	for range maxTriggerQueue {
+		if resp, _ := request(http.MethodPost, "/scans", string(q.token), ""); resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("Expected the scan to be queued, got %s", resp.Status)
+		}
+	}
+	if resp, _ := request(http.MethodPost, "/scans", string(q.token), ""); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected a full queue to reject the trigger, got %s", resp.Status)
+	}
+}
+
+func TestTriggerConfig(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	writeTestFile(t, tokenFile, "short\n")
+	if _, err := readTriggerToken(tokenFile); err == nil {
+		t.Error("Expected a short token to be rejected")
+	}
+	writeTestFile(t, tokenFile, "0123456789abcdef\n")
+	if token, err := readTriggerToken(tokenFile); err != nil || string(token) != "0123456789abcdef" {
+		t.Errorf("Expected the token without the newline, got %q: %v", token, err)
+	}
+
+	for _, config := range []string{
+		`{"version": 2, "trigger": {"address": "8089", "token_file": "token"}}`,
+		`{"version": 2, "trigger": {"address": ":8089"}}`,
+		`{"version": 2, "trigger": {"address": ":8089", "token_file": "token", "tls_cert": "cert.pem"}}`,
+		`{"version": 2, "scan_profiles": {"deep": {"depth": -2}}}`,
+	} {
+		path := filepath.Join(dir, "config.json")
+		writeTestFile(t, path, config)
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("Expected %s to be rejected", config)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import "path/filepath"
+
+// Trust levels of the location of a runtime, see trusted_paths and untrusted_paths
+const (
+	TrustTrusted   = "trusted"
+	TrustUntrusted = "untrusted"
+)
+
+// TrustDecision records whether a runtime was run to evaluate it, and why
+type TrustDecision struct {
+	Level   string `json:"level"`
+	Pattern string `json:"pattern,omitempty"` // the matching pattern, empty if none matched
+	Exec    bool   `json:"exec"`
+}
+
+// evalTrust decides per runtime whether -eval may execute it. Runtimes below
+// untrusted paths, e.g. downloads, temporary directories or network shares, are
+// only evaluated statically from their release file. If trusted paths are
+// configured, they are an allowlist and runtimes anywhere else are untrusted too.
+type evalTrust struct {
+	trusted   []string
+	untrusted []string
+}
+
+// newEvalTrust creates the trust rules from the configured patterns, nil if there are none
+func newEvalTrust(trusted, untrusted []string) *evalTrust {
+	if len(trusted) == 0 && len(untrusted) == 0 {
+		return nil
+	}
+	t := &evalTrust{}
+	for _, pattern := range trusted {
+		t.trusted = append(t.trusted, expandPattern(pattern))
+	}
+	for _, pattern := range untrusted {
+		t.untrusted = append(t.untrusted, expandPattern(pattern))
+	}
+	return t
+}
+
+// decide returns the trust level of a java executable. Untrusted patterns win
+// over trusted ones, so a trusted /home/** can exclude /home/*/Downloads/**.
+// A symbolic link is decided on the executable it resolves to as well, and is
+// untrusted if either is, so a link in a trusted directory does not run an
+// executable from an untrusted one.
+func (t *evalTrust) decide(path string) *TrustDecision {
+	decision := t.decidePath(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil && resolved != path && decision.Exec {
+		decision = t.decidePath(resolved)
+	}
+	return decision
+}
+
+// decidePath returns the trust level of a path by the patterns alone
+func (t *evalTrust) decidePath(path string) *TrustDecision {
+	name := filepath.ToSlash(path)
+	for _, pattern := range t.untrusted {
+		if matchGlob(pattern, name) {
+			return &TrustDecision{Level: TrustUntrusted, Pattern: pattern}
+		}
+	}
+	for _, pattern := range t.trusted {
+		if matchGlob(pattern, name) {
+			return &TrustDecision{Level: TrustTrusted, Pattern: pattern, Exec: true}
+		}
+	}
+	if len(t.trusted) > 0 {
+		return &TrustDecision{Level: TrustUntrusted}
+	}
+	return &TrustDecision{Level: TrustTrusted, Exec: true}
+}
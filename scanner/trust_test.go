@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestEvalTrustDecide(t *testing.T) {
+	trust := newEvalTrust([]string{"/opt/**", "/home/**"}, []string{"/home/*/Downloads/**"})
+	for path, want := range map[string]TrustDecision{
+		"/opt/jdk-17/bin/java":                {Level: TrustTrusted, Pattern: "/opt/**", Exec: true},
+		"/home/alice/Downloads/jdk/bin/java":  {Level: TrustUntrusted, Pattern: "/home/*/Downloads/**"},
+		"/home/alice/.sdkman/jdk-21/bin/java": {Level: TrustTrusted, Pattern: "/home/**", Exec: true},
+		"/tmp/jdk/bin/java":                   {Level: TrustUntrusted},
+	} {
+		if got := trust.decide(path); *got != want {
+			t.Errorf("Expected %+v for %s, got %+v", want, path, *got)
+		}
+	}
+
+	// Without trusted paths, only the untrusted ones are restricted
+	trust = newEvalTrust(nil, []string{"/tmp/**"})
+	if got := trust.decide("/usr/lib/jvm/java-17/bin/java"); !got.Exec || got.Level != TrustTrusted {
+		t.Errorf("Expected a trusted runtime outside the untrusted paths, got %+v", got)
+	}
+	if newEvalTrust(nil, nil) != nil {
+		t.Error("Expected no trust rules without patterns")
+	}
+}
+
+func TestUntrustedRuntimeNotExecuted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake java executable is a shell script")
+	}
+	root := t.TempDir()
+	trusted := createFakeJava(t, filepath.Join(root, "opt", "jdk-17"))
+	untrusted := createFakeJava(t, filepath.Join(root, "downloads", "jdk-17"))
+	writeTestFile(t, filepath.Join(root, "downloads", "jdk-17", "release"), "IMPLEMENTOR=\"Oracle Corporation\"\nJAVA_VERSION=\"17.0.9\"\n")
+
+	finder := NewJavaFinder(root, -1, false, true)
+	finder.trust = newEvalTrust([]string{filepath.Join(root, "opt", "**")}, nil)
+	finder.benchmarkRuns = 1
+
+	result := finder.newResult(context.Background(), untrusted)
+	if result.Status != ProbeStatic || result.Properties.Version != "17.0.9" || result.Startup != nil {
+		t.Errorf("Expected a static evaluation without benchmark, got %+v", result)
+	}
+	if result.Trust == nil || result.Trust.Exec || result.Trust.Level != TrustUntrusted {
+		t.Errorf("Expected the untrusted decision to be recorded, got %+v", result.Trust)
+	}
+
+	result = finder.newResult(context.Background(), trusted)
+	if result.Status == ProbeStatic || result.Status == ProbeNotExecuted || result.Startup == nil {
+		t.Errorf("Expected the trusted runtime to be executed, got %+v", result)
+	}
+	if output := buildJSONOutput([]*JavaResult{result}, finder, time.Now()); output.Runtimes[0].EvalTrust == nil {
+		t.Error("Expected eval_trust in the JSON output")
+	}
+}
+
+func TestEvalTrustResolvesLinks(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloaded := createFakeJava(t, filepath.Join(root, "downloads", "jdk-17"))
+	installed := createFakeJava(t, filepath.Join(root, "opt", "jdk-21"))
+	for name, target := range map[string]string{"java-17": downloaded, "java-21": installed} {
+		if err := os.Symlink(target, filepath.Join(root, "opt", name)); err != nil {
+			t.Skip("symbolic links are not supported:", err)
+		}
+	}
+	trusted := filepath.ToSlash(filepath.Join(root, "opt")) + "/**"
+	untrusted := filepath.ToSlash(filepath.Join(root, "downloads")) + "/**"
+
+	// A link in a trusted directory to an untrusted executable is untrusted
+	trust := newEvalTrust([]string{trusted}, []string{untrusted})
+	if got := trust.decide(filepath.Join(root, "opt", "java-17")); got.Exec || got.Pattern != untrusted {
+		t.Errorf("Expected the link to the download to be untrusted by %s, got %+v", untrusted, got)
+	}
+	// With an allowlist alone, the executable must be in it as well
+	trust = newEvalTrust([]string{trusted}, nil)
+	if got := trust.decide(filepath.Join(root, "opt", "java-17")); got.Exec {
+		t.Errorf("Expected the link to an executable outside the trusted paths to be untrusted, got %+v", got)
+	}
+	if got := trust.decide(filepath.Join(root, "opt", "java-21")); !got.Exec {
+		t.Errorf("Expected the link to a trusted executable to be trusted, got %+v", got)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// latestTZDataVersion is the newest IANA time zone database release known to this
+// build, used when the newest release cannot be fetched
+const latestTZDataVersion = "2025b"
+
+// defaultTZDataURL is the file of the IANA distribution holding the name of its newest release
+const defaultTZDataURL = "https://data.iana.org/time-zones/tzdb/version"
+
+// tzdataVersionPattern matches IANA release names such as 2024a, the year and
+// a letter, which continues with aa after z
+var tzdataVersionPattern = regexp.MustCompile(`^(\d{4})([a-z]+)$`)
+
+// tzdataOlder reports whether a release came out before another one. A
+// release name that is not understood is never older.
+func tzdataOlder(version, than string) bool {
+	v, t := tzdataVersionPattern.FindStringSubmatch(version), tzdataVersionPattern.FindStringSubmatch(than)
+	if v == nil || t == nil {
+		return false
+	}
+	if v[1] != t[1] {
+		vYear, _ := strconv.Atoi(v[1])
+		tYear, _ := strconv.Atoi(t[1])
+		return vYear < tYear
+	}
+	if len(v[2]) != len(t[2]) {
+		return len(v[2]) < len(t[2])
+	}
+	return v[2] < t[2]
+}
+
+// TZDataInfo is the enrichment of the tzdata enricher
+type TZDataInfo struct {
+	Version  string `json:"version"`
+	File     string `json:"file"`
+	Latest   string `json:"latest"`
+	Outdated bool   `json:"outdated"`
+}
+
+// tzdataEnricher reads the version of the time zone database bundled with a
+// runtime: lib/tzdb.dat since Java 8, lib/zi/ZoneInfoMappings before. Both are
+// replaced by the TZUpdater tool, so an updated runtime reports its new version.
+type tzdataEnricher struct {
+	latest string // release of the configuration, empty to fetch it from source
+	source string
+	api    *vendorAPI
+}
+
+// newTZDataEnricher creates the tzdata enricher with the latest release of the
+// configuration, a release name or the URL of a file holding one, by default
+// the version file of the IANA distribution
+func newTZDataEnricher(cfg *Config) (Enricher, error) {
+	latest := cfg.TZDataLatest
+	switch {
+	case latest == "":
+		return &tzdataEnricher{source: defaultTZDataURL, api: cfg.vendorAPI()}, nil
+	case strings.HasPrefix(latest, "http://") || strings.HasPrefix(latest, "https://"):
+		return &tzdataEnricher{source: latest, api: cfg.vendorAPI()}, nil
+	case tzdataVersionPattern.MatchString(latest):
+		return &tzdataEnricher{latest: latest}, nil
+	}
+	return nil, fmt.Errorf("invalid tzdata_latest %q (use a release name such as %s or a URL)", latest, latestTZDataVersion)
+}
+
+// latestVersion returns the newest release, fetched through the vendor API
+// cache, or the one known to this build if it cannot be fetched
+func (t *tzdataEnricher) latestVersion() string {
+	if t.latest != "" {
+		return t.latest
+	}
+	body, err := t.api.get(t.source)
+	if version := strings.TrimSpace(string(body)); err == nil && tzdataVersionPattern.MatchString(version) {
+		return version
+	}
+	return latestTZDataVersion
+}
+
+// Name returns the name of the enricher
+func (t *tzdataEnricher) Name() string {
+	return "tzdata"
+}
+
+// Enrich returns the tzdata version of the runtime and whether it is outdated
+func (t *tzdataEnricher) Enrich(result *JavaResult) (any, error) {
+	home := filepath.Dir(filepath.Dir(result.Path))
+	for _, dir := range []string{filepath.Join(home, "lib"), filepath.Join(home, "jre", "lib")} {
+		for _, read := range []struct {
+			file    string
+			version func(io.Reader) (string, error)
+		}{
+			{"tzdb.dat", tzdbVersion},
+			{filepath.Join("zi", "ZoneInfoMappings"), zoneInfoVersion},
+		} {
+			path := filepath.Join(dir, read.file)
+			file, err := os.Open(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			version, err := read.version(bufio.NewReader(file))
+			file.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", path, err)
+			}
+			latest := t.latestVersion()
+			return &TZDataInfo{Version: version, File: path, Latest: latest, Outdated: tzdataOlder(version, latest)}, nil
+		}
+	}
+	return nil, nil
+}
+
+// tzdbVersion reads the first version of a tzdb.dat file: a format byte of 1,
+// the group name TZDB and the version names, each a modified UTF-8 string
+// prefixed by its length
+func tzdbVersion(r io.Reader) (string, error) {
+	var format byte
+	if err := binary.Read(r, binary.BigEndian, &format); err != nil {
+		return "", err
+	}
+	if format != 1 {
+		return "", fmt.Errorf("unsupported tzdb format %d", format)
+	}
+	group, err := readJavaUTF(r)
+	if err != nil {
+		return "", err
+	}
+	if group != "TZDB" {
+		return "", fmt.Errorf("unexpected tzdb group %q", group)
+	}
+	var count uint16
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", fmt.Errorf("tzdb without versions")
+	}
+	return readJavaUTF(r)
+}
+
+// zoneInfoVersion reads the version of a ZoneInfoMappings file: the magic javazm,
+// a format byte and tagged records, of which tag 68 holds the version as tzdata2013b
+func zoneInfoVersion(r io.Reader) (string, error) {
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", err
+	}
+	if string(header[:6]) != "javazm" {
+		return "", fmt.Errorf("not a ZoneInfoMappings file")
+	}
+	for {
+		var tag byte
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &tag); err != nil {
+			return "", fmt.Errorf("no version record: %v", err)
+		}
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return "", err
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return "", err
+		}
+		if tag == 68 {
+			version, _ := strings.CutPrefix(string(data), "tzdata")
+			return version, nil
+		}
+	}
+}
+
+// readJavaUTF reads a string written by DataOutput.writeUTF
+func readJavaUTF(r io.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// javaUTF encodes a string like DataOutput.writeUTF
+func javaUTF(s string) string {
+	return string(binary.BigEndian.AppendUint16(nil, uint16(len(s)))) + s
+}
+
+func TestTZDataEnricher(t *testing.T) {
+	jdk17 := filepath.Join(t.TempDir(), "jdk-17")
+	writeTestFile(t, filepath.Join(jdk17, "lib", "tzdb.dat"), "\x01"+javaUTF("TZDB")+"\x00\x01"+javaUTF("2024a")+"\x02\x58")
+
+	enricher, err := newTZDataEnricher(&Config{VendorAPI: &VendorAPI{CacheDir: t.TempDir(), Offline: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := enricher.Enrich(&JavaResult{Path: filepath.Join(jdk17, "bin", "java")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := TZDataInfo{Version: "2024a", File: filepath.Join(jdk17, "lib", "tzdb.dat"), Latest: latestTZDataVersion, Outdated: true}
+	if info := value.(*TZDataInfo); *info != want {
+		t.Errorf("Expected %+v, got %+v", want, *info)
+	}
+
+	// Java 7 keeps the version in a tagged record of ZoneInfoMappings
+	jdk7 := filepath.Join(t.TempDir(), "jdk1.7.0_80")
+	writeTestFile(t, filepath.Join(jdk7, "jre", "lib", "zi", "ZoneInfoMappings"),
+		"javazm\x01"+"\x40\x00\x03abc"+"\x44"+javaUTF("tzdata2015g"))
+	enricher, _ = newTZDataEnricher(&Config{TZDataLatest: "2015g"})
+	value, err = enricher.Enrich(&JavaResult{Path: filepath.Join(jdk7, "bin", "java")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info := value.(*TZDataInfo); info.Version != "2015g" || info.Outdated {
+		t.Errorf("Expected the current version 2015g, got %+v", info)
+	}
+
+	writeTestFile(t, filepath.Join(jdk17, "lib", "tzdb.dat"), "\x02")
+	if _, err := enricher.Enrich(&JavaResult{Path: filepath.Join(jdk17, "bin", "java")}); err == nil {
+		t.Error("Expected an error for an unknown tzdb format")
+	}
+	if value, err := enricher.Enrich(&JavaResult{Path: filepath.Join(t.TempDir(), "bin", "java")}); value != nil || err != nil {
+		t.Errorf("Expected no enrichment without time zone data, got %v, %v", value, err)
+	}
+	if _, err := newTZDataEnricher(&Config{TZDataLatest: "latest"}); err == nil {
+		t.Error("Expected an error for an invalid tzdata_latest")
+	}
+}
+
+func TestTZDataOlder(t *testing.T) {
+	for _, tt := range []struct {
+		version, than string
+		older         bool
+	}{
+		{"2024a", "2025b", true},
+		{"2025a", "2025b", true},
+		{"2025b", "2025b", false},
+		{"2025z", "2025aa", true},
+		{"2026a", "2025z", false},
+		{"custom", "2025b", false},
+	} {
+		if older := tzdataOlder(tt.version, tt.than); older != tt.older {
+			t.Errorf("Expected %s older than %s to be %v", tt.version, tt.than, tt.older)
+		}
+	}
+}
+
+func TestTZDataLatestFetched(t *testing.T) {
+	jdk21 := filepath.Join(t.TempDir(), "jdk-21")
+	writeTestFile(t, filepath.Join(jdk21, "lib", "tzdb.dat"), "\x01"+javaUTF("TZDB")+"\x00\x01"+javaUTF("2025b")+"\x02\x58")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "2026a")
+	}))
+	defer server.Close()
+
+	enricher, err := newTZDataEnricher(&Config{TZDataLatest: server.URL + "/version", VendorAPI: &VendorAPI{CacheDir: t.TempDir()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := enricher.Enrich(&JavaResult{Path: filepath.Join(jdk21, "bin", "java")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info := value.(*TZDataInfo); info.Latest != "2026a" || !info.Outdated {
+		t.Errorf("Expected 2025b to be outdated by the fetched 2026a, got %+v", info)
+	}
+}
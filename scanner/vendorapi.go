@@ -0,0 +1,281 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// VendorAPI configures how online enrichers such as adoptium query public APIs.
+// The responses are cached on disk, so thousands of agents do not ask the API
+// on every scan, and a scan without internet access falls back to the cached
+// responses, however old, or goes without the enrichment.
+type VendorAPI struct {
+	// CacheDir holds the cached responses, default jfind/api in the cache
+	// directory of the user. Hosts may share it, e.g. on a network file system.
+	CacheDir string `json:"cache_dir,omitempty"`
+
+	// TTL is how long a cached response is used before it is fetched again, default 24h
+	TTL string `json:"ttl,omitempty"`
+
+	// RateLimit is the maximum number of requests per minute, default 30
+	RateLimit int `json:"rate_limit,omitempty"`
+
+	// Offline never connects and only uses the cached responses
+	Offline bool `json:"offline,omitempty"`
+
+	// AdoptiumURL is the base URL of the Adoptium API, e.g. of a caching mirror
+	AdoptiumURL string `json:"adoptium_url,omitempty"`
+
+	ttl time.Duration
+}
+
+const (
+	defaultAPITTL       = 24 * time.Hour
+	defaultAPIRateLimit = 30
+	defaultAdoptiumURL  = "https://api.adoptium.net"
+
+	// apiBackoff is how long an API that could not be reached or failed is
+	// not asked again, unless it sent Retry-After
+	apiBackoff = 5 * time.Minute
+
+	// maxAPIResponse limits the size of a response
+	maxAPIResponse = 16 << 20
+)
+
+// parse validates the configuration and fills in the defaults
+func (v *VendorAPI) parse() error {
+	v.ttl = defaultAPITTL
+	if v.TTL != "" {
+		ttl, err := time.ParseDuration(v.TTL)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid ttl %q of vendor_api, use a positive duration like 24h", v.TTL)
+		}
+		v.ttl = ttl
+	}
+	if v.RateLimit < 0 {
+		return fmt.Errorf("invalid rate_limit %d of vendor_api, use the number of requests per minute", v.RateLimit)
+	}
+	if v.AdoptiumURL != "" {
+		if u, err := url.Parse(v.AdoptiumURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid adoptium_url %q of vendor_api", v.AdoptiumURL)
+		}
+	}
+	return nil
+}
+
+// vendorAPI fetches the responses of public APIs through the cache and the
+// rate limit of the vendor_api configuration. One is shared by all enrichers.
+type vendorAPI struct {
+	config VendorAPI
+	client *http.Client
+	now    func() time.Time
+	sleep  func(time.Duration)
+
+	mu        sync.Mutex
+	memory    map[string]*apiResponse // responses read or fetched before
+	failed    map[string]apiFailure   // requests that failed without a cached response
+	next      time.Time               // earliest time of the next request
+	downUntil time.Time               // time until which the API is not asked after it failed
+}
+
+// apiFailure is a failed request, which is not repeated until the time has come
+type apiFailure struct {
+	err   error
+	until time.Time
+}
+
+// apiResponse is a response in the cache
+type apiResponse struct {
+	URL     string    `json:"url"`
+	Fetched time.Time `json:"fetched"`
+	ETag    string    `json:"etag,omitempty"`
+	Body    []byte    `json:"body"`
+}
+
+// newVendorAPI creates the client of a parsed configuration, nil for the defaults
+func newVendorAPI(cfg *VendorAPI) *vendorAPI {
+	var config VendorAPI
+	if cfg != nil {
+		config = *cfg
+	}
+	if config.ttl == 0 {
+		config.ttl = defaultAPITTL
+	}
+	if config.RateLimit == 0 {
+		config.RateLimit = defaultAPIRateLimit
+	}
+	if config.CacheDir == "" {
+		if dir, err := os.UserCacheDir(); err == nil {
+			config.CacheDir = filepath.Join(dir, "jfind", "api")
+		}
+	}
+	return &vendorAPI{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+		sleep:  time.Sleep,
+		memory: make(map[string]*apiResponse),
+		failed: make(map[string]apiFailure),
+	}
+}
+
+// vendorAPI returns the client of the vendor_api configuration shared by the enrichers
+func (c *Config) vendorAPI() *vendorAPI {
+	if c.api == nil {
+		c.api = newVendorAPI(c.VendorAPI)
+	}
+	return c.api
+}
+
+// get returns the body of a response younger than the TTL, kept in memory or
+// cached on disk, or else a fresh one from the API. If the API cannot be
+// reached or fails, a cached response of any age is used.
+func (a *vendorAPI) get(rawURL string) ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if response, ok := a.memory[rawURL]; ok && a.fresh(response) {
+		return response.Body, nil
+	}
+	if failure, ok := a.failed[rawURL]; ok && a.now().Before(failure.until) {
+		return nil, failure.err
+	}
+
+	cached := a.load(rawURL)
+	if cached != nil && a.fresh(cached) {
+		a.memory[rawURL] = cached
+		return cached.Body, nil
+	}
+	if a.config.Offline {
+		return nil, fmt.Errorf("no cached response for %s in offline mode", rawURL)
+	}
+
+	response, err := a.fetch(rawURL, cached)
+	switch {
+	case err != nil && cached == nil:
+		a.failed[rawURL] = apiFailure{err, a.now().Add(apiBackoff)}
+		return nil, err
+	case err != nil:
+		// A stale response is better than none, e.g. without internet access
+		response = cached
+	default:
+		// A read-only cache still serves the response
+		_ = a.store(response)
+		a.memory[rawURL] = response
+	}
+	return response.Body, nil
+}
+
+// fresh checks if a response is younger than the TTL, any is in offline mode
+func (a *vendorAPI) fresh(response *apiResponse) bool {
+	return a.config.Offline || a.now().Sub(response.Fetched) < a.config.ttl
+}
+
+// fetch requests a response from the API, conditionally if it is cached
+func (a *vendorAPI) fetch(rawURL string, cached *apiResponse) (*apiResponse, error) {
+	if until := a.downUntil; a.now().Before(until) {
+		return nil, fmt.Errorf("not querying %s until %s after it failed", rawURL, until.Format(time.RFC3339))
+	}
+	if wait := a.next.Sub(a.now()); wait > 0 {
+		a.sleep(wait)
+	}
+	now := a.now()
+	a.next = now.Add(time.Minute / time.Duration(a.config.RateLimit))
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "jfind")
+	req.Header.Set("Accept", "application/json")
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.downUntil = now.Add(apiBackoff)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		refreshed := *cached
+		refreshed.Fetched = now
+		return &refreshed, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		backoff := apiBackoff
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			backoff = time.Duration(seconds) * time.Second
+		}
+		a.downUntil = now.Add(backoff)
+		return nil, fmt.Errorf("%s returned %s", rawURL, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s returned %s", rawURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAPIResponse))
+	if err != nil {
+		return nil, err
+	}
+	return &apiResponse{URL: rawURL, Fetched: now, ETag: resp.Header.Get("ETag"), Body: body}, nil
+}
+
+// cacheFile returns the file caching the response of a URL
+func (a *vendorAPI) cacheFile(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(a.config.CacheDir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// load reads the cached response of a URL, nil if there is none
+func (a *vendorAPI) load(rawURL string) *apiResponse {
+	if a.config.CacheDir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(a.cacheFile(rawURL))
+	if err != nil {
+		return nil
+	}
+	var response apiResponse
+	if err := json.Unmarshal(data, &response); err != nil || response.URL != rawURL {
+		return nil
+	}
+	return &response
+}
+
+// store writes a response to the cache, replacing the file so that hosts
+// sharing the cache never read a partial one
+func (a *vendorAPI) store(response *apiResponse) error {
+	if a.config.CacheDir == "" {
+		return nil
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.config.CacheDir, 0755); err != nil {
+		return err
+	}
+	path := a.cacheFile(response.URL)
+	tmp, err := os.CreateTemp(a.config.CacheDir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestVendorAPICache(t *testing.T) {
+	var requests atomic.Int32
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch {
+		case status != http.StatusOK:
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(status)
+		case r.Header.Get("If-None-Match") == `"v1"`:
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(`[{"release_name":"jdk-21.0.4+7"}]`))
+		}
+	}))
+	defer server.Close()
+
+	config := &VendorAPI{CacheDir: t.TempDir(), TTL: "1h"}
+	if err := config.parse(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+	newAPI := func() *vendorAPI {
+		api := newVendorAPI(config)
+		api.now = func() time.Time { return now }
+		api.sleep = func(d time.Duration) { now = now.Add(d) }
+		return api
+	}
+	get := func(api *vendorAPI, path string) string {
+		t.Helper()
+		body, err := api.get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", path, err)
+		}
+		return string(body)
+	}
+
+	// A response is fetched once and then read from the cache, also by the next run
+	api := newAPI()
+	want := get(api, "/v3/assets/latest/21/hotspot")
+	get(api, "/v3/assets/latest/21/hotspot")
+	if got := get(newAPI(), "/v3/assets/latest/21/hotspot"); got != want || requests.Load() != 1 {
+		t.Errorf("Expected 1 request and the cached response, got %d and %q", requests.Load(), got)
+	}
+
+	// An expired response is revalidated with its ETag
+	now = now.Add(2 * time.Hour)
+	if got := get(newAPI(), "/v3/assets/latest/21/hotspot"); got != want || requests.Load() != 2 {
+		t.Errorf("Expected the revalidated response, got %d requests and %q", requests.Load(), got)
+	}
+
+	// A failing API falls back to the stale response and is not asked again for a while
+	now = now.Add(2 * time.Hour)
+	status = http.StatusTooManyRequests
+	api = newAPI()
+	if got := get(api, "/v3/assets/latest/21/hotspot"); got != want {
+		t.Errorf("Expected the stale response, got %q", got)
+	}
+	if _, err := api.get(server.URL + "/v3/assets/latest/17/hotspot"); err == nil || requests.Load() != 3 {
+		t.Errorf("Expected an error without asking the API again, got %v after %d requests", err, requests.Load())
+	}
+
+	// Offline only the cache is used
+	config.Offline = true
+	api = newAPI()
+	get(api, "/v3/assets/latest/21/hotspot")
+	if _, err := api.get(server.URL + "/v3/assets/latest/11/hotspot"); err == nil || requests.Load() != 3 {
+		t.Errorf("Expected an error without a request in offline mode, got %v after %d requests", err, requests.Load())
+	}
+
+	for _, invalid := range []VendorAPI{{TTL: "0s"}, {RateLimit: -1}, {AdoptiumURL: "api.adoptium.net"}} {
+		if err := invalid.parse(); err == nil {
+			t.Errorf("Expected %+v to be rejected", invalid)
+		}
+	}
+}
+
+func TestVendorAPIRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	var slept time.Duration
+	api := newVendorAPI(&VendorAPI{CacheDir: t.TempDir(), RateLimit: 6})
+	now := time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)
+	api.now = func() time.Time { return now }
+	api.sleep = func(d time.Duration) { slept += d; now = now.Add(d) }
+	for _, major := range []string{"8", "11", "17"} {
+		if _, err := api.get(server.URL + "/v3/assets/latest/" + major + "/hotspot"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if slept != 20*time.Second {
+		t.Errorf("Expected to wait 20s for 3 requests at 6 per minute, waited %v", slept)
+	}
+}
+
+func TestAdoptiumEnricher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/latest/17/hotspot"):
+			w.Write([]byte(`[{"release_name":"jdk-17.0.12+7","version":{"major":17,"security":12,"openjdk_version":"17.0.12+7"}},
+				{"release_name":"jdk-17.0.11+9","version":{"major":17,"security":11,"openjdk_version":"17.0.11+9"}}]`))
+		case strings.HasSuffix(r.URL.Path, "/latest/8/hotspot"):
+			w.Write([]byte(`[{"release_name":"jdk8u422-b05","version":{"major":8,"security":422,"openjdk_version":"1.8.0_422-b05"}}]`))
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+	defer server.Close()
+
+	cfg := &Config{VendorAPI: &VendorAPI{CacheDir: t.TempDir(), AdoptiumURL: server.URL + "/"}}
+	cfg.vendorAPI().sleep = func(time.Duration) {}
+	enrichers, err := newEnrichers([]string{"adoptium"}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		version string
+		want    *AdoptiumInfo
+	}{
+		{"17.0.9", &AdoptiumInfo{LatestVersion: "17.0.12+7"}},
+		{"17.0.12", &AdoptiumInfo{LatestVersion: "17.0.12+7", UpToDate: true}},
+		{"1.8.0_402", &AdoptiumInfo{LatestVersion: "1.8.0_422-b05"}},
+		{"13.0.2", nil},
+	}
+	for _, tt := range tests {
+		result := &JavaResult{Properties: &JavaProperties{Version: tt.version}}
+		value, err := enrichers[0].Enrich(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := value.(*AdoptiumInfo); (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.version, tt.want, value)
+		}
+	}
+}
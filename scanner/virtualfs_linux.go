@@ -0,0 +1,35 @@
+//go:build linux
+
+package main
+
+import "syscall"
+
+// virtualFSTypes are statfs magic numbers of kernel pseudo file systems
+var virtualFSTypes = map[uint32]string{
+	0x9fa0:     "proc",
+	0x62656572: "sysfs",
+	0x1cd1:     "devpts",
+	0x27e0eb:   "cgroup",
+	0x63677270: "cgroup2",
+	0x64626720: "debugfs",
+	0x74726163: "tracefs",
+	0x73636673: "securityfs",
+	0xcafe4a11: "bpf",
+	0x6e736673: "nsfs",
+	0x42494e4d: "binfmt_misc",
+	0x19800202: "mqueue",
+	0x65735546: "fusectl",
+	0xf97cff8c: "selinuxfs",
+	0x50495045: "pipefs",
+	0x858458f6: "ramfs",
+}
+
+// virtualFileSystem returns the type name if path is on a kernel pseudo file system
+func virtualFileSystem(path string) (string, bool) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return "", false
+	}
+	name, ok := virtualFSTypes[uint32(stat.Type)]
+	return name, ok
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSkipVirtualFileSystems(t *testing.T) {
+	if fsType, ok := virtualFileSystem("/proc"); !ok || fsType != "proc" {
+		t.Skip("/proc is not mounted")
+	}
+	if _, ok := virtualFileSystem(t.TempDir()); ok {
+		t.Error("Expected a temporary directory not to be a pseudo file system")
+	}
+
+	// The directories of a process in /proc are skipped, not walked
+	root := fmt.Sprint("/proc/", os.Getpid())
+	var buf bytes.Buffer
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.skipVirtualFS = true
+	finder.trace = newTracer(&buf)
+	if _, err := finder.Find(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	events := make(map[string]TraceEvent)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var event TraceEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Invalid trace line %s: %v", scanner.Text(), err)
+		}
+		events[event.Path] = event
+	}
+	if event := events[filepath.Join(root, "fd")]; event.Event != TraceSkippedVirtualFS || event.Reason != "proc" {
+		t.Errorf("Expected %s to be skipped as proc, got %+v", filepath.Join(root, "fd"), event)
+	}
+	for path, event := range events {
+		if path != root && event.Event == TraceEntered {
+			t.Errorf("Expected no directory below %s to be entered, got %s", root, path)
+		}
+	}
+}
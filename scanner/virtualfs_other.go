@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+// virtualFileSystem detects kernel pseudo file systems, which only exist on Linux
+func virtualFileSystem(path string) (string, bool) {
+	return "", false
+}
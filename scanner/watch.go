@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// watchSettle is how long -watch waits for the file system to settle before it
+// rescans the changed directories, so an extracted JDK is reported once. It is
+// shortened in tests.
+var watchSettle = 2 * time.Second
+
+// watchMaxDelay bounds the wait of a directory that keeps changing
+const watchMaxDelay = 30 * time.Second
+
+// watchOverflow is the event of a watcher that lost events, e.g. because the
+// kernel queue overflowed; everything is scanned again
+const watchOverflow = ""
+
+// dirWatcher reports directories whose entries were added, removed or renamed,
+// using the notifications of the operating system
+type dirWatcher interface {
+	// watch starts watching a directory. Watchers of whole trees only start
+	// watching at the start paths, given with root, and ignore the others.
+	watch(dir string, root bool) error
+
+	// events delivers the directories that changed, or watchOverflow
+	events() <-chan string
+
+	close() error
+}
+
+// errWatchUnsupported is returned by newDirWatcher on systems without file
+// system notifications jfind can use
+var errWatchUnsupported = errors.New("-watch is not supported on this operating system")
+
+// runtimeWatcher keeps the results of a scan up to date from file system
+// notifications, rescanning only the directories that changed
+type runtimeWatcher struct {
+	f *JavaFinder
+	w dirWatcher
+
+	// known are the results by path, aliases included; watched the directories watched
+	known   map[string]*JavaResult
+	watched map[string]bool
+
+	// previous are the results before events were lost, reused by rescanAll
+	previous map[string]*JavaResult
+
+	// warned avoids repeating a watch error for every directory
+	warned bool
+}
+
+// runWatch watches the start paths after the scan (-watch) until jfind is
+// interrupted, and emits the results whenever they change
+func runWatch(f *JavaFinder, results []*JavaResult, emit func([]*JavaResult) error) error {
+	w, err := newDirWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logf("Watching '%s' for java executables\n", strings.Join(f.startPaths(), "', '"))
+	return f.watchRuntimes(ctx, w, results, func(results []*JavaResult) {
+		if err := emit(results); err != nil {
+			logf("Warning: failed to report the change: %v\n", err)
+		}
+	})
+}
+
+// watchRuntimes watches the start paths after a scan and calls report with the
+// updated results whenever a java executable appears or disappears, until ctx
+// is done
+func (f *JavaFinder) watchRuntimes(ctx context.Context, w dirWatcher, results []*JavaResult, report func([]*JavaResult)) error {
+	rw := &runtimeWatcher{f: f, w: w, known: make(map[string]*JavaResult), watched: make(map[string]bool)}
+	for _, result := range results {
+		rw.known[result.Path] = result
+		for _, alias := range slices.Concat(result.Aliases, result.Paths) {
+			if alias == result.Path {
+				continue
+			}
+			aliased := *result
+			aliased.Path = alias
+			rw.known[alias] = &aliased
+		}
+	}
+	// The walk of the scan entered every directory already
+	f.resetLinks()
+	for _, root := range f.startPaths() {
+		if err := rw.watchRoot(root); err != nil {
+			return err
+		}
+		rw.addTree(ctx, root, false)
+	}
+	if f.verbose {
+		logf("Watching %d directories for java executables\n", len(rw.watched))
+	}
+
+	pending := make(map[string]bool)
+	var settle, deadline <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case dir, ok := <-w.events():
+			if !ok {
+				return errors.New("file system watcher stopped")
+			}
+			if len(pending) == 0 {
+				deadline = time.After(watchMaxDelay)
+			}
+			pending[dir] = true
+			settle = time.After(watchSettle)
+			continue
+		case <-settle:
+		case <-deadline:
+		}
+		settle, deadline = nil, nil
+		dirs := pending
+		pending = make(map[string]bool)
+		if rw.update(ctx, dirs) && ctx.Err() == nil {
+			report(rw.results())
+		}
+	}
+}
+
+// watchRoot starts watching a start path
+func (rw *runtimeWatcher) watchRoot(root string) error {
+	if err := rw.w.watch(root, true); err != nil {
+		return err
+	}
+	rw.watched[root] = true
+	return nil
+}
+
+// update rescans the changed directories and returns whether a java
+// executable appeared or disappeared
+func (rw *runtimeWatcher) update(ctx context.Context, dirs map[string]bool) bool {
+	if dirs[watchOverflow] {
+		if rw.f.verbose {
+			logf("File system events were lost, scanning again\n")
+		}
+		return rw.rescanAll(ctx)
+	}
+	changed := false
+	for _, dir := range slices.Sorted(maps.Keys(dirs)) {
+		if rw.rescan(ctx, dir) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// rescan lists a changed directory. Subdirectories not seen before are walked
+// and watched, java executables and subdirectories that are gone are dropped.
+func (rw *runtimeWatcher) rescan(ctx context.Context, dir string) bool {
+	if !rw.watched[dir] {
+		// A directory below an excluded one, or already dropped
+		return false
+	}
+	fsys := rw.f.treeFS()
+	names, err := readDirNames(fsys, dir)
+	if err != nil {
+		return rw.drop(dir)
+	}
+	changed := false
+	present := make(map[string]bool)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		present[path] = true
+		info, err := fsys.Lstat(path)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			if !rw.watched[path] && rw.f.watchable(path, info) {
+				changed = rw.addTree(ctx, path, true) || changed
+			}
+			continue
+		}
+		if _, ok := rw.known[path]; !ok && rw.f.watchedJava(path, info) {
+			rw.found(ctx, path)
+			changed = true
+		}
+	}
+	for path := range rw.known {
+		if filepath.Dir(path) == dir && !present[path] {
+			delete(rw.known, path)
+			rw.trace(TraceRemoved, path)
+			changed = true
+		}
+	}
+	for path := range rw.watched {
+		if filepath.Dir(path) == dir && path != dir && !present[path] {
+			changed = rw.drop(path) || changed
+		}
+	}
+	return changed
+}
+
+// addTree watches a directory and the directories below it and adds the java
+// executables found there if found is set, as for a new directory
+func (rw *runtimeWatcher) addTree(ctx context.Context, dir string, found bool) bool {
+	changed := false
+	walkFS(rw.f.treeFS(), dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || ctx.Err() != nil {
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return ctx.Err()
+		}
+		if info.IsDir() {
+			if path != dir && rw.watched[path] || path != dir && !rw.f.watchable(path, info) {
+				return filepath.SkipDir
+			}
+			if path != dir || !rw.watched[path] {
+				if err := rw.w.watch(path, false); err != nil {
+					if !rw.warned {
+						logf("Warning: not watching %s and further directories: %v\n", path, err)
+						rw.warned = true
+					}
+					return filepath.SkipDir
+				}
+				rw.watched[path] = true
+			}
+			return nil
+		}
+		if _, ok := rw.known[path]; found && !ok && rw.f.watchedJava(path, info) {
+			rw.found(ctx, path)
+			changed = true
+		}
+		return nil
+	})
+	return changed
+}
+
+// drop forgets a directory that is gone with everything below it
+func (rw *runtimeWatcher) drop(dir string) bool {
+	changed := false
+	for path := range rw.known {
+		if withinRoot(dir, path) {
+			delete(rw.known, path)
+			rw.trace(TraceRemoved, path)
+			changed = true
+		}
+	}
+	for path := range rw.watched {
+		if withinRoot(dir, path) && !slices.Contains(rw.f.startPaths(), path) {
+			delete(rw.watched, path)
+		}
+	}
+	return changed
+}
+
+// rescanAll walks every start path again after events were lost. Runtimes
+// still there keep their results.
+func (rw *runtimeWatcher) rescanAll(ctx context.Context) bool {
+	rw.previous = rw.known
+	defer func() { rw.previous = nil }()
+	rw.known = make(map[string]*JavaResult)
+	rw.watched = make(map[string]bool)
+	rw.f.resetLinks()
+	for _, root := range rw.f.startPaths() {
+		if err := rw.watchRoot(root); err != nil {
+			logf("Warning: %v\n", err)
+			continue
+		}
+		rw.addTree(ctx, root, true)
+	}
+	return !maps.EqualFunc(rw.known, rw.previous, func(*JavaResult, *JavaResult) bool { return true })
+}
+
+// found evaluates a new java executable
+func (rw *runtimeWatcher) found(ctx context.Context, path string) {
+	if result, ok := rw.previous[path]; ok {
+		rw.known[path] = result
+		return
+	}
+	rw.trace(TraceMatched, path)
+	if rw.f.verbose {
+		logf("Java executable appeared: %s\n", path)
+	}
+	rw.known[path] = rw.f.newResult(ctx, path)
+}
+
+func (rw *runtimeWatcher) trace(event, path string) {
+	rw.f.trace.event(SourceFileSystem, event, path, rw.f.getPathDepth(path), "")
+	if event == TraceRemoved && rw.f.verbose {
+		logf("Java executable disappeared: %s\n", path)
+	}
+}
+
+// results returns the current results, with links to the same file reported once
+func (rw *runtimeWatcher) results() []*JavaResult {
+	results := make([]*JavaResult, 0, len(rw.known))
+	for _, result := range rw.known {
+		r := *result
+		r.Aliases = nil
+		r.Paths = nil
+		results = append(results, &r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return collapseAliases(results)
+}
+
+// watchable checks if -watch descends into a directory, like the walk does
+func (f *JavaFinder) watchable(path string, info os.FileInfo) bool {
+	if maxDepth := f.maxDepthOf(path); maxDepth >= 0 && f.getPathDepth(path) > maxDepth {
+		return false
+	}
+	if _, ok := f.prune.match(path); ok {
+		return false
+	}
+	if _, ok := f.exclude.match(path); ok {
+		return false
+	}
+	if f.skipVirtualFS {
+		if _, ok := virtualFileSystem(path); ok {
+			return false
+		}
+	}
+	return f.links.enter(path, info)
+}
+
+// watchedJava checks if a file is a java executable the walk would report
+func (f *JavaFinder) watchedJava(path string, info os.FileInfo) bool {
+	if !f.matchesName(info.Name()) || !isExecutable(info) {
+		return false
+	}
+	maxDepth := f.maxDepthOf(path)
+	return maxDepth < 0 || f.getPathDepth(path) <= maxDepth
+}
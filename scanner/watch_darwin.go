@@ -0,0 +1,130 @@
+//go:build darwin
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// oEvtOnly opens a directory for notifications only, so the volume can still
+// be unmounted
+const oEvtOnly = 0x8000
+
+// kqueueWatcher watches every directory with kqueue. FSEvents watches trees,
+// but is only available through cgo.
+type kqueueWatcher struct {
+	kq int
+	ch chan string
+
+	mu   sync.Mutex
+	fds  map[string]int
+	dirs map[int]string
+
+	stop, done chan struct{}
+	once       sync.Once
+}
+
+func newDirWatcher() (dirWatcher, error) {
+	kq, err := syscall.Kqueue()
+	if err != nil {
+		return nil, fmt.Errorf("kqueue: %w", err)
+	}
+	syscall.CloseOnExec(kq)
+	w := &kqueueWatcher{kq: kq, ch: make(chan string, 64), fds: make(map[string]int), dirs: make(map[int]string), stop: make(chan struct{}), done: make(chan struct{})}
+	go w.read()
+	return w, nil
+}
+
+func (w *kqueueWatcher) watch(dir string, root bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if old, ok := w.fds[dir]; ok {
+		// A directory that was removed and created again gets a new watch
+		syscall.Close(old)
+		delete(w.dirs, old)
+	}
+	fd, err := syscall.Open(dir, oEvtOnly|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("kqueue watch of %s: %w", dir, err)
+	}
+	var change syscall.Kevent_t
+	syscall.SetKevent(&change, fd, syscall.EVFILT_VNODE, syscall.EV_ADD|syscall.EV_CLEAR)
+	change.Fflags = syscall.NOTE_WRITE | syscall.NOTE_DELETE | syscall.NOTE_RENAME | syscall.NOTE_ATTRIB
+	if _, err := syscall.Kevent(w.kq, []syscall.Kevent_t{change}, nil, nil); err != nil {
+		syscall.Close(fd)
+		if err == syscall.EMFILE || err == syscall.ENFILE {
+			return fmt.Errorf("kqueue watch of %s: too many directories, raise the limit of open files", dir)
+		}
+		return fmt.Errorf("kqueue watch of %s: %w", dir, err)
+	}
+	w.fds[dir] = fd
+	w.dirs[fd] = dir
+	return nil
+}
+
+func (w *kqueueWatcher) events() <-chan string {
+	return w.ch
+}
+
+func (w *kqueueWatcher) close() error {
+	// read polls, it closes the descriptors once it notices
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+	return nil
+}
+
+// read delivers the directories of the events until the watcher is closed
+func (w *kqueueWatcher) read() {
+	defer func() {
+		w.mu.Lock()
+		for fd := range w.dirs {
+			syscall.Close(fd)
+		}
+		w.mu.Unlock()
+		syscall.Close(w.kq)
+		close(w.ch)
+		close(w.done)
+	}()
+	events := make([]syscall.Kevent_t, 64)
+	timeout := syscall.NsecToTimespec(int64(500 * 1e6))
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+		n, err := syscall.Kevent(w.kq, nil, events, &timeout)
+		if err == syscall.EINTR {
+			continue
+		} else if err != nil {
+			return
+		}
+		for _, event := range events[:n] {
+			fd := int(event.Ident)
+			w.mu.Lock()
+			dir, ok := w.dirs[fd]
+			gone := event.Fflags&(syscall.NOTE_DELETE|syscall.NOTE_RENAME) != 0
+			if ok && gone {
+				syscall.Close(fd)
+				delete(w.dirs, fd)
+				delete(w.fds, dir)
+			}
+			w.mu.Unlock()
+			if !ok {
+				continue
+			}
+			if gone {
+				// The parent lists the directory as gone
+				dir = filepath.Dir(dir)
+			}
+			select {
+			case w.ch <- dir:
+			case <-w.stop:
+				return
+			}
+		}
+	}
+}
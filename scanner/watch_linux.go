@@ -0,0 +1,120 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask are the events of a watched directory: entries added, removed,
+// renamed or made executable, and the directory itself removed or renamed
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_ATTRIB | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF | syscall.IN_ONLYDIR
+
+// inotifyWatcher watches every directory with inotify, which does not watch
+// trees
+type inotifyWatcher struct {
+	file *os.File
+	ch   chan string
+	stop chan struct{}
+	once sync.Once
+
+	mu   sync.Mutex
+	dirs map[int32]string
+}
+
+func newDirWatcher() (dirWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify: %w", err)
+	}
+	// A non-blocking file is read through the runtime poller, so close
+	// unblocks the read
+	w := &inotifyWatcher{file: os.NewFile(uintptr(fd), "inotify"), ch: make(chan string, 64), stop: make(chan struct{}), dirs: make(map[int32]string)}
+	go w.read()
+	return w, nil
+}
+
+func (w *inotifyWatcher) watch(dir string, root bool) error {
+	conn, err := w.file.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var wd int
+	var watchErr error
+	err = conn.Control(func(fd uintptr) {
+		wd, watchErr = syscall.InotifyAddWatch(int(fd), dir, inotifyMask)
+	})
+	if err == nil {
+		err = watchErr
+	}
+	if errors.Is(err, syscall.ENOSPC) {
+		return fmt.Errorf("inotify watch of %s: too many directories, raise fs.inotify.max_user_watches", dir)
+	} else if err != nil {
+		return fmt.Errorf("inotify watch of %s: %w", dir, err)
+	}
+	w.mu.Lock()
+	w.dirs[int32(wd)] = dir
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *inotifyWatcher) events() <-chan string {
+	return w.ch
+}
+
+func (w *inotifyWatcher) close() error {
+	w.once.Do(func() { close(w.stop) })
+	return w.file.Close()
+}
+
+// read delivers the directories of the events until the watcher is closed
+func (w *inotifyWatcher) read() {
+	defer close(w.ch)
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+			dir, ok := w.dir(event)
+			if !ok {
+				continue
+			}
+			select {
+			case w.ch <- dir:
+			case <-w.stop:
+				return
+			}
+		}
+	}
+}
+
+// dir returns the directory that changed with an event
+func (w *inotifyWatcher) dir(event *syscall.InotifyEvent) (string, bool) {
+	if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+		return watchOverflow, true
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dir, ok := w.dirs[event.Wd]
+	if event.Mask&syscall.IN_IGNORED != 0 {
+		// The watch is gone with the directory, the other events tell why
+		delete(w.dirs, event.Wd)
+		return "", false
+	}
+	if ok && event.Mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0 {
+		// The parent lists the directory as gone
+		dir = filepath.Dir(dir)
+	}
+	return dir, ok
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+// newDirWatcher has no file system notifications to use on other systems
+func newDirWatcher() (dirWatcher, error) {
+	return nil, errWatchUnsupported
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDirWatcher records the watched directories and delivers the events of a test
+type fakeDirWatcher struct {
+	dirs []string
+	ch   chan string
+}
+
+func (w *fakeDirWatcher) watch(dir string, root bool) error {
+	w.dirs = append(w.dirs, dir)
+	return nil
+}
+
+func (w *fakeDirWatcher) events() <-chan string { return w.ch }
+func (w *fakeDirWatcher) close() error          { return nil }
+
+// waitingWatcher tells when watchRuntimes waits for events, after it watched
+// the start paths
+type waitingWatcher struct {
+	dirWatcher
+	once    sync.Once
+	waiting chan struct{}
+}
+
+func (w *waitingWatcher) events() <-chan string {
+	w.once.Do(func() { close(w.waiting) })
+	return w.dirWatcher.events()
+}
+
+// startWatch runs watchRuntimes in the background until it waits for events
+// and returns the reports
+func startWatch(t *testing.T, finder *JavaFinder, w dirWatcher, results []*JavaResult) <-chan []*JavaResult {
+	t.Helper()
+	settle := watchSettle
+	watchSettle = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	reports := make(chan []*JavaResult, 10)
+	done := make(chan error)
+	waiting := &waitingWatcher{dirWatcher: w, waiting: make(chan struct{})}
+	go func() {
+		done <- finder.watchRuntimes(ctx, waiting, results, func(results []*JavaResult) { reports <- results })
+	}()
+	<-waiting.waiting
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		watchSettle = settle
+	})
+	return reports
+}
+
+func nextReport(t *testing.T, reports <-chan []*JavaResult) []string {
+	t.Helper()
+	select {
+	case results := <-reports:
+		var paths []string
+		for _, result := range results {
+			paths = append(paths, result.Path)
+		}
+		return paths
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected a report of the change")
+		return nil
+	}
+}
+
+func TestWatchRuntimes(t *testing.T) {
+	root := filepath.FromSlash("/fake")
+	jdk17 := filepath.Join(root, "opt", "jdk-17", "bin", javaExecutableName())
+	jdk21 := filepath.Join(root, "opt", "jdk-21", "bin", javaExecutableName())
+	fsys := fakeFileSystem{}
+	fsys.add(jdk17, 0755, "")
+	fsys.add(filepath.Join(root, "home", "README"), 0644, "")
+
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.SetSystem(System{Clock: fixedClock(time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)), FS: fsys, Exec: fakeExecutor{}, Host: fakeHost("build01")})
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &fakeDirWatcher{ch: make(chan string, 10)}
+	reports := startWatch(t, finder, w, results)
+
+	// A new JDK is reported once the directory settled
+	fsys.add(jdk21, 0755, "")
+	w.ch <- filepath.Join(root, "opt")
+	if got := nextReport(t, reports); !slices.Equal(got, []string{jdk17, jdk21}) {
+		t.Errorf("Expected the new runtime to be reported, got %v", got)
+	}
+	if !slices.Contains(w.dirs, filepath.Join(root, "opt", "jdk-21", "bin")) {
+		t.Errorf("Expected the directories of the new runtime to be watched, got %v", w.dirs)
+	}
+
+	// A removed JDK directory drops its runtime, changes without a java
+	// executable are no change
+	for path := range fsys {
+		if withinRoot(filepath.Join(root, "opt", "jdk-17"), path) {
+			delete(fsys, path)
+		}
+	}
+	fsys.add(filepath.Join(root, "home", "notes"), 0644, "")
+	w.ch <- filepath.Join(root, "home")
+	w.ch <- filepath.Join(root, "opt")
+	if got := nextReport(t, reports); !slices.Equal(got, []string{jdk21}) {
+		t.Errorf("Expected the removed runtime to be dropped, got %v", got)
+	}
+
+	// Lost events scan everything again
+	delete(fsys, jdk21)
+	w.ch <- watchOverflow
+	if got := nextReport(t, reports); len(got) != 0 {
+		t.Errorf("Expected no runtimes after the full scan, got %v", got)
+	}
+}
+
+func TestDirWatcher(t *testing.T) {
+	w, err := newDirWatcher()
+	if errors.Is(err, errWatchUnsupported) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.close() })
+
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "opt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	finder := NewJavaFinder(root, -1, false, false)
+	results, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := startWatch(t, finder, w, results)
+
+	java := createFakeJava(t, filepath.Join(root, "opt", "jdk-21"))
+	if got := nextReport(t, reports); !slices.Equal(got, []string{java}) {
+		t.Errorf("Expected the new runtime to be reported, got %v", got)
+	}
+	if err := os.RemoveAll(filepath.Join(root, "opt", "jdk-21")); err != nil {
+		t.Fatal(err)
+	}
+	if got := nextReport(t, reports); len(got) != 0 {
+		t.Errorf("Expected the removed runtime to be dropped, got %v", got)
+	}
+}
@@ -0,0 +1,118 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// rdcwMask are the changes ReadDirectoryChangesW reports: files and
+// directories added, removed or renamed
+const rdcwMask = syscall.FILE_NOTIFY_CHANGE_FILE_NAME | syscall.FILE_NOTIFY_CHANGE_DIR_NAME
+
+// rdcwWatcher watches the trees of the start paths with ReadDirectoryChangesW,
+// the other directories need no watch of their own
+type rdcwWatcher struct {
+	ch   chan string
+	stop chan struct{}
+	once sync.Once
+
+	mu      sync.Mutex
+	handles map[string]syscall.Handle
+}
+
+func newDirWatcher() (dirWatcher, error) {
+	return &rdcwWatcher{ch: make(chan string, 64), stop: make(chan struct{}), handles: make(map[string]syscall.Handle)}, nil
+}
+
+func (w *rdcwWatcher) watch(dir string, root bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.handles[dir]; !root || ok {
+		return nil
+	}
+	name, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return err
+	}
+	handle, err := syscall.CreateFile(name, syscall.FILE_LIST_DIRECTORY,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fmt.Errorf("watch of %s: %w", dir, err)
+	}
+	w.handles[dir] = handle
+	go w.read(dir, handle)
+	return nil
+}
+
+func (w *rdcwWatcher) events() <-chan string {
+	return w.ch
+}
+
+func (w *rdcwWatcher) close() error {
+	w.once.Do(func() { close(w.stop) })
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for dir, handle := range w.handles {
+		// Cancels the pending read of the tree
+		syscall.CancelIoEx(handle, nil)
+		syscall.CloseHandle(handle)
+		delete(w.handles, dir)
+	}
+	return nil
+}
+
+// read delivers the directories of the changes below a start path until the
+// watcher is closed
+func (w *rdcwWatcher) read(root string, handle syscall.Handle) {
+	buf := make([]byte, 64*1024)
+	for {
+		var n uint32
+		if err := syscall.ReadDirectoryChanges(handle, &buf[0], uint32(len(buf)), true, rdcwMask, &n, nil, 0); err != nil {
+			select {
+			case <-w.stop:
+			default:
+				// The start path is gone or unreachable, which a full scan finds out
+				w.send(watchOverflow)
+				w.mu.Lock()
+				delete(w.handles, root)
+				syscall.CloseHandle(handle)
+				w.mu.Unlock()
+			}
+			return
+		}
+		if n == 0 {
+			// The changes did not fit into the buffer
+			if !w.send(watchOverflow) {
+				return
+			}
+			continue
+		}
+		for offset := uint32(0); ; {
+			info := (*syscall.FileNotifyInformation)(unsafe.Pointer(&buf[offset]))
+			name := syscall.UTF16ToString(unsafe.Slice(&info.FileName, info.FileNameLength/2))
+			if !w.send(filepath.Dir(filepath.Join(root, name))) {
+				return
+			}
+			if info.NextEntryOffset == 0 {
+				break
+			}
+			offset += info.NextEntryOffset
+		}
+	}
+}
+
+// send delivers a directory, it returns false once the watcher is closed
+func (w *rdcwWatcher) send(dir string) bool {
+	select {
+	case w.ch <- dir:
+		return true
+	case <-w.stop:
+		return false
+	}
+}
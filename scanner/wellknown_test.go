@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestFindWellKnown(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	known := createFakeJava(t, filepath.Join(home, ".jdks", "temurin-17"))
+	other := createFakeJava(t, filepath.Join(home, "projects", "app", "runtime"))
+
+	finder := NewJavaFinder(home, -1, false, false)
+	results, err := finder.FindWellKnown()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != known {
+		t.Fatalf("Expected only %s from the quick scan, got %v", known, results)
+	}
+	if finder.discovery != "well-known" {
+		t.Errorf("Expected discovery source well-known, got %s", finder.discovery)
+	}
+
+	results, err = finder.Find()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected %s and %s from the full scan, got %v", known, other, results)
+	}
+
+	// The depth limit of the full scan applies to the quick scan as well
+	finder.maxDepth = 3
+	if results, _ := finder.FindWellKnown(); len(results) != 0 {
+		t.Errorf("Expected no results beyond the maximum depth, got %v", results)
+	}
+}
+
+func TestNewScanID(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	id := newScanID()
+	if !uuid.MatchString(id) {
+		t.Errorf("Expected a version 4 UUID, got %s", id)
+	}
+	if newScanID() == id {
+		t.Error("Expected different scan IDs")
+	}
+}
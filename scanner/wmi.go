@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WMI namespace and classes the results are published to with -wmi
+const (
+	wmiNamespace    = `root\jfind`
+	wmiRuntimeClass = "JFind_JavaRuntime"
+	wmiScanClass    = "JFind_Scan"
+)
+
+// mofString quotes a value as a MOF string literal
+func mofString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(s) + `"`
+}
+
+// mofBool formats a MOF boolean
+func mofBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// generateMOF returns a MOF document that replaces the published classes with
+// the results of a scan. Deleting the classes removes the instances of earlier
+// scans, so runtimes that have been uninstalled disappear.
+func generateMOF(output JSONOutput) string {
+	var b strings.Builder
+	b.WriteString("// Generated by jfind, do not edit\n")
+	b.WriteString(`#pragma namespace("\\\\.\\root")` + "\n")
+	b.WriteString("instance of __Namespace\n{\n  Name = \"jfind\";\n};\n\n")
+	b.WriteString(`#pragma namespace("\\\\.\\root\\jfind")` + "\n")
+	fmt.Fprintf(&b, "#pragma deleteclass(%s, NOFAIL)\n", mofString(wmiRuntimeClass))
+	fmt.Fprintf(&b, "#pragma deleteclass(%s, NOFAIL)\n\n", mofString(wmiScanClass))
+
+	fmt.Fprintf(&b, `[Description("Latest jfind scan of this computer")]
+class %s
+{
+  [key] string ScanID;
+  string ScanTimestamp;
+  string ComputerName;
+  string ScanDuration;
+  uint32 CountResult;
+  boolean HasOracleJDK;
+};
+
+[Description("Java runtime found by the latest jfind scan")]
+class %s
+{
+  [key] string JavaExecutable;
+  string JavaVersion;
+  string JavaVendor;
+  string JavaRuntime;
+  uint32 VersionMajor;
+  uint32 VersionUpdate;
+  boolean IsOracle;
+  boolean RequireLicense;
+  string PathClass;
+  string ProbeStatus;
+  uint32 Confidence;
+  string ScanID;
+};
+
+`, wmiScanClass, wmiRuntimeClass)
+
+	meta := output.Meta
+	fmt.Fprintf(&b, "instance of %s\n{\n", wmiScanClass)
+	fmt.Fprintf(&b, "  ScanID = %s;\n", mofString(meta.ScanID))
+	fmt.Fprintf(&b, "  ScanTimestamp = %s;\n", mofString(meta.ScanTimestamp))
+	fmt.Fprintf(&b, "  ComputerName = %s;\n", mofString(meta.ComputerName))
+	fmt.Fprintf(&b, "  ScanDuration = %s;\n", mofString(meta.ScanDuration))
+	fmt.Fprintf(&b, "  CountResult = %d;\n", meta.CountResult)
+	fmt.Fprintf(&b, "  HasOracleJDK = %s;\n", mofBool(meta.HasOracleJDK))
+	b.WriteString("};\n")
+
+	for _, rt := range output.Runtimes {
+		fmt.Fprintf(&b, "\ninstance of %s\n{\n", wmiRuntimeClass)
+		fmt.Fprintf(&b, "  JavaExecutable = %s;\n", mofString(rt.JavaExecutable))
+		fmt.Fprintf(&b, "  JavaVersion = %s;\n", mofString(rt.JavaVersion))
+		fmt.Fprintf(&b, "  JavaVendor = %s;\n", mofString(rt.JavaVendor))
+		fmt.Fprintf(&b, "  JavaRuntime = %s;\n", mofString(rt.JavaRuntime))
+		fmt.Fprintf(&b, "  VersionMajor = %d;\n", rt.VersionMajor)
+		fmt.Fprintf(&b, "  VersionUpdate = %d;\n", rt.VersionUpdate)
+		fmt.Fprintf(&b, "  IsOracle = %s;\n", mofBool(rt.IsOracle))
+		fmt.Fprintf(&b, "  RequireLicense = %s;\n", mofBool(rt.RequireLicense != nil && *rt.RequireLicense))
+		fmt.Fprintf(&b, "  PathClass = %s;\n", mofString(rt.PathClass))
+		fmt.Fprintf(&b, "  ProbeStatus = %s;\n", mofString(rt.ProbeStatus))
+		fmt.Fprintf(&b, "  Confidence = %d;\n", rt.Confidence)
+		fmt.Fprintf(&b, "  ScanID = %s;\n", mofString(meta.ScanID))
+		b.WriteString("};\n")
+	}
+	return b.String()
+}
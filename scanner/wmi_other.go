@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// publishWMI is only supported on Windows
+func publishWMI(output JSONOutput) error {
+	return fmt.Errorf("WMI is not supported on %s", runtime.GOOS)
+}
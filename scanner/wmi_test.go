@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateMOF(t *testing.T) {
+	license := true
+	output := JSONOutput{
+		Meta: MetaInfo{ScanID: "scan-1", ComputerName: "ws-042", CountResult: 1, HasOracleJDK: true},
+		Runtimes: []JavaRuntimeJSON{{
+			JavaExecutable: `C:\Program Files\Java\jdk1.8.0_202\bin\java.exe`,
+			JavaVendor:     `Oracle "Corporation"`,
+			VersionMajor:   8,
+			VersionUpdate:  202,
+			IsOracle:       true,
+			RequireLicense: &license,
+			Confidence:     100,
+		}},
+	}
+
+	mof := generateMOF(output)
+	for _, want := range []string{
+		`#pragma deleteclass("JFind_JavaRuntime", NOFAIL)`,
+		"instance of JFind_Scan\n{\n  ScanID = \"scan-1\";",
+		`JavaExecutable = "C:\\Program Files\\Java\\jdk1.8.0_202\\bin\\java.exe";`,
+		`JavaVendor = "Oracle \"Corporation\"";`,
+		"RequireLicense = TRUE;",
+		"VersionUpdate = 202;",
+	} {
+		if !strings.Contains(mof, want) {
+			t.Errorf("Expected MOF to contain %s, got:\n%s", want, mof)
+		}
+	}
+	if n := strings.Count(mof, "instance of JFind_JavaRuntime"); n != 1 {
+		t.Errorf("Expected 1 runtime instance, got %d", n)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// publishWMI compiles the results into the WMI repository with mofcomp, which
+// requires administrator rights
+func publishWMI(output JSONOutput) error {
+	file, err := os.CreateTemp("", "jfind-*.mof")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(generateMOF(output)); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	windir := os.Getenv("SystemRoot")
+	if windir == "" {
+		windir = `C:\Windows`
+	}
+	mofcomp := filepath.Join(windir, "System32", "wbem", "mofcomp.exe")
+	out, err := exec.Command(mofcomp, file.Name()).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("mofcomp failed: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// dirQueue holds the directories waiting to be read by the walk workers. It is
+// a stack, so the workers go deep first and the queue stays small.
+type dirQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	dirs    []queuedDir
+	pending int // directories queued or being read
+	reading map[string]bool
+	done    bool
+}
+
+// queuedDir is a directory found by a worker
+type queuedDir struct {
+	path string
+	info os.FileInfo
+}
+
+func newDirQueue() *dirQueue {
+	q := &dirQueue{reading: make(map[string]bool)}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues a directory for reading
+func (q *dirQueue) push(dir queuedDir) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dirs = append(q.dirs, dir)
+	q.pending++
+	q.cond.Signal()
+}
+
+// pop waits for the next directory. It returns false when every directory has
+// been read or the walk was stopped.
+func (q *dirQueue) pop() (queuedDir, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.dirs) == 0 && !q.done {
+		q.cond.Wait()
+	}
+	if q.done {
+		return queuedDir{}, false
+	}
+	dir := q.dirs[len(q.dirs)-1]
+	q.dirs = q.dirs[:len(q.dirs)-1]
+	q.reading[dir.path] = true
+	return dir, true
+}
+
+// finish marks a popped directory as read, the walk is complete with the last one
+func (q *dirQueue) finish(dir queuedDir) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.reading, dir.path)
+	q.pending--
+	if q.pending == 0 {
+		q.done = true
+		q.cond.Broadcast()
+	}
+}
+
+// snapshot returns the directories queued or being read, sorted. Directories
+// below one of them are left out: reading it again finds them again.
+func (q *dirQueue) snapshot() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	all := make(map[string]bool, len(q.dirs)+len(q.reading))
+	for _, dir := range q.dirs {
+		all[dir.path] = true
+	}
+	for path := range q.reading {
+		all[path] = true
+	}
+	var dirs []string
+	for path := range all {
+		if !hasAncestorIn(all, path) {
+			dirs = append(dirs, path)
+		}
+	}
+	slices.Sort(dirs)
+	return dirs
+}
+
+// hasAncestorIn checks if a directory above path is in dirs
+func hasAncestorIn(dirs map[string]bool, path string) bool {
+	for dir := path; dir != filepath.Dir(dir); {
+		dir = filepath.Dir(dir)
+		if dirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// stop ends the walk, the workers return after the directory they are reading
+func (q *dirQueue) stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.done = true
+	q.cond.Broadcast()
+}
+
+// queuedWalk checks if the walk reads directories from a queue, with workers,
+// to save its frontier to a checkpoint or to index the directories for the
+// next incremental scan, instead of walking like filepath.Walk
+func (f *JavaFinder) queuedWalk() bool {
+	return f.workers > 1 || f.checkpoint != nil || f.baseline != nil
+}
+
+// walkConcurrently walks the directory tree like walkFileSystem with a pool of
+// workers that read directories and evaluate the java executables in them in
+// parallel. The results are passed to emit from the calling goroutine, in the
+// order they are found. The walk stops at the first error of emit.
+func (f *JavaFinder) walkConcurrently(ctx context.Context, emit func(*JavaResult) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var firstErr error
+	queue := newDirQueue()
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		queue.stop()
+	}
+
+	fsys := f.treeFS()
+	info, err := fsys.Lstat(f.startPath)
+	if err != nil || !info.IsDir() {
+		// Nothing to distribute, e.g. a start path that is a file
+		return walkFS(fsys, f.startPath, func(path string, info os.FileInfo, err error) error {
+			return f.visit(ctx, path, info, err, emit)
+		})
+	}
+	var frontier []string
+	resumed := false
+	if f.checkpoint != nil {
+		frontier, resumed = f.checkpoint.begin(f.startPath, queue)
+	}
+	if !resumed {
+		queue.push(queuedDir{path: f.startPath, info: info})
+	}
+	for _, dir := range frontier {
+		// Directories removed since the checkpoint are left out
+		if info, err := fsys.Lstat(dir); err == nil && info.IsDir() {
+			queue.push(queuedDir{path: dir, info: info})
+		}
+	}
+	if queue.pending == 0 {
+		f.checkpoint.end(true)
+		return nil
+	}
+
+	workers := max(f.workers, 1)
+	found := make(chan *JavaResult)
+	scanned := make([]int, workers)
+	excluded := make([]int, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		// Each worker counts its own directories, like the well-known scan does
+		worker := *f
+		worker.scanned, worker.excluded = 0, 0
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { scanned[i], excluded[i] = worker.scanned, worker.excluded }()
+			send := func(result *JavaResult) error {
+				select {
+				case found <- result:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			for {
+				dir, ok := queue.pop()
+				if !ok {
+					return
+				}
+				if err := worker.readDir(ctx, dir, queue, send); err != nil {
+					// The directory stays in the frontier of the checkpoint
+					fail(err)
+					return
+				}
+				queue.finish(dir)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	for result := range found {
+		if err := emit(result); err != nil {
+			fail(err)
+			cancel()
+			break
+		}
+	}
+	// Let the workers that are still sending see the cancellation
+	for range found {
+	}
+
+	for i := range scanned {
+		f.scanned += scanned[i]
+		f.excluded += excluded[i]
+	}
+	f.checkpoint.end(firstErr == nil && ctx.Err() == nil)
+	return firstErr
+}
+
+// readDir visits a directory and its files and queues its subdirectories. Like
+// filepath.Walk, the directory is visited after reading it, with the error if
+// that failed, and its entries in lexical order. An incremental scan takes the
+// entries of a directory unchanged since the previous walk from its index.
+func (f *JavaFinder) readDir(ctx context.Context, dir queuedDir, queue *dirQueue, emit func(*JavaResult) error) error {
+	fsys := f.treeFS()
+	names, unchanged := f.unchangedDir(dir)
+	var readErr error
+	if !unchanged {
+		names, readErr = readDirNames(fsys, dir.path)
+	}
+	if err := f.visit(ctx, dir.path, dir.info, readErr, emit); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+	var entry indexedDir
+	for _, name := range names {
+		path := filepath.Join(dir.path, name)
+		info, err := fsys.Lstat(path)
+		if err == nil && info.IsDir() {
+			entry.Dirs = append(entry.Dirs, name)
+			queue.push(queuedDir{path: path, info: info})
+			continue
+		}
+		if err == nil && f.indexedFile(name) {
+			entry.Files = append(entry.Files, name)
+		}
+		if err := f.visit(ctx, path, info, err, emit); err != nil && err != filepath.SkipDir {
+			return err
+		}
+	}
+	if readErr == nil {
+		f.baseline.indexDir(dir.path, entry)
+	}
+	return nil
+}
+
+// sortWalkOrder sorts results in the order of a sequential walk, in which a
+// directory and everything below it comes before its siblings with longer names
+// and the start paths are walked one after the other
+func (f *JavaFinder) sortWalkOrder(results []*JavaResult) {
+	key := func(path string) string {
+		return strings.ReplaceAll(path, string(filepath.Separator), "\x00")
+	}
+	slices.SortStableFunc(results, func(a, b *JavaResult) int {
+		return cmp.Or(cmp.Compare(f.rootIndex(a.Path), f.rootIndex(b.Path)), strings.Compare(key(a.Path), key(b.Path)))
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWalkConcurrently(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"opt/jdk-17", "opt/jdk-17.0.9", "opt/jdk-21", "usr/lib/jvm/java-11", "usr/lib/jvm/java-8/jre", "home/alice/.sdkman/candidates/java/21.0.2"} {
+		createFakeJava(t, filepath.Join(root, filepath.FromSlash(dir)))
+	}
+	writeTestFile(t, filepath.Join(root, "opt", "jdk-21", "lib", "modules"), "")
+
+	paths := func(results []*JavaResult) []string {
+		var paths []string
+		for _, result := range results {
+			paths = append(paths, result.Path)
+		}
+		return paths
+	}
+	sequential := NewJavaFinder(root, -1, false, false)
+	want, err := sequential.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{2, 8} {
+		finder := NewJavaFinder(root, -1, false, false)
+		finder.workers = workers
+		got, err := finder.Find()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(paths(got), paths(want)) {
+			t.Errorf("Expected the results of the sequential walk with %d workers\nwant %v\ngot  %v", workers, paths(want), paths(got))
+		}
+		if finder.scanned != sequential.scanned {
+			t.Errorf("Expected %d directories scanned with %d workers, got %d", sequential.scanned, workers, finder.scanned)
+		}
+	}
+
+	// The depth limit applies to every worker
+	finder := NewJavaFinder(root, 4, false, false)
+	finder.workers = 4
+	got, err := finder.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Errorf("Expected the 3 runtimes below opt within depth 4, got %v", paths(got))
+	}
+}
+
+func TestWalkConcurrentlyStopsOnError(t *testing.T) {
+	root := t.TempDir()
+	for i := range 20 {
+		createFakeJava(t, filepath.Join(root, fmt.Sprintf("jdk-%d", i)))
+	}
+
+	finder := NewJavaFinder(root, -1, false, false)
+	finder.workers = 4
+	stop := errors.New("stop")
+	emitted := 0
+	err := finder.walkFileSystem(context.Background(), func(result *JavaResult) error {
+		emitted++
+		if emitted == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || emitted != 2 {
+		t.Errorf("Expected the walk to stop at the error of the second result, got %v after %d results", err, emitted)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, errc := finder.Stream(ctx)
+	<-results
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+"""Authentication of the endpoints that change what the collector alerts on."""
+
+import hmac
+import os
+from typing import Optional
+
+from fastapi import Depends, HTTPException, status
+from fastapi.security import HTTPAuthorizationCredentials, HTTPBearer
+
+_bearer = HTTPBearer(auto_error=False)
+
+
+def get_admin_token() -> Optional[str]:
+    """Get the admin token from the JFIND_ADMIN_TOKEN environment variable, None if not set."""
+    return os.getenv("JFIND_ADMIN_TOKEN") or None
+
+
+def require_admin(credentials: Optional[HTTPAuthorizationCredentials] = Depends(_bearer)) -> None:
+    """Require the admin token as bearer token.
+
+    Without a configured admin token the endpoints are disabled, rather than open to anyone
+    who can reach the collector.
+    """
+    token = get_admin_token()
+    if token is None:
+        raise HTTPException(
+            status_code=status.HTTP_403_FORBIDDEN, detail="Set JFIND_ADMIN_TOKEN to enable this endpoint"
+        )
+    if credentials is None or not hmac.compare_digest(credentials.credentials.encode(), token.encode()):
+        raise HTTPException(
+            status_code=status.HTTP_401_UNAUTHORIZED,
+            detail="Admin token required",
+            headers={"WWW-Authenticate": "Bearer"},
+        )
@@ -0,0 +1,155 @@
+"""Red/amber/green compliance ratings of the runtimes of a computer.
+
+Ratings combine the patch level and enrichments reported by the scanner:
+
+- cpu: Oracle Critical Patch Updates released since the newest CPU the runtime contains, as mapped
+  by the scanner (cpu_release), or since its release date for older scanners
+- tzdata: age of the bundled time zone database, from the tzdata enricher
+- cacerts: expired and distrusted trusted root certificates, from the cacerts enricher
+
+A check without data, e.g. because the enricher did not run, is rated unknown and
+does not affect the host rating, which is the worst rating of any check of any runtime.
+"""
+
+from datetime import date, timedelta
+from typing import Optional
+
+GREEN = "green"
+AMBER = "amber"
+RED = "red"
+UNKNOWN = "unknown"
+
+# Ratings from best to worst, unknown ranks below green so it never hides a known rating
+RATING_ORDER = (UNKNOWN, GREEN, AMBER, RED)
+
+CHECKS = ("cpu", "tzdata", "cacerts")
+
+# Missed Critical Patch Updates from which a runtime is amber and red
+CPU_AMBER_BEHIND = 1
+CPU_RED_BEHIND = 2
+
+
+def worst(ratings) -> str:
+    """Get the worst of some ratings, unknown if there are none."""
+    return max(ratings, key=RATING_ORDER.index, default=UNKNOWN)
+
+
+def cpu_release_dates(start: date, end: date) -> list[date]:
+    """Get the dates of the Critical Patch Updates between two dates, inclusive.
+
+    CPUs are released in January, April, July and October, on the Tuesday closest to the 17th
+    until 2024 and on the third Tuesday since 2025, like the scanner computes them.
+    """
+    dates = []
+    for year in range(start.year, end.year + 1):
+        for month in (1, 4, 7, 10):
+            # Tuesday is weekday 1
+            if year < 2025:
+                day = date(year, month, 17)
+                day += timedelta(days=(1 - day.weekday() + 3) % 7 - 3)
+            else:
+                day = date(year, month, 15)
+                day += timedelta(days=(1 - day.weekday()) % 7)
+            if start <= day <= end:
+                dates.append(day)
+    return dates
+
+
+def rate_cpu(release_date: Optional[str], today: date) -> tuple[str, Optional[int]]:
+    """Rate the patch level of a runtime by the CPUs released since its CPU or release date.
+
+    Returns:
+        The rating and the number of missed CPUs, None if the release date is unknown
+    """
+    if not release_date:
+        return UNKNOWN, None
+    try:
+        released = date.fromisoformat(release_date)
+    except ValueError:
+        return UNKNOWN, None
+    # A build released on the day of a CPU contains it
+    behind = len(cpu_release_dates(released + timedelta(days=1), today))
+    if behind >= CPU_RED_BEHIND:
+        return RED, behind
+    if behind >= CPU_AMBER_BEHIND:
+        return AMBER, behind
+    return GREEN, behind
+
+
+def rate_tzdata(tzdata: Optional[dict]) -> str:
+    """Rate the time zone database of a runtime.
+
+    A current release is green, an outdated one from the year of the latest release or the
+    year before is amber, older ones are red.
+    """
+    if not tzdata or not tzdata.get("version"):
+        return UNKNOWN
+    if not tzdata.get("outdated"):
+        return GREEN
+    try:
+        years = int(tzdata["latest"][:4]) - int(tzdata["version"][:4])
+    except (KeyError, TypeError, ValueError):
+        return AMBER
+    return AMBER if years <= 1 else RED
+
+
+def rate_cacerts(cacerts: Optional[dict]) -> str:
+    """Rate the trusted root certificates of a runtime.
+
+    A runtime that still trusts a root the JDK removed for distrust is red, one that still
+    has expired roots is amber, as an updated runtime has neither. The age of the roots
+    tells nothing, certificate authorities rarely issue new ones.
+    """
+    if not cacerts or "certificates" not in cacerts:
+        return UNKNOWN
+    if cacerts.get("distrusted"):
+        return RED
+    if cacerts.get("expired"):
+        return AMBER
+    return GREEN
+
+
+def rate_runtime(
+    cpu_release: Optional[str], release_date: Optional[str], enrichments: Optional[dict], today: date
+) -> dict:
+    """Rate a runtime on every check.
+
+    Args:
+        cpu_release: Date of the newest CPU the runtime contains, see the scanner's patch level
+        release_date: Release date of the runtime, used without cpu_release
+        enrichments: Enrichments reported by the scanner
+        today: Date to rate at
+
+    Returns:
+        Dict with the overall rating, the rating of each check and its details
+    """
+    enrichments = enrichments or {}
+    cpu, cpu_behind = rate_cpu(cpu_release or release_date, today)
+    cacerts = rate_cacerts(enrichments.get("cacerts"))
+    tzdata = rate_tzdata(enrichments.get("tzdata"))
+    return {
+        "rating": worst((cpu, tzdata, cacerts)),
+        "cpu": cpu,
+        "cpu_behind": cpu_behind,
+        "cpu_release": cpu_release,
+        "release_date": release_date,
+        "tzdata": tzdata,
+        "tzdata_version": (enrichments.get("tzdata") or {}).get("version"),
+        "cacerts": cacerts,
+        "cacerts_expired": (enrichments.get("cacerts") or {}).get("expired"),
+        "cacerts_distrusted": (enrichments.get("cacerts") or {}).get("distrusted"),
+    }
+
+
+def rate_host(runtimes: list[dict]) -> dict:
+    """Combine the ratings of the runtimes of a computer.
+
+    Args:
+        runtimes: Runtime ratings from rate_runtime
+
+    Returns:
+        Dict with the host rating and the worst rating of each check
+    """
+    host = {check: worst(runtime[check] for runtime in runtimes) for check in CHECKS}
+    host["rating"] = worst(host[check] for check in CHECKS)
+    return host
@@ -8,6 +8,7 @@ from dotenv import load_dotenv
 from sqlalchemy.ext.asyncio import AsyncEngine, AsyncSession, async_sessionmaker, create_async_engine
 
 from jfind_svc.db_model import Base
+from jfind_svc.migrations import upgrade_schema
 
 # Load environment variables from .env files
 load_dotenv()  # Load from .env in current directory
@@ -50,9 +51,10 @@ async_session = async_sessionmaker(
 
 
 async def init_db():
-    """Initialize the database."""
+    """Initialize the database, creating missing tables and adding the columns of newer versions."""
     async with engine.begin() as conn:
         await conn.run_sync(Base.metadata.create_all)
+        await conn.run_sync(upgrade_schema)
 
 
 async def get_session() -> AsyncSession:
@@ -1,9 +1,9 @@
 """Database models for the JFind service."""
 
-from datetime import datetime
+from datetime import date, datetime
 from typing import Optional
 
-from sqlalchemy import ForeignKey, String
+from sqlalchemy import ForeignKey, Index, String, Text
 from sqlalchemy.ext.asyncio import AsyncAttrs
 from sqlalchemy.orm import DeclarativeBase, Mapped, mapped_column, relationship
 
@@ -20,8 +20,11 @@ class ScanInfo(Base):
     __tablename__ = "scan_info"
 
     id: Mapped[int] = mapped_column(primary_key=True)
+    scan_uuid: Mapped[Optional[str]] = mapped_column(String(36), nullable=True, index=True)
+    report_phase: Mapped[Optional[str]] = mapped_column(String(20), nullable=True)
     scan_ts: Mapped[datetime] = mapped_column()
     computer_name: Mapped[str] = mapped_column(String(255))
+    labels: Mapped[Optional[str]] = mapped_column(Text, nullable=True)  # JSON object of the labels of the report
     user_name: Mapped[str] = mapped_column(String(255))
     scan_duration: Mapped[str] = mapped_column(String(50))
     has_oracle_jdk: Mapped[bool] = mapped_column()
@@ -33,6 +36,87 @@ class ScanInfo(Base):
     java_runtimes: Mapped[list["JavaInfo"]] = relationship(back_populates="scan", cascade="all, delete-orphan")
 
 
+class ReportChunk(Base):
+    """Database model for a chunk of a split report, kept until all chunks have arrived."""
+
+    __tablename__ = "report_chunk"
+
+    id: Mapped[int] = mapped_column(primary_key=True)
+    scan_uuid: Mapped[str] = mapped_column(String(36), index=True)
+    sequence: Mapped[int] = mapped_column()
+    complete: Mapped[bool] = mapped_column()
+    payload: Mapped[str] = mapped_column(Text)  # JSON list of the runtimes in the chunk
+    created_at: Mapped[datetime] = mapped_column(default=datetime.utcnow)
+
+
+class EncryptedReport(Base):
+    """Database model for an encrypted report, kept encrypted at rest until it has been processed."""
+
+    __tablename__ = "encrypted_report"
+
+    id: Mapped[int] = mapped_column(primary_key=True)
+    key_id: Mapped[Optional[str]] = mapped_column(String(64), nullable=True)  # Thumbprint of the collector key
+    payload: Mapped[str] = mapped_column(Text)  # Compact JWE as sent by the scanner
+    error: Mapped[Optional[str]] = mapped_column(Text, nullable=True)  # Why the last attempt to process it failed
+    created_at: Mapped[datetime] = mapped_column(default=datetime.utcnow)
+
+
+class Acknowledgment(Base):
+    """Database model for an acknowledged finding, e.g. a licensed Oracle JDK, that no longer alerts.
+
+    Host and path are glob patterns, None matches any host, path or finding.
+    """
+
+    __tablename__ = "acknowledgment"
+
+    id: Mapped[int] = mapped_column(primary_key=True)
+    finding: Mapped[Optional[str]] = mapped_column(String(50), nullable=True)
+    host: Mapped[Optional[str]] = mapped_column(String(255), nullable=True)
+    path: Mapped[Optional[str]] = mapped_column(Text, nullable=True)
+    note: Mapped[str] = mapped_column(Text)
+    ticket: Mapped[Optional[str]] = mapped_column(String(100), nullable=True)
+    expires: Mapped[Optional[date]] = mapped_column(nullable=True)  # First day it no longer applies
+    created_at: Mapped[datetime] = mapped_column(default=datetime.utcnow)
+
+
+class HostLabel(Base):
+    """Database model for a label of a computer, e.g. env=prod, as of its newest report.
+
+    The labels of a computer are replaced by those of every newer report, so queries can
+    filter and group computers by their current labels.
+    """
+
+    __tablename__ = "host_label"
+    __table_args__ = (Index("ix_host_label_key_value", "key", "value"),)
+
+    id: Mapped[int] = mapped_column(primary_key=True)
+    computer_name: Mapped[str] = mapped_column(String(255), index=True)
+    key: Mapped[str] = mapped_column(String(63))
+    value: Mapped[str] = mapped_column(String(255))
+
+
+class HostRuntime(Base):
+    """Database model for the state of a runtime on a computer across scans.
+
+    Runtimes missing from the newest report of their computer are not deleted but
+    marked removed, so remediation stays visible.
+    """
+
+    __tablename__ = "host_runtime"
+
+    id: Mapped[int] = mapped_column(primary_key=True)
+    computer_name: Mapped[str] = mapped_column(String(255), index=True)
+    java_executable: Mapped[str] = mapped_column(String(1024))
+    java_vendor: Mapped[Optional[str]] = mapped_column(String(255), nullable=True)
+    java_version: Mapped[Optional[str]] = mapped_column(String(50), nullable=True)
+    is_oracle: Mapped[Optional[bool]] = mapped_column(nullable=True)
+    first_seen: Mapped[datetime] = mapped_column()
+    last_seen: Mapped[datetime] = mapped_column()
+    removed_at: Mapped[Optional[datetime]] = mapped_column(nullable=True, index=True)
+    quarantined: Mapped[Optional[bool]] = mapped_column(nullable=True)  # As of the newest report
+    packaged: Mapped[Optional[bool]] = mapped_column(nullable=True)  # In an archive, not installed
+
+
 class JavaInfo(Base):
     """Database model for Java runtime information."""
 
@@ -48,7 +132,14 @@ class JavaInfo(Base):
     java_version: Mapped[Optional[str]] = mapped_column(String(50), nullable=True)
     java_version_major: Mapped[Optional[int]] = mapped_column(nullable=True)
     java_version_update: Mapped[Optional[int]] = mapped_column(nullable=True)
+    release_date: Mapped[Optional[str]] = mapped_column(String(10), nullable=True)
+    cpu_release: Mapped[Optional[str]] = mapped_column(String(10), nullable=True)
+    patch_lag_quarters: Mapped[Optional[int]] = mapped_column(nullable=True)  # At the time of the scan
     require_license: Mapped[Optional[bool]] = mapped_column(nullable=True)
+    enrichments: Mapped[Optional[str]] = mapped_column(Text, nullable=True)  # JSON object of the scanner's enrichments
+    quarantined: Mapped[Optional[bool]] = mapped_column(nullable=True)
+    packaged: Mapped[Optional[bool]] = mapped_column(nullable=True)  # In an archive found by -scan-archives
+    archive: Mapped[Optional[str]] = mapped_column(String(1024), nullable=True)
     created_at: Mapped[datetime] = mapped_column(default=datetime.utcnow)
 
     # Relationship to ScanInfo
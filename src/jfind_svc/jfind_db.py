@@ -1,14 +1,98 @@
 """Database operations for JFind scanner results."""
 
-from datetime import datetime
+import json
+from datetime import date, datetime, timedelta, timezone
 from typing import Optional
 
-from sqlalchemy import select
+from sqlalchemy import and_, delete, func, or_, select, update
 from sqlalchemy.ext.asyncio import AsyncSession
 from sqlalchemy.orm import joinedload
 
-from jfind_svc.db_model import JavaInfo, ScanInfo
-from jfind_svc.model import ScannerResults
+from jfind_svc.compliance import RATING_ORDER, rate_host, rate_runtime
+from jfind_svc.db_model import (
+    Acknowledgment,
+    EncryptedReport,
+    HostLabel,
+    HostRuntime,
+    JavaInfo,
+    ReportChunk,
+    ScanInfo,
+)
+from jfind_svc.model import Acknowledgment as AcknowledgmentModel
+from jfind_svc.model import JavaRuntime, ScannerResults
+from jfind_svc.paths import match_glob, match_host, within_roots
+
+# A preliminary report is accepted only this long after its scan started. One that arrives
+# later was held up, e.g. in the spool of the scanner, and the final report is due.
+PRELIMINARY_WINDOW = timedelta(hours=6)
+
+
+async def save_report_chunk(session: AsyncSession, results: ScannerResults) -> Optional[ScannerResults]:
+    """Store a chunk of a split report and reassemble the report once it is complete.
+
+    Args:
+        session: Database session
+        results: Chunk of a report with meta.chunk and meta.scan_id set
+
+    A resent chunk replaces the earlier copy. The report is complete when the chunk
+    marked complete and all chunks before it have arrived, in any order.
+
+    Returns:
+        The reassembled report with all runtimes, or None while chunks are missing
+    """
+    chunk = results.meta.chunk
+    scan_uuid = results.meta.scan_id
+    await session.execute(
+        delete(ReportChunk).where(ReportChunk.scan_uuid == scan_uuid, ReportChunk.sequence == chunk.sequence)
+    )
+    session.add(
+        ReportChunk(
+            scan_uuid=scan_uuid,
+            sequence=chunk.sequence,
+            complete=chunk.complete,
+            payload=json.dumps([runtime.model_dump() for runtime in results.result]),
+        )
+    )
+    await session.commit()
+
+    stmt = select(ReportChunk).where(ReportChunk.scan_uuid == scan_uuid).order_by(ReportChunk.sequence)
+    chunks = list((await session.execute(stmt)).scalars().all())
+    last = next((c for c in chunks if c.complete), None)
+    if last is None or [c.sequence for c in chunks] != list(range(1, last.sequence + 1)):
+        return None
+
+    runtimes = [JavaRuntime(**runtime) for c in chunks for runtime in json.loads(c.payload)]
+    await session.execute(delete(ReportChunk).where(ReportChunk.scan_uuid == scan_uuid))
+    meta = results.meta.model_copy(update={"chunk": None})
+    return ScannerResults(meta=meta, result=runtimes)
+
+
+async def is_outdated_preliminary(session: AsyncSession, results: ScannerResults, now: Optional[datetime] = None) -> bool:
+    """Check if a report is a preliminary report that must not be saved.
+
+    Args:
+        session: Database session
+        results: Scanner results from the API
+        now: Time the report was received, default now
+
+    A preliminary report is outdated once the final report of its scan has been saved,
+    or when it arrives more than PRELIMINARY_WINDOW after the scan started.
+    """
+    meta = results.meta
+    if meta.report_phase != "preliminary":
+        return False
+    scan_ts = datetime.fromisoformat(meta.scan_ts)
+    if scan_ts.tzinfo is None:
+        scan_ts = scan_ts.replace(tzinfo=timezone.utc)
+    if (now or datetime.now(timezone.utc)) - scan_ts > PRELIMINARY_WINDOW:
+        return True
+    if not meta.scan_id:
+        return False
+    stmt = select(ScanInfo.id).where(
+        ScanInfo.scan_uuid == meta.scan_id,
+        or_(ScanInfo.report_phase.is_(None), ScanInfo.report_phase != "preliminary"),
+    )
+    return (await session.execute(stmt.limit(1))).first() is not None
 
 
 async def save_scanner_results(session: AsyncSession, results: ScannerResults) -> ScanInfo:
@@ -18,13 +102,28 @@ async def save_scanner_results(session: AsyncSession, results: ScannerResults) -
         session: Database session
         results: Scanner results from the API
 
+    A report with a scan ID supersedes earlier reports of the same scan, e.g. the
+    final report of a two-phase scan replaces the preliminary one. A preliminary
+    report only replaces preliminary reports, never a final one.
+
     Returns:
         Created ScanInfo record
     """
+    if results.meta.scan_id:
+        same_scan = ScanInfo.scan_uuid == results.meta.scan_id
+        if results.meta.report_phase == "preliminary":
+            same_scan = and_(same_scan, ScanInfo.report_phase == "preliminary")
+        superseded = select(ScanInfo.id).where(same_scan)
+        await session.execute(delete(JavaInfo).where(JavaInfo.scan_id.in_(superseded)))
+        await session.execute(delete(ScanInfo).where(same_scan))
+
     # Create scan info record
     scan_info = ScanInfo(
+        scan_uuid=results.meta.scan_id,
+        report_phase=results.meta.report_phase,
         scan_ts=datetime.fromisoformat(results.meta.scan_ts),
         computer_name=results.meta.computer_name,
+        labels=json.dumps(results.meta.labels) if results.meta.labels else None,
         user_name=results.meta.user_name,
         scan_duration=results.meta.scan_duration,
         has_oracle_jdk=results.meta.has_oracle_jdk,
@@ -46,20 +145,360 @@ async def save_scanner_results(session: AsyncSession, results: ScannerResults) -
             java_version=runtime.java_version,
             java_version_major=runtime.java_version_major,
             java_version_update=runtime.java_version_update,
+            release_date=runtime.release_date,
+            cpu_release=runtime.cpu_release,
+            patch_lag_quarters=runtime.patch_lag_quarters,
+            enrichments=json.dumps(runtime.enrichments) if runtime.enrichments else None,
+            quarantined=runtime.quarantined,
+            packaged=runtime.packaged,
+            archive=runtime.archive,
         )
         session.add(java_info)
 
+    await update_host_runtimes(session, results, scan_info.scan_ts)
+    await update_host_labels(session, results, scan_info.scan_ts)
+
     await session.commit()
     await session.refresh(scan_info, ["java_runtimes"])  # Refresh relationships
     return scan_info
 
 
-async def get_latest_scans(session: AsyncSession, limit: int = 10) -> list[ScanInfo]:
+async def update_host_runtimes(session: AsyncSession, results: ScannerResults, scan_ts: datetime) -> None:
+    """Update the per-computer runtime state from a report.
+
+    Args:
+        session: Database session
+        results: Scanner results from the API
+        scan_ts: Timestamp of the scan
+
+    Runtimes in the report are marked present, a runtime that was removed and is
+    reported again is restored. Quarantined runtimes are reported by the scanner and
+    stay present, flagged quarantined. Runtimes of the computer below the scan roots that are
+    missing from the report get a removal timestamp, if the scan was complete. Scans
+    limited by depth, exclusions, shards, listed paths or a result or time limit, and
+    preliminary reports, cover only part of the file system, so they never remove
+    anything. Reports older than the known state are ignored.
+    """
+    # Stored timestamps are naive UTC
+    if scan_ts.tzinfo is not None:
+        scan_ts = scan_ts.astimezone(timezone.utc).replace(tzinfo=None)
+
+    stmt = select(HostRuntime).where(HostRuntime.computer_name == results.meta.computer_name)
+    known = {runtime.java_executable: runtime for runtime in (await session.execute(stmt)).scalars().all()}
+    if any(runtime.last_seen > scan_ts for runtime in known.values()):
+        return
+
+    reported = set()
+    for runtime in results.result:
+        reported.add(runtime.java_executable)
+        host_runtime = known.get(runtime.java_executable)
+        if host_runtime is None:
+            host_runtime = HostRuntime(
+                computer_name=results.meta.computer_name,
+                java_executable=runtime.java_executable,
+                first_seen=scan_ts,
+            )
+            session.add(host_runtime)
+        host_runtime.last_seen = scan_ts
+        host_runtime.removed_at = None
+        host_runtime.quarantined = bool(runtime.quarantined)
+        host_runtime.packaged = bool(runtime.packaged)
+        if runtime.java_version is not None:
+            host_runtime.java_vendor = runtime.java_vendor
+            host_runtime.java_version = runtime.java_version
+            host_runtime.is_oracle = runtime.is_oracle
+
+    meta = results.meta
+    if meta.report_phase == "preliminary" or meta.shard or meta.complete is not True or not meta.scan_roots:
+        return
+    for path, host_runtime in known.items():
+        if path not in reported and host_runtime.removed_at is None and within_roots(path, meta.scan_roots):
+            host_runtime.removed_at = scan_ts
+
+
+async def update_host_labels(session: AsyncSession, results: ScannerResults, scan_ts: datetime) -> None:
+    """Replace the labels of a computer with those of a report, unless a newer report of it is saved.
+
+    Args:
+        session: Database session
+        results: Scanner results from the API
+        scan_ts: Timestamp of the scan
+
+    A report without labels removes the labels of the computer, like a scanner whose labels
+    were taken out of its configuration.
+    """
+    # Stored timestamps are naive UTC
+    if scan_ts.tzinfo is not None:
+        scan_ts = scan_ts.astimezone(timezone.utc).replace(tzinfo=None)
+
+    computer_name = results.meta.computer_name
+    newest = (await session.execute(select(func.max(ScanInfo.scan_ts)).where(ScanInfo.computer_name == computer_name))).scalar()
+    if newest is not None and newest > scan_ts:
+        return
+    await session.execute(delete(HostLabel).where(HostLabel.computer_name == computer_name))
+    for key, value in (results.meta.labels or {}).items():
+        session.add(HostLabel(computer_name=computer_name, key=key, value=value))
+
+
+def _labeled_computers(labels: dict[str, str]):
+    """Select the computers that currently carry all the labels."""
+    matches = or_(*(and_(HostLabel.key == key, HostLabel.value == value) for key, value in labels.items()))
+    return (
+        select(HostLabel.computer_name)
+        .where(matches)
+        .group_by(HostLabel.computer_name)
+        .having(func.count(HostLabel.id) == len(labels))
+    )
+
+
+def _filter_labels(stmt, computer_name, labels: Optional[dict[str, str]]):
+    """Restrict a query to the computers with all the labels, computer_name is the column to match."""
+    if not labels:
+        return stmt
+    return stmt.where(computer_name.in_(_labeled_computers(labels)))
+
+
+async def get_host_labels(session: AsyncSession) -> dict[str, dict[str, str]]:
+    """Get the current labels of all computers.
+
+    Returns:
+        Dict of the labels by computer name, computers without labels are left out
+    """
+    rows = (await session.execute(select(HostLabel).order_by(HostLabel.computer_name, HostLabel.key))).scalars().all()
+    labels: dict[str, dict[str, str]] = {}
+    for row in rows:
+        labels.setdefault(row.computer_name, {})[row.key] = row.value
+    return labels
+
+
+async def get_labels(session: AsyncSession) -> list[dict]:
+    """Get the label keys and values in use with the number of computers carrying each.
+
+    Returns:
+        List of {"key", "values": [{"value", "computers"}]}, ordered by key and value
+    """
+    stmt = (
+        select(HostLabel.key, HostLabel.value, func.count(HostLabel.id))
+        .group_by(HostLabel.key, HostLabel.value)
+        .order_by(HostLabel.key, HostLabel.value)
+    )
+    keys: dict[str, dict] = {}
+    for key, value, computers in (await session.execute(stmt)).all():
+        keys.setdefault(key, {"key": key, "values": []})["values"].append({"value": value, "computers": computers})
+    return list(keys.values())
+
+
+async def get_label_summary(session: AsyncSession, key: str, labels: Optional[dict[str, str]] = None) -> list[dict]:
+    """Aggregate the latest scans of the computers by the value of a label.
+
+    Args:
+        session: Database session
+        key: Label to group the computers by
+        labels: Only count computers with all these labels
+
+    Computers without the label are counted under the value None.
+
+    Returns:
+        List of {"value", "computers", "runtimes", "oracle_computers", "ratings"} ordered by value,
+        ratings counting the computers by compliance rating
+    """
+    values = {computer: host_labels.get(key) for computer, host_labels in (await get_host_labels(session)).items()}
+    groups: dict[Optional[str], dict] = {}
+
+    def group(computer_name: str) -> dict:
+        value = values.get(computer_name)
+        return groups.setdefault(
+            value,
+            {
+                "value": value,
+                "computers": 0,
+                "runtimes": 0,
+                "oracle_computers": 0,
+                "ratings": {rating: 0 for rating in reversed(RATING_ORDER)},
+            },
+        )
+
+    stmt = _filter_labels(_latest_scans(), ScanInfo.computer_name, labels)
+    for scan in (await session.execute(stmt)).scalars().all():
+        summary = group(scan.computer_name)
+        summary["computers"] += 1
+        summary["runtimes"] += scan.count_result
+        summary["oracle_computers"] += scan.has_oracle_jdk
+    for host in await get_compliance(session, labels=labels):
+        group(host["computer_name"])["ratings"][host["rating"]] += 1
+
+    return sorted(groups.values(), key=lambda summary: (summary["value"] is None, summary["value"] or ""))
+
+
+async def save_encrypted_report(session: AsyncSession, payload: str, key_id: Optional[str]) -> int:
+    """Store an encrypted report until it is processed.
+
+    Args:
+        session: Database session
+        payload: Compact JWE as sent by the scanner
+        key_id: ID of the collector key the report is encrypted to
+
+    Returns:
+        ID of the stored report
+    """
+    report = EncryptedReport(key_id=key_id, payload=payload)
+    session.add(report)
+    await session.commit()
+    return report.id
+
+
+async def get_encrypted_reports(session: AsyncSession, key_ids: list[str]) -> list[EncryptedReport]:
+    """Get the stored encrypted reports for the given keys, oldest first."""
+    stmt = select(EncryptedReport).where(EncryptedReport.key_id.in_(key_ids)).order_by(EncryptedReport.id)
+    return list((await session.execute(stmt)).scalars().all())
+
+
+async def finish_encrypted_report(session: AsyncSession, report_id: int, error: Optional[str] = None) -> None:
+    """Delete a processed encrypted report, or keep it with the error if it could not be processed."""
+    if error is None:
+        await session.execute(delete(EncryptedReport).where(EncryptedReport.id == report_id))
+    else:
+        await session.execute(update(EncryptedReport).where(EncryptedReport.id == report_id).values(error=error))
+    await session.commit()
+
+
+async def create_acknowledgment(session: AsyncSession, ack: AcknowledgmentModel) -> Acknowledgment:
+    """Save an acknowledgment.
+
+    Args:
+        session: Database session
+        ack: Acknowledgment to save, its id is ignored
+
+    Returns:
+        The saved acknowledgment with its ID
+    """
+    row = Acknowledgment(
+        finding=ack.finding, host=ack.host, path=ack.path, note=ack.note, ticket=ack.ticket, expires=ack.expires
+    )
+    session.add(row)
+    await session.commit()
+    return row
+
+
+async def get_acknowledgments(session: AsyncSession, computer_name: Optional[str] = None) -> list[Acknowledgment]:
+    """Get the acknowledgments, optionally only those in effect for a computer.
+
+    Args:
+        session: Database session
+        computer_name: Only return acknowledgments for this computer that have not expired
+
+    Returns:
+        List of acknowledgments, oldest first
+    """
+    acks = (await session.execute(select(Acknowledgment).order_by(Acknowledgment.id))).scalars().all()
+    if computer_name is None:
+        return list(acks)
+    today = datetime.now(timezone.utc).date()
+    return [ack for ack in acks if acknowledgment_applies(ack, computer_name, today)]
+
+
+async def delete_acknowledgment(session: AsyncSession, ack_id: int) -> bool:
+    """Delete an acknowledgment.
+
+    Returns:
+        True if the acknowledgment existed
+    """
+    result = await session.execute(delete(Acknowledgment).where(Acknowledgment.id == ack_id))
+    await session.commit()
+    return result.rowcount > 0
+
+
+def acknowledges_everything(ack: AcknowledgmentModel) -> bool:
+    """Check if an acknowledgment matches every computer and every path, e.g. with host "*" and no path."""
+    return not any(pattern and pattern.strip("*/ ") for pattern in (ack.host, ack.path))
+
+
+def acknowledgment_applies(ack: Acknowledgment | AcknowledgmentModel, computer_name: str, today: date) -> bool:
+    """Check if an acknowledgment covers a computer and has not expired."""
+    if ack.expires is not None and today >= ack.expires:
+        return False
+    return not ack.host or match_host(ack.host, computer_name)
+
+
+def acknowledgment_covers(ack: Acknowledgment | AcknowledgmentModel, finding: str, java_executable: str) -> bool:
+    """Check if an acknowledgment covers a finding of a runtime, matching its path like the scanner does."""
+    if ack.finding and ack.finding != finding:
+        return False
+    return not ack.path or match_glob(ack.path, java_executable)
+
+
+async def is_known_computer(session: AsyncSession, computer_name: str) -> bool:
+    """Check if any report of a computer has been saved.
+
+    Args:
+        session: Database session
+        computer_name: Name of the computer
+
+    Returns:
+        True if there is at least one scan of the computer
+    """
+    stmt = select(ScanInfo.id).where(ScanInfo.computer_name == computer_name).limit(1)
+    return (await session.execute(stmt)).first() is not None
+
+
+async def get_present_oracle_runtimes(session: AsyncSession, computer_name: str) -> list[HostRuntime]:
+    """Get the Oracle runtimes currently installed on a computer.
+
+    Args:
+        session: Database session
+        computer_name: Name of the computer
+
+    Returns:
+        List of HostRuntime records that are not removed, leaving out runtimes packaged in archives
+    """
+    stmt = select(HostRuntime).where(
+        HostRuntime.computer_name == computer_name,
+        HostRuntime.is_oracle == True,  # noqa: E712
+        HostRuntime.removed_at.is_(None),
+        _installed(HostRuntime),
+    )
+    return list((await session.execute(stmt)).scalars().all())
+
+
+async def get_removed_runtimes(
+    session: AsyncSession,
+    oracle_only: bool = False,
+    since: Optional[datetime] = None,
+    limit: int = 100,
+    labels: Optional[dict[str, str]] = None,
+) -> list[HostRuntime]:
+    """Get runtimes that have been removed from their computers, most recent first.
+
+    Args:
+        session: Database session
+        oracle_only: Only return Oracle runtimes
+        since: Only return runtimes removed at or after this time
+        limit: Maximum number of results to return
+        labels: Only return runtimes of computers with all these labels
+
+    Returns:
+        List of HostRuntime records with removed_at set
+    """
+    stmt = select(HostRuntime).where(HostRuntime.removed_at.is_not(None))
+    if oracle_only:
+        stmt = stmt.where(HostRuntime.is_oracle == True)  # noqa: E712
+    if since is not None:
+        stmt = stmt.where(HostRuntime.removed_at >= since)
+    stmt = _filter_labels(stmt, HostRuntime.computer_name, labels)
+    stmt = stmt.order_by(HostRuntime.removed_at.desc()).limit(limit)
+    result = await session.execute(stmt)
+    return list(result.scalars().all())
+
+
+async def get_latest_scans(
+    session: AsyncSession, limit: int = 10, labels: Optional[dict[str, str]] = None
+) -> list[ScanInfo]:
     """Get the latest scans with their Java runtime information.
 
     Args:
         session: Database session
         limit: Maximum number of scans to return
+        labels: Only return scans of computers with all these labels
 
     Returns:
         List of ScanInfo records with related JavaInfo records
@@ -70,6 +509,7 @@ async def get_latest_scans(session: AsyncSession, limit: int = 10) -> list[ScanI
         .order_by(ScanInfo.scan_ts.desc())
         .limit(limit)
     )
+    query = _filter_labels(query, ScanInfo.computer_name, labels)
     result = await session.execute(query)
     return list(result.unique().scalars().all())
 
@@ -115,22 +555,26 @@ async def get_scans_by_computer_name(session: AsyncSession, computer_name: str,
     return list(result.unique().scalars().all())
 
 
-async def get_oracle_jdks(session: AsyncSession, limit: int = 10) -> list[JavaInfo]:
-    """Get all Oracle JDKs from the database.
+async def get_oracle_jdks(
+    session: AsyncSession, limit: int = 10, labels: Optional[dict[str, str]] = None
+) -> list[JavaInfo]:
+    """Get all installed Oracle JDKs from the database.
 
     Args:
         session: Database session
         limit: Maximum number of results to return
+        labels: Only return runtimes of computers with all these labels
 
     Returns:
-        List of JavaInfo objects for Oracle JDKs
+        List of JavaInfo objects for Oracle JDKs, leaving out those packaged in archives
     """
     stmt = (
         select(JavaInfo)
-        .where(JavaInfo.is_oracle == True)  # noqa: E712
+        .where(JavaInfo.is_oracle == True, _installed(JavaInfo))  # noqa: E712
         .order_by(JavaInfo.id.desc())
         .limit(limit)
     )
+    stmt = _filter_labels(stmt, JavaInfo.computer_name, labels)
     result = await session.execute(stmt)
     return list(result.scalars().all())
 
@@ -143,7 +587,8 @@ async def has_oracle_jdk(session: AsyncSession, computer_name: str) -> Optional[
         computer_name: Name of computer to check
 
     Returns:
-        True if the computer has Oracle JDK, False if it doesn't, None if computer not found
+        True if the computer has Oracle JDK, False if it doesn't, None if computer not found.
+        An Oracle JDK packaged in an archive is not installed.
     """
     # First check if we have any records for this computer
     stmt = (
@@ -161,8 +606,243 @@ async def has_oracle_jdk(session: AsyncSession, computer_name: str) -> Optional[
         .where(
             JavaInfo.computer_name == computer_name,
             JavaInfo.is_oracle == True,  # noqa: E712
+            _installed(JavaInfo),
         )
         .limit(1)
     )
     result = await session.execute(stmt)
     return result.first() is not None
+
+
+def _installed(model):
+    """Condition leaving out the runtimes packaged in archives, which are not installed."""
+    return or_(model.packaged.is_(None), model.packaged == False)  # noqa: E712
+
+
+def _latest():
+    """Select the timestamp of the latest scan of every computer."""
+    return (
+        select(ScanInfo.computer_name, func.max(ScanInfo.scan_ts).label("scan_ts"))
+        .group_by(ScanInfo.computer_name)
+        .subquery()
+    )
+
+
+def _latest_scans():
+    """Select the latest scan of every computer."""
+    latest = _latest()
+    return select(ScanInfo).join(
+        latest, (ScanInfo.computer_name == latest.c.computer_name) & (ScanInfo.scan_ts == latest.c.scan_ts)
+    )
+
+
+def _latest_runtimes():
+    """Select the runtimes of the latest scan of every computer."""
+    latest = _latest()
+    return (
+        select(JavaInfo)
+        .join(ScanInfo, JavaInfo.scan_id == ScanInfo.id)
+        .join(latest, (ScanInfo.computer_name == latest.c.computer_name) & (ScanInfo.scan_ts == latest.c.scan_ts))
+    )
+
+
+async def get_update_drift(session: AsyncSession, labels: Optional[dict[str, str]] = None) -> list[dict]:
+    """Find computers running several update levels of the same distribution and major version.
+
+    Only the installed runtimes of the latest scan of every computer are considered, so
+    runtimes removed since an earlier scan and runtimes packaged in archives don't count.
+
+    Args:
+        session: Database session
+        labels: Only consider computers with all these labels
+
+    Returns:
+        List of dicts with computer_name, java_vendor, java_version_major, versions and paths
+    """
+    stmt = (
+        _latest_runtimes()
+        .where(JavaInfo.java_version_major.is_not(None), _installed(JavaInfo))
+        .order_by(JavaInfo.computer_name, JavaInfo.java_vendor, JavaInfo.java_version_major, JavaInfo.java_version_update)
+    )
+    stmt = _filter_labels(stmt, JavaInfo.computer_name, labels)
+    result = await session.execute(stmt)
+
+    groups: dict[tuple, dict] = {}
+    for java in result.scalars().all():
+        key = (java.computer_name, java.java_vendor, java.java_version_major)
+        group = groups.setdefault(
+            key,
+            {
+                "computer_name": java.computer_name,
+                "java_vendor": java.java_vendor,
+                "java_version_major": java.java_version_major,
+                "versions": [],
+                "paths": [],
+            },
+        )
+        if java.java_version not in group["versions"]:
+            group["versions"].append(java.java_version)
+        group["paths"].append(java.java_executable)
+
+    return [group for group in groups.values() if len(group["versions"]) > 1]
+
+
+async def get_compliance(
+    session: AsyncSession, computer_name: Optional[str] = None, labels: Optional[dict[str, str]] = None
+) -> list[dict]:
+    """Rate the computers by the patch level, time zone database and trusted roots of their runtimes.
+
+    Only the installed runtimes of the latest scan of every computer are considered, runtimes
+    packaged in archives are not run. See compliance.py for the ratings.
+
+    Args:
+        session: Database session
+        computer_name: Only rate this computer
+        labels: Only rate computers with all these labels
+
+    Returns:
+        List of dicts with computer_name, labels, scan_ts, rating, the rating of each check and
+        the rated runtimes, ordered by computer name
+    """
+    stmt = _latest_runtimes().add_columns(ScanInfo.scan_ts).where(_installed(JavaInfo))
+    if computer_name is not None:
+        stmt = stmt.where(JavaInfo.computer_name == computer_name)
+    stmt = _filter_labels(stmt, JavaInfo.computer_name, labels)
+    stmt = stmt.order_by(JavaInfo.computer_name, JavaInfo.java_executable)
+    result = await session.execute(stmt)
+    host_labels = await get_host_labels(session)
+
+    today = datetime.now(timezone.utc).date()
+    hosts: dict[str, dict] = {}
+    for java, scan_ts in result.all():
+        host = hosts.setdefault(
+            java.computer_name,
+            {
+                "computer_name": java.computer_name,
+                "labels": host_labels.get(java.computer_name, {}),
+                "scan_ts": scan_ts.isoformat(),
+                "runtimes": [],
+            },
+        )
+        enrichments = json.loads(java.enrichments) if java.enrichments else None
+        rating = rate_runtime(java.cpu_release, java.release_date, enrichments, today)
+        host["runtimes"].append(
+            {
+                "java_executable": java.java_executable,
+                "java_vendor": java.java_vendor,
+                "java_version": java.java_version,
+                **rating,
+            }
+        )
+
+    return [{**host, **rate_host(host["runtimes"])} for host in hosts.values()]
+
+
+# Columns of the inventory rows /jfind/grafana/summary can group by, besides the label_<key> columns
+INVENTORY_GROUPS = ("computer_name", "java_vendor", "java_version", "java_version_major", "is_oracle", "packaged", "rating")
+
+
+async def get_inventory(session: AsyncSession, labels: Optional[dict[str, str]] = None) -> list[dict]:
+    """Get the runtimes of the latest scan of every computer as flat rows, e.g. for Grafana tables.
+
+    Args:
+        session: Database session
+        labels: Only runtimes of computers with all these labels
+
+    The labels of the computer are columns of their own, label_<key>, so they can be
+    filtered and grouped like the other columns.
+
+    Returns:
+        List of {"computer_name", "scan_ts", "java_executable", "java_vendor", "java_version", "java_version_major",
+        "is_oracle", "packaged", "patch_lag_quarters", "cpu_behind", "rating", "label_<key>"...} ordered by computer
+        name and path
+    """
+    stmt = _latest_runtimes().add_columns(ScanInfo.scan_ts)
+    stmt = _filter_labels(stmt, JavaInfo.computer_name, labels)
+    stmt = stmt.order_by(JavaInfo.computer_name, JavaInfo.java_executable)
+    result = await session.execute(stmt)
+    host_labels = await get_host_labels(session)
+
+    today = datetime.now(timezone.utc).date()
+    rows = []
+    for java, scan_ts in result.all():
+        enrichments = json.loads(java.enrichments) if java.enrichments else None
+        rating = rate_runtime(java.cpu_release, java.release_date, enrichments, today)
+        rows.append(
+            {
+                "computer_name": java.computer_name,
+                "scan_ts": scan_ts.isoformat() + "Z",
+                "java_executable": java.java_executable,
+                "java_vendor": java.java_vendor,
+                "java_version": java.java_version,
+                "java_version_major": java.java_version_major,
+                "is_oracle": java.is_oracle,
+                "packaged": bool(java.packaged),
+                "patch_lag_quarters": java.patch_lag_quarters,
+                "cpu_behind": rating["cpu_behind"],
+                "rating": rating["rating"],
+                **{f"label_{key}": value for key, value in host_labels.get(java.computer_name, {}).items()},
+            }
+        )
+    return rows
+
+
+async def get_inventory_summary(session: AsyncSession, by: str, labels: Optional[dict[str, str]] = None) -> list[dict]:
+    """Count the runtimes of the latest scans by a column of the inventory, e.g. for Grafana pie charts.
+
+    Args:
+        session: Database session
+        by: Column of the inventory rows to group by, one of INVENTORY_GROUPS or label_<key>
+        labels: Only count runtimes of computers with all these labels
+
+    Runtimes without a value, e.g. of computers without the label, are counted under None.
+    Oracle runtimes packaged in archives are not counted as Oracle runtimes, as they are not installed.
+
+    Returns:
+        List of {"group", "runtimes", "computers", "oracle_runtimes"}, most runtimes first
+    """
+    groups: dict = {}
+    for row in await get_inventory(session, labels):
+        value = row.get(by)
+        group = groups.setdefault(value, {"group": value, "runtimes": 0, "computers": set(), "oracle_runtimes": 0})
+        group["runtimes"] += 1
+        group["computers"].add(row["computer_name"])
+        group["oracle_runtimes"] += bool(row["is_oracle"]) and not row["packaged"]
+    summary = [{**group, "computers": len(group["computers"])} for group in groups.values()]
+    return sorted(summary, key=lambda group: (-group["runtimes"], str(group["group"])))
+
+
+async def get_scan_history(session: AsyncSession, days: int, labels: Optional[dict[str, str]] = None) -> list[dict]:
+    """Count the computers and runtimes reported per day, e.g. for Grafana time series.
+
+    Args:
+        session: Database session
+        days: Number of days to return, up to today
+        labels: Only count computers with all these labels as of their newest report
+
+    A computer reporting several times a day counts with its last report of the day. Days
+    without reports are left out rather than counted as zero.
+
+    Returns:
+        List of {"time", "computers", "runtimes", "oracle_computers"} ordered by time, time being
+        midnight UTC of the day
+    """
+    # Scan timestamps are stored without time zone in UTC
+    since = datetime.now(timezone.utc).replace(tzinfo=None, hour=0, minute=0, second=0, microsecond=0)
+    since -= timedelta(days=days - 1)
+    stmt = select(ScanInfo).where(ScanInfo.scan_ts >= since).order_by(ScanInfo.scan_ts)
+    stmt = _filter_labels(stmt, ScanInfo.computer_name, labels)
+    result = await session.execute(stmt)
+
+    last: dict[tuple, ScanInfo] = {}
+    for scan in result.scalars().all():
+        last[(scan.scan_ts.date(), scan.computer_name)] = scan
+    history: dict[date, dict] = {}
+    for (day, _), scan in sorted(last.items()):
+        point = history.setdefault(
+            day, {"time": f"{day.isoformat()}T00:00:00Z", "computers": 0, "runtimes": 0, "oracle_computers": 0}
+        )
+        point["computers"] += 1
+        point["runtimes"] += scan.count_result
+        point["oracle_computers"] += scan.has_oracle_jdk
+    return list(history.values())
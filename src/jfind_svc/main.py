@@ -12,6 +12,8 @@ from fastapi.middleware.cors import CORSMiddleware
 from jfind_svc.db import init_db
 from jfind_svc.routes import health as health_router
 from jfind_svc.routes import router as api_router
+from jfind_svc.routes.jfind import process_encrypted_reports
+from jfind_svc.webhooks import get_webhook_secret, get_webhook_urls
 
 
 @asynccontextmanager
@@ -24,6 +26,8 @@ async def lifespan(_app: FastAPI):
     """
     # Startup: Initialize the database
     await init_db()
+    # Process encrypted reports stored while their key was not configured
+    await process_encrypted_reports()
     yield
     # Shutdown: Clean up if needed
     # Currently no cleanup needed
@@ -58,6 +62,8 @@ class ServerConfig(NamedTuple):
     host: str = "0.0.0.0"
     port: int = 8000
     database_url: Optional[str] = None
+    webhook_urls: Optional[list[str]] = None
+    payload_keys: Optional[list[str]] = None
 
 
 def parse_args() -> ServerConfig:
@@ -66,10 +72,25 @@ def parse_args() -> ServerConfig:
     parser.add_argument("--port", type=int, default=8000, help="Port to run the server on (default: 8000)")
     parser.add_argument("--host", type=str, default="0.0.0.0", help="Host to run the server on (default: 0.0.0.0)")
     parser.add_argument("--database-url", type=str, help="Database URL (overrides environment variable)")
+    parser.add_argument(
+        "--webhook-url", type=str, action="append", help="URL to send webhook events to, repeatable (overrides environment variable)"
+    )
+    parser.add_argument(
+        "--payload-key",
+        type=str,
+        action="append",
+        help="PEM file of an X25519 private key to decrypt reports with, repeatable (overrides environment variable)",
+    )
 
     # Don't exit on error, just use defaults
     args, _ = parser.parse_known_args()
-    return ServerConfig(host=args.host, port=args.port, database_url=args.database_url)
+    return ServerConfig(
+        host=args.host,
+        port=args.port,
+        database_url=args.database_url,
+        webhook_urls=args.webhook_url,
+        payload_keys=args.payload_key,
+    )
 
 
 def run():
@@ -77,6 +98,12 @@ def run():
     config = parse_args()
     if config.database_url:
         os.environ["DATABASE_URL"] = config.database_url
+    if config.webhook_urls:
+        os.environ["JFIND_WEBHOOK_URLS"] = ",".join(config.webhook_urls)
+    if config.payload_keys:
+        os.environ["JFIND_PAYLOAD_KEYS"] = ",".join(config.payload_keys)
+    if get_webhook_urls() and get_webhook_secret() is None:
+        print("Warning: JFIND_WEBHOOK_SECRET is not set, no webhook events will be sent")
     uvicorn.run(app, host=config.host, port=config.port)
 
 
@@ -0,0 +1,39 @@
+"""Schema upgrades of databases created by older versions of the collector.
+
+Base.metadata.create_all creates the tables that are missing but never alters a table
+that exists, so the columns added to a table since it was created are added here.
+"""
+
+from sqlalchemy import Connection, inspect, text
+
+from jfind_svc.db_model import Base
+
+
+def upgrade_schema(conn: Connection) -> list[str]:
+    """Add the missing columns and their indexes to the existing tables.
+
+    Args:
+        conn: Connection of a transaction, after create_all
+
+    Only nullable columns are added, the rows already stored have no value for them.
+    A missing column that is required cannot be added and raises a RuntimeError.
+
+    Returns:
+        The added columns as table.column
+    """
+    inspector = inspect(conn)
+    preparer = conn.dialect.identifier_preparer
+    added = []
+    for table in Base.metadata.sorted_tables:
+        existing = {column["name"] for column in inspector.get_columns(table.name)}
+        missing = [column for column in table.columns if column.name not in existing]
+        for column in missing:
+            if not column.nullable:
+                raise RuntimeError(f"column {table.name}.{column.name} is required and cannot be added to existing rows")
+            column_type = column.type.compile(dialect=conn.dialect)
+            conn.execute(text(f"ALTER TABLE {preparer.format_table(table)} ADD COLUMN {preparer.format_column(column)} {column_type}"))
+            added.append(f"{table.name}.{column.name}")
+        if missing:
+            for index in table.indexes:
+                index.create(conn, checkfirst=True)
+    return added
@@ -1,6 +1,38 @@
 """Models for the JFind service."""
 
-from pydantic import BaseModel
+from datetime import date
+from typing import Any
+
+from pydantic import BaseModel, field_validator
+
+# Findings that can be acknowledged, the same names are used by the scanner
+FINDINGS = ("oracle_jdk", "exec_failed", "arch_mismatch", "default_runtime", "java_env_injection")
+
+
+class Acknowledgment(BaseModel):
+    """Model for an acknowledged finding, see the acknowledgment table."""
+
+    id: str | None = None
+    finding: str | None = None
+    host: str | None = None  # Glob pattern of computer names
+    path: str | None = None  # Glob pattern of java executables
+    note: str
+    ticket: str | None = None
+    expires: date | None = None  # First day the acknowledgment no longer applies
+
+    @field_validator("finding")
+    @classmethod
+    def check_finding(cls, finding: str | None) -> str | None:
+        if finding is not None and finding not in FINDINGS:
+            raise ValueError(f"unknown finding, use one of {', '.join(FINDINGS)}")
+        return finding
+
+    @field_validator("note")
+    @classmethod
+    def check_note(cls, note: str) -> str:
+        if not note.strip():
+            raise ValueError("note is required")
+        return note
 
 
 class JavaRuntime(BaseModel):
@@ -13,19 +45,41 @@ class JavaRuntime(BaseModel):
     java_version: str | None = None
     java_version_major: int | None = None
     java_version_update: int | None = None
+    release_date: str | None = None  # JAVA_VERSION_DATE of the release file, Java 10 and later
+    cpu_release: str | None = None  # Release date of the newest Critical Patch Update the runtime contains
+    patch_lag_quarters: int | None = None  # CPUs released since cpu_release at the time of the scan
     require_license: bool | None = None
+    acknowledgments: list[Acknowledgment] | None = None  # Acknowledged findings of the runtime, see -acks
+    enrichments: dict[str, Any] | None = None  # Values added by the scanner's enrichers, keyed by enricher name
+    quarantined: bool | None = None  # Made non-executable by jfind serve -enforce quarantine, still installed
+    packaged: bool | None = None  # Found in an archive that was never extracted, see -scan-archives
+    archive: str | None = None  # The archive a packaged runtime is in
+
+
+class ChunkInfo(BaseModel):
+    """Model for the position of a chunk in a report split into several POST requests."""
+
+    sequence: int  # 1-based position of the chunk
+    complete: bool  # True on the last chunk only
 
 
 class MetaInfo(BaseModel):
     """Model for scan metadata."""
 
+    scan_id: str | None = None  # UUID generated by the scanner, shared by all reports of a scan
+    report_phase: str | None = None  # "preliminary" or "final" for two-phase scans
+    shard: str | None = None  # "i/n" if the scan covered only one shard of the file system
     scan_ts: str
     computer_name: str
+    labels: dict[str, str] | None = None  # Group labels of the host, e.g. {"datacenter": "fra1", "env": "prod"}
     user_name: str
     scan_duration: str
     has_oracle_jdk: bool
     count_result: int
     scanned_dirs: int
+    chunk: ChunkInfo | None = None  # Set if the report was split into several requests
+    scan_roots: list[str] | None = None  # Start paths of the scan
+    complete: bool | None = None  # True if the scan saw everything below scan_roots, None from older scanners
 
 
 class ScannerResults(BaseModel):
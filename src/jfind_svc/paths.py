@@ -0,0 +1,129 @@
+"""Path matching shared by the collector's views of scanner reports.
+
+Paths are those reported by the scanner: POSIX paths, or Windows paths with a drive letter or UNC prefix, which
+are compared without regard to case and slash direction.
+"""
+
+import re
+
+_WINDOWS_PATH = re.compile(r"^([A-Za-z]:|\\\\|//)")
+
+
+def _normalize(path: str) -> str:
+    """Normalize a path for comparison, folding case and separators of Windows paths."""
+    if _WINDOWS_PATH.match(path):
+        path = path.replace("\\", "/").lower()
+    return path.rstrip("/") or "/"
+
+
+def within_roots(path: str, roots: list[str]) -> bool:
+    """Check if a path is one of the roots or below one of them."""
+    normalized = _normalize(path)
+    for root in roots:
+        root = _normalize(root)
+        if normalized == root or normalized.startswith(root if root.endswith("/") else root + "/"):
+            return True
+    return False
+
+
+def _parse_segment(pattern: str) -> list[tuple] | None:
+    """Parse a segment of a glob pattern in the syntax of Go's path.Match, as the scanner matches it.
+
+    Returns the tokens ("*",), ("?",), ("char", c) and ("class", negated, ranges), or None for a
+    malformed pattern, which matches nothing. A class is negated with ^, not !, and \\ escapes.
+    """
+    tokens = []
+    i = 0
+    while i < len(pattern):
+        c = pattern[i]
+        if c == "*":
+            tokens.append(("*",))
+        elif c == "?":
+            tokens.append(("?",))
+        elif c == "[":
+            i += 1
+            negated = i < len(pattern) and pattern[i] == "^"
+            if negated:
+                i += 1
+            ranges = []
+            while i >= len(pattern) or pattern[i] != "]" or not ranges:
+                lo, i = _class_char(pattern, i)
+                if lo is None:
+                    return None
+                hi = lo
+                if i < len(pattern) and pattern[i] == "-":
+                    hi, i = _class_char(pattern, i + 1)
+                    if hi is None:
+                        return None
+                ranges.append((lo, hi))
+            tokens.append(("class", negated, ranges))
+        else:
+            if c == "\\":
+                i += 1
+                if i == len(pattern):
+                    return None
+            tokens.append(("char", pattern[i]))
+        i += 1
+    return tokens
+
+
+def _class_char(pattern: str, i: int) -> tuple[str | None, int]:
+    """Read a character of a class at i, returning it and the index after it, or None if malformed."""
+    if i < len(pattern) and pattern[i] == "\\":
+        i += 1
+    if i >= len(pattern) or pattern[i] in "-]":
+        return None, i
+    return pattern[i], i + 1
+
+
+def _match_tokens(tokens: list[tuple], name: str) -> bool:
+    """Match the tokens of a pattern segment against a whole path segment."""
+    if not tokens:
+        return not name
+    token = tokens[0]
+    if token[0] == "*":
+        return any(_match_tokens(tokens[1:], name[i:]) for i in range(len(name) + 1))
+    if not name:
+        return False
+    if token[0] == "char" and token[1] != name[0]:
+        return False
+    if token[0] == "class" and any(lo <= name[0] <= hi for lo, hi in token[2]) == token[1]:
+        return False
+    return _match_tokens(tokens[1:], name[1:])
+
+
+def _match_segments(patterns: list[str], names: list[str]) -> bool:
+    """Match pattern segments against path segments, a ** segment matching any number of segments."""
+    while patterns:
+        if patterns[0] == "**":
+            while patterns and patterns[0] == "**":
+                patterns = patterns[1:]
+            if not patterns:
+                return True
+            return any(_match_segments(patterns, names[i:]) for i in range(len(names)))
+        if not names:
+            return False
+        tokens = _parse_segment(patterns[0])
+        if tokens is None or not _match_tokens(tokens, names[0]):
+            return False
+        patterns, names = patterns[1:], names[1:]
+    return not names
+
+
+def match_glob(pattern: str, path: str) -> bool:
+    """Check if a reported path matches a glob pattern like the scanner's acknowledgments and path rules.
+
+    The syntax is that of Go's path.Match per path segment, plus ** segments matching any number of
+    segments. Windows paths are matched without regard to case and slash direction, the cases of
+    scanner/testdata/glob.json are shared with the scanner's tests.
+    """
+    if _WINDOWS_PATH.match(path):
+        pattern = pattern.replace("\\", "/").lower()
+        path = path.replace("\\", "/").lower()
+    return _match_segments(pattern.removesuffix("/").split("/"), path.removesuffix("/").split("/"))
+
+
+def match_host(pattern: str, computer_name: str) -> bool:
+    """Check if a computer name matches a glob pattern, without regard to case, like the scanner."""
+    tokens = _parse_segment(pattern.lower())
+    return tokens is not None and _match_tokens(tokens, computer_name.lower())
@@ -0,0 +1,119 @@
+"""Decryption of report payloads encrypted by the scanner with -encrypt-key.
+
+Reports arrive as compact JWE (RFC 7516) with ECDH-ES key agreement on X25519 and
+A256GCM content encryption. The "kid" header is the RFC 7638 thumbprint of the
+collector's public key, so several keys can be configured while rotating them.
+"""
+
+import base64
+import hashlib
+import json
+import os
+import struct
+
+from cryptography.exceptions import InvalidTag
+from cryptography.hazmat.primitives import serialization
+from cryptography.hazmat.primitives.asymmetric.x25519 import X25519PrivateKey, X25519PublicKey
+from cryptography.hazmat.primitives.ciphers.aead import AESGCM
+
+JOSE_CONTENT_TYPE = "application/jose"
+
+
+class PayloadError(Exception):
+    """Raised for payloads that cannot be decrypted."""
+
+
+def _b64decode(value: str) -> bytes:
+    return base64.urlsafe_b64decode(value + "=" * (-len(value) % 4))
+
+
+def _b64encode(value: bytes) -> str:
+    return base64.urlsafe_b64encode(value).rstrip(b"=").decode("ascii")
+
+
+def key_id(public_key: X25519PublicKey) -> str:
+    """Get the RFC 7638 thumbprint of a public key, the "kid" the scanner sets."""
+    x = _b64encode(public_key.public_bytes(serialization.Encoding.Raw, serialization.PublicFormat.Raw))
+    canonical = json.dumps({"crv": "X25519", "kty": "OKP", "x": x}, separators=(",", ":"), sort_keys=True)
+    return _b64encode(hashlib.sha256(canonical.encode()).digest())
+
+
+def load_private_keys() -> dict[str, X25519PrivateKey]:
+    """Load the X25519 private keys from the PEM files in the comma separated JFIND_PAYLOAD_KEYS.
+
+    Returns:
+        Dict of key ID to private key, empty if no keys are configured
+    """
+    keys = {}
+    for path in os.getenv("JFIND_PAYLOAD_KEYS", "").split(","):
+        if not path.strip():
+            continue
+        with open(path.strip(), "rb") as f:
+            private_key = serialization.load_pem_private_key(f.read(), password=None)
+        if not isinstance(private_key, X25519PrivateKey):
+            raise ValueError(f"{path} is not an X25519 private key")
+        keys[key_id(private_key.public_key())] = private_key
+    return keys
+
+
+def _split(token: str) -> tuple[dict, list[str]]:
+    parts = token.strip().split(".")
+    if len(parts) != 5:
+        raise PayloadError("not a compact JWE")
+    try:
+        header = json.loads(_b64decode(parts[0]))
+    except ValueError as e:
+        raise PayloadError(f"invalid JWE header: {e}") from e
+    if not isinstance(header, dict):
+        raise PayloadError("invalid JWE header")
+    return header, parts
+
+
+def get_key_id(token: str) -> str | None:
+    """Get the key ID of an encrypted payload without decrypting it."""
+    header, _ = _split(token)
+    return header.get("kid")
+
+
+def _concat_kdf(shared: bytes, algorithm: str, key_bits: int) -> bytes:
+    """Derive the content encryption key with the Concat KDF of RFC 7518, section 4.6.2."""
+    other_info = (
+        struct.pack(">I", len(algorithm)) + algorithm.encode() + struct.pack(">I", 0) + struct.pack(">I", 0) + struct.pack(">I", key_bits)
+    )
+    return hashlib.sha256(struct.pack(">I", 1) + shared + other_info).digest()[: key_bits // 8]
+
+
+def decrypt(token: str, keys: dict[str, X25519PrivateKey]) -> bytes:
+    """Decrypt a compact JWE payload.
+
+    Args:
+        token: Compact JWE as sent by the scanner
+        keys: Private keys by key ID, see load_private_keys
+
+    Returns:
+        The plaintext report
+
+    Raises:
+        PayloadError: If the payload is malformed, uses other algorithms, no key matches or it was tampered with
+    """
+    header, parts = _split(token)
+    if header.get("alg") != "ECDH-ES" or header.get("enc") != "A256GCM":
+        raise PayloadError(f"unsupported JWE algorithms {header.get('alg')}/{header.get('enc')}")
+    private_key = keys.get(header.get("kid"))
+    if private_key is None:
+        raise PayloadError(f"no private key with ID {header.get('kid')}")
+    epk = header.get("epk") or {}
+    if epk.get("kty") != "OKP" or epk.get("crv") != "X25519":
+        raise PayloadError("ephemeral key is not an X25519 key")
+
+    try:
+        shared = private_key.exchange(X25519PublicKey.from_public_bytes(_b64decode(epk["x"])))
+        iv, ciphertext, tag = (_b64decode(part) for part in parts[2:])
+    except (KeyError, ValueError) as e:
+        raise PayloadError(f"invalid JWE: {e}") from e
+    cek = _concat_kdf(shared, "A256GCM", 256)
+    try:
+        # The protected header as sent is the additional authenticated data
+        return AESGCM(cek).decrypt(iv, ciphertext + tag, parts[0].encode("ascii"))
+    except InvalidTag as e:
+        raise PayloadError("payload was tampered with or encrypted to another key") from e
@@ -1,58 +1,277 @@
 """JFind scanner results endpoint."""
 
+import asyncio
+import html
+import json
+from datetime import datetime, timedelta, timezone
 from typing import Optional
 
-from fastapi import APIRouter, Depends, HTTPException, status
-from fastapi.responses import JSONResponse
+from fastapi import APIRouter, BackgroundTasks, Depends, HTTPException, Query, Request, status
+from fastapi.responses import HTMLResponse, JSONResponse
+from pydantic import ValidationError
 from sqlalchemy.ext.asyncio import AsyncSession
 
-from jfind_svc.db import get_session
+from jfind_svc.auth import require_admin
+from jfind_svc.compliance import CHECKS, RATING_ORDER
+from jfind_svc.db import async_session, get_session
 from jfind_svc.jfind_db import (
+    INVENTORY_GROUPS,
     ScanInfo,
+    acknowledges_everything,
+    acknowledgment_covers,
+    create_acknowledgment,
+    delete_acknowledgment,
+    finish_encrypted_report,
+    get_acknowledgments,
+    get_compliance,
+    get_encrypted_reports,
+    get_inventory,
+    get_inventory_summary,
+    get_label_summary,
+    get_labels,
     get_latest_scans,
     get_oracle_jdks,
+    get_present_oracle_runtimes,
+    get_removed_runtimes,
     get_scan_by_id,
+    get_scan_history,
     get_scans_by_computer_name,
+    get_update_drift,
     has_oracle_jdk,
+    is_known_computer,
+    is_outdated_preliminary,
+    save_encrypted_report,
+    save_report_chunk,
     save_scanner_results,
 )
-from jfind_svc.model import ScannerResults
+from jfind_svc.model import Acknowledgment, ScannerResults
+from jfind_svc.payload_crypto import JOSE_CONTENT_TYPE, PayloadError, decrypt, get_key_id, load_private_keys
+from jfind_svc.webhooks import EVENT_HOST_NEW, EVENT_REPORT_RECEIVED, EVENT_VIOLATION_NEW, build_event, emit_events
 
 router = APIRouter(tags=["jfind"])
 
 # Get database session dependency
 db_session = Depends(get_session)
 
-
-@router.post("/jfind", status_code=status.HTTP_200_OK)
-async def process_scanner_results(results: ScannerResults, session: AsyncSession = db_session) -> JSONResponse:
+# Admin token dependency of the endpoints that change acknowledgments
+admin = Depends(require_admin)
+
+
+def parse_label_filter(
+    label: list[str] = Query(default=[], description="Only computers with this label, as key=value (repeatable)"),
+) -> dict[str, str]:
+    """Parse the label query parameters, e.g. ?label=env=prod&label=datacenter=fra1, into a dict."""
+    labels = {}
+    for item in label:
+        key, sep, value = item.partition("=")
+        if not sep or not key.strip():
+            raise HTTPException(
+                status_code=status.HTTP_422_UNPROCESSABLE_ENTITY, detail=f"Invalid label {item!r}, use key=value"
+            )
+        labels[key.strip()] = value.strip()
+    return labels
+
+
+# Label filter dependency of the queries over all computers
+label_filter = Depends(parse_label_filter)
+
+
+@router.post(
+    "/jfind",
+    status_code=status.HTTP_200_OK,
+    openapi_extra={
+        "requestBody": {
+            "required": True,
+            "content": {
+                "application/json": {"schema": ScannerResults.model_json_schema()},
+                JOSE_CONTENT_TYPE: {"schema": {"type": "string", "description": "Report encrypted as compact JWE"}},
+            },
+        }
+    },
+)
+async def process_scanner_results(
+    request: Request, background_tasks: BackgroundTasks, session: AsyncSession = db_session
+) -> JSONResponse:
     """Process results from the jfind scanner.
 
+    Reports split by the scanner arrive in chunks and are saved once the last one is in.
+    Once a report is saved, webhook events are sent in the background (see webhooks.py).
+    Reports encrypted with the collector's public key (Content-Type application/jose) are
+    stored encrypted and processed in the background (see payload_crypto.py).
+
     Returns:
         200 OK with {"result": "ok", "scan_id": <id>} if data is valid
+        200 OK with {"result": "pending", "chunk": <sequence>} while chunks of a split report are missing
+        200 OK with {"result": "ignored", "reason": <why>} for a preliminary report after the final one
+        200 OK with {"result": "queued", "encrypted_report_id": <id>} for an encrypted report
         422 Unprocessable Entity if data validation fails
     """
+    body = await request.body()
+    if request.headers.get("content-type", "").split(";")[0].strip() == JOSE_CONTENT_TYPE:
+        token = body.decode("ascii", errors="replace").strip()
+        try:
+            key_id = get_key_id(token)
+        except PayloadError as e:
+            raise HTTPException(status_code=status.HTTP_422_UNPROCESSABLE_ENTITY, detail=str(e)) from e
+        report_id = await save_encrypted_report(session, token, key_id)
+        background_tasks.add_task(process_encrypted_reports)
+        return JSONResponse(
+            content={"result": "queued", "encrypted_report_id": report_id}, status_code=status.HTTP_200_OK
+        )
+
+    try:
+        results = ScannerResults.model_validate_json(body)
+    except ValidationError as e:
+        raise HTTPException(status_code=status.HTTP_422_UNPROCESSABLE_ENTITY, detail=json.loads(e.json())) from e
+    response, events = await _process_results(session, results)
+    background_tasks.add_task(emit_events, events)
+    return JSONResponse(content=response, status_code=status.HTTP_200_OK)
+
+
+async def _process_results(session: AsyncSession, results: ScannerResults) -> tuple[dict, list[dict]]:
+    """Save a report or a chunk of it.
+
+    Returns:
+        The response for the scanner and the webhook events to send
+    """
+    if results.meta.chunk is not None:
+        if not results.meta.scan_id:
+            raise HTTPException(
+                status_code=status.HTTP_422_UNPROCESSABLE_ENTITY, detail="Chunked reports require meta.scan_id"
+            )
+        sequence = results.meta.chunk.sequence
+        results = await save_report_chunk(session, results)
+        if results is None:
+            return {"result": "pending", "chunk": sequence}, []
+
+    if await is_outdated_preliminary(session, results):
+        return {"result": "ignored", "reason": "outdated preliminary report"}, []
+
+    computer_name = results.meta.computer_name
+    known_computer = await is_known_computer(session, computer_name)
+    oracle_before = {runtime.java_executable for runtime in await get_present_oracle_runtimes(session, computer_name)}
+
     # Save results to database
     scan_info = await save_scanner_results(session, results)
 
+    oracle_after = await get_present_oracle_runtimes(session, computer_name)
+    new_oracle = [runtime for runtime in oracle_after if runtime.java_executable not in oracle_before]
+
+    # Acknowledged Oracle runtimes are no violations. Only the acknowledgments of the collector
+    # count, those in a report are whatever the scanner was given and could silence any alert.
+    acks = await get_acknowledgments(session, computer_name)
+    new_oracle = [
+        runtime
+        for runtime in new_oracle
+        if not any(acknowledgment_covers(ack, "oracle_jdk", runtime.java_executable) for ack in acks)
+    ]
+
     # Log success
     print(f"Saved scan from {scan_info.computer_name} with {scan_info.count_result} Java runtimes")
 
-    return JSONResponse(content={"result": "ok", "scan_id": scan_info.id}, status_code=status.HTTP_200_OK)
+    return {"result": "ok", "scan_id": scan_info.id}, _report_events(scan_info, known_computer, new_oracle)
+
+
+# Encrypted reports are processed one at a time, also when several requests queue them at once
+_encrypted_reports_lock = asyncio.Lock()
+
+
+async def process_encrypted_reports() -> None:
+    """Decrypt and save the stored encrypted reports for which a private key is configured.
+
+    Reports are deleted once saved. A report that cannot be processed stays stored with the
+    error, e.g. until the key it is encrypted to is configured; this runs again on startup.
+    """
+    keys = load_private_keys()
+    if not keys:
+        return
+    async with _encrypted_reports_lock, async_session() as session:
+        # A rollback expires the loaded reports, so take their contents first
+        pending = [(report.id, report.payload) for report in await get_encrypted_reports(session, list(keys))]
+        for report_id, payload in pending:
+            try:
+                results = ScannerResults.model_validate_json(decrypt(payload, keys))
+                _, events = await _process_results(session, results)
+            except (PayloadError, ValidationError, HTTPException) as e:
+                await session.rollback()
+                error = str(e.detail if isinstance(e, HTTPException) else e)
+                await finish_encrypted_report(session, report_id, error)
+                print(f"Failed to process encrypted report {report_id}: {error}")
+                continue
+            await finish_encrypted_report(session, report_id)
+            await emit_events(events)
+
+
+@router.get("/jfind/acknowledgments", status_code=status.HTTP_200_OK)
+async def list_acknowledgments(computer_name: Optional[str] = None, session: AsyncSession = db_session) -> JSONResponse:
+    """Get the acknowledged findings.
+
+    The scanner fetches the acknowledgments of its host with -acks <this URL>, so that its
+    reports carry them and acknowledged findings stop alerting.
+
+    Args:
+        computer_name: Only return acknowledgments for this computer that have not expired
+        session: Database session
+
+    Returns:
+        200 OK with list of {"id", "finding", "host", "path", "note", "ticket", "expires"}
+    """
+    acks = await get_acknowledgments(session, computer_name)
+    return JSONResponse(content=[_format_acknowledgment(ack) for ack in acks], status_code=status.HTTP_200_OK)
+
+
+@router.post("/jfind/acknowledgments", status_code=status.HTTP_201_CREATED, dependencies=[admin])
+async def add_acknowledgment(ack: Acknowledgment, session: AsyncSession = db_session) -> JSONResponse:
+    """Acknowledge a finding, e.g. {"finding": "oracle_jdk", "host": "build-*", "note": "licensed", "ticket": "LIC-123"}.
+
+    Requires the admin token as bearer token. The host or the path must be narrower than
+    every computer or every path, an acknowledgment of everything would disable the alerts.
+
+    Returns:
+        201 Created with the acknowledgment and its ID
+        401 Unauthorized without the admin token, 403 Forbidden if no admin token is configured
+        422 Unprocessable Entity for an unknown finding, a missing note or neither host nor path
+    """
+    if acknowledges_everything(ack):
+        raise HTTPException(
+            status_code=status.HTTP_422_UNPROCESSABLE_ENTITY,
+            detail="An acknowledgment requires a host or path pattern that does not match everything",
+        )
+    row = await create_acknowledgment(session, ack)
+    return JSONResponse(content=_format_acknowledgment(row), status_code=status.HTTP_201_CREATED)
+
+
+@router.delete("/jfind/acknowledgments/{ack_id}", status_code=status.HTTP_200_OK, dependencies=[admin])
+async def remove_acknowledgment(ack_id: int, session: AsyncSession = db_session) -> JSONResponse:
+    """Withdraw an acknowledgment, so the finding alerts again.
+
+    Requires the admin token as bearer token.
+
+    Returns:
+        200 OK with {"result": "ok"}
+        401 Unauthorized without the admin token, 403 Forbidden if no admin token is configured
+        404 Not Found if there is no acknowledgment with this ID
+    """
+    if not await delete_acknowledgment(session, ack_id):
+        raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail=f"Acknowledgment with ID {ack_id} not found")
+    return JSONResponse(content={"result": "ok"}, status_code=status.HTTP_200_OK)
 
 
 @router.get("/jfind/scans", status_code=status.HTTP_200_OK)
-async def get_scans(limit: int = 10, session: AsyncSession = db_session) -> JSONResponse:
+async def get_scans(
+    limit: int = 10, labels: dict[str, str] = label_filter, session: AsyncSession = db_session
+) -> JSONResponse:
     """Get the latest scan results.
 
     Args:
         limit: Maximum number of scans to return (default: 10)
+        labels: Only scans of computers with all these labels
         session: Database session
 
     Returns:
         200 OK with list of scans and their Java runtime information
     """
-    scans = await get_latest_scans(session, limit)
+    scans = await get_latest_scans(session, limit, labels)
     response = [_format_scan_response(scan) for scan in scans]
     return JSONResponse(content=response, status_code=status.HTTP_200_OK)
 
@@ -62,6 +281,7 @@ async def query_scans(
     computer_name: Optional[str] = None,
     scan_id: Optional[int] = None,
     limit: int = 10,
+    labels: dict[str, str] = label_filter,
     session: AsyncSession = db_session,
 ) -> JSONResponse:
     """Query scan results by computer name or scan ID.
@@ -70,6 +290,7 @@ async def query_scans(
         computer_name: Optional name of computer to query
         scan_id: Optional scan ID to query
         limit: Maximum number of results to return (default: 10)
+        labels: Only the latest scans of computers with all these labels, without computer name or scan ID
         session: Database session
 
     Returns:
@@ -88,7 +309,7 @@ async def query_scans(
         response = [_format_scan_response(scan) for scan in scans]
     else:
         # No query parameters, return latest scans
-        scans = await get_latest_scans(session, limit)
+        scans = await get_latest_scans(session, limit, labels)
         response = [_format_scan_response(scan) for scan in scans]
 
     return JSONResponse(content=response, status_code=status.HTTP_200_OK)
@@ -112,17 +333,20 @@ async def get_scans_by_computer(computer_name: str, limit: int = 10, session: As
 
 
 @router.get("/jfind/oracle", status_code=status.HTTP_200_OK)
-async def get_oracle_java_runtimes(limit: int = 10, session: AsyncSession = db_session) -> JSONResponse:
+async def get_oracle_java_runtimes(
+    limit: int = 10, labels: dict[str, str] = label_filter, session: AsyncSession = db_session
+) -> JSONResponse:
     """Get all Oracle Java runtimes.
 
     Args:
         limit: Maximum number of results to return (default: 10)
+        labels: Only runtimes of computers with all these labels
         session: Database session
 
     Returns:
         200 OK with list of Oracle Java runtimes
     """
-    java_infos = await get_oracle_jdks(session, limit)
+    java_infos = await get_oracle_jdks(session, limit, labels)
     response = [
         {
             "scan_id": java.scan_id,
@@ -134,6 +358,8 @@ async def get_oracle_java_runtimes(limit: int = 10, session: AsyncSession = db_s
             "java_version": java.java_version,
             "java_version_major": java.java_version_major,
             "java_version_update": java.java_version_update,
+            "cpu_release": java.cpu_release,
+            "patch_lag_quarters": java.patch_lag_quarters,
         }
         for java in java_infos
     ]
@@ -175,13 +401,367 @@ async def check_oracle_jdk(computer_name: str, session: AsyncSession = db_sessio
     )
 
 
+@router.get("/jfind/update-drift", status_code=status.HTTP_200_OK)
+async def get_update_drift_report(labels: dict[str, str] = label_filter, session: AsyncSession = db_session) -> JSONResponse:
+    """Get computers running several update levels of the same distribution and major version.
+
+    Args:
+        labels: Only computers with all these labels
+        session: Database session
+
+    Returns:
+        200 OK with list of {
+            "computer_name": str,
+            "java_vendor": str,
+            "java_version_major": int,
+            "versions": [str],
+            "paths": [str]
+        }
+    """
+    drift = await get_update_drift(session, labels)
+    return JSONResponse(content=drift, status_code=status.HTTP_200_OK)
+
+
+@router.get("/jfind/compliance", status_code=status.HTTP_200_OK)
+async def get_compliance_report(
+    rating: Optional[str] = None, labels: dict[str, str] = label_filter, session: AsyncSession = db_session
+) -> JSONResponse:
+    """Get the red/amber/green compliance rating of every computer.
+
+    Computers are rated by the CPU patch level, tzdata version and cacerts age of the
+    runtimes in their latest scan, see compliance.py.
+
+    Args:
+        rating: Only return computers with this rating: red, amber, green or unknown
+        labels: Only computers with all these labels
+        session: Database session
+
+    Returns:
+        200 OK with list of {
+            "computer_name": str,
+            "labels": {str: str},
+            "scan_ts": str,
+            "rating": str,
+            "cpu": str,
+            "tzdata": str,
+            "cacerts": str,
+            "runtimes": [{"java_executable", "java_vendor", "java_version", "rating", "cpu", "cpu_behind",
+                          "cpu_release", "release_date", "tzdata", "tzdata_version", "cacerts", "cacerts_expired", "cacerts_distrusted"}]
+        }
+        422 Unprocessable Entity for an unknown rating
+    """
+    if rating is not None and rating not in RATING_ORDER:
+        raise HTTPException(
+            status_code=status.HTTP_422_UNPROCESSABLE_ENTITY, detail=f"Unknown rating, use one of {', '.join(RATING_ORDER)}"
+        )
+    hosts = await get_compliance(session, labels=labels)
+    if rating is not None:
+        hosts = [host for host in hosts if host["rating"] == rating]
+    return JSONResponse(content=hosts, status_code=status.HTTP_200_OK)
+
+
+@router.get("/jfind/compliance/dashboard", response_class=HTMLResponse, status_code=status.HTTP_200_OK)
+async def get_compliance_dashboard(labels: dict[str, str] = label_filter, session: AsyncSession = db_session) -> HTMLResponse:
+    """Show the compliance ratings of all computers as an HTML page, worst first.
+
+    Args:
+        labels: Only computers with all these labels
+        session: Database session
+
+    Returns:
+        200 OK with the dashboard
+    """
+    hosts = await get_compliance(session, labels=labels)
+    hosts.sort(key=lambda host: -RATING_ORDER.index(host["rating"]))
+    return HTMLResponse(content=_compliance_dashboard(hosts), status_code=status.HTTP_200_OK)
+
+
+@router.get("/jfind/compliance/{computer_name}", status_code=status.HTTP_200_OK)
+async def get_computer_compliance(computer_name: str, session: AsyncSession = db_session) -> JSONResponse:
+    """Get the compliance rating of a computer and its runtimes.
+
+    Returns:
+        200 OK with the rating as in /jfind/compliance
+        404 Not Found if there are no runtimes of this computer
+    """
+    hosts = await get_compliance(session, computer_name)
+    if not hosts:
+        raise HTTPException(status_code=status.HTTP_404_NOT_FOUND, detail=f"No runtimes of {computer_name} found")
+    return JSONResponse(content=hosts[0], status_code=status.HTTP_200_OK)
+
+
+@router.get("/jfind/removed", status_code=status.HTTP_200_OK)
+async def get_removed_java_runtimes(
+    oracle: bool = False,
+    days: Optional[int] = None,
+    limit: int = 100,
+    labels: dict[str, str] = label_filter,
+    session: AsyncSession = db_session,
+) -> JSONResponse:
+    """Get runtimes that disappeared from the newest report of their computer.
+
+    Args:
+        oracle: Only return Oracle runtimes, e.g. to demonstrate remediation to auditors
+        days: Only return runtimes removed within this many days
+        limit: Maximum number of results to return (default: 100)
+        labels: Only runtimes of computers with all these labels
+        session: Database session
+
+    Returns:
+        200 OK with list of {
+            "computer_name": str,
+            "java_executable": str,
+            "java_vendor": str,
+            "java_version": str,
+            "is_oracle": bool,
+            "first_seen": str,
+            "last_seen": str,
+            "removed_at": str
+        }
+    """
+    since = None
+    if days is not None:
+        # Scan timestamps are stored without time zone in UTC
+        since = datetime.now(timezone.utc).replace(tzinfo=None) - timedelta(days=days)
+    runtimes = await get_removed_runtimes(session, oracle_only=oracle, since=since, limit=limit, labels=labels)
+    response = [
+        {
+            "computer_name": runtime.computer_name,
+            "java_executable": runtime.java_executable,
+            "java_vendor": runtime.java_vendor,
+            "java_version": runtime.java_version,
+            "is_oracle": runtime.is_oracle,
+            "first_seen": runtime.first_seen.isoformat(),
+            "last_seen": runtime.last_seen.isoformat(),
+            "removed_at": runtime.removed_at.isoformat(),
+        }
+        for runtime in runtimes
+    ]
+    return JSONResponse(content=response, status_code=status.HTTP_200_OK)
+
+
+@router.get("/jfind/labels", status_code=status.HTTP_200_OK)
+async def list_labels(session: AsyncSession = db_session) -> JSONResponse:
+    """Get the labels the computers carry as of their newest reports.
+
+    Returns:
+        200 OK with list of {"key": str, "values": [{"value": str, "computers": int}]}
+    """
+    return JSONResponse(content=await get_labels(session), status_code=status.HTTP_200_OK)
+
+
+@router.get("/jfind/labels/{key}", status_code=status.HTTP_200_OK)
+async def summarize_label(key: str, labels: dict[str, str] = label_filter, session: AsyncSession = db_session) -> JSONResponse:
+    """Aggregate the latest scans of the computers by the value of a label, e.g. per environment.
+
+    Args:
+        key: Label to group by
+        labels: Only computers with all these labels, e.g. /jfind/labels/env?label=datacenter=fra1
+        session: Database session
+
+    Returns:
+        200 OK with list of {
+            "value": str or null for computers without the label,
+            "computers": int,
+            "runtimes": int,
+            "oracle_computers": int,
+            "ratings": {"red": int, "amber": int, "green": int, "unknown": int}
+        }
+    """
+    return JSONResponse(content=await get_label_summary(session, key, labels), status_code=status.HTTP_200_OK)
+
+
+@router.get("/jfind/grafana/runtimes", status_code=status.HTTP_200_OK)
+async def get_grafana_runtimes(labels: dict[str, str] = label_filter, session: AsyncSession = db_session) -> JSONResponse:
+    """Get the runtimes of the latest scans as a flat table, for the Grafana Infinity datasource.
+
+    Args:
+        labels: Only runtimes of computers with all these labels
+        session: Database session
+
+    Returns:
+        200 OK with list of {
+            "computer_name": str,
+            "scan_ts": str,
+            "java_executable": str,
+            "java_vendor": str,
+            "java_version": str,
+            "java_version_major": int,
+            "is_oracle": bool,
+            "packaged": bool,
+            "patch_lag_quarters": int,
+            "cpu_behind": int,
+            "rating": str,
+            "label_<key>": str for every label of the computer
+        }
+    """
+    return JSONResponse(content=await get_inventory(session, labels), status_code=status.HTTP_200_OK)
+
+
+@router.get("/jfind/grafana/summary", status_code=status.HTTP_200_OK)
+async def get_grafana_summary(
+    by: str = "java_vendor", labels: dict[str, str] = label_filter, session: AsyncSession = db_session
+) -> JSONResponse:
+    """Count the runtimes of the latest scans by a column of /jfind/grafana/runtimes, for pie and bar charts.
+
+    Args:
+        by: Column to group by: computer_name, java_vendor, java_version, java_version_major, is_oracle,
+            packaged, rating or label_<key>
+        labels: Only runtimes of computers with all these labels
+        session: Database session
+
+    Returns:
+        200 OK with list of {"group": value or null, "runtimes": int, "computers": int, "oracle_runtimes": int}
+        422 Unprocessable Entity for an unknown column
+    """
+    if by not in INVENTORY_GROUPS and not (by.startswith("label_") and len(by) > len("label_")):
+        raise HTTPException(
+            status_code=status.HTTP_422_UNPROCESSABLE_ENTITY,
+            detail=f"Unknown column, use one of {', '.join(INVENTORY_GROUPS)} or label_<key>",
+        )
+    return JSONResponse(content=await get_inventory_summary(session, by, labels), status_code=status.HTTP_200_OK)
+
+
+@router.get("/jfind/grafana/history", status_code=status.HTTP_200_OK)
+async def get_grafana_history(
+    days: int = Query(default=30, ge=1, le=366), labels: dict[str, str] = label_filter, session: AsyncSession = db_session
+) -> JSONResponse:
+    """Count the computers and runtimes reported per day, for time series.
+
+    Args:
+        days: Number of days up to today (default: 30)
+        labels: Only computers with all these labels
+        session: Database session
+
+    Returns:
+        200 OK with list of {"time": str, "computers": int, "runtimes": int, "oracle_computers": int}
+    """
+    return JSONResponse(content=await get_scan_history(session, days, labels), status_code=status.HTTP_200_OK)
+
+
+def _report_events(scan: ScanInfo, known_computer: bool, new_oracle: list) -> list[dict]:
+    """Build the webhook events of a saved report."""
+    summary = {
+        "computer_name": scan.computer_name,
+        "labels": json.loads(scan.labels) if scan.labels else {},
+        "scan_id": scan.id,
+        "scan_uuid": scan.scan_uuid,
+        "scan_ts": scan.scan_ts.isoformat(),
+    }
+    events = []
+    if not known_computer:
+        events.append(build_event(EVENT_HOST_NEW, summary))
+    if new_oracle:
+        # Oracle runtimes require a license, a new one is a compliance violation
+        runtimes = [
+            {
+                "java_executable": runtime.java_executable,
+                "java_vendor": runtime.java_vendor,
+                "java_version": runtime.java_version,
+            }
+            for runtime in new_oracle
+        ]
+        events.append(build_event(EVENT_VIOLATION_NEW, {**summary, "violation": "oracle_jdk", "runtimes": runtimes}))
+    events.append(
+        build_event(
+            EVENT_REPORT_RECEIVED,
+            {
+                **summary,
+                "report_phase": scan.report_phase,
+                "count_result": scan.count_result,
+                "has_oracle_jdk": scan.has_oracle_jdk,
+            },
+        )
+    )
+    return events
+
+
+# Cell colors of the ratings on the compliance dashboard
+_RATING_COLORS = {"red": "#f8d7da", "amber": "#fff3cd", "green": "#d1e7dd", "unknown": "#e9ecef"}
+
+
+def _compliance_dashboard(hosts: list[dict]) -> str:
+    """Render the compliance ratings of the computers as a self-contained HTML page."""
+
+    def cell(rating: str, text: Optional[str] = None) -> str:
+        return f'<td style="background:{_RATING_COLORS[rating]}">{html.escape(text or rating)}</td>'
+
+    counts = {rating: sum(host["rating"] == rating for host in hosts) for rating in reversed(RATING_ORDER)}
+    summary = ", ".join(f"{count} {rating}" for rating, count in counts.items())
+    rows = []
+    for host in hosts:
+        rows.append(
+            "<tr>"
+            + f"<td><b>{html.escape(host['computer_name'])}</b>{_format_labels(host['labels'])}</td>"
+            + cell(host["rating"])
+            + "".join(cell(host[check]) for check in CHECKS)
+            + f"<td>{html.escape(host['scan_ts'])}</td></tr>"
+        )
+        for runtime in host["runtimes"]:
+            rows.append(
+                "<tr>"
+                + f"<td>&nbsp;&nbsp;{html.escape(runtime['java_executable'])} {html.escape(runtime['java_version'] or '')}</td>"
+                + cell(runtime["rating"])
+                + cell(runtime["cpu"], _detail(runtime["cpu_behind"], "CPUs behind"))
+                + cell(runtime["tzdata"], runtime["tzdata_version"])
+                + cell(runtime["cacerts"], _cacerts_detail(runtime))
+                + "<td></td></tr>"
+            )
+    header = "".join(f"<th>{name}</th>" for name in ("Computer", "Rating", "CPU", "tzdata", "cacerts", "Latest scan"))
+    return (
+        "<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>JFind compliance</title>"
+        "<style>body{font-family:sans-serif}table{border-collapse:collapse}td,th{padding:4px 8px;border:1px solid #ccc}</style>"
+        f"</head><body><h1>JFind compliance</h1><p>{len(hosts)} computers: {summary}</p>"
+        f"<table><tr>{header}</tr>{''.join(rows)}</table></body></html>"
+    )
+
+
+def _format_labels(labels: dict[str, str]) -> str:
+    """Format the labels of a computer for the dashboard, after its name."""
+    if not labels:
+        return ""
+    text = ", ".join(f"{key}={value}" for key, value in sorted(labels.items()))
+    return f' <small style="color:#666">{html.escape(text)}</small>'
+
+
+def _detail(value: Optional[int], unit: str) -> Optional[str]:
+    """Format a number shown in a dashboard cell, None shows the rating instead."""
+    return None if value is None else f"{value} {unit}"
+
+
+def _cacerts_detail(runtime: dict) -> Optional[str]:
+    """Format the distrusted or expired roots of a runtime for its cacerts cell."""
+    if runtime["cacerts_distrusted"]:
+        return _detail(runtime["cacerts_distrusted"], "distrusted")
+    if runtime["cacerts_expired"]:
+        return _detail(runtime["cacerts_expired"], "expired")
+    return None
+
+
+def _format_acknowledgment(ack) -> dict:
+    """Format an acknowledgment like the scanner reads it from an acknowledgments file."""
+    formatted = {
+        "id": str(ack.id),
+        "finding": ack.finding,
+        "host": ack.host,
+        "path": ack.path,
+        "note": ack.note,
+        "ticket": ack.ticket,
+        "expires": ack.expires.isoformat() if ack.expires else None,
+    }
+    return {key: value for key, value in formatted.items() if value is not None}
+
+
 def _format_scan_response(scan: ScanInfo) -> dict:
     """Format a single scan result for API response."""
     return {
         "meta": {
             "scan_id": scan.id,
+            "scan_uuid": scan.scan_uuid,
+            "report_phase": scan.report_phase,
             "scan_ts": scan.scan_ts.isoformat(),
             "computer_name": scan.computer_name,
+            "labels": json.loads(scan.labels) if scan.labels else {},
             "user_name": scan.user_name,
             "scan_duration": scan.scan_duration,
             "has_oracle_jdk": scan.has_oracle_jdk,
@@ -197,6 +777,10 @@ def _format_scan_response(scan: ScanInfo) -> dict:
                 "java_version": java.java_version,
                 "java_version_major": java.java_version_major,
                 "java_version_update": java.java_version_update,
+                "cpu_release": java.cpu_release,
+                "patch_lag_quarters": java.patch_lag_quarters,
+                "packaged": java.packaged,
+                "archive": java.archive,
             }
             for java in scan.java_runtimes
         ],
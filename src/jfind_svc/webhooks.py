@@ -0,0 +1,136 @@
+"""Signed webhooks notifying downstream systems of fleet changes."""
+
+import asyncio
+import hashlib
+import hmac
+import json
+import os
+import time
+import urllib.error
+import urllib.request
+import uuid
+from datetime import datetime, timezone
+
+# Event types
+EVENT_REPORT_RECEIVED = "report.received"
+EVENT_HOST_NEW = "host.new"
+EVENT_VIOLATION_NEW = "violation.new"
+
+# Deliveries are retried with exponential backoff: 1, 2, 4 and 8 seconds
+MAX_ATTEMPTS = 5
+TIMEOUT_SECONDS = 10
+
+
+def get_webhook_urls() -> list[str]:
+    """Get the webhook URLs from the comma separated JFIND_WEBHOOK_URLS environment variable."""
+    return [url.strip() for url in os.getenv("JFIND_WEBHOOK_URLS", "").split(",") if url.strip()]
+
+
+def build_event(event: str, data: dict) -> dict:
+    """Build the body of a webhook event.
+
+    Args:
+        event: Event type, e.g. "host.new"
+        data: Event specific payload
+
+    Returns:
+        Dict with a unique id, the event type, the time it occurred and the payload
+    """
+    return {
+        "id": str(uuid.uuid4()),
+        "event": event,
+        "occurred_at": datetime.now(timezone.utc).isoformat(),
+        "data": data,
+    }
+
+
+def sign(body: bytes, timestamp: str, secret: str) -> str:
+    """Sign a webhook body.
+
+    The signature is the HMAC-SHA256 of "<timestamp>.<body>" with the shared secret, so a
+    receiver can reject replayed deliveries by checking the X-JFind-Timestamp header.
+
+    Returns:
+        Signature in the form "sha256=<hex digest>"
+    """
+    mac = hmac.new(secret.encode(), timestamp.encode() + b"." + body, hashlib.sha256)
+    return "sha256=" + mac.hexdigest()
+
+
+def post(request: urllib.request.Request) -> None:
+    """Send a webhook request, raising on errors. Blocks, see deliver."""
+    with urllib.request.urlopen(request, timeout=TIMEOUT_SECONDS):
+        pass
+
+
+async def deliver(url: str, event: dict, secret: str) -> bool:
+    """Post an event to a webhook URL, retrying on connection errors, 429 and 5xx responses.
+
+    The request runs in the default executor, the backoff between attempts waits on the
+    event loop, so a failing receiver holds no worker thread while it is retried.
+
+    Args:
+        url: Webhook URL
+        event: Event built with build_event
+        secret: Shared secret to sign the body with
+
+    Returns:
+        True if the receiver accepted the event
+    """
+    body = json.dumps(event).encode()
+    loop = asyncio.get_running_loop()
+    for attempt in range(MAX_ATTEMPTS):
+        if attempt > 0:
+            await asyncio.sleep(2 ** (attempt - 1))
+
+        timestamp = str(int(time.time()))
+        headers = {
+            "Content-Type": "application/json",
+            "User-Agent": "jfind-svc",
+            "X-JFind-Event": event["event"],
+            "X-JFind-Delivery": event["id"],
+            "X-JFind-Timestamp": timestamp,
+            "X-JFind-Signature": sign(body, timestamp, secret),
+        }
+
+        request = urllib.request.Request(url, data=body, headers=headers, method="POST")
+        try:
+            await loop.run_in_executor(None, post, request)
+            return True
+        except urllib.error.HTTPError as e:
+            if e.code != 429 and e.code < 500:
+                print(f"Webhook {url} rejected {event['event']} event {event['id']}: HTTP {e.code}")
+                return False
+            error = f"HTTP {e.code}"
+        except (urllib.error.URLError, OSError) as e:
+            error = str(e)
+        print(f"Webhook {url} failed for {event['event']} event {event['id']} (attempt {attempt + 1}): {error}")
+    return False
+
+
+def get_webhook_secret() -> str | None:
+    """Get the secret webhook events are signed with from the JFIND_WEBHOOK_SECRET environment variable."""
+    return os.getenv("JFIND_WEBHOOK_SECRET") or None
+
+
+async def deliver_all(url: str, events: list[dict], secret: str) -> None:
+    """Post events to a webhook URL one after the other, keeping their order."""
+    for event in events:
+        await deliver(url, event, secret)
+
+
+async def emit_events(events: list[dict]) -> None:
+    """Deliver events to all configured webhook URLs, concurrently per URL.
+
+    Events are always signed: without JFIND_WEBHOOK_SECRET nothing is sent, as receivers
+    could not tell the collector's events from forged ones. Meant to run as a background
+    task after the response has been sent.
+    """
+    urls = get_webhook_urls()
+    if not urls or not events:
+        return
+    secret = get_webhook_secret()
+    if secret is None:
+        print(f"Webhook events not sent: JFIND_WEBHOOK_SECRET is not set ({len(events)} events dropped)")
+        return
+    await asyncio.gather(*(deliver_all(url, events, secret) for url in urls))
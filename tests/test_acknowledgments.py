@@ -0,0 +1,53 @@
+"""Tests of the acknowledgments of the collector."""
+
+import json
+from pathlib import Path
+
+import pytest
+from fastapi import HTTPException
+from fastapi.security import HTTPAuthorizationCredentials
+
+from jfind_svc.auth import require_admin
+from jfind_svc.jfind_db import acknowledges_everything
+from jfind_svc.model import Acknowledgment
+from jfind_svc.paths import match_glob, match_host
+
+# Glob cases shared with the scanner's TestAcknowledgmentGlobSpec
+SPEC = json.loads((Path(__file__).parent.parent / "scanner" / "testdata" / "glob.json").read_text())
+
+
+@pytest.mark.parametrize("case", SPEC["paths"], ids=lambda c: f"{c['pattern']} {c['name']}")
+def test_match_glob_like_scanner(case):
+    assert match_glob(case["pattern"], case["name"]) == case["match"]
+
+
+@pytest.mark.parametrize("case", SPEC["hosts"], ids=lambda c: f"{c['pattern']} {c['host']}")
+def test_match_host_like_scanner(case):
+    assert match_host(case["pattern"], case["host"]) == case["match"]
+
+
+def bearer(token: str) -> HTTPAuthorizationCredentials:
+    return HTTPAuthorizationCredentials(scheme="Bearer", credentials=token)
+
+
+def test_admin_token_is_required(monkeypatch):
+    monkeypatch.setenv("JFIND_ADMIN_TOKEN", "s3cret")
+    require_admin(bearer("s3cret"))
+    for credentials in (None, bearer("wrong")):
+        with pytest.raises(HTTPException) as e:
+            require_admin(credentials)
+        assert e.value.status_code == 401
+
+
+def test_changes_are_disabled_without_admin_token(monkeypatch):
+    monkeypatch.delenv("JFIND_ADMIN_TOKEN", raising=False)
+    with pytest.raises(HTTPException) as e:
+        require_admin(bearer(""))
+    assert e.value.status_code == 403
+
+
+def test_acknowledgment_of_everything_is_refused():
+    for matchers in ({}, {"host": ""}, {"host": "*"}, {"path": "**"}, {"host": "*", "path": "/**"}):
+        assert acknowledges_everything(Acknowledgment(finding="oracle_jdk", note="licensed", **matchers)), matchers
+    for matchers in ({"host": "build-*"}, {"path": "/opt/oracle/**"}):
+        assert not acknowledges_everything(Acknowledgment(finding="oracle_jdk", note="licensed", **matchers)), matchers
@@ -0,0 +1,41 @@
+"""Tests that the Go client of the scanner (scanner/client) matches the API of the collector."""
+
+import json
+import os
+from pathlib import Path
+
+import pytest
+from fastapi import FastAPI
+
+# Importing the routes creates the database engine, keep it off the default database
+os.environ.setdefault("DATABASE_URL", "sqlite+aiosqlite://")
+
+from jfind_svc.routes import health, router  # noqa: E402
+
+# Operations the client uses, shared with the client's TestClientConformsToSpec
+SPEC = json.loads((Path(__file__).parent.parent / "scanner" / "testdata" / "client_api.json").read_text())
+
+
+def openapi() -> dict:
+    """Build the OpenAPI schema of the collector with its routes mounted like in main.py."""
+    app = FastAPI()
+    app.include_router(health)
+    app.include_router(router, prefix="/api")
+    return app.openapi()
+
+
+SCHEMA = openapi()
+
+
+@pytest.mark.parametrize("op", SPEC["operations"], ids=lambda op: f"{op['method']} {op['path']}")
+def test_client_operation_exists(op):
+    assert op["path"] in SCHEMA["paths"]
+    operation = SCHEMA["paths"][op["path"]].get(op["method"].lower())
+    assert operation is not None, f"{op['method']} {op['path']} is not served"
+    query = {param["name"] for param in operation.get("parameters", []) if param["in"] == "query"}
+    assert set(op["query"]) <= query
+
+
+@pytest.mark.parametrize("name", SPEC["schemas"])
+def test_client_body_fields(name):
+    assert sorted(SCHEMA["components"]["schemas"][name]["properties"]) == SPEC["schemas"][name]
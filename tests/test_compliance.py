@@ -0,0 +1,14 @@
+"""Tests of the compliance ratings of runtimes."""
+
+from jfind_svc.compliance import AMBER, GREEN, RED, UNKNOWN, rate_cacerts
+
+
+def test_rate_cacerts_by_expired_and_distrusted_roots():
+    # An old newest root is no finding, certificate authorities rarely issue new ones
+    assert rate_cacerts({"certificates": 90, "expired": 0, "distrusted": 0, "newest_certificate": "2015-01-01"}) == GREEN
+    assert rate_cacerts({"certificates": 90, "expired": 2, "distrusted": 0}) == AMBER
+    assert rate_cacerts({"certificates": 90, "expired": 2, "distrusted": 3}) == RED
+    # Scanners before distrusted was reported
+    assert rate_cacerts({"certificates": 90, "expired": 0}) == GREEN
+    assert rate_cacerts(None) == UNKNOWN
+    assert rate_cacerts({}) == UNKNOWN
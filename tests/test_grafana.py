@@ -0,0 +1,163 @@
+"""Tests of the flat inventory tables served for Grafana."""
+
+import asyncio
+import json
+import os
+from datetime import datetime, timedelta, timezone
+
+import pytest
+from fastapi import HTTPException
+from sqlalchemy.ext.asyncio import async_sessionmaker, create_async_engine
+
+# Importing the routes creates the database engine, keep it off the default database
+os.environ.setdefault("DATABASE_URL", "sqlite+aiosqlite://")
+
+from jfind_svc.db_model import Base  # noqa: E402
+from jfind_svc.jfind_db import get_inventory, get_inventory_summary, get_scan_history, save_scanner_results  # noqa: E402
+from jfind_svc.model import ScannerResults  # noqa: E402
+from jfind_svc.routes.jfind import get_grafana_runtimes, get_grafana_summary  # noqa: E402
+
+TODAY = datetime.now(timezone.utc).date()
+YESTERDAY = TODAY - timedelta(days=1)
+
+ORACLE_8 = {
+    "java_executable": "/opt/jdk1.8.0_441/bin/java",
+    "java_vendor": "Oracle Corporation",
+    "is_oracle": True,
+    "java_version": "1.8.0_441",
+}
+TEMURIN_21 = {"java_executable": "/usr/lib/jvm/java-21/bin/java", "java_vendor": "Eclipse Adoptium", "java_version": "21.0.5"}
+TEMURIN_17 = {"java_executable": "/usr/lib/jvm/java-17/bin/java", "java_vendor": "Eclipse Adoptium", "java_version": "17.0.13"}
+TEMURIN_11 = {"java_executable": "/usr/lib/jvm/java-11/bin/java", "java_vendor": "Eclipse Adoptium", "java_version": "11.0.25"}
+
+
+def report(computer_name: str, scan_ts: str, runtimes: list[dict], labels: dict[str, str] | None = None) -> ScannerResults:
+    return ScannerResults(
+        meta={
+            "scan_ts": scan_ts,
+            "computer_name": computer_name,
+            "labels": labels,
+            "user_name": "root",
+            "scan_duration": "PT1S",
+            "has_oracle_jdk": any(runtime.get("is_oracle") for runtime in runtimes),
+            "count_result": len(runtimes),
+            "scanned_dirs": 1,
+        },
+        result=runtimes,
+    )
+
+
+REPORTS = [
+    # app-01 reports three times, the last report of each day counts
+    report("app-01", f"{YESTERDAY}T12:00:00+00:00", [ORACLE_8], {"env": "prod"}),
+    report("app-01", f"{TODAY}T00:00:01+00:00", [TEMURIN_21], {"env": "prod"}),
+    report("app-01", f"{TODAY}T00:00:02+00:00", [ORACLE_8, TEMURIN_21], {"env": "prod"}),
+    report("db-02", f"{TODAY}T00:00:03+00:00", [TEMURIN_17], {"env": "test"}),
+    # Older than the history, still the latest scan of old-03
+    report("old-03", f"{TODAY - timedelta(days=40)}T12:00:00+00:00", [TEMURIN_11]),
+]
+
+
+def query(*calls):
+    """Save the reports to a fresh database and run the queries on it."""
+
+    async def run():
+        engine = create_async_engine("sqlite+aiosqlite://")
+        async with engine.begin() as conn:
+            await conn.run_sync(Base.metadata.create_all)
+        async with async_sessionmaker(engine, expire_on_commit=False)() as session:
+            for results in REPORTS:
+                await save_scanner_results(session, results)
+            answers = [await call(session) for call in calls]
+        await engine.dispose()
+        return answers
+
+    return asyncio.run(run())
+
+
+def test_inventory_rows_of_latest_scans():
+    rows, prod = query(
+        lambda session: get_inventory(session),
+        lambda session: get_inventory(session, {"env": "prod"}),
+    )
+    assert [(row["computer_name"], row["java_executable"]) for row in rows] == [
+        ("app-01", ORACLE_8["java_executable"]),
+        ("app-01", TEMURIN_21["java_executable"]),
+        ("db-02", TEMURIN_17["java_executable"]),
+        ("old-03", TEMURIN_11["java_executable"]),
+    ]
+    assert set(rows[0]) == {
+        "computer_name",
+        "scan_ts",
+        "java_executable",
+        "java_vendor",
+        "java_version",
+        "java_version_major",
+        "is_oracle",
+        "packaged",
+        "patch_lag_quarters",
+        "cpu_behind",
+        "rating",
+        "label_env",
+    }
+    assert rows[0]["scan_ts"] == f"{TODAY}T00:00:02Z"
+    assert (rows[0]["label_env"], rows[2]["label_env"]) == ("prod", "test")
+    # Computers without labels have no label columns
+    assert not [column for column in rows[3] if column.startswith("label_")]
+    assert [row["java_executable"] for row in prod] == [ORACLE_8["java_executable"], TEMURIN_21["java_executable"]]
+
+
+def test_inventory_summary_by_column_and_label():
+    by_vendor, by_oracle, by_label, test_only = query(
+        lambda session: get_inventory_summary(session, "java_vendor"),
+        lambda session: get_inventory_summary(session, "is_oracle"),
+        lambda session: get_inventory_summary(session, "label_env"),
+        lambda session: get_inventory_summary(session, "java_vendor", {"env": "test"}),
+    )
+    assert by_vendor == [
+        {"group": "Eclipse Adoptium", "runtimes": 3, "computers": 3, "oracle_runtimes": 0},
+        {"group": "Oracle Corporation", "runtimes": 1, "computers": 1, "oracle_runtimes": 1},
+    ]
+    # Runtimes without is_oracle are grouped under None, like computers without the label
+    assert [group["group"] for group in by_oracle] == [None, True]
+    # Computers without the label are counted under None
+    assert by_label == [
+        {"group": "prod", "runtimes": 2, "computers": 1, "oracle_runtimes": 1},
+        {"group": None, "runtimes": 1, "computers": 1, "oracle_runtimes": 0},
+        {"group": "test", "runtimes": 1, "computers": 1, "oracle_runtimes": 0},
+    ]
+    assert test_only == [{"group": "Eclipse Adoptium", "runtimes": 1, "computers": 1, "oracle_runtimes": 0}]
+
+
+def test_scan_history_per_day():
+    month, today, test_only = query(
+        lambda session: get_scan_history(session, 30),
+        lambda session: get_scan_history(session, 1),
+        lambda session: get_scan_history(session, 30, {"env": "test"}),
+    )
+    assert month == [
+        {"time": f"{YESTERDAY}T00:00:00Z", "computers": 1, "runtimes": 1, "oracle_computers": 1},
+        {"time": f"{TODAY}T00:00:00Z", "computers": 2, "runtimes": 3, "oracle_computers": 1},
+    ]
+    assert today == month[1:]
+    assert test_only == [{"time": f"{TODAY}T00:00:00Z", "computers": 1, "runtimes": 1, "oracle_computers": 0}]
+
+
+def test_grafana_routes():
+    runtimes, summary = query(
+        lambda session: get_grafana_runtimes(labels={"env": "test"}, session=session),
+        lambda session: get_grafana_summary(by="label_env", labels={}, session=session),
+    )
+    assert [row["computer_name"] for row in json.loads(runtimes.body)] == ["db-02"]
+    assert json.loads(summary.body) == [
+        {"group": "prod", "runtimes": 2, "computers": 1, "oracle_runtimes": 1},
+        {"group": None, "runtimes": 1, "computers": 1, "oracle_runtimes": 0},
+        {"group": "test", "runtimes": 1, "computers": 1, "oracle_runtimes": 0},
+    ]
+
+
+@pytest.mark.parametrize("by", ["java_executable", "label_", "scan_ts"])
+def test_grafana_summary_refuses_unknown_column(by):
+    with pytest.raises(HTTPException) as e:
+        asyncio.run(get_grafana_summary(by=by, labels={}, session=None))
+    assert e.value.status_code == 422
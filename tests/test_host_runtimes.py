@@ -0,0 +1,107 @@
+"""Tests of the state of the runtimes of a computer across reports."""
+
+import asyncio
+from datetime import datetime
+
+from sqlalchemy import select
+from sqlalchemy.ext.asyncio import async_sessionmaker, create_async_engine
+
+from jfind_svc.db_model import Base, HostRuntime
+from jfind_svc.jfind_db import update_host_runtimes
+from jfind_svc.model import ScannerResults
+from jfind_svc.paths import within_roots
+
+INSTALLED = ["/opt/jdk-17/bin/java", "/usr/lib/jvm/java-21/bin/java", "/home/ci/.sdkman/java/bin/java"]
+
+
+def report(scan_ts: str, paths: list[str], **meta) -> ScannerResults:
+    """Build a report of the test computer with the runtimes at the paths."""
+    return ScannerResults(
+        meta={
+            "scan_ts": scan_ts,
+            "computer_name": "build-07",
+            "user_name": "root",
+            "scan_duration": "PT1S",
+            "has_oracle_jdk": False,
+            "count_result": len(paths),
+            "scanned_dirs": 1,
+            **meta,
+        },
+        result=[{"java_executable": path} for path in paths],
+    )
+
+
+def removed_after(*reports: ScannerResults) -> list[str]:
+    """Apply the reports in turn and return the runtimes marked removed."""
+
+    async def run() -> list[str]:
+        engine = create_async_engine("sqlite+aiosqlite://")
+        async with engine.begin() as conn:
+            await conn.run_sync(Base.metadata.create_all)
+        async with async_sessionmaker(engine, expire_on_commit=False)() as session:
+            for r in reports:
+                await update_host_runtimes(session, r, datetime.fromisoformat(r.meta.scan_ts))
+                await session.commit()
+            rows = (await session.execute(select(HostRuntime))).scalars().all()
+        await engine.dispose()
+        return sorted(row.java_executable for row in rows if row.removed_at is not None)
+
+    return asyncio.run(run())
+
+
+FIRST = report("2025-03-01T02:00:00+00:00", INSTALLED, scan_roots=["/"], complete=True)
+
+
+def test_complete_scan_removes_missing_runtimes_below_its_roots():
+    second = report("2025-03-02T02:00:00+00:00", [], scan_roots=["/opt", "/usr/lib/jvm"], complete=True)
+    assert removed_after(FIRST, second) == ["/opt/jdk-17/bin/java", "/usr/lib/jvm/java-21/bin/java"]
+
+
+def test_incomplete_scans_remove_nothing():
+    for meta in (
+        {"scan_roots": ["/"], "complete": False},  # e.g. -max-results, -timeout or -exclude
+        {"scan_roots": ["/"]},  # scanner without complete
+        {"complete": True},  # scanner without scan_roots
+        {"scan_roots": ["/"], "complete": True, "shard": "1/4"},
+        {"scan_roots": ["/"], "complete": True, "report_phase": "preliminary"},
+    ):
+        assert removed_after(FIRST, report("2025-03-02T02:00:00+00:00", [], **meta)) == [], meta
+
+
+def test_reported_runtime_is_restored():
+    second = report("2025-03-02T02:00:00+00:00", INSTALLED[1:], scan_roots=["/"], complete=True)
+    third = report("2025-03-03T02:00:00+00:00", INSTALLED, scan_roots=["/"], complete=True)
+    assert removed_after(FIRST, second) == ["/opt/jdk-17/bin/java"]
+    assert removed_after(FIRST, second, third) == []
+
+
+def test_within_roots():
+    assert within_roots("/opt/jdk-17/bin/java", ["/opt"])
+    assert within_roots("/opt/jdk-17/bin/java", ["/opt/"])
+    assert within_roots("/opt/jdk-17/bin/java", ["/"])
+    assert not within_roots("/optional/jdk/bin/java", ["/opt"])
+    assert within_roots(r"C:\Program Files\Java\jdk-21\bin\java.exe", ["c:/program files"])
+    assert within_roots(r"D:\tools\jdk\bin\java.exe", ["D:\\"])
+    assert not within_roots("/opt/jdk-17/bin/java", [])
+
+
+def test_quarantined_runtime_stays_present():
+    async def run() -> list[tuple[str, bool | None, datetime | None]]:
+        engine = create_async_engine("sqlite+aiosqlite://")
+        async with engine.begin() as conn:
+            await conn.run_sync(Base.metadata.create_all)
+        second = report("2025-03-02T02:00:00+00:00", INSTALLED, scan_roots=["/"], complete=True)
+        second.result[0].quarantined = True
+        async with async_sessionmaker(engine, expire_on_commit=False)() as session:
+            for r in (FIRST, second):
+                await update_host_runtimes(session, r, datetime.fromisoformat(r.meta.scan_ts))
+                await session.commit()
+            rows = (await session.execute(select(HostRuntime))).scalars().all()
+        await engine.dispose()
+        return sorted((row.java_executable, row.quarantined, row.removed_at) for row in rows)
+
+    assert asyncio.run(run()) == [
+        ("/home/ci/.sdkman/java/bin/java", False, None),
+        ("/opt/jdk-17/bin/java", True, None),
+        ("/usr/lib/jvm/java-21/bin/java", False, None),
+    ]
@@ -0,0 +1,59 @@
+"""Tests of the schema upgrade of databases created by older versions of the collector."""
+
+import asyncio
+
+from sqlalchemy import inspect, text
+from sqlalchemy.ext.asyncio import create_async_engine
+
+from jfind_svc.db_model import Base
+from jfind_svc.migrations import upgrade_schema
+
+# The tables as created by the first version of the collector
+OLD_SCHEMA = [
+    """CREATE TABLE scan_info (
+        id INTEGER PRIMARY KEY, scan_ts DATETIME NOT NULL, computer_name VARCHAR(255) NOT NULL,
+        user_name VARCHAR(255) NOT NULL, scan_duration VARCHAR(50) NOT NULL, has_oracle_jdk BOOLEAN NOT NULL,
+        count_result INTEGER NOT NULL, scanned_dirs INTEGER NOT NULL, created_at DATETIME NOT NULL)""",
+    """CREATE TABLE java_info (
+        id INTEGER PRIMARY KEY, scan_id INTEGER NOT NULL REFERENCES scan_info (id),
+        computer_name VARCHAR(255) NOT NULL, java_executable VARCHAR(1024) NOT NULL, java_runtime VARCHAR(255),
+        java_vendor VARCHAR(255), is_oracle BOOLEAN, java_version VARCHAR(50), java_version_major INTEGER,
+        java_version_update INTEGER, require_license BOOLEAN, created_at DATETIME NOT NULL)""",
+    """INSERT INTO scan_info VALUES (1, '2025-03-01 02:00:00', 'build-07', 'root', 'PT1S', 0, 0, 1, '2025-03-01 02:00:00')""",
+]
+
+
+def upgrade(statements: list[str]) -> tuple[list[str], dict[str, set[str]], int]:
+    """Create the tables with the statements, upgrade them and return the added and existing columns."""
+
+    async def run():
+        engine = create_async_engine("sqlite+aiosqlite://")
+        async with engine.begin() as conn:
+            for statement in statements:
+                await conn.execute(text(statement))
+            await conn.run_sync(Base.metadata.create_all)
+            added = await conn.run_sync(upgrade_schema)
+            columns = await conn.run_sync(
+                lambda sync: {table: {c["name"] for c in inspect(sync).get_columns(table)} for table in Base.metadata.tables}
+            )
+            scans = (await conn.execute(text("SELECT count(*) FROM scan_info"))).scalar()
+        await engine.dispose()
+        return added, columns, scans
+
+    return asyncio.run(run())
+
+
+def test_upgrade_adds_new_columns_to_old_tables():
+    added, columns, scans = upgrade(OLD_SCHEMA)
+    assert "scan_info.scan_uuid" in added
+    assert "scan_info.report_phase" in added
+    assert "java_info.cpu_release" in added
+    assert "java_info.enrichments" in added
+    for table in Base.metadata.sorted_tables:
+        assert columns[table.name] == {column.name for column in table.columns}, table.name
+    assert scans == 1
+
+
+def test_upgrade_of_current_schema_changes_nothing():
+    added, _, _ = upgrade([])
+    assert added == []
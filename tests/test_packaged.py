@@ -0,0 +1,72 @@
+"""Tests of runtimes packaged in archives, which are reported but not installed."""
+
+import asyncio
+
+from sqlalchemy.ext.asyncio import async_sessionmaker, create_async_engine
+
+from jfind_svc.db_model import Base
+from jfind_svc.jfind_db import (
+    get_compliance,
+    get_inventory_summary,
+    get_oracle_jdks,
+    get_present_oracle_runtimes,
+    has_oracle_jdk,
+    save_scanner_results,
+)
+from jfind_svc.model import ScannerResults
+
+ARCHIVE = "/srv/artifacts/jdk-8u441-linux-x64.tar.gz"
+
+REPORT = ScannerResults(
+    meta={
+        "scan_ts": "2025-03-01T02:00:00+00:00",
+        "computer_name": "build-07",
+        "user_name": "root",
+        "scan_duration": "PT1S",
+        "has_oracle_jdk": False,
+        "count_result": 2,
+        "scanned_dirs": 1,
+    },
+    result=[
+        {"java_executable": "/usr/lib/jvm/java-21/bin/java", "java_vendor": "Eclipse Adoptium", "java_version": "21.0.5"},
+        {
+            "java_executable": ARCHIVE + "!/jdk1.8.0_441/bin/java",
+            "java_vendor": "Oracle Corporation",
+            "is_oracle": True,
+            "java_version": "1.8.0_441",
+            "packaged": True,
+            "archive": ARCHIVE,
+        },
+    ],
+)
+
+
+def test_packaged_runtimes_are_not_installed():
+    async def run():
+        engine = create_async_engine("sqlite+aiosqlite://")
+        async with engine.begin() as conn:
+            await conn.run_sync(Base.metadata.create_all)
+        async with async_sessionmaker(engine, expire_on_commit=False)() as session:
+            scan = await save_scanner_results(session, REPORT)
+            stored = {java.java_executable: (java.packaged, java.archive) for java in scan.java_runtimes}
+            views = (
+                await has_oracle_jdk(session, "build-07"),
+                await get_oracle_jdks(session),
+                await get_present_oracle_runtimes(session, "build-07"),
+                [runtime["java_executable"] for host in await get_compliance(session) for runtime in host["runtimes"]],
+                await get_inventory_summary(session, "packaged"),
+            )
+        await engine.dispose()
+        return stored, views
+
+    stored, (has_oracle, oracle_jdks, present_oracle, rated, summary) = asyncio.run(run())
+    assert stored[ARCHIVE + "!/jdk1.8.0_441/bin/java"] == (True, ARCHIVE)
+    assert stored["/usr/lib/jvm/java-21/bin/java"] == (None, None)
+    assert has_oracle is False
+    assert oracle_jdks == []
+    assert present_oracle == []
+    assert rated == ["/usr/lib/jvm/java-21/bin/java"]
+    assert sorted((group["group"], group["runtimes"], group["oracle_runtimes"]) for group in summary) == [
+        (False, 1, 0),
+        (True, 1, 0),
+    ]
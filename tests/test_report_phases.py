@@ -0,0 +1,66 @@
+"""Tests of the preliminary and final reports of two-phase scans."""
+
+import asyncio
+from datetime import datetime, timedelta, timezone
+
+from sqlalchemy import select
+from sqlalchemy.ext.asyncio import async_sessionmaker, create_async_engine
+
+from jfind_svc.db_model import Base, ScanInfo
+from jfind_svc.jfind_db import is_outdated_preliminary, save_scanner_results
+from jfind_svc.model import ScannerResults
+
+SCAN_TS = datetime(2025, 3, 1, 2, 0, tzinfo=timezone.utc)
+
+
+def report(phase: str, paths: list[str]) -> ScannerResults:
+    """Build a report of one phase of the test scan with the runtimes at the paths."""
+    return ScannerResults(
+        meta={
+            "scan_id": "8a1c0d9e-0000-4000-8000-000000000001",
+            "report_phase": phase,
+            "scan_ts": SCAN_TS.isoformat(),
+            "computer_name": "build-07",
+            "user_name": "root",
+            "scan_duration": "PT1S",
+            "has_oracle_jdk": False,
+            "count_result": len(paths),
+            "scanned_dirs": 1,
+        },
+        result=[{"java_executable": path} for path in paths],
+    )
+
+
+def saved_after(*reports: ScannerResults, now: datetime = SCAN_TS + timedelta(minutes=5)) -> list[tuple[str, int]]:
+    """Save the reports in turn, unless outdated, and return the phase and count of the saved scans."""
+
+    async def run() -> list[tuple[str, int]]:
+        engine = create_async_engine("sqlite+aiosqlite://")
+        async with engine.begin() as conn:
+            await conn.run_sync(Base.metadata.create_all)
+        async with async_sessionmaker(engine, expire_on_commit=False)() as session:
+            for r in reports:
+                if not await is_outdated_preliminary(session, r, now):
+                    await save_scanner_results(session, r)
+            rows = (await session.execute(select(ScanInfo))).scalars().all()
+        await engine.dispose()
+        return sorted((row.report_phase, row.count_result) for row in rows)
+
+    return asyncio.run(run())
+
+
+PRELIMINARY = report("preliminary", ["/usr/lib/jvm/java-17/bin/java"])
+FINAL = report("final", ["/usr/lib/jvm/java-17/bin/java", "/opt/app/jre/bin/java"])
+
+
+def test_final_report_replaces_preliminary():
+    assert saved_after(PRELIMINARY, FINAL) == [("final", 2)]
+
+
+def test_late_preliminary_report_keeps_final():
+    assert saved_after(FINAL, PRELIMINARY) == [("final", 2)]
+
+
+def test_preliminary_report_is_time_boxed():
+    assert saved_after(PRELIMINARY, now=SCAN_TS + timedelta(hours=7)) == []
+    assert saved_after(PRELIMINARY, now=SCAN_TS + timedelta(hours=1)) == [("preliminary", 1)]
@@ -0,0 +1,84 @@
+"""Tests of the delivery of webhook events."""
+
+import asyncio
+import hashlib
+import hmac
+import urllib.error
+
+from jfind_svc import webhooks
+from jfind_svc.webhooks import EVENT_HOST_NEW, build_event, deliver, emit_events
+
+
+def fake_post(monkeypatch, responses: list[int]) -> list:
+    """Answer webhook requests with the HTTP status codes in turn and return the requests sent."""
+    sent = []
+
+    def post(request):
+        sent.append(request)
+        code = responses[len(sent) - 1]
+        if code != 200:
+            raise urllib.error.HTTPError(request.full_url, code, "error", None, None)
+
+    monkeypatch.setattr(webhooks, "post", post)
+    return sent
+
+
+def fake_sleep(monkeypatch) -> list:
+    """Skip the backoff between attempts and return the delays waited for."""
+    delays = []
+
+    async def sleep(delay):
+        delays.append(delay)
+
+    monkeypatch.setattr(webhooks.asyncio, "sleep", sleep)
+    return delays
+
+
+def test_events_are_signed(monkeypatch):
+    sent = fake_post(monkeypatch, [200])
+    assert asyncio.run(deliver("https://soar.example.com/hooks", build_event(EVENT_HOST_NEW, {}), "s3cret"))
+    headers = {key.lower(): value for key, value in sent[0].header_items()}
+    message = headers["x-jfind-timestamp"].encode() + b"." + sent[0].data
+    expected = "sha256=" + hmac.new(b"s3cret", message, hashlib.sha256).hexdigest()
+    assert headers["x-jfind-signature"] == expected
+
+
+def test_no_events_without_secret(monkeypatch):
+    monkeypatch.setenv("JFIND_WEBHOOK_URLS", "https://soar.example.com/hooks")
+    monkeypatch.delenv("JFIND_WEBHOOK_SECRET", raising=False)
+    sent = fake_post(monkeypatch, [])
+    asyncio.run(emit_events([build_event(EVENT_HOST_NEW, {})]))
+    assert sent == []
+
+
+def test_server_errors_are_retried_with_backoff(monkeypatch):
+    sent = fake_post(monkeypatch, [503, 429, 200])
+    delays = fake_sleep(monkeypatch)
+    assert asyncio.run(deliver("https://soar.example.com/hooks", build_event(EVENT_HOST_NEW, {}), "s3cret"))
+    assert len(sent) == 3
+    assert delays == [1, 2]
+
+
+def test_client_errors_are_not_retried(monkeypatch):
+    sent = fake_post(monkeypatch, [404])
+    delays = fake_sleep(monkeypatch)
+    assert not asyncio.run(deliver("https://soar.example.com/hooks", build_event(EVENT_HOST_NEW, {}), "s3cret"))
+    assert len(sent) == 1
+    assert delays == []
+
+
+def test_failing_receiver_does_not_hold_up_others(monkeypatch):
+    monkeypatch.setenv("JFIND_WEBHOOK_URLS", "https://down.example.com/hooks,https://up.example.com/hooks")
+    monkeypatch.setenv("JFIND_WEBHOOK_SECRET", "s3cret")
+    delivered = []
+
+    def post(request):
+        if request.full_url.startswith("https://down."):
+            raise urllib.error.URLError("connection refused")
+        delivered.append(request.full_url)
+
+    monkeypatch.setattr(webhooks, "post", post)
+    delays = fake_sleep(monkeypatch)
+    asyncio.run(emit_events([build_event(EVENT_HOST_NEW, {})]))
+    assert delivered == ["https://up.example.com/hooks"]
+    assert delays == [1, 2, 4, 8]